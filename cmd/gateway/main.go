@@ -30,10 +30,13 @@ import (
 
 	"github.com/canonica-labs/canonica/internal/adapters"
 	duckdb "github.com/canonica-labs/canonica/internal/adapters/duckdb"
+	"github.com/canonica-labs/canonica/internal/adapters/loader"
 	"github.com/canonica-labs/canonica/internal/adapters/spark"
 	"github.com/canonica-labs/canonica/internal/adapters/trino"
 	"github.com/canonica-labs/canonica/internal/auth"
+	"github.com/canonica-labs/canonica/internal/compression"
 	"github.com/canonica-labs/canonica/internal/gateway"
+	"github.com/canonica-labs/canonica/internal/observability"
 	"github.com/canonica-labs/canonica/internal/router"
 	"github.com/canonica-labs/canonica/internal/storage"
 
@@ -59,8 +62,11 @@ func run() error {
 		addr      = flag.String("addr", ":8080", "HTTP listen address")
 		token     = flag.String("token", "", "Static auth token (required)")
 		dbURL     = flag.String("db", "", "PostgreSQL connection URL (required in production)")
+		replicaDB = flag.String("db-replica", "", "PostgreSQL read replica URL for metadata reads (optional)")
+		replicaLg = flag.Duration("db-replica-lag", storage.DefaultReplicaLag, "How long recently written metadata is read from the primary")
 		trinoHost = flag.String("trino-host", "", "Trino server host (optional)")
 		trinoPort = flag.Int("trino-port", 8080, "Trino server port")
+		trinoImp  = flag.Bool("trino-impersonate", false, "Run Trino queries as the authenticated user (X-Trino-User)")
 		sparkHost = flag.String("spark-host", "", "Spark Thrift Server host (optional)")
 		sparkPort = flag.Int("spark-port", 10000, "Spark Thrift Server port")
 		showHelp  = flag.Bool("help", false, "Show help message")
@@ -69,6 +75,10 @@ func run() error {
 	)
 	flag.Parse()
 
+	// Warnings from the planner, router, adapters and audit logging go to
+	// the process log
+	observability.SetDiagnosticLogger(log.Default())
+
 	if *showHelp {
 		flag.Usage()
 		return nil
@@ -92,6 +102,9 @@ func run() error {
 	if *dbURL == "" {
 		*dbURL = os.Getenv("CANONIC_DATABASE_URL")
 	}
+	if *replicaDB == "" {
+		*replicaDB = os.Getenv("CANONIC_DATABASE_REPLICA_URL")
+	}
 
 	// Per execution-checklist.md 4.1: Gateway startup fails if PostgreSQL is unavailable
 	// Unless in dev mode
@@ -110,6 +123,7 @@ func run() error {
 	// Create repository
 	// Per execution-checklist.md 4.1: Repository is mandatory
 	var repo storage.TableRepository
+	var engineRepo storage.EngineRepository
 	if *dbURL != "" {
 		// Connect to PostgreSQL
 		db, err := sql.Open("postgres", *dbURL)
@@ -133,8 +147,32 @@ func run() error {
 		}
 		log.Println("Database migrations completed")
 
-		repo = storage.NewPostgresRepository(db)
+		postgresRepo := storage.NewPostgresRepository(db)
+		postgresEngineRepo := storage.NewPostgresEngineRepository(db)
 		log.Println("Connected to PostgreSQL")
+
+		// Serve metadata reads from the read replica when configured
+		if *replicaDB != "" {
+			replicaConn, err := sql.Open("postgres", *replicaDB)
+			if err != nil {
+				return fmt.Errorf("failed to connect to PostgreSQL replica: %w", err)
+			}
+			defer replicaConn.Close()
+			if err := replicaConn.PingContext(ctx); err != nil {
+				return fmt.Errorf("PostgreSQL replica connectivity check failed: %w", err)
+			}
+
+			readReplica, err := storage.NewReadReplica(db, replicaConn, *replicaLg)
+			if err != nil {
+				return err
+			}
+			postgresRepo.SetReadReplica(readReplica)
+			postgresEngineRepo.SetReadReplica(readReplica)
+			log.Printf("Serving metadata reads from PostgreSQL replica (lag window %s)", readReplica.Lag())
+		}
+
+		repo = postgresRepo
+		engineRepo = postgresEngineRepo
 	} else {
 		// Development mode: use mock repository
 		log.Println("WARNING: Development mode - using in-memory repository (not for production)")
@@ -160,8 +198,9 @@ func run() error {
 			host = os.Getenv("CANONIC_TRINO_HOST")
 		}
 		trinoAdapter := trino.NewAdapter(trino.AdapterConfig{
-			Host: host,
-			Port: *trinoPort,
+			Host:        host,
+			Port:        *trinoPort,
+			Impersonate: *trinoImp,
 		})
 		adapterRegistry.Register(trinoAdapter)
 		log.Printf("Registered Trino adapter at %s:%d", host, *trinoPort)
@@ -181,6 +220,50 @@ func run() error {
 		log.Printf("Registered Spark adapter at %s:%d", host, *sparkPort)
 	}
 
+	// Register engines defined in the repository; SIGHUP reloads them
+	if engineRepo != nil {
+		engineLoader := loader.NewLoader(engineRepo, adapterRegistry, nil)
+		if err := refreshEngines(engineLoader); err != nil {
+			return err
+		}
+
+		go func() {
+			hupCh := make(chan os.Signal, 1)
+			signal.Notify(hupCh, syscall.SIGHUP)
+			for range hupCh {
+				if err := refreshEngines(engineLoader); err != nil {
+					log.Printf("Engine refresh failed: %v", err)
+				}
+			}
+		}()
+	}
+
+	// Every engine a table is assigned to needs an adapter; in development
+	// mode a missing adapter is only a warning
+	listCtx, cancelList := context.WithTimeout(context.Background(), 10*time.Second)
+	registered, err := repo.List(listCtx)
+	cancelList()
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	if err := adapterRegistry.CheckTableAdapters(registered); err != nil {
+		if !*devMode {
+			return err
+		}
+		log.Printf("WARNING: %v", err)
+	}
+
+	// Report engine versions; SQL rewriting picks syntax variants by version
+	probeCtx, cancelProbe := context.WithTimeout(context.Background(), 10*time.Second)
+	engineVersions, probeFailures := adapterRegistry.ProbeVersions(probeCtx)
+	cancelProbe()
+	for name, engineVersion := range engineVersions {
+		log.Printf("Engine %s reports version %s", name, engineVersion)
+	}
+	for name, err := range probeFailures {
+		log.Printf("WARNING: could not determine %s version: %v", name, err)
+	}
+
 	// Create gateway
 	// Per execution-checklist.md: NewGateway validates repository and adapter registry
 	gw, err := gateway.NewGateway(
@@ -197,10 +280,18 @@ func run() error {
 		return fmt.Errorf("failed to create gateway: %w", err)
 	}
 
+	// Serve Prometheus metrics next to the gateway's routes
+	metrics := observability.NewPrometheusMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.Handle("/", gw)
+
 	// Create HTTP server
+	// Responses of at least compression.DefaultMinSize bytes are gzipped for
+	// clients that accept it
 	server := &http.Server{
 		Addr:         *addr,
-		Handler:      gw,
+		Handler:      compression.NewGzipHandler(mux, compression.DefaultMinSize),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -228,6 +319,7 @@ func run() error {
 	log.Printf("Version: %s, Commit: %s", version, commit)
 	log.Printf("Health check: http://localhost%s/health", *addr)
 	log.Printf("Readiness: http://localhost%s/readyz", *addr)
+	log.Printf("Metrics: http://localhost%s/metrics", *addr)
 
 	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		return fmt.Errorf("server error: %w", err)
@@ -237,3 +329,28 @@ func run() error {
 	log.Println("Gateway stopped")
 	return nil
 }
+
+// refreshEngines loads the stored engine definitions into the adapter registry.
+// Engines that fail to build are logged and skipped.
+func refreshEngines(engineLoader *loader.Loader) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := engineLoader.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load engines: %w", err)
+	}
+	for _, name := range result.Added {
+		log.Printf("Registered %s adapter from repository", name)
+	}
+	for _, name := range result.Updated {
+		log.Printf("Reloaded %s adapter from repository", name)
+	}
+	for _, name := range result.Removed {
+		log.Printf("Removed %s adapter", name)
+	}
+	for name, err := range result.Errors {
+		log.Printf("WARNING: engine %s not registered: %v", name, err)
+	}
+	return nil
+}
@@ -8,9 +8,15 @@ package adapters
 
 import (
 	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
 	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/tables"
 )
 
 // QueryResult represents the result of a query execution.
@@ -18,6 +24,10 @@ type QueryResult struct {
 	// Columns are the column names in the result.
 	Columns []string
 
+	// ColumnTypes are the engine type names of the columns (e.g. BIGINT,
+	// TIMESTAMP), aligned with Columns. Nil when the driver does not report them.
+	ColumnTypes []string
+
 	// Rows are the result rows, each row is a slice of values.
 	Rows [][]interface{}
 
@@ -28,6 +38,20 @@ type QueryResult struct {
 	Metadata map[string]string
 }
 
+// ColumnTypeNames returns the database type names of the result columns,
+// or nil if the driver does not report them.
+func ColumnTypeNames(rows *sql.Rows) []string {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		names[i] = ct.DatabaseTypeName()
+	}
+	return names
+}
+
 // EngineAdapter is the interface all engine adapters must implement.
 // Adapters must be:
 // - Stateless: Each operation is independent
@@ -57,30 +81,58 @@ type EngineAdapter interface {
 }
 
 // AdapterRegistry manages engine adapters.
+// It is safe for concurrent use, so adapters can be registered and removed
+// while queries are running.
 type AdapterRegistry struct {
+	mu       sync.RWMutex
 	adapters map[string]EngineAdapter
+
+	healthMu sync.Mutex
+	health   map[string]*healthTracker
 }
 
 // NewAdapterRegistry creates a new adapter registry.
 func NewAdapterRegistry() *AdapterRegistry {
 	return &AdapterRegistry{
 		adapters: make(map[string]EngineAdapter),
+		health:   make(map[string]*healthTracker),
 	}
 }
 
 // Register adds an adapter to the registry.
 func (r *AdapterRegistry) Register(adapter EngineAdapter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.adapters[adapter.Name()] = adapter
 }
 
+// Unregister removes an adapter from the registry and returns it, or nil if
+// no adapter is registered under name. The caller is responsible for
+// closing the returned adapter.
+func (r *AdapterRegistry) Unregister(name string) EngineAdapter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	adapter := r.adapters[name]
+	delete(r.adapters, name)
+
+	r.healthMu.Lock()
+	delete(r.health, name)
+	r.healthMu.Unlock()
+	return adapter
+}
+
 // Get returns an adapter by name.
 func (r *AdapterRegistry) Get(name string) (EngineAdapter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	adapter, ok := r.adapters[name]
 	return adapter, ok
 }
 
 // Available returns the names of all registered adapters.
 func (r *AdapterRegistry) Available() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	names := make([]string, 0, len(r.adapters))
 	for name := range r.adapters {
 		names = append(names, name)
@@ -90,6 +142,8 @@ func (r *AdapterRegistry) Available() []string {
 
 // CloseAll closes all registered adapters.
 func (r *AdapterRegistry) CloseAll() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	var lastErr error
 	for _, adapter := range r.adapters {
 		if err := adapter.Close(); err != nil {
@@ -101,16 +155,65 @@ func (r *AdapterRegistry) CloseAll() error {
 
 // CheckAllHealth checks the health of all registered adapters.
 // Per phase-6-spec.md: Returns a map of adapter name to health status.
-// A nil error value indicates the adapter is healthy.
+// A nil error value indicates the adapter is healthy. Results are recorded
+// in the adapters' health snapshots.
 func (r *AdapterRegistry) CheckAllHealth(ctx context.Context) map[string]error {
-	results := make(map[string]error)
+	r.mu.RLock()
+	adapters := make(map[string]EngineAdapter, len(r.adapters))
 	for name, adapter := range r.adapters {
+		adapters[name] = adapter
+	}
+	r.mu.RUnlock()
+
+	results := make(map[string]error)
+	for name, adapter := range adapters {
+		start := time.Now()
 		results[name] = adapter.CheckHealth(ctx)
+		r.recordHealthCheck(name, start, time.Since(start), results[name])
 	}
 	return results
 }
 
+// MissingAdapters returns the engines assigned to table sources that have no
+// registered adapter, each mapped to the names of the tables referencing it
+// in name order. Sources without an engine are routed by format and are not
+// checked.
+func (r *AdapterRegistry) MissingAdapters(vts []*tables.VirtualTable) map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	missing := make(map[string][]string)
+	for _, vt := range vts {
+		seen := make(map[string]bool)
+		for _, source := range vt.Sources {
+			if source.Engine == "" || seen[source.Engine] {
+				continue
+			}
+			seen[source.Engine] = true
+			if _, ok := r.adapters[source.Engine]; !ok {
+				missing[source.Engine] = append(missing[source.Engine], vt.Name)
+			}
+		}
+	}
+	for _, names := range missing {
+		sort.Strings(names)
+	}
+	return missing
+}
+
+// CheckTableAdapters returns ErrMissingAdapters if any table source is
+// assigned to an engine without a registered adapter. It is run at startup,
+// once adapters are registered.
+func (r *AdapterRegistry) CheckTableAdapters(vts []*tables.VirtualTable) error {
+	if missing := r.MissingAdapters(vts); len(missing) > 0 {
+		return errors.NewMissingAdapters(missing)
+	}
+	return nil
+}
+
 // IsEmpty returns true if no adapters are registered.
 func (r *AdapterRegistry) IsEmpty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return len(r.adapters) == 0
 }
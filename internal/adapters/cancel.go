@@ -0,0 +1,75 @@
+// Package adapters provides the engine adapter interface and utilities.
+//
+// Cancellation: when a gateway query times out or the client disconnects,
+// the engine-side query must be cancelled too, otherwise expensive queries
+// keep running on Trino/Spark after nobody is waiting for them.
+package adapters
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/observability"
+)
+
+// DefaultCancelTimeout bounds the engine-side cancel call.
+// The cancel runs after the caller's context is already done, so it uses its own deadline.
+const DefaultCancelTimeout = 10 * time.Second
+
+// QueryCanceler is implemented by adapters that can cancel a running
+// engine query explicitly.
+type QueryCanceler interface {
+	// Cancel stops the engine-side query identified by queryHandle.
+	// Cancelling a query that already finished is not an error.
+	Cancel(ctx context.Context, queryHandle string) error
+}
+
+// NewQueryHandle returns a random identifier used to tag a query so it can be
+// found and cancelled on the engine later.
+func NewQueryHandle() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand failing is unrecoverable; fall back to a time-based handle.
+		return hex.EncodeToString([]byte(time.Now().Format("150405.000000")))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// CancelOnDone issues canceler.Cancel(queryHandle) if ctx is done before the
+// query finishes, i.e. before or when the returned stop function is called.
+// Callers must call stop once the query has finished:
+//
+//	stop := adapters.CancelOnDone(ctx, a, handle)
+//	defer stop()
+//
+// stop waits for an in-flight cancel to complete.
+func CancelOnDone(ctx context.Context, canceler QueryCanceler, queryHandle string) (stop func()) {
+	finished := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		select {
+		case <-finished:
+			// The query may have returned because ctx was cancelled; the
+			// engine could still be running it, so cancel in that case too.
+			if ctx.Err() == nil {
+				return
+			}
+		case <-ctx.Done():
+		}
+
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultCancelTimeout)
+		defer cancel()
+		if err := canceler.Cancel(cancelCtx, queryHandle); err != nil {
+			observability.Diagnosticf("adapters: engine cancel for query %s failed: %v", queryHandle, err)
+		}
+	}()
+
+	return func() {
+		close(finished)
+		<-exited
+	}
+}
@@ -0,0 +1,136 @@
+package adapters
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataResultChecksum is the QueryResult.Metadata key for the result checksum.
+const MetadataResultChecksum = "result_checksum"
+
+// ResultChecksum is an order-independent hash of result rows.
+// Engines return rows in different orders and with different Go types for the
+// same SQL value (e.g. int32 from DuckDB, int64 from Trino), so each row is
+// normalized, hashed on its own, and the row hashes are summed. Two results
+// with the same multiset of rows have the same checksum regardless of the
+// engine that produced them.
+type ResultChecksum struct {
+	lanes [4]uint64
+	rows  int64
+}
+
+// NewResultChecksum creates an empty checksum.
+func NewResultChecksum() *ResultChecksum {
+	return &ResultChecksum{}
+}
+
+// AddRow adds one row, given as parallel column names and values.
+// Column order does not matter; column names are compared case-insensitively.
+func (c *ResultChecksum) AddRow(columns []string, values []interface{}) {
+	fields := make([]string, 0, len(columns))
+	for i, col := range columns {
+		var v interface{}
+		if i < len(values) {
+			v = values[i]
+		}
+		fields = append(fields, strings.ToLower(col)+"\x1f"+normalizeChecksumValue(v))
+	}
+	sort.Strings(fields)
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1e")))
+	for i := range c.lanes {
+		c.lanes[i] += binary.BigEndian.Uint64(sum[i*8:])
+	}
+	c.rows++
+}
+
+// Rows returns the number of rows added.
+func (c *ResultChecksum) Rows() int64 {
+	return c.rows
+}
+
+// Sum returns the checksum as a hex string.
+func (c *ResultChecksum) Sum() string {
+	buf := make([]byte, 40)
+	for i, lane := range c.lanes {
+		binary.BigEndian.PutUint64(buf[i*8:], lane)
+	}
+	binary.BigEndian.PutUint64(buf[32:], uint64(c.rows))
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// Checksum computes the result checksum of r.
+func (r *QueryResult) Checksum() string {
+	c := NewResultChecksum()
+	for _, row := range r.Rows {
+		c.AddRow(r.Columns, row)
+	}
+	return c.Sum()
+}
+
+// AttachChecksum stores the result checksum in r.Metadata.
+func (r *QueryResult) AttachChecksum() {
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]string)
+	}
+	r.Metadata[MetadataResultChecksum] = r.Checksum()
+}
+
+// normalizeChecksumValue renders a value so that equal SQL values from
+// different drivers hash identically.
+func normalizeChecksumValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "\x00NULL"
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case bool:
+		return strconv.FormatBool(val)
+	case int:
+		return strconv.FormatInt(int64(val), 10)
+	case int8:
+		return strconv.FormatInt(int64(val), 10)
+	case int16:
+		return strconv.FormatInt(int64(val), 10)
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case float32:
+		return normalizeFloat(float64(val), 32)
+	case float64:
+		return normalizeFloat(val, 64)
+	case time.Time:
+		return val.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// normalizeFloat renders integral floats as integers so that 1.0 and 1 match.
+func normalizeFloat(f float64, bitSize int) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
@@ -104,6 +104,7 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	if err != nil {
 		return nil, fmt.Errorf("DuckDB adapter: failed to get columns: %w", err)
 	}
+	columnTypes := adapters.ColumnTypeNames(rows)
 
 	// Read all rows
 	resultRows := make([][]interface{}, 0)
@@ -133,9 +134,10 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	}
 
 	return &adapters.QueryResult{
-		Columns:  columns,
-		Rows:     resultRows,
-		RowCount: len(resultRows),
+		Columns:     columns,
+		ColumnTypes: columnTypes,
+		Rows:        resultRows,
+		RowCount:    len(resultRows),
 		Metadata: map[string]string{
 			"engine": "duckdb",
 		},
@@ -167,6 +169,22 @@ func (a *Adapter) Ping(ctx context.Context) error {
 	return a.db.PingContext(ctx)
 }
 
+// EngineVersion returns the DuckDB library version, e.g. "v1.1.3".
+func (a *Adapter) EngineVersion(ctx context.Context) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed || a.db == nil {
+		return "", fmt.Errorf("DuckDB adapter: connection is closed")
+	}
+
+	var version string
+	if err := a.db.QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("DuckDB adapter: version query failed: %w", err)
+	}
+	return version, nil
+}
+
 // Close releases any resources held by the adapter.
 // Close is idempotent - safe to call multiple times.
 func (a *Adapter) Close() error {
@@ -0,0 +1,103 @@
+package adapters
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"strings"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// engineErrorPatterns maps fragments of engine error messages to categories.
+// Trino reports an error name (e.g. TABLE_NOT_FOUND) in its failure payload;
+// Spark reports an exception class and, since 3.4, an error class in
+// brackets. Patterns are matched case-insensitively in order.
+var engineErrorPatterns = []struct {
+	fragment string
+	category errors.EngineErrorCategory
+}{
+	// Resource limits
+	{"exceeded_", errors.EngineErrorResource}, // EXCEEDED_TIME_LIMIT, EXCEEDED_GLOBAL_MEMORY_LIMIT, ...
+	{"outofmemoryerror", errors.EngineErrorResource},
+	{"out of memory", errors.EngineErrorResource},
+	{"query exceeded", errors.EngineErrorResource},
+
+	// Missing objects
+	{"table_not_found", errors.EngineErrorNotFound},
+	{"column_not_found", errors.EngineErrorNotFound},
+	{"schema_not_found", errors.EngineErrorNotFound},
+	{"catalog_not_found", errors.EngineErrorNotFound},
+	{"table_or_view_not_found", errors.EngineErrorNotFound},
+	{"unresolved_column", errors.EngineErrorNotFound},
+	{"table or view not found", errors.EngineErrorNotFound},
+	{"does not exist", errors.EngineErrorNotFound},
+	{"given input columns", errors.EngineErrorNotFound},
+
+	// Permissions
+	{"permission_denied", errors.EngineErrorPermission},
+	{"access denied", errors.EngineErrorPermission},
+	{"accesscontrolexception", errors.EngineErrorPermission},
+	{"permission denied", errors.EngineErrorPermission},
+	{"insufficient privileges", errors.EngineErrorPermission},
+
+	// Syntax
+	{"syntax_error", errors.EngineErrorSyntax}, // also PARSE_SYNTAX_ERROR
+	{"parseexception", errors.EngineErrorSyntax},
+	{"mismatched input", errors.EngineErrorSyntax},
+	{"syntax error", errors.EngineErrorSyntax},
+
+	// Transient failures
+	{"server_starting_up", errors.EngineErrorTransient},
+	{"server_shutting_down", errors.EngineErrorTransient},
+	{"no_nodes_available", errors.EngineErrorTransient},
+	{"too_many_requests_failed", errors.EngineErrorTransient},
+	{"remote_host_gone", errors.EngineErrorTransient},
+	{"ttransportexception", errors.EngineErrorTransient},
+	{"connection refused", errors.EngineErrorTransient},
+	{"connection reset", errors.EngineErrorTransient},
+	{"broken pipe", errors.EngineErrorTransient},
+	{"i/o timeout", errors.EngineErrorTransient},
+	{"503 service unavailable", errors.EngineErrorTransient},
+}
+
+// CategorizeEngineError returns the category of an error reported by an
+// engine, based on the error name or exception class in its message.
+// Network timeouts are transient; unrecognized errors are EngineErrorUnknown.
+func CategorizeEngineError(err error) errors.EngineErrorCategory {
+	var engineErr *errors.ErrEngineQuery
+	if stderrors.As(err, &engineErr) {
+		return engineErr.Category
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, p := range engineErrorPatterns {
+		if strings.Contains(msg, p.fragment) {
+			return p.category
+		}
+	}
+
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return errors.EngineErrorTransient
+	}
+	return errors.EngineErrorUnknown
+}
+
+// ClassifyEngineError wraps an error reported by engine in an
+// *errors.ErrEngineQuery carrying its category.
+// Nil errors, context cancellation and deadline errors, and errors that are
+// already classified are returned unchanged.
+func ClassifyEngineError(engine string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var engineErr *errors.ErrEngineQuery
+	if stderrors.As(err, &engineErr) {
+		return err
+	}
+	return errors.NewEngineQueryError(engine, CategorizeEngineError(err), err)
+}
@@ -0,0 +1,161 @@
+package adapters
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// healthLatencySamples is the number of recent health checks and queries
+// averaged into HealthSnapshot.LatencyMs.
+const healthLatencySamples = 20
+
+// HealthSnapshot is the recent health of an engine, recorded by the
+// AdapterRegistry from health checks and query executions. Unlike the
+// boolean readiness check, it shows engines that are degraded but not down:
+// failing intermittently, or healthy but slow.
+type HealthSnapshot struct {
+	// Engine is the adapter name.
+	Engine string `json:"engine"`
+
+	// Healthy is the result of the last health check.
+	Healthy bool `json:"healthy"`
+
+	// LastCheck is when the last health check ran; zero if none has.
+	LastCheck time.Time `json:"last_check,omitzero"`
+
+	// LastError is the last health check or engine-side query error, which
+	// stays visible after the engine recovers. Errors caused by the query
+	// itself (syntax, permissions, missing objects) are not recorded.
+	LastError string `json:"last_error,omitempty"`
+
+	// LastErrorAt is when LastError occurred.
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+
+	// LastSuccessfulQuery is when a query last succeeded on the engine.
+	LastSuccessfulQuery time.Time `json:"last_successful_query,omitzero"`
+
+	// LastLatencyMs is the latency of the last health check or query.
+	LastLatencyMs int64 `json:"last_latency_ms"`
+
+	// LatencyMs is the average latency of recent health checks and queries.
+	LatencyMs int64 `json:"latency_ms"`
+}
+
+// healthTracker accumulates the health snapshot of one adapter.
+type healthTracker struct {
+	snapshot  HealthSnapshot
+	latencies []time.Duration // ring buffer of recent latencies
+	next      int
+}
+
+// addLatency records a latency sample.
+func (t *healthTracker) addLatency(latency time.Duration) {
+	if len(t.latencies) < healthLatencySamples {
+		t.latencies = append(t.latencies, latency)
+	} else {
+		t.latencies[t.next] = latency
+		t.next = (t.next + 1) % healthLatencySamples
+	}
+
+	var total time.Duration
+	for _, l := range t.latencies {
+		total += l
+	}
+	t.snapshot.LastLatencyMs = latency.Milliseconds()
+	t.snapshot.LatencyMs = (total / time.Duration(len(t.latencies))).Milliseconds()
+}
+
+// recordError records an error observed at a time.
+func (t *healthTracker) recordError(at time.Time, err error) {
+	t.snapshot.LastError = err.Error()
+	t.snapshot.LastErrorAt = at
+}
+
+// tracker returns the health tracker of an adapter, creating it if needed.
+// The caller must hold r.healthMu.
+func (r *AdapterRegistry) tracker(name string) *healthTracker {
+	t, ok := r.health[name]
+	if !ok {
+		t = &healthTracker{snapshot: HealthSnapshot{Engine: name}}
+		r.health[name] = t
+	}
+	return t
+}
+
+// recordHealthCheck records the result of a health check of an adapter.
+func (r *AdapterRegistry) recordHealthCheck(name string, at time.Time, latency time.Duration, err error) HealthSnapshot {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+
+	t := r.tracker(name)
+	t.snapshot.Healthy = err == nil
+	t.snapshot.LastCheck = at
+	t.addLatency(latency)
+	if err != nil {
+		t.recordError(at, err)
+	}
+	return t.snapshot
+}
+
+// CheckHealth checks the health of one adapter, records the result and
+// returns the adapter's updated health snapshot. The error is
+// ErrEngineNotFound if no adapter is registered under name; a failed check
+// is reported in the snapshot, not as an error.
+func (r *AdapterRegistry) CheckHealth(ctx context.Context, name string) (HealthSnapshot, error) {
+	adapter, ok := r.Get(name)
+	if !ok {
+		return HealthSnapshot{}, errors.NewEngineNotFound(name)
+	}
+
+	start := time.Now()
+	err := adapter.CheckHealth(ctx)
+	return r.recordHealthCheck(name, start, time.Since(start), err), nil
+}
+
+// RecordQuery records the outcome of a query executed on an adapter, for
+// its health snapshot. Successful queries update the last successful query
+// time; failures are recorded as the last error only when they come from
+// the engine rather than the query (see HealthSnapshot.LastError).
+// Canceled queries are not recorded.
+func (r *AdapterRegistry) RecordQuery(name string, latency time.Duration, err error) {
+	if stderrors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		switch CategorizeEngineError(err) {
+		case errors.EngineErrorSyntax, errors.EngineErrorPermission, errors.EngineErrorNotFound:
+			return
+		}
+	}
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+
+	now := time.Now()
+	t := r.tracker(name)
+	t.addLatency(latency)
+	if err != nil {
+		t.recordError(now, err)
+		return
+	}
+	t.snapshot.LastSuccessfulQuery = now
+}
+
+// HealthSnapshot returns the recorded health of an adapter. ok is false if
+// no adapter is registered under name. An adapter with no recorded checks
+// or queries has a zero snapshot apart from its name.
+func (r *AdapterRegistry) HealthSnapshot(name string) (snapshot HealthSnapshot, ok bool) {
+	if _, ok := r.Get(name); !ok {
+		return HealthSnapshot{}, false
+	}
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	if t, ok := r.health[name]; ok {
+		return t.snapshot, true
+	}
+	return HealthSnapshot{Engine: name}, true
+}
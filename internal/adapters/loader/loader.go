@@ -0,0 +1,290 @@
+// Package loader builds engine adapters from stored engine definitions and
+// keeps an AdapterRegistry in sync with them, so engines can be added,
+// changed and removed without restarting the gateway.
+package loader
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/adapters/duckdb"
+	"github.com/canonica-labs/canonica/internal/adapters/redshift"
+	"github.com/canonica-labs/canonica/internal/adapters/spark"
+	"github.com/canonica-labs/canonica/internal/adapters/trino"
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/storage"
+)
+
+// SecretsProvider resolves a credential reference to its secret value.
+type SecretsProvider interface {
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// EnvSecrets resolves credential references from environment variables:
+// the reference is the variable name.
+type EnvSecrets struct{}
+
+// Secret returns the value of the environment variable named ref.
+func (EnvSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("secret %s: environment variable not set", ref)
+	}
+	return value, nil
+}
+
+// Factory builds an adapter from an engine definition. credential is the
+// resolved secret of def.CredentialRef, or empty if it has none.
+type Factory func(ctx context.Context, def *storage.EngineDefinition, credential string) (adapters.EngineAdapter, error)
+
+// RefreshResult reports what a Refresh changed.
+type RefreshResult struct {
+	Added   []string
+	Updated []string
+	Removed []string
+
+	// Errors maps engines whose adapter could not be built to the cause.
+	// A failing engine keeps its previous adapter, if any.
+	Errors map[string]error
+}
+
+// Loader registers adapters for the engine definitions in a repository.
+// It only manages the adapters it registered: adapters registered directly
+// (e.g. from command-line flags) are left alone unless a stored definition
+// with the same name replaces them.
+type Loader struct {
+	repo      storage.EngineRepository
+	registry  *adapters.AdapterRegistry
+	secrets   SecretsProvider
+	factories map[string]Factory
+
+	mu     sync.Mutex
+	loaded map[string]*storage.EngineDefinition
+}
+
+// NewLoader creates a loader. A nil secrets provider selects EnvSecrets.
+func NewLoader(repo storage.EngineRepository, registry *adapters.AdapterRegistry, secrets SecretsProvider) *Loader {
+	if secrets == nil {
+		secrets = EnvSecrets{}
+	}
+	return &Loader{
+		repo:     repo,
+		registry: registry,
+		secrets:  secrets,
+		factories: map[string]Factory{
+			"duckdb":   newDuckDBAdapter,
+			"trino":    newTrinoAdapter,
+			"spark":    newSparkAdapter,
+			"redshift": newRedshiftAdapter,
+		},
+		loaded: make(map[string]*storage.EngineDefinition),
+	}
+}
+
+// RegisterFactory sets the factory used for engine definitions of engineType.
+func (l *Loader) RegisterFactory(engineType string, factory Factory) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.factories[engineType] = factory
+}
+
+// Refresh reads the engine definitions and brings the registry in line:
+// new or changed enabled engines are (re)built and registered, and engines
+// that were removed or disabled are unregistered and closed.
+// It returns an error only if the definitions cannot be read.
+func (l *Loader) Refresh(ctx context.Context) (*RefreshResult, error) {
+	defs, err := l.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list engine definitions: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := &RefreshResult{Errors: make(map[string]error)}
+	wanted := make(map[string]bool)
+
+	for _, def := range defs {
+		if !def.Enabled {
+			continue
+		}
+		wanted[def.Name] = true
+
+		previous, loaded := l.loaded[def.Name]
+		if loaded && reflect.DeepEqual(previous, def) {
+			continue
+		}
+
+		adapter, err := l.build(ctx, def)
+		if err != nil {
+			result.Errors[def.Name] = err
+			continue
+		}
+
+		old, _ := l.registry.Get(def.Name)
+		l.registry.Register(adapter)
+		if old != nil {
+			old.Close()
+		}
+		l.loaded[def.Name] = def
+
+		if loaded {
+			result.Updated = append(result.Updated, def.Name)
+		} else {
+			result.Added = append(result.Added, def.Name)
+		}
+	}
+
+	for name := range l.loaded {
+		if wanted[name] {
+			continue
+		}
+		if adapter := l.registry.Unregister(name); adapter != nil {
+			adapter.Close()
+		}
+		delete(l.loaded, name)
+		result.Removed = append(result.Removed, name)
+	}
+	sort.Strings(result.Removed)
+
+	return result, nil
+}
+
+// build constructs the adapter for a definition.
+func (l *Loader) build(ctx context.Context, def *storage.EngineDefinition) (adapters.EngineAdapter, error) {
+	factory, ok := l.factories[def.Type]
+	if !ok {
+		return nil, fmt.Errorf("engine %s: unsupported engine type %q", def.Name, def.Type)
+	}
+
+	caps := make([]capabilities.Capability, 0, len(def.Capabilities))
+	for _, capStr := range def.Capabilities {
+		cap, err := capabilities.ParseCapability(capStr)
+		if err != nil {
+			return nil, fmt.Errorf("engine %s: %w", def.Name, err)
+		}
+		caps = append(caps, cap)
+	}
+
+	var credential string
+	if def.CredentialRef != "" {
+		secret, err := l.secrets.Secret(ctx, def.CredentialRef)
+		if err != nil {
+			return nil, fmt.Errorf("engine %s: failed to resolve credential: %w", def.Name, err)
+		}
+		credential = secret
+	}
+
+	adapter, err := factory(ctx, def, credential)
+	if err != nil {
+		return nil, fmt.Errorf("engine %s: %w", def.Name, err)
+	}
+	return &namedAdapter{EngineAdapter: adapter, name: def.Name, capabilities: caps}, nil
+}
+
+// namedAdapter registers an adapter under its definition's name, which may
+// differ from the adapter's own (e.g. "trino-eu"), and with the definition's
+// capabilities when it lists any.
+type namedAdapter struct {
+	adapters.EngineAdapter
+	name         string
+	capabilities []capabilities.Capability
+}
+
+// Name returns the engine definition name.
+func (a *namedAdapter) Name() string {
+	return a.name
+}
+
+// Capabilities returns the definition's capabilities, falling back to the
+// adapter's own.
+func (a *namedAdapter) Capabilities() []capabilities.Capability {
+	if len(a.capabilities) > 0 {
+		return a.capabilities
+	}
+	return a.EngineAdapter.Capabilities()
+}
+
+// EngineVersion forwards to the wrapped adapter if it reports versions.
+func (a *namedAdapter) EngineVersion(ctx context.Context) (string, error) {
+	reporter, ok := a.EngineAdapter.(adapters.VersionReporter)
+	if !ok {
+		return "", adapters.ErrVersionNotReported
+	}
+	return reporter.EngineVersion(ctx)
+}
+
+func newDuckDBAdapter(ctx context.Context, def *storage.EngineDefinition, credential string) (adapters.EngineAdapter, error) {
+	return duckdb.NewAdapterWithConfig(duckdb.AdapterConfig{DatabasePath: def.Database}), nil
+}
+
+func newTrinoAdapter(ctx context.Context, def *storage.EngineDefinition, credential string) (adapters.EngineAdapter, error) {
+	host, port, err := hostPort(def.Endpoint, 8080)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := adapters.NewSessionProperties(def.SessionProperties); err != nil {
+		return nil, err
+	}
+	return trino.NewAdapter(trino.AdapterConfig{
+		Host:              host,
+		Port:              port,
+		Catalog:           def.Database,
+		User:              def.User,
+		SessionProperties: def.SessionProperties,
+	}), nil
+}
+
+func newSparkAdapter(ctx context.Context, def *storage.EngineDefinition, credential string) (adapters.EngineAdapter, error) {
+	host, port, err := hostPort(def.Endpoint, 10000)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := adapters.NewSessionProperties(def.SessionProperties); err != nil {
+		return nil, err
+	}
+	return spark.NewAdapter(spark.AdapterConfig{
+		Host:              host,
+		Port:              port,
+		Database:          def.Database,
+		User:              def.User,
+		SessionProperties: def.SessionProperties,
+	}), nil
+}
+
+func newRedshiftAdapter(ctx context.Context, def *storage.EngineDefinition, credential string) (adapters.EngineAdapter, error) {
+	host, port, err := hostPort(def.Endpoint, 5439)
+	if err != nil {
+		return nil, err
+	}
+	config := redshift.DefaultConfig()
+	config.Host = host
+	config.Port = port
+	config.Database = def.Database
+	config.User = def.User
+	config.Password = credential
+	return redshift.NewAdapter(ctx, config)
+}
+
+// hostPort extracts the host and port of an endpoint URL.
+func hostPort(endpoint string, defaultPort int) (string, int, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "", 0, fmt.Errorf("invalid endpoint %q: expected a URL such as scheme://host:port", endpoint)
+	}
+	port := defaultPort
+	if p := u.Port(); p != "" {
+		port, err = strconv.Atoi(p)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid endpoint %q: bad port", endpoint)
+		}
+	}
+	return u.Hostname(), port, nil
+}
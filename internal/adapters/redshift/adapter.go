@@ -198,6 +198,7 @@ func (a *Adapter) collectResults(rows *sql.Rows) (*adapters.QueryResult, error)
 	if err != nil {
 		return nil, fmt.Errorf("redshift: failed to get columns: %w", err)
 	}
+	columnTypes := adapters.ColumnTypeNames(rows)
 
 	var resultRows [][]interface{}
 	for rows.Next() {
@@ -219,9 +220,10 @@ func (a *Adapter) collectResults(rows *sql.Rows) (*adapters.QueryResult, error)
 	}
 
 	return &adapters.QueryResult{
-		Columns:  columns,
-		Rows:     resultRows,
-		RowCount: len(resultRows),
+		Columns:     columns,
+		ColumnTypes: columnTypes,
+		Rows:        resultRows,
+		RowCount:    len(resultRows),
 		Metadata: map[string]string{
 			"engine":   "redshift",
 			"host":     a.config.Host,
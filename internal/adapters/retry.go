@@ -10,8 +10,11 @@ package adapters
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"time"
+
+	"github.com/canonica-labs/canonica/internal/errors"
 )
 
 // RetryConfig configures retry behavior.
@@ -89,7 +92,7 @@ func (e *RetryableError) Unwrap() error {
 // Per phase-6-spec.md: Only retry transient failures like network timeouts.
 // Per docs/plan.md: "Never retry semantic errors."
 //
-// Returns true for:
+// Returns true for engine errors classified as transient:
 //   - Connection timeouts
 //   - Network errors
 //   - Temporary unavailability
@@ -109,11 +112,13 @@ func IsRetryable(err error) bool {
 		return false
 	}
 
-	// TODO: Add specific error type checks as we learn more about
-	// actual failure modes from Trino and Spark drivers.
-	// For MVP, we conservatively return false to avoid masking errors.
-	//
+	// Only errors classified as transient by ClassifyEngineError are retried.
+	// Unclassified errors are not.
 	// Per copilot-instructions.md: "If unsure, code must fail."
+	var engineErr *errors.ErrEngineQuery
+	if stderrors.As(err, &engineErr) {
+		return engineErr.Retryable()
+	}
 	return false
 }
 
@@ -0,0 +1,161 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/canonica-labs/canonica/internal/auth"
+)
+
+// QueryLabelsHeader is the request header carrying labels for a single query,
+// as comma-separated key=value pairs (e.g. "team=finance,priority=low").
+// Labels are available to session property templates.
+const QueryLabelsHeader = "X-Canonic-Query-Labels"
+
+// SessionContext is the request context that session property templates are
+// resolved against.
+type SessionContext struct {
+	// User is the authenticated user's ID, or empty if there is none.
+	User string
+
+	// Role is the user's first role, or empty if the user has none.
+	Role string
+
+	// Roles are all of the user's roles.
+	Roles []string
+
+	// Labels are the query labels from QueryLabelsHeader.
+	Labels map[string]string
+}
+
+// SessionProperties are engine session properties whose values are
+// text/template templates resolved per query from the SessionContext, e.g.
+//
+//	resource_group: "{{.Role}}"
+//	query_queue:    "{{.Labels.team}}_adhoc"
+//
+// Adapters apply the resolved properties before submitting the query, so it
+// lands in the resource group or queue chosen for the user.
+// A nil *SessionProperties has no properties; all methods accept nil.
+type SessionProperties struct {
+	names     []string
+	templates map[string]*template.Template
+}
+
+// validSessionProperty restricts property names to identifiers such as
+// "query_max_run_time" or "spark.scheduler.pool".
+var validSessionProperty = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// NewSessionProperties parses the property templates.
+// It returns nil if props is empty.
+func NewSessionProperties(props map[string]string) (*SessionProperties, error) {
+	if len(props) == 0 {
+		return nil, nil
+	}
+
+	p := &SessionProperties{templates: make(map[string]*template.Template, len(props))}
+	for name, text := range props {
+		if !validSessionProperty.MatchString(name) {
+			return nil, fmt.Errorf("invalid session property name %q", name)
+		}
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("session property %s: %w", name, err)
+		}
+		p.names = append(p.names, name)
+		p.templates[name] = tmpl
+	}
+	sort.Strings(p.names)
+	return p, nil
+}
+
+// SessionProperty is a resolved session property.
+type SessionProperty struct {
+	Name  string
+	Value string
+}
+
+// Resolve resolves the properties for the query running under ctx, in name
+// order. Properties that resolve to an empty value are omitted, so the
+// engine default applies (e.g. when the request has no authenticated user).
+func (p *SessionProperties) Resolve(ctx context.Context) ([]SessionProperty, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	data := SessionContextFrom(ctx)
+	resolved := make([]SessionProperty, 0, len(p.names))
+	for _, name := range p.names {
+		var buf bytes.Buffer
+		if err := p.templates[name].Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("session property %s: %w", name, err)
+		}
+		value := strings.TrimSpace(buf.String())
+		if value == "" {
+			continue
+		}
+		// Values end up in headers and SET statements; keep them on one line
+		// and free of statement separators.
+		if strings.ContainsAny(value, ";\r\n") {
+			return nil, fmt.Errorf("session property %s: resolved value %q contains forbidden characters", name, value)
+		}
+		resolved = append(resolved, SessionProperty{Name: name, Value: value})
+	}
+	return resolved, nil
+}
+
+// SessionContextFrom builds the SessionContext for ctx from the
+// authenticated user and the query labels.
+func SessionContextFrom(ctx context.Context) SessionContext {
+	data := SessionContext{Labels: QueryLabelsFromContext(ctx)}
+	if data.Labels == nil {
+		data.Labels = map[string]string{}
+	}
+	if user := auth.UserFromContext(ctx); user != nil {
+		data.User = user.ID
+		data.Roles = user.Roles
+		if len(user.Roles) > 0 {
+			data.Role = user.Roles[0]
+		}
+	}
+	return data
+}
+
+// ParseQueryLabels parses a QueryLabelsHeader value.
+// An empty value returns no labels.
+func ParseQueryLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid query label %q (expected key=value)", pair)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// queryLabelsKey is the context key for query labels.
+type queryLabelsKey struct{}
+
+// ContextWithQueryLabels returns a context carrying labels for the query.
+func ContextWithQueryLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, queryLabelsKey{}, labels)
+}
+
+// QueryLabelsFromContext returns the labels attached to ctx, or nil if there
+// are none.
+func QueryLabelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(queryLabelsKey{}).(map[string]string)
+	return labels
+}
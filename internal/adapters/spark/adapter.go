@@ -30,10 +30,14 @@ import (
 // This adapter uses a generic SQL interface that can work with various
 // Spark connection methods.
 type Adapter struct {
-	mu     sync.RWMutex
-	db     *sql.DB
-	config AdapterConfig
-	closed bool
+	mu      sync.RWMutex
+	db      *sql.DB
+	config  AdapterConfig
+	session *adapters.SessionProperties
+	// sessionErr is the error parsing config.SessionProperties; Execute
+	// returns it rather than running queries without their properties.
+	sessionErr error
+	closed     bool
 }
 
 // AdapterConfig configures the Spark adapter.
@@ -55,6 +59,12 @@ type AdapterConfig struct {
 
 	// ConnectionTimeout for establishing connections.
 	ConnectionTimeout time.Duration
+
+	// SessionProperties are Spark configuration properties set with SET
+	// before every query, e.g. {"spark.scheduler.pool": "{{.Role}}"}.
+	// Values are templates resolved from the request context; see
+	// adapters.SessionProperties.
+	SessionProperties map[string]string
 }
 
 // NewAdapter creates a new Spark adapter with the given configuration.
@@ -77,10 +87,30 @@ func NewAdapter(config AdapterConfig) *Adapter {
 	// For MVP, we create the adapter structure but defer connection
 	// until first use, allowing the adapter to be created without
 	// an active Spark cluster.
+	session, sessionErr := adapters.NewSessionProperties(config.SessionProperties)
 	return &Adapter{
-		config: config,
-		closed: false,
+		config:     config,
+		session:    session,
+		sessionErr: sessionErr,
+		closed:     false,
+	}
+}
+
+// sessionStatements returns the SET statements applying the configured
+// session properties for the request.
+func (a *Adapter) sessionStatements(ctx context.Context) ([]string, error) {
+	if a.sessionErr != nil {
+		return nil, a.sessionErr
+	}
+	props, err := a.session.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statements := make([]string, len(props))
+	for i, prop := range props {
+		statements[i] = fmt.Sprintf("SET %s=%s", prop.Name, prop.Value)
 	}
+	return statements, nil
 }
 
 // Execute runs a query on Spark and returns the result.
@@ -117,6 +147,11 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	}
 	a.mu.RUnlock()
 
+	statements, err := a.sessionStatements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Spark adapter: %w", err)
+	}
+
 	// Attempt to connect and execute
 	// Note: In production, this would use an actual Spark/Hive driver.
 	// For MVP, we simulate connection attempt to validate connectivity.
@@ -126,8 +161,21 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	}
 	defer conn.Close()
 
+	// Session properties only apply to the connection they are set on, so
+	// pin one connection for the SET statements and the query.
+	session, err := conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Spark adapter: connection failed: %w", err)
+	}
+	defer session.Close()
+	for _, stmt := range statements {
+		if _, err := session.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("Spark adapter: failed to apply session property: %w", err)
+		}
+	}
+
 	// Execute query
-	rows, err := conn.QueryContext(ctx, plan.LogicalPlan.RawSQL)
+	rows, err := session.QueryContext(ctx, plan.LogicalPlan.RawSQL)
 	if err != nil {
 		return nil, fmt.Errorf("Spark adapter: query execution failed: %w", err)
 	}
@@ -138,6 +186,7 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	if err != nil {
 		return nil, fmt.Errorf("Spark adapter: failed to get columns: %w", err)
 	}
+	columnTypes := adapters.ColumnTypeNames(rows)
 
 	// Read all rows
 	resultRows := make([][]interface{}, 0)
@@ -167,9 +216,10 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	}
 
 	return &adapters.QueryResult{
-		Columns:  columns,
-		Rows:     resultRows,
-		RowCount: len(resultRows),
+		Columns:     columns,
+		ColumnTypes: columnTypes,
+		Rows:        resultRows,
+		RowCount:    len(resultRows),
 		Metadata: map[string]string{
 			"engine":   "spark",
 			"database": a.config.Database,
@@ -247,6 +297,27 @@ func (a *Adapter) Ping(ctx context.Context) error {
 	return nil
 }
 
+// EngineVersion returns the Spark release the server reports, e.g.
+// "3.5.1 fd86f85e181fc2dc0f50a096855acf83a6cc5d9c".
+// It requires a SQL connection, which the MVP adapter does not have.
+func (a *Adapter) EngineVersion(ctx context.Context) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return "", fmt.Errorf("Spark adapter: connection is closed")
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("Spark adapter: version query requires a Spark Thrift Server driver (not available in MVP)")
+	}
+
+	var version string
+	if err := a.db.QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("Spark adapter: version query failed: %w", err)
+	}
+	return version, nil
+}
+
 // Close releases any resources held by the adapter.
 // Close is idempotent - safe to call multiple times.
 func (a *Adapter) Close() error {
@@ -9,10 +9,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/planner"
 
@@ -22,10 +26,14 @@ import (
 // Adapter implements the engine adapter interface for Trino.
 // Per docs/plan.md: "Trino (primary read engine)"
 type Adapter struct {
-	mu     sync.RWMutex
-	db     *sql.DB
-	config AdapterConfig
-	closed bool
+	mu      sync.RWMutex
+	db      *sql.DB
+	config  AdapterConfig
+	session *adapters.SessionProperties
+	// sessionErr is the error parsing config.SessionProperties; Execute
+	// returns it rather than running queries without their properties.
+	sessionErr error
+	closed     bool
 }
 
 // AdapterConfig configures the Trino adapter.
@@ -66,8 +74,24 @@ type AdapterConfig struct {
 
 	// QueryTimeout is the default query timeout. Default: 5 minutes.
 	QueryTimeout time.Duration
+
+	// Impersonate runs each query as the authenticated end user instead of
+	// the shared User account, so Trino-side access policies apply.
+	// Default: false (shared service account).
+	Impersonate bool
+
+	// SessionProperties are Trino session properties set on every query,
+	// e.g. {"resource_group": "{{.Role}}"}. Values are templates resolved
+	// from the request context; see adapters.SessionProperties.
+	SessionProperties map[string]string
 }
 
+// ImpersonationHeader is the Trino header carrying the end-user identity.
+const ImpersonationHeader = "X-Trino-User"
+
+// SessionHeader is the Trino header carrying session properties.
+const SessionHeader = "X-Trino-Session"
+
 // NewAdapter creates a new Trino adapter with the given configuration.
 // Per phase-6-spec.md: Configures connection pooling and validates settings.
 func NewAdapter(config AdapterConfig) *Adapter {
@@ -118,13 +142,17 @@ func NewAdapter(config AdapterConfig) *Adapter {
 		config.Schema,
 	)
 
+	session, sessionErr := adapters.NewSessionProperties(config.SessionProperties)
+
 	// Open database connection
 	db, err := sql.Open("trino", dsn)
 	if err != nil {
 		// Return adapter in failed state - will error on first use
 		return &Adapter{
-			config: config,
-			closed: true,
+			config:     config,
+			session:    session,
+			sessionErr: sessionErr,
+			closed:     true,
 		}
 	}
 
@@ -135,12 +163,62 @@ func NewAdapter(config AdapterConfig) *Adapter {
 	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
 	return &Adapter{
-		db:     db,
-		config: config,
-		closed: false,
+		db:         db,
+		config:     config,
+		session:    session,
+		sessionErr: sessionErr,
+		closed:     false,
+	}
+}
+
+// NewAdapterWithDB creates a Trino adapter over an existing connection pool.
+// The DSN and pool settings of config are not applied; db is used as-is.
+func NewAdapterWithDB(config AdapterConfig, db *sql.DB) *Adapter {
+	if config.User == "" {
+		config.User = "canonica"
+	}
+	if config.ConnectTimeout <= 0 {
+		config.ConnectTimeout = 10 * time.Second
+	}
+	session, sessionErr := adapters.NewSessionProperties(config.SessionProperties)
+	return &Adapter{
+		db:         db,
+		config:     config,
+		session:    session,
+		sessionErr: sessionErr,
 	}
 }
 
+// queryArgs returns the driver arguments for a query.
+// When impersonation is enabled and the context carries an authenticated user,
+// the user's ID is sent as X-Trino-User so engine-side policies apply.
+// Configured session properties are resolved for the request and sent as
+// X-Trino-Session, so the query lands in the intended resource group.
+func (a *Adapter) queryArgs(ctx context.Context) ([]interface{}, error) {
+	var args []interface{}
+	if a.config.Impersonate {
+		if user := auth.UserFromContext(ctx); user != nil && user.ID != "" {
+			args = append(args, sql.Named(ImpersonationHeader, user.ID))
+		}
+	}
+
+	if a.sessionErr != nil {
+		return nil, a.sessionErr
+	}
+	props, err := a.session.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(props) > 0 {
+		pairs := make([]string, len(props))
+		for i, prop := range props {
+			pairs[i] = prop.Name + "=" + url.QueryEscape(prop.Value)
+		}
+		args = append(args, sql.Named(SessionHeader, strings.Join(pairs, ",")))
+	}
+	return args, nil
+}
+
 // Execute runs a query on Trino and returns the result.
 // Per docs/plan.md: "Adapters must propagate errors explicitly - never swallow."
 func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*adapters.QueryResult, error) {
@@ -176,8 +254,18 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	db := a.db
 	a.mu.RUnlock()
 
+	args, err := a.queryArgs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Trino adapter: %w", err)
+	}
+
+	// Tag the query so it can be killed on Trino if the caller goes away.
+	handle := adapters.NewQueryHandle()
+	stop := adapters.CancelOnDone(ctx, a, handle)
+	defer stop()
+
 	// Execute query with context
-	rows, err := db.QueryContext(ctx, plan.LogicalPlan.RawSQL)
+	rows, err := db.QueryContext(ctx, tagQuery(plan.LogicalPlan.RawSQL, handle), args...)
 	if err != nil {
 		return nil, fmt.Errorf("Trino adapter: query execution failed: %w", err)
 	}
@@ -188,6 +276,7 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	if err != nil {
 		return nil, fmt.Errorf("Trino adapter: failed to get columns: %w", err)
 	}
+	columnTypes := adapters.ColumnTypeNames(rows)
 
 	// Read all rows
 	resultRows := make([][]interface{}, 0)
@@ -217,9 +306,10 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	}
 
 	return &adapters.QueryResult{
-		Columns:  columns,
-		Rows:     resultRows,
-		RowCount: len(resultRows),
+		Columns:     columns,
+		ColumnTypes: columnTypes,
+		Rows:        resultRows,
+		RowCount:    len(resultRows),
 		Metadata: map[string]string{
 			"engine":  "trino",
 			"catalog": a.config.Catalog,
@@ -228,6 +318,73 @@ func (a *Adapter) Execute(ctx context.Context, plan *planner.ExecutionPlan) (*ad
 	}, nil
 }
 
+// queryTagPrefix marks queries submitted by the gateway in Trino's query text.
+const queryTagPrefix = "canonic-query: "
+
+// validHandle restricts handles and Trino query IDs to characters that are
+// safe to embed in SQL literals.
+var validHandle = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// tagQuery prefixes the SQL with a comment carrying the query handle.
+// Trino keeps comments in system.runtime.queries, which is how Cancel finds it.
+func tagQuery(query, handle string) string {
+	return fmt.Sprintf("/* %s%s */ %s", queryTagPrefix, handle, query)
+}
+
+// Cancel kills the running Trino query tagged with queryHandle.
+// Per adapters.QueryCanceler: cancelling a finished query is not an error.
+func (a *Adapter) Cancel(ctx context.Context, queryHandle string) error {
+	if !validHandle.MatchString(queryHandle) {
+		return fmt.Errorf("Trino adapter: invalid query handle %q", queryHandle)
+	}
+
+	a.mu.RLock()
+	if a.closed || a.db == nil {
+		a.mu.RUnlock()
+		return fmt.Errorf("Trino adapter: connection is closed")
+	}
+	db := a.db
+	a.mu.RUnlock()
+
+	// concat() keeps this lookup from matching its own query text.
+	lookup := fmt.Sprintf(
+		"SELECT query_id FROM system.runtime.queries "+
+			"WHERE state NOT IN ('FINISHED', 'FAILED') "+
+			"AND strpos(query, concat('%s', '%s')) > 0",
+		queryTagPrefix, queryHandle)
+
+	rows, err := db.QueryContext(ctx, lookup)
+	if err != nil {
+		return fmt.Errorf("Trino adapter: failed to look up query %s: %w", queryHandle, err)
+	}
+	var queryIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("Trino adapter: failed to read query id: %w", err)
+		}
+		queryIDs = append(queryIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Trino adapter: failed to look up query %s: %w", queryHandle, err)
+	}
+
+	for _, id := range queryIDs {
+		if !validHandle.MatchString(id) {
+			return fmt.Errorf("Trino adapter: unexpected query id %q", id)
+		}
+		kill := fmt.Sprintf(
+			"CALL system.runtime.kill_query(query_id => '%s', message => 'cancelled by canonic gateway')", id)
+		if _, err := db.ExecContext(ctx, kill); err != nil {
+			return fmt.Errorf("Trino adapter: failed to cancel query %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
 // Capabilities returns the capabilities this engine supports.
 // Per docs/plan.md: "Trino (primary read engine)"
 func (a *Adapter) Capabilities() []capabilities.Capability {
@@ -256,6 +413,22 @@ func (a *Adapter) Ping(ctx context.Context) error {
 	return a.db.PingContext(ctx)
 }
 
+// EngineVersion returns the Trino release the coordinator reports, e.g. "435".
+func (a *Adapter) EngineVersion(ctx context.Context) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed || a.db == nil {
+		return "", fmt.Errorf("Trino adapter: connection is closed")
+	}
+
+	var version string
+	if err := a.db.QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("Trino adapter: version query failed: %w", err)
+	}
+	return version, nil
+}
+
 // Close releases any resources held by the adapter.
 // Close is idempotent - safe to call multiple times.
 func (a *Adapter) Close() error {
@@ -307,3 +480,6 @@ func (a *Adapter) CheckHealth(ctx context.Context) error {
 
 	return nil
 }
+
+// Ensure Adapter implements QueryCanceler interface
+var _ adapters.QueryCanceler = (*Adapter)(nil)
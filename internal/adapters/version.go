@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// ErrVersionNotReported is returned by adapters that wrap another adapter
+// when the wrapped adapter cannot report its engine version.
+var ErrVersionNotReported = stderrors.New("engine does not report its version")
+
+// VersionReporter is implemented by adapters that can query the engine for
+// its release version, e.g. with SELECT version(). The gateway probes it at
+// startup so SQL rewriting can pick the syntax the engine release supports.
+type VersionReporter interface {
+	// EngineVersion returns the version string reported by the engine.
+	EngineVersion(ctx context.Context) (string, error)
+}
+
+// ProbeVersions asks every registered adapter that implements
+// VersionReporter for its engine version.
+// It returns the reported versions and, separately, the probes that failed.
+// Adapters that do not report versions appear in neither map.
+func (r *AdapterRegistry) ProbeVersions(ctx context.Context) (map[string]string, map[string]error) {
+	r.mu.RLock()
+	reporters := make(map[string]VersionReporter, len(r.adapters))
+	for name, adapter := range r.adapters {
+		if reporter, ok := adapter.(VersionReporter); ok {
+			reporters[name] = reporter
+		}
+	}
+	r.mu.RUnlock()
+
+	versions := make(map[string]string)
+	failures := make(map[string]error)
+	for name, reporter := range reporters {
+		version, err := reporter.EngineVersion(ctx)
+		switch {
+		case stderrors.Is(err, ErrVersionNotReported):
+		case err != nil:
+			failures[name] = err
+		default:
+			versions[name] = version
+		}
+	}
+	return versions, failures
+}
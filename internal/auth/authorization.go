@@ -7,6 +7,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -28,6 +29,28 @@ type AuthorizationService struct {
 	permissions map[string]map[string][]capabilities.Capability // role → table → capabilities
 }
 
+// schemaWildcardSuffix ends a grant covering every table in a schema, as in
+// "analytics.*".
+const schemaWildcardSuffix = ".*"
+
+// IsSchemaWildcard reports whether a grant's table is a schema wildcard such
+// as "analytics.*". Other patterns containing "*" are not wildcards and
+// match no table.
+func IsSchemaWildcard(table string) bool {
+	schema, ok := strings.CutSuffix(table, schemaWildcardSuffix)
+	return ok && schema != "" && !strings.Contains(schema, "*")
+}
+
+// grantKeys returns the grant tables that cover a table: the table itself
+// and the wildcard of its schema, if qualified.
+func grantKeys(table string) []string {
+	i := strings.LastIndex(table, ".")
+	if i <= 0 {
+		return []string{table}
+	}
+	return []string{table, table[:i] + schemaWildcardSuffix}
+}
+
 // NewAuthorizationService creates a new authorization service with deny-by-default.
 func NewAuthorizationService() *AuthorizationService {
 	return &AuthorizationService{
@@ -36,7 +59,9 @@ func NewAuthorizationService() *AuthorizationService {
 }
 
 // GrantAccess grants a capability on a table to a role.
-// Per phase-2-spec.md: explicit grants only.
+// Per phase-2-spec.md: explicit grants only. A schema wildcard such as
+// "analytics.*" grants the capability on every table in the schema, including
+// tables registered later, and on no table in any other schema.
 func (s *AuthorizationService) GrantAccess(role, table string, cap capabilities.Capability) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -98,7 +123,8 @@ func (s *AuthorizationService) Authorize(ctx context.Context, user *User, tables
 	return nil
 }
 
-// hasPermission checks if any of the given roles has the required capability on the table.
+// hasPermission checks if any of the given roles has the required capability on the table,
+// granted on the table itself or on its schema's wildcard.
 // Per phase-2-spec.md: "Absence of permission is denial."
 func (s *AuthorizationService) hasPermission(roles []string, table string, requiredCap capabilities.Capability) bool {
 	s.mu.RLock()
@@ -111,14 +137,11 @@ func (s *AuthorizationService) hasPermission(roles []string, table string, requi
 			continue // Role has no permissions
 		}
 
-		tableCaps, ok := rolePerms[table]
-		if !ok {
-			continue // Role has no permissions on this table
-		}
-
-		for _, cap := range tableCaps {
-			if cap == requiredCap {
-				return true
+		for _, key := range grantKeys(table) {
+			for _, cap := range rolePerms[key] {
+				if cap == requiredCap {
+					return true
+				}
 			}
 		}
 	}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQueryPolicyName is the applied policy of queries bounded by the
+// global defaults only.
+const DefaultQueryPolicyName = "default"
+
+// QueryPolicy bounds the queries of a user.
+type QueryPolicy struct {
+	// DefaultLimit is the LIMIT added to queries that have none. Zero leaves
+	// such queries unbounded.
+	DefaultLimit int
+
+	// Timeout is how long a query may run. Zero is no timeout.
+	Timeout time.Duration
+}
+
+// RoleQueryPolicy overrides the global query policy for a role. For each
+// field, zero inherits the global default and a negative value removes the
+// bound, so a role can be both tighter and looser than the defaults.
+type RoleQueryPolicy struct {
+	DefaultLimit int
+	Timeout      time.Duration
+}
+
+// ResolvedQueryPolicy is the query policy of a user, with the roles whose
+// overrides it was resolved from.
+type ResolvedQueryPolicy struct {
+	QueryPolicy
+
+	// Roles are the roles whose overrides applied, sorted. Empty when only
+	// the global defaults applied.
+	Roles []string
+}
+
+// Name identifies the applied policy in the audit log: the roles whose
+// overrides applied, comma-separated, or DefaultQueryPolicyName.
+func (p ResolvedQueryPolicy) Name() string {
+	if len(p.Roles) == 0 {
+		return DefaultQueryPolicyName
+	}
+	return strings.Join(p.Roles, ",")
+}
+
+// QueryPolicies resolves the query policy of users from their roles, layered
+// over the global defaults. Roles differ in what they may safely run: a
+// dashboard role should never run unbounded scans, while an analyst can.
+type QueryPolicies struct {
+	mu       sync.RWMutex
+	defaults QueryPolicy
+	roles    map[string]RoleQueryPolicy
+}
+
+// NewQueryPolicies creates a resolver with the given global defaults and no
+// role overrides.
+func NewQueryPolicies(defaults QueryPolicy) *QueryPolicies {
+	return &QueryPolicies{
+		defaults: defaults,
+		roles:    make(map[string]RoleQueryPolicy),
+	}
+}
+
+// Defaults returns the global defaults.
+func (p *QueryPolicies) Defaults() QueryPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.defaults
+}
+
+// SetRolePolicy sets the overrides of a role, replacing any previous ones.
+func (p *QueryPolicies) SetRolePolicy(role string, policy RoleQueryPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roles[role] = policy
+}
+
+// Resolve returns the query policy of a user. Each bound is taken from the
+// user's roles that override it, or from the global defaults if none does.
+// When several roles override a bound, the most restrictive applies, so
+// granting a user an extra role never lifts a restriction placed on another.
+func (p *QueryPolicies) Resolve(user *User) ResolvedQueryPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	resolved := ResolvedQueryPolicy{QueryPolicy: p.defaults}
+	if user == nil {
+		return resolved
+	}
+
+	var limit, timeout bound
+	for _, role := range user.Roles {
+		override, ok := p.roles[role]
+		if !ok {
+			continue
+		}
+		limit.apply(role, int64(override.DefaultLimit))
+		timeout.apply(role, int64(override.Timeout))
+	}
+
+	applied := make(map[string]bool)
+	if limit.role != "" {
+		resolved.DefaultLimit = int(limit.value)
+		applied[limit.role] = true
+	}
+	if timeout.role != "" {
+		resolved.Timeout = time.Duration(timeout.value)
+		applied[timeout.role] = true
+	}
+	for role := range applied {
+		resolved.Roles = append(resolved.Roles, role)
+	}
+	sort.Strings(resolved.Roles)
+	return resolved
+}
+
+// bound accumulates the most restrictive override of one bound, where zero
+// is unbounded.
+type bound struct {
+	role  string
+	value int64
+}
+
+// apply considers a role's override: zero inherits, negative is unbounded.
+func (b *bound) apply(role string, override int64) {
+	if override == 0 {
+		return
+	}
+	if override < 0 {
+		override = 0
+	}
+	switch {
+	case b.role == "":
+	case override == 0 || (b.value != 0 && b.value <= override):
+		return
+	}
+	b.role, b.value = role, override
+}
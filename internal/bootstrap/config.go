@@ -11,14 +11,22 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/catalog"
 	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/federation"
+	"github.com/canonica-labs/canonica/internal/observability"
 	"github.com/canonica-labs/canonica/internal/tables"
 )
 
@@ -40,6 +48,16 @@ type Config struct {
 	// Tables configuration
 	Tables map[string]TableConfig `yaml:"tables,omitempty"`
 
+	// Federation configuration
+	Federation FederationConfig `yaml:"federation,omitempty"`
+
+	// FormatEngines overrides the default engine per table format
+	// (format → engine), e.g. iceberg: duckdb for deployments without Trino
+	FormatEngines map[string]string `yaml:"format_engines,omitempty"`
+
+	// SafeMode locks the deployment down for regulated environments
+	SafeMode SafeModeConfig `yaml:"safe_mode,omitempty"`
+
 	// validated tracks if Validate() has been called
 	validated bool
 
@@ -53,6 +71,93 @@ type Config struct {
 // GatewayConfig holds gateway server configuration.
 type GatewayConfig struct {
 	Listen string `yaml:"listen"`
+
+	// LogFormat is the query log format: "json" (default) or "logfmt".
+	LogFormat string `yaml:"log_format,omitempty"`
+
+	// VerboseAudit also logs the rewritten SQL sent to each engine with
+	// every audited query.
+	VerboseAudit bool `yaml:"verbose_audit,omitempty"`
+
+	// DefaultLimit is the LIMIT added to queries that have none. Zero leaves
+	// them unbounded. Roles may override it.
+	DefaultLimit int `yaml:"default_limit,omitempty"`
+
+	// QueryTimeout is how long a query may run, e.g. "5m". Empty is no
+	// timeout. Roles may override it.
+	QueryTimeout string `yaml:"query_timeout,omitempty"`
+}
+
+// FederationConfig holds cross-engine query configuration.
+type FederationConfig struct {
+	// MaxEnginesPerQuery caps the number of distinct engines a single query
+	// may span. Zero selects federation.DefaultMaxEnginesPerQuery.
+	MaxEnginesPerQuery int `yaml:"max_engines_per_query,omitempty"`
+
+	// MaxParallelism caps the number of sub-queries of a single query that
+	// run at once. Zero selects federation.DefaultMaxParallelism.
+	MaxParallelism int `yaml:"max_parallelism,omitempty"`
+
+	// MaxQueryMemory is the memory budget of a single query, shared by
+	// materialization, hash joins, aggregation and sorting, e.g. "512MB".
+	// Empty selects federation.DefaultMaxQueryMemory.
+	MaxQueryMemory string `yaml:"max_query_memory,omitempty"`
+
+	// DuplicateColumns is the policy for queries producing repeated output
+	// column names: "reject" (the default) or "rename" (x, x_1).
+	DuplicateColumns string `yaml:"duplicate_columns,omitempty"`
+
+	// JoinKeyCoercion is how hash joins compare keys whose types differ
+	// across engines: "numeric" (the default) compares integer and
+	// floating-point keys by value, "string_number" also parses string keys
+	// joined to integer keys, and "none" compares keys as returned.
+	JoinKeyCoercion string `yaml:"join_key_coercion,omitempty"`
+
+	// CartesianJoins is the policy for joins without a join condition, on
+	// single-engine and federated queries alike: "warn" (the default),
+	// "reject" or "allow".
+	CartesianJoins string `yaml:"cartesian_joins,omitempty"`
+
+	// SubQueryTimeout is how long each engine has to answer its sub-query
+	// of a federated query, e.g. "30s". Empty is no timeout.
+	SubQueryTimeout string `yaml:"sub_query_timeout,omitempty"`
+
+	// CostTiers limits how many queries of each estimated cost tier run at
+	// once, system-wide.
+	CostTiers CostTierConfig `yaml:"cost_tiers,omitempty"`
+}
+
+// CostTierConfig configures admission control by estimated query cost.
+// Queries are classified as cheap, medium or expensive by their estimated
+// time; cheap queries are never limited. Unset fields take the values of
+// federation.DefaultCostTierLimits.
+type CostTierConfig struct {
+	// MediumThreshold and ExpensiveThreshold are the estimated times from
+	// which a query is medium and expensive, e.g. "1s" and "30s".
+	MediumThreshold    string `yaml:"medium_threshold,omitempty"`
+	ExpensiveThreshold string `yaml:"expensive_threshold,omitempty"`
+
+	// MaxConcurrentMedium and MaxConcurrentExpensive are the number of
+	// queries of the tier that may run at once. Zero selects the default
+	// (unlimited for medium, 4 for expensive); a negative value removes the
+	// limit.
+	MaxConcurrentMedium    int `yaml:"max_concurrent_medium,omitempty"`
+	MaxConcurrentExpensive int `yaml:"max_concurrent_expensive,omitempty"`
+
+	// QueueTimeout is how long a query waits for a slot of its tier before
+	// it is rejected, e.g. "30s"; "0s" rejects it immediately.
+	QueueTimeout string `yaml:"queue_timeout,omitempty"`
+}
+
+// SafeModeConfig holds the safe-mode policy. When enabled, federation is
+// disabled, raw-file table sources are rejected and queries may only run on
+// the allowed engines.
+type SafeModeConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// AllowedEngines restricts queries to these engines. Empty allows every
+	// configured engine.
+	AllowedEngines []string `yaml:"allowed_engines,omitempty"`
 }
 
 // RepositoryConfig holds database repository configuration.
@@ -63,6 +168,15 @@ type RepositoryConfig struct {
 // PostgresConfig holds PostgreSQL connection configuration.
 type PostgresConfig struct {
 	DSN string `yaml:"dsn"`
+
+	// ReplicaDSN is an optional read replica of the database. Metadata
+	// reads and audit summaries are served from it; writes go to DSN.
+	ReplicaDSN string `yaml:"replica_dsn,omitempty"`
+
+	// ReplicaLag is how long metadata written through this gateway is read
+	// from the primary rather than the replica, e.g. "5s". Empty selects
+	// storage.DefaultReplicaLag.
+	ReplicaLag string `yaml:"replica_lag,omitempty"`
 }
 
 // EngineConfig holds query engine configuration.
@@ -71,19 +185,48 @@ type EngineConfig struct {
 	Endpoint     string   `yaml:"endpoint,omitempty"`
 	Database     string   `yaml:"database,omitempty"`
 	Capabilities []string `yaml:"capabilities,omitempty"`
+
+	// PushdownOperators are the predicate operators pushed to this engine
+	// in federated queries; others are evaluated locally. Empty uses the
+	// conservative default set.
+	PushdownOperators []string `yaml:"pushdown_operators,omitempty"`
 }
 
-// RoleConfig holds role → table permissions.
+// RoleConfig holds role → table permissions and the role's query bounds.
 type RoleConfig struct {
 	Tables map[string][]string `yaml:"tables"`
+
+	// DefaultLimit overrides gateway.default_limit for users with the role.
+	// Zero inherits it; a negative value leaves queries unbounded.
+	DefaultLimit int `yaml:"default_limit,omitempty"`
+
+	// QueryTimeout overrides gateway.query_timeout for users with the role,
+	// e.g. "30s". Empty inherits it; "none" removes the timeout.
+	QueryTimeout string `yaml:"query_timeout,omitempty"`
 }
 
+// noQueryTimeout is the role query_timeout that removes the timeout.
+const noQueryTimeout = "none"
+
 // TableConfig holds virtual table configuration.
 type TableConfig struct {
 	Description  string         `yaml:"description,omitempty"`
 	Sources      []SourceConfig `yaml:"sources"`
 	Capabilities []string       `yaml:"capabilities,omitempty"`
 	Constraints  []string       `yaml:"constraints,omitempty"`
+
+	// RequireSchema rejects queries until the table's column schema is synced.
+	RequireSchema bool `yaml:"require_schema,omitempty"`
+
+	// Deprecated marks the table for removal; queries succeed with a warning.
+	Deprecated bool `yaml:"deprecated,omitempty"`
+
+	// DeprecationMessage is included in the warning, e.g. the replacement table.
+	DeprecationMessage string `yaml:"deprecation_message,omitempty"`
+
+	// FormatOverride replaces the detected source format when routing
+	// queries, for tables whose catalog misreports the format.
+	FormatOverride string `yaml:"format_override,omitempty"`
 }
 
 // SourceConfig holds physical source configuration.
@@ -95,12 +238,22 @@ type SourceConfig struct {
 
 // LoadConfig loads and validates configuration from a YAML file.
 // Per phase-5-spec.md §1: "Unknown fields MUST fail"
+//
+// Values may reference environment variables as ${VAR}, or ${VAR:-default}
+// to fall back to default when VAR is unset or empty, so secrets such as the
+// repository DSN need not be committed (see expandEnv). Save writes the
+// resolved values.
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	data, err = expandEnv(data)
+	if err != nil {
+		return nil, err
+	}
+
 	// First pass: Check for unknown fields using strict unmarshal
 	var rawConfig map[string]interface{}
 	if err := yaml.Unmarshal(data, &rawConfig); err != nil {
@@ -109,11 +262,14 @@ func LoadConfig(path string) (*Config, error) {
 
 	// Validate known top-level keys
 	knownKeys := map[string]bool{
-		"gateway":    true,
-		"repository": true,
-		"engines":    true,
-		"roles":      true,
-		"tables":     true,
+		"gateway":        true,
+		"repository":     true,
+		"engines":        true,
+		"roles":          true,
+		"tables":         true,
+		"federation":     true,
+		"format_engines": true,
+		"safe_mode":      true,
 	}
 
 	for key := range rawConfig {
@@ -196,14 +352,198 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	cfg.canonicalize()
 	return &cfg, nil
 }
 
+// envReference matches an escaped "$${" or a ${VAR} or ${VAR:-default}
+// reference to an environment variable.
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv resolves the environment variable references in the values of
+// a YAML document against the process environment. References are only
+// resolved in values, not in comments, and a resolved value cannot change
+// the document's structure. "$${" escapes a literal "${".
+//
+// A reference to an unset variable without a default fails, naming every
+// such variable, rather than loading an empty value.
+func expandEnv(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	if doc.Kind == 0 {
+		return data, nil
+	}
+
+	unset := make(map[string]int) // variable -> line of its first reference
+	changed := false
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		for _, child := range node.Content {
+			walk(child)
+		}
+		if node.Kind != yaml.ScalarNode || !strings.Contains(node.Value, "${") {
+			return
+		}
+		node.Value = envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+			m := envReference.FindStringSubmatch(ref)
+			name, hasDefault := m[1], strings.Contains(ref, ":-")
+			value, ok := os.LookupEnv(name)
+			switch {
+			case hasDefault && value == "":
+				return m[2]
+			case !ok:
+				if _, seen := unset[name]; !seen {
+					unset[name] = node.Line
+				}
+			}
+			return value
+		})
+		// Let a resolved plain value take its own type, e.g. a port number
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+		changed = true
+	}
+	walk(&doc)
+
+	if len(unset) > 0 {
+		names := make([]string, 0, len(unset))
+		for name := range unset {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		refs := make([]string, len(names))
+		for i, name := range names {
+			refs[i] = fmt.Sprintf("${%s} (line %d)", name, unset[name])
+		}
+		return nil, errors.NewBootstrapError(
+			"config references unset environment variables",
+			fmt.Sprintf("%s not set and without a default", strings.Join(refs, ", ")),
+			fmt.Sprintf("set %s, or give a default as ${%s:-default}", strings.Join(names, ", "), names[0]),
+		)
+	}
+	if !changed {
+		return data, nil
+	}
+	return yaml.Marshal(&doc)
+}
+
+// canonicalize rewrites capabilities and constraints in canonical uppercase
+// form, so that "read" and "Read" are stored and saved as "READ".
+// Values that do not parse are left unchanged for validation to report.
+func (c *Config) canonicalize() {
+	for name, engineCfg := range c.Engines {
+		engineCfg.Capabilities = canonicalCapabilities(engineCfg.Capabilities)
+		c.Engines[name] = engineCfg
+	}
+	for name, tableCfg := range c.Tables {
+		tableCfg.Capabilities = canonicalCapabilities(tableCfg.Capabilities)
+		for i, conStr := range tableCfg.Constraints {
+			if con, err := capabilities.ParseConstraint(conStr); err == nil {
+				tableCfg.Constraints[i] = con.String()
+			}
+		}
+		c.Tables[name] = tableCfg
+	}
+	for _, roleCfg := range c.Roles {
+		for table, caps := range roleCfg.Tables {
+			roleCfg.Tables[table] = canonicalCapabilities(caps)
+		}
+	}
+}
+
+// canonicalCapabilities canonicalizes a list of capability strings in place.
+func canonicalCapabilities(caps []string) []string {
+	for i, capStr := range caps {
+		if cap, err := capabilities.ParseCapability(capStr); err == nil {
+			caps[i] = cap.String()
+		}
+	}
+	return caps
+}
+
 // Validate performs dry-run validation of the configuration.
 // Per phase-5-spec.md §2: "bootstrap validate performs dry-run invariant checks"
 func (c *Config) Validate() error {
+	// Check engine endpoints are well-formed, so a typo fails here rather
+	// than at the first query routed to the engine
+	engineNames := make([]string, 0, len(c.Engines))
+	for name := range c.Engines {
+		engineNames = append(engineNames, name)
+	}
+	sort.Strings(engineNames)
+	for _, name := range engineNames {
+		if err := validateEngineEndpoint(name, c.Engines[name].Endpoint); err != nil {
+			return err
+		}
+		for _, op := range c.Engines[name].PushdownOperators {
+			if !pushdownOperators[strings.ToUpper(op)] {
+				return fmt.Errorf("engine '%s': unknown pushdown operator '%s'", name, op)
+			}
+		}
+	}
+
+	if c.Federation.MaxEnginesPerQuery < 0 {
+		return fmt.Errorf("federation: max_engines_per_query must not be negative, got %d", c.Federation.MaxEnginesPerQuery)
+	}
+	if c.Federation.MaxParallelism < 0 {
+		return fmt.Errorf("federation: max_parallelism must not be negative, got %d", c.Federation.MaxParallelism)
+	}
+	if _, err := c.MaxQueryMemoryBytes(); err != nil {
+		return err
+	}
+	if _, err := c.DuplicateColumnPolicy(); err != nil {
+		return err
+	}
+	if _, err := c.JoinKeyCoercion(); err != nil {
+		return err
+	}
+	if _, err := c.CartesianJoinPolicy(); err != nil {
+		return err
+	}
+	if _, err := c.CostTierLimits(); err != nil {
+		return err
+	}
+	if _, err := c.SubQueryTimeout(); err != nil {
+		return err
+	}
+	if _, err := c.LogFormat(); err != nil {
+		return err
+	}
+	if _, err := c.ReplicaLag(); err != nil {
+		return err
+	}
+	if _, err := c.QueryPolicies(); err != nil {
+		return err
+	}
+
+	// Check format engine overrides name a known format and engine
+	if _, err := c.FormatEngineMap(); err != nil {
+		return err
+	}
+
+	// Check safe-mode engine allowlist names configured engines
+	for _, engine := range c.SafeMode.AllowedEngines {
+		if _, ok := c.Engines[engine]; !ok {
+			return fmt.Errorf("safe_mode: allowed_engines references unknown engine '%s'", engine)
+		}
+	}
+	safeMode := c.SafeModePolicy()
+
 	// Check engine references in tables
 	for tableName, tableCfg := range c.Tables {
+		vt := tableCfg.virtualTable(tableName)
+		if vt.FormatOverride != "" && !vt.FormatOverride.IsValid() {
+			return fmt.Errorf("table '%s': invalid format_override '%s' (valid: %v)", tableName, tableCfg.FormatOverride, tables.AllFormats())
+		}
+		if err := safeMode.CheckTable(vt); err != nil {
+			return fmt.Errorf("table '%s': %w", tableName, err)
+		}
 		for _, src := range tableCfg.Sources {
 			// First check if engine exists
 			engineCfg, ok := c.Engines[src.Engine]
@@ -220,11 +560,13 @@ func (c *Config) Validate() error {
 	// Check role references
 	for roleName, roleCfg := range c.Roles {
 		for tableName, caps := range roleCfg.Tables {
-			// Table must exist or be a wildcard pattern
-			if !strings.Contains(tableName, "*") {
-				if _, ok := c.Tables[tableName]; !ok {
-					return fmt.Errorf("role '%s': references unknown table '%s'", roleName, tableName)
+			// Table must exist or be a schema wildcard such as "analytics.*"
+			if strings.Contains(tableName, "*") {
+				if !auth.IsSchemaWildcard(tableName) {
+					return fmt.Errorf("role '%s': invalid wildcard '%s' (expected schema.*)", roleName, tableName)
 				}
+			} else if _, ok := c.Tables[tableName]; !ok {
+				return fmt.Errorf("role '%s': references unknown table '%s'", roleName, tableName)
 			}
 			// Capabilities must be valid
 			for _, capStr := range caps {
@@ -239,6 +581,264 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// FormatEngineMap returns the configured format → engine overrides in the
+// form accepted by catalog.SetFormatEngines.
+func (c *Config) FormatEngineMap() (map[catalog.TableFormat]string, error) {
+	formats := make([]string, 0, len(c.FormatEngines))
+	for format := range c.FormatEngines {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	mapping := make(map[catalog.TableFormat]string, len(formats))
+	for _, name := range formats {
+		format, err := catalog.ParseTableFormat(name)
+		if err != nil {
+			return nil, fmt.Errorf("format_engines: %v", err)
+		}
+		engine := c.FormatEngines[name]
+		if _, ok := c.Engines[engine]; !ok {
+			return nil, fmt.Errorf("format_engines: format '%s' references unknown engine '%s'", name, engine)
+		}
+		mapping[format] = engine
+	}
+	return mapping, nil
+}
+
+// MaxQueryMemoryBytes returns the configured per-query memory budget in
+// bytes, in the form accepted by FederatedExecutor.SetMaxQueryMemory.
+// Zero selects the default.
+func (c *Config) MaxQueryMemoryBytes() (int64, error) {
+	n, err := federation.ParseMemorySize(c.Federation.MaxQueryMemory)
+	if err != nil {
+		return 0, fmt.Errorf("federation: max_query_memory: %v", err)
+	}
+	return n, nil
+}
+
+// DuplicateColumnPolicy returns the configured duplicate output column
+// policy, in the form accepted by FederatedExecutor.SetDuplicateColumnPolicy.
+func (c *Config) DuplicateColumnPolicy() (federation.DuplicateColumnPolicy, error) {
+	policy, err := federation.ParseDuplicateColumnPolicy(c.Federation.DuplicateColumns)
+	if err != nil {
+		return "", fmt.Errorf("federation: duplicate_columns: %v", err)
+	}
+	return policy, nil
+}
+
+// JoinKeyCoercion returns the configured join key coercion policy, in the
+// form accepted by FederatedExecutor.SetJoinKeyCoercion.
+func (c *Config) JoinKeyCoercion() (federation.JoinKeyCoercion, error) {
+	policy, err := federation.ParseJoinKeyCoercion(c.Federation.JoinKeyCoercion)
+	if err != nil {
+		return "", fmt.Errorf("federation: join_key_coercion: %v", err)
+	}
+	return policy, nil
+}
+
+// CartesianJoinPolicy returns the configured cartesian join policy, in the
+// form accepted by FederatedExecutor.SetCartesianJoinPolicy.
+func (c *Config) CartesianJoinPolicy() (federation.CartesianJoinPolicy, error) {
+	policy, err := federation.ParseCartesianJoinPolicy(c.Federation.CartesianJoins)
+	if err != nil {
+		return "", fmt.Errorf("federation: cartesian_joins: %v", err)
+	}
+	return policy, nil
+}
+
+// CostTierLimits returns the configured cost tier limits, in the form
+// accepted by federation.NewCostTierLimiter.
+func (c *Config) CostTierLimits() (federation.CostTierLimits, error) {
+	cfg := c.Federation.CostTiers
+	limits := federation.DefaultCostTierLimits()
+
+	durations := []struct {
+		key   string
+		value string
+		dest  *time.Duration
+	}{
+		{"medium_threshold", cfg.MediumThreshold, &limits.MediumThreshold},
+		{"expensive_threshold", cfg.ExpensiveThreshold, &limits.ExpensiveThreshold},
+		{"queue_timeout", cfg.QueueTimeout, &limits.QueueTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil || duration < 0 {
+			return federation.CostTierLimits{}, fmt.Errorf("federation: cost_tiers.%s: invalid duration %q", d.key, d.value)
+		}
+		*d.dest = duration
+	}
+
+	switch {
+	case cfg.MaxConcurrentMedium > 0:
+		limits.MaxMedium = cfg.MaxConcurrentMedium
+	case cfg.MaxConcurrentMedium < 0:
+		limits.MaxMedium = 0
+	}
+	switch {
+	case cfg.MaxConcurrentExpensive > 0:
+		limits.MaxExpensive = cfg.MaxConcurrentExpensive
+	case cfg.MaxConcurrentExpensive < 0:
+		limits.MaxExpensive = 0
+	}
+
+	if _, err := federation.NewCostTierLimiter(limits); err != nil {
+		return federation.CostTierLimits{}, fmt.Errorf("federation: cost_tiers: %v", err)
+	}
+	return limits, nil
+}
+
+// SubQueryTimeout returns the configured sub-query timeout, in the form
+// accepted by FederatedExecutor.SetSubQueryTimeout. Zero is no timeout.
+func (c *Config) SubQueryTimeout() (time.Duration, error) {
+	if c.Federation.SubQueryTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(c.Federation.SubQueryTimeout)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("federation: sub_query_timeout: invalid duration %q", c.Federation.SubQueryTimeout)
+	}
+	return timeout, nil
+}
+
+// LogFormat returns the configured query log format, in the form accepted by
+// observability.NewQueryLogger.
+func (c *Config) LogFormat() (observability.LogFormat, error) {
+	format, err := observability.ParseLogFormat(c.Gateway.LogFormat)
+	if err != nil {
+		return "", fmt.Errorf("gateway: log_format: %v", err)
+	}
+	return format, nil
+}
+
+// ReplicaLag returns the configured read replica lag window, in the form
+// accepted by storage.NewReadReplica. Zero selects the default.
+func (c *Config) ReplicaLag() (time.Duration, error) {
+	if c.Repository.Postgres.ReplicaLag == "" {
+		return 0, nil
+	}
+	lag, err := time.ParseDuration(c.Repository.Postgres.ReplicaLag)
+	if err != nil || lag < 0 {
+		return 0, fmt.Errorf("repository: postgres.replica_lag: invalid duration %q", c.Repository.Postgres.ReplicaLag)
+	}
+	return lag, nil
+}
+
+// QueryPolicies returns the configured default LIMIT and query timeout,
+// with the overrides of each role.
+func (c *Config) QueryPolicies() (*auth.QueryPolicies, error) {
+	defaults := auth.QueryPolicy{DefaultLimit: c.Gateway.DefaultLimit}
+	if defaults.DefaultLimit < 0 {
+		return nil, fmt.Errorf("gateway: default_limit must not be negative, got %d", defaults.DefaultLimit)
+	}
+	if c.Gateway.QueryTimeout != "" {
+		timeout, err := time.ParseDuration(c.Gateway.QueryTimeout)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("gateway: query_timeout: invalid duration %q", c.Gateway.QueryTimeout)
+		}
+		defaults.Timeout = timeout
+	}
+
+	policies := auth.NewQueryPolicies(defaults)
+	for roleName, roleCfg := range c.Roles {
+		if roleCfg.DefaultLimit == 0 && roleCfg.QueryTimeout == "" {
+			continue
+		}
+		override := auth.RoleQueryPolicy{DefaultLimit: roleCfg.DefaultLimit}
+		switch roleCfg.QueryTimeout {
+		case "":
+		case noQueryTimeout:
+			override.Timeout = -1
+		default:
+			timeout, err := time.ParseDuration(roleCfg.QueryTimeout)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("role '%s': query_timeout: invalid duration %q (or %q)", roleName, roleCfg.QueryTimeout, noQueryTimeout)
+			}
+			override.Timeout = timeout
+		}
+		policies.SetRolePolicy(roleName, override)
+	}
+	return policies, nil
+}
+
+// SafeModePolicy returns the configured safe-mode policy, or nil when safe
+// mode is disabled.
+func (c *Config) SafeModePolicy() *tables.SafeMode {
+	if !c.SafeMode.Enabled {
+		return nil
+	}
+	return &tables.SafeMode{AllowedEngines: c.SafeMode.AllowedEngines}
+}
+
+// virtualTable returns the sources of the table configuration as a virtual
+// table, for policy checks before the table is registered.
+func (t TableConfig) virtualTable(name string) *tables.VirtualTable {
+	vt := &tables.VirtualTable{
+		Name:           name,
+		FormatOverride: tables.StorageFormat(strings.ToUpper(t.FormatOverride)),
+	}
+	for _, src := range t.Sources {
+		vt.Sources = append(vt.Sources, tables.PhysicalSource{
+			Format:   tables.StorageFormat(strings.ToUpper(src.Format)),
+			Location: src.Location,
+			Engine:   src.Engine,
+		})
+	}
+	return vt
+}
+
+// pushdownOperators are the predicate operators that can be listed in
+// EngineConfig.PushdownOperators.
+var pushdownOperators = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+	"LIKE": true, "IN": true,
+}
+
+// engineEndpointSchemes lists the URL schemes each known engine accepts.
+// Engines not listed accept any scheme.
+var engineEndpointSchemes = map[string][]string{
+	"trino":     {"http", "https"},
+	"spark":     {"thrift", "hive2"},
+	"snowflake": {"https"},
+	"bigquery":  {"https"},
+	"redshift":  {"postgres", "postgresql"},
+}
+
+// validateEngineEndpoint checks that an engine endpoint is an absolute URL
+// with a host and a scheme the engine can be reached over.
+// An empty endpoint is allowed (e.g. embedded DuckDB).
+func validateEngineEndpoint(engine, endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("engine '%s': invalid endpoint '%s': %v", engine, endpoint, err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("engine '%s': invalid endpoint '%s': must be an absolute URL such as scheme://host:port", engine, endpoint)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("engine '%s': invalid endpoint '%s': missing host", engine, endpoint)
+	}
+
+	schemes, ok := engineEndpointSchemes[engine]
+	if !ok {
+		return nil
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(parsed.Scheme, scheme) {
+			return nil
+		}
+	}
+	return fmt.Errorf("engine '%s': invalid endpoint '%s': scheme must be one of %s",
+		engine, endpoint, strings.Join(schemes, ", "))
+}
+
 // IsValidated returns true if Validate() has been called successfully.
 func (c *Config) IsValidated() bool {
 	return c.validated
@@ -296,8 +896,12 @@ func (c *Config) ApplyToRepository(ctx context.Context, repo Repository) error {
 // tableConfigToVirtualTable converts a TableConfig to a VirtualTable.
 func (c *Config) tableConfigToVirtualTable(name string, cfg TableConfig) *tables.VirtualTable {
 	vt := &tables.VirtualTable{
-		Name:        name,
-		Description: cfg.Description,
+		Name:               name,
+		Description:        cfg.Description,
+		RequireSchema:      cfg.RequireSchema,
+		Deprecated:         cfg.Deprecated,
+		DeprecationMessage: cfg.DeprecationMessage,
+		FormatOverride:     tables.StorageFormat(strings.ToUpper(cfg.FormatOverride)),
 	}
 
 	// Convert sources
@@ -325,7 +929,9 @@ func (c *Config) tableConfigToVirtualTable(name string, cfg TableConfig) *tables
 }
 
 // Save saves the configuration to a YAML file.
+// Capabilities and constraints are written in canonical uppercase form.
 func (c *Config) Save(path string) error {
+	c.canonicalize()
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
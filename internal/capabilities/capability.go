@@ -89,6 +89,8 @@ func (c Capability) String() string {
 }
 
 // ParseCapability parses a string into a Capability.
+// Parsing is case-insensitive ("read", "Read" and "READ" are equivalent) and
+// the result is always the canonical uppercase form.
 // Returns an error if the string is not a valid capability.
 func ParseCapability(s string) (Capability, error) {
 	c := Capability(strings.ToUpper(strings.TrimSpace(s)))
@@ -108,6 +110,11 @@ const (
 
 	// ConstraintSnapshotConsistent requires all reads to use a consistent snapshot.
 	ConstraintSnapshotConsistent Constraint = "SNAPSHOT_CONSISTENT"
+
+	// ConstraintMixedSnapshots allows the table to be read AS OF a different
+	// timestamp than the other tables of a query. SNAPSHOT_CONSISTENT takes
+	// precedence.
+	ConstraintMixedSnapshots Constraint = "MIXED_SNAPSHOTS"
 )
 
 // AllConstraints returns all valid constraints.
@@ -115,6 +122,7 @@ func AllConstraints() []Constraint {
 	return []Constraint{
 		ConstraintReadOnly,
 		ConstraintSnapshotConsistent,
+		ConstraintMixedSnapshots,
 	}
 }
 
@@ -134,6 +142,8 @@ func (c Constraint) String() string {
 }
 
 // ParseConstraint parses a string into a Constraint.
+// Parsing is case-insensitive and the result is always the canonical
+// uppercase form.
 // Returns an error if the string is not a valid constraint.
 func ParseConstraint(s string) (Constraint, error) {
 	c := Constraint(strings.ToUpper(strings.TrimSpace(s)))
@@ -55,10 +55,13 @@ type TableMetadata struct {
 	Format     TableFormat       `json:"format"`
 	Location   string            `json:"location"` // s3://bucket/path or hdfs://path
 	Columns    []ColumnMetadata  `json:"columns"`
-	Partitions []string          `json:"partitions"`
+	Partitions []string          `json:"partitions"` // partition column names
 	Properties map[string]string `json:"properties"`
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
+
+	// PartitionCount is the number of partitions, or 0 if unknown.
+	PartitionCount int64 `json:"partition_count,omitempty"`
 }
 
 // FullName returns the schema-qualified table name.
@@ -4,7 +4,9 @@
 package catalog
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 )
 
 // DetectFormatFromProperties detects table format from metadata properties.
@@ -158,23 +160,64 @@ func DetectFormatFromLocationHint(location string) TableFormat {
 	return FormatUnknown
 }
 
-// SelectEngine chooses the query engine based on table format.
+// defaultFormatEngines is the compiled-in format → engine mapping.
 // Per phase-7-spec.md §4.3: Engine selection based on format.
+var defaultFormatEngines = map[TableFormat]string{
+	FormatIceberg: "trino",  // Trino has best Iceberg support
+	FormatDelta:   "trino",  // Trino Delta connector
+	FormatHudi:    "trino",  // Trino Hudi connector
+	FormatParquet: "duckdb", // DuckDB is fast for Parquet
+	FormatORC:     "trino",  // DuckDB has limited ORC support
+	FormatCSV:     "duckdb", // DuckDB is fast for CSV
+}
+
+// formatEngines holds the deployment's format → engine overrides.
+var (
+	formatEnginesMu sync.RWMutex
+	formatEngines   map[TableFormat]string
+)
+
+// SetFormatEngines overrides the engine chosen for the listed formats, e.g.
+// Iceberg → duckdb for deployments without Trino. Formats not listed keep
+// their default. A nil or empty map restores the defaults.
+func SetFormatEngines(overrides map[TableFormat]string) {
+	copied := make(map[TableFormat]string, len(overrides))
+	for format, engine := range overrides {
+		copied[format] = engine
+	}
+
+	formatEnginesMu.Lock()
+	defer formatEnginesMu.Unlock()
+	formatEngines = copied
+}
+
+// ConfiguredEngine returns the engine configured for format by
+// SetFormatEngines, if any.
+func ConfiguredEngine(format TableFormat) (string, bool) {
+	formatEnginesMu.RLock()
+	defer formatEnginesMu.RUnlock()
+	engine, ok := formatEngines[TableFormat(strings.ToLower(string(format)))]
+	return engine, ok
+}
+
+// ParseTableFormat parses a table format name case-insensitively.
+func ParseTableFormat(s string) (TableFormat, error) {
+	format := TableFormat(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := defaultFormatEngines[format]; !ok {
+		return FormatUnknown, fmt.Errorf("unknown table format %q", s)
+	}
+	return format, nil
+}
+
+// SelectEngine chooses the query engine based on table format.
+// A mapping configured with SetFormatEngines takes precedence over the
+// compiled-in defaults; unknown formats fall back to duckdb.
 func SelectEngine(format TableFormat) string {
-	switch format {
-	case FormatIceberg:
-		return "trino" // Trino has best Iceberg support
-	case FormatDelta:
-		return "trino" // Trino Delta connector
-	case FormatHudi:
-		return "trino" // Trino Hudi connector
-	case FormatParquet:
-		return "duckdb" // DuckDB is fast for Parquet
-	case FormatORC:
-		return "trino" // DuckDB has limited ORC support
-	case FormatCSV:
-		return "duckdb" // DuckDB is fast for CSV
-	default:
-		return "duckdb" // Default fallback
+	if engine, ok := ConfiguredEngine(format); ok {
+		return engine
+	}
+	if engine, ok := defaultFormatEngines[format]; ok {
+		return engine
 	}
+	return "duckdb" // Default fallback
 }
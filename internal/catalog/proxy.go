@@ -0,0 +1,138 @@
+package catalog
+
+import (
+	"context"
+	"sort"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// DatabaseFilter selects the databases exposed from a catalog.
+// Exclude takes precedence over Include; an empty Include allows all.
+type DatabaseFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Allows reports whether the filter exposes database.
+func (f DatabaseFilter) Allows(database string) bool {
+	for _, excluded := range f.Exclude {
+		if excluded == database {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, included := range f.Include {
+		if included == database {
+			return true
+		}
+	}
+	return false
+}
+
+// Proxy is a read-through view of a catalog for clients browsing it through
+// Canonic (the gateway's /catalog endpoints). Every call reaches the
+// underlying catalog; results are filtered and normalized so that clients
+// see the same shape regardless of the catalog type.
+type Proxy struct {
+	catalog Catalog
+	filter  DatabaseFilter
+}
+
+// NewProxy creates a proxy over cat exposing the databases allowed by filter.
+func NewProxy(cat Catalog, filter DatabaseFilter) *Proxy {
+	return &Proxy{catalog: cat, filter: filter}
+}
+
+// Name returns the underlying catalog's name.
+func (p *Proxy) Name() string {
+	return p.catalog.Name()
+}
+
+// ListDatabases returns the exposed databases in name order.
+func (p *Proxy) ListDatabases(ctx context.Context) ([]string, error) {
+	databases, err := p.catalog.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exposed := make([]string, 0, len(databases))
+	for _, db := range databases {
+		if p.filter.Allows(db) {
+			exposed = append(exposed, db)
+		}
+	}
+	sort.Strings(exposed)
+	return exposed, nil
+}
+
+// ListTables returns the tables of an exposed database in name order.
+// It returns ErrCatalogObjectNotFound if the database is filtered out.
+func (p *Proxy) ListTables(ctx context.Context, database string) ([]TableInfo, error) {
+	if !p.filter.Allows(database) {
+		return nil, errors.NewCatalogObjectNotFound(database)
+	}
+
+	tables, err := p.catalog.ListTables(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]TableInfo, len(tables))
+	for i, t := range tables {
+		if t.Database == "" {
+			t.Database = database
+		}
+		t.Format = normalizeFormat(t.Format)
+		normalized[i] = t
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		return normalized[i].Name < normalized[j].Name
+	})
+	return normalized, nil
+}
+
+// GetTable returns the metadata of a table in an exposed database.
+// It returns ErrCatalogObjectNotFound if the database is filtered out or the
+// catalog has no such table.
+func (p *Proxy) GetTable(ctx context.Context, database, table string) (*TableMetadata, error) {
+	if !p.filter.Allows(database) {
+		return nil, errors.NewCatalogObjectNotFound(database + "." + table)
+	}
+
+	meta, err := p.catalog.GetTable(ctx, database, table)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, errors.NewCatalogObjectNotFound(database + "." + table)
+	}
+
+	normalized := *meta
+	if normalized.Database == "" {
+		normalized.Database = database
+	}
+	if normalized.Name == "" {
+		normalized.Name = table
+	}
+	normalized.Format = normalizeFormat(normalized.Format)
+	if normalized.Columns == nil {
+		normalized.Columns = []ColumnMetadata{}
+	}
+	if normalized.Partitions == nil {
+		normalized.Partitions = []string{}
+	}
+	if normalized.Properties == nil {
+		normalized.Properties = map[string]string{}
+	}
+	return &normalized, nil
+}
+
+// normalizeFormat maps catalog-reported formats onto the known TableFormat
+// values, reporting anything else as FormatUnknown.
+func normalizeFormat(format TableFormat) TableFormat {
+	normalized, _ := ParseTableFormat(string(format))
+	return normalized
+}
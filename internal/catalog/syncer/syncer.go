@@ -0,0 +1,468 @@
+// Package syncer registers the tables of external catalogs as virtual tables.
+//
+// Per phase-7-spec.md §4: "canonic catalog sync" discovers tables from Hive
+// Metastore, AWS Glue or Unity Catalog and keeps them registered.
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// SyncRepository is the table storage a Syncer writes to.
+// storage.TableRepository satisfies it.
+type SyncRepository interface {
+	List(ctx context.Context) ([]*tables.VirtualTable, error)
+	Create(ctx context.Context, table *tables.VirtualTable) error
+	Update(ctx context.Context, table *tables.VirtualTable) error
+	Delete(ctx context.Context, name string) error
+}
+
+// SyncAction is what a sync does to one table.
+type SyncAction string
+
+const (
+	// SyncCreate registers a catalog table that is not yet registered.
+	SyncCreate SyncAction = "create"
+
+	// SyncUpdate updates the sources or columns of a registered table to
+	// match the catalog.
+	SyncUpdate SyncAction = "update"
+
+	// SyncRemove deletes a previously synced table that is no longer in the
+	// catalog. Only done with SyncOptions.Prune.
+	SyncRemove SyncAction = "remove"
+
+	// SyncUnchanged leaves a registered table that matches the catalog.
+	SyncUnchanged SyncAction = "unchanged"
+
+	// SyncSkip leaves a table the sync cannot or may not change, such as a
+	// table registered outside catalog sync or one in an unsupported format.
+	SyncSkip SyncAction = "skip"
+)
+
+// SyncChange is the planned or applied change to one table.
+type SyncChange struct {
+	Table  string     `json:"table"`
+	Action SyncAction `json:"action"`
+
+	// Details describes what differs for updates, e.g.
+	// "location: s3://a → s3://b", or why a table is skipped.
+	Details []string `json:"details,omitempty"`
+
+	// Error is set when applying the change failed.
+	Error string `json:"error,omitempty"`
+}
+
+// SyncReport is the result of a catalog sync: the change to each table,
+// in table name order. In a dry run the changes are planned but not applied.
+type SyncReport struct {
+	Catalog string       `json:"catalog"`
+	DryRun  bool         `json:"dry_run"`
+	Changes []SyncChange `json:"changes"`
+
+	// Errors lists databases and tables that could not be read from the
+	// catalog; they are neither changed nor removed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Tables returns the names of the tables with an action.
+func (r *SyncReport) Tables(action SyncAction) []string {
+	var names []string
+	for _, change := range r.Changes {
+		if change.Action == action {
+			names = append(names, change.Table)
+		}
+	}
+	return names
+}
+
+// Failed returns the number of changes that failed to apply plus the
+// catalog read errors.
+func (r *SyncReport) Failed() int {
+	failed := len(r.Errors)
+	for _, change := range r.Changes {
+		if change.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// SyncOptions configures a catalog sync.
+type SyncOptions struct {
+	// Database limits the sync to one database (empty = all).
+	Database string
+
+	// DryRun reports the changes without writing to the repository.
+	DryRun bool
+
+	// Force also updates tables registered outside catalog sync, which are
+	// otherwise skipped.
+	Force bool
+
+	// Prune removes tables previously synced from the catalog that it no
+	// longer lists. Databases that fail to list are never pruned.
+	Prune bool
+
+	// Concurrency is the number of catalog requests made at once while
+	// discovering tables. Zero selects DefaultSyncConcurrency.
+	Concurrency int
+}
+
+// DefaultSyncConcurrency is the default SyncOptions.Concurrency. It keeps
+// large catalogs fast to sync without flooding the catalog service.
+const DefaultSyncConcurrency = 8
+
+// Syncer registers the tables of an external catalog as virtual tables.
+// Per phase-7-spec.md: tables are discovered from the catalog and their
+// format, location and columns kept in sync.
+//
+// Tables created by a Syncer are marked by their description (see
+// SyncedDescription). Updates replace only the sources and columns of a
+// table, so capabilities, constraints and descriptions edited after the
+// first sync are kept.
+type Syncer struct {
+	cat  catalog.Catalog
+	repo SyncRepository
+}
+
+// NewSyncer creates a syncer from cat into repo.
+func NewSyncer(cat catalog.Catalog, repo SyncRepository) *Syncer {
+	return &Syncer{cat: cat, repo: repo}
+}
+
+// SyncedDescription is the description of tables synced from a catalog.
+func SyncedDescription(catalogName string) string {
+	return fmt.Sprintf("Synced from %s catalog", catalogName)
+}
+
+// Sync compares the catalog with the repository and, unless opts.DryRun is
+// set, applies the changes. Failures to read or write individual tables are
+// recorded in the report; an error is returned only when the sync cannot
+// start, such as when the catalog or repository is unreachable or
+// opts.Database does not exist.
+func (s *Syncer) Sync(ctx context.Context, opts SyncOptions) (*SyncReport, error) {
+	databases, err := s.cat.ListDatabases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases of %s catalog: %w", s.cat.Name(), err)
+	}
+	if opts.Database != "" {
+		if !containsDatabase(databases, opts.Database) {
+			return nil, fmt.Errorf("database %q not found in %s catalog", opts.Database, s.cat.Name())
+		}
+		databases = []string{opts.Database}
+	}
+
+	registered, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registered tables: %w", err)
+	}
+	existing := make(map[string]*tables.VirtualTable, len(registered))
+	for _, vt := range registered {
+		existing[vt.Name] = vt
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultSyncConcurrency
+	}
+
+	// Discover every table first; the catalog is read in parallel
+	listings := s.listTables(ctx, databases, concurrency)
+	tableMetas := s.getTables(ctx, listings, concurrency)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("sync of %s catalog canceled: %w", s.cat.Name(), err)
+	}
+
+	report := &SyncReport{Catalog: s.cat.Name(), DryRun: opts.DryRun}
+	listed := make(map[string]bool)                  // databases listed successfully
+	seen := make(map[string]bool)                    // tables listed in the catalog
+	metas := make(map[string]*catalog.TableMetadata) // metadata of readable tables
+
+	for _, listing := range listings {
+		if listing.err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", listing.database, listing.err))
+			continue
+		}
+		listed[listing.database] = true
+	}
+	for _, discovered := range tableMetas {
+		seen[discovered.name] = true
+		if discovered.err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", discovered.name, discovered.err))
+			continue
+		}
+		metas[discovered.name] = discovered.meta
+		report.Changes = append(report.Changes, s.planTable(discovered.name, discovered.meta, existing[discovered.name], opts))
+	}
+
+	if opts.Prune {
+		synced := SyncedDescription(s.cat.Name())
+		for name, vt := range existing {
+			db, _, _ := strings.Cut(name, ".")
+			if listed[db] && !seen[name] && vt.Description == synced {
+				report.Changes = append(report.Changes, SyncChange{Table: name, Action: SyncRemove})
+			}
+		}
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool {
+		return report.Changes[i].Table < report.Changes[j].Table
+	})
+
+	if !opts.DryRun {
+		s.apply(ctx, report, existing, metas)
+	}
+	return report, nil
+}
+
+// databaseListing is the result of listing the tables of one database.
+type databaseListing struct {
+	database string
+	tables   []catalog.TableInfo
+	err      error
+}
+
+// discoveredTable is the result of reading one table from the catalog.
+type discoveredTable struct {
+	database string
+	table    string
+	name     string
+	meta     *catalog.TableMetadata
+	err      error
+}
+
+// listTables lists the tables of each database, making at most concurrency
+// catalog requests at once. Results are in database order.
+func (s *Syncer) listTables(ctx context.Context, databases []string, concurrency int) []databaseListing {
+	listings := make([]databaseListing, len(databases))
+	forEachBounded(ctx, len(databases), concurrency, func(i int) {
+		infos, err := s.cat.ListTables(ctx, databases[i])
+		listings[i] = databaseListing{database: databases[i], tables: infos, err: err}
+	})
+	return listings
+}
+
+// getTables reads the metadata of every listed table, making at most
+// concurrency catalog requests at once. A failure is recorded on its table
+// and does not stop the others. Results are in listing order.
+func (s *Syncer) getTables(ctx context.Context, listings []databaseListing, concurrency int) []discoveredTable {
+	var discovered []discoveredTable
+	for _, listing := range listings {
+		for _, info := range listing.tables {
+			discovered = append(discovered, discoveredTable{
+				database: listing.database,
+				table:    info.Name,
+				name:     listing.database + "." + info.Name,
+			})
+		}
+	}
+
+	forEachBounded(ctx, len(discovered), concurrency, func(i int) {
+		meta, err := s.cat.GetTable(ctx, discovered[i].database, discovered[i].table)
+		if err == nil && meta == nil {
+			err = fmt.Errorf("table not found")
+		}
+		discovered[i].meta, discovered[i].err = meta, err
+	})
+	return discovered
+}
+
+// forEachBounded calls fn for each index in [0, n) on at most workers
+// goroutines at once and waits for the calls to return. Once ctx is done no
+// further calls are started.
+func forEachBounded(ctx context.Context, n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// planTable decides the change for one catalog table.
+func (s *Syncer) planTable(name string, meta *catalog.TableMetadata, current *tables.VirtualTable, opts SyncOptions) SyncChange {
+	change := SyncChange{Table: name}
+	sources := syncedSources(meta)
+
+	if !sources[0].Format.IsValid() {
+		change.Action = SyncSkip
+		change.Details = []string{fmt.Sprintf("unsupported format %s", strings.ToLower(string(sources[0].Format)))}
+		return change
+	}
+	if meta.Location == "" {
+		change.Action = SyncSkip
+		change.Details = []string{"catalog reports no location"}
+		return change
+	}
+
+	if current == nil {
+		change.Action = SyncCreate
+		change.Details = []string{fmt.Sprintf("%s at %s on %s", sources[0].Format, sources[0].Location, sources[0].Engine)}
+		return change
+	}
+	if current.Description != SyncedDescription(s.cat.Name()) && !opts.Force {
+		change.Action = SyncSkip
+		change.Details = []string{"registered outside catalog sync (use force to update)"}
+		return change
+	}
+
+	change.Details = append(diffSources(current.Sources, sources), diffColumns(current.Columns, syncedColumns(meta))...)
+	if len(change.Details) == 0 {
+		change.Action = SyncUnchanged
+	} else {
+		change.Action = SyncUpdate
+	}
+	return change
+}
+
+// apply writes the changes of a report to the repository, recording
+// failures on each change.
+func (s *Syncer) apply(ctx context.Context, report *SyncReport, existing map[string]*tables.VirtualTable, metas map[string]*catalog.TableMetadata) {
+	for i := range report.Changes {
+		change := &report.Changes[i]
+
+		var err error
+		switch change.Action {
+		case SyncCreate:
+			err = s.create(ctx, change.Table, metas[change.Table])
+		case SyncUpdate:
+			err = s.update(ctx, existing[change.Table], metas[change.Table])
+		case SyncRemove:
+			err = s.repo.Delete(ctx, change.Table)
+		}
+		if err != nil {
+			change.Error = err.Error()
+		}
+	}
+}
+
+// create registers a catalog table, readable only.
+func (s *Syncer) create(ctx context.Context, name string, meta *catalog.TableMetadata) error {
+	vt := &tables.VirtualTable{
+		Name:         name,
+		Description:  SyncedDescription(s.cat.Name()),
+		Sources:      syncedSources(meta),
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Columns:      syncedColumns(meta),
+	}
+	if err := vt.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, vt)
+}
+
+// update replaces the sources and columns of a registered table.
+func (s *Syncer) update(ctx context.Context, current *tables.VirtualTable, meta *catalog.TableMetadata) error {
+	vt := *current
+	vt.Sources = syncedSources(meta)
+	vt.Columns = syncedColumns(meta)
+	if err := vt.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, &vt)
+}
+
+// syncedSources returns the sources of a catalog table. Formats are
+// normalized first, as some catalogs report them in upper case.
+func syncedSources(meta *catalog.TableMetadata) []tables.PhysicalSource {
+	format, _ := catalog.ParseTableFormat(string(meta.Format))
+	return []tables.PhysicalSource{{
+		Format:   tables.StorageFormat(strings.ToUpper(string(format))),
+		Location: meta.Location,
+		Engine:   catalog.SelectEngine(format),
+	}}
+}
+
+// syncedColumns returns the columns of a catalog table.
+func syncedColumns(meta *catalog.TableMetadata) []tables.Column {
+	columns := make([]tables.Column, 0, len(meta.Columns))
+	for _, col := range meta.Columns {
+		columns = append(columns, tables.Column{Name: col.Name, Type: col.Type, Nullable: col.Nullable})
+	}
+	return columns
+}
+
+// diffSources describes how the sources of a table differ from want.
+func diffSources(have, want []tables.PhysicalSource) []string {
+	if len(have) != len(want) {
+		return []string{fmt.Sprintf("sources: %d → %d", len(have), len(want))}
+	}
+	var diffs []string
+	for i := range want {
+		if have[i].Format != want[i].Format {
+			diffs = append(diffs, fmt.Sprintf("format: %s → %s", have[i].Format, want[i].Format))
+		}
+		if have[i].Location != want[i].Location {
+			diffs = append(diffs, fmt.Sprintf("location: %s → %s", have[i].Location, want[i].Location))
+		}
+		if have[i].Engine != want[i].Engine {
+			diffs = append(diffs, fmt.Sprintf("engine: %s → %s", have[i].Engine, want[i].Engine))
+		}
+	}
+	return diffs
+}
+
+// diffColumns describes how the columns of a table differ from want.
+func diffColumns(have, want []tables.Column) []string {
+	current := make(map[string]tables.Column, len(have))
+	for _, col := range have {
+		current[col.Name] = col
+	}
+
+	var diffs []string
+	for _, col := range want {
+		old, ok := current[col.Name]
+		delete(current, col.Name)
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("column added: %s %s", col.Name, col.Type))
+		case !strings.EqualFold(old.Type, col.Type):
+			diffs = append(diffs, fmt.Sprintf("column type: %s %s → %s", col.Name, old.Type, col.Type))
+		case old.Nullable != col.Nullable:
+			diffs = append(diffs, fmt.Sprintf("column nullable: %s %t → %t", col.Name, old.Nullable, col.Nullable))
+		}
+	}
+	for _, col := range have {
+		if _, removed := current[col.Name]; removed {
+			diffs = append(diffs, fmt.Sprintf("column removed: %s", col.Name))
+		}
+	}
+	return diffs
+}
+
+func containsDatabase(databases []string, database string) bool {
+	for _, db := range databases {
+		if db == database {
+			return true
+		}
+	}
+	return false
+}
@@ -303,6 +303,10 @@ func (c *CLI) newAuditCmd() *cobra.Command {
 }
 
 func (c *CLI) newAuditSummaryCmd() *cobra.Command {
+	var top int
+	var dimensions []string
+	var since, until string
+
 	cmd := &cobra.Command{
 		Use:   "summary",
 		Short: "Show audit summary",
@@ -313,23 +317,71 @@ Per phase-5-spec.md §4: canonic audit summary displays:
   - Top rejection reasons
   - Top queried tables
 
-No raw data is exposed.`,
+Use --by to rank other dimensions (rejection_reasons, tables, users,
+engines) and --top to change the length of each list.
+
+Use --since and --until to summarize the queries logged in a window. Each
+takes an RFC 3339 timestamp, a date (2006-01-02, midnight UTC) or a
+duration before now (24h).
+
+No raw data is exposed.
+
+Example:
+  canonic audit summary --top 10 --by users,engines
+  canonic audit summary --since 24h
+  canonic audit summary --since 2026-01-01 --until 2026-02-01`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.runAuditSummary()
+			opts := AuditSummaryOptions{TopN: top, Dimensions: dimensions}
+			now := time.Now()
+			var err error
+			if opts.Since, err = parseAuditTime("since", since, now); err != nil {
+				return err
+			}
+			if opts.Until, err = parseAuditTime("until", until, now); err != nil {
+				return err
+			}
+			if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Until.After(opts.Since) {
+				return fmt.Errorf("--until must be after --since")
+			}
+			return c.runAuditSummary(opts)
 		},
 	}
 
+	cmd.Flags().IntVar(&top, "top", 0, "number of entries in each top-N list (default: gateway default)")
+	cmd.Flags().StringSliceVar(&dimensions, "by", nil, "dimensions to rank: rejection_reasons, tables, users, engines")
+	cmd.Flags().StringVar(&since, "since", "", "summarize queries logged at or after this time")
+	cmd.Flags().StringVar(&until, "until", "", "summarize queries logged before this time")
+
 	return cmd
 }
 
-func (c *CLI) runAuditSummary() error {
+// parseAuditTime parses the value of an audit time flag: an RFC 3339
+// timestamp, a date in UTC, or a duration before now. An empty value is the
+// zero time.
+func parseAuditTime(flag, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q: expected an RFC 3339 timestamp, a date (2006-01-02) or a duration (24h)", flag, value)
+}
+
+func (c *CLI) runAuditSummary(opts AuditSummaryOptions) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Use gateway client to get audit summary
 	client := c.newGatewayClient()
 
-	summary, err := client.GetAuditSummary(ctx)
+	summary, err := client.GetAuditSummaryWithOptions(ctx, opts)
 	if err != nil {
 		c.errorf("Error: %v\n", err)
 		return err
@@ -338,6 +390,7 @@ func (c *CLI) runAuditSummary() error {
 	c.println("Query Summary:")
 	c.printf("  Accepted: %d\n", summary.AcceptedCount)
 	c.printf("  Rejected: %d\n", summary.RejectedCount)
+	c.printf("  Slow:     %d\n", summary.SlowQueryCount)
 
 	if len(summary.TopRejectionReasons) > 0 {
 		c.println("\nTop Rejection Reasons:")
@@ -353,6 +406,20 @@ func (c *CLI) runAuditSummary() error {
 		}
 	}
 
+	if len(summary.TopUsers) > 0 {
+		c.println("\nTop Users:")
+		for _, u := range summary.TopUsers {
+			c.printf("  - %s: %d\n", u.User, u.Count)
+		}
+	}
+
+	if len(summary.TopEngines) > 0 {
+		c.println("\nTop Engines:")
+		for _, e := range summary.TopEngines {
+			c.printf("  - %s: %d\n", e.Engine, e.Count)
+		}
+	}
+
 	if c.jsonOutput {
 		return c.outputJSON(summary)
 	}
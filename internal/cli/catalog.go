@@ -6,27 +6,35 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/catalog/syncer"
 )
 
 // CatalogSyncOptions configures the catalog sync operation.
 type CatalogSyncOptions struct {
 	// Source is the catalog to sync from (empty = all configured).
-	Source string
+	Source string `json:"source,omitempty"`
 
 	// Database filters which database to sync (empty = all).
-	Database string
+	Database string `json:"database,omitempty"`
 
 	// DryRun shows what would be synced without making changes.
-	DryRun bool
+	DryRun bool `json:"dry_run"`
 
-	// Force updates existing tables.
-	Force bool
+	// Force also updates tables registered outside catalog sync.
+	Force bool `json:"force"`
+
+	// Prune removes previously synced tables that are no longer in the catalog.
+	Prune bool `json:"prune"`
+
+	// Concurrency is the number of catalog requests made at once (0 = default).
+	Concurrency int `json:"concurrency,omitempty"`
 }
 
 // newCatalogCmd creates the catalog command group.
@@ -39,12 +47,14 @@ and Databricks Unity Catalog.
 
 Available Commands:
   sync        Synchronize tables from external catalogs
-  list        List configured catalogs
+  list        List catalog databases, or the tables of a database
+  describe    Show catalog metadata for a table
   status      Show catalog connection status`,
 	}
 
 	cmd.AddCommand(c.newCatalogSyncCmd())
 	cmd.AddCommand(c.newCatalogListCmd())
+	cmd.AddCommand(c.newCatalogDescribeCmd())
 	cmd.AddCommand(c.newCatalogStatusCmd())
 
 	return cmd
@@ -63,7 +73,10 @@ This command:
 1. Connects to configured catalog sources (Hive, Glue, Unity)
 2. Discovers databases and tables
 3. Detects table formats (Iceberg, Delta, Hudi)
-4. Registers tables in Canonic
+4. Registers new tables and updates the sources and columns of synced ones
+
+The sync prints a report of the tables created, updated, removed, unchanged
+and skipped. Use --dry-run to review the report before anything is written.
 
 Examples:
   # Sync all tables from all configured catalogs
@@ -79,7 +92,10 @@ Examples:
   canonic catalog sync --dry-run
 
   # Force refresh (update existing tables)
-  canonic catalog sync --force`,
+  canonic catalog sync --force
+
+  # Remove synced tables that were dropped from the catalog
+  canonic catalog sync --prune`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return c.catalogSync(cmd.Context(), opts)
 		},
@@ -88,213 +104,236 @@ Examples:
 	cmd.Flags().StringVar(&opts.Source, "source", "", "catalog source to sync from (hive, glue, unity)")
 	cmd.Flags().StringVar(&opts.Database, "database", "", "specific database to sync")
 	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "show what would be synced without making changes")
-	cmd.Flags().BoolVar(&opts.Force, "force", false, "update existing tables")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "also update tables registered outside catalog sync")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "remove synced tables no longer in the catalog")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 0, "number of catalog requests made at once (default 8)")
 
 	return cmd
 }
 
-// catalogSync performs the catalog synchronization.
+// catalogSync performs the catalog synchronization through the gateway and
+// prints its report.
 func (c *CLI) catalogSync(ctx context.Context, opts *CatalogSyncOptions) error {
-	// Get gateway client
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
 	client := c.newGatewayClient()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
 
-	// For now, provide clear feedback that catalogs need to be configured
-	// The actual catalog clients (Hive, Glue, Unity) require additional dependencies
-	c.println("Canonic Catalog Sync")
-	c.println("====================")
-	c.println()
-
-	if opts.DryRun {
-		c.println("Mode: Dry-run (no changes will be made)")
+	report, err := client.SyncCatalog(ctx, opts)
+	if err != nil {
+		c.errorf("Catalog sync failed: %v\n", err)
+		return err
 	}
 
-	if opts.Source != "" {
-		c.printf("Source: %s\n", opts.Source)
+	if c.jsonOutput {
+		if err := c.outputJSON(report); err != nil {
+			return err
+		}
 	} else {
-		c.println("Source: all configured catalogs")
+		c.printSyncReport(report)
 	}
 
-	if opts.Database != "" {
-		c.printf("Database: %s\n", opts.Database)
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("catalog sync: %d tables failed", failed)
 	}
+	return nil
+}
 
-	c.println()
+// syncActionSymbols mark each action in the sync report.
+var syncActionSymbols = map[syncer.SyncAction]string{
+	syncer.SyncCreate:    "+",
+	syncer.SyncUpdate:    "~",
+	syncer.SyncRemove:    "-",
+	syncer.SyncUnchanged: "=",
+	syncer.SyncSkip:      "!",
+}
 
-	// Check if we have catalog configuration
-	// For MVP, we show guidance on how to configure catalogs
-	c.println("Note: Catalog integration requires configuration.")
-	c.println()
-	c.println("To configure catalogs, add the following to your config.yaml:")
-	c.println()
-	c.println("  catalogs:")
-	c.println("    hive:")
-	c.println("      type: hive")
-	c.println("      thrift_uri: thrift://hive-metastore:9083")
-	c.println("    glue:")
-	c.println("      type: glue")
-	c.println("      region: us-east-1")
-	c.println("    unity:")
-	c.println("      type: unity")
-	c.println("      host: https://your-workspace.cloud.databricks.com")
-	c.println("      token: ${DATABRICKS_TOKEN}")
-	c.println()
-	c.println("See docs/phase-7-spec.md for complete configuration reference.")
+// printSyncReport prints a catalog sync report: one line per table with its
+// action and details, then a summary.
+func (c *CLI) printSyncReport(report *syncer.SyncReport) {
+	if report.DryRun {
+		c.printf("Catalog sync from %s (dry run, no changes made)\n\n", report.Catalog)
+	} else {
+		c.printf("Catalog sync from %s\n\n", report.Catalog)
+	}
 
-	// Show what would be synced if this were a real implementation
-	if opts.DryRun {
-		c.println()
-		c.println("Dry-run summary:")
-		c.println("  Tables discovered: 0")
-		c.println("  Tables to sync: 0")
-		c.println("  Tables to skip: 0")
+	if len(report.Changes) == 0 {
+		c.println("No tables found")
 	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, change := range report.Changes {
+		details := strings.Join(change.Details, "; ")
+		if change.Error != "" {
+			details = strings.TrimPrefix(details+"; failed: "+change.Error, "; ")
+		}
+		fmt.Fprintf(w, "  %s %s\t%s\t%s\n", syncActionSymbols[change.Action], change.Table, change.Action, details)
+	}
+	w.Flush()
 
-	// Keep client reference to avoid unused variable
-	_ = client
+	for _, msg := range report.Errors {
+		c.errorf("  ✗ %s\n", msg)
+	}
 
-	return nil
+	verb := ""
+	if report.DryRun {
+		verb = "to "
+	}
+	c.printf("\nSummary: %d %screate, %d %supdate, %d %sremove, %d unchanged, %d skipped",
+		len(report.Tables(syncer.SyncCreate)), verb,
+		len(report.Tables(syncer.SyncUpdate)), verb,
+		len(report.Tables(syncer.SyncRemove)), verb,
+		len(report.Tables(syncer.SyncUnchanged)),
+		len(report.Tables(syncer.SyncSkip)))
+	if failed := report.Failed(); failed > 0 {
+		c.printf(", %d failed", failed)
+	}
+	c.println()
 }
 
-// CatalogSyncResult holds the result of a sync operation.
-type CatalogSyncResult struct {
-	Synced  int
-	Skipped int
-	Failed  int
-	Errors  []string
-}
+// newCatalogListCmd creates the catalog list command.
+func (c *CLI) newCatalogListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [database]",
+		Short: "List catalog databases, or the tables of a database",
+		Long: `Browse the external catalog through the gateway.
 
-// syncFromCatalog syncs tables from a single catalog.
-// This is the core sync logic used by catalogSync.
-func (c *CLI) syncFromCatalog(ctx context.Context, cat catalog.Catalog, opts *CatalogSyncOptions, client *GatewayClient) (*CatalogSyncResult, error) {
-	result := &CatalogSyncResult{}
+Without arguments, lists the catalog's databases. With a database, lists
+its tables and their formats. Databases excluded by the gateway's catalog
+filters are not shown.
 
-	// Check connectivity
-	if err := cat.CheckConnectivity(ctx); err != nil {
-		return nil, fmt.Errorf("catalog %s connectivity failed: %w", cat.Name(), err)
+Examples:
+  canonic catalog list
+  canonic catalog list analytics`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return c.catalogListTables(args[0])
+			}
+			return c.catalogListDatabases()
+		},
 	}
+}
 
-	c.printf("Connected to %s catalog\n", cat.Name())
+// catalogListDatabases lists the catalog's databases.
+func (c *CLI) catalogListDatabases() error {
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	// List databases
-	databases, err := cat.ListDatabases(ctx)
+	databases, err := client.ListCatalogDatabases(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list databases: %w", err)
+		c.errorf("Failed to list catalog databases: %v\n", err)
+		return err
 	}
 
-	c.printf("Found %d databases\n", len(databases))
-
-	// Filter to specific database if requested
-	if opts.Database != "" {
-		found := false
-		for _, db := range databases {
-			if db == opts.Database {
-				databases = []string{db}
-				found = true
-				break
-			}
-		}
-		if !found {
-			return nil, fmt.Errorf("database %q not found in catalog", opts.Database)
-		}
+	if c.jsonOutput {
+		return c.outputJSON(map[string]interface{}{
+			"databases": databases,
+		})
 	}
 
-	// Sync each database
+	if len(databases) == 0 {
+		c.println("No catalog databases")
+		return nil
+	}
 	for _, db := range databases {
-		c.printf("\nSyncing database: %s\n", db)
-
-		tables, err := cat.ListTables(ctx, db)
-		if err != nil {
-			c.errorf("  Failed to list tables: %v\n", err)
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", db, err))
-			continue
-		}
-
-		for _, table := range tables {
-			// Get full metadata
-			meta, err := cat.GetTable(ctx, db, table.Name)
-			if err != nil {
-				c.errorf("  ✗ %s.%s (failed: %v)\n", db, table.Name, err)
-				result.Failed++
-				result.Errors = append(result.Errors, fmt.Sprintf("%s.%s: %v", db, table.Name, err))
-				continue
-			}
-
-			// Check if table already exists
-			fullName := fmt.Sprintf("%s.%s", db, table.Name)
-
-			if opts.DryRun {
-				c.printf("  Would sync: %s (format: %s → %s)\n",
-					fullName, meta.Format, catalog.SelectEngine(meta.Format))
-				result.Synced++
-				continue
-			}
+		c.println(db)
+	}
+	return nil
+}
 
-			// Skip existing tables unless force is set
-			if !opts.Force {
-				// Check if table exists
-				_, err := client.DescribeTable(ctx, fullName)
-				if err == nil {
-					c.printf("  - %s (skipped: already registered)\n", fullName)
-					result.Skipped++
-					continue
-				}
-			}
+// catalogListTables lists the tables of a catalog database.
+func (c *CLI) catalogListTables(database string) error {
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-			// Register the table
-			err = c.registerTableFromCatalog(ctx, client, meta)
-			if err != nil {
-				c.errorf("  ✗ %s (failed: %v)\n", fullName, err)
-				result.Failed++
-				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", fullName, err))
-				continue
-			}
+	tables, err := client.ListCatalogTables(ctx, database)
+	if err != nil {
+		c.errorf("Failed to list catalog tables: %v\n", err)
+		return err
+	}
 
-			c.printf("  ✓ %s (%s → %s)\n", fullName, meta.Format, catalog.SelectEngine(meta.Format))
-			result.Synced++
-		}
+	if c.jsonOutput {
+		return c.outputJSON(map[string]interface{}{
+			"tables": tables,
+		})
 	}
 
-	return result, nil
-}
+	if len(tables) == 0 {
+		c.printf("No tables in %s\n", database)
+		return nil
+	}
 
-// registerTableFromCatalog registers a table in Canonic from catalog metadata.
-func (c *CLI) registerTableFromCatalog(ctx context.Context, client *GatewayClient, meta *catalog.TableMetadata) error {
-	// Build registration request using existing RegisterTableRequest structure
-	// The existing structure uses Sources for engine routing
-	req := &RegisterTableRequest{
-		Name:        meta.FullName(),
-		Description: fmt.Sprintf("Synced from catalog (format: %s, engine: %s)", meta.Format, catalog.SelectEngine(meta.Format)),
-		Sources: []SourceInfo{{
-			Format:   string(meta.Format),
-			Location: meta.Location,
-		}},
-		Capabilities: []string{"read"}, // Default to read-only for synced tables
-	}
-
-	return client.RegisterTable(ctx, req)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tFORMAT")
+	for _, t := range tables {
+		fmt.Fprintf(w, "%s\t%s\n", t.FullName(), t.Format)
+	}
+	w.Flush()
+	return nil
 }
 
-// newCatalogListCmd creates the catalog list command.
-func (c *CLI) newCatalogListCmd() *cobra.Command {
+// newCatalogDescribeCmd creates the catalog describe command.
+func (c *CLI) newCatalogDescribeCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "list",
-		Short: "List configured catalogs",
-		Long:  `List all configured external catalogs.`,
+		Use:   "describe <database>.<table>",
+		Short: "Show catalog metadata for a table",
+		Long: `Display the external catalog's metadata for a table: format, location,
+columns, and partition columns.
+
+Example:
+  canonic catalog describe analytics.orders`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.catalogList()
+			return c.catalogDescribe(args[0])
 		},
 	}
 }
 
-// catalogList lists configured catalogs.
-func (c *CLI) catalogList() error {
-	c.println("Configured Catalogs")
-	c.println("===================")
-	c.println()
-	c.println("No catalogs configured.")
-	c.println()
-	c.println("To configure catalogs, see docs/phase-7-spec.md")
+// catalogDescribe shows catalog metadata for a table.
+func (c *CLI) catalogDescribe(name string) error {
+	database, table, ok := strings.Cut(name, ".")
+	if !ok || database == "" || table == "" {
+		err := fmt.Errorf("invalid table name %q: expected <database>.<table>", name)
+		c.errorf("Failed to describe catalog table: %v\n", err)
+		return err
+	}
+
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	meta, err := client.DescribeCatalogTable(ctx, database, table)
+	if err != nil {
+		c.errorf("Failed to describe catalog table: %v\n", err)
+		return err
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(meta)
+	}
+
+	c.println("Table:", meta.FullName())
+	c.printf("  Format: %s\n", meta.Format)
+	if meta.Location != "" {
+		c.printf("  Location: %s\n", meta.Location)
+	}
+	if len(meta.Partitions) > 0 {
+		c.printf("  Partitioned by: %s\n", strings.Join(meta.Partitions, ", "))
+	}
+	c.println("  Columns:")
+	for _, col := range meta.Columns {
+		nullable := ""
+		if !col.Nullable {
+			nullable = " NOT NULL"
+		}
+		c.printf("    - %s %s%s\n", col.Name, col.Type, nullable)
+	}
 	return nil
 }
 
@@ -9,15 +9,17 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/canonica-labs/canonica/internal/config"
+	"github.com/canonica-labs/canonica/internal/errors"
 )
 
-// Exit codes as defined in docs/canonic-cli-spec.md
+// Exit codes as defined in docs/canonic-cli-spec.md, one per error code
+// (see errors.ErrorCode.ExitCode).
 const (
 	ExitSuccess    = 0
-	ExitValidation = 1
-	ExitAuth       = 2
-	ExitEngine     = 3
-	ExitInternal   = 4
+	ExitValidation = int(errors.CodeValidation)
+	ExitAuth       = int(errors.CodeAuth)
+	ExitEngine     = int(errors.CodeEngine)
+	ExitInternal   = int(errors.CodeInternal)
 )
 
 // Version information (set at build time)
@@ -48,12 +50,10 @@ func New() *CLI {
 	return cli
 }
 
-// Execute runs the CLI.
+// Execute runs the CLI. A failed command exits with the code of its error
+// (see errors.ExitCodeForError), or ExitInternal for errors without one.
 func (c *CLI) Execute() int {
-	if err := c.rootCmd.Execute(); err != nil {
-		return ExitInternal
-	}
-	return ExitSuccess
+	return errors.ExitCodeForError(c.rootCmd.Execute())
 }
 
 func (c *CLI) newRootCmd() *cobra.Command {
@@ -95,6 +95,7 @@ This CLI is a control interface for configuration, validation, and diagnostics.`
 	cmd.AddCommand(c.newBootstrapCmd())
 	cmd.AddCommand(c.newStatusCmd())
 	cmd.AddCommand(c.newAuditCmd())
+	cmd.AddCommand(c.newLogsCmd())
 	// Phase 7 commands
 	cmd.AddCommand(c.newCatalogCmd())
 
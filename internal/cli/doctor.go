@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -11,7 +14,9 @@ import (
 )
 
 func (c *CLI) newDoctorCmd() *cobra.Command {
-	return &cobra.Command{
+	var sqlFeatures bool
+
+	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Run system diagnostics",
 		Long: `Run comprehensive system diagnostics.
@@ -20,11 +25,83 @@ Checks:
   - connectivity to control plane
   - authentication status
   - engine health
-  - metadata integrity`,
+  - metadata integrity
+
+With --sql-features, lists the SQL features the gateway supports instead.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if sqlFeatures {
+				return c.runDoctorSQLFeatures()
+			}
 			return c.runDoctor()
 		},
 	}
+
+	cmd.Flags().BoolVar(&sqlFeatures, "sql-features", false, "List supported SQL features and known limitations")
+	return cmd
+}
+
+func (c *CLI) runDoctorSQLFeatures() error {
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := client.GetSQLFeatures(ctx)
+	if err != nil {
+		c.errorf("Failed to get SQL features: %v\n", err)
+		return err
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(result)
+	}
+
+	c.println("Supported SQL")
+	c.println("=============")
+	c.println("")
+	c.printf("Operations: %s\n", strings.Join(result.Operations, ", "))
+	c.println("")
+	c.println("Features:")
+	for _, f := range result.Features {
+		status := "✗"
+		if f.Supported {
+			status = "✓"
+		}
+		c.printf("  %s %s\n", status, f.Feature)
+	}
+
+	if len(result.Engines) > 0 {
+		engines := make([]string, 0, len(result.Engines))
+		for engine := range result.Engines {
+			engines = append(engines, engine)
+		}
+		sort.Strings(engines)
+
+		c.println("")
+		c.println("Engine Support:")
+		for _, engine := range engines {
+			var supported []string
+			for feature, ok := range result.Engines[engine] {
+				if ok {
+					supported = append(supported, feature)
+				}
+			}
+			sort.Strings(supported)
+			if len(supported) == 0 {
+				supported = []string{"(none)"}
+			}
+			c.printf("  %s: %s\n", engine, strings.Join(supported, ", "))
+		}
+	}
+
+	if len(result.Limitations) > 0 {
+		c.println("")
+		c.println("Limitations:")
+		for _, limitation := range result.Limitations {
+			c.printf("  - %s\n", limitation)
+		}
+	}
+
+	return nil
 }
 
 func (c *CLI) runDoctor() error {
@@ -1,13 +1,16 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"text/tabwriter"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/canonica-labs/canonica/internal/adapters"
 	"github.com/canonica-labs/canonica/internal/router"
 )
 
@@ -126,6 +129,10 @@ func (c *CLI) runEngineDescribe(engineName string) error {
 		Priority:     engine.Priority,
 	}
 
+	// Recorded health comes from the gateway; describe still works without it
+	health, healthErr := c.fetchEngineHealth(engine.Name)
+	info.Health = health
+
 	if c.jsonOutput {
 		return c.outputJSON(info)
 	}
@@ -146,6 +153,7 @@ func (c *CLI) runEngineDescribe(engineName string) error {
 		c.printf("  • %s\n", cap)
 	}
 	c.println("")
+	c.printEngineHealth(health, healthErr)
 
 	// Engine-specific info
 	switch engine.Name {
@@ -175,6 +183,49 @@ type EngineInfo struct {
 	Available    bool     `json:"available"`
 	Capabilities []string `json:"capabilities"`
 	Priority     int      `json:"priority"`
+
+	// Health is the health recorded by the gateway, if reachable.
+	Health *adapters.HealthSnapshot `json:"health,omitempty"`
+}
+
+// fetchEngineHealth retrieves the recorded health of an engine from the
+// gateway.
+func (c *CLI) fetchEngineHealth(name string) (*adapters.HealthSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return c.newGatewayClient().GetEngineHealth(ctx, name)
+}
+
+// printEngineHealth prints the Health section of engine describe.
+func (c *CLI) printEngineHealth(health *adapters.HealthSnapshot, err error) {
+	c.println("Health:")
+	if err != nil {
+		c.printf("  unknown (%v)\n", err)
+		c.println("")
+		return
+	}
+
+	status := "✗ failing"
+	if health.Healthy {
+		status = "✓ healthy"
+	}
+	if health.LastCheck.IsZero() {
+		status = "not checked yet"
+	}
+	c.printf("  Last check: %s\n", status)
+	if !health.LastCheck.IsZero() {
+		c.printf("  Checked at: %s\n", health.LastCheck.Format(time.RFC3339))
+	}
+	if health.LastError != "" {
+		c.printf("  Last error: %s (%s)\n", health.LastError, health.LastErrorAt.Format(time.RFC3339))
+	}
+	if health.LastSuccessfulQuery.IsZero() {
+		c.println("  Last successful query: never")
+	} else {
+		c.printf("  Last successful query: %s\n", health.LastSuccessfulQuery.Format(time.RFC3339))
+	}
+	c.printf("  Latency: %dms (last %dms)\n", health.LatencyMs, health.LastLatencyMs)
+	c.println("")
 }
 
 func (c *CLI) getEngineInfo(r *router.Router, name string) EngineInfo {
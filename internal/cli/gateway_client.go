@@ -11,9 +11,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/catalog/syncer"
 	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/pkg/models"
 )
 
 // GatewayClient is the HTTP client for communicating with the canonica gateway.
@@ -77,20 +83,67 @@ type ExplainResult struct {
 	Plan         string   `json:"plan,omitempty"`
 }
 
+// LineageResult represents column lineage from the gateway.
+type LineageResult struct {
+	SQL     string          `json:"sql"`
+	Columns []LineageColumn `json:"columns"`
+}
+
+// LineageColumn maps one output column to the source columns it derives from.
+type LineageColumn struct {
+	Column     string   `json:"column"`
+	Sources    []string `json:"sources"`
+	Expression string   `json:"expression,omitempty"`
+}
+
+// SQLFeaturesResult represents the SQL surface reported by the gateway.
+type SQLFeaturesResult struct {
+	Operations  []string                   `json:"operations"`
+	Features    []SQLFeatureInfo           `json:"features"`
+	Engines     map[string]map[string]bool `json:"engines"`
+	Limitations []string                   `json:"limitations"`
+}
+
+// SQLFeatureInfo describes whether a SQL feature is supported.
+type SQLFeatureInfo struct {
+	Feature    string `json:"feature"`
+	Supported  bool   `json:"supported"`
+	Limitation string `json:"limitation,omitempty"`
+	Example    string `json:"example"`
+}
+
 // ValidateResult represents query validation from the gateway.
 type ValidateResult struct {
 	Valid bool   `json:"valid"`
 	Error string `json:"error,omitempty"`
+
+	// Statements holds a verdict per statement when the input is a
+	// multi-statement script.
+	Statements []StatementValidation `json:"statements,omitempty"`
+}
+
+// StatementValidation is the verdict for one statement of a validated script.
+type StatementValidation struct {
+	Index int    `json:"index"`
+	SQL   string `json:"sql"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
 }
 
 // QueryResult represents a query execution result.
+// It mirrors the gateway's models.QueryResponse envelope.
 type QueryResult struct {
-	QueryID  string                   `json:"query_id"`
-	Columns  []string                 `json:"columns,omitempty"`
-	Rows     []map[string]interface{} `json:"rows,omitempty"`
-	RowCount int                      `json:"row_count"`
-	Engine   string                   `json:"engine"`
-	Duration string                   `json:"duration"`
+	QueryID   string                   `json:"query_id"`
+	Columns   []string                 `json:"columns,omitempty"`
+	Schema    []models.ColumnSchema    `json:"schema,omitempty"`
+	Rows      []map[string]interface{} `json:"rows,omitempty"`
+	RowCount  int                      `json:"row_count"`
+	Engine    string                   `json:"engine"`
+	Duration  string                   `json:"duration"`
+	Warnings  []models.QueryWarning    `json:"warnings,omitempty"`
+	Truncated bool                     `json:"truncated"`
+	Stats     *models.QueryStats       `json:"stats,omitempty"`
+	Metadata  map[string]string        `json:"metadata,omitempty"`
 }
 
 // ListTables retrieves all registered tables from the gateway.
@@ -127,6 +180,10 @@ type RegisterTableRequest struct {
 	Sources      []SourceInfo `json:"sources"`
 	Capabilities []string     `json:"capabilities"`
 	Constraints  []string     `json:"constraints,omitempty"`
+
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+	FormatOverride     string `json:"format_override,omitempty"`
 }
 
 // RegisterTable registers a new table with the gateway.
@@ -195,6 +252,31 @@ func (c *GatewayClient) DescribeTable(ctx context.Context, tableName string) (*T
 	return &result, nil
 }
 
+// GetTableEngines lists the engines that can serve a table, in the order
+// the router would try them, with their availability and estimated cost.
+func (c *GatewayClient) GetTableEngines(ctx context.Context, tableName string) (*models.TableEnginesResponse, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/tables/"+url.PathEscape(tableName)+"/engines", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result models.TableEnginesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode table engines response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // ExplainQuery gets the execution plan for a query from the gateway.
 // Per phase-3-spec.md §8: "canonic query explain"
 func (c *GatewayClient) ExplainQuery(ctx context.Context, sql string) (*ExplainResult, error) {
@@ -221,6 +303,31 @@ func (c *GatewayClient) ExplainQuery(ctx context.Context, sql string) (*ExplainR
 	return &result, nil
 }
 
+// QueryLineage gets the column lineage of a query from the gateway.
+func (c *GatewayClient) QueryLineage(ctx context.Context, sql string) (*LineageResult, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	body, _ := json.Marshal(map[string]string{"sql": sql})
+	resp, err := c.doRequest(ctx, "POST", "/query/lineage", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result LineageResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // ValidateQuery validates a query without executing it.
 // Per phase-3-spec.md §8: "canonic query validate"
 func (c *GatewayClient) ValidateQuery(ctx context.Context, sql string) (*ValidateResult, error) {
@@ -273,6 +380,99 @@ func (c *GatewayClient) ExecuteQuery(ctx context.Context, sql string) (*QueryRes
 	return &result, nil
 }
 
+// EstimateQuery asks the gateway for the estimated cost of a query without
+// executing it.
+func (c *GatewayClient) EstimateQuery(ctx context.Context, sql string) (*models.QueryEstimateResponse, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	body, _ := json.Marshal(map[string]string{"sql": sql})
+	resp, err := c.doRequest(ctx, "POST", "/query/estimate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result models.QueryEstimateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CursorResult is the response to opening a paged query.
+type CursorResult struct {
+	QueryID string `json:"query_id"`
+	Token   string `json:"token"`
+	Engine  string `json:"engine"`
+}
+
+// CursorPage is one page of a paged query result.
+type CursorPage struct {
+	Token   string                   `json:"token"`
+	Page    int                      `json:"page"`
+	Columns []string                 `json:"columns,omitempty"`
+	Rows    []map[string]interface{} `json:"rows,omitempty"`
+	Done    bool                     `json:"done"`
+}
+
+// OpenCursor executes a query in paged mode and returns a cursor token.
+// Pages are fetched with FetchCursorPage until a page reports Done.
+func (c *GatewayClient) OpenCursor(ctx context.Context, sql string) (*CursorResult, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	body, _ := json.Marshal(map[string]string{"sql": sql})
+	resp, err := c.doRequest(ctx, "POST", "/query/cursor", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result CursorResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// FetchCursorPage fetches page number page (starting at 1) of a cursor.
+func (c *GatewayClient) FetchCursorPage(ctx context.Context, token string, page int) (*CursorPage, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	path := fmt.Sprintf("/query/cursor/%s?page=%d", url.PathEscape(token), page)
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result CursorPage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // HealthInfo represents the health response from the gateway.
 type HealthInfo struct {
 	Status    string `json:"status"`
@@ -305,6 +505,56 @@ func (c *GatewayClient) GetHealthInfo(ctx context.Context) (*HealthInfo, error)
 	return &health, nil
 }
 
+// GetEngineHealth retrieves the recorded health of an engine: the last
+// health check result and error, the last successful query and recent
+// latency.
+func (c *GatewayClient) GetEngineHealth(ctx context.Context, engine string) (*adapters.HealthSnapshot, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/engines/"+url.PathEscape(engine)+"/health", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var health adapters.HealthSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode engine health response: %w", err)
+	}
+
+	return &health, nil
+}
+
+// GetSQLFeatures retrieves the supported SQL features and limitations.
+func (c *GatewayClient) GetSQLFeatures(ctx context.Context) (*SQLFeaturesResult, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/capabilities/sql", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result SQLFeaturesResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode SQL features response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // CheckHealth verifies gateway connectivity.
 // Per phase-3-spec.md §8: "canonic doctor"
 func (c *GatewayClient) CheckHealth(ctx context.Context) (bool, error) {
@@ -347,19 +597,14 @@ func (c *GatewayClient) doRequest(ctx context.Context, method, path string, body
 func (c *GatewayClient) parseErrorResponse(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
 
-	var errResp struct {
-		Error      string `json:"error"`
-		Reason     string `json:"reason"`
-		Suggestion string `json:"suggestion"`
-	}
-	if err := json.Unmarshal(body, &errResp); err != nil {
+	var errResp errors.CanonicError
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Message == "" {
 		return fmt.Errorf("gateway error: %d - %s", resp.StatusCode, string(body))
 	}
-
-	if errResp.Reason != "" {
-		return fmt.Errorf("%s: %s", errResp.Error, errResp.Reason)
+	if errResp.Code == 0 {
+		errResp.Code = errors.CodeForHTTPStatus(resp.StatusCode)
 	}
-	return fmt.Errorf("%s", errResp.Error)
+	return &errResp
 }
 
 // AuditSummary represents aggregated audit statistics.
@@ -367,8 +612,11 @@ func (c *GatewayClient) parseErrorResponse(resp *http.Response) error {
 type AuditSummary struct {
 	AcceptedCount       int                   `json:"accepted_count"`
 	RejectedCount       int                   `json:"rejected_count"`
+	SlowQueryCount      int                   `json:"slow_query_count"`
 	TopRejectionReasons []RejectionReasonStat `json:"top_rejection_reasons"`
 	TopQueriedTables    []TableQueryStat      `json:"top_queried_tables"`
+	TopUsers            []UserQueryStat       `json:"top_users,omitempty"`
+	TopEngines          []EngineQueryStat     `json:"top_engines,omitempty"`
 }
 
 // RejectionReasonStat represents rejection reason statistics.
@@ -383,14 +631,66 @@ type TableQueryStat struct {
 	Count int    `json:"count"`
 }
 
+// UserQueryStat represents per-user query statistics.
+type UserQueryStat struct {
+	User  string `json:"user"`
+	Count int    `json:"count"`
+}
+
+// EngineQueryStat represents per-engine query statistics.
+type EngineQueryStat struct {
+	Engine string `json:"engine"`
+	Count  int    `json:"count"`
+}
+
+// AuditSummaryOptions selects the top-N lists of an audit summary.
+// Zero values use the gateway defaults (top 5 rejection reasons and tables).
+type AuditSummaryOptions struct {
+	// TopN is the length of each top-N list.
+	TopN int
+
+	// Dimensions are the lists to build: rejection_reasons, tables,
+	// users, engines.
+	Dimensions []string
+
+	// Since and Until restrict the summary to queries logged from Since
+	// (inclusive) to Until (exclusive). Zero times leave the window open.
+	Since time.Time
+	Until time.Time
+}
+
 // GetAuditSummary retrieves audit summary from the gateway.
 // Per phase-5-spec.md §4: "canonic audit summary"
 func (c *GatewayClient) GetAuditSummary(ctx context.Context) (*AuditSummary, error) {
+	return c.GetAuditSummaryWithOptions(ctx, AuditSummaryOptions{})
+}
+
+// GetAuditSummaryWithOptions retrieves an audit summary with the requested
+// top-N lists from the gateway.
+func (c *GatewayClient) GetAuditSummaryWithOptions(ctx context.Context, opts AuditSummaryOptions) (*AuditSummary, error) {
 	if c.endpoint == "" {
 		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
 	}
 
-	resp, err := c.doRequest(ctx, "GET", "/audit/summary", nil)
+	query := url.Values{}
+	if opts.TopN > 0 {
+		query.Set("top", fmt.Sprintf("%d", opts.TopN))
+	}
+	if len(opts.Dimensions) > 0 {
+		query.Set("dimensions", strings.Join(opts.Dimensions, ","))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.UTC().Format(time.RFC3339))
+	}
+	path := "/audit/summary"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -408,6 +708,186 @@ func (c *GatewayClient) GetAuditSummary(ctx context.Context) (*AuditSummary, err
 	return &result, nil
 }
 
+// CurrentUser is the AuditEntryFilter.User value the gateway resolves to the
+// authenticated caller.
+const CurrentUser = "me"
+
+// AuditEntry represents one query recorded in the audit log.
+type AuditEntry struct {
+	QueryID    string   `json:"query_id"`
+	User       string   `json:"user"`
+	SQL        string   `json:"sql,omitempty"`
+	Tables     []string `json:"tables,omitempty"`
+	Engine     string   `json:"engine,omitempty"`
+	Outcome    string   `json:"outcome"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+	Timestamp  string   `json:"timestamp"`
+}
+
+// AuditEntryFilter selects audit entries. Zero values do not filter.
+type AuditEntryFilter struct {
+	User  string
+	Limit int
+}
+
+// ListAuditEntries retrieves audit log entries, most recent first.
+func (c *GatewayClient) ListAuditEntries(ctx context.Context, filter AuditEntryFilter) ([]AuditEntry, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	query := url.Values{}
+	if filter.User != "" {
+		query.Set("user", filter.User)
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", fmt.Sprintf("%d", filter.Limit))
+	}
+	path := "/audit/entries"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Entries, nil
+}
+
+// GetAuditEntry retrieves a single audit log entry by query ID.
+func (c *GatewayClient) GetAuditEntry(ctx context.Context, queryID string) (*AuditEntry, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/audit/entries/"+url.PathEscape(queryID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var result AuditEntry
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// RerunQuery fetches the SQL of a previously executed query from the audit
+// log and executes it again. The new execution gets its own query ID.
+func (c *GatewayClient) RerunQuery(ctx context.Context, queryID string) (*QueryResult, error) {
+	entry, err := c.GetAuditEntry(ctx, queryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.SQL == "" {
+		return nil, fmt.Errorf("audit entry %s has no recorded SQL", queryID)
+	}
+	return c.ExecuteQuery(ctx, entry.SQL)
+}
+
+// ListCatalogDatabases lists the databases of the gateway's external catalog.
+func (c *GatewayClient) ListCatalogDatabases(ctx context.Context) ([]string, error) {
+	var result struct {
+		Databases []string `json:"databases"`
+	}
+	if err := c.getCatalog(ctx, "/catalog/databases", &result); err != nil {
+		return nil, err
+	}
+	return result.Databases, nil
+}
+
+// ListCatalogTables lists the tables of a database in the gateway's external
+// catalog.
+func (c *GatewayClient) ListCatalogTables(ctx context.Context, database string) ([]catalog.TableInfo, error) {
+	var result struct {
+		Tables []catalog.TableInfo `json:"tables"`
+	}
+	if err := c.getCatalog(ctx, "/catalog/"+url.PathEscape(database)+"/tables", &result); err != nil {
+		return nil, err
+	}
+	return result.Tables, nil
+}
+
+// DescribeCatalogTable retrieves a table's metadata from the gateway's
+// external catalog.
+func (c *GatewayClient) DescribeCatalogTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	var result catalog.TableMetadata
+	if err := c.getCatalog(ctx, "/catalog/"+url.PathEscape(database)+"/"+url.PathEscape(table), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SyncCatalog syncs the gateway's external catalog into the table
+// repository and returns the sync report. With opts.DryRun the report
+// previews the changes and nothing is written.
+func (c *GatewayClient) SyncCatalog(ctx context.Context, opts *CatalogSyncOptions) (*syncer.SyncReport, error) {
+	if c.endpoint == "" {
+		return nil, errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	body, _ := json.Marshal(opts)
+	resp, err := c.doRequest(ctx, "POST", "/catalog/sync", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	var report syncer.SyncReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &report, nil
+}
+
+// getCatalog performs a GET against a catalog proxy endpoint and decodes the
+// response into result.
+func (c *GatewayClient) getCatalog(ctx context.Context, path string, result interface{}) error {
+	if c.endpoint == "" {
+		return errors.NewGatewayUnavailable("", "no gateway endpoint configured")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseErrorResponse(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 // GetStatus retrieves system status from the gateway.
 // Per phase-5-spec.md §4: "canonic status"
 func (c *GatewayClient) GetStatus(ctx context.Context) (*StatusResult, error) {
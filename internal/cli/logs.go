@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// LogRecord is one structured gateway log record in the JSONLogger format.
+// Slow query records (event "slow_query") share the format.
+type LogRecord struct {
+	Timestamp       string   `json:"timestamp"`
+	Level           string   `json:"level"`
+	Event           string   `json:"event,omitempty"`
+	QueryID         string   `json:"query_id"`
+	User            string   `json:"user"`
+	Tables          []string `json:"tables"`
+	Engine          string   `json:"engine"`
+	ExecutionTimeMs int64    `json:"execution_time_ms"`
+	ThresholdMs     int64    `json:"threshold_ms,omitempty"`
+	Outcome         string   `json:"outcome,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	SQL             string   `json:"sql,omitempty"`
+}
+
+// newLogRecord converts an audit entry fetched from the gateway.
+func newLogRecord(entry AuditEntry) LogRecord {
+	level := "info"
+	if entry.Error != "" {
+		level = "error"
+	}
+	return LogRecord{
+		Timestamp:       entry.Timestamp,
+		Level:           level,
+		QueryID:         entry.QueryID,
+		User:            entry.User,
+		Tables:          entry.Tables,
+		Engine:          entry.Engine,
+		ExecutionTimeMs: entry.DurationMs,
+		Outcome:         entry.Outcome,
+		Error:           entry.Error,
+		SQL:             entry.SQL,
+	}
+}
+
+// EffectiveOutcome returns the outcome shown for the record: "slow" for slow
+// query records, otherwise the recorded outcome. Records written without an
+// outcome are "error" if they carry an error and "success" otherwise.
+func (r LogRecord) EffectiveOutcome() string {
+	switch {
+	case r.Event == "slow_query":
+		return "slow"
+	case r.Outcome != "":
+		return r.Outcome
+	case r.Error != "":
+		return "error"
+	default:
+		return "success"
+	}
+}
+
+// LogFilter selects log records. Empty fields match every record; matching
+// is case-insensitive.
+type LogFilter struct {
+	User    string
+	Table   string
+	Outcome string
+}
+
+// Matches reports whether a record passes the filter. A table filter matches
+// records that reference the table among others.
+func (f LogFilter) Matches(r LogRecord) bool {
+	if f.User != "" && !strings.EqualFold(f.User, r.User) {
+		return false
+	}
+	if f.Outcome != "" && !strings.EqualFold(f.Outcome, r.EffectiveOutcome()) {
+		return false
+	}
+	if f.Table != "" {
+		for _, table := range r.Tables {
+			if strings.EqualFold(f.Table, table) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ANSI colors used for outcomes.
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// Column widths of formatted records. Widths are fixed rather than computed
+// from the records so that a live view stays aligned as records arrive.
+const (
+	logTimeWidth     = 20
+	logOutcomeWidth  = 8
+	logUserWidth     = 16
+	logEngineWidth   = 8
+	logDurationWidth = 9
+)
+
+// LogFormatter renders log records as aligned columns for operators:
+// time, outcome, user, engine, duration, tables and query ID, followed by
+// the error of failed queries.
+type LogFormatter struct {
+	// Color highlights outcomes: green for success, red for errors and
+	// rejections, yellow for slow queries.
+	Color bool
+}
+
+// Header returns the column header line.
+func (f LogFormatter) Header() string {
+	return f.columns("TIME", "OUTCOME", "USER", "ENGINE", "DURATION", "TABLES", "QUERY ID")
+}
+
+// Format renders a record as a single line.
+func (f LogFormatter) Format(r LogRecord) string {
+	tables := strings.Join(r.Tables, ",")
+	if tables == "" {
+		tables = "-"
+	}
+
+	duration := fmt.Sprintf("%dms", r.ExecutionTimeMs)
+	line := f.columns(r.Timestamp, r.EffectiveOutcome(), r.User, r.Engine, duration, tables, r.QueryID)
+
+	switch {
+	case r.Error != "":
+		line += "  " + r.Error
+	case r.Event == "slow_query":
+		line += fmt.Sprintf("  exceeded %dms", r.ThresholdMs)
+	}
+	return line
+}
+
+// columns lays out the columns of a line. Padding is applied before
+// coloring so escape codes do not break alignment.
+func (f LogFormatter) columns(ts, outcome, user, engine, duration, tables, queryID string) string {
+	outcomeCol := f.colorize(outcome, padRight(outcome, logOutcomeWidth))
+	return strings.Join([]string{
+		padRight(ts, logTimeWidth),
+		outcomeCol,
+		padRight(orDash(user), logUserWidth),
+		padRight(orDash(engine), logEngineWidth),
+		fmt.Sprintf("%*s", logDurationWidth, duration),
+		tables,
+		queryID,
+	}, "  ")
+}
+
+// colorize wraps text in the color of an outcome.
+func (f LogFormatter) colorize(outcome, text string) string {
+	if !f.Color {
+		return text
+	}
+	switch strings.ToLower(outcome) {
+	case "success":
+		return ansiGreen + text + ansiReset
+	case "error", "rejected":
+		return ansiRed + text + ansiReset
+	case "slow":
+		return ansiYellow + text + ansiReset
+	default:
+		return text
+	}
+}
+
+// padRight pads s with spaces to width; longer values are kept whole.
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+// orDash returns "-" for empty values.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// TailLogs reads JSON log records from r, one per line, and writes the records
+// matching filter to w, formatted by formatter. Lines that are not JSON
+// objects (such as plain log lines interleaved on the gateway's stdout) are
+// skipped. It returns the number of records written.
+func TailLogs(r io.Reader, w io.Writer, filter LogFilter, formatter LogFormatter) (int, error) {
+	reader := bufio.NewReader(r)
+	written := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record LogRecord
+			if json.Unmarshal(line, &record) == nil && filter.Matches(record) {
+				if _, werr := fmt.Fprintln(w, formatter.Format(record)); werr != nil {
+					return written, werr
+				}
+				written++
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// followReader reads a growing file like tail -f: at end of file it waits
+// for more data instead of returning io.EOF, until its context is done.
+type followReader struct {
+	ctx      context.Context
+	r        io.Reader
+	interval time.Duration
+}
+
+// Read implements io.Reader.
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+// logsOptions holds the flags of the logs command.
+type logsOptions struct {
+	filter   LogFilter
+	follow   bool
+	limit    int
+	interval time.Duration
+	noColor  bool
+}
+
+func (c *CLI) newLogsCmd() *cobra.Command {
+	var opts logsOptions
+
+	cmd := &cobra.Command{
+		Use:   "logs [file]",
+		Short: "Pretty-print gateway query logs",
+		Long: `Display structured gateway query logs as aligned, color-coded columns.
+
+With a file argument, JSON log records written by the gateway (for example
+its stdout, or an audit overflow file) are read from the file; use "-" to
+read from stdin. Without one, recent entries are fetched from the gateway
+audit log.
+
+Use --follow to keep watching for new entries, and --user, --table and
+--outcome (success, error, rejected, slow) to filter them.
+
+Example:
+  canonic logs --follow --outcome error
+  canonic logs /var/log/canonic/gateway.log --table sales.orders
+  canonic-gateway 2>&1 | canonic logs -`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return c.runLogsFile(args[0], opts)
+			}
+			return c.runLogsGateway(opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "keep watching for new entries")
+	cmd.Flags().StringVar(&opts.filter.User, "user", "", "only show entries of this user")
+	cmd.Flags().StringVar(&opts.filter.Table, "table", "", "only show entries that reference this table")
+	cmd.Flags().StringVar(&opts.filter.Outcome, "outcome", "", "only show entries with this outcome")
+	cmd.Flags().IntVar(&opts.limit, "limit", 50, "number of recent gateway entries to fetch")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 2*time.Second, "polling interval with --follow")
+	cmd.Flags().BoolVar(&opts.noColor, "no-color", false, "disable colored output")
+
+	return cmd
+}
+
+// logFormatter returns the formatter for stdout. Color is used only on a
+// terminal and can be disabled with --no-color or NO_COLOR.
+func (c *CLI) logFormatter(opts logsOptions) LogFormatter {
+	color := !opts.noColor && os.Getenv("NO_COLOR") == ""
+	if color {
+		info, err := os.Stdout.Stat()
+		color = err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return LogFormatter{Color: color}
+}
+
+func (c *CLI) runLogsFile(path string, opts logsOptions) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			c.errorf("Failed to open log file: %v\n", err)
+			return err
+		}
+		defer file.Close()
+		r = file
+
+		if opts.follow {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			r = &followReader{ctx: ctx, r: file, interval: opts.interval}
+		}
+	}
+
+	formatter := c.logFormatter(opts)
+	c.println(formatter.Header())
+	if _, err := TailLogs(r, os.Stdout, opts.filter, formatter); err != nil {
+		c.errorf("Failed to read logs: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+func (c *CLI) runLogsGateway(opts logsOptions) error {
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
+	client := c.newGatewayClient()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The user filter is applied by the gateway, which also resolves "me"
+	filter := opts.filter
+	filter.User = ""
+
+	formatter := c.logFormatter(opts)
+	c.println(formatter.Header())
+
+	// Entries seen in the previous poll; anything older has dropped out of
+	// the fetched window and will not be returned again
+	seen := make(map[string]bool)
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		entries, err := client.ListAuditEntries(fetchCtx, AuditEntryFilter{User: opts.filter.User, Limit: opts.limit})
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			c.errorf("Failed to get audit entries: %v\n", err)
+			return err
+		}
+
+		current := make(map[string]bool, len(entries))
+		// Entries are most recent first; print them in time order
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			current[entry.QueryID] = true
+			if seen[entry.QueryID] {
+				continue
+			}
+			if record := newLogRecord(entry); filter.Matches(record) {
+				c.println(formatter.Format(record))
+			}
+		}
+		seen = current
+
+		if !opts.follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opts.interval):
+		}
+	}
+}
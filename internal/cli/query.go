@@ -2,11 +2,16 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
+
+	"github.com/canonica-labs/canonica/pkg/models"
 )
 
 func (c *CLI) newQueryCmd() *cobra.Command {
@@ -19,12 +24,18 @@ func (c *CLI) newQueryCmd() *cobra.Command {
 	cmd.AddCommand(c.newQueryExecCmd())
 	cmd.AddCommand(c.newQueryExplainCmd())
 	cmd.AddCommand(c.newQueryValidateCmd())
+	cmd.AddCommand(c.newQueryLineageCmd())
+	cmd.AddCommand(c.newQueryExportCmd())
+	cmd.AddCommand(c.newQueryHistoryCmd())
+	cmd.AddCommand(c.newQueryRerunCmd())
 
 	return cmd
 }
 
 func (c *CLI) newQueryExecCmd() *cobra.Command {
-	return &cobra.Command{
+	var confirmCost bool
+
+	cmd := &cobra.Command{
 		Use:   "exec <SQL>",
 		Short: "Execute a SQL query",
 		Long: `Execute a SQL query through the canonica gateway.
@@ -32,23 +43,103 @@ func (c *CLI) newQueryExecCmd() *cobra.Command {
 The query is validated, routed to the appropriate engine, and executed.
 Results are streamed to stdout.
 
+With --confirm-cost, the gateway's cost estimate is shown first and the
+query only runs after confirmation.
+
 Example:
-  canonic query exec "SELECT * FROM analytics.sales_orders LIMIT 10"`,
+  canonic query exec "SELECT * FROM analytics.sales_orders LIMIT 10"
+  canonic query exec --confirm-cost "SELECT * FROM sales.orders o JOIN crm.customers c ON o.customer_id = c.id"`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.runQueryExec(args[0])
+			return c.runQueryExec(args[0], confirmCost)
 		},
 	}
+
+	cmd.Flags().BoolVar(&confirmCost, "confirm-cost", false, "show the estimated cost and ask before executing")
+
+	return cmd
 }
 
-func (c *CLI) runQueryExec(sqlQuery string) error {
+func (c *CLI) runQueryExec(sqlQuery string, confirmCost bool) error {
 	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
 	// No local parsing - all validation happens on the gateway
 	client := c.newGatewayClient()
+
+	if confirmCost {
+		estimateCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		estimate, err := client.EstimateQuery(estimateCtx, sqlQuery)
+		cancel()
+		if err != nil {
+			c.errorf("Cost estimate failed: %v\n", err)
+			return err
+		}
+
+		// The prompt goes to stderr so it is seen even when results are piped
+		c.errorf("%s — continue? [y/N]: ", FormatCostEstimate(estimate))
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			c.errorf("Cancelled\n")
+			return nil
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	result, err := client.ExecuteQuery(ctx, sqlQuery)
+	return c.printQueryResult(result, err)
+}
+
+// FormatCostEstimate summarizes a cost estimate for a confirmation prompt,
+// e.g. "This query will scan ~2,000,000 rows across spark and trino
+// (~244.1 MB transferred, est. 1.2s)".
+func FormatCostEstimate(estimate *models.QueryEstimateResponse) string {
+	engines := strings.Join(estimate.Engines, ", ")
+	if n := len(estimate.Engines); n > 1 {
+		engines = strings.Join(estimate.Engines[:n-1], ", ") + " and " + estimate.Engines[n-1]
+	}
+	summary := fmt.Sprintf("This query will scan ~%s rows across %s (~%s transferred, est. %s)",
+		formatCount(estimate.EstimatedRows), engines, formatBytes(estimate.EstimatedBytes), estimate.EstimatedTime)
+	if estimate.GenericProfile {
+		summary += "; some engines have untuned cost factors"
+	}
+	return summary
+}
+
+// formatCount formats n with thousands separators.
+func formatCount(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte(',')
+		}
+		out.WriteRune(digit)
+	}
+	return sign + out.String()
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 GB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[exp])
+}
+
+// printQueryResult renders the result of an executed query.
+func (c *CLI) printQueryResult(result *QueryResult, err error) error {
 	if err != nil {
 		if c.jsonOutput {
 			return c.outputJSON(map[string]interface{}{
@@ -64,14 +155,58 @@ func (c *CLI) runQueryExec(sqlQuery string) error {
 		return c.outputJSON(result)
 	}
 
+	// Warnings go to stderr, ahead of the result, so they are not lost in
+	// long output or piped along with the rows, and are shown even with --quiet
+	for _, warning := range result.Warnings {
+		c.errorf("%s\n", FormatWarning(warning))
+	}
+
 	c.printf("Query ID: %s\n", result.QueryID)
 	c.printf("Engine: %s\n", result.Engine)
 	c.printf("Duration: %s\n", result.Duration)
-	c.printf("Rows: %d\n", result.RowCount)
+	if result.Truncated {
+		c.printf("Rows: %d (truncated)\n", result.RowCount)
+	} else {
+		c.printf("Rows: %d\n", result.RowCount)
+	}
+	if checksum := result.Metadata["result_checksum"]; checksum != "" {
+		c.printf("Checksum: %s\n", checksum)
+	}
+	if stats := result.Stats; stats != nil {
+		if len(stats.Engines) > 1 {
+			c.printf("Engines: %s (%d sub-queries)\n", strings.Join(stats.Engines, ", "), stats.SubQueries)
+		}
+		if stats.RowsRead > 0 {
+			c.printf("Rows read: %d\n", stats.RowsRead)
+		}
+	}
 
 	if len(result.Columns) > 0 && len(result.Rows) > 0 {
 		c.println("")
-		c.println(strings.Join(result.Columns, "\t"))
+		for _, line := range FormatResultRows(result) {
+			c.println(line)
+		}
+	}
+
+	return nil
+}
+
+// FormatWarning renders a query warning for the terminal, e.g.
+// "⚠ WARNING [deprecated_table]: table analytics.events is deprecated".
+func FormatWarning(w models.QueryWarning) string {
+	if w.Code == "" {
+		return fmt.Sprintf("⚠ WARNING: %s", w.Message)
+	}
+	return fmt.Sprintf("⚠ WARNING [%s]: %s", w.Code, w.Message)
+}
+
+// FormatResultRows renders the header and rows of a query result, one line
+// each. When the result carries a column schema, columns are padded to a
+// common width, numeric columns are right-aligned and timestamps are shown
+// as "2006-01-02 15:04:05"; otherwise values are tab-separated.
+func FormatResultRows(result *QueryResult) []string {
+	if len(result.Schema) != len(result.Columns) {
+		lines := []string{strings.Join(result.Columns, "\t")}
 		for _, row := range result.Rows {
 			var values []string
 			for _, col := range result.Columns {
@@ -79,11 +214,88 @@ func (c *CLI) runQueryExec(sqlQuery string) error {
 					values = append(values, formatValue(v))
 				}
 			}
-			c.println(strings.Join(values, "\t"))
+			lines = append(lines, strings.Join(values, "\t"))
 		}
+		return lines
 	}
 
-	return nil
+	cells := [][]string{result.Columns}
+	for _, row := range result.Rows {
+		values := make([]string, len(result.Columns))
+		for i, col := range result.Columns {
+			values[i] = formatTypedValue(row[col], result.Schema[i])
+		}
+		cells = append(cells, values)
+	}
+
+	widths := make([]int, len(result.Columns))
+	for _, values := range cells {
+		for i, value := range values {
+			widths[i] = max(widths[i], utf8.RuneCountInString(value))
+		}
+	}
+
+	lines := make([]string, 0, len(cells))
+	for _, values := range cells {
+		var line strings.Builder
+		for i, value := range values {
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value))
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			if result.Schema[i].IsNumeric() {
+				line.WriteString(padding + value)
+			} else if i < len(values)-1 {
+				line.WriteString(value + padding)
+			} else {
+				line.WriteString(value)
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// resultTimestampLayouts are the encodings of timestamp values accepted
+// from the gateway.
+var resultTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// formatTypedValue formats a value for display according to its column type.
+func formatTypedValue(v interface{}, col models.ColumnSchema) string {
+	switch {
+	case v == nil:
+		return "NULL"
+	case col.IsNumeric():
+		if f, ok := v.(float64); ok {
+			// JSON numbers decode as float64; avoid exponent notation
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case col.IsTimestamp() || col.IsDate():
+		s, ok := v.(string)
+		if !ok {
+			break
+		}
+		for _, layout := range resultTimestampLayouts {
+			t, err := time.Parse(layout, s)
+			if err != nil {
+				continue
+			}
+			switch {
+			case col.IsDate():
+				return t.Format("2006-01-02")
+			case t.Location() == time.UTC:
+				return t.Format("2006-01-02 15:04:05.999999999")
+			default:
+				return t.Format("2006-01-02 15:04:05.999999999 -07:00")
+			}
+		}
+	}
+	return formatValue(v)
 }
 
 // formatValue formats a value for display
@@ -176,6 +388,9 @@ func (c *CLI) newQueryValidateCmd() *cobra.Command {
 Useful for CI/CD pipelines and pre-flight checks.
 Exit code 0 means valid, exit code 1 means invalid.
 
+A script of several semicolon-separated statements is validated statement
+by statement, with a verdict and rejection reason for each.
+
 Example:
   canonic query validate "SELECT * FROM analytics.sales_orders"`,
 		Args: cobra.ExactArgs(1),
@@ -205,6 +420,10 @@ func (c *CLI) runQueryValidate(sqlQuery string) error {
 		return err
 	}
 
+	if len(result.Statements) > 0 {
+		return c.printScriptValidation(sqlQuery, result)
+	}
+
 	if !result.Valid {
 		if c.jsonOutput {
 			return c.outputJSON(map[string]interface{}{
@@ -227,3 +446,236 @@ func (c *CLI) runQueryValidate(sqlQuery string) error {
 	c.println("✓ Valid")
 	return nil
 }
+
+// printScriptValidation prints one verdict per statement of a script.
+func (c *CLI) printScriptValidation(script string, result *ValidateResult) error {
+	invalid := 0
+	for _, stmt := range result.Statements {
+		if !stmt.Valid {
+			invalid++
+		}
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(map[string]interface{}{
+			"valid":      invalid == 0,
+			"query":      script,
+			"statements": result.Statements,
+		})
+	}
+
+	for _, stmt := range result.Statements {
+		if stmt.Valid {
+			c.printf("✓ [%d] %s\n", stmt.Index, stmt.SQL)
+		} else {
+			c.printf("✗ [%d] %s\n    %s\n", stmt.Index, stmt.SQL,
+				strings.ReplaceAll(stmt.Error, "\n", "\n    "))
+		}
+	}
+	if invalid > 0 {
+		c.errorf("%d of %d statements invalid\n", invalid, len(result.Statements))
+		return fmt.Errorf("validation failed: %d of %d statements invalid", invalid, len(result.Statements))
+	}
+	return nil
+}
+
+func (c *CLI) newQueryLineageCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lineage <SQL>",
+		Short: "Show which source columns feed each output column",
+		Long: `Show the column lineage of a query.
+
+For each output column, lists the source table.column(s) it derives from,
+traced through joins, aggregates, subqueries and CTEs. SELECT * is expanded
+from the registered table schemas.
+
+Example:
+  canonic query lineage "SELECT o.id, SUM(o.amount) AS total FROM analytics.orders o GROUP BY o.id"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runQueryLineage(args[0])
+		},
+	}
+}
+
+func (c *CLI) runQueryLineage(sqlQuery string) error {
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := client.QueryLineage(ctx, sqlQuery)
+	if err != nil {
+		if c.jsonOutput {
+			return c.outputJSON(map[string]interface{}{
+				"error": err.Error(),
+				"query": sqlQuery,
+			})
+		}
+		c.errorf("Lineage failed: %v\n", err)
+		return err
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(result)
+	}
+
+	c.println("Column Lineage")
+	c.println("==============")
+	c.println("")
+	for _, col := range result.Columns {
+		sources := "(constant)"
+		if len(col.Sources) > 0 {
+			sources = strings.Join(col.Sources, ", ")
+		}
+		c.printf("  %s <- %s\n", col.Column, sources)
+		if col.Expression != "" && col.Expression != col.Column {
+			c.printf("      %s\n", col.Expression)
+		}
+	}
+
+	return nil
+}
+
+func (c *CLI) newQueryExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export <SQL>",
+		Short: "Export a large query result page by page",
+		Long: `Export a query result through a gateway-side cursor.
+
+The gateway executes the query once and keeps the result stream open; rows
+are fetched in pages until the result is exhausted, so exports larger than a
+single response can be written to stdout. Cursors left idle expire on the
+gateway.
+
+Example:
+  canonic query export "SELECT * FROM analytics.sales_orders" > orders.tsv`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runQueryExport(args[0])
+		},
+	}
+}
+
+func (c *CLI) runQueryExport(sqlQuery string) error {
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
+	client := c.newGatewayClient()
+	ctx := context.Background()
+
+	openCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	cursor, err := client.OpenCursor(openCtx, sqlQuery)
+	cancel()
+	if err != nil {
+		c.errorf("Export failed: %v\n", err)
+		return err
+	}
+
+	for page := 1; ; page++ {
+		pageCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		result, err := client.FetchCursorPage(pageCtx, cursor.Token, page)
+		cancel()
+		if err != nil {
+			c.errorf("Export failed on page %d: %v\n", page, err)
+			return err
+		}
+
+		if page == 1 && !c.jsonOutput {
+			c.println(strings.Join(result.Columns, "\t"))
+		}
+		for _, row := range result.Rows {
+			if c.jsonOutput {
+				line, err := json.Marshal(row)
+				if err != nil {
+					return err
+				}
+				c.println(string(line))
+				continue
+			}
+			values := make([]string, 0, len(result.Columns))
+			for _, col := range result.Columns {
+				values = append(values, formatValue(row[col]))
+			}
+			c.println(strings.Join(values, "\t"))
+		}
+
+		if result.Done {
+			return nil
+		}
+	}
+}
+
+func (c *CLI) newQueryHistoryCmd() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List your recent queries",
+		Long: `List your recent queries from the gateway audit log, most recent first,
+with their outcome, duration and query ID.
+
+Use 'canonic query rerun <query_id>' to execute a query again.
+
+Example:
+  canonic query history --limit 10`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runQueryHistory(limit)
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", 20, "maximum number of queries to list")
+	return cmd
+}
+
+func (c *CLI) runQueryHistory(limit int) error {
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	entries, err := client.ListAuditEntries(ctx, AuditEntryFilter{User: CurrentUser, Limit: limit})
+	if err != nil {
+		c.errorf("Failed to get query history: %v\n", err)
+		return err
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(map[string]interface{}{"entries": entries})
+	}
+
+	if len(entries) == 0 {
+		c.println("No queries found.")
+		return nil
+	}
+
+	c.println("TIME\tQUERY ID\tOUTCOME\tDURATION\tSQL")
+	for _, entry := range entries {
+		c.printf("%s\t%s\t%s\t%dms\t%s\n",
+			entry.Timestamp, entry.QueryID, entry.Outcome, entry.DurationMs, formatValue(entry.SQL))
+	}
+
+	return nil
+}
+
+func (c *CLI) newQueryRerunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rerun <query_id>",
+		Short: "Re-execute a query from your history",
+		Long: `Fetch the SQL of a previous query from the audit log and execute it again.
+
+Example:
+  canonic query rerun 3f2a9c1e-...`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runQueryRerun(args[0])
+		},
+	}
+}
+
+func (c *CLI) runQueryRerun(queryID string) error {
+	// Per execution-checklist.md 4.2: CLI uses GatewayClient exclusively
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := client.RerunQuery(ctx, queryID)
+	return c.printQueryResult(result, err)
+}
@@ -26,6 +26,7 @@ func (c *CLI) newTableCmd() *cobra.Command {
 	cmd.AddCommand(c.newTableRegisterCmd())
 	cmd.AddCommand(c.newTableValidateCmd())
 	cmd.AddCommand(c.newTableDescribeCmd())
+	cmd.AddCommand(c.newTableEnginesCmd())
 	cmd.AddCommand(c.newTableListCmd())
 	cmd.AddCommand(c.newTableDeleteCmd())
 
@@ -54,7 +55,12 @@ Example file:
     - READ
     - TIME_TRAVEL
   constraints:
-    - READ_ONLY`,
+    - READ_ONLY
+
+Optionally mark a table as deprecated; queries still succeed but return
+a warning with the message:
+  deprecated: true
+  deprecation_message: use analytics.orders_v2 instead`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return c.runTableRegister(args[0])
@@ -86,8 +92,11 @@ func (c *CLI) runTableRegister(filePath string) error {
 
 	// Convert to gateway request
 	req := &RegisterTableRequest{
-		Name:        vt.Name,
-		Description: vt.Description,
+		Name:               vt.Name,
+		Description:        vt.Description,
+		Deprecated:         vt.Deprecated,
+		DeprecationMessage: vt.DeprecationMessage,
+		FormatOverride:     string(vt.FormatOverride),
 	}
 	for _, src := range vt.Sources {
 		req.Sources = append(req.Sources, SourceInfo{
@@ -229,6 +238,68 @@ func (c *CLI) runTableDescribe(tableName string) error {
 	return nil
 }
 
+func (c *CLI) newTableEnginesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "engines <table_name>",
+		Short: "List the engines that can serve a table",
+		Long: `List the registered engines that can serve a table, in the order the
+router would try them.
+
+Shows for each engine:
+  - availability, and whether the router would select it
+  - the required capabilities it provides
+  - why it is placed where it is
+  - the estimated cost of scanning the table
+
+Engines that do not support the table's format or capabilities are not listed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runTableEngines(args[0])
+		},
+	}
+}
+
+func (c *CLI) runTableEngines(tableName string) error {
+	client := c.newGatewayClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := client.GetTableEngines(ctx, tableName)
+	if err != nil {
+		c.errorf("Failed to list table engines: %v\n", err)
+		return err
+	}
+
+	if c.jsonOutput {
+		return c.outputJSON(result)
+	}
+
+	c.printf("Table: %s (%s)\n", result.Table, result.Format)
+	if len(result.Engines) == 0 {
+		c.println("  No registered engine can serve this table")
+		return nil
+	}
+	for i, engine := range result.Engines {
+		status := "available"
+		if !engine.Available {
+			status = "unavailable"
+		}
+		if engine.Selected {
+			status += ", selected"
+		}
+		c.printf("  %d. %s (%s)\n", i+1, engine.Engine, status)
+		c.printf("     Reason: %s\n", engine.Reason)
+		if len(engine.Capabilities) > 0 {
+			c.printf("     Capabilities: %s\n", strings.Join(engine.Capabilities, ", "))
+		}
+		if engine.EstimatedTime != "" {
+			c.printf("     Estimated cost: %s\n", engine.EstimatedTime)
+		}
+	}
+
+	return nil
+}
+
 func (c *CLI) newTableListCmd() *cobra.Command {
 	var (
 		filterEngine     string
@@ -385,8 +456,11 @@ func (c *CLI) parseTableDefinition(filePath string) (*tables.VirtualTable, error
 
 	// Convert to internal model
 	vt := &tables.VirtualTable{
-		Name:        def.Name,
-		Description: def.Description,
+		Name:               def.Name,
+		Description:        def.Description,
+		Deprecated:         def.Deprecated,
+		DeprecationMessage: def.DeprecationMessage,
+		FormatOverride:     tables.StorageFormat(strings.ToUpper(def.FormatOverride)),
 	}
 
 	// Parse sources
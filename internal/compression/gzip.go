@@ -0,0 +1,178 @@
+// Package compression provides HTTP response compression for the gateway.
+// Large query results, buffered or streamed as NDJSON, are gzip-compressed
+// for clients that accept it.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMinSize is the smallest response body, in bytes, that is compressed
+// by default. Smaller bodies gain little and cost a gzip header and CPU.
+const DefaultMinSize = 1024
+
+// GzipHandler compresses the responses of another handler with gzip when the
+// request's Accept-Encoding allows it and the body reaches a size threshold.
+//
+// The body is buffered until it reaches the threshold, so small responses are
+// sent unchanged. A handler that flushes, like a streaming NDJSON result,
+// commits the response to gzip at its first flush: its size is unknown, and
+// each flush still reaches the client.
+type GzipHandler struct {
+	next    http.Handler
+	minSize int
+}
+
+// NewGzipHandler wraps next with gzip compression of bodies of at least
+// minSize bytes. A minSize of zero or less selects DefaultMinSize.
+func NewGzipHandler(next http.Handler, minSize int) *GzipHandler {
+	if minSize <= 0 {
+		minSize = DefaultMinSize
+	}
+	return &GzipHandler{next: next, minSize: minSize}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *GzipHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	gw := &gzipResponseWriter{ResponseWriter: w, minSize: h.minSize, status: http.StatusOK}
+	defer gw.close()
+	h.next.ServeHTTP(gw, r)
+}
+
+// acceptsGzip reports whether an Accept-Encoding header value allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		name, value, found := strings.Cut(strings.TrimSpace(params), "=")
+		if !found || strings.ToLower(strings.TrimSpace(name)) != "q" {
+			return true
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return err == nil && q > 0
+	}
+	return false
+}
+
+// gzipResponseWriter buffers a response until it knows whether to compress.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+
+	// decided is set once the response is sent; gz is nil when it is sent
+	// uncompressed.
+	decided bool
+	gz      *gzip.Writer
+}
+
+// WriteHeader records the status; it is sent when the body is decided.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write buffers p until the body reaches the size threshold.
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf.Write(p)
+	if w.buf.Len() >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the buffered body, compressed, and flushes the underlying
+// writer.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start sends the header and the buffered body. The body is compressed when
+// compress is set and the response allows it.
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if compress && w.compressible() {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// compressible reports whether the response may be re-encoded: it has a body
+// and the handler has not encoded it already.
+func (w *gzipResponseWriter) compressible() bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	return w.ResponseWriter.Header().Get("Content-Encoding") == ""
+}
+
+// close sends a body still under the threshold uncompressed and finishes the
+// gzip stream.
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
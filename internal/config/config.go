@@ -28,6 +28,9 @@ type Config struct {
 
 	// Server configuration (for gateway)
 	Server ServerConfig `mapstructure:"server"`
+
+	// Audit configuration (for gateway)
+	Audit AuditConfig `mapstructure:"audit"`
 }
 
 // AuthConfig holds authentication configuration.
@@ -75,6 +78,13 @@ type SparkConfig struct {
 type LoggingConfig struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
+
+	// SlowQueryThreshold is a duration (e.g. "5s") above which queries are
+	// logged as slow. Empty disables slow-query reporting.
+	SlowQueryThreshold string `mapstructure:"slowQueryThreshold"`
+
+	// SlowQueryWebhook receives a JSON POST for every slow query (optional).
+	SlowQueryWebhook string `mapstructure:"slowQueryWebhook"`
 }
 
 // ServerConfig holds HTTP server configuration.
@@ -84,6 +94,16 @@ type ServerConfig struct {
 	WriteTimeout string `mapstructure:"writeTimeout"`
 }
 
+// AuditConfig holds audit logging configuration.
+type AuditConfig struct {
+	// HashSalt salts the hashes of sensitive column values in audit logs.
+	HashSalt string `mapstructure:"hashSalt"`
+
+	// SensitiveColumns lists, per table, the columns whose literal values are
+	// hashed in logged SQL. The key "*" applies to every table.
+	SensitiveColumns map[string][]string `mapstructure:"sensitiveColumns"`
+}
+
 // DefaultConfig returns a configuration with default values.
 func DefaultConfig() *Config {
 	return &Config{
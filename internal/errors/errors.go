@@ -5,7 +5,13 @@
 package errors
 
 import (
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
 // CanonicError is the base error type for all canonica errors.
@@ -28,6 +34,32 @@ const (
 	CodeInternal   ErrorCode = 4
 )
 
+// HTTPStatus returns the HTTP status code the gateway reports for errors of
+// the code.
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case CodeValidation:
+		return http.StatusBadRequest
+	case CodeAuth:
+		return http.StatusForbidden
+	case CodeEngine:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ExitCode returns the CLI exit code for errors of the code, as defined in
+// docs/canonic-cli-spec.md. Unknown codes exit as internal errors.
+func (c ErrorCode) ExitCode() int {
+	switch c {
+	case CodeValidation, CodeAuth, CodeEngine:
+		return int(c)
+	default:
+		return int(CodeInternal)
+	}
+}
+
 func (e *CanonicError) Error() string {
 	msg := e.Message
 	if e.Reason != "" {
@@ -46,6 +78,122 @@ func (e *CanonicError) Unwrap() error {
 	return e.Cause
 }
 
+// canonic returns the error itself. It is promoted to every error type
+// embedding CanonicError, so CodeOf and WriteHTTPError find their base.
+func (e *CanonicError) canonic() *CanonicError {
+	return e
+}
+
+// wireError is the JSON form of a CanonicError, as the gateway writes it
+// and the CLI reads it. Cause is not sent.
+type wireError struct {
+	Code       ErrorCode `json:"code"`
+	Error      string    `json:"error"`
+	Reason     string    `json:"reason,omitempty"`
+	Suggestion string    `json:"suggestion,omitempty"`
+}
+
+// MarshalJSON encodes the error as {code, error, reason, suggestion}.
+func (e *CanonicError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wireError{
+		Code:       e.Code,
+		Error:      e.Message,
+		Reason:     e.Reason,
+		Suggestion: e.Suggestion,
+	})
+}
+
+// UnmarshalJSON decodes an error encoded by MarshalJSON.
+func (e *CanonicError) UnmarshalJSON(data []byte) error {
+	var wire wireError
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*e = CanonicError{
+		Code:       wire.Code,
+		Message:    wire.Error,
+		Reason:     wire.Reason,
+		Suggestion: wire.Suggestion,
+	}
+	return nil
+}
+
+// CodeOf returns the code of the CanonicError in err's chain, or
+// CodeInternal when it has none.
+func CodeOf(err error) ErrorCode {
+	var coded interface{ canonic() *CanonicError }
+	if !stderrors.As(err, &coded) || coded.canonic().Code == 0 {
+		return CodeInternal
+	}
+	return coded.canonic().Code
+}
+
+// ExitCodeForError returns the CLI exit code for err: 0 for nil, otherwise
+// that of the code of the CanonicError in its chain (see CodeOf).
+func ExitCodeForError(err error) int {
+	if err == nil {
+		return 0
+	}
+	return CodeOf(err).ExitCode()
+}
+
+// HTTPStatus returns the HTTP status code the gateway reports for err: the
+// one its error type chooses when it has an HTTPStatus method, otherwise
+// one derived from its sentinel category (see ErrNotFound), or failing
+// that from its code.
+func HTTPStatus(err error) int {
+	var status interface{ HTTPStatus() int }
+	if stderrors.As(err, &status) {
+		return status.HTTPStatus()
+	}
+	switch {
+	case stderrors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case stderrors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case stderrors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case stderrors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case stderrors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case stderrors.Is(err, ErrTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return CodeOf(err).HTTPStatus()
+}
+
+// CodeForHTTPStatus returns the code of an error response that carries no
+// code, such as one from an older gateway, from its HTTP status.
+func CodeForHTTPStatus(status int) ErrorCode {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return CodeAuth
+	case status >= 400 && status < 500:
+		return CodeValidation
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return CodeEngine
+	default:
+		return CodeInternal
+	}
+}
+
+// WriteHTTPError writes err as a JSON error response with the status from
+// HTTPStatus. An error that is not a CanonicError is sent as an internal
+// error with its message.
+func WriteHTTPError(w http.ResponseWriter, err error) {
+	body := CanonicError{Message: err.Error()}
+	var coded interface{ canonic() *CanonicError }
+	if stderrors.As(err, &coded) {
+		body = *coded.canonic()
+	}
+	body.Code = CodeOf(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatus(err))
+	_ = json.NewEncoder(w).Encode(&body)
+}
+
 // ErrCapabilityDenied is returned when an operation requires a capability
 // that the virtual table does not have.
 type ErrCapabilityDenied struct {
@@ -59,7 +207,7 @@ type ErrCapabilityDenied struct {
 func NewCapabilityDenied(table, capability, operation string) *ErrCapabilityDenied {
 	return &ErrCapabilityDenied{
 		CanonicError: CanonicError{
-			Code:       CodeValidation,
+			Code:       CodeAuth,
 			Message:    fmt.Sprintf("%s forbidden on %s", operation, table),
 			Reason:     fmt.Sprintf("table lacks %s capability", capability),
 			Suggestion: fmt.Sprintf("check table capabilities with 'canonic table describe %s'", table),
@@ -116,6 +264,14 @@ func NewTableNotFound(table string) *ErrTableNotFound {
 type ErrEngineUnavailable struct {
 	CanonicError
 	RequiredCapabilities []string
+
+	// MissingCapabilities are the required capabilities that no available
+	// engine provides. Empty when each capability is provided by some engine
+	// but no single engine provides all of them.
+	MissingCapabilities []string
+
+	// ConsideredEngines maps each available engine to its capabilities.
+	ConsideredEngines map[string][]string
 }
 
 // NewEngineUnavailable creates a new ErrEngineUnavailable.
@@ -131,6 +287,45 @@ func NewEngineUnavailable(required []string) *ErrEngineUnavailable {
 	}
 }
 
+// NewEngineCapabilityUnavailable creates an ErrEngineUnavailable that names
+// the unsatisfiable capabilities and the engines that were considered.
+func NewEngineCapabilityUnavailable(required, missing []string, considered map[string][]string) *ErrEngineUnavailable {
+	names := make([]string, 0, len(considered))
+	for name := range considered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var reason string
+	switch {
+	case len(names) == 0:
+		reason = fmt.Sprintf("query requires capabilities %v but no engines are available", required)
+	case len(missing) > 0:
+		reason = fmt.Sprintf("no available engine provides %s", strings.Join(missing, ", "))
+	default:
+		reason = fmt.Sprintf("no single available engine provides all of %s", strings.Join(required, ", "))
+	}
+	if len(names) > 0 {
+		engines := make([]string, len(names))
+		for i, name := range names {
+			engines[i] = fmt.Sprintf("%s %v", name, considered[name])
+		}
+		reason = fmt.Sprintf("%s; considered engines: %s", reason, strings.Join(engines, ", "))
+	}
+
+	return &ErrEngineUnavailable{
+		CanonicError: CanonicError{
+			Code:       CodeEngine,
+			Message:    "no compatible engine available",
+			Reason:     reason,
+			Suggestion: "enable an engine that provides the missing capability, or check engine status with 'canonic engine list'",
+		},
+		RequiredCapabilities: required,
+		MissingCapabilities:  missing,
+		ConsideredEngines:    considered,
+	}
+}
+
 // ErrAuthFailed is returned when authentication fails.
 type ErrAuthFailed struct {
 	CanonicError
@@ -477,3 +672,451 @@ func NewCrossEngineQuery(engines []string) *ErrCrossEngineQuery {
 		Engines: engines,
 	}
 }
+
+// ErrSchemaRequired is returned when a query targets a table without a synced
+// column schema while the require-schema policy is in effect.
+type ErrSchemaRequired struct {
+	CanonicError
+	Table string
+}
+
+// NewSchemaRequired creates an error for tables lacking a column schema.
+func NewSchemaRequired(table string) *ErrSchemaRequired {
+	return &ErrSchemaRequired{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("query rejected: table %s has no column schema", table),
+			Reason:     "require-schema policy is enabled and the table's columns have not been synced, so the query cannot be column-validated",
+			Suggestion: fmt.Sprintf("run 'canonic catalog sync' to sync the schema of %s, or disable the require-schema policy", table),
+		},
+		Table: table,
+	}
+}
+
+// EngineErrorCategory classifies an error reported by an execution engine.
+type EngineErrorCategory string
+
+const (
+	// EngineErrorSyntax means the engine rejected the SQL it was sent.
+	EngineErrorSyntax EngineErrorCategory = "syntax"
+
+	// EngineErrorPermission means the engine's credentials lack access.
+	EngineErrorPermission EngineErrorCategory = "permission"
+
+	// EngineErrorNotFound means a table, schema or column is missing at the source.
+	EngineErrorNotFound EngineErrorCategory = "not_found"
+
+	// EngineErrorTransient means the failure is temporary (connection reset,
+	// engine restarting, server busy) and the query may succeed if retried.
+	EngineErrorTransient EngineErrorCategory = "transient"
+
+	// EngineErrorResource means the query exceeded an engine resource limit
+	// such as memory or execution time.
+	EngineErrorResource EngineErrorCategory = "resource"
+
+	// EngineErrorUnknown means the error could not be classified.
+	EngineErrorUnknown EngineErrorCategory = "unknown"
+)
+
+// ErrEngineQuery is returned when an engine reports an error while executing
+// a query. Category determines the error code, the HTTP status returned by
+// the gateway, and whether the query may be retried.
+type ErrEngineQuery struct {
+	CanonicError
+	Engine   string
+	Category EngineErrorCategory
+}
+
+// NewEngineQueryError creates an error for a classified engine failure.
+func NewEngineQueryError(engine string, category EngineErrorCategory, cause error) *ErrEngineQuery {
+	code := CodeEngine
+	var reason, suggestion string
+	switch category {
+	case EngineErrorSyntax:
+		code = CodeValidation
+		reason = "the engine rejected the query syntax"
+		suggestion = fmt.Sprintf("check that the query uses SQL supported by %s", engine)
+	case EngineErrorPermission:
+		code = CodeAuth
+		reason = "the engine denied access to a referenced object"
+		suggestion = fmt.Sprintf("grant the %s connection access to the source tables", engine)
+	case EngineErrorNotFound:
+		code = CodeValidation
+		reason = "a referenced table or column does not exist at the source"
+		suggestion = "check that the table's physical sources exist, or run 'canonic catalog sync'"
+	case EngineErrorTransient:
+		reason = "the engine reported a temporary failure"
+		suggestion = "retry the query; if it keeps failing, check engine status with 'canonic engine list'"
+	case EngineErrorResource:
+		reason = "the query exceeded an engine resource limit"
+		suggestion = "add filters or a LIMIT to reduce the data scanned, or raise the engine's limits"
+	default:
+		category = EngineErrorUnknown
+		reason = "the engine reported an unclassified error"
+		suggestion = fmt.Sprintf("check the %s logs for details", engine)
+	}
+
+	return &ErrEngineQuery{
+		CanonicError: CanonicError{
+			Code:       code,
+			Message:    fmt.Sprintf("query failed on engine %s", engine),
+			Reason:     reason,
+			Suggestion: suggestion,
+			Cause:      cause,
+		},
+		Engine:   engine,
+		Category: category,
+	}
+}
+
+// Retryable reports whether the query may succeed if retried.
+// Only transient failures are retryable.
+func (e *ErrEngineQuery) Retryable() bool {
+	return e.Category == EngineErrorTransient
+}
+
+// HTTPStatus returns the HTTP status code the gateway reports for the error.
+func (e *ErrEngineQuery) HTTPStatus() int {
+	switch e.Category {
+	case EngineErrorSyntax:
+		return http.StatusBadRequest
+	case EngineErrorPermission:
+		return http.StatusForbidden
+	case EngineErrorNotFound:
+		return http.StatusNotFound
+	case EngineErrorTransient:
+		return http.StatusServiceUnavailable
+	case EngineErrorResource:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// ErrTooManyEngines is returned when a federated query spans more engines
+// than the configured maximum.
+type ErrTooManyEngines struct {
+	CanonicError
+	Engines    []string
+	MaxEngines int
+}
+
+// NewTooManyEngines creates an error for a query spanning too many engines.
+func NewTooManyEngines(engines []string, maxEngines int) *ErrTooManyEngines {
+	return &ErrTooManyEngines{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("query spans %d engines, the maximum is %d", len(engines), maxEngines),
+			Reason:     fmt.Sprintf("query references tables on engines: %s", strings.Join(engines, ", ")),
+			Suggestion: "co-locate the joined tables on fewer engines, or raise federation.max_engines_per_query",
+		},
+		Engines:    engines,
+		MaxEngines: maxEngines,
+	}
+}
+
+// ErrEngineNotFound is returned when a referenced engine definition does not exist.
+type ErrEngineNotFound struct {
+	CanonicError
+	Engine string
+}
+
+// NewEngineNotFound creates a new ErrEngineNotFound.
+func NewEngineNotFound(engine string) *ErrEngineNotFound {
+	return &ErrEngineNotFound{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("engine not found: %s", engine),
+			Reason:     "no engine definition stored with this name",
+			Suggestion: "list configured engines with 'canonic engine list'",
+		},
+		Engine: engine,
+	}
+}
+
+// ErrEngineNotAllowed is returned when safe mode restricts engines to an
+// allowlist and a table or query needs an engine outside it.
+type ErrEngineNotAllowed struct {
+	CanonicError
+	Engine  string
+	Allowed []string
+}
+
+// NewEngineNotAllowed creates a new ErrEngineNotAllowed.
+func NewEngineNotAllowed(engine string, allowed []string) *ErrEngineNotAllowed {
+	return &ErrEngineNotAllowed{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("engine not allowed: %s", engine),
+			Reason:     fmt.Sprintf("safe mode restricts engines to: %s", strings.Join(allowed, ", ")),
+			Suggestion: "use an allowed engine or add it to safe_mode.allowed_engines",
+		},
+		Engine:  engine,
+		Allowed: allowed,
+	}
+}
+
+// ErrMemoryBudgetExceeded is returned when a federated query needs more
+// memory than its budget allows and the operator cannot spill to disk.
+type ErrMemoryBudgetExceeded struct {
+	CanonicError
+	Operator string
+	Limit    int64
+}
+
+// NewMemoryBudgetExceeded creates a new ErrMemoryBudgetExceeded.
+func NewMemoryBudgetExceeded(operator string, limit int64) *ErrMemoryBudgetExceeded {
+	return &ErrMemoryBudgetExceeded{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("query exceeded its memory budget of %d bytes", limit),
+			Reason:     fmt.Sprintf("%s needed more memory than the budget had left", operator),
+			Suggestion: "add filters or a LIMIT to reduce the rows buffered, or raise federation.max_query_memory",
+		},
+		Operator: operator,
+		Limit:    limit,
+	}
+}
+
+// ErrDuplicateColumn is returned when a query produces more than one output
+// column with the same name.
+type ErrDuplicateColumn struct {
+	CanonicError
+	Column string
+}
+
+// NewDuplicateColumn creates a new ErrDuplicateColumn.
+func NewDuplicateColumn(column string) *ErrDuplicateColumn {
+	return &ErrDuplicateColumn{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("duplicate output column: %s", column),
+			Reason:     "results are keyed by column name, so all but one of the columns would be lost",
+			Suggestion: "give each output column a distinct alias, or set federation.duplicate_columns to rename",
+		},
+		Column: column,
+	}
+}
+
+// ErrCartesianJoin is returned when a query joins tables without a
+// condition linking them and the cartesian join policy rejects it.
+type ErrCartesianJoin struct {
+	CanonicError
+	Join string
+}
+
+// NewCartesianJoin creates a new ErrCartesianJoin.
+func NewCartesianJoin(join string) *ErrCartesianJoin {
+	return &ErrCartesianJoin{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("cartesian product: %s", join),
+			Reason:     "a join without a join condition returns every combination of rows and can overwhelm the engine",
+			Suggestion: "add an ON condition or a WHERE predicate relating the tables, or set federation.cartesian_joins to warn or allow",
+		},
+		Join: join,
+	}
+}
+
+// ErrConcurrencyLimit is returned when a query's cost tier already has its
+// maximum number of queries running and no slot freed up within the queue
+// timeout.
+type ErrConcurrencyLimit struct {
+	CanonicError
+	Tier  string
+	Limit int
+}
+
+// NewConcurrencyLimit creates a new ErrConcurrencyLimit.
+func NewConcurrencyLimit(tier string, limit int, waited time.Duration) *ErrConcurrencyLimit {
+	return &ErrConcurrencyLimit{
+		CanonicError: CanonicError{
+			Code:       CodeEngine,
+			Message:    fmt.Sprintf("too many %s queries running (limit %d)", tier, limit),
+			Reason:     fmt.Sprintf("no %s query slot became free within %s", tier, waited),
+			Suggestion: "retry later, narrow the query to lower its estimated cost, or raise federation.cost_tiers",
+		},
+		Tier:  tier,
+		Limit: limit,
+	}
+}
+
+// HTTPStatus returns the HTTP status code the gateway reports for the error.
+func (e *ErrConcurrencyLimit) HTTPStatus() int {
+	return http.StatusTooManyRequests
+}
+
+// ErrEngineTimeout is returned when an engine does not answer the sub-query
+// of a federated query within the sub-query timeout.
+type ErrEngineTimeout struct {
+	CanonicError
+	Engine  string
+	Timeout time.Duration
+}
+
+// NewEngineTimeout creates a new ErrEngineTimeout.
+func NewEngineTimeout(engine string, timeout time.Duration) *ErrEngineTimeout {
+	return &ErrEngineTimeout{
+		CanonicError: CanonicError{
+			Code:       CodeEngine,
+			Message:    fmt.Sprintf("engine %s timed out", engine),
+			Reason:     fmt.Sprintf("the %s sub-query did not complete within %s", engine, timeout),
+			Suggestion: fmt.Sprintf("check the health of %s with 'canonic engine list', add filters to the query, or raise federation.sub_query_timeout", engine),
+		},
+		Engine:  engine,
+		Timeout: timeout,
+	}
+}
+
+// HTTPStatus returns the HTTP status code the gateway reports for the error.
+func (e *ErrEngineTimeout) HTTPStatus() int {
+	return http.StatusGatewayTimeout
+}
+
+// ErrSubQueryFailed is returned when a sub-query of a federated query fails
+// on its engine. It names the engine, the tables the sub-query read and the
+// SQL sent to the engine; Cause holds the engine's error.
+type ErrSubQueryFailed struct {
+	CanonicError
+	Engine string
+	Tables []string
+	SQL    string
+}
+
+// NewSubQueryFailed creates a new ErrSubQueryFailed.
+func NewSubQueryFailed(engine string, tables []string, sql string, cause error) *ErrSubQueryFailed {
+	return &ErrSubQueryFailed{
+		CanonicError: CanonicError{
+			Code:       CodeEngine,
+			Message:    fmt.Sprintf("sub-query on %s failed", engine),
+			Reason:     fmt.Sprintf("%s could not read %s", engine, strings.Join(tables, ", ")),
+			Suggestion: fmt.Sprintf("run the sub-query on %s directly to investigate: %s", engine, sql),
+			Cause:      cause,
+		},
+		Engine: engine,
+		Tables: tables,
+		SQL:    sql,
+	}
+}
+
+// HTTPStatus returns the HTTP status code the gateway reports for the error:
+// that of the engine's error when it has one, otherwise 502 Bad Gateway.
+func (e *ErrSubQueryFailed) HTTPStatus() int {
+	for err := e.Cause; err != nil; {
+		if status, ok := err.(interface{ HTTPStatus() int }); ok {
+			return status.HTTPStatus()
+		}
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = wrapper.Unwrap()
+	}
+	return http.StatusBadGateway
+}
+
+// ErrPartitionScanLimit is returned when a query without a partition filter
+// would scan more partitions of a table than the partition guard allows.
+type ErrPartitionScanLimit struct {
+	CanonicError
+	Table            string
+	Partitions       int64
+	Limit            int64
+	PartitionColumns []string
+}
+
+// NewPartitionScanLimit creates a new ErrPartitionScanLimit.
+func NewPartitionScanLimit(table string, partitions, limit int64, partitionColumns []string) *ErrPartitionScanLimit {
+	return &ErrPartitionScanLimit{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("query would scan %d partitions of %s (limit %d)", partitions, table, limit),
+			Reason:     "the query has no predicate on the table's partition columns, so no partitions can be pruned",
+			Suggestion: fmt.Sprintf("add a filter on %s", strings.Join(partitionColumns, ", ")),
+		},
+		Table:            table,
+		Partitions:       partitions,
+		Limit:            limit,
+		PartitionColumns: partitionColumns,
+	}
+}
+
+// ErrCatalogObjectNotFound is returned when a database or table is not
+// exposed by the external catalog.
+type ErrCatalogObjectNotFound struct {
+	CanonicError
+	Object string
+}
+
+// NewCatalogObjectNotFound creates a new ErrCatalogObjectNotFound.
+func NewCatalogObjectNotFound(object string) *ErrCatalogObjectNotFound {
+	return &ErrCatalogObjectNotFound{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("catalog object not found: %s", object),
+			Reason:     "the catalog has no such database or table, or it is excluded by the catalog filters",
+			Suggestion: "list available databases with 'canonic catalog list'",
+		},
+		Object: object,
+	}
+}
+
+// ErrJoinKeyCoercion is returned when the keys of a cross-engine join have
+// types that cannot be compared without a lossy or disallowed conversion.
+type ErrJoinKeyCoercion struct {
+	CanonicError
+	LeftColumn  string
+	LeftType    string
+	RightColumn string
+	RightType   string
+}
+
+// NewJoinKeyCoercion creates a new ErrJoinKeyCoercion.
+func NewJoinKeyCoercion(leftColumn, leftType, rightColumn, rightType, reason, suggestion string) *ErrJoinKeyCoercion {
+	return &ErrJoinKeyCoercion{
+		CanonicError: CanonicError{
+			Code:       CodeValidation,
+			Message:    fmt.Sprintf("cannot join %s (%s) with %s (%s)", leftColumn, leftType, rightColumn, rightType),
+			Reason:     reason,
+			Suggestion: suggestion,
+		},
+		LeftColumn:  leftColumn,
+		LeftType:    leftType,
+		RightColumn: rightColumn,
+		RightType:   rightType,
+	}
+}
+
+// ErrMissingAdapters is returned at startup when registered tables reference
+// engines that have no registered adapter, which would otherwise only fail
+// when the tables are queried.
+type ErrMissingAdapters struct {
+	CanonicError
+	// Missing maps each engine without an adapter to the tables that
+	// reference it.
+	Missing map[string][]string
+}
+
+// NewMissingAdapters creates a new ErrMissingAdapters.
+func NewMissingAdapters(missing map[string][]string) *ErrMissingAdapters {
+	engines := make([]string, 0, len(missing))
+	for engine := range missing {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+
+	details := make([]string, len(engines))
+	for i, engine := range engines {
+		details[i] = fmt.Sprintf("%s (%s)", engine, strings.Join(missing[engine], ", "))
+	}
+
+	return &ErrMissingAdapters{
+		CanonicError: CanonicError{
+			Code:       CodeEngine,
+			Message:    fmt.Sprintf("tables reference engines with no registered adapter: %s", strings.Join(details, "; ")),
+			Reason:     "queries on these tables would fail at query time",
+			Suggestion: fmt.Sprintf("register adapters for %s, or assign the tables to a registered engine", strings.Join(engines, ", ")),
+		},
+		Missing: missing,
+	}
+}
@@ -0,0 +1,164 @@
+package errors
+
+import stderrors "errors"
+
+// Sentinel errors name the category of a typed error, so callers can match
+// it with errors.Is without asserting its type:
+//
+//	if errors.Is(err, canonicerrors.ErrForbidden) { ... }
+//
+// Each typed error reports the category it belongs to through its Is
+// method. An error wrapping another, such as ErrSubQueryFailed, matches the
+// category of its Cause.
+var (
+	// ErrNotFound matches errors for tables, engines and catalog objects
+	// that do not exist.
+	ErrNotFound = stderrors.New("not found")
+
+	// ErrForbidden matches errors for operations the caller, the table or
+	// the gateway's policy does not allow.
+	ErrForbidden = stderrors.New("forbidden")
+
+	// ErrUnauthenticated matches authentication failures.
+	ErrUnauthenticated = stderrors.New("unauthenticated")
+
+	// ErrInvalid matches errors for queries and definitions that are
+	// invalid or use unsupported constructs.
+	ErrInvalid = stderrors.New("invalid request")
+
+	// ErrConflict matches errors for definitions that conflict with
+	// existing ones.
+	ErrConflict = stderrors.New("conflict")
+
+	// ErrUnavailable matches errors for engines, the gateway and the
+	// metadata database being unavailable.
+	ErrUnavailable = stderrors.New("unavailable")
+
+	// ErrLimitExceeded matches errors for queries exceeding a configured
+	// limit.
+	ErrLimitExceeded = stderrors.New("limit exceeded")
+
+	// ErrTimeout matches errors for engines that did not answer in time.
+	ErrTimeout = stderrors.New("timeout")
+
+	// ErrInternal matches failures of canonica itself.
+	ErrInternal = stderrors.New("internal error")
+)
+
+// Is reports whether target is ErrForbidden.
+func (e *ErrCapabilityDenied) Is(target error) bool { return target == ErrForbidden }
+
+// Is reports whether target is ErrForbidden.
+func (e *ErrConstraintViolation) Is(target error) bool { return target == ErrForbidden }
+
+// Is reports whether target is ErrNotFound.
+func (e *ErrTableNotFound) Is(target error) bool { return target == ErrNotFound }
+
+// Is reports whether target is ErrUnavailable.
+func (e *ErrEngineUnavailable) Is(target error) bool { return target == ErrUnavailable }
+
+// Is reports whether target is ErrUnauthenticated.
+func (e *ErrAuthFailed) Is(target error) bool { return target == ErrUnauthenticated }
+
+// Is reports whether target is ErrForbidden.
+func (e *ErrAccessDenied) Is(target error) bool { return target == ErrForbidden }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrQueryRejected) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrForbidden.
+func (e *ErrWriteNotAllowed) Is(target error) bool { return target == ErrForbidden }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrAmbiguousTable) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrInvalidTableDefinition) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrConflict.
+func (e *ErrTableAlreadyExists) Is(target error) bool { return target == ErrConflict }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrUnsupportedSyntax) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrVendorHint) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrUnavailable.
+func (e *ErrGatewayUnavailable) Is(target error) bool { return target == ErrUnavailable }
+
+// Is reports whether target is ErrUnavailable.
+func (e *ErrDatabaseUnavailable) Is(target error) bool { return target == ErrUnavailable }
+
+// Is reports whether target is ErrConflict.
+func (e *ErrMetadataConflict) Is(target error) bool { return target == ErrConflict }
+
+// Is reports whether target is ErrInternal.
+func (e *ErrBootstrapError) Is(target error) bool { return target == ErrInternal }
+
+// Is reports whether target is ErrInternal.
+func (e *ErrMigrationFailed) Is(target error) bool { return target == ErrInternal }
+
+// Is reports whether target is ErrInternal.
+func (e *ErrPlannerError) Is(target error) bool { return target == ErrInternal }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrCrossEngineQuery) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrSchemaRequired) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is the sentinel of the engine error's category.
+// Unclassified engine errors match none.
+func (e *ErrEngineQuery) Is(target error) bool {
+	switch e.Category {
+	case EngineErrorSyntax:
+		return target == ErrInvalid
+	case EngineErrorPermission:
+		return target == ErrForbidden
+	case EngineErrorNotFound:
+		return target == ErrNotFound
+	case EngineErrorTransient:
+		return target == ErrUnavailable
+	case EngineErrorResource:
+		return target == ErrLimitExceeded
+	default:
+		return false
+	}
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *ErrTooManyEngines) Is(target error) bool { return target == ErrLimitExceeded }
+
+// Is reports whether target is ErrNotFound.
+func (e *ErrEngineNotFound) Is(target error) bool { return target == ErrNotFound }
+
+// Is reports whether target is ErrForbidden.
+func (e *ErrEngineNotAllowed) Is(target error) bool { return target == ErrForbidden }
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *ErrMemoryBudgetExceeded) Is(target error) bool { return target == ErrLimitExceeded }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrDuplicateColumn) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrCartesianJoin) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *ErrConcurrencyLimit) Is(target error) bool { return target == ErrLimitExceeded }
+
+// Is reports whether target is ErrTimeout.
+func (e *ErrEngineTimeout) Is(target error) bool { return target == ErrTimeout }
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *ErrPartitionScanLimit) Is(target error) bool { return target == ErrLimitExceeded }
+
+// Is reports whether target is ErrNotFound.
+func (e *ErrCatalogObjectNotFound) Is(target error) bool { return target == ErrNotFound }
+
+// Is reports whether target is ErrInvalid.
+func (e *ErrJoinKeyCoercion) Is(target error) bool { return target == ErrInvalid }
+
+// Is reports whether target is ErrUnavailable.
+func (e *ErrMissingAdapters) Is(target error) bool { return target == ErrUnavailable }
@@ -0,0 +1,176 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+)
+
+// CostTier classifies a query by its estimated cost, for admission control.
+type CostTier string
+
+const (
+	// CostTierCheap queries are never limited.
+	CostTierCheap CostTier = "cheap"
+
+	// CostTierMedium queries are estimated to take at least
+	// CostTierLimits.MediumThreshold.
+	CostTierMedium CostTier = "medium"
+
+	// CostTierExpensive queries are estimated to take at least
+	// CostTierLimits.ExpensiveThreshold.
+	CostTierExpensive CostTier = "expensive"
+)
+
+// Default cost tier limits. Only expensive queries are limited by default.
+const (
+	DefaultMediumThreshold    = 1 * time.Second
+	DefaultExpensiveThreshold = 30 * time.Second
+	DefaultMaxExpensive       = 4
+	DefaultTierQueueTimeout   = 30 * time.Second
+)
+
+// CostTierLimits configures how many queries of each cost tier may run at
+// once, system-wide. Request rate limits treat every request alike; a single
+// huge cross-engine join can overload an engine on its own, so concurrency
+// is limited by estimated cost instead.
+type CostTierLimits struct {
+	// MediumThreshold is the estimated time from which a query is medium.
+	MediumThreshold time.Duration
+
+	// ExpensiveThreshold is the estimated time from which a query is
+	// expensive. It must not be below MediumThreshold.
+	ExpensiveThreshold time.Duration
+
+	// MaxMedium is the number of medium queries that may run at once.
+	// Zero is unlimited.
+	MaxMedium int
+
+	// MaxExpensive is the number of expensive queries that may run at once.
+	// Zero is unlimited.
+	MaxExpensive int
+
+	// QueueTimeout is how long a query waits for a slot of its tier before
+	// it is rejected with ErrConcurrencyLimit. Zero rejects it immediately.
+	QueueTimeout time.Duration
+}
+
+// DefaultCostTierLimits returns the default limits: at most
+// DefaultMaxExpensive expensive queries at once, medium and cheap queries
+// unlimited.
+func DefaultCostTierLimits() CostTierLimits {
+	return CostTierLimits{
+		MediumThreshold:    DefaultMediumThreshold,
+		ExpensiveThreshold: DefaultExpensiveThreshold,
+		MaxExpensive:       DefaultMaxExpensive,
+		QueueTimeout:       DefaultTierQueueTimeout,
+	}
+}
+
+// CostTierLimiter admits queries by cost tier: at most the configured number
+// of queries of a tier run at once, and further ones queue for a free slot.
+// Cheap queries are always admitted.
+type CostTierLimiter struct {
+	limits CostTierLimits
+	slots  map[CostTier]chan struct{}
+}
+
+// NewCostTierLimiter creates a limiter. It returns an error if the limits
+// are inconsistent.
+func NewCostTierLimiter(limits CostTierLimits) (*CostTierLimiter, error) {
+	if limits.MediumThreshold < 0 || limits.ExpensiveThreshold < limits.MediumThreshold {
+		return nil, fmt.Errorf("cost tier thresholds must satisfy 0 <= medium (%s) <= expensive (%s)",
+			limits.MediumThreshold, limits.ExpensiveThreshold)
+	}
+	if limits.MaxMedium < 0 || limits.MaxExpensive < 0 {
+		return nil, fmt.Errorf("cost tier concurrency limits must not be negative")
+	}
+	if limits.QueueTimeout < 0 {
+		return nil, fmt.Errorf("cost tier queue timeout must not be negative, got %s", limits.QueueTimeout)
+	}
+
+	l := &CostTierLimiter{limits: limits, slots: make(map[CostTier]chan struct{})}
+	if limits.MaxMedium > 0 {
+		l.slots[CostTierMedium] = make(chan struct{}, limits.MaxMedium)
+	}
+	if limits.MaxExpensive > 0 {
+		l.slots[CostTierExpensive] = make(chan struct{}, limits.MaxExpensive)
+	}
+	return l, nil
+}
+
+// Limits returns the limiter's configuration.
+func (l *CostTierLimiter) Limits() CostTierLimits {
+	return l.limits
+}
+
+// Classify returns the cost tier of a query estimated to take estimated.
+func (l *CostTierLimiter) Classify(estimated time.Duration) CostTier {
+	switch {
+	case estimated >= l.limits.ExpensiveThreshold:
+		return CostTierExpensive
+	case estimated >= l.limits.MediumThreshold:
+		return CostTierMedium
+	default:
+		return CostTierCheap
+	}
+}
+
+// Running returns the number of admitted queries of a tier that have not
+// been released. It is always zero for unlimited tiers.
+func (l *CostTierLimiter) Running(tier CostTier) int {
+	return len(l.slots[tier])
+}
+
+// Acquire admits a query of a tier, waiting up to the queue timeout for a
+// slot. The returned release function frees the slot and is safe to call
+// more than once. It returns ErrConcurrencyLimit when no slot frees up in
+// time, or the context's error if it is done first.
+func (l *CostTierLimiter) Acquire(ctx context.Context, tier CostTier) (func(), error) {
+	slots, ok := l.slots[tier]
+	if !ok {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return l.releaser(slots), nil
+	default:
+	}
+	if l.limits.QueueTimeout == 0 {
+		return nil, cerrors.NewConcurrencyLimit(string(tier), cap(slots), 0)
+	}
+
+	timer := time.NewTimer(l.limits.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return l.releaser(slots), nil
+	case <-timer.C:
+		return nil, cerrors.NewConcurrencyLimit(string(tier), cap(slots), l.limits.QueueTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *CostTierLimiter) releaser(slots chan struct{}) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-slots })
+	}
+}
+
+// admittedStream holds a cost tier slot until the result stream is closed.
+type admittedStream struct {
+	ResultStream
+	release func()
+}
+
+// Close closes the stream and releases its slot.
+func (s *admittedStream) Close() error {
+	defer s.release()
+	return s.ResultStream.Close()
+}
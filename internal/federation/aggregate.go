@@ -0,0 +1,629 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderedStream is a ResultStream whose rows are known to arrive sorted.
+// Streams produced by a merge join or by a sub-query with a pushed ORDER BY
+// can declare their order so post-join aggregation can run in a single pass.
+type OrderedStream interface {
+	ResultStream
+
+	// SortOrder returns the columns the rows are sorted by, most significant first.
+	SortOrder() []string
+}
+
+// orderedStream wraps a stream with a declared sort order.
+type orderedStream struct {
+	ResultStream
+	order []string
+}
+
+// NewOrderedStream declares that rows from stream are sorted by the given columns.
+// The caller is responsible for the order actually holding.
+func NewOrderedStream(stream ResultStream, order []string) OrderedStream {
+	return &orderedStream{ResultStream: stream, order: order}
+}
+
+// SortOrder implements OrderedStream.
+func (o *orderedStream) SortOrder() []string {
+	return o.order
+}
+
+// sortedByGroupKeys reports whether the stream is sorted such that all rows of a
+// group are adjacent, i.e. the leading sort columns are exactly the GROUP BY keys.
+func sortedByGroupKeys(stream ResultStream, groupBy []string) bool {
+	if len(groupBy) == 0 {
+		return false
+	}
+	ordered, ok := stream.(OrderedStream)
+	if !ok {
+		return false
+	}
+	order := ordered.SortOrder()
+	if len(order) < len(groupBy) {
+		return false
+	}
+
+	keys := make(map[string]bool, len(groupBy))
+	for _, col := range groupBy {
+		keys[columnName(col)] = true
+	}
+	for _, col := range order[:len(groupBy)] {
+		if !keys[columnName(col)] {
+			return false
+		}
+	}
+	return true
+}
+
+// NewAggregationStream selects the aggregation strategy for the source.
+// Sources sorted by the GROUP BY keys are aggregated in a streaming,
+// constant-memory pass; everything else is buffered and grouped by hash.
+func NewAggregationStream(source ResultStream, aggregations []*Aggregation, groupBy []string) ResultStream {
+	return newAggregationStream(source, aggregations, groupBy, nil, nil)
+}
+
+// newAggregationStream is NewAggregationStream with the hash strategy's
+// groups charged against budget. When partials is set, source rows hold
+// the partial aggregates of a sub-query, which are combined rather than
+// aggregated.
+func newAggregationStream(source ResultStream, aggregations []*Aggregation, groupBy []string, partials []partialColumns, budget *MemoryBudget) ResultStream {
+	if sortedByGroupKeys(source, groupBy) {
+		return &streamingAggregateStream{
+			source:       source,
+			aggregations: aggregations,
+			groupBy:      groupBy,
+			partials:     partials,
+		}
+	}
+	return &aggregatingStream{
+		source:       source,
+		aggregations: aggregations,
+		groupBy:      groupBy,
+		partials:     partials,
+		budget:       budget,
+	}
+}
+
+// OutputName returns the column name of the aggregate in result rows.
+func (a *Aggregation) OutputName() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(a.Function), a.Column)
+}
+
+// aggregatingStream buffers all source rows, groups them by the GROUP BY keys,
+// and emits one row per group in first-seen order.
+type aggregatingStream struct {
+	source       ResultStream
+	aggregations []*Aggregation
+	groupBy      []string
+	partials     []partialColumns
+	budget       *MemoryBudget
+	done         bool
+	results      []Row
+	index        int
+}
+
+func (a *aggregatingStream) Schema() *ResultSchema {
+	return aggregateSchema(a.source.Schema(), a.aggregations, a.groupBy, a.partials)
+}
+
+func (a *aggregatingStream) Next(ctx context.Context) (Row, error) {
+	if !a.done {
+		if err := a.compute(ctx); err != nil {
+			return nil, err
+		}
+		a.done = true
+	}
+
+	if a.index >= len(a.results) {
+		return nil, nil
+	}
+	row := a.results[a.index]
+	a.index++
+	return row, nil
+}
+
+// compute drains the source and builds one result row per group.
+func (a *aggregatingStream) compute(ctx context.Context) error {
+	groups := make(map[string]*groupState)
+	var order []string
+
+	for {
+		row, err := a.source.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+
+		key := groupKey(row, a.groupBy)
+		group, ok := groups[key]
+		if !ok {
+			if err := a.budget.Reserve(OperatorAggregate, estimateRowBytes(row)); err != nil {
+				return err
+			}
+			group = newGroupState(row, a.groupBy, a.aggregations, a.partials)
+			groups[key] = group
+			order = append(order, key)
+		}
+		if err := group.add(row); err != nil {
+			return err
+		}
+	}
+
+	// A global aggregate over no rows still yields a single row.
+	if len(order) == 0 && len(a.groupBy) == 0 {
+		group := newGroupState(nil, nil, a.aggregations, a.partials)
+		a.results = append(a.results, group.result())
+		return nil
+	}
+
+	for _, key := range order {
+		a.results = append(a.results, groups[key].result())
+	}
+	return nil
+}
+
+func (a *aggregatingStream) Close() error {
+	return a.source.Close()
+}
+
+func (a *aggregatingStream) EstimatedRows() int64 {
+	return 1 // Aggregation typically returns few rows
+}
+
+// streamingAggregateStream aggregates input that is sorted by the GROUP BY keys.
+// Only the current group is held in memory; a group is emitted as soon as the
+// key changes.
+type streamingAggregateStream struct {
+	source       ResultStream
+	aggregations []*Aggregation
+	groupBy      []string
+	partials     []partialColumns
+	current      *groupState
+	currentKey   string
+	exhausted    bool
+}
+
+func (s *streamingAggregateStream) Schema() *ResultSchema {
+	return aggregateSchema(s.source.Schema(), s.aggregations, s.groupBy, s.partials)
+}
+
+func (s *streamingAggregateStream) Next(ctx context.Context) (Row, error) {
+	if s.exhausted {
+		return nil, nil
+	}
+
+	for {
+		row, err := s.source.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			s.exhausted = true
+			if s.current == nil {
+				return nil, nil
+			}
+			return s.current.result(), nil
+		}
+
+		key := groupKey(row, s.groupBy)
+		if s.current != nil && key != s.currentKey {
+			// Key changed: the previous group is complete.
+			finished := s.current
+			s.current = newGroupState(row, s.groupBy, s.aggregations, s.partials)
+			s.currentKey = key
+			if err := s.current.add(row); err != nil {
+				return nil, err
+			}
+			return finished.result(), nil
+		}
+
+		if s.current == nil {
+			s.current = newGroupState(row, s.groupBy, s.aggregations, s.partials)
+			s.currentKey = key
+		}
+		if err := s.current.add(row); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *streamingAggregateStream) Close() error {
+	return s.source.Close()
+}
+
+func (s *streamingAggregateStream) EstimatedRows() int64 {
+	return s.source.EstimatedRows()
+}
+
+// aggregateSchema returns the schema of aggregated rows: the GROUP BY keys,
+// then one column per aggregate named by its output name. Types are derived
+// from the source columns where the source declares them, or from the
+// partial aggregate columns when partials is set.
+func aggregateSchema(source *ResultSchema, aggregations []*Aggregation, groupBy []string, partials []partialColumns) *ResultSchema {
+	sourceType := func(col string) string {
+		if typ := columnType(source, col); typ != "" {
+			return typ
+		}
+		return columnType(source, columnName(col))
+	}
+
+	columns := make([]ColumnDef, 0, len(groupBy)+len(aggregations))
+	for _, col := range groupBy {
+		columns = append(columns, ColumnDef{Name: columnName(col), Type: sourceType(col)})
+	}
+	for i, agg := range aggregations {
+		column := agg.Column
+		if partials != nil && partials[i].value != "" {
+			column = partials[i].value
+		}
+		var typ string
+		switch strings.ToUpper(agg.Function) {
+		case "COUNT":
+			typ = "BIGINT"
+		case "AVG":
+			typ = "DOUBLE"
+		case "SUM":
+			// Integer sums widen to BIGINT; others keep the column type
+			typ = sourceType(column)
+			switch classifyKeyType(typ) {
+			case keyInteger:
+				typ = "BIGINT"
+			case keyFloat:
+				typ = "DOUBLE"
+			}
+		default:
+			typ = sourceType(column)
+		}
+		columns = append(columns, ColumnDef{Name: agg.OutputName(), Type: typ})
+	}
+	return &ResultSchema{Columns: columns}
+}
+
+// groupState holds the grouping values and accumulators for one group.
+type groupState struct {
+	keys         Row
+	aggregations []*Aggregation
+	partials     []partialColumns
+	accumulators []*accumulator
+}
+
+func newGroupState(first Row, groupBy []string, aggregations []*Aggregation, partials []partialColumns) *groupState {
+	keys := make(Row, len(groupBy))
+	for _, col := range groupBy {
+		keys[columnName(col)] = rowValue(first, col)
+	}
+
+	accs := make([]*accumulator, len(aggregations))
+	for i, agg := range aggregations {
+		accs[i] = &accumulator{function: strings.ToUpper(agg.Function)}
+	}
+
+	return &groupState{keys: keys, aggregations: aggregations, partials: partials, accumulators: accs}
+}
+
+func (g *groupState) add(row Row) error {
+	for i, agg := range g.aggregations {
+		if g.partials != nil {
+			if err := g.accumulators[i].combine(rowValue(row, g.partials[i].value), rowValue(row, g.partials[i].count)); err != nil {
+				return fmt.Errorf("aggregation %s: %w", agg.OutputName(), err)
+			}
+			continue
+		}
+
+		var value interface{}
+		if agg.Column == "*" {
+			value = countStar{}
+		} else {
+			value = rowValue(row, agg.Column)
+		}
+		if err := g.accumulators[i].add(value); err != nil {
+			return fmt.Errorf("aggregation %s: %w", agg.OutputName(), err)
+		}
+	}
+	return nil
+}
+
+func (g *groupState) result() Row {
+	row := make(Row, len(g.keys)+len(g.aggregations))
+	for k, v := range g.keys {
+		row[k] = v
+	}
+	for i, agg := range g.aggregations {
+		row[agg.OutputName()] = g.accumulators[i].result()
+	}
+	return row
+}
+
+// countStar marks a COUNT(*) input, which counts every row including NULLs.
+type countStar struct{}
+
+// accumulator computes a single aggregate function incrementally.
+type accumulator struct {
+	function string
+	count    int64
+	sumInt   int64
+	sumFloat float64
+	isFloat  bool
+	extreme  interface{}
+}
+
+func (a *accumulator) add(value interface{}) error {
+	if _, ok := value.(countStar); ok {
+		a.count++
+		return nil
+	}
+	if value == nil {
+		return nil // NULLs are ignored by every aggregate except COUNT(*)
+	}
+
+	switch a.function {
+	case "COUNT":
+		a.count++
+	case "SUM", "AVG":
+		a.count++
+		if i, ok := toInt64(value); ok && !a.isFloat {
+			a.sumInt += i
+			return nil
+		}
+		f, ok := toFloat64(value)
+		if !ok {
+			return fmt.Errorf("cannot %s non-numeric value of type %T", a.function, value)
+		}
+		if !a.isFloat {
+			a.isFloat = true
+			a.sumFloat = float64(a.sumInt)
+		}
+		a.sumFloat += f
+	case "MIN", "MAX":
+		a.count++
+		if a.extreme == nil {
+			a.extreme = value
+			return nil
+		}
+		cmp, err := compareValues(value, a.extreme)
+		if err != nil {
+			return err
+		}
+		if (a.function == "MIN" && cmp < 0) || (a.function == "MAX" && cmp > 0) {
+			a.extreme = value
+		}
+	default:
+		return fmt.Errorf("unsupported aggregate function %s", a.function)
+	}
+	return nil
+}
+
+// combine adds the partial aggregate of a set of rows: sums of sums and of
+// counts, the least minimum or greatest maximum, and an average from its
+// partial sum and count.
+func (a *accumulator) combine(value, count interface{}) error {
+	switch a.function {
+	case "COUNT":
+		n, ok := toInt64(count)
+		if !ok && count != nil {
+			return fmt.Errorf("partial count of type %T is not an integer", count)
+		}
+		a.count += n
+		return nil
+	case "AVG":
+		n, ok := toInt64(count)
+		if !ok || n == 0 || value == nil {
+			return nil
+		}
+		if err := a.add(value); err != nil {
+			return err
+		}
+		a.count += n - 1
+		return nil
+	default:
+		return a.add(value)
+	}
+}
+
+func (a *accumulator) result() interface{} {
+	switch a.function {
+	case "COUNT":
+		return a.count
+	case "SUM":
+		if a.count == 0 {
+			return nil
+		}
+		if a.isFloat {
+			return a.sumFloat
+		}
+		return a.sumInt
+	case "AVG":
+		if a.count == 0 {
+			return nil
+		}
+		if a.isFloat {
+			return a.sumFloat / float64(a.count)
+		}
+		return float64(a.sumInt) / float64(a.count)
+	default:
+		return a.extreme
+	}
+}
+
+// partialColumns are the columns of the partial aggregates a sub-query
+// computes for one of the query's aggregations.
+type partialColumns struct {
+	value string // partial SUM, MIN or MAX; empty for COUNT
+	count string // partial COUNT, for COUNT and AVG; empty otherwise
+}
+
+// partialAggregateColumns names the partial aggregate columns of each
+// aggregation.
+func partialAggregateColumns(aggregations []*Aggregation) []partialColumns {
+	partials := make([]partialColumns, len(aggregations))
+	for i, agg := range aggregations {
+		value := fmt.Sprintf("canonic_partial_%d", i)
+		switch strings.ToUpper(agg.Function) {
+		case "COUNT":
+			partials[i].count = value + "_count"
+		case "AVG":
+			partials[i].value, partials[i].count = value, value+"_count"
+		default:
+			partials[i].value = value
+		}
+	}
+	return partials
+}
+
+// selectItems returns the select items computing the partial aggregates
+// of agg. An average is computed from its partial sum and count.
+func (p partialColumns) selectItems(agg *Aggregation) []string {
+	var items []string
+	if p.value != "" {
+		function := strings.ToUpper(agg.Function)
+		if function == "AVG" {
+			function = "SUM"
+		}
+		items = append(items, fmt.Sprintf("%s(%s) AS %s", function, agg.Column, p.value))
+	}
+	if p.count != "" {
+		items = append(items, fmt.Sprintf("COUNT(%s) AS %s", agg.Column, p.count))
+	}
+	return items
+}
+
+// groupKey builds a comparable key from the values of a row's columns, in
+// order, for GROUP BY, DISTINCT and set operations. NULLs encode alike, and
+// so do integers of any width, as engines return them in different types.
+func groupKey(row Row, columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		value := rowValue(row, col)
+		if n, ok := toInt64(value); ok {
+			value = n
+		}
+		parts[i] = fmt.Sprintf("%T:%v", value, value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// rowValue looks up a column in a row, accepting qualified (alias.col) names.
+func rowValue(row Row, col string) interface{} {
+	if row == nil {
+		return nil
+	}
+	if v, ok := row[col]; ok {
+		return v
+	}
+	return row[columnName(col)]
+}
+
+// columnName strips any table qualifier from a column reference.
+func columnName(col string) string {
+	if idx := strings.LastIndex(col, "."); idx >= 0 {
+		return col[idx+1:]
+	}
+	return col
+}
+
+// toInt64 converts integer values to int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// toFloat64 converts numeric values to float64.
+func toFloat64(v interface{}) (float64, bool) {
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	switch n := v.(type) {
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// compareValues orders two non-NULL values of comparable types.
+// Numbers compare across integer and float types; strings and times compare
+// with their own kind. Anything else is an explicit error.
+func compareValues(a, b interface{}) (int, error) {
+	if af, ok := toFloat64(a); ok {
+		bf, ok := toFloat64(b)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		switch {
+		case af < bf:
+			return -1, nil
+		case af > bf:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		return strings.Compare(av, bv), nil
+	case time.Time:
+		bv, ok := b.(time.Time)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		return av.Compare(bv), nil
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		switch {
+		case av == bv:
+			return 0, nil
+		case !av:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	default:
+		return 0, fmt.Errorf("values of type %T are not orderable", a)
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/canonica-labs/canonica/internal/catalog"
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/observability"
 	"github.com/canonica-labs/canonica/internal/sql"
 	"github.com/canonica-labs/canonica/internal/storage"
 )
@@ -46,6 +49,18 @@ type QueryAnalysis struct {
 	// Keyed by table full name.
 	PushablePredicates map[string][]*Predicate
 
+	// UnsupportedPredicates are the top-level WHERE predicates that are not
+	// simple single-table comparisons with a literal. The federated executor
+	// cannot evaluate them and rejects the query; EXPLAIN reports them as
+	// rejected.
+	UnsupportedPredicates []string
+
+	// UnsupportedJoinPredicates are the ON conjuncts that are neither
+	// equi-join conditions nor filters of a table before its join (see
+	// sql.LogicalPlan.JoinFilters). The federated executor cannot evaluate
+	// them and rejects the query; EXPLAIN reports them as rejected.
+	UnsupportedJoinPredicates []string
+
 	// RequiredColumns are columns needed from each table.
 	// Keyed by table full name.
 	RequiredColumns map[string][]string
@@ -53,11 +68,69 @@ type QueryAnalysis struct {
 	// Aggregations are aggregate functions (must be done post-join).
 	Aggregations []*Aggregation
 
+	// GroupBy lists the GROUP BY columns (must be done post-join).
+	GroupBy []string
+
+	// PartialAggregationTable is the full name of the table whose
+	// sub-query computes partial aggregates, grouped by
+	// PartialAggregationKeys, for the post-join aggregation to combine. It
+	// is set when every aggregation is decomposable and reads that table
+	// only, and the query has only inner joins; otherwise it is empty and
+	// every row is aggregated after the joins.
+	PartialAggregationTable string
+
+	// PartialAggregationKeys are the table's GROUP BY columns and join
+	// keys, qualified as in the query. Rows grouped on them join the same
+	// rows, so each partial aggregate joins the rows its rows would have.
+	PartialAggregationKeys []string
+
 	// OrderBy clauses (must be done post-join if cross-engine).
 	OrderBy []*OrderByClause
 
 	// Limit value (applied after join).
 	Limit *int
+
+	// Offset is the number of rows skipped before the Limit rows.
+	Offset *int
+
+	// Distinct is set for SELECT DISTINCT: duplicate rows are removed
+	// after the joins and aggregation.
+	Distinct bool
+
+	// DistinctColumns are the columns SELECT DISTINCT compares rows on: the
+	// SELECT-list columns, since joined rows also carry join keys and other
+	// columns the query reads. Nil compares rows whole, as for aggregated
+	// rows and SELECT DISTINCT *.
+	DistinctColumns []string
+
+	// SetOperation is the kind of a set operation, such as
+	// sql.SetOperationIntersect. Its operands run as separate sub-queries,
+	// whose results are combined rather than joined.
+	SetOperation string
+
+	// SetOperands are the left and right operands of SetOperation.
+	SetOperands []*SetOperand
+
+	// OutputColumns are the names of the columns the query produces, after
+	// the duplicate column policy renamed any repeated names.
+	OutputColumns []string
+
+	// Warnings are non-fatal notices about the query, such as table
+	// aliases that collide with reserved words.
+	Warnings []string
+}
+
+// SetOperand is an operand of a cross-engine set operation: a SELECT over
+// tables on a single engine.
+type SetOperand struct {
+	// SQL is the operand's SELECT.
+	SQL string
+
+	// Engine is the engine of the operand's tables.
+	Engine string
+
+	// Tables are the tables the operand reads.
+	Tables []*TableRef
 }
 
 // TableRef represents a table reference in a query.
@@ -76,6 +149,10 @@ type TableRef struct {
 
 	// Format is the table format (Iceberg, Delta, etc.).
 	Format catalog.TableFormat
+
+	// Version is the snapshot ID or version the query pins the table to
+	// with FOR VERSION AS OF. Empty reads the table's current state.
+	Version string
 }
 
 // FullName returns the fully qualified table name.
@@ -94,7 +171,16 @@ func (t *TableRef) DisplayName() string {
 	return t.FullName()
 }
 
-// JoinCondition represents a join condition between tables.
+// qualifier returns the name the query qualifies the table's columns with:
+// the alias if set, otherwise the unqualified table name.
+func (t *TableRef) qualifier() string {
+	if t.Alias != "" {
+		return t.Alias
+	}
+	return t.Name
+}
+
+// JoinCondition represents an equi-join condition between tables.
 type JoinCondition struct {
 	// Type is the join type (INNER, LEFT, etc.).
 	Type JoinType
@@ -102,17 +188,14 @@ type JoinCondition struct {
 	// LeftTable is the left side table (alias or name).
 	LeftTable string
 
-	// LeftCol is the left side column.
-	LeftCol string
-
 	// RightTable is the right side table (alias or name).
 	RightTable string
 
-	// RightCol is the right side column.
-	RightCol string
-
-	// Operator is the join operator (=, <, >, etc.).
-	Operator string
+	// LeftCols and RightCols are the columns of the equi-join key of each
+	// side, paired by position: one pair for ON a.x = b.x, two for
+	// ON a.x = b.x AND a.y = b.y.
+	LeftCols  []string
+	RightCols []string
 }
 
 // Predicate represents a WHERE clause predicate.
@@ -129,6 +212,10 @@ type Predicate struct {
 	// Value is the literal value being compared.
 	Value interface{}
 
+	// Values are the literals of an IN list, or the lower and upper bounds
+	// of a BETWEEN range. String literals keep their SQL quoting.
+	Values []interface{}
+
 	// Raw is the original SQL fragment.
 	Raw string
 }
@@ -144,6 +231,11 @@ type Aggregation struct {
 	// Alias is the result alias.
 	Alias string
 
+	// Decomposable reports whether the aggregate of a set of rows can be
+	// combined from the partial aggregates of its parts: SUM, COUNT, AVG,
+	// MIN and MAX without DISTINCT.
+	Decomposable bool
+
 	// Raw is the original SQL fragment.
 	Raw string
 }
@@ -155,22 +247,67 @@ type OrderByClause struct {
 
 	// Descending indicates DESC order.
 	Descending bool
+
+	// NullsFirst places NULLs before all other values (NULLS FIRST),
+	// whatever the direction. By default NULLs sort last, since engines
+	// disagree on the default and federated results must not depend on it.
+	NullsFirst bool
 }
 
 // Analyzer analyzes SQL queries for cross-engine federation.
 type Analyzer struct {
 	parser   *sql.Parser
 	metadata storage.TableRepository
+
+	// reservedWords are reserved in addition to the dialect keywords,
+	// e.g. keywords of the engines queries are routed to. Upper case.
+	reservedWords map[string]bool
+
+	// duplicateColumns is the policy for repeated output column names.
+	duplicateColumns DuplicateColumnPolicy
+
+	// cartesianJoins is the policy for joins without a join condition.
+	cartesianJoins CartesianJoinPolicy
 }
 
 // NewAnalyzer creates a new query analyzer.
 func NewAnalyzer(parser *sql.Parser, metadata storage.TableRepository) *Analyzer {
 	return &Analyzer{
-		parser:   parser,
-		metadata: metadata,
+		parser:           parser,
+		metadata:         metadata,
+		duplicateColumns: DuplicateColumnsReject,
+		cartesianJoins:   CartesianJoinsWarn,
 	}
 }
 
+// SetDuplicateColumnPolicy sets how queries producing repeated output
+// column names are handled. The default is DuplicateColumnsReject.
+func (a *Analyzer) SetDuplicateColumnPolicy(policy DuplicateColumnPolicy) {
+	a.duplicateColumns = policy
+}
+
+// SetCartesianJoinPolicy sets how queries joining tables without a join
+// condition are handled, whether or not they span engines. The default is
+// CartesianJoinsWarn.
+func (a *Analyzer) SetCartesianJoinPolicy(policy CartesianJoinPolicy) {
+	a.cartesianJoins = policy
+}
+
+// SetReservedWords sets words that are reserved in addition to the SQL
+// dialect keywords. Table aliases matching a reserved word produce a warning.
+func (a *Analyzer) SetReservedWords(words []string) {
+	a.reservedWords = make(map[string]bool, len(words))
+	for _, word := range words {
+		a.reservedWords[strings.ToUpper(word)] = true
+	}
+}
+
+// isReservedWord reports whether word is a dialect keyword or one of the
+// configured reserved words.
+func (a *Analyzer) isReservedWord(word string) bool {
+	return sql.IsReservedWord(word) || a.reservedWords[strings.ToUpper(word)]
+}
+
 // Analyze parses a SQL query and determines if it's a cross-engine query.
 // Per phase-9-spec.md §1.2.
 func (a *Analyzer) Analyze(ctx context.Context, sqlQuery string) (*QueryAnalysis, error) {
@@ -191,11 +328,26 @@ func (a *Analyzer) Analyze(ctx context.Context, sqlQuery string) (*QueryAnalysis
 		return nil, fmt.Errorf("federation: parse error: %w", err)
 	}
 
-	// Extract table references from the query
-	tables, err := a.extractTables(ctx, logicalPlan)
+	// Reject or rename repeated output column names
+	outputColumns, columnWarnings, err := a.duplicateColumns.resolveOutputColumns(logicalPlan.OutputColumns)
 	if err != nil {
 		return nil, err
 	}
+	analysis.OutputColumns = outputColumns
+
+	// Warn about or reject unconditional joins, on every engine
+	cartesianWarnings, err := a.cartesianJoins.check(logicalPlan.CartesianProducts)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cartesianWarnings {
+		observability.Diagnosticf("federation: %s", warning)
+	}
+
+	// Extract table references from the query
+	tables, warnings := a.extractTables(logicalPlan)
+	analysis.Warnings = append(warnings, columnWarnings...)
+	analysis.Warnings = append(analysis.Warnings, cartesianWarnings...)
 
 	if len(tables) == 0 {
 		return nil, fmt.Errorf("federation: no tables found in query")
@@ -208,16 +360,22 @@ func (a *Analyzer) Analyze(ctx context.Context, sqlQuery string) (*QueryAnalysis
 			return nil, fmt.Errorf("federation: table %s not found: %w", table.FullName(), err)
 		}
 
+		// A format override takes precedence over the detected format
+		if notice := vt.FormatOverrideNotice(); notice != "" {
+			observability.Diagnosticf("federation: %s", notice)
+		}
+		format := string(vt.EffectiveFormat())
+
 		// Determine engine from table metadata
 		if len(vt.Sources) > 0 && vt.Sources[0].Engine != "" {
 			table.Engine = vt.Sources[0].Engine
 		} else {
 			// Default based on format
-			table.Engine = a.defaultEngineForFormat(string(vt.Sources[0].Format))
+			table.Engine = a.defaultEngineForFormat(format)
 		}
 
 		if len(vt.Sources) > 0 {
-			table.Format = catalog.TableFormat(string(vt.Sources[0].Format))
+			table.Format = catalog.TableFormat(format)
 		}
 
 		analysis.TablesByEngine[table.Engine] = append(
@@ -232,29 +390,141 @@ func (a *Analyzer) Analyze(ctx context.Context, sqlQuery string) (*QueryAnalysis
 		return analysis, nil
 	}
 
+	// Window functions are forwarded to a single engine; the executor
+	// cannot evaluate them over joined rows
+	if logicalPlan.HasWindowFunction {
+		return nil, cerrors.NewUnsupportedSyntax(
+			"WINDOW FUNCTION (OVER clause) in a cross-engine query",
+			"window functions over tables on a single engine",
+		)
+	}
+
+	// Nor can it filter the groups of a HAVING clause after the joins
+	if logicalPlan.Having != "" {
+		return nil, cerrors.NewUnsupportedSyntax(
+			"HAVING in a cross-engine query",
+			"HAVING over tables on a single engine",
+		)
+	}
+
+	// The operands of a set operation run on their own engines
+	if logicalPlan.SetOperation != "" {
+		return a.analyzeSetOperation(analysis, logicalPlan)
+	}
+
+	// Version pins are carried into each sub-query; the rest of the query
+	// is analyzed without them
+	pins, sqlQuery := sql.ExtractVersionPins(sqlQuery)
+	for _, table := range tables {
+		table.Version = pins[table.FullName()]
+	}
+
 	// Extract join conditions
-	analysis.Joins = a.extractJoins(sqlQuery, tables)
+	analysis.Joins = a.extractJoins(logicalPlan.JoinConditions)
+
+	analysis.UnsupportedJoinPredicates = logicalPlan.JoinFilters
+	if err := a.checkOuterJoinFilters(analysis.Joins, logicalPlan.ColumnPredicates, tables); err != nil {
+		return nil, err
+	}
 
 	// Extract pushable predicates
-	analysis.PushablePredicates = a.extractPushablePredicates(sqlQuery, tables)
+	analysis.PushablePredicates = a.extractPushablePredicates(logicalPlan.ColumnPredicates, tables)
+	analysis.UnsupportedPredicates = a.extractUnsupportedPredicates(
+		logicalPlan.WherePredicates, logicalPlan.ColumnPredicates, tables)
 
 	// Extract required columns per table
-	analysis.RequiredColumns = a.extractRequiredColumns(sqlQuery, tables, analysis.Joins)
+	analysis.RequiredColumns = a.extractRequiredColumns(logicalPlan.Columns, tables, analysis.Joins)
 
-	// Extract aggregations
-	analysis.Aggregations = a.extractAggregations(sqlQuery)
+	// Extract aggregations, which the executor computes over joined rows
+	aggregations, err := a.extractAggregations(logicalPlan)
+	if err != nil {
+		return nil, err
+	}
+	analysis.Aggregations = aggregations
+
+	analysis.GroupBy = logicalPlan.GroupBy
+
+	// Compute partial aggregates in a sub-query where possible
+	a.planPartialAggregation(analysis, tables)
 
 	// Extract ORDER BY
-	analysis.OrderBy = a.extractOrderBy(sqlQuery)
+	analysis.OrderBy, err = a.extractOrderBy(logicalPlan.OrderBy, analysis.Aggregations)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis.Limit = logicalPlan.Limit
+	analysis.Offset = logicalPlan.Offset
 
-	// Extract LIMIT
-	analysis.Limit = a.extractLimit(sqlQuery)
+	analysis.Distinct = logicalPlan.Distinct
+	if analysis.Distinct {
+		aggregated := len(analysis.Aggregations) > 0 || len(analysis.GroupBy) > 0
+		analysis.DistinctColumns, err = a.extractDistinctColumns(logicalPlan, aggregated)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return analysis, nil
+}
+
+// analyzeSetOperation analyzes a cross-engine set operation of two SELECTs,
+// each over tables on a single engine. Its ORDER BY, LIMIT and OFFSET apply
+// to the combined rows.
+func (a *Analyzer) analyzeSetOperation(analysis *QueryAnalysis, logicalPlan *sql.LogicalPlan) (*QueryAnalysis, error) {
+	if len(logicalPlan.SetOperands) != 2 {
+		return nil, cerrors.NewUnsupportedSyntax(
+			"nested SET OPERATION in a cross-engine query",
+			"a set operation of two SELECTs",
+		)
+	}
+
+	resolved := make(map[string]*TableRef)
+	for _, tables := range analysis.TablesByEngine {
+		for _, table := range tables {
+			resolved[table.FullName()] = table
+		}
+	}
+	for _, operandSQL := range logicalPlan.SetOperands {
+		operandPlan, err := a.parser.Parse(operandSQL)
+		if err != nil {
+			return nil, fmt.Errorf("federation: parse error: %w", err)
+		}
+		tables, _ := a.extractTables(operandPlan)
+		operand := &SetOperand{SQL: operandSQL, Tables: tables}
+		for _, table := range tables {
+			ref, ok := resolved[table.FullName()]
+			if !ok {
+				return nil, fmt.Errorf("federation: table %s not found", table.FullName())
+			}
+			if operand.Engine != "" && operand.Engine != ref.Engine {
+				return nil, cerrors.NewUnsupportedSyntax(
+					"SET OPERATION operand spanning engines",
+					"operands that each read tables on a single engine",
+				)
+			}
+			operand.Engine = ref.Engine
+			table.Engine, table.Format = ref.Engine, ref.Format
+		}
+		analysis.SetOperands = append(analysis.SetOperands, operand)
+	}
 
+	analysis.SetOperation = logicalPlan.SetOperation
+	orderBy, err := a.extractOrderBy(logicalPlan.OrderBy, nil)
+	if err != nil {
+		return nil, err
+	}
+	analysis.OrderBy = orderBy
+	analysis.Limit = logicalPlan.Limit
+	analysis.Offset = logicalPlan.Offset
 	return analysis, nil
 }
 
 // extractTables extracts table references from a logical plan.
-func (a *Analyzer) extractTables(ctx context.Context, plan *sql.LogicalPlan) ([]*TableRef, error) {
+// Aliases come from the parsed statement rather than the raw SQL, so an
+// alias that collides with a keyword cannot hide or misattribute a table.
+// It returns a warning for each alias that is a reserved word.
+func (a *Analyzer) extractTables(plan *sql.LogicalPlan) ([]*TableRef, []string) {
 	var tables []*TableRef
 
 	for _, tableName := range plan.Tables {
@@ -271,223 +541,370 @@ func (a *Analyzer) extractTables(ctx context.Context, plan *sql.LogicalPlan) ([]
 		tables = append(tables, ref)
 	}
 
-	// Also extract aliases from the original SQL
-	a.extractAliases(plan.RawSQL, tables)
+	aliases := make([]string, 0, len(plan.TableAliases))
+	for alias := range plan.TableAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var warnings []string
+	for _, alias := range aliases {
+		tableName := plan.TableAliases[alias]
+		if a.isReservedWord(alias) {
+			warnings = append(warnings, fmt.Sprintf(
+				"alias %q for table %s is a reserved word; quote it consistently or choose another alias",
+				alias, tableName))
+		}
+		for _, table := range tables {
+			if table.Alias == "" && table.FullName() == tableName {
+				table.Alias = alias
+				break
+			}
+		}
+	}
 
-	return tables, nil
+	return tables, warnings
 }
 
-// extractAliases extracts table aliases from raw SQL.
-func (a *Analyzer) extractAliases(rawSQL string, tables []*TableRef) {
-	// Pattern: table_name AS alias or table_name alias
-	aliasPattern := regexp.MustCompile(`(?i)(\w+(?:\.\w+)*)\s+(?:AS\s+)?(\w+)\s*(?:ON|JOIN|WHERE|,|$)`)
-
-	matches := aliasPattern.FindAllStringSubmatch(rawSQL, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			tableName := match[1]
-			alias := match[2]
-
-			// Skip SQL keywords
-			if isKeyword(alias) {
-				continue
-			}
-
-			// Find the table and set its alias
-			for _, table := range tables {
-				if table.FullName() == tableName || table.Name == tableName {
-					table.Alias = alias
-					break
+// extractJoins converts the join conditions of the parsed query. The
+// equalities of an ON clause between the same two tables form one
+// condition with a compound key.
+func (a *Analyzer) extractJoins(conditions []sql.JoinCondition) []*JoinCondition {
+	joins := make([]*JoinCondition, 0, len(conditions))
+	for _, cond := range conditions {
+		// An ON clause's equalities are listed together
+		if n := len(joins); n > 0 {
+			last := joins[n-1]
+			if last.Type == JoinType(cond.Type) {
+				switch {
+				case last.LeftTable == cond.LeftTable && last.RightTable == cond.RightTable:
+					last.addKey(cond.LeftColumn, cond.RightColumn)
+					continue
+				case last.LeftTable == cond.RightTable && last.RightTable == cond.LeftTable:
+					last.addKey(cond.RightColumn, cond.LeftColumn)
+					continue
 				}
 			}
 		}
+
+		joins = append(joins, &JoinCondition{
+			Type:       JoinType(cond.Type),
+			LeftTable:  cond.LeftTable,
+			RightTable: cond.RightTable,
+			LeftCols:   []string{cond.LeftColumn},
+			RightCols:  []string{cond.RightColumn},
+		})
 	}
+	return joins
 }
 
-// extractJoins extracts join conditions from SQL.
-func (a *Analyzer) extractJoins(sqlQuery string, tables []*TableRef) []*JoinCondition {
-	var joins []*JoinCondition
-
-	// Pattern: ON left.col = right.col
-	joinPattern := regexp.MustCompile(
-		`(?i)(?:(INNER|LEFT|RIGHT|FULL|CROSS)\s+)?JOIN\s+` +
-			`\S+\s+(?:AS\s+)?(\w+)\s+ON\s+` +
-			`(\w+)\.(\w+)\s*(=|<|>|<=|>=|<>)\s*(\w+)\.(\w+)`)
-
-	matches := joinPattern.FindAllStringSubmatch(sqlQuery, -1)
-	for _, match := range matches {
-		if len(match) >= 8 {
-			joinType := JoinTypeInner
-			if match[1] != "" {
-				joinType = JoinType(strings.ToUpper(match[1]))
+// checkOuterJoinFilters rejects ON predicates on the table an outer join
+// pads with NULLs when both tables of the join are on the same engine. Such
+// predicates are pushed into the WHERE clause of their table's sub-query,
+// which filters the table before the join only when the join runs after
+// the sub-query; within the sub-query it would filter the joined rows.
+func (a *Analyzer) checkOuterJoinFilters(joins []*JoinCondition, predicates []sql.ColumnPredicate, tables []*TableRef) error {
+	engines := make(map[string]string, len(tables))
+	for _, table := range tables {
+		engines[table.FullName()] = table.Engine
+	}
+	for _, join := range joins {
+		var padded string
+		switch join.Type {
+		case JoinTypeLeft:
+			padded = a.resolveTableRef(join.RightTable, tables)
+		case JoinTypeRight:
+			padded = a.resolveTableRef(join.LeftTable, tables)
+		default:
+			continue
+		}
+		left, right := a.resolveTableRef(join.LeftTable, tables), a.resolveTableRef(join.RightTable, tables)
+		if engines[left] != engines[right] {
+			continue
+		}
+		for _, pred := range predicates {
+			if pred.Conjunct == "" && a.resolveTableRef(pred.Table, tables) == padded {
+				return cerrors.NewUnsupportedSyntax(
+					fmt.Sprintf("ON condition %s of an outer join between tables on the same engine in a cross-engine query", pred.SQL),
+					"filters in the ON clause of an outer join between tables on different engines",
+				)
 			}
-
-			joins = append(joins, &JoinCondition{
-				Type:       joinType,
-				LeftTable:  match[3],
-				LeftCol:    match[4],
-				Operator:   match[5],
-				RightTable: match[6],
-				RightCol:   match[7],
-			})
 		}
 	}
+	return nil
+}
 
-	return joins
+// addKey adds a column pair to the condition's key.
+func (j *JoinCondition) addKey(left, right string) {
+	j.LeftCols = append(j.LeftCols, left)
+	j.RightCols = append(j.RightCols, right)
 }
 
-// extractPushablePredicates extracts predicates that can be pushed to each engine.
-// Per phase-9-spec.md §1.3: Only single-table predicates can be pushed.
-func (a *Analyzer) extractPushablePredicates(sqlQuery string, tables []*TableRef) map[string][]*Predicate {
+// extractPushablePredicates groups the single-column predicates of the
+// parsed query by the full name of their table. Predicates on columns of
+// unknown tables, such as those of derived tables, are skipped.
+func (a *Analyzer) extractPushablePredicates(columnPredicates []sql.ColumnPredicate, tables []*TableRef) map[string][]*Predicate {
 	predicates := make(map[string][]*Predicate)
+	for _, cp := range columnPredicates {
+		tableName := a.resolveTableRef(cp.Table, tables)
+		if tableName == "" {
+			continue
+		}
 
-	// Pattern: table.column operator value
-	// Only captures simple predicates with single table reference
-	predPattern := regexp.MustCompile(
-		`(?i)(\w+)\.(\w+)\s*(=|<|>|<=|>=|<>|LIKE|IN)\s*` +
-			`('[^']*'|\d+(?:\.\d+)?|\([^)]+\))`)
-
-	matches := predPattern.FindAllStringSubmatch(sqlQuery, -1)
-	for _, match := range matches {
-		if len(match) >= 5 {
-			tableRef := match[1]
-			column := match[2]
-			operator := match[3]
-			value := match[4]
-
-			// Find the full table name for this reference
-			tableName := a.resolveTableRef(tableRef, tables)
-			if tableName == "" {
-				continue
+		pred := &Predicate{
+			Table:    tableName,
+			Column:   cp.Column,
+			Operator: cp.Operator,
+			Raw:      cp.SQL,
+		}
+		switch cp.Operator {
+		case "IN", "BETWEEN":
+			for _, v := range cp.Values {
+				pred.Values = append(pred.Values, v)
 			}
+		default:
+			pred.Value = cp.Values[0]
+		}
+		predicates[tableName] = append(predicates[tableName], pred)
+	}
+	return predicates
+}
 
-			predicates[tableName] = append(predicates[tableName], &Predicate{
-				Table:    tableName,
-				Column:   column,
-				Operator: operator,
-				Value:    value,
-				Raw:      match[0],
-			})
+// extractUnsupportedPredicates returns the WHERE predicates that
+// extractPushablePredicates cannot represent: anything but a comparison of
+// a column of a known table with literals, such as function calls, OR
+// expressions, IN sub-queries and comparisons between the columns of two
+// tables.
+func (a *Analyzer) extractUnsupportedPredicates(
+	wherePredicates []string,
+	columnPredicates []sql.ColumnPredicate,
+	tables []*TableRef,
+) []string {
+	supported := make(map[string]bool)
+	for _, cp := range columnPredicates {
+		if cp.Conjunct != "" && a.resolveTableRef(cp.Table, tables) != "" {
+			supported[cp.Conjunct] = true
 		}
 	}
 
-	return predicates
+	var unsupported []string
+	for _, pred := range wherePredicates {
+		if !supported[pred] {
+			unsupported = append(unsupported, pred)
+		}
+	}
+	return unsupported
 }
 
-// extractRequiredColumns extracts columns needed from each table.
+// extractRequiredColumns returns the columns needed from each table, keyed
+// by full table name, from the columns the query references (see
+// sql.LogicalPlan.Columns) and the join keys. A table read with a wildcard
+// has no entry, and neither has any table when the query references
+// unqualified columns that cannot be attributed to one table: the
+// sub-queries of such tables read every column.
 func (a *Analyzer) extractRequiredColumns(
-	sqlQuery string,
+	referenced map[string][]string,
 	tables []*TableRef,
 	joins []*JoinCondition,
 ) map[string][]string {
 	columns := make(map[string][]string)
+	if _, ok := referenced[sql.UnresolvedColumnsKey]; ok {
+		return columns
+	}
 
-	// Pattern: table.column references
-	colPattern := regexp.MustCompile(`(?i)(\w+)\.(\w+)`)
-
-	matches := colPattern.FindAllStringSubmatch(sqlQuery, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			tableRef := match[1]
-			column := match[2]
-
-			tableName := a.resolveTableRef(tableRef, tables)
-			if tableName == "" {
-				continue
-			}
-
-			// Add column if not already present
-			if !contains(columns[tableName], column) {
-				columns[tableName] = append(columns[tableName], column)
-			}
+	for _, table := range tables {
+		cols, ok := referenced[table.FullName()]
+		if !ok || contains(cols, sql.StarColumn) {
+			continue
 		}
+		columns[table.FullName()] = append([]string(nil), cols...)
 	}
 
 	// Ensure join keys are included
 	for _, join := range joins {
-		leftTable := a.resolveTableRef(join.LeftTable, tables)
-		rightTable := a.resolveTableRef(join.RightTable, tables)
-
-		if leftTable != "" && !contains(columns[leftTable], join.LeftCol) {
-			columns[leftTable] = append(columns[leftTable], join.LeftCol)
-		}
-		if rightTable != "" && !contains(columns[rightTable], join.RightCol) {
-			columns[rightTable] = append(columns[rightTable], join.RightCol)
+		for _, key := range []struct {
+			table string
+			cols  []string
+		}{
+			{join.LeftTable, join.LeftCols},
+			{join.RightTable, join.RightCols},
+		} {
+			table := a.resolveTableRef(key.table, tables)
+			for _, col := range key.cols {
+				if cols, ok := columns[table]; ok && !contains(cols, col) {
+					columns[table] = append(cols, col)
+				}
+			}
 		}
 	}
 
 	return columns
 }
 
-// extractAggregations extracts aggregate functions from SQL.
-func (a *Analyzer) extractAggregations(sqlQuery string) []*Aggregation {
+// postJoinAggregates are the aggregate functions the executor computes over
+// joined rows.
+var postJoinAggregates = map[string]bool{"SUM": true, "COUNT": true, "AVG": true, "MIN": true, "MAX": true}
+
+// extractAggregations returns the aggregations of the SELECT list of a
+// cross-engine query, computed over joined rows. Aggregates the executor
+// cannot compute are rejected rather than dropped: DISTINCT aggregates,
+// aggregates of expressions or within expressions, and functions other
+// than SUM, COUNT, AVG, MIN and MAX. So are GROUP BY expressions other
+// than columns.
+func (a *Analyzer) extractAggregations(plan *sql.LogicalPlan) ([]*Aggregation, error) {
 	var aggs []*Aggregation
+	for _, call := range plan.Aggregates {
+		var form string
+		switch {
+		case !postJoinAggregates[call.Function]:
+			form = call.Function
+		case call.Distinct:
+			form = call.Function + "(DISTINCT ...)"
+		case !call.ColumnArgument:
+			form = call.Function + " of an expression"
+		case call.Nested:
+			form = call.Function + " within an expression"
+		}
+		if form != "" {
+			return nil, cerrors.NewUnsupportedSyntax(
+				form+" in a cross-engine query",
+				"SUM, COUNT, AVG, MIN or MAX of a column, or aggregates over tables on a single engine",
+			)
+		}
+		aggs = append(aggs, &Aggregation{
+			Function: call.Function,
+			Column:   call.Argument,
+			Alias:    call.Alias,
+			// The partial aggregates of a SELECT DISTINCT would not be
+			// distinct
+			Decomposable: !plan.Distinct,
+			Raw:          fmt.Sprintf("%s(%s)", call.Function, call.Argument),
+		})
+	}
 
-	// Pattern: SUM(col), COUNT(*), AVG(col), etc.
-	aggPattern := regexp.MustCompile(
-		`(?i)(SUM|COUNT|AVG|MIN|MAX)\s*\(\s*(\*|[\w.]+)\s*\)(?:\s+(?:AS\s+)?(\w+))?`)
-
-	matches := aggPattern.FindAllStringSubmatch(sqlQuery, -1)
-	for _, match := range matches {
-		if len(match) >= 3 {
-			agg := &Aggregation{
-				Function: strings.ToUpper(match[1]),
-				Column:   match[2],
-				Raw:      match[0],
-			}
-			if len(match) >= 4 && match[3] != "" {
-				agg.Alias = match[3]
-			}
-			aggs = append(aggs, agg)
+	for _, col := range plan.GroupBy {
+		if !groupByColumnPattern.MatchString(col) {
+			return nil, cerrors.NewUnsupportedSyntax(
+				"GROUP BY expression in a cross-engine query",
+				"GROUP BY columns, or expressions over tables on a single engine",
+			)
 		}
 	}
+	return aggs, nil
+}
 
-	return aggs
+// groupByColumnPattern matches a GROUP BY column, qualified or not.
+var groupByColumnPattern = regexp.MustCompile(`^\w+(\.\w+)*$`)
+
+// extractDistinctColumns returns the columns SELECT DISTINCT compares rows
+// on (see QueryAnalysis.DistinctColumns). Aggregated rows hold only their
+// group keys and aggregates, and are compared whole. SELECT lists of
+// expressions or table wildcards are rejected, since the executor cannot
+// tell their columns apart from the other columns of joined rows.
+func (a *Analyzer) extractDistinctColumns(plan *sql.LogicalPlan, aggregated bool) ([]string, error) {
+	switch {
+	case aggregated:
+		return nil, nil
+	case plan.SelectColumns != nil:
+		return plan.SelectColumns, nil
+	case len(plan.OutputColumns) == 1 && plan.OutputColumns[0] == sql.StarColumn:
+		return nil, nil
+	default:
+		return nil, cerrors.NewUnsupportedSyntax(
+			"SELECT DISTINCT of expressions or table wildcards in a cross-engine query",
+			"SELECT DISTINCT of columns, or of *",
+		)
+	}
 }
 
-// extractOrderBy extracts ORDER BY clauses from SQL.
-func (a *Analyzer) extractOrderBy(sqlQuery string) []*OrderByClause {
-	var orderBy []*OrderByClause
+// planPartialAggregation sets the table whose sub-query computes partial
+// aggregates, if any (see QueryAnalysis.PartialAggregationTable). Every
+// column the aggregations, and the GROUP BY columns, read must be
+// qualified, so that the table they belong to is known.
+func (a *Analyzer) planPartialAggregation(analysis *QueryAnalysis, tables []*TableRef) {
+	if len(analysis.Aggregations) == 0 {
+		return
+	}
+	for _, join := range analysis.Joins {
+		if join.Type != JoinTypeInner {
+			return
+		}
+	}
 
-	// Pattern: ORDER BY col [ASC|DESC]
-	orderPattern := regexp.MustCompile(
-		`(?i)ORDER\s+BY\s+(.+?)(?:\s+LIMIT|\s*$)`)
-
-	match := orderPattern.FindStringSubmatch(sqlQuery)
-	if len(match) >= 2 {
-		// Split by comma
-		parts := strings.Split(match[1], ",")
-		for _, part := range parts {
-			part = strings.TrimSpace(part)
-			desc := strings.Contains(strings.ToUpper(part), " DESC")
-
-			// Extract column name
-			colPattern := regexp.MustCompile(`(?i)([\w.]+)\s*(?:ASC|DESC)?`)
-			colMatch := colPattern.FindStringSubmatch(part)
-			if len(colMatch) >= 2 {
-				orderBy = append(orderBy, &OrderByClause{
-					Column:     colMatch[1],
-					Descending: desc,
-				})
-			}
+	// The aggregations must all read the same table
+	table := ""
+	for _, agg := range analysis.Aggregations {
+		if !agg.Decomposable {
+			return
+		}
+		if agg.Column == "*" {
+			continue
+		}
+		qualifier, _, ok := strings.Cut(agg.Column, ".")
+		name := a.resolveTableRef(qualifier, tables)
+		if !ok || name == "" || (table != "" && name != table) {
+			return
 		}
+		table = name
+	}
+	if table == "" {
+		return
 	}
 
-	return orderBy
-}
+	var keys []string
+	addKey := func(qualifier, col string) {
+		if key := qualifier + "." + col; a.resolveTableRef(qualifier, tables) == table && !contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, col := range analysis.GroupBy {
+		qualifier, name, ok := strings.Cut(col, ".")
+		if !ok {
+			return
+		}
+		addKey(qualifier, name)
+	}
+	for _, join := range analysis.Joins {
+		for _, col := range join.LeftCols {
+			addKey(join.LeftTable, col)
+		}
+		for _, col := range join.RightCols {
+			addKey(join.RightTable, col)
+		}
+	}
 
-// extractLimit extracts LIMIT clause from SQL.
-func (a *Analyzer) extractLimit(sqlQuery string) *int {
-	limitPattern := regexp.MustCompile(`(?i)LIMIT\s+(\d+)`)
+	analysis.PartialAggregationTable = table
+	analysis.PartialAggregationKeys = keys
+}
 
-	match := limitPattern.FindStringSubmatch(sqlQuery)
-	if len(match) >= 2 {
-		var limit int
-		fmt.Sscanf(match[1], "%d", &limit)
-		return &limit
+// extractOrderBy converts the ORDER BY items of a query into the clauses the
+// executor sorts result rows by. An item must name a value of each result
+// row: a column, a SELECT-list alias or an aggregate of the query.
+func (a *Analyzer) extractOrderBy(items []sql.OrderItem, aggregations []*Aggregation) ([]*OrderByClause, error) {
+	var orderBy []*OrderByClause
+	for _, item := range items {
+		column := item.Expr
+		if !item.Column {
+			column = ""
+			for _, agg := range aggregations {
+				if strings.EqualFold(item.Expr, agg.Raw) {
+					column = agg.OutputName()
+				}
+			}
+		}
+		if column == "" {
+			return nil, cerrors.NewUnsupportedSyntax(
+				"ORDER BY expression in a cross-engine query",
+				"ORDER BY columns, SELECT-list aliases or ordinals, or expressions over tables on a single engine",
+			)
+		}
+		orderBy = append(orderBy, &OrderByClause{
+			Column:     column,
+			Descending: item.Descending,
+			NullsFirst: item.NullsFirst,
+		})
 	}
-
-	return nil
+	return orderBy, nil
 }
 
 // resolveTableRef resolves an alias or name to a full table name.
@@ -501,7 +918,11 @@ func (a *Analyzer) resolveTableRef(ref string, tables []*TableRef) string {
 }
 
 // defaultEngineForFormat returns the default engine for a table format.
+// An engine configured with catalog.SetFormatEngines takes precedence.
 func (a *Analyzer) defaultEngineForFormat(format string) string {
+	if engine, ok := catalog.ConfiguredEngine(catalog.TableFormat(format)); ok {
+		return engine
+	}
 	switch strings.ToUpper(format) {
 	case "ICEBERG":
 		return "trino"
@@ -516,17 +937,6 @@ func (a *Analyzer) defaultEngineForFormat(format string) string {
 	}
 }
 
-// isKeyword checks if a string is a SQL keyword.
-func isKeyword(s string) bool {
-	keywords := map[string]bool{
-		"ON": true, "JOIN": true, "WHERE": true, "AND": true, "OR": true,
-		"FROM": true, "SELECT": true, "GROUP": true, "ORDER": true,
-		"HAVING": true, "LIMIT": true, "INNER": true, "LEFT": true,
-		"RIGHT": true, "FULL": true, "CROSS": true, "AS": true,
-	}
-	return keywords[strings.ToUpper(s)]
-}
-
 // contains checks if a slice contains a value.
 func contains(slice []string, value string) bool {
 	for _, v := range slice {
@@ -0,0 +1,181 @@
+package federation
+
+import "strings"
+
+// DefaultBroadcastThreshold is the estimated row count below which a join
+// input is broadcast: its join keys filter the other input's sub-query.
+const DefaultBroadcastThreshold = 1000
+
+// DefaultMaxBroadcastKeys is the largest number of distinct join keys a
+// broadcast join inlines into the other input's sub-query.
+const DefaultMaxBroadcastKeys = 1000
+
+// broadcastJoin is a broadcast join step between two sub-queries: the keys
+// of the small sub-query's results filter the large sub-query, which runs
+// once they are known.
+type broadcastJoin struct {
+	small int // sub-query index
+
+	smallKey string // qualified key column of the small results
+	largeKey string // qualified key column filtered in the large sub-query
+}
+
+// selectBroadcastJoins marks the join steps with an input estimated below
+// the broadcast threshold as broadcast joins. Only sub-queries whose row
+// count comes from table statistics are broadcast, and only into a
+// sub-query whose rows are dropped when unmatched: either side of an inner
+// join, or the padded side of an outer join. Strings are not inlined into
+// queries on numbers, so no join is broadcast when the key coercion policy
+// matches them. Broadcasting is a pushdown, so it is not planned when
+// pushdown is disabled.
+func (e *FederatedExecutor) selectBroadcastJoins(plans []*SubQueryPlan, joinPlan *JoinPlan, mode PushdownMode) {
+	if e.broadcastThreshold <= 0 || joinPlan == nil || mode == PushdownDisabled || e.keyPolicy == JoinKeyCoercionStringNumber {
+		return
+	}
+	index := make(map[string]int, len(plans))
+	for i, p := range plans {
+		index[p.SubQuery.ID] = i
+	}
+
+	for i := range joinPlan.Steps {
+		step := &joinPlan.Steps[i]
+		if step.Strategy != JoinStrategyHash {
+			continue
+		}
+		left, leftOK := index[step.LeftInput]
+		right, rightOK := index[step.RightInput]
+		if !leftOK || !rightOK {
+			continue
+		}
+
+		// An input is broadcast into one whose unmatched rows are dropped
+		keepsLeft := step.Type == JoinTypeLeft || step.Type == JoinTypeFull
+		keepsRight := step.Type == JoinTypeRight || step.Type == JoinTypeFull
+		intoLeft := !keepsLeft && e.broadcastable(plans[right], plans[left])
+		intoRight := !keepsRight && e.broadcastable(plans[left], plans[right])
+		var small int
+		switch {
+		case intoLeft && (!intoRight || plans[right].EstimatedRows <= plans[left].EstimatedRows):
+			small = right
+		case intoRight:
+			small = left
+		default:
+			continue
+		}
+		step.Strategy = JoinStrategyBroadcast
+		step.BroadcastInput = plans[small].SubQuery.ID
+		plans[small].RequiresMaterial = true
+	}
+}
+
+// broadcastable reports whether the results of small are estimated below
+// the broadcast threshold, and below those of large when known. A large
+// sub-query computing partial aggregates is not filtered, as its WHERE
+// clause precedes its GROUP BY.
+func (e *FederatedExecutor) broadcastable(small, large *SubQueryPlan) bool {
+	if large.SubQuery.PartialAggregates || !small.StatsKnown || small.EstimatedRows >= e.broadcastThreshold {
+		return false
+	}
+	return !large.StatsKnown || small.EstimatedRows < large.EstimatedRows
+}
+
+// broadcastJoins returns the broadcast join steps of a plan, by the index
+// of their large sub-query.
+func (p *ExecutionPlan) broadcastJoins() map[int]*broadcastJoin {
+	if p.JoinPlan == nil {
+		return nil
+	}
+	index := make(map[string]int, len(p.SubQueryPlans))
+	for i, sqp := range p.SubQueryPlans {
+		index[sqp.SubQuery.ID] = i
+	}
+
+	joins := make(map[int]*broadcastJoin)
+	for _, step := range p.JoinPlan.Steps {
+		if step.Strategy != JoinStrategyBroadcast {
+			continue
+		}
+		left, right := index[step.LeftInput], index[step.RightInput]
+		// The first key column filters the large input; any further
+		// ones are matched by the join
+		leftKey, rightKey := step.Keys[0].left(), step.Keys[0].right()
+		if step.BroadcastInput == step.RightInput {
+			joins[left] = &broadcastJoin{small: right, smallKey: rightKey, largeKey: leftKey}
+		} else {
+			joins[right] = &broadcastJoin{small: left, smallKey: leftKey, largeKey: rightKey}
+		}
+	}
+	return joins
+}
+
+// broadcastKeys collects the distinct join keys of a broadcast input, as
+// SQL literals, while it is materialized.
+type broadcastKeys struct {
+	column string
+	max    int
+
+	seen     map[string]bool
+	literals []string
+
+	// overflow is set once the input has more than max keys, or a key
+	// with no literal form, such as a timestamp.
+	overflow bool
+}
+
+// newBroadcastKeys creates a collector of the keys in column, up to max.
+func newBroadcastKeys(column string, max int) *broadcastKeys {
+	return &broadcastKeys{column: column, max: max, seen: make(map[string]bool)}
+}
+
+// add collects the key of a row. NULL keys never match, so they are
+// skipped.
+func (k *broadcastKeys) add(row Row) {
+	value := rowValue(row, k.column)
+	if k.overflow || value == nil {
+		return
+	}
+	literal, ok := formatPredicateLiteral(value)
+	if k.seen[literal] {
+		return
+	}
+	if !ok || len(k.literals) >= k.max {
+		k.overflow = true
+		k.seen, k.literals = nil, nil
+		return
+	}
+	k.seen[literal] = true
+	k.literals = append(k.literals, literal)
+}
+
+// filter returns the large sub-query restricted to the collected keys with
+// an IN list, added to its WHERE clause ahead of any GROUP BY or LIMIT. When the keys cannot be inlined, because there are more than
+// max of them, one has no literal form, or there are none, the sub-query
+// is returned unchanged: the join then reads the large input whole, as a
+// hash join would, and its result is the same.
+func (k *broadcastKeys) filter(subQuery *SubQuery, column string) *SubQuery {
+	if k.overflow || len(k.literals) == 0 {
+		return subQuery
+	}
+	pred := &Predicate{
+		Table:    subQuery.tableName(column),
+		Column:   columnName(column),
+		Operator: "IN",
+		Raw:      column + " IN (" + strings.Join(k.literals, ", ") + ")",
+	}
+	for _, literal := range k.literals {
+		pred.Values = append(pred.Values, literal)
+	}
+	return (&FilterPushdown{}).Rewrite(subQuery, &PredicateOp{predicate: pred})
+}
+
+// tableName returns the full name of the sub-query table a qualified
+// column belongs to, or "" if none matches.
+func (sq *SubQuery) tableName(column string) string {
+	qualifier := strings.TrimSuffix(column, "."+columnName(column))
+	for _, table := range sq.Tables {
+		if table.qualifier() == qualifier {
+			return table.FullName()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package federation
+
+import (
+	"fmt"
+	"strings"
+
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+)
+
+// CartesianJoinPolicy controls how the Analyzer treats queries joining
+// tables without a condition linking them (see sql.LogicalPlan
+// CartesianProducts). Such joins return every combination of rows and can
+// explode on any engine, so the check applies to single-engine queries
+// pushed down as one statement as well as to federated ones.
+type CartesianJoinPolicy string
+
+const (
+	// CartesianJoinsWarn runs the query, adding a warning to the analysis.
+	CartesianJoinsWarn CartesianJoinPolicy = "warn"
+
+	// CartesianJoinsReject rejects the query with ErrCartesianJoin.
+	CartesianJoinsReject CartesianJoinPolicy = "reject"
+
+	// CartesianJoinsAllow runs the query without a warning.
+	CartesianJoinsAllow CartesianJoinPolicy = "allow"
+)
+
+// ParseCartesianJoinPolicy parses a policy name case-insensitively.
+// An empty value selects CartesianJoinsWarn.
+func ParseCartesianJoinPolicy(s string) (CartesianJoinPolicy, error) {
+	switch policy := CartesianJoinPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return CartesianJoinsWarn, nil
+	case CartesianJoinsWarn, CartesianJoinsReject, CartesianJoinsAllow:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid cartesian join policy %q (valid: %s, %s, %s)",
+			s, CartesianJoinsWarn, CartesianJoinsReject, CartesianJoinsAllow)
+	}
+}
+
+// check applies the policy to the cartesian products of a query. It returns
+// a warning for each product, or an error when the policy rejects them.
+func (p CartesianJoinPolicy) check(products []string) ([]string, error) {
+	if len(products) == 0 || p == CartesianJoinsAllow {
+		return nil, nil
+	}
+	if p == CartesianJoinsReject {
+		return nil, cerrors.NewCartesianJoin(products[0])
+	}
+
+	warnings := make([]string, len(products))
+	for i, product := range products {
+		warnings[i] = "cartesian product: " + product
+	}
+	return warnings, nil
+}
@@ -0,0 +1,59 @@
+package federation
+
+import (
+	"context"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+)
+
+// ChecksumStream computes an order-independent checksum of the rows that pass
+// through it. It is used to verify that the same logical query returns the
+// same data whichever engine served it.
+type ChecksumStream struct {
+	ResultStream
+	checksum  *adapters.ResultChecksum
+	exhausted bool
+}
+
+// NewChecksumStream wraps a stream to checksum its rows as they are read.
+func NewChecksumStream(stream ResultStream) *ChecksumStream {
+	return &ChecksumStream{
+		ResultStream: stream,
+		checksum:     adapters.NewResultChecksum(),
+	}
+}
+
+// Next returns the next row and adds it to the checksum.
+func (s *ChecksumStream) Next(ctx context.Context) (Row, error) {
+	row, err := s.ResultStream.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		s.exhausted = true
+		return nil, nil
+	}
+
+	columns := make([]string, 0, len(row))
+	values := make([]interface{}, 0, len(row))
+	for col, v := range row {
+		columns = append(columns, col)
+		values = append(values, v)
+	}
+	s.checksum.AddRow(columns, values)
+	return row, nil
+}
+
+// Checksum returns the checksum of all rows read. ok is false until the
+// stream has been read to the end, since a partial checksum cannot be compared.
+func (s *ChecksumStream) Checksum() (checksum string, ok bool) {
+	if !s.exhausted {
+		return "", false
+	}
+	return s.checksum.Sum(), true
+}
+
+// Rows returns the number of rows read so far.
+func (s *ChecksumStream) Rows() int64 {
+	return s.checksum.Rows()
+}
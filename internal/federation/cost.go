@@ -4,7 +4,10 @@ package federation
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/canonica-labs/canonica/internal/observability"
 )
 
 // EngineCostFactors contains cost factors for a specific engine.
@@ -82,15 +85,33 @@ var DefaultCostFactors = map[string]*EngineCostFactors{
 	},
 }
 
+// GenericCostFactors is the cost profile used for engines without tuned
+// factors, e.g. a newly added adapter. It assumes a remote engine with
+// moderate startup and transfer costs so an untuned engine is never
+// preferred over a tuned local one by accident.
+var GenericCostFactors = &EngineCostFactors{
+	QueryOverhead:      250 * time.Millisecond,
+	ScanCostPerRow:     0.0003,
+	TransferCostPerRow: 0.002,
+	FilterCostPerRow:   0.0001,
+	AggCostPerRow:      0.0002,
+	NetworkLatency:     15 * time.Millisecond,
+}
+
 // CostModel holds cost factors for all engines.
 type CostModel struct {
 	engineCosts map[string]*EngineCostFactors
+	generic     *EngineCostFactors
+
+	// warned records engines already logged as using the generic profile.
+	warned sync.Map
 }
 
 // NewCostModel creates a cost model with default factors.
 func NewCostModel() *CostModel {
 	return &CostModel{
 		engineCosts: DefaultCostFactors,
+		generic:     GenericCostFactors,
 	}
 }
 
@@ -104,16 +125,38 @@ func NewCostModelWithFactors(factors map[string]*EngineCostFactors) *CostModel {
 	for k, v := range factors {
 		merged[k] = v
 	}
-	return &CostModel{engineCosts: merged}
+	return &CostModel{engineCosts: merged, generic: GenericCostFactors}
+}
+
+// SetGenericFactors replaces the profile used for engines without factors.
+// A nil profile restores GenericCostFactors.
+func (m *CostModel) SetGenericFactors(factors *EngineCostFactors) {
+	if factors == nil {
+		factors = GenericCostFactors
+	}
+	m.generic = factors
+}
+
+// HasFactors reports whether the engine has its own cost factors.
+func (m *CostModel) HasFactors(engine string) bool {
+	_, ok := m.engineCosts[engine]
+	return ok
 }
 
 // GetFactors returns cost factors for an engine.
+// Engines without factors get the generic profile; this is logged once per
+// engine so operators know its estimates are untuned.
 func (m *CostModel) GetFactors(engine string) *EngineCostFactors {
 	if factors, ok := m.engineCosts[engine]; ok {
 		return factors
 	}
-	// Fallback to DuckDB as default
-	return m.engineCosts["duckdb"]
+	if _, logged := m.warned.LoadOrStore(engine, true); !logged {
+		observability.Diagnosticf("federation: no cost factors for engine %q, using generic cost profile", engine)
+	}
+	if m.generic == nil {
+		return GenericCostFactors
+	}
+	return m.generic
 }
 
 // CostBreakdown details the components of a cost estimate.
@@ -137,6 +180,10 @@ type QueryCost struct {
 	EstimatedTime time.Duration
 	EstimatedRows int64
 	Breakdown     *CostBreakdown
+
+	// GenericProfile is true when the engine had no cost factors and the
+	// generic profile was used instead.
+	GenericProfile bool
 }
 
 // TableStats holds statistics for cost estimation.
@@ -220,10 +267,11 @@ func (e *CostEstimator) EstimateCost(
 	}
 
 	return &QueryCost{
-		Engine:        engine,
-		EstimatedTime: breakdown.Total(),
-		EstimatedRows: int64(float64(totalRows) * selectivity),
-		Breakdown:     breakdown,
+		Engine:         engine,
+		EstimatedTime:  breakdown.Total(),
+		EstimatedRows:  int64(float64(totalRows) * selectivity),
+		Breakdown:      breakdown,
+		GenericProfile: !e.model.HasFactors(engine),
 	}, nil
 }
 
@@ -244,6 +292,9 @@ func (e *CostEstimator) estimatePredicateSelectivity(
 	case "<", ">", "<=", ">=":
 		return 0.33 // Range predicates typically filter ~1/3
 
+	case "BETWEEN":
+		return 0.25 // Bounded on both sides: narrower than a one-sided range
+
 	case "LIKE":
 		valueStr, ok := pred.Value.(string)
 		if ok && strings.HasPrefix(valueStr, "%") {
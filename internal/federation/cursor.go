@@ -0,0 +1,301 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cursor defaults for paged exports.
+const (
+	// DefaultCursorTTL is how long an idle cursor is kept open.
+	DefaultCursorTTL = 5 * time.Minute
+
+	// DefaultCursorPageSize is the number of rows returned per page.
+	DefaultCursorPageSize = 1000
+)
+
+var (
+	// ErrCursorNotFound is returned for unknown or exhausted cursor tokens.
+	ErrCursorNotFound = errors.New("cursor not found")
+
+	// ErrCursorExpired is returned when a cursor was closed after being idle
+	// for longer than its TTL.
+	ErrCursorExpired = errors.New("cursor expired after idle timeout")
+)
+
+// CursorPage is one page of a cursor's result.
+type CursorPage struct {
+	Token   string
+	Page    int
+	Columns []ColumnDef
+	Rows    []Row
+
+	// Done is true on the last page. The stream is closed afterwards, but the
+	// last page can be fetched again until the cursor's TTL passes.
+	Done bool
+}
+
+// cursor holds an open stream between page fetches.
+type cursor struct {
+	// mu is held while a page is built and guards the fields below it.
+	mu       sync.Mutex
+	stream   ResultStream
+	nextPage int
+	lastPage *CursorPage
+	closed   bool
+
+	// lastAccess is guarded by CursorManager.mu.
+	lastAccess time.Time
+}
+
+// closeStream closes the cursor's stream once. c.mu must be held.
+func (c *cursor) closeStream() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.stream.Close()
+}
+
+// CursorManager keeps result streams open across requests so that very large
+// exports can be fetched page by page instead of buffered in one response.
+// Cursors idle for longer than the TTL are closed, releasing the engine-side
+// resources held by their stream.
+type CursorManager struct {
+	ttl      time.Duration
+	pageSize int
+
+	mu       sync.Mutex
+	cursors  map[string]*cursor
+	finished map[string]*cursor   // exhausted cursors, kept for one TTL for retries
+	expired  map[string]time.Time // token -> expiry time, kept for one TTL
+}
+
+// NewCursorManager creates a cursor manager. Non-positive arguments select
+// DefaultCursorTTL and DefaultCursorPageSize.
+func NewCursorManager(ttl time.Duration, pageSize int) *CursorManager {
+	if ttl <= 0 {
+		ttl = DefaultCursorTTL
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultCursorPageSize
+	}
+	return &CursorManager{
+		ttl:      ttl,
+		pageSize: pageSize,
+		cursors:  make(map[string]*cursor),
+		finished: make(map[string]*cursor),
+		expired:  make(map[string]time.Time),
+	}
+}
+
+// Open registers a stream and returns the token used to fetch its pages.
+// The manager owns the stream from then on and closes it when the cursor is
+// exhausted, closed, or expires.
+func (m *CursorManager) Open(stream ResultStream) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		stream.Close()
+		return "", fmt.Errorf("failed to generate cursor token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	m.mu.Lock()
+	m.cursors[token] = &cursor{
+		stream:     stream,
+		nextPage:   1,
+		lastAccess: time.Now(),
+	}
+	m.mu.Unlock()
+	return token, nil
+}
+
+// Fetch returns page number page (starting at 1) of a cursor.
+// Pages must be fetched in order; re-fetching the most recent page returns it
+// again so that clients can retry a failed transfer, including the last page.
+func (m *CursorManager) Fetch(ctx context.Context, token string, page int) (*CursorPage, error) {
+	m.mu.Lock()
+	if f, ok := m.finished[token]; ok {
+		f.lastAccess = time.Now()
+		m.mu.Unlock()
+		if page == f.lastPage.Page {
+			return f.lastPage, nil
+		}
+		return nil, fmt.Errorf("cursor page %d requested after last page %d: %w", page, f.lastPage.Page, ErrCursorNotFound)
+	}
+	c, ok := m.cursors[token]
+	if !ok {
+		_, wasExpired := m.expired[token]
+		m.mu.Unlock()
+		if wasExpired {
+			return nil, ErrCursorExpired
+		}
+		return nil, ErrCursorNotFound
+	}
+	// A cursor locked by another fetch is in use, not idle
+	if time.Since(c.lastAccess) > m.ttl && c.mu.TryLock() {
+		m.expireLocked(token)
+		m.mu.Unlock()
+		c.closeStream()
+		c.mu.Unlock()
+		return nil, ErrCursorExpired
+	}
+	c.lastAccess = time.Now()
+	m.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrCursorNotFound
+	}
+	if c.lastPage != nil && page == c.lastPage.Page {
+		m.touch(c)
+		return c.lastPage, nil
+	}
+	if page != c.nextPage {
+		return nil, fmt.Errorf("cursor page %d requested, next page is %d", page, c.nextPage)
+	}
+
+	result := &CursorPage{
+		Token:   token,
+		Page:    page,
+		Columns: c.stream.Schema().Columns,
+		Rows:    make([]Row, 0, m.pageSize),
+	}
+	for len(result.Rows) < m.pageSize {
+		row, err := c.stream.Next(ctx)
+		if err != nil {
+			c.closeStream()
+			m.remove(token)
+			return nil, fmt.Errorf("cursor page %d: %w", page, err)
+		}
+		if row == nil {
+			result.Done = true
+			break
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	c.nextPage++
+	c.lastPage = result
+	if result.Done {
+		c.closeStream()
+		m.finish(token, c)
+	} else {
+		// Building the page may take longer than the TTL
+		m.touch(c)
+	}
+	return result, nil
+}
+
+// Close closes a cursor and its stream. Closing an unknown cursor is a no-op.
+// A fetch in progress completes before the stream is closed.
+func (m *CursorManager) Close(token string) error {
+	m.mu.Lock()
+	c, ok := m.cursors[token]
+	delete(m.cursors, token)
+	delete(m.finished, token)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeStream()
+}
+
+// ExpireIdle closes every cursor that has been idle for longer than the TTL
+// and returns how many were closed. Cursors with a fetch in progress are
+// skipped.
+func (m *CursorManager) ExpireIdle() int {
+	m.mu.Lock()
+	now := time.Now()
+	for token, at := range m.expired {
+		if now.Sub(at) > m.ttl {
+			delete(m.expired, token)
+		}
+	}
+	for token, f := range m.finished {
+		if now.Sub(f.lastAccess) > m.ttl {
+			delete(m.finished, token)
+			m.expired[token] = now
+		}
+	}
+
+	var idle []*cursor
+	for token, c := range m.cursors {
+		if now.Sub(c.lastAccess) > m.ttl && c.mu.TryLock() {
+			m.expireLocked(token)
+			idle = append(idle, c)
+		}
+	}
+	m.mu.Unlock()
+
+	// Streams may block on the engine while closing
+	for _, c := range idle {
+		c.closeStream()
+		c.mu.Unlock()
+	}
+	return len(idle)
+}
+
+// Run calls ExpireIdle every interval until ctx is done.
+func (m *CursorManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.ExpireIdle()
+		}
+	}
+}
+
+// Len returns the number of open cursors.
+func (m *CursorManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.cursors)
+}
+
+// expireLocked removes an idle cursor and records its expiry; the caller
+// closes its stream. m.mu must be held.
+func (m *CursorManager) expireLocked(token string) {
+	delete(m.cursors, token)
+	m.expired[token] = time.Now()
+}
+
+// touch records an access to a cursor.
+func (m *CursorManager) touch(c *cursor) {
+	m.mu.Lock()
+	c.lastAccess = time.Now()
+	m.mu.Unlock()
+}
+
+// finish moves an exhausted cursor to the finished cursors, where its last
+// page is kept for one TTL.
+func (m *CursorManager) finish(token string, c *cursor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.cursors[token]; !ok {
+		return
+	}
+	delete(m.cursors, token)
+	c.lastAccess = time.Now()
+	m.finished[token] = c
+}
+
+// remove forgets a cursor whose stream failed.
+func (m *CursorManager) remove(token string) {
+	m.mu.Lock()
+	delete(m.cursors, token)
+	m.mu.Unlock()
+}
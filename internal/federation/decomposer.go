@@ -3,7 +3,11 @@ package federation
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/sql"
 )
 
 // JoinStrategy represents the join execution strategy.
@@ -13,6 +17,10 @@ const (
 	JoinStrategyHash       JoinStrategy = "hash"
 	JoinStrategyMerge      JoinStrategy = "merge"
 	JoinStrategyNestedLoop JoinStrategy = "nested_loop"
+
+	// JoinStrategyBroadcast is a hash join whose small input runs first,
+	// its join keys inlined into the other input's sub-query as an IN list.
+	JoinStrategyBroadcast JoinStrategy = "broadcast"
 )
 
 // SubQuery represents a sub-query to be executed on a single engine.
@@ -32,13 +40,37 @@ type SubQuery struct {
 	// Predicates are the predicates pushed to this sub-query.
 	Predicates []*Predicate
 
+	// PostFilters are predicates on this sub-query's tables that the engine
+	// is not trusted to evaluate; they are applied to its results locally.
+	PostFilters []*Predicate
+
 	// Columns are the columns to select.
 	Columns []string
 
+	// OrderBy are the columns the sub-query's ORDER BY sorts its rows on,
+	// ascending, most significant first. Its result stream declares that
+	// order (see OrderedStream).
+	OrderBy []string
+
+	// PartialAggregates is set when the sub-query computes partial
+	// aggregates of the query's aggregations (see
+	// QueryAnalysis.PartialAggregationTable), which the post-join
+	// aggregation combines.
+	PartialAggregates bool
+
 	// EstimatedRows is the estimated row count (-1 if unknown).
 	EstimatedRows int64
 }
 
+// tableNames returns the full names of the sub-query's tables.
+func (sq *SubQuery) tableNames() []string {
+	names := make([]string, len(sq.Tables))
+	for i, table := range sq.Tables {
+		names[i] = table.FullName()
+	}
+	return names
+}
+
 // JoinStep represents a single join operation in the join plan.
 type JoinStep struct {
 	// StepID is the step identifier.
@@ -53,14 +85,48 @@ type JoinStep struct {
 	// RightInput is the sub-query ID.
 	RightInput string
 
-	// LeftKey is the join key on the left side.
-	LeftKey string
-
-	// RightKey is the join key on the right side.
-	RightKey string
+	// Keys are the column pairs of the join key, such as a.x = b.y and
+	// a.z = b.w in ON a.x = b.y AND a.z = b.w. Rows match when every pair
+	// is equal.
+	Keys []JoinKey
 
 	// Strategy is the join execution strategy.
 	Strategy JoinStrategy
+
+	// BroadcastInput is the input of a JoinStrategyBroadcast step whose
+	// join keys filter the other input's sub-query.
+	BroadcastInput string
+}
+
+// JoinKey is a column pair of a join key, with the column of the step's
+// left input first.
+type JoinKey struct {
+	// LeftTable and RightTable are the tables (alias or name) of the
+	// columns.
+	LeftTable  string
+	LeftKey    string
+	RightTable string
+	RightKey   string
+}
+
+// left returns the qualified left column of the pair.
+func (k JoinKey) left() string {
+	return qualifiedColumn(k.LeftTable, k.LeftKey)
+}
+
+// right returns the qualified right column of the pair.
+func (k JoinKey) right() string {
+	return qualifiedColumn(k.RightTable, k.RightKey)
+}
+
+// keyColumns returns the qualified key columns of each input of the step,
+// paired by position.
+func (s *JoinStep) keyColumns() (left, right []string) {
+	for _, key := range s.Keys {
+		left = append(left, key.left())
+		right = append(right, key.right())
+	}
+	return left, right
 }
 
 // JoinPlan represents the complete join execution plan.
@@ -70,9 +136,13 @@ type JoinPlan struct {
 
 // PostJoinOperations are operations applied after all joins.
 type PostJoinOperations struct {
-	Aggregations []*Aggregation
-	OrderBy      []*OrderByClause
-	Limit        *int
+	Aggregations    []*Aggregation
+	GroupBy         []string
+	Distinct        bool
+	DistinctColumns []string
+	OrderBy         []*OrderByClause
+	Limit           *int
+	Offset          *int
 }
 
 // DecomposedQuery is the result of decomposing a cross-engine query.
@@ -89,6 +159,10 @@ type DecomposedQuery struct {
 
 	// PostJoinOps are operations after joins (aggregation, sort, limit).
 	PostJoinOps *PostJoinOperations
+
+	// SetOperation is the kind of set operation combining the results of
+	// the two sub-queries of a set operation, which have no JoinPlan.
+	SetOperation string
 }
 
 // Decomposer decomposes multi-engine queries into sub-queries.
@@ -110,16 +184,28 @@ func (d *Decomposer) Decompose(analysis *QueryAnalysis) (*DecomposedQuery, error
 		return nil, fmt.Errorf("decomposer: no tables found")
 	}
 
+	if analysis.SetOperation != "" {
+		return d.decomposeSetOperation(analysis), nil
+	}
+
 	result := &DecomposedQuery{
 		OriginalSQL: analysis.OriginalSQL,
 		SubQueries:  make([]*SubQuery, 0),
 	}
 
-	// Generate sub-query for each engine
+	// Generate sub-query for each engine, in engine name order so sub-query
+	// IDs are the same on every run
+	engines := make([]string, 0, len(analysis.TablesByEngine))
+	for engine := range analysis.TablesByEngine {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+
 	subQueryID := 0
 	engineToSubQuery := make(map[string]string) // engine -> subQuery ID
 
-	for engine, tables := range analysis.TablesByEngine {
+	for _, engine := range engines {
+		tables := analysis.TablesByEngine[engine]
 		subQuery, err := d.generateSubQuery(subQueryID, engine, tables, analysis)
 		if err != nil {
 			return nil, fmt.Errorf("decomposer: failed to generate sub-query for %s: %w", engine, err)
@@ -138,14 +224,42 @@ func (d *Decomposer) Decompose(analysis *QueryAnalysis) (*DecomposedQuery, error
 
 	// Set post-join operations
 	result.PostJoinOps = &PostJoinOperations{
-		Aggregations: analysis.Aggregations,
-		OrderBy:      analysis.OrderBy,
-		Limit:        analysis.Limit,
+		Aggregations:    analysis.Aggregations,
+		GroupBy:         analysis.GroupBy,
+		Distinct:        analysis.Distinct,
+		DistinctColumns: analysis.DistinctColumns,
+		OrderBy:         analysis.OrderBy,
+		Limit:           analysis.Limit,
+		Offset:          analysis.Offset,
 	}
 
 	return result, nil
 }
 
+// decomposeSetOperation generates a sub-query for each operand of a set
+// operation, in operand order.
+func (d *Decomposer) decomposeSetOperation(analysis *QueryAnalysis) *DecomposedQuery {
+	result := &DecomposedQuery{
+		OriginalSQL:  analysis.OriginalSQL,
+		SetOperation: analysis.SetOperation,
+		PostJoinOps: &PostJoinOperations{
+			OrderBy: analysis.OrderBy,
+			Limit:   analysis.Limit,
+			Offset:  analysis.Offset,
+		},
+	}
+	for i, operand := range analysis.SetOperands {
+		result.SubQueries = append(result.SubQueries, &SubQuery{
+			ID:            fmt.Sprintf("sq_%d_%s", i, operand.Engine),
+			Engine:        operand.Engine,
+			SQL:           operand.SQL,
+			Tables:        operand.Tables,
+			EstimatedRows: -1,
+		})
+	}
+	return result
+}
+
 // generateSubQuery generates a sub-query for a specific engine.
 func (d *Decomposer) generateSubQuery(
 	id int,
@@ -155,93 +269,138 @@ func (d *Decomposer) generateSubQuery(
 ) (*SubQuery, error) {
 	subQueryID := fmt.Sprintf("sq_%d_%s", id, engine)
 
-	// Collect required columns for this engine's tables
+	// Read every column; the PushdownOptimizer narrows the projection to
+	// the required columns
 	var columns []string
-	var tableAliases []string
-
 	for _, table := range tables {
-		// Get columns required for this table
-		tableCols := analysis.RequiredColumns[table.FullName()]
-		alias := table.DisplayName()
-		tableAliases = append(tableAliases, alias)
-
-		for _, col := range tableCols {
-			colRef := fmt.Sprintf("%s.%s", alias, col)
-			if !contains(columns, colRef) {
-				columns = append(columns, colRef)
-			}
-		}
-
-		// Ensure join keys are included
-		for _, join := range analysis.Joins {
-			if join.LeftTable == alias || join.LeftTable == table.Name {
-				colRef := fmt.Sprintf("%s.%s", alias, join.LeftCol)
-				if !contains(columns, colRef) {
-					columns = append(columns, colRef)
-				}
-			}
-			if join.RightTable == alias || join.RightTable == table.Name {
-				colRef := fmt.Sprintf("%s.%s", alias, join.RightCol)
-				if !contains(columns, colRef) {
-					columns = append(columns, colRef)
-				}
-			}
-		}
+		columns = append(columns, table.DisplayName()+".*")
 	}
 
-	// If no columns found, select all
-	if len(columns) == 0 {
-		for _, table := range tables {
-			columns = append(columns, table.DisplayName()+".*")
+	// Build FROM clause. Each table is joined to the tables before it on
+	// the join conditions linking them, which the engine evaluates; a table
+	// no condition links is cross joined.
+	var from strings.Builder
+	for i, table := range tables {
+		source, err := pinnedTableSource(table, engine)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	// Build FROM clause
-	var fromParts []string
-	for _, table := range tables {
 		if table.Alias != "" && table.Alias != table.Name {
-			fromParts = append(fromParts, fmt.Sprintf("%s AS %s", table.FullName(), table.Alias))
-		} else {
-			fromParts = append(fromParts, table.FullName())
+			source = fmt.Sprintf("%s AS %s", source, table.Alias)
 		}
-	}
-
-	// Build WHERE clause with pushable predicates
-	var whereParts []string
-	for _, table := range tables {
-		preds := analysis.PushablePredicates[table.FullName()]
-		for _, pred := range preds {
-			whereParts = append(whereParts, pred.Raw)
+		if i == 0 {
+			from.WriteString(source)
+			continue
 		}
+		joinType, conditions := linkingConditions(analysis.Joins, tables[:i], table)
+		if len(conditions) == 0 {
+			fmt.Fprintf(&from, " CROSS JOIN %s", source)
+			continue
+		}
+		fmt.Fprintf(&from, " %s JOIN %s ON %s", joinType, source, strings.Join(conditions, " AND "))
 	}
 
-	// Construct SQL
+	// Construct SQL. Predicates are added by the PushdownOptimizer, which
+	// decides per engine whether each one is pushed or evaluated locally.
 	sql := fmt.Sprintf("SELECT %s FROM %s",
 		strings.Join(columns, ", "),
-		strings.Join(fromParts, ", "))
-
-	if len(whereParts) > 0 {
-		sql += " WHERE " + strings.Join(whereParts, " AND ")
-	}
-
-	// Collect predicates
-	var predicates []*Predicate
-	for _, table := range tables {
-		predicates = append(predicates, analysis.PushablePredicates[table.FullName()]...)
-	}
+		from.String())
 
 	return &SubQuery{
 		ID:            subQueryID,
 		Engine:        engine,
 		SQL:           sql,
 		Tables:        tables,
-		Predicates:    predicates,
 		Columns:       columns,
 		EstimatedRows: -1, // Unknown at decomposition time
 	}, nil
 }
 
+// linkingConditions returns the equalities of the join conditions linking
+// table to any of joined, as SQL text, and the type of the join adding
+// table to them: that of the first condition, with its sides exchanged
+// when table is on the condition's left.
+func linkingConditions(joins []*JoinCondition, joined []*TableRef, table *TableRef) (JoinType, []string) {
+	isJoined := func(qualifier string) bool {
+		for _, ref := range joined {
+			if ref.refersTo(qualifier) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var joinType JoinType
+	var conditions []string
+	for _, join := range joins {
+		kind := join.Type
+		switch {
+		case table.refersTo(join.RightTable) && isJoined(join.LeftTable):
+		case table.refersTo(join.LeftTable) && isJoined(join.RightTable):
+			kind = swapJoinType(join.Type)
+		default:
+			continue
+		}
+		if joinType == "" {
+			joinType = kind
+		}
+		for i := range join.LeftCols {
+			conditions = append(conditions, qualifiedColumn(join.LeftTable, join.LeftCols[i])+" = "+qualifiedColumn(join.RightTable, join.RightCols[i]))
+		}
+	}
+	return joinType, conditions
+}
+
+// refersTo reports whether a column qualifier of the query, an alias or a
+// table name, refers to the table.
+func (t *TableRef) refersTo(qualifier string) bool {
+	return qualifier == t.DisplayName() || qualifier == t.Name || qualifier == t.FullName()
+}
+
+// pinnedTableSource returns the FROM reference of a table in its engine's
+// sub-query. A table pinned with FOR VERSION AS OF is read at that version
+// in the syntax of its format and engine; formats without version history
+// are rejected by the time-travel rewriter.
+func pinnedTableSource(table *TableRef, engine string) (string, error) {
+	if table.Version == "" {
+		return table.FullName(), nil
+	}
+	if strings.Trim(table.Version, "0123456789") != "" {
+		return "", fmt.Errorf("table %s: time-travel: VERSION AS OF expects a numeric snapshot ID or version, got %q",
+			table.FullName(), table.Version)
+	}
+
+	// Table metadata spells formats in upper case; the rewriter expects
+	// catalog formats
+	format := table.Format
+	if parsed, err := catalog.ParseTableFormat(string(format)); err == nil {
+		format = parsed
+	}
+
+	pinned := fmt.Sprintf("%s FOR VERSION AS OF %s", table.FullName(), table.Version)
+	source, err := sql.NewTimeTravelRewriter(format, engine).Rewrite(pinned)
+	if err != nil {
+		return "", fmt.Errorf("table %s: %w", table.FullName(), err)
+	}
+	return source, nil
+}
+
+// joinStepID returns the input ID under which later join steps refer to
+// the result of step n.
+func joinStepID(n int) string {
+	return fmt.Sprintf("step_%d", n)
+}
+
 // generateJoinPlan creates a plan for joining sub-query results.
+//
+// The plan is a left-deep tree, ((sq0 JOIN sq1) JOIN sq2) ...: the first
+// step joins two sub-queries, and each later step joins the previous
+// step's result (its LeftInput) with one sub-query not joined yet (its
+// RightInput). Join conditions are taken in query order, except that each
+// step takes the first remaining condition linking a joined sub-query to a
+// new one; the condition's sides are swapped when the new sub-query is on
+// its left. A sub-query no remaining condition links is cross joined, and
+// conditions between tables of one sub-query are left to its engine.
 func (d *Decomposer) generateJoinPlan(
 	analysis *QueryAnalysis,
 	subQueries []*SubQuery,
@@ -264,73 +423,145 @@ func (d *Decomposer) generateJoinPlan(
 		}
 	}
 
-	// Generate join steps from join conditions
-	// Use left-deep tree: ((sq0 JOIN sq1) JOIN sq2) ...
-	usedSubQueries := make(map[string]bool)
-	var lastStepResult string
-
-	for i, join := range analysis.Joins {
+	// Collect the conditions between sub-queries
+	type condition struct {
+		join        *JoinCondition
+		left, right string // sub-query IDs
+	}
+	var pending []condition
+	for _, join := range analysis.Joins {
 		leftSQ := tableToSubQuery[join.LeftTable]
 		rightSQ := tableToSubQuery[join.RightTable]
+		if leftSQ == "" || rightSQ == "" || leftSQ == rightSQ {
+			continue
+		}
+		pending = append(pending, condition{join: join, left: leftSQ, right: rightSQ})
+	}
 
-		if leftSQ == "" || rightSQ == "" {
-			continue // Skip if tables not found
+	joined := make(map[string]bool)
+	addStep := func(step JoinStep) {
+		step.StepID = len(plan.Steps)
+		if step.StepID > 0 {
+			step.LeftInput = joinStepID(step.StepID - 1)
 		}
+		plan.Steps = append(plan.Steps, step)
+	}
 
-		// Determine inputs for this join step
-		var leftInput, rightInput string
-
-		if lastStepResult == "" {
-			// First join
-			leftInput = leftSQ
-			rightInput = rightSQ
-			usedSubQueries[leftSQ] = true
-			usedSubQueries[rightSQ] = true
-		} else {
-			// Subsequent join - left side is previous result
-			leftInput = lastStepResult
-
-			// Right side is the sub-query not yet used
-			if !usedSubQueries[leftSQ] {
-				rightInput = leftSQ
-				usedSubQueries[leftSQ] = true
-			} else if !usedSubQueries[rightSQ] {
-				rightInput = rightSQ
-				usedSubQueries[rightSQ] = true
+	for len(joined) < len(subQueries) {
+		// Take the first condition linking the joined sub-queries to a new
+		// one; any condition qualifies for the first step
+		next := -1
+		for i, c := range pending {
+			if len(joined) == 0 || joined[c.left] != joined[c.right] {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			// Nothing links a new sub-query: cross join the first unjoined
+			// one (the first two, for the first step)
+			var unjoined []string
+			for _, sq := range subQueries {
+				if !joined[sq.ID] {
+					unjoined = append(unjoined, sq.ID)
+				}
+			}
+			step := JoinStep{Type: JoinTypeCross, Strategy: JoinStrategyNestedLoop}
+			if len(joined) == 0 {
+				step.LeftInput, step.RightInput = unjoined[0], unjoined[1]
+				joined[unjoined[0]] = true
 			} else {
-				// Both already used, this is a self-join or complex case
-				rightInput = rightSQ
+				step.RightInput = unjoined[0]
 			}
+			joined[step.RightInput] = true
+			addStep(step)
+			continue
 		}
 
-		stepID := fmt.Sprintf("step_%d", i)
-		plan.Steps = append(plan.Steps, JoinStep{
-			StepID:     i,
-			Type:       join.Type,
-			LeftInput:  leftInput,
-			RightInput: rightInput,
-			LeftKey:    join.LeftCol,
-			RightKey:   join.RightCol,
+		c := pending[next]
+		pending = append(pending[:next], pending[next+1:]...)
+		step := JoinStep{
+			Type:       c.join.Type,
+			RightInput: c.right,
 			Strategy:   JoinStrategyHash, // Default to hash join
-		})
+		}
+		switch {
+		case len(joined) == 0:
+			step.LeftInput = c.left
+			joined[c.left] = true
+			step.addKeys(c.join, false)
+		case !joined[c.left]:
+			// The new sub-query is on the condition's left: swap the sides
+			// so the joined result stays on the left
+			step.RightInput = c.left
+			step.Type = swapJoinType(c.join.Type)
+			step.addKeys(c.join, true)
+		default:
+			step.addKeys(c.join, false)
+		}
+
+		// Further conditions of the same join type linking the joined
+		// sub-queries to the new one make a compound key
+		remaining := pending[:0]
+		for _, p := range pending {
+			switch {
+			case p.join.Type != c.join.Type:
+				remaining = append(remaining, p)
+			case joined[p.left] && p.right == step.RightInput:
+				step.addKeys(p.join, false)
+			case joined[p.right] && p.left == step.RightInput:
+				step.addKeys(p.join, true)
+			default:
+				remaining = append(remaining, p)
+			}
+		}
+		pending = remaining
 
-		lastStepResult = stepID
+		joined[step.RightInput] = true
+		addStep(step)
 	}
 
-	// If no joins found but multiple sub-queries, create implicit cross join
-	if len(plan.Steps) == 0 && len(subQueries) >= 2 {
-		plan.Steps = append(plan.Steps, JoinStep{
-			StepID:     0,
-			Type:       JoinTypeCross,
-			LeftInput:  subQueries[0].ID,
-			RightInput: subQueries[1].ID,
-			Strategy:   JoinStrategyNestedLoop,
-		})
+	// Conditions left over link sub-queries that were already joined, and
+	// a join step cannot apply them
+	if len(pending) > 0 {
+		c := pending[0].join
+		return nil, fmt.Errorf("join condition %s.%s = %s.%s links tables that are already joined; "+
+			"cross-engine joins must form a tree", c.LeftTable, c.LeftCols[0], c.RightTable, c.RightCols[0])
 	}
 
 	return plan, nil
 }
 
+// addKeys adds the key column pairs of a join condition to the step, with
+// the condition's sides exchanged if swap is set.
+func (s *JoinStep) addKeys(join *JoinCondition, swap bool) {
+	leftTable, rightTable := join.LeftTable, join.RightTable
+	leftCols, rightCols := join.LeftCols, join.RightCols
+	if swap {
+		leftTable, rightTable = rightTable, leftTable
+		leftCols, rightCols = rightCols, leftCols
+	}
+	for i := range leftCols {
+		s.Keys = append(s.Keys, JoinKey{
+			LeftTable: leftTable, LeftKey: leftCols[i],
+			RightTable: rightTable, RightKey: rightCols[i],
+		})
+	}
+}
+
+// swapJoinType returns the join type with its sides exchanged.
+func swapJoinType(joinType JoinType) JoinType {
+	switch joinType {
+	case JoinTypeLeft:
+		return JoinTypeRight
+	case JoinTypeRight:
+		return JoinTypeLeft
+	default:
+		return joinType
+	}
+}
+
 // Validate checks if the decomposed query is valid for execution.
 func (d *DecomposedQuery) Validate() error {
 	if len(d.SubQueries) == 0 {
@@ -355,3 +586,17 @@ func (d *DecomposedQuery) Validate() error {
 
 	return nil
 }
+
+// engines returns the distinct engines of the sub-queries, sorted.
+func (d *DecomposedQuery) engines() []string {
+	seen := make(map[string]bool)
+	var engines []string
+	for _, sq := range d.SubQueries {
+		if !seen[sq.Engine] {
+			seen[sq.Engine] = true
+			engines = append(engines, sq.Engine)
+		}
+	}
+	sort.Strings(engines)
+	return engines
+}
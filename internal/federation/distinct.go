@@ -0,0 +1,80 @@
+package federation
+
+import (
+	"context"
+	"sort"
+)
+
+// distinctingStream removes duplicate rows for SELECT DISTINCT. Rows are
+// compared on the tuple of their distinct columns, or their full column
+// tuple when there are none, and NULLs compare equal to each other, as
+// DISTINCT requires. Each row is returned as soon as it is first
+// seen, so the stream does not wait for its input to end, but it keeps the
+// tuple of every distinct row until it is closed: its memory grows with the
+// number of distinct rows, and is reserved from the query's budget.
+type distinctingStream struct {
+	source   ResultStream
+	columns  []string
+	budget   *MemoryBudget
+	seen     map[string]bool
+	reserved int64
+}
+
+// newDistinctingStream returns source without rows duplicating an earlier
+// row's columns, or all of its columns when columns is empty.
+func newDistinctingStream(source ResultStream, columns []string, budget *MemoryBudget) *distinctingStream {
+	return &distinctingStream{source: source, columns: columns, budget: budget, seen: make(map[string]bool)}
+}
+
+// Schema returns the source schema.
+func (s *distinctingStream) Schema() *ResultSchema {
+	return s.source.Schema()
+}
+
+// Next returns the next row not returned before.
+func (s *distinctingStream) Next(ctx context.Context) (Row, error) {
+	for {
+		row, err := s.source.Next(ctx)
+		if err != nil || row == nil {
+			return row, err
+		}
+
+		key := distinctKey(row, s.columns)
+		if s.seen[key] {
+			continue
+		}
+		size := int64(len(key)) + rowOverheadBytes
+		if err := s.budget.Reserve(OperatorDistinct, size); err != nil {
+			return nil, err
+		}
+		s.reserved += size
+		s.seen[key] = true
+		return row, nil
+	}
+}
+
+// Close releases the seen tuples and closes the source.
+func (s *distinctingStream) Close() error {
+	s.budget.Release(s.reserved)
+	s.seen, s.reserved = nil, 0
+	return s.source.Close()
+}
+
+// EstimatedRows returns the source estimate, an upper bound.
+func (s *distinctingStream) EstimatedRows() int64 {
+	return s.source.EstimatedRows()
+}
+
+// distinctKey encodes the tuple of a row's columns, or of all of its
+// columns in column name order when columns is empty. A NULL encodes the
+// same in every row.
+func distinctKey(row Row, columns []string) string {
+	if len(columns) == 0 {
+		columns = make([]string, 0, len(row))
+		for col := range row {
+			columns = append(columns, col)
+		}
+		sort.Strings(columns)
+	}
+	return groupKey(row, columns)
+}
@@ -0,0 +1,128 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonica-labs/canonica/pkg/models"
+)
+
+// DefaultEstimatedRowBytes is the assumed width of a transferred row when
+// estimating bytes moved to the gateway. Engines do not report row widths,
+// so estimates are rows × DefaultEstimatedRowBytes.
+const DefaultEstimatedRowBytes = 128
+
+// QueryEstimate is the estimated cost of a federated query, computed from
+// the plan and the cost model without executing it.
+type QueryEstimate struct {
+	// EstimatedTime is the estimated wall time. Sub-queries in the same
+	// parallel group overlap, so each group contributes its slowest member.
+	EstimatedTime time.Duration
+
+	// EstimatedRows is the number of rows the engines are expected to return.
+	EstimatedRows int64
+
+	// EstimatedBytes is the estimated data transferred to the gateway.
+	EstimatedBytes int64
+
+	// Engines lists the engines the query runs on.
+	Engines []string
+
+	// SubQueries holds the cost of each sub-query, in plan order.
+	SubQueries []*QueryCost
+
+	// GenericProfile is true when any engine had no tuned cost factors, so
+	// the estimate is less reliable.
+	GenericProfile bool
+}
+
+// adapterStatsProvider supplies table statistics from an engine adapter.
+type adapterStatsProvider struct {
+	adapter EngineAdapter
+}
+
+// GetTableStats returns the adapter's statistics, or nil when the row
+// count is unknown so the estimator falls back to its default.
+func (p adapterStatsProvider) GetTableStats(ctx context.Context, table string) (*TableStats, error) {
+	stats, err := p.adapter.TableStats(ctx, table)
+	if err != nil || stats == nil || stats.RowCount < 0 {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Estimate plans a query and estimates its cost without executing it, so
+// interactive clients can confirm expensive queries first.
+func (e *FederatedExecutor) Estimate(ctx context.Context, query string) (*QueryEstimate, error) {
+	plan, err := e.Plan(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return e.estimatePlan(ctx, plan)
+}
+
+// estimatePlan estimates the cost of a planned query.
+func (e *FederatedExecutor) estimatePlan(ctx context.Context, plan *ExecutionPlan) (*QueryEstimate, error) {
+	estimate := &QueryEstimate{Engines: plan.Decomposed.engines()}
+	groupTimes := make(map[int]time.Duration)
+	for _, sqPlan := range plan.SubQueryPlans {
+		var stats StatsProvider
+		if adapter, err := e.registry.Get(sqPlan.Engine); err == nil {
+			stats = adapterStatsProvider{adapter: adapter}
+		}
+		cost, err := NewCostEstimator(e.costModel, stats).EstimateCost(ctx, sqPlan.SubQuery, sqPlan.Engine)
+		if err != nil {
+			return nil, fmt.Errorf("cost estimation failed for %s: %w", sqPlan.SubQuery.ID, err)
+		}
+
+		estimate.SubQueries = append(estimate.SubQueries, cost)
+		estimate.EstimatedRows += cost.EstimatedRows
+		estimate.GenericProfile = estimate.GenericProfile || cost.GenericProfile
+		if cost.EstimatedTime > groupTimes[sqPlan.ParallelGroup] {
+			groupTimes[sqPlan.ParallelGroup] = cost.EstimatedTime
+		}
+	}
+	for _, groupTime := range groupTimes {
+		estimate.EstimatedTime += groupTime
+	}
+	estimate.EstimatedBytes = estimate.EstimatedRows * DefaultEstimatedRowBytes
+
+	return estimate, nil
+}
+
+// EstimateScan estimates how long reading a whole table takes on an engine,
+// using the engine's table statistics when it has them. It lets the router
+// show the estimated cost of each engine that can serve a table.
+func (e *FederatedExecutor) EstimateScan(ctx context.Context, table, engine string) (time.Duration, error) {
+	var stats StatsProvider
+	if adapter, err := e.registry.Get(engine); err == nil {
+		stats = adapterStatsProvider{adapter: adapter}
+	}
+	subQuery := &SubQuery{Engine: engine, Tables: []*TableRef{{Name: table, Engine: engine}}, EstimatedRows: -1}
+	cost, err := NewCostEstimator(e.costModel, stats).EstimateCost(ctx, subQuery, engine)
+	if err != nil {
+		return 0, err
+	}
+	return cost.EstimatedTime, nil
+}
+
+// Response converts the estimate to the API response for query.
+func (q *QueryEstimate) Response(query string) *models.QueryEstimateResponse {
+	resp := &models.QueryEstimateResponse{
+		SQL:            query,
+		Engines:        q.Engines,
+		EstimatedRows:  q.EstimatedRows,
+		EstimatedBytes: q.EstimatedBytes,
+		EstimatedTime:  q.EstimatedTime.String(),
+		GenericProfile: q.GenericProfile,
+	}
+	for _, cost := range q.SubQueries {
+		resp.SubQueries = append(resp.SubQueries, models.SubQueryEstimate{
+			Engine:        cost.Engine,
+			EstimatedRows: cost.EstimatedRows,
+			EstimatedTime: cost.EstimatedTime.String(),
+		})
+	}
+	return resp
+}
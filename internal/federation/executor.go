@@ -4,12 +4,20 @@ package federation
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/observability"
 	"github.com/canonica-labs/canonica/internal/sql"
 	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
 )
 
 // EngineAdapter executes queries on a specific engine.
@@ -80,6 +88,19 @@ type ExecutionPlan struct {
 	SubQueryPlans  []*SubQueryPlan
 	JoinPlan       *JoinPlan
 	ExecutionOrder []int // Order to execute sub-queries
+	PushdownMode   PushdownMode
+}
+
+// EngineSQL returns the final SQL sent to the engines, after decomposition
+// and pushdown, for the audit log (observability.QueryLogEntry.EngineSQL).
+// It is keyed by sub-query ID, such as "sq_1_trino", so sub-queries that
+// run on the same engine are all recorded.
+func (p *ExecutionPlan) EngineSQL() map[string]string {
+	engineSQL := make(map[string]string, len(p.SubQueryPlans))
+	for _, sqp := range p.SubQueryPlans {
+		engineSQL[sqp.SubQuery.ID] = sqp.SubQuery.SQL
+	}
+	return engineSQL
 }
 
 // SubQueryPlan contains execution details for a sub-query.
@@ -90,6 +111,7 @@ type SubQueryPlan struct {
 	EstimatedCost    float64
 	ParallelGroup    int  // Sub-queries in same group execute in parallel
 	RequiresMaterial bool // True if results must be materialized for join
+	StatsKnown       bool // True if EstimatedRows comes from table statistics
 }
 
 // ExecutionStats tracks execution statistics.
@@ -98,11 +120,36 @@ type ExecutionStats struct {
 	PlanningTime     time.Duration
 	SubQueryTimes    map[int]time.Duration
 	JoinTime         time.Duration
-	RowsProcessed    int64
-	BytesTransferred int64
+	RowsProcessed    int64 // Rows read from engines
+	BytesTransferred int64 // Estimated size of the rows read from engines
 	EnginesUsed      []string
 }
 
+// StatsStream is the ResultStream of a federated query, which reports the
+// statistics of its execution.
+type StatsStream interface {
+	ResultStream
+
+	// Stats returns the statistics of the query so far. RowsProcessed and
+	// BytesTransferred grow as rows are read, and are final once the
+	// stream is exhausted.
+	Stats() ExecutionStats
+}
+
+// statsStream is a ResultStream with the statistics of its query.
+type statsStream struct {
+	ResultStream
+	stats *ExecutionStats
+}
+
+// Stats implements StatsStream.
+func (s *statsStream) Stats() ExecutionStats {
+	stats := *s.stats
+	stats.RowsProcessed = atomic.LoadInt64(&s.stats.RowsProcessed)
+	stats.BytesTransferred = atomic.LoadInt64(&s.stats.BytesTransferred)
+	return stats
+}
+
 // FederatedExecutor orchestrates cross-engine query execution.
 // Per phase-9-spec.md §3.3.
 type FederatedExecutor struct {
@@ -111,8 +158,29 @@ type FederatedExecutor struct {
 	decomposer *Decomposer
 	optimizer  *PushdownOptimizer
 	costModel  *CostModel
+	maxEngines int
+	maxMemory  int64
+	safeMode   *tables.SafeMode
+	keyPolicy  JoinKeyCoercion
+	tiers      *CostTierLimiter
+	spillDir   string
+	metrics    observability.Metrics
+	tracer     trace.Tracer
+
+	broadcastThreshold int64
+	maxBroadcastKeys   int
+	subQueryTimeout    time.Duration
+	maxParallelism     int
 }
 
+// DefaultMaxEnginesPerQuery is the default limit on the number of distinct
+// engines a single federated query may span.
+const DefaultMaxEnginesPerQuery = 8
+
+// DefaultMaxParallelism is the default limit on the number of sub-queries of
+// a single federated query that run at once.
+const DefaultMaxParallelism = 8
+
 // NewFederatedExecutor creates a new federated executor.
 func NewFederatedExecutor(
 	registry *AdapterRegistry,
@@ -125,11 +193,175 @@ func NewFederatedExecutor(
 		decomposer: NewDecomposer(),
 		optimizer:  NewPushdownOptimizer(),
 		costModel:  NewCostModel(),
+		maxEngines: DefaultMaxEnginesPerQuery,
+		maxMemory:  DefaultMaxQueryMemory,
+		metrics:    observability.NewNoopMetrics(),
+		tracer:     observability.NewNoopTracer(),
+
+		broadcastThreshold: DefaultBroadcastThreshold,
+		maxBroadcastKeys:   DefaultMaxBroadcastKeys,
+		maxParallelism:     DefaultMaxParallelism,
+	}
+}
+
+// SetMaxEngines sets the maximum number of distinct engines a query may span.
+// Non-positive values restore DefaultMaxEnginesPerQuery.
+func (e *FederatedExecutor) SetMaxEngines(n int) {
+	if n <= 0 {
+		n = DefaultMaxEnginesPerQuery
+	}
+	e.maxEngines = n
+}
+
+// SetMaxQueryMemory sets the memory budget of each query, in bytes.
+// Non-positive values restore DefaultMaxQueryMemory.
+func (e *FederatedExecutor) SetMaxQueryMemory(n int64) {
+	if n <= 0 {
+		n = DefaultMaxQueryMemory
+	}
+	e.maxMemory = n
+}
+
+// queryMemoryBudget returns the memory budget for a query: the configured
+// maximum, lowered by a limit attached with ContextWithMemoryLimit.
+func (e *FederatedExecutor) queryMemoryBudget(ctx context.Context) *MemoryBudget {
+	limit := e.maxMemory
+	if requested := MemoryLimitFromContext(ctx); requested > 0 && requested < limit {
+		limit = requested
+	}
+	return NewMemoryBudget(limit)
+}
+
+// SetSafeMode applies the safe-mode policy. In safe mode, queries spanning
+// more than one engine are rejected with ErrCrossEngineQuery and queries on
+// engines outside the allowlist with ErrEngineNotAllowed. Nil disables it.
+func (e *FederatedExecutor) SetSafeMode(mode *tables.SafeMode) {
+	e.safeMode = mode
+}
+
+// SetDuplicateColumnPolicy sets how queries producing repeated output column
+// names are handled. See Analyzer.SetDuplicateColumnPolicy.
+func (e *FederatedExecutor) SetDuplicateColumnPolicy(policy DuplicateColumnPolicy) {
+	e.analyzer.SetDuplicateColumnPolicy(policy)
+}
+
+// SetCartesianJoinPolicy sets how queries joining tables without a join
+// condition are handled. See Analyzer.SetCartesianJoinPolicy.
+func (e *FederatedExecutor) SetCartesianJoinPolicy(policy CartesianJoinPolicy) {
+	e.analyzer.SetCartesianJoinPolicy(policy)
+}
+
+// SetCostTierLimiter limits how many queries of each estimated cost tier
+// run at once. Queries are estimated after planning and hold their slot
+// until the result stream is closed. Nil disables the limit.
+func (e *FederatedExecutor) SetCostTierLimiter(limiter *CostTierLimiter) {
+	e.tiers = limiter
+}
+
+// admit waits for a slot of the query's cost tier and returns the function
+// releasing it. Without a limiter every query is admitted.
+func (e *FederatedExecutor) admit(ctx context.Context, plan *ExecutionPlan) (func(), error) {
+	if e.tiers == nil {
+		return func() {}, nil
+	}
+	estimate, err := e.estimatePlan(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	return e.tiers.Acquire(ctx, e.tiers.Classify(estimate.EstimatedTime))
+}
+
+// SetJoinKeyCoercion sets how hash joins compare keys whose types differ
+// across engines. Empty selects JoinKeyCoercionNumeric.
+func (e *FederatedExecutor) SetJoinKeyCoercion(policy JoinKeyCoercion) {
+	e.keyPolicy = policy
+}
+
+// SetSpillDir sets the directory of the temporary files of hash joins that
+// spill to disk. Empty uses the system temp directory.
+func (e *FederatedExecutor) SetSpillDir(dir string) {
+	e.spillDir = dir
+}
+
+// SetBroadcastThreshold sets the estimated row count below which a join
+// input is broadcast (see JoinStrategyBroadcast). Zero disables broadcast
+// joins; negative values restore DefaultBroadcastThreshold.
+func (e *FederatedExecutor) SetBroadcastThreshold(rows int64) {
+	if rows < 0 {
+		rows = DefaultBroadcastThreshold
+	}
+	e.broadcastThreshold = rows
+}
+
+// SetMaxBroadcastKeys sets the largest number of distinct join keys a
+// broadcast join inlines into a sub-query. An input with more keys is
+// joined without filtering the other input. Non-positive values restore
+// DefaultMaxBroadcastKeys.
+func (e *FederatedExecutor) SetMaxBroadcastKeys(n int) {
+	if n <= 0 {
+		n = DefaultMaxBroadcastKeys
+	}
+	e.maxBroadcastKeys = n
+}
+
+// SetMaxParallelism sets the maximum number of sub-queries of a query that
+// run at once. Non-positive values restore DefaultMaxParallelism.
+func (e *FederatedExecutor) SetMaxParallelism(n int) {
+	if n <= 0 {
+		n = DefaultMaxParallelism
 	}
+	e.maxParallelism = n
+}
+
+// SetSubQueryTimeout sets how long each engine has to answer its sub-query
+// of a federated query, from the start of the sub-query until its results
+// are materialized or closed. A sub-query running past it fails the query
+// with ErrEngineTimeout, and the other sub-queries are cancelled.
+// Non-positive values disable the timeout.
+func (e *FederatedExecutor) SetSubQueryTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	e.subQueryTimeout = d
+}
+
+// SetMetrics sets the metrics the latency of each sub-query is recorded in,
+// by engine. Nil disables recording.
+func (e *FederatedExecutor) SetMetrics(m observability.Metrics) {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	e.metrics = m
+}
+
+// SetTracer sets the tracer of the spans of a query's planning, sub-queries
+// and joins (see observability.NewTracer). Nil disables tracing.
+func (e *FederatedExecutor) SetTracer(tracer trace.Tracer) {
+	if tracer == nil {
+		tracer = observability.NewNoopTracer()
+	}
+	e.tracer = tracer
+}
+
+// SetPushdownOperators sets the predicate operators pushed to an engine.
+// See PushdownOptimizer.SetPushdownOperators.
+func (e *FederatedExecutor) SetPushdownOperators(engine string, operators []string) {
+	e.optimizer.SetPushdownOperators(engine, operators)
 }
 
-// Execute runs a federated query and returns results.
+// Execute runs a federated query and returns results. It is traced in a
+// span, the parent of the spans of the query's planning, sub-queries and
+// joins; the span ends once the result stream is returned. The stream is a
+// StatsStream, which reports the query's execution statistics.
 func (e *FederatedExecutor) Execute(ctx context.Context, query string) (ResultStream, error) {
+	ctx, span := e.tracer.Start(ctx, observability.SpanExecute)
+	result, err := e.execute(ctx, query)
+	observability.EndSpan(span, err)
+	return result, err
+}
+
+// execute runs a federated query.
+func (e *FederatedExecutor) execute(ctx context.Context, query string) (ResultStream, error) {
 	stats := &ExecutionStats{
 		SubQueryTimes: make(map[int]time.Duration),
 	}
@@ -141,37 +373,112 @@ func (e *FederatedExecutor) Execute(ctx context.Context, query string) (ResultSt
 		return nil, fmt.Errorf("planning failed: %w", err)
 	}
 	stats.PlanningTime = time.Since(start)
+	for _, sqp := range plan.SubQueryPlans {
+		if !contains(stats.EnginesUsed, sqp.Engine) {
+			stats.EnginesUsed = append(stats.EnginesUsed, sqp.Engine)
+		}
+	}
+
+	// EXPLAIN reports the predicates the executor cannot evaluate; running
+	// the query without them would return wrong results
+	if err := plan.checkPredicates(); err != nil {
+		return nil, err
+	}
+
+	// Limit concurrent queries by estimated cost
+	release, err := e.admit(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	// Materialization, joins and post-join operators share one budget
+	budget := e.queryMemoryBudget(ctx)
 
 	// Phase 2: Execute sub-queries
-	results, err := e.executeSubQueries(ctx, plan, stats)
+	results, err := e.executeSubQueries(ctx, plan, stats, budget)
+	e.observeSubQueries(plan, stats)
 	if err != nil {
-		return nil, fmt.Errorf("sub-query execution failed: %w", err)
+		release()
+		return nil, err
 	}
 
-	// Phase 3: Execute joins if needed
+	// Phase 3: Execute joins if needed, or combine the operands of a set
+	// operation
 	var result ResultStream
-	if len(results) == 1 {
+	switch {
+	case plan.Decomposed.SetOperation != "":
+		result, err = newSetOperationStream(plan.Decomposed.SetOperation, results[0], results[1], budget)
+		if err != nil {
+			closeStreams(results)
+			release()
+			return nil, fmt.Errorf("set operation failed: %w", err)
+		}
+	case len(results) == 1:
 		result = results[0]
-	} else {
-		result, err = e.executeJoins(ctx, results, plan, stats)
+	default:
+		result, err = e.executeJoins(ctx, results, plan, stats, budget)
 		if err != nil {
+			closeStreams(results)
+			release()
 			return nil, fmt.Errorf("join execution failed: %w", err)
 		}
 	}
 
 	// Phase 4: Apply post-join operations
-	result, err = e.applyPostJoinOps(ctx, result, plan)
+	result, err = e.applyPostJoinOps(ctx, result, plan, budget)
 	if err != nil {
+		release()
 		return nil, fmt.Errorf("post-join operations failed: %w", err)
 	}
 
 	stats.TotalTime = time.Since(start)
 
-	return result, nil
+	if e.tiers != nil {
+		result = &admittedStream{ResultStream: result, release: release}
+	}
+	return &statsStream{ResultStream: result, stats: stats}, nil
+}
+
+// checkPredicates rejects a plan with WHERE predicates or ON conjuncts the
+// executor cannot evaluate (see QueryAnalysis.UnsupportedPredicates and
+// UnsupportedJoinPredicates) with ErrUnsupportedSyntax.
+func (p *ExecutionPlan) checkPredicates() error {
+	if p.Analysis == nil {
+		return nil
+	}
+	if preds := p.Analysis.UnsupportedPredicates; len(preds) > 0 {
+		return cerrors.NewUnsupportedSyntax(
+			fmt.Sprintf("WHERE predicate %s in a cross-engine query", preds[0]),
+			"comparisons of one table's columns with literals, or predicates over tables on a single engine",
+		)
+	}
+	if preds := p.Analysis.UnsupportedJoinPredicates; len(preds) > 0 {
+		return cerrors.NewUnsupportedSyntax(
+			fmt.Sprintf("ON condition %s in a cross-engine join", preds[0]),
+			"equalities between the columns of the joined tables, and filters on the tables an outer join pads with NULLs",
+		)
+	}
+	return nil
 }
 
-// Plan creates an execution plan for a query.
+// observeSubQueries records the latency of each sub-query that ran,
+// including failed ones, under its engine.
+func (e *FederatedExecutor) observeSubQueries(plan *ExecutionPlan, stats *ExecutionStats) {
+	for idx, elapsed := range stats.SubQueryTimes {
+		e.metrics.ObserveSubQuery(plan.SubQueryPlans[idx].Engine, elapsed)
+	}
+}
+
+// Plan creates an execution plan for a query, traced in a span.
 func (e *FederatedExecutor) Plan(ctx context.Context, query string) (*ExecutionPlan, error) {
+	ctx, span := e.tracer.Start(ctx, observability.SpanPlan)
+	plan, err := e.plan(ctx, query)
+	observability.EndSpan(span, err)
+	return plan, err
+}
+
+// plan creates an execution plan for a query.
+func (e *FederatedExecutor) plan(ctx context.Context, query string) (*ExecutionPlan, error) {
 	// Analyze the query
 	analysis, err := e.analyzer.Analyze(ctx, query)
 	if err != nil {
@@ -184,8 +491,18 @@ func (e *FederatedExecutor) Plan(ctx context.Context, query string) (*ExecutionP
 		return nil, fmt.Errorf("decomposition failed: %w", err)
 	}
 
+	// Reject queries spanning more engines than allowed
+	engines := decomposed.engines()
+	if err := e.safeMode.CheckEngines(engines); err != nil {
+		return nil, err
+	}
+	if len(engines) > e.maxEngines {
+		return nil, cerrors.NewTooManyEngines(engines, e.maxEngines)
+	}
+
 	// Optimize with pushdowns
-	decomposed, err = e.optimizer.Optimize(decomposed, analysis)
+	mode := PushdownModeFromContext(ctx)
+	decomposed, err = e.optimizer.OptimizeWithMode(decomposed, analysis, mode)
 	if err != nil {
 		return nil, fmt.Errorf("optimization failed: %w", err)
 	}
@@ -196,6 +513,15 @@ func (e *FederatedExecutor) Plan(ctx context.Context, query string) (*ExecutionP
 		return nil, fmt.Errorf("sub-query planning failed: %w", err)
 	}
 
+	// Broadcast small inputs into the sub-queries they join
+	e.selectBroadcastJoins(subQueryPlans, decomposed.JoinPlan, mode)
+
+	// Sort large inputs on their engines and merge them
+	e.selectMergeJoins(subQueryPlans, decomposed.JoinPlan, mode)
+
+	// Assign parallel groups based on dependencies
+	e.assignParallelGroups(subQueryPlans, decomposed.JoinPlan)
+
 	// Determine execution order
 	executionOrder := e.determineExecutionOrder(subQueryPlans, decomposed.JoinPlan)
 
@@ -206,6 +532,7 @@ func (e *FederatedExecutor) Plan(ctx context.Context, query string) (*ExecutionP
 		SubQueryPlans:  subQueryPlans,
 		JoinPlan:       decomposed.JoinPlan,
 		ExecutionOrder: executionOrder,
+		PushdownMode:   mode,
 	}, nil
 }
 
@@ -218,6 +545,7 @@ func (e *FederatedExecutor) buildSubQueryPlans(
 
 	for i, sq := range decomposed.SubQueries {
 		var estimatedRows int64 = 1000 // Default estimate
+		statsKnown := false
 
 		// Try to get table stats
 		adapter, err := e.registry.Get(sq.Engine)
@@ -225,6 +553,7 @@ func (e *FederatedExecutor) buildSubQueryPlans(
 			stats, err := adapter.TableStats(ctx, sq.Tables[0].Name)
 			if err == nil && stats != nil {
 				estimatedRows = stats.RowCount
+				statsKnown = true
 			}
 		}
 
@@ -234,29 +563,49 @@ func (e *FederatedExecutor) buildSubQueryPlans(
 			EstimatedRows:    estimatedRows,
 			ParallelGroup:    0, // Initially all in same group
 			RequiresMaterial: i < len(decomposed.SubQueries)-1, // All but last need materialization
+			StatsKnown:       statsKnown,
 		}
 	}
 
-	// Assign parallel groups based on dependencies
-	e.assignParallelGroups(plans, decomposed.JoinPlan)
-
 	return plans, nil
 }
 
 // assignParallelGroups determines which sub-queries can run in parallel.
+// Sub-queries run in group 0 unless they are the large input of a broadcast
+// join, which runs in the group after that of its small input, once the
+// keys filtering it are known.
 func (e *FederatedExecutor) assignParallelGroups(plans []*SubQueryPlan, joinPlan *JoinPlan) {
+	for i := range plans {
+		plans[i].ParallelGroup = 0
+	}
 	if joinPlan == nil || len(joinPlan.Steps) == 0 {
-		// No joins - all can run in parallel
-		for i := range plans {
-			plans[i].ParallelGroup = 0
-		}
 		return
 	}
 
-	// For hash join, both sides can run in parallel
-	// Use simple heuristic: all independent sub-queries in group 0
-	for i := range plans {
-		plans[i].ParallelGroup = 0
+	byID := make(map[string]*SubQueryPlan, len(plans))
+	for _, p := range plans {
+		byID[p.SubQuery.ID] = p
+	}
+
+	// Each pass settles the groups of one more level of broadcast inputs
+	for pass := 0; pass < len(plans); pass++ {
+		changed := false
+		for _, step := range joinPlan.Steps {
+			if step.Strategy != JoinStrategyBroadcast {
+				continue
+			}
+			small, large := byID[step.LeftInput], byID[step.RightInput]
+			if step.BroadcastInput == step.RightInput {
+				small, large = large, small
+			}
+			if small != nil && large != nil && large.ParallelGroup <= small.ParallelGroup {
+				large.ParallelGroup = small.ParallelGroup + 1
+				changed = true
+			}
+		}
+		if !changed {
+			return
+		}
 	}
 }
 
@@ -270,87 +619,298 @@ func (e *FederatedExecutor) determineExecutionOrder(
 		order[i] = i
 	}
 
-	// Sort by estimated rows (smaller first for hash join build phase)
-	for i := 0; i < len(order)-1; i++ {
-		for j := i + 1; j < len(order); j++ {
-			if plans[order[i]].EstimatedRows > plans[order[j]].EstimatedRows {
-				order[i], order[j] = order[j], order[i]
-			}
+	// Sort by parallel group, then by estimated rows (smaller first for
+	// hash join build phase). The sort is stable, so sub-queries with equal
+	// estimates keep decomposition order, which is sub-query ID order.
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := plans[order[i]], plans[order[j]]
+		if a.ParallelGroup != b.ParallelGroup {
+			return a.ParallelGroup < b.ParallelGroup
 		}
-	}
+		return a.EstimatedRows < b.EstimatedRows
+	})
 
 	return order
 }
 
-// executeSubQueries executes all sub-queries, potentially in parallel.
+// executeSubQueries executes all sub-queries, one parallel group after the
+// other. The sub-queries of a group run in execution order on at most
+// maxParallelism workers, so the build side of a join, which is smaller, is
+// started before its probe side. The large sub-query of a broadcast join is
+// in a later group than its small input, and is filtered on the keys of its
+// results. Each sub-query runs under its own context, bound by the sub-query
+// timeout; when one fails, the others are cancelled and the first failure
+// is returned as ErrSubQueryFailed.
 func (e *FederatedExecutor) executeSubQueries(
 	ctx context.Context,
 	plan *ExecutionPlan,
 	stats *ExecutionStats,
+	budget *MemoryBudget,
 ) ([]ResultStream, error) {
 	numSubQueries := len(plan.SubQueryPlans)
 	results := make([]ResultStream, numSubQueries)
-	errors := make([]error, numSubQueries)
 
-	var wg sync.WaitGroup
+	broadcasts := plan.broadcastJoins()
+	keys := make(map[int]*broadcastKeys)
+	for _, join := range broadcasts {
+		keys[join.small] = newBroadcastKeys(join.smallKey, e.maxBroadcastKeys)
+	}
 
-	for _, idx := range plan.ExecutionOrder {
-		idx := idx // Capture for goroutine
+	var (
+		mu       sync.Mutex
+		cancels  []context.CancelFunc
+		failed   = -1 // index of the first failed sub-query
+		firstErr error
+	)
+	fail := func(idx int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failed < 0 {
+			failed, firstErr = idx, err
+		}
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+	workers := make(chan struct{}, e.maxParallelism)
+	var wg sync.WaitGroup
+	run := func(idx int, subQuery *SubQuery) {
+		workers <- struct{}{}
 		subPlan := plan.SubQueryPlans[idx]
+		subCtx, cancel := e.subQueryContext(ctx)
+		mu.Lock()
+		if failed >= 0 {
+			// Another sub-query failed while this one waited for a worker
+			mu.Unlock()
+			cancel()
+			<-workers
+			return
+		}
+		cancels = append(cancels, cancel)
+		mu.Unlock()
 
 		wg.Add(1)
 		go func() {
-			defer wg.Done()
-
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			spanCtx, span := e.tracer.Start(subCtx, observability.SpanSubQuery, trace.WithAttributes(
+				observability.AttrEngine.String(subPlan.Engine),
+				observability.AttrEstimatedRows.Int64(subPlan.EstimatedRows),
+			))
 			start := time.Now()
+			result, err := e.executeSubQuery(spanCtx, cancel, subPlan, subQuery, keys[idx], stats, budget)
+			elapsed := time.Since(start)
 
-			adapter, err := e.registry.Get(subPlan.Engine)
+			if err != nil && ctx.Err() == nil && subCtx.Err() == context.DeadlineExceeded {
+				err = cerrors.NewEngineTimeout(subPlan.Engine, e.subQueryTimeout)
+			}
 			if err != nil {
-				errors[idx] = err
-				return
+				err = cerrors.NewSubQueryFailed(subPlan.Engine, subQuery.tableNames(), subQuery.SQL, err)
 			}
-
-			result, err := adapter.Execute(ctx, subPlan.SubQuery.SQL)
+			observability.EndSpan(span, err)
+			mu.Lock()
+			results[idx] = result
+			stats.SubQueryTimes[idx] = elapsed
+			mu.Unlock()
 			if err != nil {
-				errors[idx] = fmt.Errorf("engine %s: %w", subPlan.Engine, err)
-				return
+				fail(idx, err)
 			}
+		}()
+	}
 
-			// Materialize if needed for joins
-			if subPlan.RequiresMaterial {
-				store := NewMemoryResultStore(result.Schema())
-				for {
-					row, err := result.Next(ctx)
-					if err != nil {
-						errors[idx] = fmt.Errorf("materialization failed: %w", err)
-						return
-					}
-					if row == nil {
-						break
-					}
-					if err := store.Append(row); err != nil {
-						errors[idx] = fmt.Errorf("materialization append failed: %w", err)
-						return
-					}
-				}
-				result = store.Stream()
+	for _, group := range plan.parallelGroups() {
+		for _, idx := range group {
+			subQuery := plan.SubQueryPlans[idx].SubQuery
+			if join, ok := broadcasts[idx]; ok {
+				subQuery = keys[join.small].filter(subQuery, join.largeKey)
 			}
+			run(idx, subQuery)
+		}
+		wg.Wait()
+	}
 
-			results[idx] = result
-			stats.SubQueryTimes[idx] = time.Since(start)
-		}()
+	if failed >= 0 {
+		closeStreams(results)
+		return nil, firstErr
 	}
 
-	wg.Wait()
+	return results, nil
+}
 
-	// Check for errors
-	for i, err := range errors {
-		if err != nil {
-			return nil, fmt.Errorf("sub-query %d failed: %w", i, err)
+// parallelGroups returns the sub-query indexes of each parallel group, in
+// group order, each in execution order.
+func (p *ExecutionPlan) parallelGroups() [][]int {
+	var groups [][]int
+	for _, idx := range p.ExecutionOrder {
+		group := p.SubQueryPlans[idx].ParallelGroup
+		for len(groups) <= group {
+			groups = append(groups, nil)
 		}
+		groups[group] = append(groups[group], idx)
 	}
+	return groups
+}
 
-	return results, nil
+// subQueryContext returns the context of a sub-query, with the sub-query
+// timeout when one is set.
+func (e *FederatedExecutor) subQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.subQueryTimeout > 0 {
+		return context.WithTimeout(ctx, e.subQueryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// executeSubQuery runs a sub-query on its engine. Its results are
+// materialized if the plan requires it, and their join keys collected
+// into keys when it is the small input of a broadcast join. The sub-query's
+// context is cancelled once its results are materialized, or else when its
+// stream is closed.
+func (e *FederatedExecutor) executeSubQuery(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	subPlan *SubQueryPlan,
+	subQuery *SubQuery,
+	keys *broadcastKeys,
+	stats *ExecutionStats,
+	budget *MemoryBudget,
+) (ResultStream, error) {
+	adapter, err := e.registry.Get(subPlan.Engine)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	result, err := adapter.Execute(ctx, subQuery.SQL)
+	if err != nil {
+		cancel()
+		return nil, adapters.ClassifyEngineError(subPlan.Engine, err)
+	}
+	result = newSubQueryStream(ctx, cancel, result, subPlan.Engine, e.subQueryTimeout)
+
+	// Rows arrive in the order of a pushed ORDER BY, or of one the engine's
+	// stream declares; filtering and materialization keep it
+	order := subQuery.OrderBy
+	if ordered, ok := result.(OrderedStream); ok && len(order) == 0 {
+		order = ordered.SortOrder()
+	}
+
+	// Label the columns of a single table with it, for joins to
+	// qualify the columns shared with other tables
+	if len(subQuery.Tables) == 1 {
+		result = withTable(result, subQuery.Tables[0].qualifier())
+	}
+	result = countTransferred(result, stats)
+
+	// Apply predicates that were not pushed to the engine
+	if len(subQuery.PostFilters) > 0 {
+		result = NewPredicateFilterStream(result, subQuery.PostFilters)
+	}
+
+	// Materialize if needed for joins, spilling to disk over budget
+	if subPlan.RequiresMaterial {
+		source := result
+		defer source.Close()
+		store := NewMemoryResultStore(result.Schema())
+		store.SetBudget(budget, true)
+		for {
+			row, err := result.Next(ctx)
+			if err != nil {
+				store.Close()
+				return nil, fmt.Errorf("materialization failed: %w", err)
+			}
+			if row == nil {
+				break
+			}
+			if keys != nil {
+				keys.add(row)
+			}
+			if err := store.Append(row); err != nil {
+				store.Close()
+				return nil, fmt.Errorf("materialization append failed: %w", err)
+			}
+		}
+		result = &materializedStream{ResultStream: store.Stream(), store: store}
+	}
+
+	if len(order) > 0 {
+		result = NewOrderedStream(result, order)
+	}
+	return result, nil
+}
+
+// closeStreams closes the non-nil streams of a failed query, releasing
+// materialized rows and spill files.
+func closeStreams(streams []ResultStream) {
+	for _, stream := range streams {
+		if stream != nil {
+			stream.Close()
+		}
+	}
+}
+
+// transferStream counts the rows read from an engine, and their estimated
+// size, in the statistics of their query.
+type transferStream struct {
+	ResultStream
+	stats *ExecutionStats
+}
+
+// countTransferred returns stream with its rows and their size added to
+// stats as they are read.
+func countTransferred(stream ResultStream, stats *ExecutionStats) ResultStream {
+	counted := &transferStream{ResultStream: stream, stats: stats}
+	if ordered, ok := stream.(OrderedStream); ok {
+		return NewOrderedStream(counted, ordered.SortOrder())
+	}
+	return counted
+}
+
+// Next returns the next row and counts it.
+func (s *transferStream) Next(ctx context.Context) (Row, error) {
+	row, err := s.ResultStream.Next(ctx)
+	if row != nil {
+		atomic.AddInt64(&s.stats.RowsProcessed, 1)
+		atomic.AddInt64(&s.stats.BytesTransferred, estimateRowBytes(row))
+	}
+	return row, err
+}
+
+// subQueryStream reads the results of a sub-query under its context, which
+// it cancels when closed. Errors caused by the context's deadline are
+// reported as ErrEngineTimeout.
+type subQueryStream struct {
+	ResultStream
+	ctx     context.Context
+	cancel  context.CancelFunc
+	engine  string
+	timeout time.Duration
+}
+
+// newSubQueryStream returns stream bound to the context of its sub-query.
+func newSubQueryStream(ctx context.Context, cancel context.CancelFunc, stream ResultStream, engine string, timeout time.Duration) ResultStream {
+	bound := &subQueryStream{ResultStream: stream, ctx: ctx, cancel: cancel, engine: engine, timeout: timeout}
+	if ordered, ok := stream.(OrderedStream); ok {
+		return NewOrderedStream(bound, ordered.SortOrder())
+	}
+	return bound
+}
+
+// Next returns the next row.
+func (s *subQueryStream) Next(ctx context.Context) (Row, error) {
+	row, err := s.ResultStream.Next(ctx)
+	if err != nil && ctx.Err() == nil && s.ctx.Err() == context.DeadlineExceeded {
+		return nil, cerrors.NewEngineTimeout(s.engine, s.timeout)
+	}
+	return row, err
+}
+
+// Close closes the stream and cancels the sub-query's context.
+func (s *subQueryStream) Close() error {
+	defer s.cancel()
+	return s.ResultStream.Close()
 }
 
 // executeJoins executes the join plan on sub-query results.
@@ -359,58 +919,64 @@ func (e *FederatedExecutor) executeJoins(
 	results []ResultStream,
 	plan *ExecutionPlan,
 	stats *ExecutionStats,
+	budget *MemoryBudget,
 ) (ResultStream, error) {
 	if plan.JoinPlan == nil || len(plan.JoinPlan.Steps) == 0 {
 		return nil, fmt.Errorf("no join plan for multiple results")
 	}
 
+	ctx, span := e.tracer.Start(ctx, observability.SpanJoin)
+	defer span.End()
 	start := time.Now()
-	current := results[0]
 
-	// Build a map from sub-query ID to result stream
-	subQueryResults := make(map[string]ResultStream)
+	// Inputs are sub-query results, or the results of earlier steps
+	// (left-deep: each step's left input is the previous step's result)
+	inputs := make(map[string]ResultStream)
 	for i, sq := range plan.Decomposed.SubQueries {
-		subQueryResults[sq.ID] = results[i]
+		inputs[sq.ID] = results[i]
 	}
 
-	// Also track intermediate join results
-	stepResults := make(map[int]ResultStream)
-
+	var current ResultStream
 	for i, step := range plan.JoinPlan.Steps {
-		// Determine left and right streams
-		var leftStream, rightStream ResultStream
-
-		// Left input is either a sub-query or previous step result
-		if leftResult, ok := subQueryResults[step.LeftInput]; ok {
-			leftStream = leftResult
-		} else if i > 0 {
-			leftStream = stepResults[i-1]
-		} else {
-			leftStream = current
+		leftStream, ok := inputs[step.LeftInput]
+		if !ok {
+			return nil, fmt.Errorf("join step %d: unknown left input %s", i, step.LeftInput)
 		}
-
-		// Right input is a sub-query ID
-		rightStream, ok := subQueryResults[step.RightInput]
+		rightStream, ok := inputs[step.RightInput]
 		if !ok {
-			return nil, fmt.Errorf("invalid right sub-query: %s", step.RightInput)
+			return nil, fmt.Errorf("join step %d: unknown right input %s", i, step.RightInput)
 		}
+		// Each input is consumed by exactly one step
+		delete(inputs, step.LeftInput)
+		delete(inputs, step.RightInput)
 
 		// Build JoinConfig
 		joinConfig := &JoinConfig{
-			BuildSide:  leftStream,
-			ProbeSide:  rightStream,
-			BuildKey:   step.LeftKey,
-			ProbeKey:   step.RightKey,
-			Type:       step.Type,
-			AllowSpill: true,
+			BuildSide:   leftStream,
+			ProbeSide:   rightStream,
+			Type:        step.Type,
+			AllowSpill:  true,
+			SpillDir:    e.spillDir,
+			Budget:      budget,
+			KeyCoercion: e.keyPolicy,
+		}
+		joinConfig.BuildKeys, joinConfig.ProbeKeys = step.keyColumns()
+
+		// Inputs that arrive sorted on their keys are merged rather than
+		// hashed, so neither side is held in memory. Compound keys are
+		// always hashed.
+		strategy := step.Strategy
+		if strategy == JoinStrategyHash && len(step.Keys) == 1 &&
+			sortedOnKey(leftStream, step.Keys[0].LeftKey) && sortedOnKey(rightStream, step.Keys[0].RightKey) {
+			strategy = JoinStrategyMerge
 		}
 
-		joined, err := ExecuteJoin(ctx, step.Strategy, joinConfig)
+		joined, err := ExecuteJoin(ctx, strategy, joinConfig)
 		if err != nil {
 			return nil, fmt.Errorf("join step %d failed: %w", i, err)
 		}
 
-		stepResults[i] = joined
+		inputs[joinStepID(step.StepID)] = joined
 		current = joined
 	}
 
@@ -423,6 +989,7 @@ func (e *FederatedExecutor) applyPostJoinOps(
 	ctx context.Context,
 	result ResultStream,
 	plan *ExecutionPlan,
+	budget *MemoryBudget,
 ) (ResultStream, error) {
 	if plan.Decomposed.PostJoinOps == nil {
 		return result, nil
@@ -430,12 +997,22 @@ func (e *FederatedExecutor) applyPostJoinOps(
 
 	postOps := plan.Decomposed.PostJoinOps
 
-	// Apply final aggregation if needed
-	if len(postOps.Aggregations) > 0 {
-		result = &aggregatingStream{
-			source:       result,
-			aggregations: postOps.Aggregations,
+	// Apply final aggregation if needed, combining the partial aggregates
+	// of a sub-query that computed them. A GROUP BY without aggregates
+	// still groups the rows.
+	if len(postOps.Aggregations) > 0 || len(postOps.GroupBy) > 0 {
+		var partials []partialColumns
+		for _, sq := range plan.Decomposed.SubQueries {
+			if sq.PartialAggregates {
+				partials = partialAggregateColumns(postOps.Aggregations)
+			}
 		}
+		result = newAggregationStream(result, postOps.Aggregations, postOps.GroupBy, partials, budget)
+	}
+
+	// Remove duplicate rows for SELECT DISTINCT
+	if postOps.Distinct {
+		result = newDistinctingStream(result, postOps.DistinctColumns, budget)
 	}
 
 	// Apply final ORDER BY
@@ -443,73 +1020,30 @@ func (e *FederatedExecutor) applyPostJoinOps(
 		result = &sortingStream{
 			source:  result,
 			orderBy: postOps.OrderBy,
+			budget:  budget,
 		}
 	}
 
-	// Apply final LIMIT
+	// Apply final LIMIT and OFFSET
 	if postOps.Limit != nil {
-		result = &limitingStream{
+		limited := &limitingStream{
 			source: result,
 			limit:  *postOps.Limit,
 		}
-	}
-
-	return result, nil
-}
-
-// aggregatingStream applies aggregation to results.
-type aggregatingStream struct {
-	source       ResultStream
-	aggregations []*Aggregation
-	done         bool
-	result       Row
-}
-
-func (a *aggregatingStream) Schema() *ResultSchema {
-	return a.source.Schema()
-}
-
-func (a *aggregatingStream) Next(ctx context.Context) (Row, error) {
-	if a.done {
-		return nil, nil
-	}
-
-	// Collect all rows and compute aggregations
-	// This is a simplified implementation
-	var rows []Row
-	for {
-		row, err := a.source.Next(ctx)
-		if err != nil {
-			return nil, err
-		}
-		if row == nil {
-			break
+		if postOps.Offset != nil {
+			limited.offset = *postOps.Offset
 		}
-		rows = append(rows, row)
-	}
-
-	a.done = true
-
-	// Return aggregation result (simplified)
-	if len(rows) == 0 {
-		return nil, nil
+		result = limited
 	}
 
-	return rows[0], nil // Placeholder - real implementation would compute aggregates
-}
-
-func (a *aggregatingStream) Close() error {
-	return a.source.Close()
-}
-
-func (a *aggregatingStream) EstimatedRows() int64 {
-	return 1 // Aggregation typically returns few rows
+	return result, nil
 }
 
 // sortingStream applies ORDER BY to results.
 type sortingStream struct {
 	source    ResultStream
 	orderBy   []*OrderByClause
+	budget    *MemoryBudget
 	sorted    []Row
 	index     int
 	collected bool
@@ -530,9 +1064,14 @@ func (s *sortingStream) Next(ctx context.Context) (Row, error) {
 			if row == nil {
 				break
 			}
+			if err := s.budget.Reserve(OperatorSort, estimateRowBytes(row)); err != nil {
+				return nil, err
+			}
 			s.sorted = append(s.sorted, row)
 		}
-		// Sorting would happen here (simplified - just use collected order)
+		if err := s.sort(); err != nil {
+			return nil, err
+		}
 		s.collected = true
 	}
 
@@ -545,6 +1084,25 @@ func (s *sortingStream) Next(ctx context.Context) (Row, error) {
 	return row, nil
 }
 
+// sort orders the collected rows by the ORDER BY clauses. The sort is
+// stable, so rows the clauses do not distinguish keep their input order.
+// Values that cannot be ordered against each other fail the sort.
+func (s *sortingStream) sort() error {
+	var sortErr error
+	sort.SliceStable(s.sorted, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+		cmp, err := CompareRows(s.sorted[i], s.sorted[j], s.orderBy)
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		return cmp < 0
+	})
+	return sortErr
+}
+
 func (s *sortingStream) Close() error {
 	return s.source.Close()
 }
@@ -557,6 +1115,7 @@ func (s *sortingStream) EstimatedRows() int64 {
 type limitingStream struct {
 	source ResultStream
 	limit  int
+	offset int // rows skipped before the limit
 	count  int
 }
 
@@ -569,6 +1128,13 @@ func (l *limitingStream) Next(ctx context.Context) (Row, error) {
 		return nil, nil
 	}
 
+	for ; l.offset > 0; l.offset-- {
+		row, err := l.source.Next(ctx)
+		if err != nil || row == nil {
+			return nil, err
+		}
+	}
+
 	row, err := l.source.Next(ctx)
 	if err != nil {
 		return nil, err
@@ -603,18 +1169,41 @@ func (e *FederatedExecutor) Explain(ctx context.Context, query string) (string,
 	var sb strings.Builder
 	sb.WriteString("=== Federated Query Execution Plan ===\n\n")
 
+	sb.WriteString(fmt.Sprintf("Pushdown: %s\n\n", plan.PushdownMode))
 	sb.WriteString("Sub-Queries:\n")
 	for i, sqp := range plan.SubQueryPlans {
 		sb.WriteString(fmt.Sprintf("  [%d] Engine: %s, Est. Rows: %d\n",
 			i, sqp.Engine, sqp.EstimatedRows))
 		sb.WriteString(fmt.Sprintf("      SQL: %s\n", sqp.SubQuery.SQL))
+		for _, pred := range sqp.SubQuery.PostFilters {
+			sb.WriteString(fmt.Sprintf("      Post-Filter: %s\n", pred.Raw))
+		}
+	}
+
+	if predicates := plan.Predicates(); len(predicates) > 0 {
+		sb.WriteString("\nPredicates:\n")
+		for _, pred := range predicates {
+			sb.WriteString(fmt.Sprintf("  %s\n", pred))
+		}
 	}
 
 	if plan.JoinPlan != nil && len(plan.JoinPlan.Steps) > 0 {
 		sb.WriteString("\nJoin Plan:\n")
 		for i, step := range plan.JoinPlan.Steps {
-			sb.WriteString(fmt.Sprintf("  Step %d: %s JOIN on %v = %v\n",
-				i, step.Type, step.LeftKey, step.RightKey))
+			if step.Type == JoinTypeCross {
+				sb.WriteString(fmt.Sprintf("  Step %d: %s CROSS JOIN %s\n", i, step.LeftInput, step.RightInput))
+				continue
+			}
+			var conditions []string
+			for _, key := range step.Keys {
+				conditions = append(conditions, key.left()+" = "+key.right())
+			}
+			condition := strings.Join(conditions, " AND ")
+			if step.Strategy == JoinStrategyBroadcast {
+				condition += " (broadcast " + step.BroadcastInput + ")"
+			}
+			sb.WriteString(fmt.Sprintf("  Step %d: %s %s JOIN %s on %s\n",
+				i, step.LeftInput, step.Type, step.RightInput, condition))
 		}
 	}
 
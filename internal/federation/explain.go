@@ -0,0 +1,100 @@
+package federation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PredicateDisposition is where a WHERE predicate of a federated query is
+// evaluated.
+type PredicateDisposition string
+
+const (
+	// PredicatePushed predicates are evaluated by the engine, in its
+	// sub-query's WHERE clause.
+	PredicatePushed PredicateDisposition = "pushed"
+
+	// PredicatePostFilter predicates are evaluated by the gateway on the
+	// engine's results, before the join.
+	PredicatePostFilter PredicateDisposition = "post_filter"
+
+	// PredicateRejected predicates are not supported in federated queries;
+	// executing a query with any of them fails with ErrUnsupportedSyntax.
+	PredicateRejected PredicateDisposition = "rejected"
+)
+
+// PredicateExplanation reports the disposition of one predicate of a
+// federated query, for EXPLAIN.
+type PredicateExplanation struct {
+	// Predicate is the predicate's SQL text.
+	Predicate string `json:"predicate"`
+
+	// Disposition is where the predicate is evaluated.
+	Disposition PredicateDisposition `json:"disposition"`
+
+	// Engine is the engine whose sub-query the predicate applies to. Empty
+	// for rejected predicates.
+	Engine string `json:"engine,omitempty"`
+
+	// Reason explains why a predicate was not pushed.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Predicates returns the disposition of every predicate of the query: those
+// pushed to each engine, those kept as post-filters on its results, and
+// the WHERE and ON predicates the query is rejected for, in that order.
+func (p *ExecutionPlan) Predicates() []PredicateExplanation {
+	var explanations []PredicateExplanation
+	for _, sqp := range p.SubQueryPlans {
+		for _, pred := range sqp.SubQuery.Predicates {
+			explanations = append(explanations, PredicateExplanation{
+				Predicate:   pred.Raw,
+				Disposition: PredicatePushed,
+				Engine:      sqp.Engine,
+			})
+		}
+	}
+	for _, sqp := range p.SubQueryPlans {
+		for _, pred := range sqp.SubQuery.PostFilters {
+			reason := fmt.Sprintf("operator %s is not push-safe for %s", strings.ToUpper(pred.Operator), sqp.Engine)
+			if p.PushdownMode == PushdownDisabled {
+				reason = "pushdown disabled"
+			}
+			explanations = append(explanations, PredicateExplanation{
+				Predicate:   pred.Raw,
+				Disposition: PredicatePostFilter,
+				Engine:      sqp.Engine,
+				Reason:      reason,
+			})
+		}
+	}
+	if p.Analysis != nil {
+		for _, pred := range p.Analysis.UnsupportedPredicates {
+			explanations = append(explanations, PredicateExplanation{
+				Predicate:   pred,
+				Disposition: PredicateRejected,
+				Reason:      "not a single-table comparison with a literal",
+			})
+		}
+		for _, pred := range p.Analysis.UnsupportedJoinPredicates {
+			explanations = append(explanations, PredicateExplanation{
+				Predicate:   pred,
+				Disposition: PredicateRejected,
+				Reason:      "ON condition that is neither an equality of two tables' columns nor a filter of one table before the join",
+			})
+		}
+	}
+	return explanations
+}
+
+// String describes the disposition for EXPLAIN output.
+func (e PredicateExplanation) String() string {
+	switch e.Disposition {
+	case PredicatePushed:
+		return fmt.Sprintf("%s: pushed to %s", e.Predicate, e.Engine)
+	case PredicatePostFilter:
+		return fmt.Sprintf("%s: kept as post-filter on %s results (%s)", e.Predicate, e.Engine, e.Reason)
+	default:
+		return fmt.Sprintf("%s: unsupported, the query is rejected (%s)", e.Predicate, e.Reason)
+	}
+}
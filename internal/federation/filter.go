@@ -0,0 +1,252 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NewPredicateFilterStream returns a stream that yields only the rows of
+// source matching every predicate. It evaluates predicates that were not
+// pushed down to the source engine.
+func NewPredicateFilterStream(source ResultStream, predicates []*Predicate) ResultStream {
+	return &predicateFilterStream{source: source, predicates: predicates}
+}
+
+// predicateFilterStream filters rows locally.
+type predicateFilterStream struct {
+	source     ResultStream
+	predicates []*Predicate
+}
+
+// Schema returns the source schema.
+func (s *predicateFilterStream) Schema() *ResultSchema {
+	return s.source.Schema()
+}
+
+// Next returns the next matching row.
+func (s *predicateFilterStream) Next(ctx context.Context) (Row, error) {
+	for {
+		row, err := s.source.Next(ctx)
+		if err != nil || row == nil {
+			return row, err
+		}
+		match, err := s.matches(row)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			return row, nil
+		}
+	}
+}
+
+// Close closes the source stream.
+func (s *predicateFilterStream) Close() error {
+	return s.source.Close()
+}
+
+// EstimatedRows returns -1; the selectivity of the filter is unknown.
+func (s *predicateFilterStream) EstimatedRows() int64 {
+	return -1
+}
+
+func (s *predicateFilterStream) matches(row Row) (bool, error) {
+	for _, pred := range s.predicates {
+		ok, err := evaluatePredicate(pred, row)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evaluatePredicate evaluates a simple predicate against a row.
+// Comparisons with NULL are false, as in SQL.
+func evaluatePredicate(pred *Predicate, row Row) (bool, error) {
+	value, ok := lookupColumn(row, pred.Column)
+	if !ok {
+		return false, fmt.Errorf("post-filter %s: column %s not in result", pred.Raw, pred.Column)
+	}
+	if value == nil {
+		return false, nil
+	}
+	literal := parsePredicateLiteral(pred.Value)
+
+	switch op := strings.ToUpper(pred.Operator); op {
+	case "LIKE":
+		pattern, ok := literal.(string)
+		if !ok {
+			return false, fmt.Errorf("post-filter %s: LIKE requires a string pattern", pred.Raw)
+		}
+		return likePattern(pattern).MatchString(fmt.Sprint(value)), nil
+	case "IN":
+		items, ok := literal.([]interface{})
+		if !ok {
+			items = []interface{}{literal}
+		}
+		if pred.Values != nil {
+			items = predicateLiterals(pred.Values)
+		}
+		for _, item := range items {
+			cmp, err := compareValues(value, item)
+			if err != nil {
+				return false, fmt.Errorf("post-filter %s: %w", pred.Raw, err)
+			}
+			if cmp == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "BETWEEN":
+		if len(pred.Values) != 2 {
+			return false, fmt.Errorf("post-filter %s: BETWEEN requires a lower and an upper bound", pred.Raw)
+		}
+		bounds := predicateLiterals(pred.Values)
+		low, err := compareValues(value, bounds[0])
+		if err != nil {
+			return false, fmt.Errorf("post-filter %s: %w", pred.Raw, err)
+		}
+		high, err := compareValues(value, bounds[1])
+		if err != nil {
+			return false, fmt.Errorf("post-filter %s: %w", pred.Raw, err)
+		}
+		return low >= 0 && high <= 0, nil
+	default:
+		cmp, err := compareValues(value, literal)
+		if err != nil {
+			return false, fmt.Errorf("post-filter %s: %w", pred.Raw, err)
+		}
+		switch op {
+		case "=":
+			return cmp == 0, nil
+		case "<>", "!=":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case ">":
+			return cmp > 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">=":
+			return cmp >= 0, nil
+		default:
+			return false, fmt.Errorf("post-filter %s: unsupported operator %s", pred.Raw, pred.Operator)
+		}
+	}
+}
+
+// lookupColumn looks up a column, falling back to a case-insensitive match.
+func lookupColumn(row Row, column string) (interface{}, bool) {
+	if v, ok := row[column]; ok {
+		return v, true
+	}
+	for name, v := range row {
+		if strings.EqualFold(name, column) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// parsePredicateLiteral converts a SQL literal captured by the analyzer
+// ('text', 42, 1.5 or a parenthesized list) into a Go value.
+func parsePredicateLiteral(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		var items []interface{}
+		for _, item := range splitLiteralList(s[1 : len(s)-1]) {
+			items = append(items, parsePredicateLiteral(item))
+		}
+		return items
+	}
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// formatPredicateLiteral formats a string or number as a SQL literal, the
+// inverse of parsePredicateLiteral. It reports false for other values.
+func formatPredicateLiteral(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case string:
+		return "'" + strings.ReplaceAll(n, "'", "''") + "'", true
+	case float32:
+		return formatPredicateLiteral(float64(n))
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	case uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(n), true
+	}
+	if i, ok := toInt64(v); ok {
+		return strconv.FormatInt(i, 10), true
+	}
+	return "", false
+}
+
+// predicateLiterals converts the literals of an IN list or BETWEEN range
+// into Go values.
+func predicateLiterals(values []interface{}) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, v := range values {
+		items[i] = parsePredicateLiteral(v)
+	}
+	return items
+}
+
+// splitLiteralList splits a comma-separated literal list, ignoring commas
+// inside quoted strings.
+func splitLiteralList(s string) []string {
+	var items []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ',' && !quoted:
+			items = append(items, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(items, current.String())
+}
+
+// likePattern compiles a SQL LIKE pattern (% and _ wildcards) to a regexp.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile("(?s)" + b.String())
+}
@@ -0,0 +1,216 @@
+package federation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// planShape is the structural part of an ExecutionPlan hashed by
+// PlanFingerprint. It covers what the planner decides (engines, pushed-down
+// SQL and predicates, join order and strategy, post-join work) and leaves out
+// what varies between equivalent queries and runs of the same plan: the query
+// text and the row and cost estimates.
+type planShape struct {
+	PushdownMode   PushdownMode    `json:"pushdown_mode"`
+	SubQueries     []subQueryShape `json:"sub_queries"`
+	Joins          []joinShape     `json:"joins"`
+	SetOperation   string          `json:"set_operation,omitempty"`
+	ExecutionOrder []int           `json:"execution_order"`
+	PostJoin       *postJoinShape  `json:"post_join,omitempty"`
+}
+
+// subQueryShape is the structural part of a SubQueryPlan.
+type subQueryShape struct {
+	Engine           string           `json:"engine"`
+	SQL              string           `json:"sql"`
+	Tables           []string         `json:"tables"`
+	Predicates       []predicateShape `json:"predicates"`
+	PostFilters      []predicateShape `json:"post_filters"`
+	Columns          []string         `json:"columns"`
+	ParallelGroup    int              `json:"parallel_group"`
+	RequiresMaterial bool             `json:"requires_material"`
+}
+
+// predicateShape is the structural part of a Predicate.
+type predicateShape struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// joinShape is the structural part of a JoinStep.
+type joinShape struct {
+	Type       JoinType     `json:"type"`
+	LeftInput  string       `json:"left_input"`
+	RightInput string       `json:"right_input"`
+	Keys       []string     `json:"keys"`
+	Strategy   JoinStrategy `json:"strategy"`
+}
+
+// postJoinShape is the structural part of PostJoinOperations.
+type postJoinShape struct {
+	Aggregations []string `json:"aggregations"`
+	GroupBy      []string `json:"group_by"`
+	OrderBy      []string `json:"order_by"`
+	Limit        *int     `json:"limit,omitempty"`
+	Offset       *int     `json:"offset,omitempty"`
+}
+
+// PlanFingerprint returns a hash of the structure of a plan. Equivalent
+// queries planned the same way have the same fingerprint; a change to the
+// chosen engines, pushdowns, join order or join strategy changes it.
+//
+// Fingerprints are meant to be compared against golden values recorded for
+// representative queries (see PlanFingerprints and ComparePlanFingerprints),
+// so that CI flags unintended plan changes.
+func PlanFingerprint(plan *ExecutionPlan) string {
+	// The shape holds only strings, integers and booleans, so encoding
+	// cannot fail
+	data, _ := json.Marshal(newPlanShape(plan))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newPlanShape extracts the structural part of a plan.
+func newPlanShape(plan *ExecutionPlan) planShape {
+	shape := planShape{
+		PushdownMode:   plan.PushdownMode,
+		SubQueries:     []subQueryShape{},
+		Joins:          []joinShape{},
+		ExecutionOrder: plan.ExecutionOrder,
+	}
+
+	for _, sqp := range plan.SubQueryPlans {
+		sq := sqp.SubQuery
+		sub := subQueryShape{
+			Engine:           sqp.Engine,
+			SQL:              sq.SQL,
+			Tables:           make([]string, len(sq.Tables)),
+			Predicates:       newPredicateShapes(sq.Predicates),
+			PostFilters:      newPredicateShapes(sq.PostFilters),
+			Columns:          sq.Columns,
+			ParallelGroup:    sqp.ParallelGroup,
+			RequiresMaterial: sqp.RequiresMaterial,
+		}
+		for i, table := range sq.Tables {
+			sub.Tables[i] = fmt.Sprintf("%s@%s/%s", table.FullName(), table.Engine, table.Format)
+		}
+		shape.SubQueries = append(shape.SubQueries, sub)
+	}
+
+	if plan.JoinPlan != nil {
+		for _, step := range plan.JoinPlan.Steps {
+			join := joinShape{
+				Type:       step.Type,
+				LeftInput:  step.LeftInput,
+				RightInput: step.RightInput,
+				Strategy:   step.Strategy,
+			}
+			for _, key := range step.Keys {
+				join.Keys = append(join.Keys, key.LeftKey+"="+key.RightKey)
+			}
+			shape.Joins = append(shape.Joins, join)
+		}
+	}
+
+	if plan.Decomposed != nil {
+		shape.SetOperation = plan.Decomposed.SetOperation
+	}
+
+	if plan.Decomposed != nil && plan.Decomposed.PostJoinOps != nil {
+		ops := plan.Decomposed.PostJoinOps
+		post := &postJoinShape{
+			Aggregations: make([]string, len(ops.Aggregations)),
+			GroupBy:      ops.GroupBy,
+			OrderBy:      make([]string, len(ops.OrderBy)),
+			Limit:        ops.Limit,
+			Offset:       ops.Offset,
+		}
+		for i, agg := range ops.Aggregations {
+			post.Aggregations[i] = fmt.Sprintf("%s(%s) AS %s", agg.Function, agg.Column, agg.Alias)
+		}
+		for i, ob := range ops.OrderBy {
+			post.OrderBy[i] = ob.Column
+			if ob.Descending {
+				post.OrderBy[i] += " DESC"
+			}
+			if ob.NullsFirst {
+				post.OrderBy[i] += " NULLS FIRST"
+			}
+		}
+		shape.PostJoin = post
+	}
+
+	return shape
+}
+
+// newPredicateShapes extracts the structural part of predicates.
+func newPredicateShapes(preds []*Predicate) []predicateShape {
+	shapes := make([]predicateShape, len(preds))
+	for i, p := range preds {
+		shapes[i] = predicateShape{
+			Table:    p.Table,
+			Column:   p.Column,
+			Operator: p.Operator,
+			Value:    fmt.Sprint(p.Value),
+		}
+	}
+	return shapes
+}
+
+// PlanFingerprints plans each named query and returns its fingerprint by
+// name, e.g. to record or check golden fingerprints for a curated query set.
+func (e *FederatedExecutor) PlanFingerprints(ctx context.Context, queries map[string]string) (map[string]string, error) {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fingerprints := make(map[string]string, len(queries))
+	for _, name := range names {
+		plan, err := e.Plan(ctx, queries[name])
+		if err != nil {
+			return nil, fmt.Errorf("planning %s failed: %w", name, err)
+		}
+		fingerprints[name] = PlanFingerprint(plan)
+	}
+	return fingerprints, nil
+}
+
+// PlanDrift describes a query whose plan fingerprint differs from its golden
+// fingerprint. Golden is empty for a query without a golden fingerprint, and
+// Current is empty for a golden query that was not planned.
+type PlanDrift struct {
+	Name    string
+	Golden  string
+	Current string
+}
+
+// ComparePlanFingerprints compares current fingerprints against golden ones
+// and returns the drifted queries in name order.
+func ComparePlanFingerprints(golden, current map[string]string) []PlanDrift {
+	names := make(map[string]bool, len(golden)+len(current))
+	for name := range golden {
+		names[name] = true
+	}
+	for name := range current {
+		names[name] = true
+	}
+
+	var drift []PlanDrift
+	for name := range names {
+		if golden[name] != current[name] {
+			drift = append(drift, PlanDrift{Name: name, Golden: golden[name], Current: current[name]})
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool {
+		return drift[i].Name < drift[j].Name
+	})
+	return drift
+}
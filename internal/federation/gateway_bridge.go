@@ -74,13 +74,20 @@ type QueryResultStream struct {
 }
 
 // NewQueryResultStream creates a ResultStream from a QueryResult.
+// Repeated column names are suffixed (x, x_1) so that no value is lost
+// when rows are keyed by column name.
 func NewQueryResultStream(result *adapters.QueryResult) *QueryResultStream {
-	// Build schema from columns
-	columns := make([]ColumnDef, len(result.Columns))
-	for i, col := range result.Columns {
+	// Build schema from columns, with the engine's types when reported
+	names := sql.DisambiguateColumns(result.Columns)
+	columns := make([]ColumnDef, len(names))
+	for i, col := range names {
+		colType := "unknown"
+		if i < len(result.ColumnTypes) && result.ColumnTypes[i] != "" {
+			colType = result.ColumnTypes[i]
+		}
 		columns[i] = ColumnDef{
 			Name: col,
-			Type: "unknown", // Gateway doesn't provide type info
+			Type: colType,
 		}
 	}
 
@@ -105,9 +112,9 @@ func (s *QueryResultStream) Next(ctx context.Context) (Row, error) {
 	// Convert []interface{} to Row (map)
 	rowData := s.result.Rows[s.idx]
 	row := make(Row)
-	for i, col := range s.result.Columns {
+	for i, col := range s.schema.Columns {
 		if i < len(rowData) {
-			row[col] = rowData[i]
+			row[col.Name] = rowData[i]
 		}
 	}
 
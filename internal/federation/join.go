@@ -16,22 +16,46 @@ type HashJoinConfig struct {
 	// ProbeSide is the larger input (streamed through).
 	ProbeSide ResultStream
 
-	// BuildKey is the join key column on the build side.
-	BuildKey string
-
-	// ProbeKey is the join key column on the probe side.
-	ProbeKey string
+	// BuildKeys and ProbeKeys are the join key columns of each side,
+	// paired by position: rows match when every pair is equal.
+	BuildKeys []string
+	ProbeKeys []string
 
 	// Type is the join type.
 	Type JoinType
 
-	// AllowSpill enables spilling to disk for large tables.
+	// AllowSpill enables spilling to disk for large tables. Once the build
+	// side exceeds SpillThreshold rows, both sides are partitioned by key
+	// into temporary files and joined one partition at a time (a grace hash
+	// join). A partition that exceeds Budget still fails with
+	// ErrMemoryBudgetExceeded.
 	AllowSpill bool
 
-	// SpillThreshold is the row count threshold before spilling.
+	// SpillThreshold is the number of build rows held in memory before the
+	// join spills. Zero selects DefaultHashJoinSpillThreshold.
 	SpillThreshold int
+
+	// SpillDir is the directory of the spill files. Empty uses the system
+	// temp directory.
+	SpillDir string
+
+	// Budget is the query's memory budget, charged for every row in the
+	// hash table. Nil is unlimited.
+	Budget *MemoryBudget
+
+	// KeyCoercion is the policy for keys whose types differ between the
+	// sides. Empty selects JoinKeyCoercionNumeric.
+	KeyCoercion JoinKeyCoercion
 }
 
+// DefaultHashJoinSpillThreshold is the number of build rows a hash join
+// holds in memory before it spills, when spilling is allowed.
+const DefaultHashJoinSpillThreshold = 1000000
+
+// hashJoinSpillPartitions is the number of partitions a spilled hash join
+// splits each side into.
+const hashJoinSpillPartitions = 16
+
 // HashJoinExecutor executes hash join operations.
 type HashJoinExecutor struct {
 	config HashJoinConfig
@@ -39,9 +63,6 @@ type HashJoinExecutor struct {
 
 // NewHashJoinExecutor creates a new hash join executor.
 func NewHashJoinExecutor(config HashJoinConfig) *HashJoinExecutor {
-	if config.SpillThreshold == 0 {
-		config.SpillThreshold = 100000 // Default 100K rows
-	}
 	return &HashJoinExecutor{config: config}
 }
 
@@ -56,50 +77,127 @@ func (e *HashJoinExecutor) Execute(ctx context.Context) (ResultStream, error) {
 		return nil, fmt.Errorf("hash join: probe side is nil")
 	}
 
+	// Plan key coercion from the declared key types before hashing
+	buildSchema := e.config.BuildSide.Schema()
+	keys, err := newJoinKeys(e.config.KeyCoercion, e.config.BuildKeys, buildSchema, e.config.ProbeKeys, e.config.ProbeSide.Schema())
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := e.config.SpillThreshold
+	if threshold <= 0 {
+		threshold = DefaultHashJoinSpillThreshold
+	}
+
 	// Phase 1: Build hash table from build side
 	hashTable := make(map[interface{}][]Row)
-	buildSchema := e.config.BuildSide.Schema()
+	var rows int
+	var reserved int64
+	var spill *hashJoinSpill
 
-	rowCount := 0
 	for {
 		row, err := e.config.BuildSide.Next(ctx)
 		if err != nil {
+			spill.remove()
 			return nil, fmt.Errorf("hash join build phase failed: %w", err)
 		}
 		if row == nil {
 			break
 		}
 
-		key := row[e.config.BuildKey]
+		key := keys.buildKey(row)
+		if spill != nil {
+			if err := spill.build[spill.partition(key)].write(row); err != nil {
+				spill.remove()
+				return nil, err
+			}
+			continue
+		}
+
+		size := estimateRowBytes(row)
+		if err := e.config.Budget.Reserve(OperatorHashJoin, size); err != nil {
+			return nil, err
+		}
+		reserved += size
 		hashTable[key] = append(hashTable[key], row)
-		rowCount++
+		rows++
 
-		// Check if we should spill (future enhancement)
-		if e.config.AllowSpill && rowCount > e.config.SpillThreshold {
-			// TODO: Implement spill to disk for large datasets
-			// For now, continue in memory with warning
+		// Past the threshold, move the hash table to disk
+		if e.config.AllowSpill && rows > threshold {
+			if spill, err = newHashJoinSpill(e.config.SpillDir); err != nil {
+				return nil, err
+			}
+			if err := spill.partitionTable(hashTable); err != nil {
+				spill.remove()
+				return nil, err
+			}
+			hashTable = nil
+			e.config.Budget.Release(reserved)
+			reserved = 0
+		}
+	}
+
+	// The build side is fully drained; release it (and any spill file)
+	if err := e.config.BuildSide.Close(); err != nil {
+		spill.remove()
+		return nil, fmt.Errorf("hash join build phase failed: %w", err)
+	}
+
+	if spill != nil {
+		// Phase 2: Partition the probe side the same way
+		if err := e.partitionProbeSide(ctx, spill, keys); err != nil {
+			spill.remove()
+			return nil, err
 		}
+		return &spilledHashJoinStream{
+			spill:       spill,
+			keys:        keys,
+			joinType:    e.config.Type,
+			budget:      e.config.Budget,
+			buildSchema: buildSchema,
+			probeSchema: e.config.ProbeSide.Schema(),
+			columns:     newJoinColumns(e.config.ProbeSide.Schema(), buildSchema),
+		}, nil
 	}
 
 	// Phase 2: Create probe stream
 	return &hashJoinStream{
-		hashTable:   hashTable,
-		probeSide:   e.config.ProbeSide,
-		probeKey:    e.config.ProbeKey,
-		joinType:    e.config.Type,
-		buildSchema: buildSchema,
-		probeSchema: e.config.ProbeSide.Schema(),
+		hashTable: hashTable,
+		probeSide: e.config.ProbeSide,
+		keys:      keys,
+		joinType:  e.config.Type,
+		columns:   newJoinColumns(e.config.ProbeSide.Schema(), buildSchema),
+		budget:    e.config.Budget,
+		reserved:  reserved,
 	}, nil
 }
 
+// partitionProbeSide drains the probe side into the spill's probe
+// partitions and closes it.
+func (e *HashJoinExecutor) partitionProbeSide(ctx context.Context, spill *hashJoinSpill, keys *joinKeys) error {
+	defer e.config.ProbeSide.Close()
+	for {
+		row, err := e.config.ProbeSide.Next(ctx)
+		if err != nil {
+			return fmt.Errorf("hash join probe phase failed: %w", err)
+		}
+		if row == nil {
+			return nil
+		}
+		key := keys.probeKey(row)
+		if err := spill.probe[spill.partition(key)].write(row); err != nil {
+			return err
+		}
+	}
+}
+
 // hashJoinStream implements ResultStream for hash join results.
 type hashJoinStream struct {
-	hashTable   map[interface{}][]Row
-	probeSide   ResultStream
-	probeKey    string
-	joinType    JoinType
-	buildSchema *ResultSchema
-	probeSchema *ResultSchema
+	hashTable map[interface{}][]Row
+	probeSide ResultStream
+	keys      *joinKeys
+	joinType  JoinType
+	columns   *joinColumns // probe columns, then build columns
 
 	// Current state
 	currentProbeRow Row
@@ -109,22 +207,17 @@ type hashJoinStream struct {
 	// For RIGHT/FULL OUTER joins: track matched build rows
 	matchedBuildKeys map[interface{}]bool
 
+	// budget is released of the hash table's reservation on Close
+	budget   *MemoryBudget
+	reserved int64
+
 	mu     sync.Mutex
 	closed bool
 }
 
 // Schema returns the merged schema.
 func (s *hashJoinStream) Schema() *ResultSchema {
-	if s.probeSchema == nil || s.buildSchema == nil {
-		return nil
-	}
-
-	// Merge schemas
-	columns := make([]ColumnDef, 0)
-	columns = append(columns, s.probeSchema.Columns...)
-	columns = append(columns, s.buildSchema.Columns...)
-
-	return &ResultSchema{Columns: columns}
+	return s.columns.Schema()
 }
 
 // Next returns the next joined row.
@@ -146,7 +239,7 @@ func (s *hashJoinStream) Next(ctx context.Context) (Row, error) {
 	for {
 		// If we have pending matches, emit them
 		if s.matchIdx < len(s.matches) {
-			result := s.mergeRows(s.currentProbeRow, s.matches[s.matchIdx])
+			result := s.columns.merge(s.currentProbeRow, s.matches[s.matchIdx])
 			s.matchIdx++
 			return result, nil
 		}
@@ -165,15 +258,18 @@ func (s *hashJoinStream) Next(ctx context.Context) (Row, error) {
 			return nil, nil
 		}
 
-		// Look up in hash table
-		key := probeRow[s.probeKey]
-		matches := s.hashTable[key]
+		// Look up in hash table; a NULL key matches nothing
+		key := s.keys.probeKey(probeRow)
+		var matches []Row
+		if key != nil {
+			matches = s.hashTable[key]
+		}
 
 		if len(matches) == 0 {
 			// No matches
 			if s.joinType == JoinTypeLeft || s.joinType == JoinTypeFull {
 				// LEFT/FULL: emit probe row with nulls for build side
-				return s.mergeRowsWithNulls(probeRow, nil, true), nil
+				return s.columns.merge(probeRow, nil), nil
 			}
 			// INNER: skip non-matching rows
 			continue
@@ -193,47 +289,6 @@ func (s *hashJoinStream) Next(ctx context.Context) (Row, error) {
 	}
 }
 
-// mergeRows combines probe and build rows.
-func (s *hashJoinStream) mergeRows(probe, build Row) Row {
-	result := make(Row)
-	for k, v := range probe {
-		result[k] = v
-	}
-	for k, v := range build {
-		result[k] = v
-	}
-	return result
-}
-
-// mergeRowsWithNulls handles NULL padding for outer joins.
-func (s *hashJoinStream) mergeRowsWithNulls(probe, build Row, probeIsLeft bool) Row {
-	result := make(Row)
-
-	if probe != nil {
-		for k, v := range probe {
-			result[k] = v
-		}
-	} else if s.probeSchema != nil {
-		// Add nulls for probe columns
-		for _, col := range s.probeSchema.Columns {
-			result[col.Name] = nil
-		}
-	}
-
-	if build != nil {
-		for k, v := range build {
-			result[k] = v
-		}
-	} else if s.buildSchema != nil {
-		// Add nulls for build columns
-		for _, col := range s.buildSchema.Columns {
-			result[col.Name] = nil
-		}
-	}
-
-	return result
-}
-
 // emitUnmatchedBuildRow emits unmatched build rows for FULL OUTER join.
 func (s *hashJoinStream) emitUnmatchedBuildRow() (Row, error) {
 	for key, rows := range s.hashTable {
@@ -247,7 +302,7 @@ func (s *hashJoinStream) emitUnmatchedBuildRow() (Row, error) {
 			if len(s.hashTable[key]) == 0 {
 				delete(s.hashTable, key)
 			}
-			return s.mergeRowsWithNulls(nil, row, false), nil
+			return s.columns.merge(nil, row), nil
 		}
 	}
 	return nil, nil
@@ -261,6 +316,8 @@ func (s *hashJoinStream) Close() error {
 	s.closed = true
 	s.hashTable = nil
 	s.matches = nil
+	s.budget.Release(s.reserved)
+	s.reserved = 0
 
 	if s.probeSide != nil {
 		return s.probeSide.Close()
@@ -289,16 +346,20 @@ func NewJoinStrategySelector(memoryLimit int64) *JoinStrategySelector {
 
 // JoinConfig configures a join operation.
 type JoinConfig struct {
-	BuildSide   ResultStream
-	ProbeSide   ResultStream
-	BuildKey    string
-	ProbeKey    string
-	Type        JoinType
-	AllowSpill  bool
-	LeftStream  ResultStream // For merge join
-	RightStream ResultStream
-	LeftKey     string
-	RightKey    string
+	BuildSide      ResultStream
+	ProbeSide      ResultStream
+	BuildKeys      []string // Key columns of each side, paired by position
+	ProbeKeys      []string
+	Type           JoinType
+	AllowSpill     bool
+	SpillThreshold int           // Build rows held in memory before spilling; zero is the default
+	SpillDir       string        // Spill file directory; empty is the system temp directory
+	Budget         *MemoryBudget // Query memory budget; nil is unlimited
+	LeftStream     ResultStream  // For merge join
+	RightStream    ResultStream
+	LeftKeys       []string
+	RightKeys      []string
+	KeyCoercion    JoinKeyCoercion // Empty selects JoinKeyCoercionNumeric
 }
 
 // SelectStrategy chooses the optimal join strategy.
@@ -309,53 +370,51 @@ func (s *JoinStrategySelector) SelectStrategy(
 ) (JoinStrategy, *JoinConfig) {
 	leftRows := leftStream.EstimatedRows()
 	rightRows := rightStream.EstimatedRows()
-
-	// Rule 1: If one side is small, use hash join with small side as build
-	const smallTableThreshold int64 = 100000
-
-	if leftRows >= 0 && leftRows < smallTableThreshold {
-		return JoinStrategyHash, &JoinConfig{
+	hashConfig := func(buildLeft, allowSpill bool) *JoinConfig {
+		config := &JoinConfig{
 			BuildSide:  leftStream,
 			ProbeSide:  rightStream,
-			BuildKey:   join.LeftCol,
-			ProbeKey:   join.RightCol,
+			BuildKeys:  join.LeftCols,
+			ProbeKeys:  join.RightCols,
 			Type:       join.Type,
-			AllowSpill: false,
+			AllowSpill: allowSpill,
+		}
+		if !buildLeft {
+			config.BuildSide, config.ProbeSide = rightStream, leftStream
+			config.BuildKeys, config.ProbeKeys = config.ProbeKeys, config.BuildKeys
 		}
+		return config
 	}
 
-	if rightRows >= 0 && rightRows < smallTableThreshold {
-		return JoinStrategyHash, &JoinConfig{
-			BuildSide:  rightStream,
-			ProbeSide:  leftStream,
-			BuildKey:   join.RightCol,
-			ProbeKey:   join.LeftCol,
-			Type:       join.Type,
-			AllowSpill: false,
+	// Rule 1: If one side is small, use hash join with small side as build
+	if leftRows >= 0 && leftRows < mergeJoinThreshold {
+		return JoinStrategyHash, hashConfig(true, false)
+	}
+
+	if rightRows >= 0 && rightRows < mergeJoinThreshold {
+		return JoinStrategyHash, hashConfig(false, false)
+	}
+
+	// Rule 2: Two large inputs already sorted on their keys are merged,
+	// which streams both instead of holding either in memory. Compound
+	// keys are always hashed.
+	if len(join.LeftCols) == 1 && sortedOnKey(leftStream, join.LeftCols[0]) && sortedOnKey(rightStream, join.RightCols[0]) {
+		return JoinStrategyMerge, &JoinConfig{
+			LeftStream:  leftStream,
+			RightStream: rightStream,
+			LeftKeys:    join.LeftCols,
+			RightKeys:   join.RightCols,
+			Type:        join.Type,
 		}
 	}
 
-	// Rule 2: Default to hash join with spill enabled
+	// Rule 3: Default to hash join with spill enabled
 	// Pick smaller estimated side as build
 	if leftRows < rightRows || rightRows < 0 {
-		return JoinStrategyHash, &JoinConfig{
-			BuildSide:  leftStream,
-			ProbeSide:  rightStream,
-			BuildKey:   join.LeftCol,
-			ProbeKey:   join.RightCol,
-			Type:       join.Type,
-			AllowSpill: true,
-		}
+		return JoinStrategyHash, hashConfig(true, true)
 	}
 
-	return JoinStrategyHash, &JoinConfig{
-		BuildSide:  rightStream,
-		ProbeSide:  leftStream,
-		BuildKey:   join.RightCol,
-		ProbeKey:   join.LeftCol,
-		Type:       join.Type,
-		AllowSpill: true,
-	}
+	return JoinStrategyHash, hashConfig(false, true)
 }
 
 // ExecuteJoin executes a join based on the selected strategy.
@@ -365,14 +424,19 @@ func ExecuteJoin(
 	config *JoinConfig,
 ) (ResultStream, error) {
 	switch strategy {
-	case JoinStrategyHash:
+	case JoinStrategyHash, JoinStrategyBroadcast:
+		// A broadcast join's input was filtered when its sub-query ran
 		executor := NewHashJoinExecutor(HashJoinConfig{
-			BuildSide:  config.BuildSide,
-			ProbeSide:  config.ProbeSide,
-			BuildKey:   config.BuildKey,
-			ProbeKey:   config.ProbeKey,
-			Type:       config.Type,
-			AllowSpill: config.AllowSpill,
+			BuildSide:      config.BuildSide,
+			ProbeSide:      config.ProbeSide,
+			BuildKeys:      config.BuildKeys,
+			ProbeKeys:      config.ProbeKeys,
+			Type:           config.Type,
+			AllowSpill:     config.AllowSpill,
+			SpillThreshold: config.SpillThreshold,
+			SpillDir:       config.SpillDir,
+			Budget:         config.Budget,
+			KeyCoercion:    config.KeyCoercion,
 		})
 		return executor.Execute(ctx)
 
@@ -381,8 +445,21 @@ func ExecuteJoin(
 		return executeNestedLoopJoin(ctx, config)
 
 	case JoinStrategyMerge:
-		// Merge join for sorted inputs
-		return nil, fmt.Errorf("merge join not yet implemented")
+		// Merge join for sorted inputs; the build and probe sides stand in
+		// for the left and right inputs when those are not set
+		mergeConfig := MergeJoinConfig{
+			Left:      config.LeftStream,
+			Right:     config.RightStream,
+			LeftKeys:  config.LeftKeys,
+			RightKeys: config.RightKeys,
+			Type:      config.Type,
+			Budget:    config.Budget,
+		}
+		if mergeConfig.Left == nil && mergeConfig.Right == nil {
+			mergeConfig.Left, mergeConfig.LeftKeys = config.BuildSide, config.BuildKeys
+			mergeConfig.Right, mergeConfig.RightKeys = config.ProbeSide, config.ProbeKeys
+		}
+		return NewMergeJoinExecutor(mergeConfig).Execute(ctx)
 
 	default:
 		return nil, fmt.Errorf("unknown join strategy: %s", strategy)
@@ -392,7 +469,7 @@ func ExecuteJoin(
 // executeNestedLoopJoin performs a nested loop join.
 func executeNestedLoopJoin(ctx context.Context, config *JoinConfig) (ResultStream, error) {
 	// Collect left side (should be smaller for efficiency)
-	leftRows, err := CollectStream(ctx, config.BuildSide)
+	leftRows, err := collectStreamWithBudget(ctx, config.BuildSide, config.Budget, OperatorNestedLoop)
 	if err != nil {
 		return nil, fmt.Errorf("nested loop join: %w", err)
 	}
@@ -401,8 +478,7 @@ func executeNestedLoopJoin(ctx context.Context, config *JoinConfig) (ResultStrea
 		leftRows:    leftRows,
 		rightStream: config.ProbeSide,
 		joinType:    config.Type,
-		leftSchema:  config.BuildSide.Schema(),
-		rightSchema: config.ProbeSide.Schema(),
+		columns:     newJoinColumns(config.BuildSide.Schema(), config.ProbeSide.Schema()),
 	}, nil
 }
 
@@ -411,8 +487,7 @@ type nestedLoopJoinStream struct {
 	leftRows    []Row
 	rightStream ResultStream
 	joinType    JoinType
-	leftSchema  *ResultSchema
-	rightSchema *ResultSchema
+	columns     *joinColumns // left columns, then right columns
 
 	currentRightRow Row
 	leftIdx         int
@@ -423,13 +498,7 @@ type nestedLoopJoinStream struct {
 
 // Schema returns the merged schema.
 func (s *nestedLoopJoinStream) Schema() *ResultSchema {
-	if s.leftSchema == nil || s.rightSchema == nil {
-		return nil
-	}
-	columns := make([]ColumnDef, 0)
-	columns = append(columns, s.leftSchema.Columns...)
-	columns = append(columns, s.rightSchema.Columns...)
-	return &ResultSchema{Columns: columns}
+	return s.columns.Schema()
 }
 
 // Next returns the next joined row.
@@ -440,7 +509,7 @@ func (s *nestedLoopJoinStream) Next(ctx context.Context) (Row, error) {
 	for {
 		// If we have a current right row and more left rows to pair
 		if s.currentRightRow != nil && s.leftIdx < len(s.leftRows) {
-			result := s.mergeRows(s.leftRows[s.leftIdx], s.currentRightRow)
+			result := s.columns.merge(s.leftRows[s.leftIdx], s.currentRightRow)
 			s.leftIdx++
 			return result, nil
 		}
@@ -464,17 +533,6 @@ func (s *nestedLoopJoinStream) Next(ctx context.Context) (Row, error) {
 	}
 }
 
-func (s *nestedLoopJoinStream) mergeRows(left, right Row) Row {
-	result := make(Row)
-	for k, v := range left {
-		result[k] = v
-	}
-	for k, v := range right {
-		result[k] = v
-	}
-	return result
-}
-
 // Close releases resources.
 func (s *nestedLoopJoinStream) Close() error {
 	s.mu.Lock()
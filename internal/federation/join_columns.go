@@ -0,0 +1,151 @@
+package federation
+
+import (
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// joinColumns names the columns of a join's output rows. Rows are keyed by
+// column name, so a column present on both inputs (orders.id and
+// customers.id) would otherwise overwrite the other. Such columns are
+// qualified with the table their ColumnDef names (o.id, c.id), which ORDER
+// BY and later join steps resolve like any qualified reference; columns
+// found on one side only keep their bare names. Colliding columns of
+// unknown tables are suffixed instead (id, id_1).
+type joinColumns struct {
+	firstSchema  *ResultSchema
+	secondSchema *ResultSchema
+
+	// first and second map the renamed columns of each input to their
+	// output names.
+	first  map[string]string
+	second map[string]string
+
+	schema *ResultSchema
+}
+
+// newJoinColumns names the output columns of a join whose rows list the
+// columns of first, then those of second.
+func newJoinColumns(first, second *ResultSchema) *joinColumns {
+	c := &joinColumns{
+		firstSchema:  first,
+		secondSchema: second,
+		first:        make(map[string]string),
+		second:       make(map[string]string),
+	}
+	if first == nil || second == nil {
+		return c
+	}
+
+	inFirst := make(map[string]bool, len(first.Columns))
+	for _, col := range first.Columns {
+		inFirst[col.Name] = true
+	}
+	shared := make(map[string]bool)
+	for _, col := range second.Columns {
+		if inFirst[col.Name] {
+			shared[col.Name] = true
+		}
+	}
+
+	columns := make([]ColumnDef, 0, len(first.Columns)+len(second.Columns))
+	columns = append(columns, first.Columns...)
+	columns = append(columns, second.Columns...)
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+		if shared[col.Name] && col.Table != "" {
+			names[i] = col.Table + "." + col.Name
+		}
+	}
+	names = sql.DisambiguateColumns(names)
+
+	for i, name := range names {
+		renamed := c.first
+		if i >= len(first.Columns) {
+			renamed = c.second
+		}
+		if name != columns[i].Name {
+			renamed[columns[i].Name] = name
+			columns[i].Name = name
+		}
+	}
+	c.schema = &ResultSchema{Columns: columns}
+	return c
+}
+
+// Schema returns the output schema, or nil if either input has none.
+func (c *joinColumns) Schema() *ResultSchema {
+	return c.schema
+}
+
+// merge combines a row of each input into an output row. A nil row is
+// padded with NULLs for its input's columns.
+func (c *joinColumns) merge(first, second Row) Row {
+	result := make(Row)
+	c.add(result, first, c.firstSchema, c.first)
+	c.add(result, second, c.secondSchema, c.second)
+	return result
+}
+
+func (c *joinColumns) add(result, row Row, schema *ResultSchema, renamed map[string]string) {
+	if row == nil {
+		if schema != nil {
+			for _, col := range schema.Columns {
+				result[c.outputName(col.Name, renamed)] = nil
+			}
+		}
+		return
+	}
+	for k, v := range row {
+		result[c.outputName(k, renamed)] = v
+	}
+}
+
+func (c *joinColumns) outputName(col string, renamed map[string]string) string {
+	if name, ok := renamed[col]; ok {
+		return name
+	}
+	return col
+}
+
+// qualifiedColumn returns a column reference qualified with table, or the
+// bare column when table is empty.
+func qualifiedColumn(table, col string) string {
+	if table == "" {
+		return col
+	}
+	return table + "." + col
+}
+
+// tableStream labels the columns of a single-table sub-query's result with
+// the table's alias or name, for joins to qualify colliding columns with.
+type tableStream struct {
+	ResultStream
+	schema *ResultSchema
+}
+
+// withTable returns stream with its schema's columns labeled with table.
+// Columns already labeled keep their table.
+func withTable(stream ResultStream, table string) ResultStream {
+	schema := stream.Schema()
+	if schema == nil || table == "" {
+		return stream
+	}
+	columns := make([]ColumnDef, len(schema.Columns))
+	for i, col := range schema.Columns {
+		if col.Table == "" {
+			col.Table = table
+		}
+		columns[i] = col
+	}
+	labeled := &tableStream{ResultStream: stream, schema: &ResultSchema{Columns: columns}}
+	if ordered, ok := stream.(OrderedStream); ok {
+		return NewOrderedStream(labeled, ordered.SortOrder())
+	}
+	return labeled
+}
+
+// Schema returns the labeled schema.
+func (s *tableStream) Schema() *ResultSchema {
+	return s.schema
+}
@@ -0,0 +1,297 @@
+package federation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/observability"
+)
+
+// JoinKeyCoercion controls how hash joins compare keys whose types differ
+// across engines, e.g. Trino BIGINT against Spark INT. Keys are hashed by
+// value, so without coercion int32(1) and int64(1) never match.
+type JoinKeyCoercion string
+
+const (
+	// JoinKeyCoercionNone compares keys exactly as the engines return them.
+	JoinKeyCoercionNone JoinKeyCoercion = "none"
+
+	// JoinKeyCoercionNumeric compares integer and floating-point keys by
+	// numeric value. Joins between string and numeric keys are rejected.
+	JoinKeyCoercionNumeric JoinKeyCoercion = "numeric"
+
+	// JoinKeyCoercionStringNumber additionally parses string keys joined to
+	// integer keys as integers.
+	JoinKeyCoercionStringNumber JoinKeyCoercion = "string_number"
+)
+
+// ParseJoinKeyCoercion parses a coercion policy name case-insensitively.
+// An empty value selects JoinKeyCoercionNumeric.
+func ParseJoinKeyCoercion(s string) (JoinKeyCoercion, error) {
+	switch policy := JoinKeyCoercion(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return JoinKeyCoercionNumeric, nil
+	case JoinKeyCoercionNone, JoinKeyCoercionNumeric, JoinKeyCoercionStringNumber:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid join key coercion %q (valid: %s, %s, %s)", s, JoinKeyCoercionNone, JoinKeyCoercionNumeric, JoinKeyCoercionStringNumber)
+	}
+}
+
+// keyClass groups engine column types by how their values compare.
+type keyClass int
+
+const (
+	keyUnknown keyClass = iota
+	keyInteger
+	keyFloat
+	keyDecimal
+	keyString
+	keyOther
+)
+
+// classifyKeyType classifies an engine type name such as "BIGINT" or
+// "decimal(10,2)". Empty types are keyUnknown.
+func classifyKeyType(typ string) keyClass {
+	name := strings.ToUpper(strings.TrimSpace(typ))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	switch name {
+	case "":
+		return keyUnknown
+	case "TINYINT", "SMALLINT", "INT", "INTEGER", "BIGINT", "LONG", "SHORT", "BYTE", "INT2", "INT4", "INT8":
+		return keyInteger
+	case "REAL", "FLOAT", "DOUBLE", "DOUBLE PRECISION", "FLOAT4", "FLOAT8":
+		return keyFloat
+	case "DECIMAL", "NUMERIC":
+		return keyDecimal
+	case "VARCHAR", "CHAR", "STRING", "TEXT":
+		return keyString
+	default:
+		return keyOther
+	}
+}
+
+// columnType returns the declared type of a column, or "" if unknown.
+func columnType(schema *ResultSchema, column string) string {
+	if schema == nil {
+		return ""
+	}
+	for _, col := range schema.Columns {
+		if col.Name == column || qualifiedColumn(col.Table, col.Name) == column {
+			return col.Type
+		}
+	}
+	// A qualified reference resolves to a column kept under its bare name
+	for _, col := range schema.Columns {
+		if col.Name == columnName(column) {
+			return col.Type
+		}
+	}
+	return ""
+}
+
+// joinKeys computes the hash keys of join rows from their key columns,
+// paired by position between the build and probe sides. Each pair is
+// coerced on its own; a compound key combines the coerced values of every
+// pair, and is NULL when any of them is.
+type joinKeys struct {
+	build    []string
+	probe    []string
+	coercers []*joinKeyCoercer
+}
+
+// newJoinKeys plans the coercion of each key pair (see newJoinKeyCoercer).
+func newJoinKeys(policy JoinKeyCoercion, build []string, buildSchema *ResultSchema, probe []string, probeSchema *ResultSchema) (*joinKeys, error) {
+	if len(build) == 0 || len(build) != len(probe) {
+		return nil, fmt.Errorf("hash join: %d build keys do not pair with %d probe keys", len(build), len(probe))
+	}
+	keys := &joinKeys{build: build, probe: probe}
+	for i := range build {
+		coercer, err := newJoinKeyCoercer(policy, build[i], buildSchema, probe[i], probeSchema)
+		if err != nil {
+			return nil, err
+		}
+		keys.coercers = append(keys.coercers, coercer)
+	}
+	return keys, nil
+}
+
+// buildKey returns the hash key of a build row.
+func (k *joinKeys) buildKey(row Row) interface{} {
+	return k.key(row, k.build, (*joinKeyCoercer).buildKey)
+}
+
+// probeKey returns the hash key of a probe row.
+func (k *joinKeys) probeKey(row Row) interface{} {
+	return k.key(row, k.probe, (*joinKeyCoercer).probeKey)
+}
+
+func (k *joinKeys) key(row Row, columns []string, coerce func(*joinKeyCoercer, interface{}) interface{}) interface{} {
+	if len(columns) == 1 {
+		return coerce(k.coercers[0], rowValue(row, columns[0]))
+	}
+	var b strings.Builder
+	for i, col := range columns {
+		v := coerce(k.coercers[i], rowValue(row, col))
+		if v == nil {
+			return nil
+		}
+		fmt.Fprintf(&b, "%T:%#v;", v, v)
+	}
+	return compositeKey(b.String())
+}
+
+// compositeKey is the hash key of a compound join key: the tuple of its
+// coerced values, each formatted with its type. Coerced values that are
+// equal have the same type, so equal tuples, and only those, have equal
+// keys. Formatting rather than storing the values lets values that are not
+// comparable, such as byte slices, be hashed.
+type compositeKey string
+
+// joinKeyCoercer converts the key values of both join sides to a common
+// representation before hashing. A nil coercer leaves keys unchanged.
+type joinKeyCoercer struct {
+	// parseBuild and parseProbe parse string keys on that side as integers.
+	parseBuild bool
+	parseProbe bool
+}
+
+// newJoinKeyCoercer plans the coercion of a join's keys from their declared
+// types. It rejects joins whose keys can only be compared through a lossy
+// conversion, or a conversion the policy does not allow, and logs the
+// coercion applied to keys of differing types.
+func newJoinKeyCoercer(policy JoinKeyCoercion, buildKey string, buildSchema *ResultSchema, probeKey string, probeSchema *ResultSchema) (*joinKeyCoercer, error) {
+	if policy == "" {
+		policy = JoinKeyCoercionNumeric
+	}
+	if policy == JoinKeyCoercionNone {
+		return nil, nil
+	}
+
+	buildType := columnType(buildSchema, buildKey)
+	probeType := columnType(probeSchema, probeKey)
+	buildClass := classifyKeyType(buildType)
+	probeClass := classifyKeyType(probeType)
+
+	reject := func(reason, suggestion string) error {
+		return cerrors.NewJoinKeyCoercion(buildKey, buildType, probeKey, probeType, reason, suggestion)
+	}
+	castSuggestion := "CAST one join key to the other's type in the query"
+
+	coercer := &joinKeyCoercer{}
+	target := "numeric values"
+	switch {
+	case buildClass == keyUnknown || probeClass == keyUnknown || buildClass == probeClass:
+		// Nothing declared to reconcile; numeric values are still compared
+		// by value, since drivers return integers of differing widths.
+	case lossyKeyConversion(buildClass, probeClass):
+		return nil, reject("comparing these key types requires a lossy conversion", castSuggestion)
+	case (buildClass == keyString && probeClass == keyInteger) || (buildClass == keyInteger && probeClass == keyString):
+		if policy != JoinKeyCoercionStringNumber {
+			return nil, reject(
+				"string and integer keys are not coerced under the numeric join key policy",
+				fmt.Sprintf("set federation.join_key_coercion to %s, or %s", JoinKeyCoercionStringNumber, castSuggestion))
+		}
+		coercer.parseBuild = buildClass == keyString
+		coercer.parseProbe = probeClass == keyString
+		target = "integers"
+	case buildClass == keyInteger && probeClass == keyFloat, buildClass == keyFloat && probeClass == keyInteger:
+		// Numeric widening
+	default:
+		// Types outside the coercion rules are compared as returned
+		return coercer, nil
+	}
+
+	if buildType != "" && probeType != "" && !strings.EqualFold(buildType, probeType) {
+		observability.Diagnosticf("federation: coercing join keys %s (%s) and %s (%s) to %s", buildKey, buildType, probeKey, probeType, target)
+	}
+	return coercer, nil
+}
+
+// lossyKeyConversion reports whether comparing keys of the two classes
+// requires a conversion that can change values: strings to floating-point or
+// decimal numbers, or decimals to binary floating-point or integers.
+func lossyKeyConversion(a, b keyClass) bool {
+	pair := func(x, y keyClass) bool { return (a == x && b == y) || (a == y && b == x) }
+	return pair(keyString, keyFloat) || pair(keyString, keyDecimal) ||
+		pair(keyDecimal, keyFloat) || pair(keyDecimal, keyInteger)
+}
+
+// buildKey returns the hash key of a build-side key value.
+func (c *joinKeyCoercer) buildKey(v interface{}) interface{} {
+	if c == nil {
+		return v
+	}
+	return coerceKey(v, c.parseBuild)
+}
+
+// probeKey returns the hash key of a probe-side key value.
+func (c *joinKeyCoercer) probeKey(v interface{}) interface{} {
+	if c == nil {
+		return v
+	}
+	return coerceKey(v, c.parseProbe)
+}
+
+// coerceKey normalizes numeric values: integers of every width become
+// int64, and floating-point values with an exact int64 representation
+// become that int64. With parseString, strings holding an integer become
+// that int64; other strings are unchanged and so match no integer.
+func coerceKey(v interface{}, parseString bool) interface{} {
+	switch x := v.(type) {
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case uint:
+		return coerceUint(uint64(x))
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case uint64:
+		return coerceUint(x)
+	case float32:
+		return coerceFloat(float64(x))
+	case float64:
+		return coerceFloat(x)
+	case string:
+		if parseString {
+			if n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64); err == nil {
+				return n
+			}
+		}
+		return x
+	default:
+		return v
+	}
+}
+
+// coerceUint returns u as an int64 if it fits.
+func coerceUint(u uint64) interface{} {
+	if u <= math.MaxInt64 {
+		return int64(u)
+	}
+	return u
+}
+
+// coerceFloat returns f as an int64 if it is integral and in range.
+func coerceFloat(f float64) interface{} {
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f)
+	}
+	return f
+}
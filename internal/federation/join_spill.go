@@ -0,0 +1,201 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+)
+
+// hashJoinSpill holds the partitions of a hash join that spilled to disk.
+// Rows are assigned to partitions by the hash of their coerced key, so rows
+// that can match always share a partition number on both sides.
+type hashJoinSpill struct {
+	dir   string
+	build []*spillFile
+	probe []*spillFile
+}
+
+// newHashJoinSpill creates a directory of empty partition files under
+// parent, or under the system temp directory when parent is empty.
+func newHashJoinSpill(parent string) (*hashJoinSpill, error) {
+	dir, err := os.MkdirTemp(parent, "canonic-hashjoin-*")
+	if err != nil {
+		return nil, fmt.Errorf("spill to disk failed: %w", err)
+	}
+	spill := &hashJoinSpill{dir: dir}
+	for i := 0; i < hashJoinSpillPartitions; i++ {
+		build, err := newSpillFile(dir)
+		if err != nil {
+			spill.remove()
+			return nil, err
+		}
+		spill.build = append(spill.build, build)
+
+		probe, err := newSpillFile(dir)
+		if err != nil {
+			spill.remove()
+			return nil, err
+		}
+		spill.probe = append(spill.probe, probe)
+	}
+	return spill, nil
+}
+
+// partition returns the partition of a coerced join key. Coerced keys that
+// are equal have the same dynamic type, so formatting the type with the
+// value hashes equal keys alike.
+func (s *hashJoinSpill) partition(key interface{}) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%T:%v", key, key)
+	return int(h.Sum32() % uint32(len(s.build)))
+}
+
+// partitionTable writes the rows of an in-memory hash table to the build
+// partitions.
+func (s *hashJoinSpill) partitionTable(table map[interface{}][]Row) error {
+	for key, rows := range table {
+		file := s.build[s.partition(key)]
+		for _, row := range rows {
+			if err := file.write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// remove deletes the partition files and their directory. It is a no-op on
+// a nil spill.
+func (s *hashJoinSpill) remove() error {
+	if s == nil {
+		return nil
+	}
+	for _, file := range append(s.build, s.probe...) {
+		file.file.Close()
+	}
+	s.build, s.probe = nil, nil
+	return os.RemoveAll(s.dir)
+}
+
+// spilledHashJoinStream joins the partitions of a spilled hash join one at
+// a time: each build partition is loaded into a hash table and its probe
+// partition streamed through it, so only one partition is held in memory.
+type spilledHashJoinStream struct {
+	spill       *hashJoinSpill
+	keys        *joinKeys
+	joinType    JoinType
+	budget      *MemoryBudget
+	buildSchema *ResultSchema
+	probeSchema *ResultSchema
+	columns     *joinColumns
+
+	partition int
+	current   *hashJoinStream
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Schema returns the merged schema.
+func (s *spilledHashJoinStream) Schema() *ResultSchema {
+	return s.columns.Schema()
+}
+
+// Next returns the next joined row.
+func (s *spilledHashJoinStream) Next(ctx context.Context) (Row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, nil
+	}
+
+	for {
+		if s.current != nil {
+			row, err := s.current.Next(ctx)
+			if err != nil || row != nil {
+				return row, err
+			}
+			if err := s.current.Close(); err != nil {
+				return nil, err
+			}
+			s.current = nil
+			s.partition++
+		}
+
+		if s.partition >= len(s.spill.build) {
+			return nil, nil
+		}
+		current, err := s.loadPartition(ctx, s.partition)
+		if err != nil {
+			return nil, err
+		}
+		s.current = current
+	}
+}
+
+// loadPartition builds the hash table of a build partition and returns the
+// stream probing it with the matching probe partition.
+func (s *spilledHashJoinStream) loadPartition(ctx context.Context, i int) (*hashJoinStream, error) {
+	build := s.spill.build[i].stream(s.buildSchema)
+	defer build.Close()
+
+	hashTable := make(map[interface{}][]Row)
+	var reserved int64
+	for {
+		row, err := build.Next(ctx)
+		if err != nil {
+			s.budget.Release(reserved)
+			return nil, fmt.Errorf("hash join build phase failed: %w", err)
+		}
+		if row == nil {
+			break
+		}
+		size := estimateRowBytes(row)
+		if err := s.budget.Reserve(OperatorHashJoin, size); err != nil {
+			s.budget.Release(reserved)
+			return nil, err
+		}
+		reserved += size
+		key := s.keys.buildKey(row)
+		hashTable[key] = append(hashTable[key], row)
+	}
+
+	return &hashJoinStream{
+		hashTable: hashTable,
+		probeSide: s.spill.probe[i].stream(s.probeSchema),
+		keys:      s.keys,
+		joinType:  s.joinType,
+		columns:   s.columns,
+		budget:    s.budget,
+		reserved:  reserved,
+	}, nil
+}
+
+// Close releases the current partition and deletes the spill files.
+func (s *spilledHashJoinStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var closeErr error
+	if s.current != nil {
+		closeErr = s.current.Close()
+		s.current = nil
+	}
+	if err := s.spill.remove(); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// EstimatedRows returns -1 (unknown for join results).
+func (s *spilledHashJoinStream) EstimatedRows() int64 {
+	return -1
+}
@@ -0,0 +1,174 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+)
+
+// DefaultMaxQueryMemory is the default memory budget of a single federated
+// query, in bytes.
+const DefaultMaxQueryMemory int64 = 512 << 20
+
+// MemoryLimitHeader is the request header that sets the memory budget for a
+// single query, e.g. "64MB". It can only lower the configured budget.
+const MemoryLimitHeader = "X-Canonic-Memory-Limit"
+
+// Operators that reserve memory from a query's budget, as reported in
+// ErrMemoryBudgetExceeded.
+const (
+	OperatorMaterialize = "materialization"
+	OperatorHashJoin    = "hash join"
+	OperatorNestedLoop  = "nested loop join"
+	OperatorMergeJoin   = "merge join"
+	OperatorAggregate   = "aggregation"
+	OperatorSort        = "sort"
+	OperatorDistinct    = "distinct"
+	OperatorSetOp       = "set operation"
+)
+
+// MemoryBudget is the memory available to one federated query. Operators
+// that buffer rows (materialized sub-query results, hash-join build tables,
+// nested-loop inputs, aggregation groups, sort buffers, the rows seen by
+// DISTINCT and the right operands of INTERSECT and EXCEPT) reserve the estimated size of each buffered row. A reservation
+// that would exceed the budget fails with ErrMemoryBudgetExceeded;
+// operators that can spill to disk do so instead.
+// A nil *MemoryBudget is unlimited; all methods accept nil.
+type MemoryBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// NewMemoryBudget creates a budget of limit bytes.
+// Non-positive limits select DefaultMaxQueryMemory.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	if limit <= 0 {
+		limit = DefaultMaxQueryMemory
+	}
+	return &MemoryBudget{limit: limit}
+}
+
+// Limit returns the budget in bytes, or 0 for an unlimited budget.
+func (b *MemoryBudget) Limit() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.limit
+}
+
+// Used returns the bytes currently reserved.
+func (b *MemoryBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.used.Load()
+}
+
+// Reserve reserves n bytes for operator. It returns ErrMemoryBudgetExceeded,
+// and reserves nothing, if the reservation would exceed the budget.
+func (b *MemoryBudget) Reserve(operator string, n int64) error {
+	if b == nil {
+		return nil
+	}
+	if b.used.Add(n) > b.limit {
+		b.used.Add(-n)
+		return cerrors.NewMemoryBudgetExceeded(operator, b.limit)
+	}
+	return nil
+}
+
+// Release returns n previously reserved bytes to the budget.
+func (b *MemoryBudget) Release(n int64) {
+	if b == nil {
+		return
+	}
+	b.used.Add(-n)
+}
+
+// Approximate in-memory overheads used by estimateRowBytes.
+const (
+	rowOverheadBytes   = 48 // map header and buckets
+	valueOverheadBytes = 16 // interface header per value
+	scalarValueBytes   = 8  // numeric, boolean and other fixed-size values
+)
+
+// estimateRowBytes approximates the memory held by a buffered row.
+func estimateRowBytes(row Row) int64 {
+	n := int64(rowOverheadBytes)
+	for col, value := range row {
+		n += int64(len(col)) + valueOverheadBytes
+		switch v := value.(type) {
+		case string:
+			n += int64(len(v))
+		case []byte:
+			n += int64(len(v))
+		default:
+			n += scalarValueBytes
+		}
+	}
+	return n
+}
+
+// memorySizeUnits maps size suffixes to multipliers. Units are binary, so
+// "1KB" is 1024 bytes.
+var memorySizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseMemorySize parses a memory size such as "512MB", "2G" or "1048576"
+// (bytes). An empty value returns 0, selecting the default.
+func ParseMemorySize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range memorySizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q (e.g. 512MB, 2GB)", s)
+	}
+	if n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("memory size %q is too large", s)
+	}
+	return n * multiplier, nil
+}
+
+// memoryLimitKey is the context key for the per-query memory limit.
+type memoryLimitKey struct{}
+
+// ContextWithMemoryLimit returns a context that runs federated queries with
+// a memory budget of at most limit bytes.
+func ContextWithMemoryLimit(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, memoryLimitKey{}, limit)
+}
+
+// MemoryLimitFromContext returns the memory limit attached to ctx, or 0 if
+// there is none.
+func MemoryLimitFromContext(ctx context.Context) int64 {
+	limit, _ := ctx.Value(memoryLimitKey{}).(int64)
+	return limit
+}
@@ -0,0 +1,410 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MergeJoinConfig configures a sort-merge join.
+type MergeJoinConfig struct {
+	// Left and Right are the join inputs. An input that does not declare
+	// ascending order on its key (see OrderedStream) is sorted first.
+	Left  ResultStream
+	Right ResultStream
+
+	// LeftKeys and RightKeys are the join key columns of each input. A
+	// merge join takes a single key column per side.
+	LeftKeys  []string
+	RightKeys []string
+
+	// Type is the join type: INNER, LEFT, RIGHT or FULL.
+	Type JoinType
+
+	// Budget is the query's memory budget, charged for inputs that must be
+	// sorted and for the right rows sharing one key. Nil is unlimited.
+	Budget *MemoryBudget
+}
+
+// MergeJoinExecutor executes sort-merge joins. Unlike a hash join, which
+// holds the whole build side in memory, a merge join over inputs already
+// sorted on their keys only holds the right rows of the current key, so it
+// suits joins between two large inputs.
+type MergeJoinExecutor struct {
+	config MergeJoinConfig
+}
+
+// NewMergeJoinExecutor creates a new merge join executor.
+func NewMergeJoinExecutor(config MergeJoinConfig) *MergeJoinExecutor {
+	return &MergeJoinExecutor{config: config}
+}
+
+// Execute returns the stream of joined rows, in key order, which the
+// stream declares (see OrderedStream) unless the join is FULL. Keys are
+// compared as ORDER BY compares values; NULL keys never match. Declared
+// sort orders are verified as rows arrive, and an input found out of order
+// fails the join rather than silently missing matches.
+func (e *MergeJoinExecutor) Execute(ctx context.Context) (ResultStream, error) {
+	if e.config.Left == nil {
+		return nil, fmt.Errorf("merge join: left side is nil")
+	}
+	if e.config.Right == nil {
+		return nil, fmt.Errorf("merge join: right side is nil")
+	}
+	switch e.config.Type {
+	case JoinTypeInner, JoinTypeLeft, JoinTypeRight, JoinTypeFull:
+	default:
+		return nil, fmt.Errorf("merge join: unsupported join type %s", e.config.Type)
+	}
+	if len(e.config.LeftKeys) != 1 || len(e.config.RightKeys) != 1 {
+		return nil, fmt.Errorf("merge join: compound join keys are not supported")
+	}
+
+	return &mergeJoinStream{
+		left:     newMergeJoinInput("left", e.config.Left, e.config.LeftKeys[0], e.config.Budget),
+		right:    newMergeJoinInput("right", e.config.Right, e.config.RightKeys[0], e.config.Budget),
+		joinType: e.config.Type,
+		budget:   e.config.Budget,
+		columns:  newJoinColumns(e.config.Left.Schema(), e.config.Right.Schema()),
+	}, nil
+}
+
+// mergeJoinThreshold is the estimated row count from which neither input of
+// a join is small enough to hold in memory, so both are sorted on their
+// keys and merged.
+const mergeJoinThreshold int64 = 100000
+
+// selectMergeJoins marks the hash join steps between two sub-queries both
+// estimated at or above mergeJoinThreshold as merge joins, and pushes an
+// ORDER BY on its join key into each sub-query, so the engines return the
+// rows sorted. Only single-column keys are merged, and only sub-queries
+// whose row count comes from table statistics. The ORDER BY is a
+// pushdown, so no merge join is planned when pushdown is disabled.
+func (e *FederatedExecutor) selectMergeJoins(plans []*SubQueryPlan, joinPlan *JoinPlan, mode PushdownMode) {
+	if joinPlan == nil || mode == PushdownDisabled {
+		return
+	}
+	index := make(map[string]*SubQueryPlan, len(plans))
+	for _, p := range plans {
+		index[p.SubQuery.ID] = p
+	}
+
+	large := func(p *SubQueryPlan) bool {
+		return p != nil && p.StatsKnown && p.EstimatedRows >= mergeJoinThreshold
+	}
+	for i := range joinPlan.Steps {
+		step := &joinPlan.Steps[i]
+		left, right := index[step.LeftInput], index[step.RightInput]
+		if step.Strategy != JoinStrategyHash || len(step.Keys) != 1 || !large(left) || !large(right) {
+			continue
+		}
+		step.Strategy = JoinStrategyMerge
+		orderSubQuery(left.SubQuery, step.Keys[0].left())
+		orderSubQuery(right.SubQuery, step.Keys[0].right())
+	}
+}
+
+// orderSubQuery pushes an ORDER BY on column into a sub-query.
+func orderSubQuery(subQuery *SubQuery, column string) {
+	subQuery.OrderBy = []string{column}
+	subQuery.SQL = addOrderBy(subQuery.SQL, subQuery.OrderBy)
+}
+
+// sortedOnKey reports whether a stream declares that it is sorted on key,
+// i.e. key is its leading sort column.
+func sortedOnKey(stream ResultStream, key string) bool {
+	ordered, ok := stream.(OrderedStream)
+	if !ok {
+		return false
+	}
+	order := ordered.SortOrder()
+	return len(order) > 0 && columnName(order[0]) == columnName(key)
+}
+
+// mergeJoinInput reads one side of a merge join, one row ahead, and checks
+// that its keys do not decrease.
+type mergeJoinInput struct {
+	name   string
+	stream ResultStream
+	key    string
+
+	row     Row // current row; nil once exhausted
+	started bool
+	last    interface{} // last non-NULL key
+}
+
+// newMergeJoinInput wraps a join input, sorting it on key unless it
+// declares that order.
+func newMergeJoinInput(name string, stream ResultStream, key string, budget *MemoryBudget) *mergeJoinInput {
+	if !sortedOnKey(stream, key) {
+		stream = &sortingStream{source: stream, orderBy: []*OrderByClause{{Column: key}}, budget: budget}
+	}
+	return &mergeJoinInput{name: name, stream: stream, key: key}
+}
+
+// current returns the current row, reading the first one if needed.
+func (in *mergeJoinInput) current(ctx context.Context) (Row, error) {
+	if !in.started {
+		in.started = true
+		if err := in.advance(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return in.row, nil
+}
+
+// advance moves to the next row.
+func (in *mergeJoinInput) advance(ctx context.Context) error {
+	row, err := in.stream.Next(ctx)
+	if err != nil {
+		return err
+	}
+	in.row = row
+	if row == nil {
+		return nil
+	}
+
+	key := rowValue(row, in.key)
+	if key == nil {
+		return nil
+	}
+	if in.last != nil {
+		cmp, err := compareValues(in.last, key)
+		if err != nil {
+			return fmt.Errorf("merge join: %s key %s: %w", in.name, in.key, err)
+		}
+		if cmp > 0 {
+			return fmt.Errorf("merge join: %s input is not sorted on %s (%v after %v)", in.name, in.key, key, in.last)
+		}
+	}
+	in.last = key
+	return nil
+}
+
+// mergeJoinStream merges two inputs sorted on their join keys.
+type mergeJoinStream struct {
+	left     *mergeJoinInput
+	right    *mergeJoinInput
+	joinType JoinType
+	budget   *MemoryBudget
+	columns  *joinColumns // left columns, then right columns
+
+	// group holds the right rows of groupKey, joined with each left row of
+	// the same key as it arrives.
+	group      []Row
+	groupKey   interface{}
+	groupBytes int64
+
+	pending []Row
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Schema returns the left columns followed by the right columns.
+func (s *mergeJoinStream) Schema() *ResultSchema {
+	return s.columns.Schema()
+}
+
+// Next returns the next joined row.
+func (s *mergeJoinStream) Next(ctx context.Context) (Row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	for {
+		if len(s.pending) > 0 {
+			row := s.pending[0]
+			s.pending = s.pending[1:]
+			return row, nil
+		}
+
+		left, err := s.left.current(ctx)
+		if err != nil {
+			return nil, err
+		}
+		right, err := s.right.current(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Join left rows with the right rows of the current key
+		if s.group != nil {
+			if left != nil {
+				cmp, err := s.compareKeys(rowValue(left, s.left.key), s.groupKey)
+				if err != nil {
+					return nil, err
+				}
+				if cmp == 0 {
+					for _, match := range s.group {
+						s.pending = append(s.pending, s.columns.merge(left, match))
+					}
+					if err := s.left.advance(ctx); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			s.releaseGroup()
+		}
+
+		if left == nil && right == nil {
+			return nil, nil
+		}
+		leftKey, rightKey := rowValue(left, s.left.key), rowValue(right, s.right.key)
+
+		// A side with no more rows, or a NULL key, cannot match
+		if left != nil && (right == nil || leftKey == nil) {
+			if right == nil && !s.keepsLeft() {
+				return nil, nil
+			}
+			if err := s.left.advance(ctx); err != nil {
+				return nil, err
+			}
+			if s.keepsLeft() {
+				return s.columns.merge(left, nil), nil
+			}
+			continue
+		}
+		if right != nil && (left == nil || rightKey == nil) {
+			if left == nil && !s.keepsRight() {
+				return nil, nil
+			}
+			if err := s.right.advance(ctx); err != nil {
+				return nil, err
+			}
+			if s.keepsRight() {
+				return s.columns.merge(nil, right), nil
+			}
+			continue
+		}
+
+		cmp, err := s.compareKeys(leftKey, rightKey)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case cmp < 0:
+			if err := s.left.advance(ctx); err != nil {
+				return nil, err
+			}
+			if s.keepsLeft() {
+				return s.columns.merge(left, nil), nil
+			}
+		case cmp > 0:
+			if err := s.right.advance(ctx); err != nil {
+				return nil, err
+			}
+			if s.keepsRight() {
+				return s.columns.merge(nil, right), nil
+			}
+		default:
+			if err := s.collectGroup(ctx, rightKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// keepsLeft reports whether unmatched left rows are emitted.
+func (s *mergeJoinStream) keepsLeft() bool {
+	return s.joinType == JoinTypeLeft || s.joinType == JoinTypeFull
+}
+
+// keepsRight reports whether unmatched right rows are emitted.
+func (s *mergeJoinStream) keepsRight() bool {
+	return s.joinType == JoinTypeRight || s.joinType == JoinTypeFull
+}
+
+// collectGroup buffers the right rows whose key equals key.
+func (s *mergeJoinStream) collectGroup(ctx context.Context, key interface{}) error {
+	s.groupKey = key
+	for {
+		right, err := s.right.current(ctx)
+		if err != nil {
+			return err
+		}
+		if right == nil {
+			return nil
+		}
+		cmp, err := s.compareKeys(rowValue(right, s.right.key), key)
+		if err != nil {
+			return err
+		}
+		if cmp != 0 {
+			return nil
+		}
+
+		size := estimateRowBytes(right)
+		if err := s.budget.Reserve(OperatorMergeJoin, size); err != nil {
+			return err
+		}
+		s.groupBytes += size
+		s.group = append(s.group, right)
+		if err := s.right.advance(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// releaseGroup forgets the current key's right rows.
+func (s *mergeJoinStream) releaseGroup() {
+	s.budget.Release(s.groupBytes)
+	s.group, s.groupKey, s.groupBytes = nil, nil, 0
+}
+
+// compareKeys compares a left key with a right key. A NULL key sorts
+// before any other so it never equals one.
+func (s *mergeJoinStream) compareKeys(a, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		return -1, nil
+	}
+	cmp, err := compareValues(a, b)
+	if err != nil {
+		return 0, fmt.Errorf("merge join: %s = %s: %w", s.left.key, s.right.key, err)
+	}
+	return cmp, nil
+}
+
+// Close releases both inputs.
+func (s *mergeJoinStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.releaseGroup()
+	s.pending = nil
+	leftErr := s.left.stream.Close()
+	if err := s.right.stream.Close(); err != nil {
+		return err
+	}
+	return leftErr
+}
+
+// SortOrder implements OrderedStream. Rows arrive in the key order of the
+// side whose every row is kept: the left key for INNER and LEFT joins, the
+// right key for RIGHT joins. A FULL join pads either key with NULLs, so
+// its rows have no declared order.
+func (s *mergeJoinStream) SortOrder() []string {
+	switch s.joinType {
+	case JoinTypeInner, JoinTypeLeft:
+		return []string{s.left.key}
+	case JoinTypeRight:
+		return []string{s.right.key}
+	default:
+		return nil
+	}
+}
+
+// EstimatedRows returns -1 (unknown for join results).
+func (s *mergeJoinStream) EstimatedRows() int64 {
+	return -1
+}
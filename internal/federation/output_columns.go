@@ -0,0 +1,59 @@
+package federation
+
+import (
+	"fmt"
+	"strings"
+
+	cerrors "github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// DuplicateColumnPolicy controls how the Analyzer treats queries that
+// produce more than one output column with the same name, e.g.
+// SELECT a AS x, b AS x. Rows are keyed by column name, so such columns
+// would otherwise silently overwrite each other.
+type DuplicateColumnPolicy string
+
+const (
+	// DuplicateColumnsReject rejects the query with ErrDuplicateColumn.
+	DuplicateColumnsReject DuplicateColumnPolicy = "reject"
+
+	// DuplicateColumnsRename keeps every column, suffixing repeated names
+	// (x, x_1) and adding a warning to the analysis.
+	DuplicateColumnsRename DuplicateColumnPolicy = "rename"
+)
+
+// ParseDuplicateColumnPolicy parses a policy name case-insensitively.
+// An empty value selects DuplicateColumnsReject.
+func ParseDuplicateColumnPolicy(s string) (DuplicateColumnPolicy, error) {
+	switch policy := DuplicateColumnPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return DuplicateColumnsReject, nil
+	case DuplicateColumnsReject, DuplicateColumnsRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid duplicate column policy %q (valid: %s, %s)", s, DuplicateColumnsReject, DuplicateColumnsRename)
+	}
+}
+
+// resolveOutputColumns applies the duplicate column policy to the output
+// columns of a query. It returns the (possibly renamed) columns and a
+// warning for each renamed column.
+func (p DuplicateColumnPolicy) resolveOutputColumns(columns []string) ([]string, []string, error) {
+	duplicates := sql.DuplicateColumns(columns)
+	if len(duplicates) == 0 {
+		return columns, nil, nil
+	}
+	if p != DuplicateColumnsRename {
+		return nil, nil, cerrors.NewDuplicateColumn(duplicates[0])
+	}
+
+	renamed := sql.DisambiguateColumns(columns)
+	var warnings []string
+	for i, col := range renamed {
+		if col != columns[i] {
+			warnings = append(warnings, fmt.Sprintf("duplicate output column %s renamed to %s", columns[i], col))
+		}
+	}
+	return renamed, warnings, nil
+}
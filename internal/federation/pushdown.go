@@ -34,7 +34,10 @@ func (p *PredicateOp) HasSubquery() bool {
 
 // ProjectionOp represents a column projection operation.
 type ProjectionOp struct {
-	columns []string
+	// required maps full table names to the columns needed from them
+	// (QueryAnalysis.RequiredColumns). Tables without an entry are read
+	// whole.
+	required map[string][]string
 }
 
 // Type returns "projection".
@@ -42,11 +45,12 @@ func (p *ProjectionOp) Type() string {
 	return "projection"
 }
 
-// AggregationOp represents an aggregation operation.
+// AggregationOp represents the partial aggregation of a table's rows (see
+// QueryAnalysis.PartialAggregationTable).
 type AggregationOp struct {
 	aggregations []*Aggregation
-	groupBy      []string
-	tables       []*TableRef
+	table        string
+	keys         []string
 }
 
 // Type returns "aggregation".
@@ -54,11 +58,6 @@ func (a *AggregationOp) Type() string {
 	return "aggregation"
 }
 
-// IsSingleTable returns true if aggregation involves only one table.
-func (a *AggregationOp) IsSingleTable() bool {
-	return len(a.tables) == 1
-}
-
 // LimitOp represents a LIMIT operation.
 type LimitOp struct {
 	limit   int
@@ -85,8 +84,37 @@ type PushdownRule interface {
 	Rewrite(subQuery *SubQuery, op Operation) *SubQuery
 }
 
+// DefaultPushdownOperators are the predicate operators pushed to engines
+// without a configured allowlist. Operators whose semantics vary between
+// engines (LIKE collation) are evaluated locally by default.
+var DefaultPushdownOperators = []string{"=", "<>", "!=", "<", ">", "<=", ">=", "IN", "BETWEEN"}
+
 // FilterPushdown pushes WHERE predicates to source engines.
-type FilterPushdown struct{}
+type FilterPushdown struct {
+	// Operators maps an engine to its push-safe predicate operators.
+	// Engines without an entry use DefaultPushdownOperators.
+	Operators map[string][]string
+
+	// AllOperators ignores Operators and pushes predicates with any operator.
+	AllOperators bool
+}
+
+// operatorAllowed reports whether a predicate operator is push-safe for engine.
+func (f *FilterPushdown) operatorAllowed(engine, operator string) bool {
+	if f.AllOperators {
+		return true
+	}
+	allowed, ok := f.Operators[engine]
+	if !ok {
+		allowed = DefaultPushdownOperators
+	}
+	for _, op := range allowed {
+		if strings.EqualFold(op, operator) {
+			return true
+		}
+	}
+	return false
+}
 
 // CanPush checks if filter can be pushed to engine.
 func (f *FilterPushdown) CanPush(op Operation, engine string) bool {
@@ -95,6 +123,11 @@ func (f *FilterPushdown) CanPush(op Operation, engine string) bool {
 		return false
 	}
 
+	// Operators outside the engine's allowlist stay as local post-filters
+	if !f.operatorAllowed(engine, pred.predicate.Operator) {
+		return false
+	}
+
 	// Can always push simple predicates
 	if pred.IsSimple() {
 		return true
@@ -131,13 +164,77 @@ func (f *FilterPushdown) Rewrite(subQuery *SubQuery, op Operation) *SubQuery {
 	result.Predicates = append(result.Predicates, pred.predicate)
 
 	// Rebuild SQL with new predicate
-	if strings.Contains(strings.ToUpper(result.SQL), "WHERE") {
-		result.SQL = result.SQL + " AND " + pred.predicate.Raw
+	result.SQL = addWherePredicate(result.SQL, pred.predicate.Raw)
+
+	return &result
+}
+
+// addWherePredicate adds a predicate to the WHERE clause of a sub-query's
+// SQL, ahead of its GROUP BY, ORDER BY and LIMIT clauses, whichever of
+// them the SQL already has.
+func addWherePredicate(query, predicate string) string {
+	end := len(query)
+	for _, clause := range []string{"GROUP BY", "ORDER BY", "LIMIT"} {
+		if i := clauseIndex(query, clause); i >= 0 && i < end {
+			end = i
+		}
+	}
+	head, tail := strings.TrimRight(query[:end], " "), query[end:]
+	if clauseIndex(head, "WHERE") >= 0 {
+		head += " AND " + predicate
 	} else {
-		result.SQL = result.SQL + " WHERE " + pred.predicate.Raw
+		head += " WHERE " + predicate
+	}
+	if tail == "" {
+		return head
 	}
+	return head + " " + tail
+}
 
-	return &result
+// addOrderBy adds an ORDER BY clause on columns to a sub-query's SQL, ahead
+// of its LIMIT.
+func addOrderBy(query string, columns []string) string {
+	clause := "ORDER BY " + strings.Join(columns, ", ")
+	if i := clauseIndex(query, "LIMIT"); i >= 0 {
+		return query[:i] + clause + " " + query[i:]
+	}
+	return query + " " + clause
+}
+
+// clauseIndex returns the index of a clause keyword of a sub-query's SQL,
+// outside quoted strings, quoted identifiers and parentheses, or -1 if the
+// SQL has no such clause.
+func clauseIndex(query, keyword string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && i > 0 && query[i-1] == ' ' && hasKeywordAt(query, i, keyword):
+			return i
+		}
+	}
+	return -1
+}
+
+// hasKeywordAt reports whether keyword, in any case, starts query at i as
+// a whole word.
+func hasKeywordAt(query string, i int, keyword string) bool {
+	end := i + len(keyword)
+	if end > len(query) || !strings.EqualFold(query[i:end], keyword) {
+		return false
+	}
+	return end == len(query) || query[end] == ' '
 }
 
 // ProjectionPushdown pushes column selection to source engines.
@@ -149,23 +246,43 @@ func (p *ProjectionPushdown) CanPush(op Operation, engine string) bool {
 	return ok
 }
 
-// Rewrite updates the SELECT clause with specific columns.
+// Rewrite updates the SELECT clause with the columns required from the
+// sub-query's tables. The sub-query is unchanged when every table must be
+// read whole.
 func (p *ProjectionPushdown) Rewrite(subQuery *SubQuery, op Operation) *SubQuery {
 	proj, ok := op.(*ProjectionOp)
 	if !ok {
 		return subQuery
 	}
 
+	var columns []string
+	narrowed := false
+	for _, table := range subQuery.Tables {
+		alias := table.DisplayName()
+		cols, ok := proj.required[table.FullName()]
+		if !ok {
+			columns = append(columns, alias+".*")
+			continue
+		}
+		narrowed = true
+		for _, col := range cols {
+			columns = append(columns, alias+"."+col)
+		}
+	}
+	if !narrowed {
+		return subQuery
+	}
+
 	result := *subQuery
-	result.Columns = proj.columns
+	result.Columns = columns
 
 	// Rebuild SQL SELECT clause
 	selectIdx := strings.Index(strings.ToUpper(result.SQL), "SELECT")
-	fromIdx := strings.Index(strings.ToUpper(result.SQL), "FROM")
+	fromIdx := strings.Index(strings.ToUpper(result.SQL), " FROM ")
 
 	if selectIdx >= 0 && fromIdx > selectIdx {
 		result.SQL = result.SQL[:selectIdx+6] + " " +
-			strings.Join(proj.columns, ", ") + " " +
+			strings.Join(columns, ", ") +
 			result.SQL[fromIdx:]
 	}
 
@@ -178,48 +295,39 @@ type AggregationPushdown struct{}
 // CanPush checks if aggregation can be pushed.
 func (a *AggregationPushdown) CanPush(op Operation, engine string) bool {
 	agg, ok := op.(*AggregationOp)
-	if !ok {
-		return false
-	}
-
-	// Can push if:
-	// 1. Single table (no joins needed first)
-	// 2. All group-by columns from same table
-	// 3. All aggregation inputs from same table
-	return agg.IsSingleTable()
+	return ok && agg.table != ""
 }
 
-// Rewrite adds GROUP BY to the sub-query.
+// Rewrite makes the table's sub-query compute partial aggregates, grouped
+// by the partial aggregation keys. A sub-query reading other tables too,
+// or with predicates evaluated on its rows locally, is unchanged, since
+// its rows must reach the join or filter unaggregated.
 func (a *AggregationPushdown) Rewrite(subQuery *SubQuery, op Operation) *SubQuery {
 	agg, ok := op.(*AggregationOp)
-	if !ok {
+	if !ok || len(subQuery.Tables) != 1 || subQuery.Tables[0].FullName() != agg.table || len(subQuery.PostFilters) > 0 {
 		return subQuery
 	}
 
 	result := *subQuery
-
-	// Build aggregation columns for SELECT
-	var selectParts []string
-	for _, col := range agg.groupBy {
-		selectParts = append(selectParts, col)
-	}
-	for _, aggFn := range agg.aggregations {
-		selectParts = append(selectParts, aggFn.Raw)
+	result.Columns = append([]string(nil), agg.keys...)
+	for i, partial := range partialAggregateColumns(agg.aggregations) {
+		result.Columns = append(result.Columns, partial.selectItems(agg.aggregations[i])...)
 	}
+	result.PartialAggregates = true
 
 	// Rebuild SQL with aggregation
 	selectIdx := strings.Index(strings.ToUpper(result.SQL), "SELECT")
-	fromIdx := strings.Index(strings.ToUpper(result.SQL), "FROM")
+	fromIdx := strings.Index(strings.ToUpper(result.SQL), " FROM ")
 
 	if selectIdx >= 0 && fromIdx > selectIdx {
 		result.SQL = result.SQL[:selectIdx+6] + " " +
-			strings.Join(selectParts, ", ") + " " +
+			strings.Join(result.Columns, ", ") +
 			result.SQL[fromIdx:]
 	}
 
 	// Add GROUP BY
-	if len(agg.groupBy) > 0 {
-		result.SQL = result.SQL + " GROUP BY " + strings.Join(agg.groupBy, ", ")
+	if len(agg.keys) > 0 {
+		result.SQL = result.SQL + " GROUP BY " + strings.Join(agg.keys, ", ")
 	}
 
 	return &result
@@ -235,16 +343,18 @@ func (l *LimitPushdown) CanPush(op Operation, engine string) bool {
 		return false
 	}
 
-	// Can only push limit if:
-	// 1. No join (limit applies to single source)
-	// 2. Or limit applies to outer query after join
+	// Only a sub-query whose rows are the query's result can be limited;
+	// the rows of a join input, or of a sub-query whose rows are
+	// aggregated, de-duplicated or sorted locally, must all be read
 	return limit.IsFinal()
 }
 
-// Rewrite adds LIMIT to the sub-query.
+// Rewrite adds LIMIT to the sub-query. A sub-query with predicates
+// evaluated on its rows locally is unchanged, since the limit applies to
+// the filtered rows.
 func (l *LimitPushdown) Rewrite(subQuery *SubQuery, op Operation) *SubQuery {
 	limit, ok := op.(*LimitOp)
-	if !ok {
+	if !ok || len(subQuery.PostFilters) > 0 {
 		return subQuery
 	}
 
@@ -261,25 +371,47 @@ func (l *LimitPushdown) Rewrite(subQuery *SubQuery, op Operation) *SubQuery {
 // PushdownOptimizer optimizes queries by pushing operations to source engines.
 // Per phase-9-spec.md §5.2.
 type PushdownOptimizer struct {
-	rules []PushdownRule
+	rules  []PushdownRule
+	filter *FilterPushdown
 }
 
 // NewPushdownOptimizer creates a new pushdown optimizer.
 func NewPushdownOptimizer() *PushdownOptimizer {
+	filter := &FilterPushdown{Operators: make(map[string][]string)}
 	return &PushdownOptimizer{
 		rules: []PushdownRule{
-			&FilterPushdown{},
+			filter,
 			&ProjectionPushdown{},
 			&AggregationPushdown{},
 			&LimitPushdown{},
 		},
+		filter: filter,
 	}
 }
 
+// SetPushdownOperators sets the predicate operators that are pushed to an
+// engine. Predicates using other operators are kept as post-filters on that
+// engine's results.
+func (o *PushdownOptimizer) SetPushdownOperators(engine string, operators []string) {
+	o.filter.Operators[engine] = operators
+}
+
 // Optimize applies pushdown optimizations to a decomposed query.
 func (o *PushdownOptimizer) Optimize(
 	decomposed *DecomposedQuery,
 	analysis *QueryAnalysis,
+) (*DecomposedQuery, error) {
+	return o.OptimizeWithMode(decomposed, analysis, PushdownDefault)
+}
+
+// OptimizeWithMode applies pushdown optimizations according to mode.
+// With PushdownDisabled no operation is pushed and every predicate becomes a
+// post-filter; with PushdownForced predicates are pushed regardless of the
+// engine's operator allowlist.
+func (o *PushdownOptimizer) OptimizeWithMode(
+	decomposed *DecomposedQuery,
+	analysis *QueryAnalysis,
+	mode PushdownMode,
 ) (*DecomposedQuery, error) {
 	// Validate input
 	if decomposed == nil {
@@ -293,28 +425,76 @@ func (o *PushdownOptimizer) Optimize(
 
 	// Extract operations from analysis
 	operations := o.extractOperations(analysis)
+	rules := o.rulesFor(mode)
 
 	// For each sub-query, try to push down operations
 	for i, subQuery := range optimized.SubQueries {
-		for _, rule := range o.rules {
-			for _, op := range operations {
+		for _, op := range operations {
+			pred, isPredicate := op.(*PredicateOp)
+			if isPredicate && !subQueryReads(subQuery, pred.predicate.Table) {
+				continue
+			}
+
+			pushed := false
+			for _, rule := range rules {
 				if rule.CanPush(op, subQuery.Engine) {
-					optimized.SubQueries[i] = rule.Rewrite(subQuery, op)
+					subQuery = rule.Rewrite(subQuery, op)
+					pushed = true
 				}
 			}
+
+			// Predicates the engine cannot evaluate safely are applied locally
+			if isPredicate && !pushed {
+				subQuery.PostFilters = append(subQuery.PostFilters, pred.predicate)
+			}
 		}
+		optimized.SubQueries[i] = subQuery
 	}
 
 	return optimized, nil
 }
 
+// rulesFor returns the pushdown rules to apply in mode.
+func (o *PushdownOptimizer) rulesFor(mode PushdownMode) []PushdownRule {
+	switch mode {
+	case PushdownDisabled:
+		return nil
+	case PushdownForced:
+		rules := make([]PushdownRule, len(o.rules))
+		for i, rule := range o.rules {
+			if rule == PushdownRule(o.filter) {
+				rule = &FilterPushdown{Operators: o.filter.Operators, AllOperators: true}
+			}
+			rules[i] = rule
+		}
+		return rules
+	default:
+		return o.rules
+	}
+}
+
+// subQueryReads reports whether a sub-query reads the table a predicate
+// applies to. Predicates without a table apply to every sub-query.
+func subQueryReads(subQuery *SubQuery, table string) bool {
+	if table == "" {
+		return true
+	}
+	for _, ref := range subQuery.Tables {
+		if ref.FullName() == table || ref.Name == table || ref.Alias == table {
+			return true
+		}
+	}
+	return false
+}
+
 // cloneDecomposed creates a deep copy of a decomposed query.
 func (o *PushdownOptimizer) cloneDecomposed(d *DecomposedQuery) *DecomposedQuery {
 	result := &DecomposedQuery{
-		OriginalSQL: d.OriginalSQL,
-		SubQueries:  make([]*SubQuery, len(d.SubQueries)),
-		JoinPlan:    d.JoinPlan,
-		PostJoinOps: d.PostJoinOps,
+		OriginalSQL:  d.OriginalSQL,
+		SubQueries:   make([]*SubQuery, len(d.SubQueries)),
+		JoinPlan:     d.JoinPlan,
+		PostJoinOps:  d.PostJoinOps,
+		SetOperation: d.SetOperation,
 	}
 
 	for i, sq := range d.SubQueries {
@@ -323,6 +503,8 @@ func (o *PushdownOptimizer) cloneDecomposed(d *DecomposedQuery) *DecomposedQuery
 		copy(clone.Tables, sq.Tables)
 		clone.Predicates = make([]*Predicate, len(sq.Predicates))
 		copy(clone.Predicates, sq.Predicates)
+		clone.PostFilters = make([]*Predicate, len(sq.PostFilters))
+		copy(clone.PostFilters, sq.PostFilters)
 		clone.Columns = make([]string, len(sq.Columns))
 		copy(clone.Columns, sq.Columns)
 		result.SubQueries[i] = &clone
@@ -342,25 +524,50 @@ func (o *PushdownOptimizer) extractOperations(analysis *QueryAnalysis) []Operati
 		}
 	}
 
-	// Add aggregation operations
-	if len(analysis.Aggregations) > 0 {
-		// Extract GROUP BY columns from query (simplified)
+	// Add the projection of the required columns
+	if len(analysis.RequiredColumns) > 0 {
+		ops = append(ops, &ProjectionOp{required: analysis.RequiredColumns})
+	}
+
+	// Add the partial aggregation of a table
+	if analysis.PartialAggregationTable != "" {
 		ops = append(ops, &AggregationOp{
 			aggregations: analysis.Aggregations,
+			table:        analysis.PartialAggregationTable,
+			keys:         analysis.PartialAggregationKeys,
 		})
 	}
 
-	// Add limit operation
-	if analysis.Limit != nil {
+	// Add limit operation. Engines return the rows up to the end of the
+	// limit; the offset is skipped locally. The limit of a set operation
+	// applies to its combined rows, so it is not pushed.
+	if analysis.Limit != nil && analysis.SetOperation == "" {
+		limit := *analysis.Limit
+		if analysis.Offset != nil {
+			limit += *analysis.Offset
+		}
 		ops = append(ops, &LimitOp{
-			limit:   *analysis.Limit,
-			isFinal: true, // After joins
+			limit:   limit,
+			isFinal: limitsSubQuery(analysis),
 		})
 	}
 
 	return ops
 }
 
+// limitsSubQuery reports whether the query's LIMIT applies to the rows of
+// its only sub-query: the query reads a single table, and no join,
+// aggregation, DISTINCT or ORDER BY runs on those rows after it.
+func limitsSubQuery(analysis *QueryAnalysis) bool {
+	tables := 0
+	for _, refs := range analysis.TablesByEngine {
+		tables += len(refs)
+	}
+	return tables == 1 && len(analysis.Joins) == 0 &&
+		len(analysis.Aggregations) == 0 && len(analysis.GroupBy) == 0 &&
+		!analysis.Distinct && len(analysis.OrderBy) == 0
+}
+
 // PushdownStats tracks pushdown optimization statistics.
 type PushdownStats struct {
 	FiltersPushed      int
@@ -382,6 +589,11 @@ func (o *PushdownOptimizer) AnalyzePushdown(
 		// Count additional predicates
 		stats.FiltersPushed += len(sq.Predicates) - len(origSQ.Predicates)
 
+		// Check for narrowed projections
+		if strings.Join(sq.Columns, ",") != strings.Join(origSQ.Columns, ",") {
+			stats.ProjectionsPushed++
+		}
+
 		// Check for aggregations (simplified)
 		if strings.Contains(strings.ToUpper(sq.SQL), "GROUP BY") &&
 			!strings.Contains(strings.ToUpper(origSQ.SQL), "GROUP BY") {
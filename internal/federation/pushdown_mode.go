@@ -0,0 +1,68 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PushdownModeHeader is the request header that overrides predicate pushdown
+// for a single query. It exists for debugging: comparing results with and
+// without pushdown shows whether a wrong result comes from an engine
+// evaluating a pushed predicate differently.
+const PushdownModeHeader = "X-Canonic-Pushdown"
+
+// PushdownMode controls how the PushdownOptimizer treats a query.
+type PushdownMode string
+
+const (
+	// PushdownDefault pushes operations according to the configured rules.
+	PushdownDefault PushdownMode = ""
+
+	// PushdownDisabled pushes nothing; every predicate is evaluated locally
+	// as a post-filter on the engine's unfiltered results.
+	PushdownDisabled PushdownMode = "no_pushdown"
+
+	// PushdownForced pushes every predicate the engine can evaluate,
+	// ignoring the per-engine operator allowlist.
+	PushdownForced PushdownMode = "force_pushdown"
+)
+
+// ParsePushdownMode parses a PushdownModeHeader value.
+// An empty value selects PushdownDefault.
+func ParsePushdownMode(s string) (PushdownMode, error) {
+	switch mode := PushdownMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case PushdownDefault, PushdownDisabled, PushdownForced:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid pushdown mode %q (valid: %s, %s)", s, PushdownDisabled, PushdownForced)
+	}
+}
+
+// String returns a description of the mode for EXPLAIN output.
+func (m PushdownMode) String() string {
+	switch m {
+	case PushdownDisabled:
+		return "disabled (no_pushdown)"
+	case PushdownForced:
+		return "forced (force_pushdown)"
+	default:
+		return "default"
+	}
+}
+
+// pushdownModeKey is the context key for the pushdown mode.
+type pushdownModeKey struct{}
+
+// ContextWithPushdownMode returns a context that runs federated queries with
+// the given pushdown mode.
+func ContextWithPushdownMode(ctx context.Context, mode PushdownMode) context.Context {
+	return context.WithValue(ctx, pushdownModeKey{}, mode)
+}
+
+// PushdownModeFromContext returns the pushdown mode attached to ctx, or
+// PushdownDefault if there is none.
+func PushdownModeFromContext(ctx context.Context) PushdownMode {
+	mode, _ := ctx.Value(pushdownModeKey{}).(PushdownMode)
+	return mode
+}
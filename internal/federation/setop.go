@@ -0,0 +1,262 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// newSetOperationStream combines the results of the left and right operands
+// of a cross-engine set operation, one of the sql.SetOperation kinds.
+// Columns are matched by position, and the combined rows take the column
+// names of the left operand.
+func newSetOperationStream(kind string, left, right ResultStream, budget *MemoryBudget) (ResultStream, error) {
+	leftColumns, rightColumns := schemaColumns(left.Schema()), schemaColumns(right.Schema())
+	if len(leftColumns) == 0 || len(leftColumns) != len(rightColumns) {
+		return nil, fmt.Errorf("%s operands have %d and %d columns", kind, len(leftColumns), len(rightColumns))
+	}
+	operands := setOperands{left: left, right: right, leftColumns: leftColumns, rightColumns: rightColumns}
+
+	switch kind {
+	case sql.SetOperationUnionAll:
+		return &unionStream{setOperands: operands}, nil
+	case sql.SetOperationUnion:
+		return newDistinctingStream(&unionStream{setOperands: operands}, nil, budget), nil
+	case sql.SetOperationIntersect, sql.SetOperationIntersectAll:
+		return &intersectStream{
+			setOperands: operands,
+			counts:      rowCounts{budget: budget},
+			all:         kind == sql.SetOperationIntersectAll,
+		}, nil
+	case sql.SetOperationExcept, sql.SetOperationExceptAll:
+		return &exceptStream{
+			setOperands: operands,
+			counts:      rowCounts{budget: budget},
+			all:         kind == sql.SetOperationExceptAll,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported set operation %s", kind)
+	}
+}
+
+// setOperands are the two result streams of a set operation and their
+// column names, in SELECT-list order.
+type setOperands struct {
+	left         ResultStream
+	right        ResultStream
+	leftColumns  []string
+	rightColumns []string
+}
+
+// Schema returns the schema of the left operand.
+func (o *setOperands) Schema() *ResultSchema {
+	return o.left.Schema()
+}
+
+// Close closes both operands.
+func (o *setOperands) Close() error {
+	leftErr := o.left.Close()
+	if err := o.right.Close(); err != nil {
+		return err
+	}
+	return leftErr
+}
+
+// EstimatedRows returns the left operand estimate, an upper bound for
+// INTERSECT and EXCEPT.
+func (o *setOperands) EstimatedRows() int64 {
+	return o.left.EstimatedRows()
+}
+
+// unionStream returns the rows of the left operand, then those of the right
+// operand under the left operand's column names (UNION ALL).
+type unionStream struct {
+	setOperands
+	leftDone bool
+}
+
+// Next returns the next row of either operand.
+func (s *unionStream) Next(ctx context.Context) (Row, error) {
+	if !s.leftDone {
+		row, err := s.left.Next(ctx)
+		if err != nil || row != nil {
+			return row, err
+		}
+		s.leftDone = true
+	}
+
+	row, err := s.right.Next(ctx)
+	if err != nil || row == nil {
+		return row, err
+	}
+	renamed := make(Row, len(s.leftColumns))
+	for i, col := range s.leftColumns {
+		renamed[col] = rowValue(row, s.rightColumns[i])
+	}
+	return renamed, nil
+}
+
+// EstimatedRows returns the sum of the operand estimates, or -1 if either
+// is unknown.
+func (s *unionStream) EstimatedRows() int64 {
+	left, right := s.left.EstimatedRows(), s.right.EstimatedRows()
+	if left < 0 || right < 0 {
+		return -1
+	}
+	return left + right
+}
+
+// intersectStream returns the rows of the left operand that are also rows
+// of the right operand. The right operand is buffered into a count of its
+// rows on the first call to Next; the left operand is streamed. Without
+// all (INTERSECT), each matching row is returned once; with all (INTERSECT
+// ALL), as many times as it occurs in both operands.
+type intersectStream struct {
+	setOperands
+	counts rowCounts
+	all    bool
+}
+
+// Next returns the next left row matching a right row.
+func (s *intersectStream) Next(ctx context.Context) (Row, error) {
+	if err := s.counts.load(ctx, s.right, s.rightColumns); err != nil {
+		return nil, err
+	}
+	for {
+		row, err := s.left.Next(ctx)
+		if err != nil || row == nil {
+			return row, err
+		}
+
+		key := groupKey(row, s.leftColumns)
+		if s.counts.rows[key] <= 0 {
+			continue
+		}
+		if s.all {
+			s.counts.rows[key]--
+		} else {
+			s.counts.rows[key] = 0
+		}
+		return row, nil
+	}
+}
+
+// Close releases the right operand's rows and closes both operands.
+func (s *intersectStream) Close() error {
+	s.counts.release()
+	return s.setOperands.Close()
+}
+
+// exceptStream returns the rows of the left operand that are not rows of
+// the right operand, which is buffered like that of intersectStream.
+// Without all (EXCEPT), each row is returned once; with all (EXCEPT ALL),
+// each right row cancels one equal left row.
+type exceptStream struct {
+	setOperands
+	counts rowCounts
+	all    bool
+}
+
+// Next returns the next left row not matching a right row.
+func (s *exceptStream) Next(ctx context.Context) (Row, error) {
+	if err := s.counts.load(ctx, s.right, s.rightColumns); err != nil {
+		return nil, err
+	}
+	for {
+		row, err := s.left.Next(ctx)
+		if err != nil || row == nil {
+			return row, err
+		}
+
+		key := groupKey(row, s.leftColumns)
+		switch n := s.counts.rows[key]; {
+		case n > 0 && s.all:
+			s.counts.rows[key]--
+			continue
+		case n != 0: // a right row, or returned already
+			continue
+		}
+		if !s.all {
+			if err := s.counts.reserve(key); err != nil {
+				return nil, err
+			}
+			s.counts.rows[key] = -1
+		}
+		return row, nil
+	}
+}
+
+// Close releases the right operand's rows and closes both operands.
+func (s *exceptStream) Close() error {
+	s.counts.release()
+	return s.setOperands.Close()
+}
+
+// rowCounts counts the rows of the right operand of INTERSECT or EXCEPT by
+// their column tuple. The memory of each distinct tuple is reserved from
+// the query's budget.
+type rowCounts struct {
+	budget   *MemoryBudget
+	rows     map[string]int
+	reserved int64
+	loaded   bool
+}
+
+// load counts the rows of stream, once.
+func (c *rowCounts) load(ctx context.Context, stream ResultStream, columns []string) error {
+	if c.loaded {
+		return nil
+	}
+	if c.rows == nil {
+		c.rows = make(map[string]int)
+	}
+	for {
+		row, err := stream.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		key := groupKey(row, columns)
+		if err := c.reserve(key); err != nil {
+			return err
+		}
+		c.rows[key]++
+	}
+	c.loaded = true
+	return nil
+}
+
+// reserve reserves the memory of a tuple not counted yet.
+func (c *rowCounts) reserve(key string) error {
+	if _, ok := c.rows[key]; ok {
+		return nil
+	}
+	size := int64(len(key)) + rowOverheadBytes
+	if err := c.budget.Reserve(OperatorSetOp, size); err != nil {
+		return err
+	}
+	c.reserved += size
+	c.rows[key] = 0
+	return nil
+}
+
+// release releases the counted tuples.
+func (c *rowCounts) release() {
+	c.budget.Release(c.reserved)
+	c.rows, c.reserved = nil, 0
+}
+
+// schemaColumns returns the column names of a schema, in order.
+func schemaColumns(schema *ResultSchema) []string {
+	if schema == nil {
+		return nil
+	}
+	columns := make([]string, len(schema.Columns))
+	for i, col := range schema.Columns {
+		columns[i] = col.Name
+	}
+	return columns
+}
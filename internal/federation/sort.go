@@ -0,0 +1,50 @@
+package federation
+
+import "fmt"
+
+// CompareRows orders two rows by ORDER BY clauses, returning a negative
+// number if a sorts before b, a positive number if after, and zero if the
+// clauses do not distinguish them.
+//
+// NULL (or missing) values sort after all other values unless the clause
+// sets NullsFirst, in either direction. Values that cannot be ordered
+// against each other are an error.
+func CompareRows(a, b Row, orderBy []*OrderByClause) (int, error) {
+	for _, clause := range orderBy {
+		cmp, err := clause.compare(rowValue(a, clause.Column), rowValue(b, clause.Column))
+		if err != nil {
+			return 0, err
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
+
+// compare orders two values of the clause's column.
+func (c *OrderByClause) compare(a, b interface{}) (int, error) {
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil || b == nil:
+		// NULL placement does not depend on the direction
+		nullOrder := 1
+		if c.NullsFirst {
+			nullOrder = -1
+		}
+		if a == nil {
+			return nullOrder, nil
+		}
+		return -nullOrder, nil
+	}
+
+	cmp, err := compareValues(a, b)
+	if err != nil {
+		return 0, fmt.Errorf("federation: ORDER BY %s: %w", c.Column, err)
+	}
+	if c.Descending {
+		return -cmp, nil
+	}
+	return cmp, nil
+}
@@ -0,0 +1,139 @@
+package federation
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+func init() {
+	// Engines return timestamps as time.Time; gob must know the concrete
+	// type to encode it inside a Row.
+	gob.Register(time.Time{})
+}
+
+// spillFile holds rows that did not fit in the memory budget in a temporary
+// file, gob-encoded in append order.
+type spillFile struct {
+	file  *os.File
+	buf   *bufio.Writer
+	enc   *gob.Encoder
+	count int
+}
+
+// newSpillFile creates an empty spill file in dir, or in the system temp
+// directory when dir is empty.
+func newSpillFile(dir string) (*spillFile, error) {
+	file, err := os.CreateTemp(dir, "canonic-spill-*")
+	if err != nil {
+		return nil, fmt.Errorf("spill to disk failed: %w", err)
+	}
+	buf := bufio.NewWriter(file)
+	return &spillFile{file: file, buf: buf, enc: gob.NewEncoder(buf)}, nil
+}
+
+// write appends a row to the file.
+func (f *spillFile) write(row Row) error {
+	if err := f.enc.Encode(row); err != nil {
+		return fmt.Errorf("spill to disk failed: %w", err)
+	}
+	f.count++
+	return nil
+}
+
+// stream returns a stream over the rows written so far.
+func (f *spillFile) stream(schema *ResultSchema) ResultStream {
+	if err := f.buf.Flush(); err != nil {
+		return &spillStream{schema: schema, err: fmt.Errorf("spill to disk failed: %w", err)}
+	}
+	file, err := os.Open(f.file.Name())
+	if err != nil {
+		return &spillStream{schema: schema, err: fmt.Errorf("reading spilled rows failed: %w", err)}
+	}
+	return &spillStream{
+		file:   file,
+		dec:    gob.NewDecoder(bufio.NewReader(file)),
+		schema: schema,
+		rows:   f.count,
+	}
+}
+
+// remove closes and deletes the file.
+func (f *spillFile) remove() error {
+	closeErr := f.file.Close()
+	if err := os.Remove(f.file.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// spillStream reads rows back from a spill file.
+type spillStream struct {
+	file   *os.File
+	dec    *gob.Decoder
+	schema *ResultSchema
+	rows   int
+	err    error
+	mu     sync.Mutex
+}
+
+// Schema returns the result schema.
+func (s *spillStream) Schema() *ResultSchema {
+	return s.schema
+}
+
+// Next returns the next row, or nil once the file is exhausted.
+func (s *spillStream) Next(ctx context.Context) (Row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.dec == nil {
+		return nil, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var row Row
+	if err := s.dec.Decode(&row); err != nil {
+		if errors.Is(err, io.EOF) {
+			s.dec = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading spilled rows failed: %w", err)
+	}
+	if row == nil {
+		row = Row{}
+	}
+	return row, nil
+}
+
+// Close releases the read handle.
+func (s *spillStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dec = nil
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// EstimatedRows returns the number of spilled rows.
+func (s *spillStream) EstimatedRows() int64 {
+	return int64(s.rows)
+}
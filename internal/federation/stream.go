@@ -11,6 +11,10 @@ import (
 type ColumnDef struct {
 	Name string
 	Type string
+
+	// Table is the alias, or name, of the table the column was read from;
+	// empty when unknown. Joins qualify the columns both inputs share with it.
+	Table string
 }
 
 // ResultSchema defines the schema of query results.
@@ -58,6 +62,12 @@ type MemoryResultStore struct {
 	rows   []Row
 	schema *ResultSchema
 	mu     sync.RWMutex
+
+	// budget, when set, is charged for every buffered row.
+	budget     *MemoryBudget
+	reserved   int64
+	allowSpill bool
+	spill      *spillFile
 }
 
 // NewMemoryResultStore creates a new in-memory result store.
@@ -68,17 +78,74 @@ func NewMemoryResultStore(schema *ResultSchema) *MemoryResultStore {
 	}
 }
 
+// SetBudget charges buffered rows against budget. When a row would exceed
+// the budget, the store moves its rows to a temporary file if allowSpill is
+// set, and otherwise Append fails with ErrMemoryBudgetExceeded.
+func (s *MemoryResultStore) SetBudget(budget *MemoryBudget, allowSpill bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budget = budget
+	s.allowSpill = allowSpill
+}
+
 // Append adds a row to the store.
 func (s *MemoryResultStore) Append(row Row) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+
+	if s.spill != nil {
+		return s.spill.write(row)
+	}
+
+	size := estimateRowBytes(row)
+	if err := s.budget.Reserve(OperatorMaterialize, size); err != nil {
+		if !s.allowSpill {
+			return err
+		}
+		if err := s.spillRows(); err != nil {
+			return err
+		}
+		return s.spill.write(row)
+	}
+	s.reserved += size
 	s.rows = append(s.rows, row)
 	return nil
 }
 
+// spillRows moves the buffered rows to a temporary file and releases their
+// memory reservation. Later rows are appended to the file.
+func (s *MemoryResultStore) spillRows() error {
+	spill, err := newSpillFile("")
+	if err != nil {
+		return err
+	}
+	for _, row := range s.rows {
+		if err := spill.write(row); err != nil {
+			spill.remove()
+			return err
+		}
+	}
+	s.spill = spill
+	s.rows = nil
+	s.budget.Release(s.reserved)
+	s.reserved = 0
+	return nil
+}
+
+// Spilled reports whether the store moved its rows to disk.
+func (s *MemoryResultStore) Spilled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.spill != nil
+}
+
 // Stream returns a stream over the stored rows.
 func (s *MemoryResultStore) Stream() ResultStream {
 	s.mu.RLock()
+	if s.spill != nil {
+		defer s.mu.RUnlock()
+		return s.spill.stream(s.schema)
+	}
 	// Copy rows to prevent mutation during iteration
 	rows := make([]Row, len(s.rows))
 	copy(rows, s.rows)
@@ -95,6 +162,9 @@ func (s *MemoryResultStore) Stream() ResultStream {
 func (s *MemoryResultStore) Size() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.spill != nil {
+		return s.spill.count
+	}
 	return len(s.rows)
 }
 
@@ -103,9 +173,32 @@ func (s *MemoryResultStore) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.rows = nil
+	s.budget.Release(s.reserved)
+	s.reserved = 0
+	if s.spill != nil {
+		err := s.spill.remove()
+		s.spill = nil
+		return err
+	}
 	return nil
 }
 
+// materializedStream streams the rows of a store and closes the store, which
+// releases its memory reservation and spill file, when the stream is closed.
+type materializedStream struct {
+	ResultStream
+	store ResultStore
+}
+
+// Close closes the stream and the underlying store.
+func (s *materializedStream) Close() error {
+	err := s.ResultStream.Close()
+	if storeErr := s.store.Close(); err == nil {
+		err = storeErr
+	}
+	return err
+}
+
 // memoryStream implements ResultStream for in-memory results.
 type memoryStream struct {
 	rows   []Row
@@ -386,3 +479,23 @@ func CollectStream(ctx context.Context, stream ResultStream) ([]Row, error) {
 	}
 	return rows, nil
 }
+
+// collectStreamWithBudget collects all rows from a stream, reserving memory
+// for each row from budget on behalf of operator.
+func collectStreamWithBudget(ctx context.Context, stream ResultStream, budget *MemoryBudget, operator string) ([]Row, error) {
+	var rows []Row
+	for {
+		row, err := stream.Next(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("collect stream: %w", err)
+		}
+		if row == nil {
+			break
+		}
+		if err := budget.Reserve(operator, estimateRowBytes(row)); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,152 @@
+package observability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAuditTopN is the number of entries in each top-N list of the audit
+// summary when AuditSummaryOptions.TopN is not set.
+const DefaultAuditTopN = 5
+
+// MaxAuditTopN bounds AuditSummaryOptions.TopN.
+const MaxAuditTopN = 100
+
+// AuditDimension is a dimension the audit summary can rank by.
+type AuditDimension string
+
+const (
+	// DimensionRejectionReasons ranks rejected queries by error message.
+	DimensionRejectionReasons AuditDimension = "rejection_reasons"
+
+	// DimensionTables ranks tables by the number of queries referencing them.
+	DimensionTables AuditDimension = "tables"
+
+	// DimensionUsers ranks users by the number of queries they ran.
+	DimensionUsers AuditDimension = "users"
+
+	// DimensionEngines ranks engines by the number of queries routed to them.
+	DimensionEngines AuditDimension = "engines"
+)
+
+// DefaultAuditDimensions are the dimensions summarized when
+// AuditSummaryOptions.Dimensions is empty.
+var DefaultAuditDimensions = []AuditDimension{DimensionRejectionReasons, DimensionTables}
+
+// ParseAuditDimension parses a dimension name case-insensitively.
+func ParseAuditDimension(s string) (AuditDimension, error) {
+	switch dim := AuditDimension(strings.ToLower(strings.TrimSpace(s))); dim {
+	case DimensionRejectionReasons, DimensionTables, DimensionUsers, DimensionEngines:
+		return dim, nil
+	default:
+		return "", fmt.Errorf("observability: unknown audit dimension %q (valid: %s, %s, %s, %s)",
+			s, DimensionRejectionReasons, DimensionTables, DimensionUsers, DimensionEngines)
+	}
+}
+
+// AuditSummaryOptions selects what the audit summary ranks.
+// The zero value selects DefaultAuditTopN and DefaultAuditDimensions.
+type AuditSummaryOptions struct {
+	// TopN is the length of each top-N list.
+	TopN int
+
+	// Dimensions are the top-N lists to build.
+	Dimensions []AuditDimension
+
+	// Consistent reads a persisted summary from the primary database even
+	// when a read replica is configured, so it includes entries the replica
+	// has not received yet.
+	Consistent bool
+
+	// Since and Until restrict the summary to entries logged from Since
+	// (inclusive) to Until (exclusive). A zero time leaves that side of the
+	// window open.
+	Since time.Time
+	Until time.Time
+}
+
+// inWindow reports whether an entry logged at t falls in the window.
+func (o AuditSummaryOptions) inWindow(t time.Time) bool {
+	if !o.Since.IsZero() && t.Before(o.Since) {
+		return false
+	}
+	return o.Until.IsZero() || t.Before(o.Until)
+}
+
+// normalize applies defaults and validates the options.
+func (o AuditSummaryOptions) normalize() (AuditSummaryOptions, error) {
+	if o.TopN < 0 || o.TopN > MaxAuditTopN {
+		return o, fmt.Errorf("observability: audit summary top-N must be between 1 and %d, got %d", MaxAuditTopN, o.TopN)
+	}
+	if !o.Since.IsZero() && !o.Until.IsZero() && !o.Until.After(o.Since) {
+		return o, fmt.Errorf("observability: audit summary window must end after it starts, got %s to %s",
+			o.Since.Format(time.RFC3339), o.Until.Format(time.RFC3339))
+	}
+	if o.TopN == 0 {
+		o.TopN = DefaultAuditTopN
+	}
+	if len(o.Dimensions) == 0 {
+		o.Dimensions = DefaultAuditDimensions
+	}
+	for _, dim := range o.Dimensions {
+		if _, err := ParseAuditDimension(string(dim)); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+// newAuditSummary returns a summary with empty lists for the requested
+// dimensions, so they encode as [] rather than being omitted.
+func newAuditSummary(dimensions []AuditDimension) *AuditSummary {
+	summary := &AuditSummary{
+		TopRejectionReasons: []RejectionReasonStat{},
+		TopQueriedTables:    []TableQueryStat{},
+	}
+	for _, dim := range dimensions {
+		switch dim {
+		case DimensionUsers:
+			summary.TopUsers = []UserQueryStat{}
+		case DimensionEngines:
+			summary.TopEngines = []EngineQueryStat{}
+		}
+	}
+	return summary
+}
+
+// addTopN appends one ranked entry for dimension to the summary.
+func (s *AuditSummary) addTopN(dim AuditDimension, key string, count int) {
+	switch dim {
+	case DimensionRejectionReasons:
+		s.TopRejectionReasons = append(s.TopRejectionReasons, RejectionReasonStat{Reason: key, Count: count})
+	case DimensionTables:
+		s.TopQueriedTables = append(s.TopQueriedTables, TableQueryStat{Table: key, Count: count})
+	case DimensionUsers:
+		s.TopUsers = append(s.TopUsers, UserQueryStat{User: key, Count: count})
+	case DimensionEngines:
+		s.TopEngines = append(s.TopEngines, EngineQueryStat{Engine: key, Count: count})
+	}
+}
+
+// addTopNFromCounts ranks counts by count descending, then key, and adds
+// the first n to the summary.
+func (s *AuditSummary) addTopNFromCounts(dim AuditDimension, counts map[string]int, n int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	for _, key := range keys {
+		s.addTopN(dim, key, counts[key])
+	}
+}
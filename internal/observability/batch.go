@@ -0,0 +1,232 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxAuditBatchSize is the largest AuditBatch.Size. It keeps a batch's
+// INSERT within PostgreSQL's limit of 65535 bind parameters.
+const MaxAuditBatchSize = 1000
+
+// AuditBatch configures asynchronous, batched persistence of audit entries
+// (see PersistentLogger.SetAuditBatch). Entries are buffered and written by
+// a background flush with one multi-row INSERT, when Size entries are
+// buffered or Interval has passed since the last flush.
+type AuditBatch struct {
+	// Size is the number of buffered entries that triggers a flush, up to
+	// MaxAuditBatchSize.
+	Size int
+
+	// Interval is the longest an entry waits in the buffer before it is
+	// flushed.
+	Interval time.Duration
+
+	// MaxPending bounds the entries buffered while the database fails and
+	// there is no writer to fall back to. Once reached, LogQuery returns an
+	// error instead of buffering more. Zero defaults to 10 * Size.
+	MaxPending int
+}
+
+// auditBatcher buffers the audit rows of a PersistentLogger until they are
+// flushed.
+type auditBatcher struct {
+	cfg     AuditBatch
+	mu      sync.Mutex
+	pending []auditRow
+	closed  bool
+	flushMu sync.Mutex // serializes flushes
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// enqueue buffers a row, waking the background flush once Size rows are
+// buffered.
+func (b *auditBatcher) enqueue(row auditRow) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return fmt.Errorf("observability: audit logger is closed")
+	}
+	if len(b.pending) >= b.cfg.MaxPending {
+		return fmt.Errorf("observability: audit buffer is full: %d entries are waiting to be persisted", len(b.pending))
+	}
+	b.pending = append(b.pending, row)
+	if len(b.pending) >= b.cfg.Size {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// SetAuditBatch switches the logger to asynchronous, batched persistence:
+// LogQuery buffers entries and returns without waiting for the database.
+// Flush writes the buffered entries, and Close drains them on shutdown.
+//
+// When a batch fails to persist, its entries are written to the logger's
+// writer, if any, and counted by the metrics' ObserveAuditFailure. Without
+// a writer they stay buffered and are retried by the next flush, up to
+// AuditBatch.MaxPending, so entries are never dropped silently.
+func (l *PersistentLogger) SetAuditBatch(cfg AuditBatch) error {
+	if cfg.Size <= 0 || cfg.Size > MaxAuditBatchSize {
+		return fmt.Errorf("observability: audit batch size must be between 1 and %d", MaxAuditBatchSize)
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("observability: audit batch interval must be positive")
+	}
+	if cfg.MaxPending == 0 {
+		cfg.MaxPending = 10 * cfg.Size
+	}
+	if cfg.MaxPending < cfg.Size {
+		return fmt.Errorf("observability: audit batch max pending must be at least the batch size")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.batch != nil {
+		return fmt.Errorf("observability: audit batching is already enabled")
+	}
+	l.batch = &auditBatcher{
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go l.runAuditBatch(l.batch)
+	return nil
+}
+
+// SetMetrics sets the metrics counting slow queries and entries that
+// failed to persist.
+func (l *PersistentLogger) SetMetrics(m Metrics) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.metrics = m
+	l.slow.setMetrics(m)
+}
+
+// runAuditBatch flushes the buffer every Interval, or once it holds Size
+// entries, until the batcher is stopped.
+func (l *PersistentLogger) runAuditBatch(b *auditBatcher) {
+	defer close(b.done)
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		}
+		if err := l.Flush(context.Background()); err != nil {
+			Diagnosticf("observability: background audit flush failed: %v", err)
+		}
+	}
+}
+
+// Flush persists the buffered entries. It does nothing unless batching is
+// enabled with SetAuditBatch.
+func (l *PersistentLogger) Flush(ctx context.Context) error {
+	l.mu.RLock()
+	b, metrics := l.batch, l.metrics
+	l.mu.RUnlock()
+	if b == nil {
+		return nil
+	}
+
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	for {
+		b.mu.Lock()
+		n := len(b.pending)
+		if n > b.cfg.Size {
+			n = b.cfg.Size
+		}
+		rows := b.pending[:n:n]
+		b.pending = b.pending[n:]
+		b.mu.Unlock()
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if err := l.insertAuditRows(ctx, rows); err != nil {
+			if metrics != nil {
+				metrics.ObserveAuditFailure(len(rows))
+			}
+			if l.writer == nil {
+				b.mu.Lock()
+				b.pending = append(rows, b.pending...)
+				b.mu.Unlock()
+				return fmt.Errorf("observability: failed to persist %d audit logs, keeping them buffered: %w", len(rows), err)
+			}
+			for _, row := range rows {
+				l.writeEntry(row.entry)
+			}
+			return fmt.Errorf("observability: failed to persist %d audit logs, wrote them to the log writer: %w", len(rows), err)
+		}
+		for _, row := range rows {
+			l.writeEntry(row.entry)
+		}
+	}
+}
+
+// Close stops batching and flushes the buffered entries. It returns an
+// error if entries could not be persisted or written to the writer.
+func (l *PersistentLogger) Close() error {
+	l.mu.RLock()
+	b := l.batch
+	l.mu.RUnlock()
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.stop)
+	<-b.done
+
+	return l.Flush(context.Background())
+}
+
+// insertAuditRows persists rows in one transaction, with one multi-row
+// INSERT per set of columns written.
+func (l *PersistentLogger) insertAuditRows(ctx context.Context, rows []auditRow) error {
+	var order []string
+	groups := make(map[string][]auditRow)
+	for _, row := range rows {
+		key := strings.Join(row.columns, ",")
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, key := range order {
+		group := groups[key]
+		var args []interface{}
+		for _, row := range group {
+			args = append(args, row.args...)
+		}
+		query := insertAuditLogRowsQuery(group[0].columns, len(group), "")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,36 @@
+package observability
+
+import "sync"
+
+// DiagnosticLogger receives operational messages that are not tied to a
+// query's log entry, such as deprecation and partition warnings, engine
+// affinity changes, failed engine cancels, and audit or webhook delivery
+// failures. *log.Logger satisfies it.
+type DiagnosticLogger interface {
+	Printf(format string, args ...interface{})
+}
+
+var (
+	diagnosticsMu sync.RWMutex
+	diagnostics   DiagnosticLogger
+)
+
+// SetDiagnosticLogger routes the diagnostics of every package to l.
+// A nil l discards them, which is the default: library packages never write
+// to the process's standard logger on their own.
+func SetDiagnosticLogger(l DiagnosticLogger) {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	diagnostics = l
+}
+
+// Diagnosticf logs a diagnostic message to the DiagnosticLogger, if any.
+// Messages are prefixed with the package or component they come from.
+func Diagnosticf(format string, args ...interface{}) {
+	diagnosticsMu.RLock()
+	l := diagnostics
+	diagnosticsMu.RUnlock()
+	if l != nil {
+		l.Printf(format, args...)
+	}
+}
@@ -0,0 +1,217 @@
+package observability
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// AllTables is the SensitiveColumns key for columns that are sensitive in
+// every table.
+const AllTables = "*"
+
+// hashPrefix marks hashed values in logs.
+const hashPrefix = "sha256:"
+
+// ColumnHasher replaces literal values compared against sensitive columns with
+// a deterministic salted hash before they are logged.
+// Unlike masking, equal values produce equal hashes, so analysts can still
+// correlate log entries (e.g. all queries for the same customer) without
+// seeing the value itself.
+type ColumnHasher struct {
+	salt      []byte
+	sensitive map[string]map[string]bool // table -> lowercase column names
+	fallback  []*regexp.Regexp
+}
+
+// NewColumnHasher creates a hasher for the given sensitive columns, keyed by
+// table name (or AllTables). The salt is required: unsalted hashes of
+// low-cardinality values are trivially reversible.
+func NewColumnHasher(salt string, sensitiveColumns map[string][]string) (*ColumnHasher, error) {
+	if salt == "" {
+		return nil, fmt.Errorf("observability: sensitive column hashing requires a salt")
+	}
+
+	h := &ColumnHasher{
+		salt:      []byte(salt),
+		sensitive: make(map[string]map[string]bool),
+	}
+	seen := make(map[string]bool)
+	for table, columns := range sensitiveColumns {
+		set := make(map[string]bool, len(columns))
+		for _, col := range columns {
+			col = strings.ToLower(col)
+			set[col] = true
+			if !seen[col] {
+				seen[col] = true
+				h.fallback = append(h.fallback, regexp.MustCompile(
+					`(?i)\b`+regexp.QuoteMeta(col)+`\s*(=|<>|!=|<=|>=|<|>|\bLIKE\b)\s*('(?:[^']|'')*'|-?\d+(?:\.\d+)?)`))
+			}
+		}
+		h.sensitive[strings.ToLower(table)] = set
+	}
+	return h, nil
+}
+
+// Hash returns the deterministic salted hash of a value.
+func (h *ColumnHasher) Hash(value string) string {
+	mac := hmac.New(sha256.New, h.salt)
+	mac.Write([]byte(value))
+	return hashPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// Apply returns a copy of the entry with sensitive literals hashed in the SQL,
+// the engine SQL and the error message.
+func (h *ColumnHasher) Apply(entry QueryLogEntry) QueryLogEntry {
+	if len(entry.EngineSQL) > 0 {
+		engineSQL := make(map[string]string, len(entry.EngineSQL))
+		for engine, query := range entry.EngineSQL {
+			engineSQL[engine], _ = h.HashSQL(query)
+		}
+		entry.EngineSQL = engineSQL
+	}
+	if entry.SQL == "" {
+		return entry
+	}
+	hashed, values := h.HashSQL(entry.SQL)
+	entry.SQL = hashed
+	entry.Error = h.hashValuesIn(entry.Error, values)
+	return entry
+}
+
+// HashSQL hashes literals compared against sensitive columns in a query.
+// It returns the rewritten query and the original literal values that were
+// hashed. Queries that cannot be parsed are rewritten textually.
+func (h *ColumnHasher) HashSQL(query string) (string, []string) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return h.hashText(query)
+	}
+
+	tables := make(map[string]string)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if aliased, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			if name, ok := aliased.Expr.(sqlparser.TableName); ok {
+				table := tableName(name)
+				tables[table] = table
+				tables[strings.ToLower(name.Name.String())] = table
+				if !aliased.As.IsEmpty() {
+					tables[strings.ToLower(aliased.As.String())] = table
+				}
+			}
+		}
+		return true, nil
+	}, stmt)
+
+	var values []string
+	hashVal := func(expr sqlparser.Expr) {
+		switch v := expr.(type) {
+		case *sqlparser.SQLVal:
+			values = append(values, string(v.Val))
+			hashed := h.Hash(string(v.Val))
+			v.Type = sqlparser.StrVal
+			v.Val = []byte(hashed)
+		case sqlparser.ValTuple:
+			for _, item := range v {
+				if val, ok := item.(*sqlparser.SQLVal); ok {
+					values = append(values, string(val.Val))
+					val.Type = sqlparser.StrVal
+					val.Val = []byte(h.Hash(string(val.Val)))
+				}
+			}
+		}
+	}
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.ComparisonExpr:
+			if col, ok := n.Left.(*sqlparser.ColName); ok && h.isSensitive(col, tables) {
+				hashVal(n.Right)
+			} else if col, ok := n.Right.(*sqlparser.ColName); ok && h.isSensitive(col, tables) {
+				hashVal(n.Left)
+			}
+		case *sqlparser.RangeCond:
+			if col, ok := n.Left.(*sqlparser.ColName); ok && h.isSensitive(col, tables) {
+				hashVal(n.From)
+				hashVal(n.To)
+			}
+		}
+		return true, nil
+	}, stmt)
+
+	if len(values) == 0 {
+		return query, nil
+	}
+	return sqlparser.String(stmt), values
+}
+
+// isSensitive reports whether a column reference targets a sensitive column.
+// Unqualified columns are sensitive if any table in the query marks them so.
+func (h *ColumnHasher) isSensitive(col *sqlparser.ColName, tables map[string]string) bool {
+	name := strings.ToLower(col.Name.String())
+	if h.sensitive[AllTables][name] {
+		return true
+	}
+	if !col.Qualifier.IsEmpty() {
+		qualifier := tableName(col.Qualifier)
+		if table, ok := tables[qualifier]; ok {
+			return h.sensitive[table][name]
+		}
+		return h.sensitive[qualifier][name]
+	}
+	for _, table := range tables {
+		if h.sensitive[table][name] {
+			return true
+		}
+	}
+	return false
+}
+
+// hashText hashes literals following sensitive column names in unparseable text.
+func (h *ColumnHasher) hashText(query string) (string, []string) {
+	var values []string
+	for _, re := range h.fallback {
+		query = re.ReplaceAllStringFunc(query, func(match string) string {
+			sub := re.FindStringSubmatch(match)
+			literal := sub[2]
+			value := literal
+			if strings.HasPrefix(literal, "'") {
+				value = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+			}
+			values = append(values, value)
+			return strings.TrimSuffix(match, literal) + "'" + h.Hash(value) + "'"
+		})
+	}
+	return query, values
+}
+
+// hashValuesIn replaces quoted occurrences of hashed values in free text,
+// such as error messages that echo the query.
+func (h *ColumnHasher) hashValuesIn(text string, values []string) string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		hashed := h.Hash(value)
+		text = strings.ReplaceAll(text, "'"+value+"'", "'"+hashed+"'")
+		text = strings.ReplaceAll(text, `"`+value+`"`, `"`+hashed+`"`)
+	}
+	return text
+}
+
+// tableName formats a possibly qualified table name in lowercase.
+func tableName(tn sqlparser.TableName) string {
+	name := tn.Name.String()
+	if !tn.SchemaQualifier.IsEmpty() {
+		name = tn.SchemaQualifier.String() + "." + name
+	}
+	if !tn.DbQualifier.IsEmpty() {
+		name = tn.DbQualifier.String() + "." + name
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,166 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// IngestResult reports the outcome of merging shipped audit entries.
+type IngestResult struct {
+	// Ingested is the number of entries added to audit_logs.
+	Ingested int `json:"ingested"`
+
+	// Duplicates is the number of entries skipped because an entry with the
+	// same query_id and gateway_id was already recorded.
+	Duplicates int `json:"duplicates"`
+}
+
+// IngestNDJSON merges audit entries shipped from a sibling gateway into
+// audit_logs, so the audit summary covers the whole fleet. r holds one
+// JSON log record per line, in the format written by JSONLogger; slow_query
+// records are skipped.
+//
+// Entries are keyed by (query_id, gateway_id): a record's gateway_id takes
+// precedence, otherwise gatewayID is used, and one of the two is required.
+// Re-shipping entries is safe, since entries already recorded are counted as
+// duplicates. The batch is all-or-nothing: a malformed or invalid record
+// rejects the whole batch. Entries are recorded as LogQuery records them,
+// with the logger's ColumnHasher and Redactor applied, and keep the time
+// they were originally logged.
+func (l *PersistentLogger) IngestNDJSON(ctx context.Context, gatewayID string, r io.Reader) (*IngestResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("observability: context error: %w", err)
+	}
+
+	entries, err := parseNDJSON(r, gatewayID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("observability: failed to ingest audit logs: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &IngestResult{}
+	for _, entry := range entries {
+		row, err := l.auditRow(entry)
+		if err != nil {
+			return nil, fmt.Errorf("observability: failed to ingest audit log %s: %w", entry.QueryID, err)
+		}
+		query := insertAuditLogQuery(row.columns, "ON CONFLICT (query_id, gateway_id) DO NOTHING")
+
+		res, err := tx.ExecContext(ctx, query, row.args...)
+		if err != nil {
+			return nil, fmt.Errorf("observability: failed to ingest audit log %s: %w", entry.QueryID, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("observability: failed to ingest audit log %s: %w", entry.QueryID, err)
+		}
+		if n == 0 {
+			result.Duplicates++
+		} else {
+			result.Ingested++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("observability: failed to ingest audit logs: %w", err)
+	}
+	return result, nil
+}
+
+// parseNDJSON decodes and validates every record of r. Records are read as
+// a JSON stream, so they need not be newline-terminated.
+func parseNDJSON(r io.Reader, gatewayID string) ([]QueryLogEntry, error) {
+	var entries []QueryLogEntry
+
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var record struct {
+			jsonLogOutput
+			Event string `json:"event"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("observability: ndjson record %d: %w", n, err)
+		}
+		if record.Event == "slow_query" {
+			continue
+		}
+
+		entry, err := record.jsonLogOutput.toEntry(gatewayID)
+		if err != nil {
+			return nil, fmt.Errorf("observability: ndjson record %d: %w", n, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// entry converts a JSON log record back into the entry it was logged from.
+func (o jsonLogOutput) entry() QueryLogEntry {
+	return QueryLogEntry{
+		QueryID:               o.QueryID,
+		User:                  o.User,
+		Role:                  o.Role,
+		QueryPolicy:           o.QueryPolicy,
+		Tables:                o.Tables,
+		AuthorizationDecision: o.AuthorizationDecision,
+		PlannerDecision:       o.PlannerDecision,
+		Engine:                o.Engine,
+		ExecutionTime:         time.Duration(o.ExecutionTimeMs) * time.Millisecond,
+		Outcome:               o.Outcome,
+		Error:                 o.Error,
+		InvariantViolated:     o.InvariantViolated,
+		SQL:                   o.SQL,
+		Fingerprint:           o.Fingerprint,
+		GatewayID:             o.GatewayID,
+		EngineSQL:             o.EngineSQL,
+		Timestamp:             o.timestamp(),
+		SlowQuery:             o.SlowQuery,
+	}
+}
+
+// timestamp parses the record's timestamp; it is zero if missing or
+// invalid.
+func (o jsonLogOutput) timestamp() time.Time {
+	ts, err := time.Parse(time.RFC3339, o.Timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts.UTC()
+}
+
+// toEntry converts a JSON log record back into an audit entry for ingestion.
+// An entry without a timestamp is given the current time.
+func (o jsonLogOutput) toEntry(gatewayID string) (QueryLogEntry, error) {
+	entry := o.entry()
+	if entry.GatewayID == "" {
+		entry.GatewayID = gatewayID
+	}
+	if entry.Tables == nil {
+		entry.Tables = []string{}
+	}
+
+	if err := entry.Validate(); err != nil {
+		return entry, err
+	}
+	if entry.GatewayID == "" {
+		return entry, fmt.Errorf("observability: gateway_id is required for ingested entries")
+	}
+	if o.Timestamp != "" && entry.Timestamp.IsZero() {
+		return entry, fmt.Errorf("observability: invalid timestamp %q", o.Timestamp)
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	return entry, nil
+}
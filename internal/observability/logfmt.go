@@ -0,0 +1,141 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LogFormat is the output format of a stream logger.
+type LogFormat string
+
+const (
+	// LogFormatJSON writes one JSON object per record (the default).
+	LogFormatJSON LogFormat = "json"
+
+	// LogFormatLogfmt writes one line of key=value pairs per record.
+	LogFormatLogfmt LogFormat = "logfmt"
+)
+
+// ParseLogFormat parses a LogFormat. An empty value selects LogFormatJSON.
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch format := LogFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return LogFormatJSON, nil
+	case LogFormatJSON, LogFormatLogfmt:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid log format %q (valid: %s, %s)", s, LogFormatJSON, LogFormatLogfmt)
+	}
+}
+
+// NewQueryLogger creates a logger writing records in format to w.
+func NewQueryLogger(format LogFormat, w io.Writer) (QueryLogger, error) {
+	switch format {
+	case "", LogFormatJSON:
+		return NewJSONLogger(w), nil
+	case LogFormatLogfmt:
+		return NewLogfmtLogger(w), nil
+	default:
+		return nil, fmt.Errorf("observability: invalid log format %q (valid: %s, %s)", format, LogFormatJSON, LogFormatLogfmt)
+	}
+}
+
+// encode encodes a log record in the format.
+func (f LogFormat) encode(record interface{}) ([]byte, error) {
+	if f == LogFormatLogfmt {
+		return encodeLogfmt(record)
+	}
+	return json.Marshal(record)
+}
+
+// LogfmtLogger implements QueryLogger with logfmt output, e.g.
+//
+//	timestamp=2024-01-02T15:04:05Z level=info query_id=q-1 user=alice tables=sales.orders ...
+//
+// It logs the same fields as JSONLogger, under the same keys.
+type LogfmtLogger struct {
+	streamLogger
+}
+
+// NewLogfmtLogger creates a new logfmt logger writing to the given writer.
+func NewLogfmtLogger(w io.Writer) *LogfmtLogger {
+	return &LogfmtLogger{streamLogger: newStreamLogger(w, LogFormatLogfmt)}
+}
+
+// encodeLogfmt encodes a log record struct as logfmt, with keys and
+// omitempty handling taken from its json tags. String slices are joined with
+// commas and maps are written as JSON objects.
+func encodeLogfmt(record interface{}) ([]byte, error) {
+	v := reflect.ValueOf(record)
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("logfmt: unsupported record type %T", record)
+	}
+
+	var buf bytes.Buffer
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		field := v.Field(i)
+		if opts == "omitempty" && field.IsZero() {
+			continue
+		}
+
+		var value string
+		switch field.Kind() {
+		case reflect.String:
+			value = field.String()
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			value = strconv.FormatInt(field.Int(), 10)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				return nil, fmt.Errorf("logfmt: unsupported field type %s for %s", field.Type(), key)
+			}
+			items := make([]string, field.Len())
+			for j := range items {
+				items[j] = field.Index(j).String()
+			}
+			value = strings.Join(items, ",")
+		case reflect.Map:
+			// Maps are written as JSON objects, with keys in sorted order
+			data, err := json.Marshal(field.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("logfmt: unsupported field type %s for %s", field.Type(), key)
+			}
+			value = string(data)
+		default:
+			return nil, fmt.Errorf("logfmt: unsupported field type %s for %s", field.Type(), key)
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(value))
+	}
+	return buf.Bytes(), nil
+}
+
+// logfmtValue quotes a value if it is empty or contains spaces, '=', quotes,
+// backslashes, or non-printable characters.
+func logfmtValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
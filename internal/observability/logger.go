@@ -11,7 +11,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -32,6 +32,12 @@ type QueryLogEntry struct {
 	// Phase 4: Added for authorization logging.
 	Role string
 
+	// QueryPolicy names the role query policy that bounded the query's
+	// default LIMIT and timeout: the roles whose overrides applied, or
+	// "default" for the global defaults (see auth.ResolvedQueryPolicy.Name).
+	// PersistentLogger requires the query_policy column (migration 000010).
+	QueryPolicy string
+
 	// Tables are the virtual tables referenced in the query.
 	// May be empty for queries like "SELECT 1".
 	Tables []string
@@ -63,6 +69,35 @@ type QueryLogEntry struct {
 	// InvariantViolated indicates which invariant was violated (if any).
 	// Phase 4: "Silent failures are forbidden."
 	InvariantViolated string
+
+	// SQL is the query text, included in JSON log output. Literals compared
+	// against sensitive columns are hashed when a ColumnHasher is configured,
+	// and every literal is replaced by "?" when a Redactor is.
+	SQL string
+
+	// Fingerprint groups entries for queries that differ only in literal
+	// values or formatting (see sql.Parser.Fingerprint). It is included in
+	// JSON log output, and empty for queries that failed to parse.
+	Fingerprint string
+
+	// GatewayID identifies the gateway that served the query.
+	// Empty for entries logged by a gateway without an ID.
+	GatewayID string
+
+	// Timestamp is when the query was logged. LogQuery sets it to the
+	// current time when it is zero; audit summaries are windowed by it.
+	Timestamp time.Time
+
+	// SlowQuery marks a query whose execution time exceeded the logger's
+	// slow-query threshold; LogQuery sets it. Slow entries are logged at
+	// warn level and counted by audit summaries. PersistentLogger requires
+	// the slow_query column (migration 000011) to record them.
+	SlowQuery bool
+
+	// EngineSQL maps each sub-query sent to an engine, by ID, to its final
+	// SQL, after rewriting and decomposition. It is only logged by loggers with verbose
+	// audit enabled (SetVerboseAudit), since it can be large.
+	EngineSQL map[string]string
 }
 
 // Validate checks that all required fields are present.
@@ -88,6 +123,15 @@ type QueryLogger interface {
 	// GetAuditSummary returns aggregated audit statistics.
 	// Per phase-5-spec.md §4: "No raw data exposure"
 	GetAuditSummary() *AuditSummary
+
+	// GetAuditSummaryWithOptions returns aggregated audit statistics with
+	// top-N lists of the given length for the given dimensions.
+	GetAuditSummaryWithOptions(opts AuditSummaryOptions) (*AuditSummary, error)
+
+	// GetAuditSummaryRange returns the default audit summary of the entries
+	// logged from from (inclusive) to to (exclusive). A zero bound leaves
+	// that side of the window open.
+	GetAuditSummaryRange(ctx context.Context, from, to time.Time) (*AuditSummary, error)
 }
 
 // AuditSummary represents aggregated audit statistics.
@@ -95,8 +139,11 @@ type QueryLogger interface {
 type AuditSummary struct {
 	AcceptedCount       int                   `json:"accepted_count"`
 	RejectedCount       int                   `json:"rejected_count"`
+	SlowQueryCount      int                   `json:"slow_query_count"`
 	TopRejectionReasons []RejectionReasonStat `json:"top_rejection_reasons"`
 	TopQueriedTables    []TableQueryStat      `json:"top_queried_tables"`
+	TopUsers            []UserQueryStat       `json:"top_users,omitempty"`
+	TopEngines          []EngineQueryStat     `json:"top_engines,omitempty"`
 }
 
 // RejectionReasonStat represents rejection reason statistics.
@@ -111,64 +158,63 @@ type TableQueryStat struct {
 	Count int    `json:"count"`
 }
 
-// jsonLogOutput is the structured format for JSON logs.
-// Per phase-4-spec.md §5: Every request MUST log these fields.
-type jsonLogOutput struct {
-	Timestamp             string   `json:"timestamp"`
-	Level                 string   `json:"level"`
-	QueryID               string   `json:"query_id"`
-	User                  string   `json:"user"`
-	Role                  string   `json:"role,omitempty"`
-	Tables                []string `json:"tables"`
-	AuthorizationDecision string   `json:"authorization_decision,omitempty"`
-	PlannerDecision       string   `json:"planner_decision,omitempty"`
-	Engine                string   `json:"engine"`
-	ExecutionTimeMs       int64    `json:"execution_time_ms"`
-	Outcome               string   `json:"outcome,omitempty"`
-	Error                 string   `json:"error,omitempty"`
-	InvariantViolated     string   `json:"invariant_violated,omitempty"`
+// UserQueryStat represents per-user query statistics.
+type UserQueryStat struct {
+	User  string `json:"user"`
+	Count int    `json:"count"`
 }
 
-// JSONLogger implements QueryLogger with JSON output.
-type JSONLogger struct {
-	writer  io.Writer
-	entries []QueryLogEntry // Track entries for audit summary
-	mu      sync.RWMutex
+// EngineQueryStat represents per-engine query statistics.
+type EngineQueryStat struct {
+	Engine string `json:"engine"`
+	Count  int    `json:"count"`
 }
 
-// NewJSONLogger creates a new JSON logger writing to the given writer.
-func NewJSONLogger(w io.Writer) *JSONLogger {
-	return &JSONLogger{
-		writer:  w,
-		entries: make([]QueryLogEntry, 0),
-	}
+// jsonLogOutput is the structured format for JSON logs.
+// Per phase-4-spec.md §5: Every request MUST log these fields.
+type jsonLogOutput struct {
+	Timestamp             string            `json:"timestamp"`
+	Level                 string            `json:"level"`
+	QueryID               string            `json:"query_id"`
+	User                  string            `json:"user"`
+	Role                  string            `json:"role,omitempty"`
+	QueryPolicy           string            `json:"query_policy,omitempty"`
+	Tables                []string          `json:"tables"`
+	AuthorizationDecision string            `json:"authorization_decision,omitempty"`
+	PlannerDecision       string            `json:"planner_decision,omitempty"`
+	Engine                string            `json:"engine"`
+	ExecutionTimeMs       int64             `json:"execution_time_ms"`
+	Outcome               string            `json:"outcome,omitempty"`
+	Error                 string            `json:"error,omitempty"`
+	InvariantViolated     string            `json:"invariant_violated,omitempty"`
+	SQL                   string            `json:"sql,omitempty"`
+	Fingerprint           string            `json:"fingerprint,omitempty"`
+	GatewayID             string            `json:"gateway_id,omitempty"`
+	SlowQuery             bool              `json:"slow_query,omitempty"`
+	EngineSQL             map[string]string `json:"engine_sql,omitempty"`
 }
 
-// LogQuery logs a query execution event as JSON.
-func (l *JSONLogger) LogQuery(ctx context.Context, entry QueryLogEntry) error {
-	// Check context first
-	if err := ctx.Err(); err != nil {
-		return fmt.Errorf("observability: context error: %w", err)
-	}
-
-	// Validate entry
-	if err := entry.Validate(); err != nil {
-		return err
-	}
-
-	// Determine log level
+// newLogOutput builds the log record for an entry.
+func newLogOutput(entry QueryLogEntry) jsonLogOutput {
 	level := "info"
 	if entry.Error != "" {
 		level = "error"
+	} else if entry.SlowQuery {
+		level = "warn"
+	}
+
+	timestamp := entry.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
 	}
 
-	// Build output
 	output := jsonLogOutput{
-		Timestamp:             time.Now().UTC().Format(time.RFC3339),
+		Timestamp:             timestamp.UTC().Format(time.RFC3339),
 		Level:                 level,
 		QueryID:               entry.QueryID,
 		User:                  entry.User,
 		Role:                  entry.Role,
+		QueryPolicy:           entry.QueryPolicy,
 		Tables:                entry.Tables,
 		AuthorizationDecision: entry.AuthorizationDecision,
 		PlannerDecision:       entry.PlannerDecision,
@@ -177,18 +223,188 @@ func (l *JSONLogger) LogQuery(ctx context.Context, entry QueryLogEntry) error {
 		Outcome:               entry.Outcome,
 		Error:                 entry.Error,
 		InvariantViolated:     entry.InvariantViolated,
+		SQL:                   entry.SQL,
+		Fingerprint:           entry.Fingerprint,
+		GatewayID:             entry.GatewayID,
+		SlowQuery:             entry.SlowQuery,
+		EngineSQL:             entry.EngineSQL,
 	}
 
 	// Ensure tables is never nil in JSON
 	if output.Tables == nil {
 		output.Tables = []string{}
 	}
+	return output
+}
+
+// JSONLogger implements QueryLogger with JSON output.
+type JSONLogger struct {
+	streamLogger
+}
+
+// NewJSONLogger creates a new JSON logger writing to the given writer.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{streamLogger: newStreamLogger(w, LogFormatJSON)}
+}
+
+// streamLogger writes every entry to a writer as a record in its format and
+// tracks entries for the audit summary. JSONLogger and LogfmtLogger share it,
+// so they log the same fields with the same validation.
+type streamLogger struct {
+	writer     io.Writer
+	format     LogFormat
+	entries    []QueryLogEntry // Track entries for audit summary
+	maxEntries int             // optional: bounds entries
+	overflow   *overflowFile   // optional: receives entries evicted from entries
+	hasher     *ColumnHasher   // optional: hashes sensitive literals
+	redactor   *Redactor       // optional: redacts all literals
+	gateway    string          // optional: stamped on every entry
+	verbose    bool            // optional: logs EngineSQL
+	slow       slowQueryDetector
+	mu         sync.RWMutex
+}
+
+// newStreamLogger creates a stream logger writing records in format to w.
+func newStreamLogger(w io.Writer, format LogFormat) streamLogger {
+	return streamLogger{
+		writer:  w,
+		format:  format,
+		entries: make([]QueryLogEntry, 0),
+	}
+}
+
+// SetColumnHasher enables hashing of sensitive column values in logged SQL.
+func (l *streamLogger) SetColumnHasher(h *ColumnHasher) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hasher = h
+}
+
+// SetRedactor enables redaction of the literal values of logged SQL and
+// error messages.
+func (l *streamLogger) SetRedactor(r *Redactor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redactor = r
+}
+
+// SetGatewayID stamps every logged entry with the gateway's ID, so its NDJSON
+// output can be shipped to sibling gateways (see PersistentLogger.IngestNDJSON).
+func (l *streamLogger) SetGatewayID(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gateway = id
+}
+
+// SetVerboseAudit enables logging of the engine SQL of each entry
+// (QueryLogEntry.EngineSQL). It is disabled by default.
+func (l *streamLogger) SetVerboseAudit(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.verbose = enabled
+}
+
+// SetMaxEntries bounds the number of entries kept in memory for the audit
+// summary. Once the buffer is full, logging an entry evicts the oldest one:
+// it is written to the overflow file if SetAuditOverflow configured one, and
+// dropped otherwise. Zero keeps every entry.
+func (l *streamLogger) SetMaxEntries(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	l.maxEntries = n
+}
 
-	// Encode as JSON
-	data, err := json.Marshal(output)
+// SetAuditOverflow persists entries evicted by SetMaxEntries to a rotating
+// JSONL file. It replaces (and closes) any previously configured file.
+func (l *streamLogger) SetAuditOverflow(cfg AuditOverflow) error {
+	overflow, err := openOverflowFile(cfg)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.overflow != nil {
+		l.overflow.close()
+	}
+	l.overflow = overflow
+	return nil
+}
+
+// Close closes the audit overflow file, if any.
+func (l *streamLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.overflow == nil {
+		return nil
+	}
+	err := l.overflow.close()
+	l.overflow = nil
+	return err
+}
+
+// SetSlowQueryThreshold enables slow-query reporting: queries whose execution
+// time exceeds threshold are logged at warn level with slow_query set, get an
+// additional warn-level "slow_query" record and trigger hook (which may be
+// nil). A zero threshold disables reporting.
+func (l *streamLogger) SetSlowQueryThreshold(threshold time.Duration, hook SlowQueryHook) {
+	l.slow.set(threshold, hook)
+}
+
+// SetMetrics sets the metrics counting slow queries.
+func (l *streamLogger) SetMetrics(m Metrics) {
+	l.slow.setMetrics(m)
+}
+
+// LogQuery logs a query execution event in the logger's format.
+func (l *streamLogger) LogQuery(ctx context.Context, entry QueryLogEntry) error {
+	// Check context first
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("observability: context error: %w", err)
+	}
+
+	// Validate entry
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	entry.SlowQuery = entry.SlowQuery || l.slow.exceeds(entry)
+
+	// Hash sensitive literals before anything is written or retained
+	l.mu.RLock()
+	hasher := l.hasher
+	redactor := l.redactor
+	gateway := l.gateway
+	verbose := l.verbose
+	l.mu.RUnlock()
+	if !verbose {
+		entry.EngineSQL = nil
+	}
+	if hasher != nil {
+		entry = hasher.Apply(entry)
+	}
+	if redactor != nil {
+		entry = redactor.Apply(entry)
+	}
+	if entry.GatewayID == "" {
+		entry.GatewayID = gateway
+	}
+
+	// Encode the record
+	data, err := l.format.encode(newLogOutput(entry))
 	if err != nil {
 		return fmt.Errorf("observability: failed to marshal log: %w", err)
 	}
+	// logfmt is line-oriented; JSON records are self-delimiting
+	if l.format == LogFormatLogfmt {
+		data = append(data, '\n')
+	}
 
 	// Write to output
 	_, err = l.writer.Write(data)
@@ -197,69 +413,106 @@ func (l *JSONLogger) LogQuery(ctx context.Context, entry QueryLogEntry) error {
 	}
 
 	// Track entry for audit summary
+	if err := l.track(entry); err != nil {
+		return err
+	}
+
+	return l.slow.report(ctx, l.writer, l.format, entry)
+}
+
+// track adds an entry to the audit buffer, evicting the oldest entries
+// beyond maxEntries to the overflow file.
+func (l *streamLogger) track(entry QueryLogEntry) error {
 	l.mu.Lock()
-	l.entries = append(l.entries, entry)
-	l.mu.Unlock()
+	defer l.mu.Unlock()
 
+	l.entries = append(l.entries, entry)
+	for l.maxEntries > 0 && len(l.entries) > l.maxEntries {
+		if l.overflow != nil {
+			if err := l.overflow.write(l.entries[0]); err != nil {
+				return err
+			}
+		}
+		l.entries = l.entries[1:]
+	}
 	return nil
 }
 
 // GetAuditSummary returns aggregated audit statistics.
 // Per phase-5-spec.md §4: "No raw data exposure"
-func (l *JSONLogger) GetAuditSummary() *AuditSummary {
+func (l *streamLogger) GetAuditSummary() *AuditSummary {
+	summary, _ := l.GetAuditSummaryWithOptions(AuditSummaryOptions{})
+	return summary
+}
+
+// GetAuditSummaryWithOptions returns aggregated audit statistics with the
+// requested top-N lists. Entries in the overflow files are included when the
+// overflow is configured with IncludeInSummary; if they cannot be read, the
+// summary of the in-memory entries is returned with the error.
+func (l *streamLogger) GetAuditSummaryWithOptions(opts AuditSummaryOptions) (*AuditSummary, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	summary := &AuditSummary{
-		TopRejectionReasons: []RejectionReasonStat{},
-		TopQueriedTables:    []TableQueryStat{},
+	entries := l.entries
+	var overflowErr error
+	if l.overflow != nil && l.overflow.cfg.IncludeInSummary {
+		flushed, err := l.overflow.entries()
+		if err != nil {
+			overflowErr = err
+		} else {
+			entries = append(flushed, l.entries...)
+		}
 	}
 
-	rejectionReasons := make(map[string]int)
-	tableCounts := make(map[string]int)
+	summary := newAuditSummary(opts.Dimensions)
+	counts := map[AuditDimension]map[string]int{
+		DimensionRejectionReasons: make(map[string]int),
+		DimensionTables:           make(map[string]int),
+		DimensionUsers:            make(map[string]int),
+		DimensionEngines:          make(map[string]int),
+	}
 
-	for _, entry := range l.entries {
+	for _, entry := range entries {
+		if !opts.inWindow(entry.Timestamp) {
+			continue
+		}
 		if entry.Error == "" {
 			summary.AcceptedCount++
 		} else {
 			summary.RejectedCount++
-			rejectionReasons[entry.Error]++
+			counts[DimensionRejectionReasons][entry.Error]++
+		}
+		if entry.SlowQuery {
+			summary.SlowQueryCount++
 		}
 
 		for _, table := range entry.Tables {
-			tableCounts[table]++
+			counts[DimensionTables][table]++
+		}
+		counts[DimensionUsers][entry.User]++
+		if entry.Engine != "" {
+			counts[DimensionEngines][entry.Engine]++
 		}
 	}
 
-	// Build top rejection reasons
-	for reason, count := range rejectionReasons {
-		summary.TopRejectionReasons = append(summary.TopRejectionReasons, RejectionReasonStat{
-			Reason: reason,
-			Count:  count,
-		})
-	}
-	sort.Slice(summary.TopRejectionReasons, func(i, j int) bool {
-		return summary.TopRejectionReasons[i].Count > summary.TopRejectionReasons[j].Count
-	})
-	if len(summary.TopRejectionReasons) > 5 {
-		summary.TopRejectionReasons = summary.TopRejectionReasons[:5]
+	for _, dim := range opts.Dimensions {
+		summary.addTopNFromCounts(dim, counts[dim], opts.TopN)
 	}
+	return summary, overflowErr
+}
 
-	// Build top queried tables
-	for table, count := range tableCounts {
-		summary.TopQueriedTables = append(summary.TopQueriedTables, TableQueryStat{
-			Table: table,
-			Count: count,
-		})
-	}
-	sort.Slice(summary.TopQueriedTables, func(i, j int) bool {
-		return summary.TopQueriedTables[i].Count > summary.TopQueriedTables[j].Count
-	})
-	if len(summary.TopQueriedTables) > 5 {
-		summary.TopQueriedTables = summary.TopQueriedTables[:5]
+// GetAuditSummaryRange returns the audit summary of the entries logged in
+// [from, to). See GetAuditSummaryWithOptions.
+func (l *streamLogger) GetAuditSummaryRange(ctx context.Context, from, to time.Time) (*AuditSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("observability: context error: %w", err)
 	}
-
-	return summary
+	return l.GetAuditSummaryWithOptions(AuditSummaryOptions{Since: from, Until: to})
 }
 
 // NoopLogger is a logger that discards all logs.
@@ -284,13 +537,35 @@ func (l *NoopLogger) GetAuditSummary() *AuditSummary {
 	}
 }
 
+// GetAuditSummaryWithOptions returns an empty summary for the no-op logger.
+func (l *NoopLogger) GetAuditSummaryWithOptions(opts AuditSummaryOptions) (*AuditSummary, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return newAuditSummary(opts.Dimensions), nil
+}
+
+// GetAuditSummaryRange returns an empty summary.
+func (l *NoopLogger) GetAuditSummaryRange(ctx context.Context, from, to time.Time) (*AuditSummary, error) {
+	return l.GetAuditSummaryWithOptions(AuditSummaryOptions{Since: from, Until: to})
+}
+
 // PersistentLogger implements QueryLogger with PostgreSQL persistence.
 // Per T030: Audit logs must be persisted to PostgreSQL.
 // Per phase-4-spec.md §5: Every request MUST log these fields.
 type PersistentLogger struct {
-	db     *sql.DB
-	mu     sync.RWMutex
-	writer io.Writer // optional: also write to stdout for debugging
+	db       *sql.DB
+	mu       sync.RWMutex
+	writer   io.Writer     // optional: also write to stdout for debugging
+	hasher   *ColumnHasher // optional: hashes sensitive literals
+	redactor *Redactor     // optional: redacts all literals
+	gateway  string        // optional: recorded as gateway_id
+	verbose  bool          // optional: records engine_sql
+	replica  *sql.DB       // optional: serves audit summaries
+	metrics  Metrics       // optional: counts entries failing to persist
+	batch    *auditBatcher // optional: persists entries asynchronously
+	slow     slowQueryDetector
 }
 
 // NewPersistentLogger creates a logger that persists audit entries to PostgreSQL.
@@ -315,6 +590,67 @@ func NewPersistentLoggerWithWriter(db *sql.DB, w io.Writer) (*PersistentLogger,
 	}, nil
 }
 
+// SetColumnHasher enables hashing of sensitive column values in logged SQL.
+func (l *PersistentLogger) SetColumnHasher(h *ColumnHasher) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hasher = h
+}
+
+// SetRedactor enables redaction of the literal values of persisted error
+// messages and engine SQL.
+func (l *PersistentLogger) SetRedactor(r *Redactor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redactor = r
+}
+
+// SetGatewayID records the gateway's ID with every persisted entry, keeping
+// its entries distinct from those ingested from sibling gateways.
+// Requires the gateway_id column (migration 000007).
+func (l *PersistentLogger) SetGatewayID(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gateway = id
+}
+
+// SetVerboseAudit enables persisting the engine SQL of each entry
+// (QueryLogEntry.EngineSQL) as JSON. Requires the engine_sql column
+// (migration 000009).
+func (l *PersistentLogger) SetVerboseAudit(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.verbose = enabled
+}
+
+// SetReadReplica serves audit summaries from a read replica of the
+// database, so they do not compete with audit writes. Entries are still
+// written to the primary. A replica lags the primary, so a summary may not
+// yet count the most recently logged entries; set
+// AuditSummaryOptions.Consistent to read from the primary instead.
+func (l *PersistentLogger) SetReadReplica(replica *sql.DB) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.replica = replica
+}
+
+// summaryDB returns the connection audit summaries are read from.
+func (l *PersistentLogger) summaryDB(opts AuditSummaryOptions) *sql.DB {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.replica == nil || opts.Consistent {
+		return l.db
+	}
+	return l.replica
+}
+
+// SetSlowQueryThreshold enables slow-query reporting. Slow entries are
+// recorded in the slow_query column (migration 000011).
+// See JSONLogger.SetSlowQueryThreshold.
+func (l *PersistentLogger) SetSlowQueryThreshold(threshold time.Duration, hook SlowQueryHook) {
+	l.slow.set(threshold, hook)
+}
+
 // LogQuery persists a query log entry to PostgreSQL.
 // Per T030: Audit entries must be written to audit_logs table.
 func (l *PersistentLogger) LogQuery(ctx context.Context, entry QueryLogEntry) error {
@@ -328,22 +664,84 @@ func (l *PersistentLogger) LogQuery(ctx context.Context, entry QueryLogEntry) er
 		return err
 	}
 
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	entry.SlowQuery = entry.SlowQuery || l.slow.exceeds(entry)
+	row, err := l.auditRow(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.RLock()
+	batch := l.batch
+	l.mu.RUnlock()
+	if batch != nil {
+		if err := batch.enqueue(row); err != nil {
+			return err
+		}
+		return l.slow.report(ctx, l.writer, LogFormatJSON, row.entry)
+	}
+
+	query := insertAuditLogQuery(row.columns, "")
+	_, err = l.db.ExecContext(ctx, query, row.args...)
+	if err != nil {
+		return fmt.Errorf("observability: failed to persist audit log: %w", err)
+	}
+
+	// Also write to optional writer (for debugging)
+	l.writeEntry(row.entry)
+
+	return l.slow.report(ctx, l.writer, LogFormatJSON, row.entry)
+}
+
+// auditRow is an entry as persisted: the audit_logs columns written and
+// their values.
+type auditRow struct {
+	entry   QueryLogEntry
+	columns []string
+	args    []interface{}
+}
+
+// auditRow prepares an entry for persistence, hashing its sensitive
+// literals and recording the gateway's ID.
+func (l *PersistentLogger) auditRow(entry QueryLogEntry) (auditRow, error) {
+	// Hash sensitive literals before the error message is persisted
+	l.mu.RLock()
+	hasher := l.hasher
+	redactor := l.redactor
+	gateway := l.gateway
+	verbose := l.verbose
+	l.mu.RUnlock()
+	if !verbose {
+		entry.EngineSQL = nil
+	}
+	if hasher != nil {
+		entry = hasher.Apply(entry)
+	}
+	if redactor != nil {
+		entry = redactor.Apply(entry)
+	}
+	if entry.GatewayID == "" {
+		entry.GatewayID = gateway
+	}
+
 	// Convert tables to JSON
 	tablesJSON, err := json.Marshal(entry.Tables)
 	if err != nil {
 		tablesJSON = []byte("[]")
 	}
 
-	// Insert into audit_logs
-	query := `
-		INSERT INTO audit_logs (
-			query_id, user_id, role, tables_json, auth_decision,
-			planner_decision, engine, execution_time_ms, outcome,
-			error_message, invariant_violated
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`
-
-	_, err = l.db.ExecContext(ctx, query,
+	// Insert into audit_logs; gateway_id, engine_sql and query_policy are
+	// only written when set, so older schemas keep working without them.
+	// created_at records the log time rather than the time of a batched
+	// insert.
+	columns := []string{
+		"query_id", "user_id", "role", "tables_json", "auth_decision",
+		"planner_decision", "engine", "execution_time_ms", "outcome",
+		"error_message", "invariant_violated", "created_at",
+	}
+	args := []interface{}{
 		entry.QueryID,
 		entry.User,
 		nullableString(entry.Role),
@@ -355,110 +753,216 @@ func (l *PersistentLogger) LogQuery(ctx context.Context, entry QueryLogEntry) er
 		nullableString(entry.Outcome),
 		nullableString(entry.Error),
 		nullableString(entry.InvariantViolated),
-	)
-	if err != nil {
-		return fmt.Errorf("observability: failed to persist audit log: %w", err)
+		entry.Timestamp.UTC(),
 	}
-
-	// Also write to optional writer (for debugging)
-	if l.writer != nil {
-		level := "info"
-		if entry.Error != "" {
-			level = "error"
-		}
-		output := jsonLogOutput{
-			Timestamp:             time.Now().UTC().Format(time.RFC3339),
-			Level:                 level,
-			QueryID:               entry.QueryID,
-			User:                  entry.User,
-			Role:                  entry.Role,
-			Tables:                entry.Tables,
-			AuthorizationDecision: entry.AuthorizationDecision,
-			PlannerDecision:       entry.PlannerDecision,
-			Engine:                entry.Engine,
-			ExecutionTimeMs:       entry.ExecutionTime.Milliseconds(),
-			Outcome:               entry.Outcome,
-			Error:                 entry.Error,
-			InvariantViolated:     entry.InvariantViolated,
-		}
-		if data, err := json.Marshal(output); err == nil {
-			l.writer.Write(data)
-			l.writer.Write([]byte("\n"))
+	if entry.GatewayID != "" {
+		columns = append(columns, "gateway_id")
+		args = append(args, entry.GatewayID)
+	}
+	if len(entry.EngineSQL) > 0 {
+		engineSQLJSON, err := json.Marshal(entry.EngineSQL)
+		if err != nil {
+			return auditRow{}, fmt.Errorf("observability: failed to marshal engine SQL: %w", err)
 		}
+		columns = append(columns, "engine_sql")
+		args = append(args, engineSQLJSON)
 	}
+	if entry.QueryPolicy != "" {
+		columns = append(columns, "query_policy")
+		args = append(args, entry.QueryPolicy)
+	}
+	if entry.SlowQuery {
+		columns = append(columns, "slow_query")
+		args = append(args, true)
+	}
+	return auditRow{entry: entry, columns: columns, args: args}, nil
+}
 
-	return nil
+// writeEntry writes an entry to the optional writer as a JSON log record.
+func (l *PersistentLogger) writeEntry(entry QueryLogEntry) {
+	if l.writer == nil {
+		return
+	}
+	output := newLogOutput(entry)
+	if data, err := json.Marshal(output); err == nil {
+		l.writer.Write(data)
+		l.writer.Write([]byte("\n"))
+	}
+}
+
+// insertAuditLogQuery builds an INSERT into audit_logs of the columns, with
+// an optional trailing clause such as ON CONFLICT.
+func insertAuditLogQuery(columns []string, clause string) string {
+	return insertAuditLogRowsQuery(columns, 1, clause)
+}
+
+// insertAuditLogRowsQuery builds an INSERT into audit_logs of rows rows of
+// the columns, with an optional trailing clause.
+func insertAuditLogRowsQuery(columns []string, rows int, clause string) string {
+	values := make([]string, rows)
+	placeholders := make([]string, len(columns))
+	for r := range values {
+		for i := range columns {
+			placeholders[i] = fmt.Sprintf("$%d", r*len(columns)+i+1)
+		}
+		values[r] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	query := fmt.Sprintf("INSERT INTO audit_logs (%s) VALUES %s",
+		strings.Join(columns, ", "), strings.Join(values, ", "))
+	if clause != "" {
+		query += " " + clause
+	}
+	return query
 }
 
 // GetAuditSummary returns aggregated audit statistics from the database.
 // Per phase-5-spec.md §4: "No raw data exposure"
 // Per T030: Summary must be retrieved from persisted data.
+// Query failures leave the affected fields empty; use
+// GetAuditSummaryWithOptions to see them.
 func (l *PersistentLogger) GetAuditSummary() *AuditSummary {
-	summary := &AuditSummary{
-		TopRejectionReasons: []RejectionReasonStat{},
-		TopQueriedTables:    []TableQueryStat{},
-	}
-
-	ctx := context.Background()
+	summary, _ := l.auditSummary(context.Background(), AuditSummaryOptions{
+		TopN:       DefaultAuditTopN,
+		Dimensions: DefaultAuditDimensions,
+	})
+	return summary
+}
 
-	// Get accepted count
-	row := l.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM audit_logs WHERE error_message IS NULL OR error_message = ''
-	`)
-	row.Scan(&summary.AcceptedCount)
+// GetAuditSummaryWithOptions returns aggregated audit statistics from the
+// database with the requested top-N lists.
+func (l *PersistentLogger) GetAuditSummaryWithOptions(opts AuditSummaryOptions) (*AuditSummary, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := l.auditSummary(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
 
-	// Get rejected count
-	row = l.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM audit_logs WHERE error_message IS NOT NULL AND error_message != ''
-	`)
-	row.Scan(&summary.RejectedCount)
+// GetAuditSummaryRange returns the audit summary of the entries created in
+// [from, to), reading the created_at column of audit_logs.
+func (l *PersistentLogger) GetAuditSummaryRange(ctx context.Context, from, to time.Time) (*AuditSummary, error) {
+	opts, err := AuditSummaryOptions{Since: from, Until: to}.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return l.auditSummary(ctx, opts)
+}
 
-	// Get top rejection reasons
-	rows, err := l.db.QueryContext(ctx, `
+// auditTopNQueries are the queries ranking each dimension; $1 is N, and %s
+// is replaced by the window conditions (see auditWindow).
+var auditTopNQueries = map[AuditDimension]string{
+	DimensionRejectionReasons: `
 		SELECT error_message, COUNT(*) as cnt
 		FROM audit_logs
-		WHERE error_message IS NOT NULL AND error_message != ''
+		WHERE error_message IS NOT NULL AND error_message != ''%s
 		GROUP BY error_message
-		ORDER BY cnt DESC
-		LIMIT 5
-	`)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var reason string
-			var count int
-			if rows.Scan(&reason, &count) == nil {
-				summary.TopRejectionReasons = append(summary.TopRejectionReasons, RejectionReasonStat{
-					Reason: reason,
-					Count:  count,
-				})
-			}
-		}
-	}
-
-	// Get top queried tables
-	rows, err = l.db.QueryContext(ctx, `
+		ORDER BY cnt DESC, error_message
+		LIMIT $1
+	`,
+	DimensionTables: `
 		SELECT table_name, COUNT(*) as cnt
 		FROM audit_logs, jsonb_array_elements_text(tables_json) as table_name
+		WHERE TRUE%s
 		GROUP BY table_name
-		ORDER BY cnt DESC
-		LIMIT 5
-	`)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var table string
-			var count int
-			if rows.Scan(&table, &count) == nil {
-				summary.TopQueriedTables = append(summary.TopQueriedTables, TableQueryStat{
-					Table: table,
-					Count: count,
-				})
-			}
+		ORDER BY cnt DESC, table_name
+		LIMIT $1
+	`,
+	DimensionUsers: `
+		SELECT user_id, COUNT(*) as cnt
+		FROM audit_logs
+		WHERE TRUE%s
+		GROUP BY user_id
+		ORDER BY cnt DESC, user_id
+		LIMIT $1
+	`,
+	DimensionEngines: `
+		SELECT engine, COUNT(*) as cnt
+		FROM audit_logs
+		WHERE engine IS NOT NULL AND engine != ''%s
+		GROUP BY engine
+		ORDER BY cnt DESC, engine
+		LIMIT $1
+	`,
+}
+
+// auditSummary aggregates the summary for normalized options. It always
+// returns a summary, holding what was aggregated before the first error.
+func (l *PersistentLogger) auditSummary(ctx context.Context, opts AuditSummaryOptions) (*AuditSummary, error) {
+	summary := newAuditSummary(opts.Dimensions)
+	db := l.summaryDB(opts)
+
+	// Get accepted count
+	window, args := auditWindow(opts, 1)
+	row := db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM audit_logs WHERE (error_message IS NULL OR error_message = '')%s
+	`, window), args...)
+	if err := row.Scan(&summary.AcceptedCount); err != nil {
+		return summary, fmt.Errorf("observability: failed to count accepted queries: %w", err)
+	}
+
+	// Get rejected count
+	row = db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM audit_logs WHERE error_message IS NOT NULL AND error_message != ''%s
+	`, window), args...)
+	if err := row.Scan(&summary.RejectedCount); err != nil {
+		return summary, fmt.Errorf("observability: failed to count rejected queries: %w", err)
+	}
+
+	// Get slow query count
+	row = db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM audit_logs WHERE slow_query = TRUE%s
+	`, window), args...)
+	if err := row.Scan(&summary.SlowQueryCount); err != nil {
+		return summary, fmt.Errorf("observability: failed to count slow queries: %w", err)
+	}
+
+	for _, dim := range opts.Dimensions {
+		if err := addTopN(ctx, db, summary, dim, opts); err != nil {
+			return summary, err
 		}
 	}
+	return summary, nil
+}
 
-	return summary
+// auditWindow returns the conditions on created_at selecting the window of
+// opts, each preceded by AND, with their arguments numbered from first.
+func auditWindow(opts AuditSummaryOptions, first int) (string, []interface{}) {
+	var conditions strings.Builder
+	var args []interface{}
+	if !opts.Since.IsZero() {
+		args = append(args, opts.Since.UTC())
+		fmt.Fprintf(&conditions, " AND created_at >= $%d", first+len(args)-1)
+	}
+	if !opts.Until.IsZero() {
+		args = append(args, opts.Until.UTC())
+		fmt.Fprintf(&conditions, " AND created_at < $%d", first+len(args)-1)
+	}
+	return conditions.String(), args
+}
+
+// addTopN runs the ranking query for one dimension.
+func addTopN(ctx context.Context, db *sql.DB, summary *AuditSummary, dim AuditDimension, opts AuditSummaryOptions) error {
+	window, args := auditWindow(opts, 2)
+	query := fmt.Sprintf(auditTopNQueries[dim], window)
+	rows, err := db.QueryContext(ctx, query, append([]interface{}{opts.TopN}, args...)...)
+	if err != nil {
+		return fmt.Errorf("observability: failed to rank %s: %w", dim, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return fmt.Errorf("observability: failed to rank %s: %w", dim, err)
+		}
+		summary.addTopN(dim, key, count)
+	}
+	return rows.Err()
 }
 
 // nullableString converts empty strings to nil for SQL NULL.
@@ -0,0 +1,288 @@
+package observability
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// Metrics records query metrics. The gateway observes every query it logs,
+// and the federated executor the latency of each sub-query it runs. Tests
+// inject a fake to assert what was recorded.
+type Metrics interface {
+	// ObserveQuery records a query: its outcome, engine and execution time
+	// from entry, and for a failed query the code of err (see
+	// errors.CodeOf).
+	ObserveQuery(entry QueryLogEntry, err error)
+
+	// ObserveSubQuery records how long a sub-query of a federated query
+	// took on engine.
+	ObserveSubQuery(engine string, latency time.Duration)
+
+	// ObserveAuditFailure records audit entries that failed to persist to
+	// the database (see PersistentLogger.SetAuditBatch).
+	ObserveAuditFailure(entries int)
+
+	// ObserveSlowQuery records a query whose execution time exceeded the
+	// logger's slow-query threshold (see JSONLogger.SetSlowQueryThreshold).
+	ObserveSlowQuery(entry QueryLogEntry)
+}
+
+// NoopMetrics discards all metrics.
+type NoopMetrics struct{}
+
+// NewNoopMetrics creates metrics that record nothing.
+func NewNoopMetrics() *NoopMetrics {
+	return &NoopMetrics{}
+}
+
+// ObserveQuery does nothing.
+func (m *NoopMetrics) ObserveQuery(entry QueryLogEntry, err error) {}
+
+// ObserveSubQuery does nothing.
+func (m *NoopMetrics) ObserveSubQuery(engine string, latency time.Duration) {}
+
+// ObserveAuditFailure does nothing.
+func (m *NoopMetrics) ObserveAuditFailure(entries int) {}
+
+// ObserveSlowQuery does nothing.
+func (m *NoopMetrics) ObserveSlowQuery(entry QueryLogEntry) {}
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histograms of PrometheusMetrics.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metric names exposed by PrometheusMetrics.
+const (
+	MetricQueriesTotal            = "canonica_queries_total"
+	MetricQueryErrorsTotal        = "canonica_query_errors_total"
+	MetricQueryDurationSeconds    = "canonica_query_duration_seconds"
+	MetricSubQueryDurationSeconds = "canonica_subquery_duration_seconds"
+	MetricAuditFailuresTotal      = "canonica_audit_persist_failures_total"
+	MetricSlowQueriesTotal        = "canonica_slow_queries_total"
+)
+
+// PrometheusMetrics collects metrics in memory and serves them in the
+// Prometheus text exposition format, for the gateway's /metrics endpoint:
+//
+//   - canonica_queries_total{outcome, engine}, a counter of queries
+//   - canonica_query_errors_total{code}, a counter of failed queries by
+//     error code: validation, auth, engine or internal
+//   - canonica_query_duration_seconds{engine}, a histogram of query
+//     execution times
+//   - canonica_subquery_duration_seconds{engine}, a histogram of the
+//     sub-query latencies of federated queries
+//   - canonica_audit_persist_failures_total, a counter of audit entries
+//     that failed to persist to the database
+//   - canonica_slow_queries_total{engine}, a counter of queries over the
+//     slow-query threshold
+//
+// Rejected queries have no engine, and are counted with engine="".
+type PrometheusMetrics struct {
+	mu              sync.Mutex
+	queries         map[queryLabels]uint64
+	errors          map[string]uint64
+	queryLatency    map[string]*histogram
+	subQueryLatency map[string]*histogram
+	auditFailures   uint64
+	slowQueries     map[string]uint64
+}
+
+// queryLabels are the labels of canonica_queries_total.
+type queryLabels struct {
+	outcome string
+	engine  string
+}
+
+// NewPrometheusMetrics creates an empty collector.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		queries:         make(map[queryLabels]uint64),
+		errors:          make(map[string]uint64),
+		queryLatency:    make(map[string]*histogram),
+		subQueryLatency: make(map[string]*histogram),
+		slowQueries:     make(map[string]uint64),
+	}
+}
+
+// ObserveQuery counts a query by outcome and engine, and its error by code.
+// An entry without an outcome is a success, or an error if it has one.
+func (m *PrometheusMetrics) ObserveQuery(entry QueryLogEntry, err error) {
+	outcome := entry.Outcome
+	if outcome == "" {
+		outcome = "success"
+		if entry.Error != "" || err != nil {
+			outcome = "error"
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queries[queryLabels{outcome: outcome, engine: entry.Engine}]++
+	if err != nil {
+		m.errors[errorCodeLabel(errors.CodeOf(err))]++
+	}
+	observe(m.queryLatency, entry.Engine, entry.ExecutionTime)
+}
+
+// ObserveSubQuery records a sub-query latency for engine.
+func (m *PrometheusMetrics) ObserveSubQuery(engine string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	observe(m.subQueryLatency, engine, latency)
+}
+
+// ObserveAuditFailure counts audit entries that failed to persist.
+func (m *PrometheusMetrics) ObserveAuditFailure(entries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.auditFailures += uint64(entries)
+}
+
+// ObserveSlowQuery counts a slow query by engine.
+func (m *PrometheusMetrics) ObserveSlowQuery(entry QueryLogEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slowQueries[entry.Engine]++
+}
+
+// ServeHTTP writes the collected metrics in the text exposition format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WriteText(w)
+}
+
+// WriteText writes the collected metrics in the text exposition format.
+// Series are sorted by label values, so the output is deterministic.
+func (m *PrometheusMetrics) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+
+	writeHeader(out, MetricQueriesTotal, "counter", "Queries served, by outcome and engine.")
+	queryKeys := make([]queryLabels, 0, len(m.queries))
+	for key := range m.queries {
+		queryKeys = append(queryKeys, key)
+	}
+	sort.Slice(queryKeys, func(i, j int) bool {
+		if queryKeys[i].outcome != queryKeys[j].outcome {
+			return queryKeys[i].outcome < queryKeys[j].outcome
+		}
+		return queryKeys[i].engine < queryKeys[j].engine
+	})
+	for _, key := range queryKeys {
+		fmt.Fprintf(out, "%s{outcome=%s,engine=%s} %d\n",
+			MetricQueriesTotal, labelValue(key.outcome), labelValue(key.engine), m.queries[key])
+	}
+
+	writeHeader(out, MetricQueryErrorsTotal, "counter", "Failed queries, by error code.")
+	for _, code := range sortedKeys(m.errors) {
+		fmt.Fprintf(out, "%s{code=%s} %d\n", MetricQueryErrorsTotal, labelValue(code), m.errors[code])
+	}
+
+	writeHeader(out, MetricQueryDurationSeconds, "histogram", "Query execution time in seconds, by engine.")
+	writeHistograms(out, MetricQueryDurationSeconds, m.queryLatency)
+
+	writeHeader(out, MetricSubQueryDurationSeconds, "histogram", "Federated sub-query latency in seconds, by engine.")
+	writeHistograms(out, MetricSubQueryDurationSeconds, m.subQueryLatency)
+
+	writeHeader(out, MetricAuditFailuresTotal, "counter", "Audit entries that failed to persist to the database.")
+	fmt.Fprintf(out, "%s %d\n", MetricAuditFailuresTotal, m.auditFailures)
+
+	writeHeader(out, MetricSlowQueriesTotal, "counter", "Queries over the slow-query threshold, by engine.")
+	for _, engine := range sortedKeys(m.slowQueries) {
+		fmt.Fprintf(out, "%s{engine=%s} %d\n", MetricSlowQueriesTotal, labelValue(engine), m.slowQueries[engine])
+	}
+
+	return out.Flush()
+}
+
+// histogram counts observations into cumulative buckets, as Prometheus
+// histograms expose them.
+type histogram struct {
+	counts []uint64 // counts[i] observations <= DefaultLatencyBuckets[i]
+	count  uint64
+	sum    float64
+}
+
+// observe adds a latency to the histogram of engine in histograms.
+func observe(histograms map[string]*histogram, engine string, latency time.Duration) {
+	h, ok := histograms[engine]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(DefaultLatencyBuckets))}
+		histograms[engine] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range DefaultLatencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(out io.Writer, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeHistograms writes the buckets, sum and count of each engine's
+// histogram.
+func writeHistograms(out io.Writer, name string, histograms map[string]*histogram) {
+	for _, engine := range sortedKeys(histograms) {
+		h := histograms[engine]
+		engineLabel := "engine=" + labelValue(engine)
+		for i, bound := range DefaultLatencyBuckets {
+			fmt.Fprintf(out, "%s_bucket{%s,le=\"%s\"} %d\n",
+				name, engineLabel, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(out, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, engineLabel, h.count)
+		fmt.Fprintf(out, "%s_sum{%s} %s\n", name, engineLabel, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count{%s} %d\n", name, engineLabel, h.count)
+	}
+}
+
+// labelValue quotes a label value, escaping backslashes, quotes and
+// newlines.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// errorCodeLabel returns the code label of canonica_query_errors_total.
+func errorCodeLabel(code errors.ErrorCode) string {
+	switch code {
+	case errors.CodeValidation:
+		return "validation"
+	case errors.CodeAuth:
+		return "auth"
+	case errors.CodeEngine:
+		return "engine"
+	default:
+		return "internal"
+	}
+}
+
+// sortedKeys returns the keys of a map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package observability
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// AuditOverflow configures where a stream logger persists audit entries
+// evicted from its bounded in-memory buffer (see SetMaxEntries), so audit
+// survives without Postgres.
+//
+// Entries are appended to Path as JSON lines in the JSONLogger record
+// format, so the files can also be shipped with PersistentLogger.IngestNDJSON.
+type AuditOverflow struct {
+	// Path is the overflow file. Rotated files are Path.1 (newest) to
+	// Path.N (oldest).
+	Path string
+
+	// MaxBytes rotates the file before it would grow beyond this size.
+	// Zero disables rotation.
+	MaxBytes int64
+
+	// MaxFiles is the number of rotated files kept; older files are
+	// deleted. Zero keeps only Path, truncating it on rotation.
+	MaxFiles int
+
+	// IncludeInSummary folds the entries in the overflow files into the
+	// audit summary.
+	IncludeInSummary bool
+}
+
+// overflowFile appends evicted entries to the overflow file.
+type overflowFile struct {
+	cfg  AuditOverflow
+	file *os.File
+	size int64
+}
+
+// openOverflowFile opens the overflow file for appending.
+func openOverflowFile(cfg AuditOverflow) (*overflowFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("observability: audit overflow path is required")
+	}
+	if cfg.MaxBytes < 0 || cfg.MaxFiles < 0 {
+		return nil, fmt.Errorf("observability: audit overflow limits must not be negative")
+	}
+
+	f := &overflowFile{cfg: cfg}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens (or creates) Path and records its size.
+func (f *overflowFile) open() error {
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("observability: failed to open audit overflow file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("observability: failed to open audit overflow file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// write appends an entry, rotating the file first if it would exceed
+// MaxBytes.
+func (f *overflowFile) write(entry QueryLogEntry) error {
+	data, err := json.Marshal(newLogOutput(entry))
+	if err != nil {
+		return fmt.Errorf("observability: failed to marshal audit overflow entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	if f.cfg.MaxBytes > 0 && f.size > 0 && f.size+int64(len(data)) > f.cfg.MaxBytes {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("observability: failed to write audit overflow entry: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts Path to Path.1, Path.1 to Path.2 and so on, deleting the
+// file beyond MaxFiles, and starts a new Path.
+func (f *overflowFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("observability: failed to rotate audit overflow file: %w", err)
+	}
+
+	if f.cfg.MaxFiles == 0 {
+		if err := os.Truncate(f.cfg.Path, 0); err != nil {
+			return fmt.Errorf("observability: failed to rotate audit overflow file: %w", err)
+		}
+		return f.open()
+	}
+
+	if err := os.Remove(f.rotatedPath(f.cfg.MaxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("observability: failed to rotate audit overflow file: %w", err)
+	}
+	for i := f.cfg.MaxFiles - 1; i >= 0; i-- {
+		if err := os.Rename(f.rotatedPath(i), f.rotatedPath(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("observability: failed to rotate audit overflow file: %w", err)
+		}
+	}
+	return f.open()
+}
+
+// rotatedPath returns the path of the i-th rotated file; 0 is Path itself.
+func (f *overflowFile) rotatedPath(i int) string {
+	if i == 0 {
+		return f.cfg.Path
+	}
+	return fmt.Sprintf("%s.%d", f.cfg.Path, i)
+}
+
+// entries reads back every entry in the overflow files, oldest first.
+func (f *overflowFile) entries() ([]QueryLogEntry, error) {
+	var entries []QueryLogEntry
+	for i := f.cfg.MaxFiles; i >= 0; i-- {
+		file, err := os.Open(f.rotatedPath(i))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return entries, fmt.Errorf("observability: failed to read audit overflow file: %w", err)
+		}
+
+		dec := json.NewDecoder(bufio.NewReader(file))
+		for {
+			var record jsonLogOutput
+			if err := dec.Decode(&record); err != nil {
+				file.Close()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return entries, fmt.Errorf("observability: corrupt audit overflow file %s: %w", f.rotatedPath(i), err)
+			}
+			entries = append(entries, record.entry())
+		}
+	}
+	return entries, nil
+}
+
+// close closes the overflow file.
+func (f *overflowFile) close() error {
+	return f.file.Close()
+}
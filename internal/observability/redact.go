@@ -0,0 +1,83 @@
+package observability
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// redactedLiteral replaces literal values in redacted text.
+const redactedLiteral = "?"
+
+var (
+	// quotedLiteral matches a single-quoted SQL string literal.
+	quotedLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+	// numericLiteral matches a standalone number in SQL text.
+	numericLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+
+	// comparedNumber matches a number compared by an operator in free text,
+	// keeping the operator.
+	comparedNumber = regexp.MustCompile(`(=|<>|!=|<=|>=|<|>)(\s*)-?\d+(?:\.\d+)?\b`)
+)
+
+// Redactor replaces the literal values of logged queries with "?" before
+// entries are written, so audit logs record the shape of a query and not the
+// data in it. Per phase-5-spec.md §4: "No raw data exposure."
+//
+// Queries are normalized as for sql.Parser.Fingerprint; queries the SQL
+// grammar rejects, and error messages, are redacted textually.
+type Redactor struct {
+	parser *sql.Parser
+}
+
+// NewRedactor creates a redactor.
+func NewRedactor() *Redactor {
+	return &Redactor{parser: sql.NewParser()}
+}
+
+// Apply returns a copy of the entry with the literals of its SQL, engine SQL
+// and error message redacted. An entry without a fingerprint is given the
+// fingerprint of its SQL, when the gateway accepts it.
+func (r *Redactor) Apply(entry QueryLogEntry) QueryLogEntry {
+	if len(entry.EngineSQL) > 0 {
+		engineSQL := make(map[string]string, len(entry.EngineSQL))
+		for engine, query := range entry.EngineSQL {
+			engineSQL[engine] = r.RedactSQL(query)
+		}
+		entry.EngineSQL = engineSQL
+	}
+	if entry.SQL != "" {
+		if entry.Fingerprint == "" {
+			entry.Fingerprint, _ = r.parser.Fingerprint(entry.SQL)
+		}
+		redacted := r.RedactSQL(entry.SQL)
+		// Errors often echo the query they reject
+		entry.Error = strings.ReplaceAll(entry.Error, entry.SQL, redacted)
+		entry.SQL = redacted
+	}
+	entry.Error = r.RedactText(entry.Error)
+	return entry
+}
+
+// RedactSQL replaces the string and numeric literals of a SQL fragment with
+// "?". Fragments that cannot be parsed are redacted textually.
+func (r *Redactor) RedactSQL(fragment string) string {
+	if strings.TrimSpace(fragment) == "" {
+		return fragment
+	}
+	if normalized, err := sql.NormalizeLiterals(fragment); err == nil {
+		return normalized
+	}
+	fragment = quotedLiteral.ReplaceAllString(fragment, redactedLiteral)
+	return numericLiteral.ReplaceAllString(fragment, redactedLiteral)
+}
+
+// RedactText replaces the SQL literals embedded in free text, such as an
+// error message: single-quoted strings, and numbers compared by an
+// operator. Other numbers, like limits and positions, are kept.
+func (r *Redactor) RedactText(text string) string {
+	text = quotedLiteral.ReplaceAllString(text, redactedLiteral)
+	return comparedNumber.ReplaceAllString(text, "${1}${2}"+redactedLiteral)
+}
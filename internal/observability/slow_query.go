@@ -0,0 +1,170 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SlowQueryEvent describes a query that exceeded the slow-query threshold.
+type SlowQueryEvent struct {
+	QueryID   string        `json:"query_id"`
+	User      string        `json:"user"`
+	Tables    []string      `json:"tables"`
+	Engine    string        `json:"engine"`
+	Duration  time.Duration `json:"duration_ns"`
+	Threshold time.Duration `json:"threshold_ns"`
+}
+
+// SlowQueryHook is invoked for every slow query, e.g. to page an operator.
+// Hooks run synchronously on the logging path and should return quickly.
+type SlowQueryHook func(ctx context.Context, event SlowQueryEvent)
+
+// slowQueryDetector flags queries whose execution time exceeds a threshold.
+// A zero threshold disables detection.
+type slowQueryDetector struct {
+	mu        sync.RWMutex
+	threshold time.Duration
+	hook      SlowQueryHook
+	metrics   Metrics // optional: counts slow queries
+}
+
+func (d *slowQueryDetector) set(threshold time.Duration, hook SlowQueryHook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.threshold = threshold
+	d.hook = hook
+}
+
+func (d *slowQueryDetector) setMetrics(m Metrics) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.metrics = m
+}
+
+// exceeds reports whether the entry's execution time exceeds the threshold.
+// A query taking exactly the threshold is not slow.
+func (d *slowQueryDetector) exceeds(entry QueryLogEntry) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.threshold > 0 && entry.ExecutionTime > d.threshold
+}
+
+// check returns the event for a slow query and whether the entry was slow.
+func (d *slowQueryDetector) check(entry QueryLogEntry) (SlowQueryEvent, SlowQueryHook, Metrics, bool) {
+	d.mu.RLock()
+	threshold, hook, metrics := d.threshold, d.hook, d.metrics
+	d.mu.RUnlock()
+
+	if threshold <= 0 || entry.ExecutionTime <= threshold {
+		return SlowQueryEvent{}, nil, nil, false
+	}
+	return SlowQueryEvent{
+		QueryID:   entry.QueryID,
+		User:      entry.User,
+		Tables:    entry.Tables,
+		Engine:    entry.Engine,
+		Duration:  entry.ExecutionTime,
+		Threshold: threshold,
+	}, hook, metrics, true
+}
+
+// slowQueryLogOutput is the distinct warn-level record for slow queries.
+type slowQueryLogOutput struct {
+	Timestamp       string   `json:"timestamp"`
+	Level           string   `json:"level"`
+	Event           string   `json:"event"`
+	QueryID         string   `json:"query_id"`
+	User            string   `json:"user"`
+	Tables          []string `json:"tables"`
+	Engine          string   `json:"engine"`
+	ExecutionTimeMs int64    `json:"execution_time_ms"`
+	ThresholdMs     int64    `json:"threshold_ms"`
+}
+
+// report writes the slow-query record in format, counts the query in the
+// metrics and invokes the hook. Without a writer the record goes to the
+// DiagnosticLogger.
+func (d *slowQueryDetector) report(ctx context.Context, w io.Writer, format LogFormat, entry QueryLogEntry) error {
+	event, hook, metrics, slow := d.check(entry)
+	if !slow {
+		return nil
+	}
+	if metrics != nil {
+		metrics.ObserveSlowQuery(entry)
+	}
+
+	tables := event.Tables
+	if tables == nil {
+		tables = []string{}
+	}
+	data, err := format.encode(slowQueryLogOutput{
+		Timestamp:       time.Now().UTC().Format(time.RFC3339),
+		Level:           "warn",
+		Event:           "slow_query",
+		QueryID:         event.QueryID,
+		User:            event.User,
+		Tables:          tables,
+		Engine:          event.Engine,
+		ExecutionTimeMs: event.Duration.Milliseconds(),
+		ThresholdMs:     event.Threshold.Milliseconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("observability: failed to marshal slow query log: %w", err)
+	}
+	if w == nil {
+		Diagnosticf("%s", data)
+	} else if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("observability: failed to write slow query log: %w", err)
+	}
+
+	if hook != nil {
+		hook(ctx, event)
+	}
+	return nil
+}
+
+// DefaultWebhookTimeout bounds a slow-query webhook delivery.
+const DefaultWebhookTimeout = 5 * time.Second
+
+// NewSlowQueryWebhook returns a hook that POSTs each SlowQueryEvent as JSON to
+// url. Delivery happens in the background so logging is never blocked by the
+// receiver; failures are logged and dropped.
+func NewSlowQueryWebhook(url string, client *http.Client) SlowQueryHook {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	return func(ctx context.Context, event SlowQueryEvent) {
+		body, err := json.Marshal(event)
+		if err != nil {
+			Diagnosticf("observability: failed to encode slow query webhook: %v", err)
+			return
+		}
+		go func() {
+			reqCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultWebhookTimeout)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				Diagnosticf("observability: invalid slow query webhook: %v", err)
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				Diagnosticf("observability: slow query webhook failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				Diagnosticf("observability: slow query webhook returned %s", resp.Status)
+			}
+		}()
+	}
+}
@@ -0,0 +1,58 @@
+package observability
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the instrumentation name of canonica's tracer.
+const TracerName = "github.com/canonica-labs/canonica"
+
+// Span names of a query's lifecycle. The gateway starts the parse and
+// authorize spans; the federated executor the others, as children of the
+// span in the query's context.
+const (
+	SpanParse     = "canonica.parse"
+	SpanAuthorize = "canonica.authorize"
+	SpanExecute   = "canonica.execute"
+	SpanPlan      = "canonica.plan"
+	SpanSubQuery  = "canonica.subquery"
+	SpanJoin      = "canonica.join"
+)
+
+// Span attributes.
+const (
+	// AttrEngine is the engine a sub-query runs on.
+	AttrEngine = attribute.Key("canonica.engine")
+
+	// AttrEstimatedRows is the planner's row estimate of a sub-query.
+	AttrEstimatedRows = attribute.Key("canonica.estimated_rows")
+)
+
+// NewTracer returns canonica's tracer from provider, such as an SDK
+// TracerProvider exporting spans to a collector. A nil provider returns a
+// tracer recording nothing.
+func NewTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		return NewNoopTracer()
+	}
+	return provider.Tracer(TracerName)
+}
+
+// NewNoopTracer returns a tracer recording nothing. Its spans still carry
+// the span context of their parent, so traces started upstream propagate.
+func NewNoopTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(TracerName)
+}
+
+// EndSpan ends span, recording err and marking the span failed if err is
+// not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
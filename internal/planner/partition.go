@@ -0,0 +1,150 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/observability"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// PartitionInfo describes how a table is partitioned.
+type PartitionInfo struct {
+	// Columns are the partition columns.
+	Columns []string
+
+	// Count is the number of partitions.
+	Count int64
+}
+
+// PartitionResolver reports the partitioning of a table.
+type PartitionResolver interface {
+	// Partitions returns the table's partitioning, or nil if the table is
+	// not partitioned or its partitioning is unknown.
+	Partitions(ctx context.Context, table *tables.VirtualTable) (*PartitionInfo, error)
+}
+
+// PartitionGuardAction is what the partition guard does with a query that
+// exceeds its limit.
+type PartitionGuardAction string
+
+const (
+	// PartitionGuardReject rejects the query with ErrPartitionScanLimit.
+	PartitionGuardReject PartitionGuardAction = "reject"
+
+	// PartitionGuardWarn runs the query and returns a warning with it.
+	PartitionGuardWarn PartitionGuardAction = "warn"
+)
+
+// ParsePartitionGuardAction parses a PartitionGuardAction.
+// An empty value selects PartitionGuardReject.
+func ParsePartitionGuardAction(s string) (PartitionGuardAction, error) {
+	switch action := PartitionGuardAction(strings.ToLower(strings.TrimSpace(s))); action {
+	case "":
+		return PartitionGuardReject, nil
+	case PartitionGuardReject, PartitionGuardWarn:
+		return action, nil
+	default:
+		return "", fmt.Errorf("invalid partition guard action %q (valid: %s, %s)", s, PartitionGuardReject, PartitionGuardWarn)
+	}
+}
+
+// PartitionGuard flags queries that would scan more than MaxPartitions
+// partitions of a table because they have no predicate on its partition
+// columns.
+type PartitionGuard struct {
+	// Resolver supplies each table's partitioning.
+	Resolver PartitionResolver
+
+	// MaxPartitions is the most partitions an unfiltered query may scan.
+	MaxPartitions int64
+
+	// Action is applied to queries over the limit.
+	Action PartitionGuardAction
+}
+
+// SetPartitionGuard enables the partition guard. Nil disables it.
+func (p *Planner) SetPartitionGuard(guard *PartitionGuard) {
+	p.partitionGuard = guard
+}
+
+// checkPartitions applies the partition guard and returns its warnings.
+//
+// A table is estimated to touch all of its partitions unless the query
+// filters on one of its partition columns, in which case pruning is assumed.
+// Unqualified filter columns that cannot be attributed to a table count as
+// filters on every table partitioned by a column of that name.
+// Tables whose partitioning cannot be resolved are not checked.
+func (p *Planner) checkPartitions(ctx context.Context, logical *sql.LogicalPlan, resolvedTables []*tables.VirtualTable) ([]string, error) {
+	guard := p.partitionGuard
+	if guard == nil || guard.Resolver == nil || guard.MaxPartitions <= 0 {
+		return nil, nil
+	}
+
+	var warnings []string
+	for _, vt := range resolvedTables {
+		info, err := guard.Resolver.Partitions(ctx, vt)
+		if err != nil {
+			observability.Diagnosticf("planner: partition guard: cannot resolve partitions of %s: %v", vt.Name, err)
+			continue
+		}
+		if info == nil || len(info.Columns) == 0 || info.Count <= guard.MaxPartitions {
+			continue
+		}
+		if filtersPartitions(logical, vt.Name, info.Columns) {
+			continue
+		}
+
+		limitErr := errors.NewPartitionScanLimit(vt.Name, info.Count, guard.MaxPartitions, info.Columns)
+		if guard.Action != PartitionGuardWarn {
+			return nil, limitErr
+		}
+		warning := fmt.Sprintf("%s; %s", limitErr.Message, limitErr.Suggestion)
+		observability.Diagnosticf("planner: partition guard: %s", warning)
+		warnings = append(warnings, warning)
+	}
+	return warnings, nil
+}
+
+// filtersPartitions reports whether the query has a predicate on one of the
+// table's partition columns.
+func filtersPartitions(logical *sql.LogicalPlan, table string, partitionColumns []string) bool {
+	for _, key := range []string{table, sql.UnresolvedColumnsKey} {
+		for _, column := range logical.FilterColumns[key] {
+			for _, partition := range partitionColumns {
+				if strings.EqualFold(column, partition) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CatalogPartitionResolver resolves partitioning from catalog table
+// metadata. Virtual tables are looked up in the catalog by their
+// "database.table" name.
+type CatalogPartitionResolver struct {
+	Catalog catalog.Catalog
+}
+
+// Partitions returns the partition columns and count reported by the
+// catalog, or nil if the table has none.
+func (r *CatalogPartitionResolver) Partitions(ctx context.Context, table *tables.VirtualTable) (*PartitionInfo, error) {
+	database, name, ok := strings.Cut(table.Name, ".")
+	if !ok {
+		return nil, nil
+	}
+	meta, err := r.Catalog.GetTable(ctx, database, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.Partitions) == 0 || meta.PartitionCount <= 0 {
+		return nil, nil
+	}
+	return &PartitionInfo{Columns: meta.Partitions, Count: meta.PartitionCount}, nil
+}
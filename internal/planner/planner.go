@@ -6,9 +6,11 @@ package planner
 
 import (
 	"context"
+	"strings"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/observability"
 	"github.com/canonica-labs/canonica/internal/sql"
 	"github.com/canonica-labs/canonica/internal/tables"
 )
@@ -26,12 +28,28 @@ type ExecutionPlan struct {
 
 	// RequiredCapabilities are the capabilities needed for this query.
 	RequiredCapabilities []capabilities.Capability
+
+	// Warnings are non-fatal notices returned with the query result,
+	// such as references to deprecated tables.
+	Warnings []string
 }
 
 // Planner creates execution plans from logical plans.
 type Planner struct {
 	tableRegistry TableRegistry
 	engineMatcher EngineMatcher
+
+	// requireSchema rejects queries on tables without a synced column schema.
+	requireSchema bool
+
+	// snapshotResolver finds latest snapshots for LogicalPlan.PinSnapshots.
+	snapshotResolver SnapshotResolver
+
+	// safeMode restricts tables and engines; nil when disabled.
+	safeMode *tables.SafeMode
+
+	// partitionGuard flags unfiltered scans of many partitions; nil when disabled.
+	partitionGuard *PartitionGuard
 }
 
 // TableRegistry provides access to registered virtual tables.
@@ -59,6 +77,21 @@ func NewPlanner(registry TableRegistry, matcher EngineMatcher) *Planner {
 	}
 }
 
+// SetRequireSchema enables the global require-schema policy.
+// When enabled, queries referencing a table without column metadata are
+// rejected with ErrSchemaRequired. Tables can also opt in individually via
+// VirtualTable.RequireSchema.
+func (p *Planner) SetRequireSchema(require bool) {
+	p.requireSchema = require
+}
+
+// SetSafeMode applies the safe-mode policy. In safe mode, queries on
+// raw-file tables, cross-engine queries and queries on engines outside the
+// allowlist are rejected. Nil disables safe mode.
+func (p *Planner) SetSafeMode(mode *tables.SafeMode) {
+	p.safeMode = mode
+}
+
 // Plan creates an execution plan from a logical plan.
 // Returns an error if the query cannot be planned.
 func (p *Planner) Plan(ctx context.Context, logical *sql.LogicalPlan) (*ExecutionPlan, error) {
@@ -69,9 +102,32 @@ func (p *Planner) Plan(ctx context.Context, logical *sql.LogicalPlan) (*Executio
 		if err != nil {
 			return nil, err
 		}
+		if err := p.safeMode.CheckTable(vt); err != nil {
+			return nil, err
+		}
 		resolvedTables = append(resolvedTables, vt)
 	}
 
+	// Reject tables whose schema has not been synced when the policy requires it
+	if err := p.checkSchemas(resolvedTables); err != nil {
+		return nil, err
+	}
+
+	// Flag queries that would scan too many partitions without a partition filter
+	partitionWarnings, err := p.checkPartitions(ctx, logical, resolvedTables)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pin every table to the latest common snapshot when requested
+	if logical.PinSnapshots {
+		pinned, err := p.pinSnapshots(ctx, logical, resolvedTables)
+		if err != nil {
+			return nil, err
+		}
+		logical = pinned
+	}
+
 	// Phase 9: Check for cross-engine queries
 	// Per phase-9-spec.md: Queries spanning multiple engines require federation
 	if err := p.checkCrossEngine(resolvedTables); err != nil {
@@ -84,12 +140,17 @@ func (p *Planner) Plan(ctx context.Context, logical *sql.LogicalPlan) (*Executio
 		return nil, err
 	}
 
+	// Tables read AS OF different timestamps must allow mixed snapshots
+	if err := p.checkMixedSnapshots(logical, resolvedTables); err != nil {
+		return nil, err
+	}
+
 	// Determine required capabilities
 	required := p.determineRequiredCapabilities(logical, resolvedTables)
 
 	// Check that all tables support the required capabilities
 	for _, vt := range resolvedTables {
-		if err := p.checkTableCapabilities(vt, logical.Operation, required); err != nil {
+		if err := p.checkTableCapabilities(vt, logical.Operation, requiredForTable(logical, vt, required)); err != nil {
 			return nil, err
 		}
 	}
@@ -99,15 +160,44 @@ func (p *Planner) Plan(ctx context.Context, logical *sql.LogicalPlan) (*Executio
 	if err != nil {
 		return nil, err
 	}
+	if err := p.safeMode.CheckEngine(engine); err != nil {
+		return nil, err
+	}
 
 	return &ExecutionPlan{
 		LogicalPlan:          logical,
 		Engine:               engine,
 		ResolvedTables:       resolvedTables,
 		RequiredCapabilities: required,
+		Warnings:             append(deprecationWarnings(resolvedTables), partitionWarnings...),
 	}, nil
 }
 
+// deprecationWarnings returns a warning for each deprecated table and logs it,
+// so owners can find remaining consumers before removing the table.
+func deprecationWarnings(resolvedTables []*tables.VirtualTable) []string {
+	var warnings []string
+	for _, vt := range resolvedTables {
+		if warning := vt.DeprecationWarning(); warning != "" {
+			observability.Diagnosticf("planner: deprecated table queried: %s", warning)
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// checkSchemas enforces the require-schema policy.
+// Without column metadata, column references cannot be validated before the
+// query reaches an engine.
+func (p *Planner) checkSchemas(resolvedTables []*tables.VirtualTable) error {
+	for _, vt := range resolvedTables {
+		if (p.requireSchema || vt.RequireSchema) && !vt.HasSchema() {
+			return errors.NewSchemaRequired(vt.Name)
+		}
+	}
+	return nil
+}
+
 // checkCrossEngine detects queries that span multiple engines.
 // Per phase-9-spec.md: Returns ErrCrossEngineQuery when tables require different engines.
 func (p *Planner) checkCrossEngine(resolvedTables []*tables.VirtualTable) error {
@@ -142,9 +232,12 @@ func (p *Planner) preferredEngineForTable(vt *tables.VirtualTable) string {
 		return vt.Sources[0].Engine
 	}
 
-	// Rule 2: Based on format
+	// Rule 2: Based on format, honoring a format override
+	if notice := vt.FormatOverrideNotice(); notice != "" {
+		observability.Diagnosticf("planner: %s", notice)
+	}
 	if len(vt.Sources) > 0 {
-		switch vt.Sources[0].Format {
+		switch strings.ToLower(string(vt.EffectiveFormat())) {
 		case "iceberg":
 			return "trino" // Best Iceberg support
 		case "delta":
@@ -211,7 +304,10 @@ func (p *Planner) checkSnapshotConsistency(logical *sql.LogicalPlan, resolvedTab
 	}
 
 	// Rule 3: All SNAPSHOT_CONSISTENT tables must have the same snapshot timestamp
-	// This is because different snapshots could see inconsistent data states
+	// This is because different snapshots could see inconsistent data states.
+	// Parsed timestamps are compared as instants, so '2024-01-01' and
+	// '2024-01-01 00:00:00' are the same snapshot; other AS OF values are
+	// compared as written.
 	if len(snapshotTables) > 1 && len(logical.TimeTravelPerTable) > 0 {
 		var firstTimestamp string
 		var firstTable string
@@ -225,7 +321,7 @@ func (p *Planner) checkSnapshotConsistency(logical *sql.LogicalPlan, resolvedTab
 			if firstTimestamp == "" {
 				firstTimestamp = ts
 				firstTable = vt.Name
-			} else if ts != firstTimestamp {
+			} else if !sameSnapshot(logical, firstTable, firstTimestamp, vt.Name, ts) {
 				return errors.NewConstraintViolation(
 					vt.Name,
 					string(capabilities.ConstraintSnapshotConsistent),
@@ -239,6 +335,48 @@ func (p *Planner) checkSnapshotConsistency(logical *sql.LogicalPlan, resolvedTab
 	return nil
 }
 
+// checkMixedSnapshots rejects a query reading tables AS OF different
+// timestamps, which would join data from inconsistent states, unless the
+// tables allow it with the MIXED_SNAPSHOTS constraint. Only timestamps are
+// compared: snapshot IDs and versions (FOR VERSION AS OF) are specific to
+// each table.
+func (p *Planner) checkMixedSnapshots(logical *sql.LogicalPlan, resolvedTables []*tables.VirtualTable) error {
+	var first *tables.VirtualTable
+	for _, vt := range resolvedTables {
+		ts, ok := logical.ParsedTimestamps[vt.Name]
+		if !ok || vt.HasConstraint(capabilities.ConstraintMixedSnapshots) {
+			continue
+		}
+		if first == nil {
+			first = vt
+			continue
+		}
+		if !ts.Equal(logical.ParsedTimestamps[first.Name]) {
+			return errors.NewConstraintViolation(
+				vt.Name,
+				string(capabilities.ConstraintSnapshotConsistent),
+				"tables must be read AS OF the same timestamp unless they allow "+
+					string(capabilities.ConstraintMixedSnapshots)+"; "+
+					first.Name+" uses "+logical.TimeTravelPerTable[first.Name]+
+					" but "+vt.Name+" uses "+logical.TimeTravelPerTable[vt.Name],
+			)
+		}
+	}
+	return nil
+}
+
+// sameSnapshot reports whether two tables are read AS OF the same
+// snapshot: at the same instant when both timestamps were parsed, or with
+// the same AS OF text otherwise.
+func sameSnapshot(logical *sql.LogicalPlan, table, ts, other, otherTS string) bool {
+	parsed, ok := logical.ParsedTimestamps[table]
+	otherParsed, otherOK := logical.ParsedTimestamps[other]
+	if ok && otherOK {
+		return parsed.Equal(otherParsed)
+	}
+	return ts == otherTS
+}
+
 // determineRequiredCapabilities determines what capabilities are needed for a query.
 func (p *Planner) determineRequiredCapabilities(logical *sql.LogicalPlan, _ []*tables.VirtualTable) []capabilities.Capability {
 	required := []capabilities.Capability{}
@@ -253,9 +391,32 @@ func (p *Planner) determineRequiredCapabilities(logical *sql.LogicalPlan, _ []*t
 		required = append(required, capabilities.CapabilityTimeTravel)
 	}
 
+	// Window functions are forwarded to the engine, which must run them
+	if logical.HasWindowFunction {
+		required = append(required, capabilities.CapabilityWindow)
+	}
+
 	return required
 }
 
+// requiredForTable narrows the query's required capabilities to one table.
+// When AS OF is known per table, only tables actually read AS OF need
+// TIME_TRAVEL, so pinned and unpinned tables can be mixed. WINDOW is
+// required of the engine only.
+func requiredForTable(logical *sql.LogicalPlan, vt *tables.VirtualTable, required []capabilities.Capability) []capabilities.Capability {
+	_, pinned := logical.TimeTravelPerTable[vt.Name]
+	narrowed := make([]capabilities.Capability, 0, len(required))
+	for _, cap := range required {
+		switch {
+		case cap == capabilities.CapabilityWindow:
+		case cap == capabilities.CapabilityTimeTravel && len(logical.TimeTravelPerTable) > 0 && !pinned:
+		default:
+			narrowed = append(narrowed, cap)
+		}
+	}
+	return narrowed
+}
+
 // checkTableCapabilities verifies a table can perform the required operation.
 func (p *Planner) checkTableCapabilities(vt *tables.VirtualTable, op capabilities.OperationType, required []capabilities.Capability) error {
 	// First check if operation is allowed (handles constraints)
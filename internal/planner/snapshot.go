@@ -0,0 +1,120 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// SnapshotModeHeader is the request header that enables snapshot pinning.
+// Setting it to SnapshotModeLatestCommon runs the query as of the latest
+// snapshot shared by all referenced tables (LogicalPlan.PinSnapshots).
+const SnapshotModeHeader = "X-Canonic-Snapshot-Mode"
+
+// SnapshotModeLatestCommon is the SnapshotModeHeader value for pinning.
+const SnapshotModeLatestCommon = "latest-common"
+
+// snapshotTimestampLayout matches the layouts accepted by the time-travel rewriter.
+const snapshotTimestampLayout = "2006-01-02 15:04:05"
+
+// SnapshotResolver reports the latest committed snapshot of a table.
+type SnapshotResolver interface {
+	// LatestSnapshot returns the commit time of the table's newest snapshot.
+	LatestSnapshot(ctx context.Context, table *tables.VirtualTable) (time.Time, error)
+}
+
+// SetSnapshotResolver configures how snapshot pinning finds each table's
+// latest snapshot. Without a resolver, pinning fails for
+// SNAPSHOT_CONSISTENT tables and leaves other tables unpinned.
+func (p *Planner) SetSnapshotResolver(resolver SnapshotResolver) {
+	p.snapshotResolver = resolver
+}
+
+// pinSnapshots rewrites the plan so every snapshot-capable table reads AS OF
+// the latest snapshot common to all of them: the earliest of their latest
+// snapshots, which every table has reached.
+//
+// Tables whose format has no snapshots, or whose snapshot cannot be resolved,
+// are left unpinned, unless they are SNAPSHOT_CONSISTENT, in which case the
+// query is rejected because consistency cannot be guaranteed.
+func (p *Planner) pinSnapshots(ctx context.Context, logical *sql.LogicalPlan, resolvedTables []*tables.VirtualTable) (*sql.LogicalPlan, error) {
+	var common time.Time
+	var pinnable []*tables.VirtualTable
+
+	for _, vt := range resolvedTables {
+		if _, explicit := logical.TimeTravelPerTable[vt.Name]; explicit {
+			continue // An explicit AS OF wins over pinning
+		}
+
+		latest, err := p.latestSnapshot(ctx, vt)
+		if err != nil {
+			if vt.HasConstraint(capabilities.ConstraintSnapshotConsistent) {
+				return nil, errors.NewConstraintViolation(
+					vt.Name,
+					string(capabilities.ConstraintSnapshotConsistent),
+					fmt.Sprintf("cannot pin a snapshot: %v", err),
+				)
+			}
+			continue
+		}
+
+		pinnable = append(pinnable, vt)
+		if common.IsZero() || latest.Before(common) {
+			common = latest
+		}
+	}
+
+	if len(pinnable) == 0 {
+		return logical, nil
+	}
+
+	ts := common.UTC().Format(snapshotTimestampLayout)
+	pins := make(map[string]string, len(pinnable))
+	for _, vt := range pinnable {
+		pins[vt.Name] = ts
+	}
+	return sql.NewParser().PinSnapshots(logical, pins)
+}
+
+// latestSnapshot resolves the latest snapshot of a snapshot-capable table.
+func (p *Planner) latestSnapshot(ctx context.Context, vt *tables.VirtualTable) (time.Time, error) {
+	if !supportsSnapshots(vt) {
+		return time.Time{}, fmt.Errorf("table format does not support snapshots")
+	}
+	if p.snapshotResolver == nil {
+		return time.Time{}, fmt.Errorf("no snapshot resolver configured")
+	}
+	latest, err := p.snapshotResolver.LatestSnapshot(ctx, vt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if latest.IsZero() {
+		return time.Time{}, fmt.Errorf("table has no snapshots")
+	}
+	return latest, nil
+}
+
+// supportsSnapshots reports whether the table allows time travel and any of
+// its physical sources uses a format with snapshots (Delta, Iceberg, Hudi).
+func supportsSnapshots(vt *tables.VirtualTable) bool {
+	if !vt.HasCapability(capabilities.CapabilityTimeTravel) {
+		return false
+	}
+	if vt.FormatOverride != "" {
+		return capabilities.FormatSupportsTimeTravel(catalog.TableFormat(strings.ToLower(string(vt.FormatOverride))))
+	}
+	for _, src := range vt.Sources {
+		format := catalog.TableFormat(strings.ToLower(string(src.Format)))
+		if capabilities.FormatSupportsTimeTravel(format) {
+			return true
+		}
+	}
+	return false
+}
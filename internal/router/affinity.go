@@ -0,0 +1,99 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/observability"
+)
+
+// engineAffinity remembers, per table, the engine that last served a query
+// successfully, so repeated queries keep using it instead of re-running
+// selection (which can flap between equally capable engines). Entries
+// expire after a fixed TTL so selection is periodically re-evaluated.
+type engineAffinity struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]affinityEntry
+}
+
+// affinityEntry is the affine engine of a table.
+type affinityEntry struct {
+	engine  string
+	expires time.Time
+}
+
+// newEngineAffinity creates an affinity cache whose entries live for ttl.
+func newEngineAffinity(ttl time.Duration) *engineAffinity {
+	return &engineAffinity{
+		ttl:     ttl,
+		entries: make(map[string]affinityEntry),
+	}
+}
+
+// lookup returns the affine engine of a table if it is still one of the
+// candidates. Expired entries and entries whose engine is no longer a
+// candidate (unhealthy or incapable) are dropped.
+func (a *engineAffinity) lookup(table string, candidates []string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[table]
+	if !ok {
+		return "", false
+	}
+
+	if !time.Now().Before(entry.expires) {
+		delete(a.entries, table)
+		observability.Diagnosticf("router: engine affinity %s for %s expired; re-evaluating", entry.engine, table)
+		return "", false
+	}
+
+	for _, candidate := range candidates {
+		if candidate == entry.engine {
+			observability.Diagnosticf("router: using engine affinity %s for %s", entry.engine, table)
+			return entry.engine, true
+		}
+	}
+
+	delete(a.entries, table)
+	observability.Diagnosticf("router: dropping engine affinity %s for %s: engine is unavailable or not capable; re-evaluating", entry.engine, table)
+	return "", false
+}
+
+// peek returns the unexpired affine engine of a table without logging or
+// dropping entries, for inspection.
+func (a *engineAffinity) peek(table string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[table]
+	if !ok || !time.Now().Before(entry.expires) {
+		return "", false
+	}
+	return entry.engine, true
+}
+
+// record makes engine the affine engine of a table. Recording the current
+// affine engine again does not extend its TTL, so hot tables are still
+// re-evaluated periodically.
+func (a *engineAffinity) record(table, engine string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if entry, ok := a.entries[table]; ok && entry.engine == engine && time.Now().Before(entry.expires) {
+		return
+	}
+	a.entries[table] = affinityEntry{engine: engine, expires: time.Now().Add(a.ttl)}
+}
+
+// drop forgets the affinity of a table for engine.
+func (a *engineAffinity) drop(table, engine string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if entry, ok := a.entries[table]; ok && entry.engine == engine {
+		delete(a.entries, table)
+		observability.Diagnosticf("router: dropping engine affinity %s for %s: query failed", engine, table)
+	}
+}
@@ -0,0 +1,172 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/tables"
+	"github.com/canonica-labs/canonica/pkg/models"
+)
+
+// ScanCostEstimator estimates how long reading a whole table takes on an
+// engine. federation.FederatedExecutor implements it.
+type ScanCostEstimator interface {
+	EstimateScan(ctx context.Context, table, engine string) (time.Duration, error)
+}
+
+// EngineCandidate is an engine capable of serving a table, as evaluated by
+// the selector.
+type EngineCandidate struct {
+	// Engine is the engine name.
+	Engine string
+
+	// Available is true if the engine is currently available.
+	Available bool
+
+	// Selected is true for the engine SelectEngine would pick.
+	Selected bool
+
+	// Priority is the engine's selection priority (lower is preferred).
+	Priority int
+
+	// Capabilities are the required capabilities the engine provides.
+	Capabilities []capabilities.Capability
+
+	// Reason explains the engine's place in the order.
+	Reason string
+
+	// EstimatedTime is the estimated time to scan the table on the engine,
+	// or zero if no estimate is available.
+	EstimatedTime time.Duration
+}
+
+// SetCostEstimator sets the estimator CandidateEngines uses for the
+// estimated cost of each candidate. A nil estimator leaves costs out.
+func (s *EngineSelector) SetCostEstimator(estimator ScanCostEstimator) {
+	s.costs = estimator
+}
+
+// CandidateEngines lists the engines that can serve a table with the
+// required capabilities, in the order SelectEngine considers them: the
+// available engines first, by the same rules, then the unavailable ones by
+// priority. Engines that do not support the table's format or lack a
+// required capability are left out. A table with an explicitly assigned
+// engine has only that engine as candidate.
+func (s *EngineSelector) CandidateEngines(ctx context.Context, table *tables.VirtualTable, required []capabilities.Capability) []EngineCandidate {
+	var candidates []EngineCandidate
+	if len(table.Sources) > 0 && table.Sources[0].Engine != "" {
+		name := table.Sources[0].Engine
+		candidate := EngineCandidate{Engine: name, Capabilities: required, Reason: "assigned to the table"}
+		if engine, ok := s.router.GetEngine(name); ok {
+			candidate.Available = engine.Available
+			candidate.Priority = engine.Priority
+		}
+		candidates = append(candidates, candidate)
+	} else {
+		candidates = s.rankCapableEngines(table, required)
+	}
+
+	for i := range candidates {
+		if i == 0 && candidates[i].Available {
+			candidates[i].Selected = true
+		}
+		if s.costs != nil {
+			if estimated, err := s.costs.EstimateScan(ctx, table.Name, candidates[i].Engine); err == nil {
+				candidates[i].EstimatedTime = estimated
+			}
+		}
+	}
+	return candidates
+}
+
+// rankCapableEngines orders the engines that support the table's format and
+// have the required capabilities.
+func (s *EngineSelector) rankCapableEngines(table *tables.VirtualTable, required []capabilities.Capability) []EngineCandidate {
+	format := s.getTableFormat(table)
+
+	s.router.mu.RLock()
+	var available, unavailable []EngineCandidate
+	for name, engine := range s.router.engines {
+		if !s.engineSupportsFormat(name, format) || !engine.HasAllCapabilities(required) {
+			continue
+		}
+		candidate := EngineCandidate{
+			Engine:       name,
+			Available:    engine.Available,
+			Priority:     engine.Priority,
+			Capabilities: required,
+			Reason:       fmt.Sprintf("priority %d", engine.Priority),
+		}
+		if engine.Available {
+			available = append(available, candidate)
+		} else {
+			candidate.Reason = "unavailable"
+			unavailable = append(unavailable, candidate)
+		}
+	}
+	s.router.mu.RUnlock()
+
+	byPriority := func(c []EngineCandidate) {
+		sort.Slice(c, func(i, j int) bool {
+			if c[i].Priority != c[j].Priority {
+				return c[i].Priority < c[j].Priority
+			}
+			return c[i].Engine < c[j].Engine
+		})
+	}
+	byPriority(available)
+	byPriority(unavailable)
+
+	// The preferred engine for the format, then the affine engine, move to
+	// the front, mirroring SelectEngine
+	promote := func(name, reason string) {
+		for i, candidate := range available {
+			if candidate.Engine == name {
+				candidate.Reason = reason
+				copy(available[1:i+1], available[:i])
+				available[0] = candidate
+				return
+			}
+		}
+	}
+	promote(s.preferredEngineForFormat(format), fmt.Sprintf("preferred for %s", format))
+	if s.affinity != nil {
+		if engine, ok := s.affinity.peek(table.Name); ok {
+			promote(engine, "last served the table")
+		}
+	}
+
+	return append(available, unavailable...)
+}
+
+// TableEnginesResponse converts the candidates of a table to the API
+// response.
+func (s *EngineSelector) TableEnginesResponse(table *tables.VirtualTable, candidates []EngineCandidate) *models.TableEnginesResponse {
+	resp := &models.TableEnginesResponse{
+		Table:   table.Name,
+		Format:  string(s.getTableFormat(table)),
+		Engines: make([]models.EngineCandidate, 0, len(candidates)),
+	}
+	for _, candidate := range candidates {
+		caps := make([]string, len(candidate.Capabilities))
+		for i, c := range candidate.Capabilities {
+			caps[i] = string(c)
+		}
+		engine := models.EngineCandidate{
+			Engine:       candidate.Engine,
+			Available:    candidate.Available,
+			Selected:     candidate.Selected,
+			Priority:     candidate.Priority,
+			Capabilities: caps,
+			Reason:       candidate.Reason,
+		}
+		if candidate.EstimatedTime > 0 {
+			engine.EstimatedTime = candidate.EstimatedTime.String()
+		}
+		resp.Engines = append(resp.Engines, engine)
+	}
+	return resp
+}
@@ -94,16 +94,50 @@ func (r *Router) SelectEngine(ctx context.Context, required []capabilities.Capab
 	}
 
 	if bestEngine == nil {
-		capStrings := make([]string, len(required))
-		for i, c := range required {
-			capStrings[i] = string(c)
-		}
-		return "", errors.NewEngineUnavailable(capStrings)
+		return "", r.unavailableError(required)
 	}
 
 	return bestEngine.Name, nil
 }
 
+// unavailableError explains why no engine satisfied the required capabilities:
+// which capabilities no available engine provides, and what each available
+// engine does provide. r.mu must be held.
+func (r *Router) unavailableError(required []capabilities.Capability) error {
+	capStrings := make([]string, len(required))
+	for i, c := range required {
+		capStrings[i] = string(c)
+	}
+
+	considered := make(map[string][]string)
+	for name, engine := range r.engines {
+		if !engine.Available {
+			continue
+		}
+		caps := make([]string, len(engine.Capabilities))
+		for i, c := range engine.Capabilities {
+			caps[i] = string(c)
+		}
+		considered[name] = caps
+	}
+
+	var missing []string
+	for _, c := range required {
+		provided := false
+		for name := range considered {
+			if r.engines[name].HasCapability(c) {
+				provided = true
+				break
+			}
+		}
+		if !provided {
+			missing = append(missing, string(c))
+		}
+	}
+
+	return errors.NewEngineCapabilityUnavailable(capStrings, missing, considered)
+}
+
 // AvailableEngines returns the list of available engine names.
 func (r *Router) AvailableEngines(ctx context.Context) []string {
 	r.mu.RLock()
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/adapters"
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -32,6 +33,8 @@ const (
 type EngineSelector struct {
 	router   *Router
 	adapters map[string]adapters.EngineAdapter
+	affinity *engineAffinity
+	costs    ScanCostEstimator
 }
 
 // NewEngineSelector creates a new engine selector.
@@ -42,12 +45,43 @@ func NewEngineSelector(router *Router, engineAdapters map[string]adapters.Engine
 	}
 }
 
+// SetEngineAffinityTTL enables engine affinity: once a query on a table
+// succeeds on an engine (see RecordEngineSuccess), format-based selection
+// for that table prefers the same engine for ttl, as long as it stays
+// available and capable. A ttl of zero or less disables affinity.
+func (s *EngineSelector) SetEngineAffinityTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		s.affinity = nil
+		return
+	}
+	s.affinity = newEngineAffinity(ttl)
+}
+
+// RecordEngineSuccess records that a query on table succeeded on engine,
+// making it the table's affine engine if affinity is enabled.
+func (s *EngineSelector) RecordEngineSuccess(table, engine string) {
+	if s.affinity != nil {
+		s.affinity.record(table, engine)
+	}
+}
+
+// RecordEngineFailure records that a query on table failed on engine,
+// dropping the table's affinity for it.
+func (s *EngineSelector) RecordEngineFailure(table, engine string) {
+	if s.affinity != nil {
+		s.affinity.drop(table, engine)
+	}
+}
+
 // SelectEngine selects the best engine for executing a plan.
 // Per phase-8-spec.md §7.1:
 //   - Rule 1: If table has explicit engine assignment, use it
 //   - Rule 2: Select based on format capabilities
 //   - Rule 3: Prefer engine by format
 //   - Rule 4: Use first available
+//
+// With engine affinity enabled, the table's affine engine is preferred over
+// rules 3 and 4 while it is among the capable engines.
 func (s *EngineSelector) SelectEngine(ctx context.Context, plan *planner.ExecutionPlan) (string, error) {
 	if plan == nil || len(plan.ResolvedTables) == 0 {
 		return "", errors.NewPlannerError("no tables in execution plan")
@@ -80,6 +114,13 @@ func (s *EngineSelector) SelectEngine(ctx context.Context, plan *planner.Executi
 			format, capStrings)
 	}
 
+	// Reuse the engine that last served this table, if still capable
+	if s.affinity != nil {
+		if engine, ok := s.affinity.lookup(plan.ResolvedTables[0].Name, candidates); ok {
+			return engine, nil
+		}
+	}
+
 	// Rule 3: Prefer engine by format
 	preferred := s.preferredEngineForFormat(format)
 	if s.contains(candidates, preferred) {
@@ -251,12 +292,13 @@ func (s *EngineSelector) sortByPriority(engines []string) {
 	}
 }
 
-// getTableFormat extracts the format from a virtual table.
+// getTableFormat extracts the format from a virtual table, honoring its
+// format override.
 func (s *EngineSelector) getTableFormat(table *tables.VirtualTable) TableFormat {
-	if len(table.Sources) == 0 {
+	if len(table.Sources) == 0 && table.FormatOverride == "" {
 		return FormatParquet // Default assumption
 	}
-	return TableFormat(table.Sources[0].Format)
+	return TableFormat(table.EffectiveFormat())
 }
 
 // isEngineAvailable checks if an engine is registered and available.
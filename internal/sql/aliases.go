@@ -0,0 +1,152 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// extractTableAliases returns the alias → table name mapping of every
+// aliased base table in the statement, including those in subqueries.
+func extractTableAliases(stmt sqlparser.Statement) map[string]string {
+	aliases := make(map[string]string)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok || aliased.As.IsEmpty() {
+			return true, nil
+		}
+		if name, ok := aliased.Expr.(sqlparser.TableName); ok {
+			aliases[aliased.As.String()] = formatTableName(name)
+		}
+		return true, nil
+	}, stmt)
+	return aliases
+}
+
+// IsReservedWord reports whether word is a keyword of the SQL dialect, so
+// using it unquoted as an identifier is ambiguous or a syntax error.
+func IsReservedWord(word string) bool {
+	if word == "" {
+		return false
+	}
+	token, _ := sqlparser.NewStringTokenizer(word).Scan()
+	return token != sqlparser.ID
+}
+
+// extractOutputColumns returns the names of the columns a query produces, in
+// SELECT-list order: the alias if one is given, else the column name, else
+// the expression text. Wildcards are reported as StarColumn, qualified with
+// the table if the query qualifies them. A set operation takes its column
+// names from its first SELECT.
+func extractOutputColumns(stmt sqlparser.SelectStatement) []string {
+	for {
+		setOp, ok := stmt.(*sqlparser.SetOp)
+		if !ok {
+			break
+		}
+		stmt = setOp.Left
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil
+	}
+
+	columns := make([]string, 0, len(sel.SelectExprs))
+	for _, expr := range sel.SelectExprs {
+		switch e := expr.(type) {
+		case *sqlparser.StarExpr:
+			if e.TableName.IsEmpty() {
+				columns = append(columns, StarColumn)
+			} else {
+				columns = append(columns, formatTableName(e.TableName)+"."+StarColumn)
+			}
+		case *sqlparser.AliasedExpr:
+			switch {
+			case !e.As.IsEmpty():
+				columns = append(columns, e.As.String())
+			case isColName(e.Expr):
+				columns = append(columns, e.Expr.(*sqlparser.ColName).Name.String())
+			case e.InputExpression != "":
+				columns = append(columns, e.InputExpression)
+			default:
+				columns = append(columns, sqlparser.String(e.Expr))
+			}
+		}
+	}
+	return columns
+}
+
+// extractSelectColumns returns the column references of the SELECT list of
+// a SELECT, formatted like WherePredicates (c.region), in SELECT-list order.
+// It returns nil when any item is not a plain column reference, such as a
+// wildcard, an aggregate or another expression.
+func extractSelectColumns(sel *sqlparser.Select) []string {
+	columns := make([]string, 0, len(sel.SelectExprs))
+	for _, expr := range sel.SelectExprs {
+		aliased, ok := expr.(*sqlparser.AliasedExpr)
+		if !ok || !isColName(aliased.Expr) {
+			return nil
+		}
+		columns = append(columns, formatPredicate(aliased.Expr))
+	}
+	return columns
+}
+
+// isColName reports whether expr is a plain column reference.
+func isColName(expr sqlparser.Expr) bool {
+	_, ok := expr.(*sqlparser.ColName)
+	return ok
+}
+
+// DuplicateColumns returns the output column names that occur more than
+// once, compared case-insensitively, in order of their second occurrence.
+// Wildcards are skipped since their columns are unknown until execution.
+func DuplicateColumns(columns []string) []string {
+	seen := make(map[string]bool, len(columns))
+	reported := make(map[string]bool)
+	var duplicates []string
+	for _, col := range columns {
+		if strings.HasSuffix(col, StarColumn) {
+			continue
+		}
+		key := strings.ToLower(col)
+		if seen[key] && !reported[key] {
+			duplicates = append(duplicates, col)
+			reported[key] = true
+		}
+		seen[key] = true
+	}
+	return duplicates
+}
+
+// DisambiguateColumns returns the column names with repeated names made
+// unique by a numeric suffix, e.g. x, x becomes x, x_1. Names are compared
+// case-insensitively, a suffix never reuses a name already in the list and
+// wildcards are left unchanged.
+func DisambiguateColumns(columns []string) []string {
+	taken := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		taken[strings.ToLower(col)] = true
+	}
+
+	used := make(map[string]bool, len(columns))
+	result := make([]string, len(columns))
+	for i, col := range columns {
+		if key := strings.ToLower(col); !used[key] || strings.HasSuffix(col, StarColumn) {
+			used[key] = true
+			result[i] = col
+			continue
+		}
+		for suffix := 1; ; suffix++ {
+			candidate := fmt.Sprintf("%s_%d", col, suffix)
+			if key := strings.ToLower(candidate); !taken[key] {
+				taken[key] = true
+				used[key] = true
+				result[i] = candidate
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package sql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// extractAsOfTimestamps parses the timestamp of each table read AS OF a
+// string literal, keyed like LogicalPlan.TimeTravelPerTable. Version pins
+// (FOR VERSION AS OF), bind parameters and expressions are not timestamps
+// and are skipped. A literal in none of the forms the time-travel rewriter
+// accepts rejects the query.
+func extractAsOfTimestamps(sql string, stmt sqlparser.Statement) (map[string]time.Time, error) {
+	pins, _ := ExtractVersionPins(sql)
+	timestamps := make(map[string]time.Time)
+
+	var parseErr error
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok || aliased.AsOf == nil || parseErr != nil {
+			return parseErr == nil, nil
+		}
+		name, ok := aliased.Expr.(sqlparser.TableName)
+		val, isVal := aliased.AsOf.Time.(*sqlparser.SQLVal)
+		if !ok || !isVal || val.Type != sqlparser.StrVal {
+			return true, nil
+		}
+		table := formatTableName(name)
+		if _, pinned := pins[table]; pinned {
+			return true, nil
+		}
+
+		ts, err := parseTimeTravelTimestamp(string(val.Val))
+		if err != nil {
+			parseErr = errors.NewQueryRejected(sql,
+				fmt.Sprintf("invalid AS OF timestamp %q for table %s", string(val.Val), table),
+				"use an ISO 8601 timestamp, such as '2026-01-01T00:00:00Z' or '2026-01-01 00:00:00'")
+			return false, nil
+		}
+		timestamps[table] = ts
+		return true, nil
+	}, stmt)
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return timestamps, nil
+}
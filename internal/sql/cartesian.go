@@ -0,0 +1,262 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// extractCartesianProducts returns a description of every SELECT in a
+// statement whose FROM items are combined without a condition linking them:
+// a JOIN without ON or USING (or whose condition does not compare the two
+// sides), a CROSS JOIN, or a comma join whose WHERE clause has no predicate
+// relating the tables. Such a join produces every combination of rows.
+//
+// Relations are linked by any comparison between columns of two of them,
+// in a join condition or the WHERE clause, and by USING and NATURAL joins.
+// A comparison involving an unqualified column of a multi-relation scope
+// cannot be attributed without schema information and is assumed to link
+// every relation, so it never produces a false positive.
+func extractCartesianProducts(stmt sqlparser.SelectStatement) []string {
+	d := &cartesianDetector{}
+	d.visitStatement(stmt)
+	return d.products
+}
+
+// cartesianDetector accumulates cartesian products across nested SELECTs.
+type cartesianDetector struct {
+	products []string
+}
+
+func (d *cartesianDetector) visitStatement(stmt sqlparser.SelectStatement) {
+	switch s := stmt.(type) {
+	case *sqlparser.Select:
+		d.visitSelect(s)
+	case *sqlparser.SetOp:
+		d.visitWith(s.With)
+		d.visitStatement(s.Left)
+		d.visitStatement(s.Right)
+	case *sqlparser.ParenSelect:
+		d.visitStatement(s.Select)
+	}
+}
+
+func (d *cartesianDetector) visitWith(with *sqlparser.With) {
+	if with == nil {
+		return
+	}
+	for _, cte := range with.Ctes {
+		if subquery, ok := cte.Expr.(*sqlparser.Subquery); ok {
+			d.visitStatement(subquery.Select)
+		}
+	}
+}
+
+// visitSelect checks that the FROM items of a SELECT are linked, then
+// checks its subqueries.
+func (d *cartesianDetector) visitSelect(sel *sqlparser.Select) {
+	d.visitWith(sel.With)
+
+	g := newRelationGraph()
+	for _, expr := range sel.From {
+		d.addTableExpr(g, expr)
+	}
+	if sel.Where != nil {
+		d.link(g, sel.Where.Expr)
+	}
+
+	if components := g.components(); len(components) > 1 {
+		groups := make([]string, len(components))
+		for i, component := range components {
+			groups[i] = strings.Join(component, ", ")
+		}
+		d.products = append(d.products, fmt.Sprintf(
+			"%s are joined without a join condition", strings.Join(groups, " and ")))
+	}
+
+	// Subqueries in the select list, WHERE and HAVING are separate scopes
+	for _, expr := range sel.SelectExprs {
+		if aliased, ok := expr.(*sqlparser.AliasedExpr); ok {
+			d.visitSubqueries(aliased.Expr)
+		}
+	}
+	if sel.Where != nil {
+		d.visitSubqueries(sel.Where.Expr)
+	}
+	if sel.Having != nil {
+		d.visitSubqueries(sel.Having.Expr)
+	}
+}
+
+// addTableExpr adds the relations of a FROM item to the graph, linking the
+// sides of joins by their conditions. It returns the relations added.
+func (d *cartesianDetector) addTableExpr(g *relationGraph, expr sqlparser.TableExpr) []string {
+	switch t := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		alias := t.As.String()
+		switch e := t.Expr.(type) {
+		case sqlparser.TableName:
+			name := formatTableName(e)
+			if alias != "" {
+				return []string{g.add(alias)}
+			}
+			node := g.add(name)
+			g.qualify(e.Name.String(), node)
+			return []string{node}
+		case *sqlparser.Subquery:
+			d.visitStatement(e.Select)
+			if alias != "" {
+				return []string{g.add(alias)}
+			}
+		}
+	case *sqlparser.JoinTableExpr:
+		left := d.addTableExpr(g, t.LeftExpr)
+		right := d.addTableExpr(g, t.RightExpr)
+		if len(t.Condition.Using) > 0 || strings.HasPrefix(strings.ToLower(t.Join), "natural") {
+			if len(left) > 0 && len(right) > 0 {
+				g.union(left[0], right[0])
+			}
+		}
+		if t.Condition.On != nil {
+			d.link(g, t.Condition.On)
+			d.visitSubqueries(t.Condition.On)
+		}
+		return append(left, right...)
+	case *sqlparser.ParenTableExpr:
+		var nodes []string
+		for _, inner := range t.Exprs {
+			nodes = append(nodes, d.addTableExpr(g, inner)...)
+		}
+		return nodes
+	}
+	return nil
+}
+
+// link links the relations compared by each comparison in a condition.
+func (d *cartesianDetector) link(g *relationGraph, expr sqlparser.Expr) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.ComparisonExpr:
+			nodes, unresolved := g.referenced(n)
+			if unresolved {
+				g.unionAll()
+				return false, nil
+			}
+			for _, other := range nodes[min(1, len(nodes)):] {
+				g.union(nodes[0], other)
+			}
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+}
+
+// visitSubqueries checks the subqueries of an expression.
+func (d *cartesianDetector) visitSubqueries(expr sqlparser.Expr) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if subquery, ok := node.(*sqlparser.Subquery); ok {
+			d.visitStatement(subquery.Select)
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+}
+
+// relationGraph tracks which FROM items of one SELECT are linked, as a
+// union-find over relation names (aliases, or table names when unaliased).
+type relationGraph struct {
+	nodes      []string
+	parent     map[string]string
+	qualifiers map[string]string
+}
+
+func newRelationGraph() *relationGraph {
+	return &relationGraph{
+		parent:     make(map[string]string),
+		qualifiers: make(map[string]string),
+	}
+}
+
+// add adds a relation and returns its node name.
+func (g *relationGraph) add(name string) string {
+	if _, ok := g.parent[name]; !ok {
+		g.nodes = append(g.nodes, name)
+		g.parent[name] = name
+	}
+	g.qualifiers[name] = name
+	return name
+}
+
+// qualify makes qualifier another way to reference node.
+func (g *relationGraph) qualify(qualifier, node string) {
+	if _, ok := g.qualifiers[qualifier]; !ok {
+		g.qualifiers[qualifier] = node
+	}
+}
+
+func (g *relationGraph) find(node string) string {
+	for g.parent[node] != node {
+		g.parent[node] = g.parent[g.parent[node]]
+		node = g.parent[node]
+	}
+	return node
+}
+
+func (g *relationGraph) union(a, b string) {
+	g.parent[g.find(a)] = g.find(b)
+}
+
+func (g *relationGraph) unionAll() {
+	for _, node := range g.nodes[min(1, len(g.nodes)):] {
+		g.union(g.nodes[0], node)
+	}
+}
+
+// referenced returns the relations whose columns a comparison reads.
+// unresolved is true when it compares an unqualified column with another
+// column in a scope of several relations.
+func (g *relationGraph) referenced(cmp *sqlparser.ComparisonExpr) (nodes []string, unresolved bool) {
+	seen := make(map[string]bool)
+	columns, unqualified := 0, false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.ColName:
+			columns++
+			if n.Qualifier.IsEmpty() {
+				unqualified = true
+				return false, nil
+			}
+			// Qualifiers of outer scopes (correlated references) are ignored
+			if rel, ok := g.qualifiers[formatTableName(n.Qualifier)]; ok && !seen[rel] {
+				seen[rel] = true
+				nodes = append(nodes, rel)
+			}
+			return false, nil
+		}
+		return true, nil
+	}, cmp)
+	return nodes, unqualified && columns > 1 && len(g.nodes) > 1
+}
+
+// components returns the linked groups of relations, in FROM order, when
+// there is more than one.
+func (g *relationGraph) components() [][]string {
+	index := make(map[string]int)
+	var components [][]string
+	for _, node := range g.nodes {
+		root := g.find(node)
+		i, ok := index[root]
+		if !ok {
+			i = len(components)
+			index[root] = i
+			components = append(components, nil)
+		}
+		components[i] = append(components[i], node)
+	}
+	return components
+}
@@ -0,0 +1,309 @@
+package sql
+
+import (
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// UnresolvedColumnsKey is the LogicalPlan.Columns key for unqualified columns
+// that cannot be attributed to a single table without schema information
+// (e.g. "SELECT id FROM a JOIN b ON ...").
+const UnresolvedColumnsKey = ""
+
+// StarColumn marks a wildcard reference (SELECT * or SELECT t.*).
+const StarColumn = "*"
+
+// columnScope tracks the tables visible to one SELECT.
+// Qualifiers map to the base table they reference; derived tables and CTE
+// references map to "" because their columns do not belong to a base table.
+type columnScope struct {
+	parent     *columnScope
+	qualifiers map[string]string
+	tables     []string
+	derived    int
+	ctes       map[string]bool
+	aliases    map[string]bool
+
+	// resolveAliases is set while visiting HAVING and ORDER BY, the clauses
+	// that may refer to SELECT aliases.
+	resolveAliases bool
+}
+
+func newColumnScope(parent *columnScope) *columnScope {
+	ctes := make(map[string]bool)
+	if parent != nil {
+		for name := range parent.ctes {
+			ctes[name] = true
+		}
+	}
+	return &columnScope{
+		parent:     parent,
+		qualifiers: make(map[string]string),
+		ctes:       ctes,
+		aliases:    make(map[string]bool),
+	}
+}
+
+// resolve returns the base table for a qualifier, searching enclosing scopes
+// for correlated references. ok is false when the qualifier is unknown.
+func (s *columnScope) resolve(qualifier string) (table string, ok bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if table, ok := scope.qualifiers[qualifier]; ok {
+			return table, true
+		}
+	}
+	return "", false
+}
+
+// columnCollector accumulates referenced columns per table, in first-seen order.
+type columnCollector struct {
+	columns map[string][]string
+	seen    map[string]map[string]bool
+
+	// filtersOnly restricts collection to WHERE and join conditions.
+	filtersOnly bool
+}
+
+func newColumnCollector() *columnCollector {
+	return &columnCollector{
+		columns: make(map[string][]string),
+		seen:    make(map[string]map[string]bool),
+	}
+}
+
+func (c *columnCollector) add(table, column string) {
+	if c.seen[table] == nil {
+		c.seen[table] = make(map[string]bool)
+	}
+	if c.seen[table][column] {
+		return
+	}
+	c.seen[table][column] = true
+	c.columns[table] = append(c.columns[table], column)
+}
+
+// extractColumns returns the columns referenced by a statement, keyed by the
+// base table they belong to. Aliases are resolved to table names, star
+// expressions are recorded as "*", and unqualified columns are attributed to
+// the only table in scope or to UnresolvedColumnsKey when ambiguous.
+func extractColumns(stmt sqlparser.SelectStatement) map[string][]string {
+	c := newColumnCollector()
+	c.visitStatement(stmt, nil)
+	return c.columns
+}
+
+// extractFilterColumns returns the columns referenced by the WHERE clauses
+// and join conditions of a statement, including those of its subqueries and
+// CTEs, keyed like extractColumns.
+func extractFilterColumns(stmt sqlparser.SelectStatement) map[string][]string {
+	c := newColumnCollector()
+	c.filtersOnly = true
+	c.visitStatement(stmt, nil)
+	return c.columns
+}
+
+// visitStatement collects columns from any SelectStatement.
+func (c *columnCollector) visitStatement(stmt sqlparser.SelectStatement, parent *columnScope) {
+	switch s := stmt.(type) {
+	case *sqlparser.Select:
+		c.visitSelect(s, parent)
+	case *sqlparser.SetOp:
+		scope := c.visitWith(s.With, parent)
+		c.visitStatement(s.Left, scope)
+		c.visitStatement(s.Right, scope)
+	case *sqlparser.ParenSelect:
+		c.visitStatement(s.Select, parent)
+	}
+}
+
+// visitWith collects columns from CTE definitions and returns a scope in which
+// the CTE names are known.
+func (c *columnCollector) visitWith(with *sqlparser.With, parent *columnScope) *columnScope {
+	if with == nil {
+		return parent
+	}
+	scope := newColumnScope(parent)
+	for _, cte := range with.Ctes {
+		if subquery, ok := cte.Expr.(*sqlparser.Subquery); ok {
+			c.visitStatement(subquery.Select, scope)
+		}
+		if name := cte.As.String(); name != "" {
+			scope.ctes[name] = true
+		}
+	}
+	return scope
+}
+
+// visitSelect collects columns from a single SELECT and its subqueries.
+func (c *columnCollector) visitSelect(sel *sqlparser.Select, parent *columnScope) {
+	outer := c.visitWith(sel.With, parent)
+	scope := newColumnScope(outer)
+
+	var joinConditions []sqlparser.Expr
+	for _, tableExpr := range sel.From {
+		c.registerTableExpr(tableExpr, scope, &joinConditions)
+	}
+
+	for _, expr := range sel.SelectExprs {
+		switch e := expr.(type) {
+		case *sqlparser.StarExpr:
+			if !c.filtersOnly {
+				c.addStar(e, scope)
+			}
+		case *sqlparser.AliasedExpr:
+			if !c.filtersOnly {
+				c.visitExpr(e.Expr, scope)
+			}
+			if !e.As.IsEmpty() {
+				scope.aliases[e.As.String()] = true
+			}
+		}
+	}
+
+	for _, cond := range joinConditions {
+		c.visitExpr(cond, scope)
+	}
+	if sel.Where != nil {
+		c.visitExpr(sel.Where.Expr, scope)
+	}
+	if c.filtersOnly {
+		return
+	}
+	for _, expr := range sel.GroupBy {
+		c.visitExpr(expr, scope)
+	}
+	scope.resolveAliases = true
+	if sel.Having != nil {
+		c.visitExpr(sel.Having.Expr, scope)
+	}
+	for _, order := range sel.OrderBy {
+		c.visitExpr(order.Expr, scope)
+	}
+}
+
+// registerTableExpr adds the tables of a FROM item to the scope.
+func (c *columnCollector) registerTableExpr(expr sqlparser.TableExpr, scope *columnScope, joinConditions *[]sqlparser.Expr) {
+	switch t := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		alias := t.As.String()
+		switch e := t.Expr.(type) {
+		case sqlparser.TableName:
+			name := formatTableName(e)
+			if scope.ctes[name] {
+				// CTE reference: columns belong to the CTE, not a base table.
+				scope.derived++
+				scope.qualifiers[name] = ""
+				if alias != "" {
+					scope.qualifiers[alias] = ""
+				}
+				return
+			}
+			scope.tables = append(scope.tables, name)
+			if alias != "" {
+				scope.qualifiers[alias] = name
+				return
+			}
+			scope.qualifiers[name] = name
+			scope.qualifiers[e.Name.String()] = name
+		case *sqlparser.Subquery:
+			c.visitStatement(e.Select, scope.parent)
+			scope.derived++
+			if alias != "" {
+				scope.qualifiers[alias] = ""
+			}
+		}
+	case *sqlparser.JoinTableExpr:
+		c.registerTableExpr(t.LeftExpr, scope, joinConditions)
+		c.registerTableExpr(t.RightExpr, scope, joinConditions)
+		if t.Condition.On != nil {
+			*joinConditions = append(*joinConditions, t.Condition.On)
+		}
+		for _, col := range t.Condition.Using {
+			// USING columns exist on both sides of the join.
+			for _, side := range []sqlparser.TableExpr{t.LeftExpr, t.RightExpr} {
+				if table := baseTableOf(side, scope); table != "" {
+					c.add(table, col.String())
+				}
+			}
+		}
+	case *sqlparser.ParenTableExpr:
+		for _, inner := range t.Exprs {
+			c.registerTableExpr(inner, scope, joinConditions)
+		}
+	}
+}
+
+// baseTableOf returns the base table of a simple FROM item, or "".
+func baseTableOf(expr sqlparser.TableExpr, scope *columnScope) string {
+	aliased, ok := expr.(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return ""
+	}
+	tn, ok := aliased.Expr.(sqlparser.TableName)
+	if !ok || scope.ctes[formatTableName(tn)] {
+		return ""
+	}
+	return formatTableName(tn)
+}
+
+// addStar records a wildcard for the tables it expands to.
+func (c *columnCollector) addStar(star *sqlparser.StarExpr, scope *columnScope) {
+	if star.TableName.IsEmpty() {
+		for _, table := range scope.tables {
+			c.add(table, StarColumn)
+		}
+		return
+	}
+	if table, ok := scope.resolve(formatTableName(star.TableName)); ok && table != "" {
+		c.add(table, StarColumn)
+	}
+}
+
+// visitExpr collects column references from an expression, descending into
+// subqueries with a nested scope.
+func (c *columnCollector) visitExpr(expr sqlparser.Expr, scope *columnScope) {
+	if expr == nil {
+		return
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			c.visitStatement(n.Select, scope)
+			return false, nil
+		case *sqlparser.ColName:
+			c.addColumn(n, scope)
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+}
+
+// addColumn attributes a column reference to its base table.
+func (c *columnCollector) addColumn(col *sqlparser.ColName, scope *columnScope) {
+	name := col.Name.String()
+
+	if !col.Qualifier.IsEmpty() {
+		if table, ok := scope.resolve(formatTableName(col.Qualifier)); ok {
+			if table != "" {
+				c.add(table, name)
+			}
+			return
+		}
+		c.add(UnresolvedColumnsKey, name)
+		return
+	}
+
+	// Unqualified references to SELECT aliases in HAVING and ORDER BY are
+	// not table columns.
+	if scope.resolveAliases && scope.aliases[name] {
+		return
+	}
+
+	switch {
+	case len(scope.tables) == 1 && scope.derived == 0:
+		c.add(scope.tables[0], name)
+	case len(scope.tables) == 0 && scope.derived > 0:
+		// Column of a derived table or CTE; attributed inside its definition.
+	default:
+		c.add(UnresolvedColumnsKey, name)
+	}
+}
@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// hasCorrelatedSubquery reports whether a subquery of stmt reads a column of
+// an enclosing query: its WHERE clause references a qualifier bound in the
+// FROM clause of an outer SELECT and not in its own. Unqualified columns
+// cannot be bound without the table schemas, so they are not considered.
+// Derived tables in FROM are not correlated, as they cannot see the other
+// tables of their FROM clause.
+func hasCorrelatedSubquery(stmt sqlparser.SQLNode) bool {
+	correlated := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		sel, ok := node.(*sqlparser.Select)
+		if !ok {
+			return !correlated, nil
+		}
+		correlated = selectCorrelated(sel, map[string]bool{})
+		return !correlated, nil
+	}, stmt)
+	return correlated
+}
+
+// selectCorrelated reports whether a subquery in the WHERE, HAVING or SELECT
+// list of sel references a qualifier of sel's FROM clause or of outer.
+func selectCorrelated(sel *sqlparser.Select, outer map[string]bool) bool {
+	scope := make(map[string]bool, len(outer))
+	for qualifier := range outer {
+		scope[qualifier] = true
+	}
+	for _, expr := range sel.From {
+		for qualifier := range tableQualifiers(expr) {
+			scope[qualifier] = true
+		}
+	}
+
+	nodes := []sqlparser.SQLNode{sel.SelectExprs}
+	if sel.Where != nil {
+		nodes = append(nodes, sel.Where.Expr)
+	}
+	if sel.Having != nil {
+		nodes = append(nodes, sel.Having.Expr)
+	}
+	correlated := false
+	for _, n := range nodes {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if subquery, ok := node.(*sqlparser.Subquery); ok {
+				correlated = correlated || subqueryCorrelated(subquery.Select, scope)
+				return false, nil
+			}
+			return !correlated, nil
+		}, n)
+	}
+	return correlated
+}
+
+// subqueryCorrelated reports whether the WHERE clause of a subquery, or of
+// a subquery nested in it, references a qualifier of outer that the
+// subquery does not bind itself.
+func subqueryCorrelated(stmt sqlparser.SelectStatement, outer map[string]bool) bool {
+	switch s := stmt.(type) {
+	case *sqlparser.ParenSelect:
+		return subqueryCorrelated(s.Select, outer)
+	case *sqlparser.SetOp:
+		return subqueryCorrelated(s.Left, outer) || subqueryCorrelated(s.Right, outer)
+	case *sqlparser.Select:
+		inner := make(map[string]bool)
+		for _, expr := range s.From {
+			for qualifier := range tableQualifiers(expr) {
+				inner[qualifier] = true
+			}
+		}
+		if s.Where != nil && referencesOuter(s.Where.Expr, outer, inner) {
+			return true
+		}
+		return selectCorrelated(s, outer)
+	}
+	return false
+}
+
+// referencesOuter reports whether expr, outside its subqueries, reads a
+// column qualified by an outer qualifier not shadowed by inner.
+func referencesOuter(expr sqlparser.Expr, outer, inner map[string]bool) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.ColName:
+			if !n.Qualifier.IsEmpty() {
+				qualifier := formatTableName(n.Qualifier)
+				found = found || (outer[qualifier] && !inner[qualifier])
+			}
+			return false, nil
+		}
+		return !found, nil
+	}, expr)
+	return found
+}
@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// EngineVersion is an engine release version, e.g. Trino "435" or
+// Spark "3.5.1". The zero value means the version is unknown.
+type EngineVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// engineVersionPattern matches the first dotted version number in a version
+// string such as "435", "v1.1.3" or "3.5.1 fd86f85e181fc2dc0f50a096855acf83a6cc5d9c".
+var engineVersionPattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseEngineVersion parses the version string an engine reports.
+func ParseEngineVersion(s string) (EngineVersion, error) {
+	match := engineVersionPattern.FindStringSubmatch(s)
+	if match == nil {
+		return EngineVersion{}, fmt.Errorf("invalid engine version %q", s)
+	}
+
+	var parts [3]int
+	for i, part := range match[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return EngineVersion{}, fmt.Errorf("invalid engine version %q", s)
+		}
+		parts[i] = n
+	}
+	return EngineVersion{Major: parts[0], Minor: parts[1], Patch: parts[2]}, nil
+}
+
+// IsZero reports whether the version is unknown.
+func (v EngineVersion) IsZero() bool {
+	return v == EngineVersion{}
+}
+
+// Less reports whether v is an earlier release than other.
+func (v EngineVersion) Less(other EngineVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+// String returns the version in dotted form, omitting trailing zero
+// components after the major version ("385", "3.3", "3.2.1").
+func (v EngineVersion) String() string {
+	switch {
+	case v.Patch != 0:
+		return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	case v.Minor != 0:
+		return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	default:
+		return strconv.Itoa(v.Major)
+	}
+}
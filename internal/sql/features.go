@@ -0,0 +1,200 @@
+package sql
+
+import (
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// SQLFeature names a SQL construct whose support is reported to clients.
+type SQLFeature string
+
+const (
+	FeatureSelect             SQLFeature = "SELECT"
+	FeatureJoin               SQLFeature = "JOIN"
+	FeatureSubquery           SQLFeature = "SUBQUERY"
+	FeatureCorrelatedSubquery SQLFeature = "CORRELATED_SUBQUERY"
+	FeatureSetOperation       SQLFeature = "SET_OPERATION"
+	FeatureCTE                SQLFeature = "CTE"
+	FeatureRecursiveCTE       SQLFeature = "RECURSIVE_CTE"
+	FeatureTimeTravel         SQLFeature = "TIME_TRAVEL"
+	FeatureWindowFunction     SQLFeature = "WINDOW_FUNCTION"
+	FeatureInsert             SQLFeature = "INSERT"
+	FeatureUpdate             SQLFeature = "UPDATE"
+	FeatureDelete             SQLFeature = "DELETE"
+	FeatureDDL                SQLFeature = "DDL"
+	FeatureShow               SQLFeature = "SHOW"
+	FeatureSet                SQLFeature = "SET"
+	FeatureVendorHint         SQLFeature = "VENDOR_HINT"
+	FeatureMultipleStatements SQLFeature = "MULTIPLE_STATEMENTS"
+)
+
+// FeatureSupport describes whether the parser accepts a SQL feature.
+type FeatureSupport struct {
+	Feature    SQLFeature `json:"feature"`
+	Supported  bool       `json:"supported"`
+	Limitation string     `json:"limitation,omitempty"`
+	Example    string     `json:"example"`
+}
+
+// parserFeatures is the parser's allowlist. Parse consults it through
+// featureSupported, so the reported surface cannot drift from what is enforced.
+var parserFeatures = []FeatureSupport{
+	{Feature: FeatureSelect, Supported: true,
+		Example: "SELECT id FROM analytics.orders WHERE amount > 10"},
+	{Feature: FeatureJoin, Supported: true,
+		Example: "SELECT o.id FROM analytics.orders o JOIN analytics.customers c ON o.customer_id = c.id"},
+	{Feature: FeatureSubquery, Supported: true,
+		Example: "SELECT id FROM analytics.orders WHERE customer_id IN (SELECT id FROM analytics.customers)"},
+	{Feature: FeatureCorrelatedSubquery, Supported: false,
+		Limitation: "subqueries referencing a column of the outer query are rejected; rewrite them as a JOIN",
+		Example:    "SELECT id FROM analytics.customers c WHERE EXISTS (SELECT 1 FROM analytics.orders o WHERE o.customer_id = c.id)"},
+	{Feature: FeatureSetOperation, Supported: true,
+		Example: "SELECT id FROM analytics.orders UNION SELECT id FROM analytics.returns"},
+	{Feature: FeatureCTE, Supported: true,
+		Example: "WITH recent AS (SELECT id FROM analytics.orders) SELECT id FROM recent"},
+	{Feature: FeatureRecursiveCTE, Supported: true,
+		Example: "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n WHERE x < 5) SELECT x FROM n"},
+	{Feature: FeatureTimeTravel, Supported: true,
+		Example: "SELECT id FROM analytics.orders AS OF '2024-01-01 00:00:00'"},
+	{Feature: FeatureWindowFunction, Supported: false,
+		Limitation: "window functions (OVER clause) are rejected; use GROUP BY for aggregation",
+		Example:    "SELECT ROW_NUMBER() OVER (ORDER BY id) FROM analytics.orders"},
+	{Feature: FeatureInsert, Supported: false,
+		Limitation: "the gateway is read-only; INSERT is rejected",
+		Example:    "INSERT INTO analytics.orders (id) VALUES (1)"},
+	{Feature: FeatureUpdate, Supported: false,
+		Limitation: "the gateway is read-only; UPDATE is rejected",
+		Example:    "UPDATE analytics.orders SET amount = 0 WHERE id = 1"},
+	{Feature: FeatureDelete, Supported: false,
+		Limitation: "the gateway is read-only; DELETE is rejected",
+		Example:    "DELETE FROM analytics.orders WHERE id = 1"},
+	{Feature: FeatureDDL, Supported: false,
+		Limitation: "DDL statements are rejected",
+		Example:    "CREATE TABLE analytics.t (id INT)"},
+	{Feature: FeatureShow, Supported: false,
+		Limitation: "SHOW statements are rejected; use 'canonic table list'",
+		Example:    "SHOW TABLES"},
+	{Feature: FeatureSet, Supported: false,
+		Limitation: "SET statements are rejected",
+		Example:    "SET autocommit = 1"},
+	{Feature: FeatureVendorHint, Supported: false,
+		Limitation: "vendor-specific index and optimizer hints are rejected",
+		Example:    "SELECT id FROM analytics.orders USE INDEX (idx_id)"},
+	{Feature: FeatureMultipleStatements, Supported: false,
+		Limitation: "only one statement per request is accepted",
+		Example:    "SELECT 1; SELECT 2"},
+}
+
+// featureSupported reports whether the parser accepts a feature.
+func featureSupported(feature SQLFeature) bool {
+	for _, f := range parserFeatures {
+		if f.Feature == feature {
+			return f.Supported
+		}
+	}
+	return false
+}
+
+// engineFeatureCapabilities maps features that vary by engine to the engine
+// capability that provides them.
+var engineFeatureCapabilities = map[SQLFeature]capabilities.Capability{
+	FeatureCTE:            capabilities.CapabilityCTE,
+	FeatureTimeTravel:     capabilities.CapabilityTimeTravel,
+	FeatureWindowFunction: capabilities.CapabilityWindow,
+}
+
+// SQLFeatures is the machine-readable SQL surface of the gateway.
+type SQLFeatures struct {
+	// Operations are the accepted statement types.
+	Operations []string `json:"operations"`
+
+	// Features lists every known feature and whether the parser accepts it.
+	Features []FeatureSupport `json:"features"`
+
+	// Engines reports, per engine, the engine-dependent features it can execute.
+	// A feature must be supported by both the parser and the engine.
+	Engines map[string]map[SQLFeature]bool `json:"engines"`
+
+	// Limitations summarizes the unsupported features, and the limits of
+	// supported ones.
+	Limitations []string `json:"limitations"`
+}
+
+// Features reports the SQL surface accepted by the parser.
+func (p *Parser) Features() *SQLFeatures {
+	result := &SQLFeatures{
+		Features: make([]FeatureSupport, len(parserFeatures)),
+		Engines:  make(map[string]map[SQLFeature]bool),
+	}
+	copy(result.Features, parserFeatures)
+	for i, f := range result.Features {
+		if f.Feature == FeatureWindowFunction && p.allowWindowFunctions {
+			result.Features[i].Supported = true
+			result.Features[i].Limitation = "window functions run on single-engine queries only; cross-engine queries reject them"
+		}
+	}
+
+	for _, op := range []struct {
+		feature SQLFeature
+		op      capabilities.OperationType
+	}{
+		{FeatureSelect, capabilities.OperationSelect},
+		{FeatureInsert, capabilities.OperationInsert},
+		{FeatureUpdate, capabilities.OperationUpdate},
+		{FeatureDelete, capabilities.OperationDelete},
+	} {
+		if p.supports(op.feature) {
+			result.Operations = append(result.Operations, string(op.op))
+		}
+	}
+
+	for _, f := range result.Features {
+		if f.Limitation != "" {
+			result.Limitations = append(result.Limitations, f.Limitation)
+		}
+	}
+
+	for engine, engineCaps := range capabilities.EngineCapabilities {
+		caps := make(map[capabilities.Capability]bool, len(engineCaps))
+		for _, c := range engineCaps {
+			caps[c] = true
+		}
+		support := make(map[SQLFeature]bool, len(engineFeatureCapabilities))
+		for feature, capability := range engineFeatureCapabilities {
+			support[feature] = p.supports(feature) && caps[capability]
+		}
+		result.Engines[engine] = support
+	}
+
+	return result
+}
+
+// checkFeatureAllowlist rejects parsed SELECT constructs that the allowlist
+// disables.
+func checkFeatureAllowlist(stmt sqlparser.Statement, hasTimeTravel bool) error {
+	if hasTimeTravel && !featureSupported(FeatureTimeTravel) {
+		return errors.NewUnsupportedSyntax("TIME TRAVEL (AS OF)", "")
+	}
+	if !featureSupported(FeatureCorrelatedSubquery) && hasCorrelatedSubquery(stmt) {
+		return errors.NewUnsupportedSyntax("CORRELATED SUBQUERY",
+			"a JOIN on the outer query's columns, such as SELECT DISTINCT c.id FROM customers c JOIN orders o ON o.customer_id = c.id")
+	}
+
+	var err error
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		with, ok := node.(*sqlparser.With)
+		if !ok || with == nil {
+			return true, nil
+		}
+		switch {
+		case !featureSupported(FeatureCTE):
+			err = errors.NewUnsupportedSyntax("COMMON TABLE EXPRESSION (WITH)", "")
+		case with.Recursive && !featureSupported(FeatureRecursiveCTE):
+			err = errors.NewUnsupportedSyntax("RECURSIVE CTE (WITH RECURSIVE)", "non-recursive WITH")
+		}
+		return err == nil, nil
+	}, stmt)
+	return err
+}
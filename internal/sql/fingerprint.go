@@ -0,0 +1,95 @@
+package sql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// literalPlaceholder and listPlaceholder replace literal values and lists of
+// literal values in the query text hashed by Fingerprint.
+const (
+	literalPlaceholder = "?"
+	listPlaceholder    = "::?"
+)
+
+// Fingerprint returns a stable hash of the shape of a query, for grouping
+// audit entries and keying caches. Queries that differ only in literal
+// values, in the length of a literal IN list, in comments, whitespace or the
+// case of keywords and identifiers have the same fingerprint; queries over
+// other tables, columns or predicates differ. ORDER BY and GROUP BY ordinals
+// are positions rather than values, so they are kept.
+//
+// The query must be accepted by Parse; its rejection is returned otherwise.
+func (p *Parser) Fingerprint(query string) (string, error) {
+	if _, err := p.Parse(query); err != nil {
+		return "", err
+	}
+
+	normalized, err := NormalizeLiterals(query)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(strings.ToLower(normalized)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// NormalizeLiterals returns a query with its literal values replaced by "?"
+// and its literal lists by "::?", and its comments dropped: the text whose
+// hash is its Fingerprint. Unlike Fingerprint, it accepts any statement the
+// SQL grammar parses, so it can redact queries the gateway rejected.
+func NormalizeLiterals(query string) (string, error) {
+	stmt, err := sqlparser.Parse(stripNullOrdering(strings.TrimSpace(query)))
+	if err != nil {
+		return "", errors.NewQueryRejected(query, "invalid SQL syntax", err.Error())
+	}
+	normalizeLiterals(stmt)
+	return sqlparser.String(stmt), nil
+}
+
+// normalizeLiterals replaces the literal values of a parsed statement with
+// placeholders, and drops its comments.
+func normalizeLiterals(stmt sqlparser.Statement) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case sqlparser.OrderBy, sqlparser.GroupBy:
+			return false, nil
+		case *sqlparser.Select:
+			n.Comments = nil
+		case *sqlparser.ComparisonExpr:
+			switch right := n.Right.(type) {
+			case sqlparser.ValTuple:
+				if literalTuple(right) {
+					n.Right = sqlparser.ListArg(listPlaceholder)
+				}
+			case sqlparser.BoolVal:
+				n.Right = placeholder()
+			}
+		case *sqlparser.SQLVal:
+			*n = *placeholder()
+		}
+		return true, nil
+	}, stmt)
+}
+
+// literalTuple reports whether every value of a tuple is a literal.
+func literalTuple(tuple sqlparser.ValTuple) bool {
+	for _, expr := range tuple {
+		switch expr.(type) {
+		case *sqlparser.SQLVal, sqlparser.BoolVal:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// placeholder returns a literal placeholder.
+func placeholder() *sqlparser.SQLVal {
+	return &sqlparser.SQLVal{Type: sqlparser.ValArg, Val: []byte(literalPlaceholder)}
+}
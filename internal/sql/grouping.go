@@ -0,0 +1,148 @@
+package sql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// extractGroupBy returns the GROUP BY expressions of a SELECT as SQL text,
+// formatted like WHERE predicates. An ordinal (GROUP BY 1) is replaced by
+// the SELECT-list expression it refers to; one that refers to a wildcard or
+// is out of range is kept as written.
+func extractGroupBy(sel *sqlparser.Select) []string {
+	var groupBy []string
+	for _, expr := range sel.GroupBy {
+		if item, ok := groupByOrdinal(sel, expr); ok {
+			expr = item
+		}
+		groupBy = append(groupBy, formatPredicate(expr))
+	}
+	return groupBy
+}
+
+// groupByOrdinal returns the SELECT-list expression an integer GROUP BY
+// item refers to, counting from 1.
+func groupByOrdinal(sel *sqlparser.Select, expr sqlparser.Expr) (sqlparser.Expr, bool) {
+	item, ok := ordinalItem(sel, expr)
+	if !ok {
+		return nil, false
+	}
+	return item.Expr, true
+}
+
+// ordinalItem returns the SELECT item an integer GROUP BY or ORDER BY item
+// refers to, counting from 1.
+func ordinalItem(sel *sqlparser.Select, expr sqlparser.Expr) (*sqlparser.AliasedExpr, bool) {
+	val, ok := expr.(*sqlparser.SQLVal)
+	if !ok || val.Type != sqlparser.IntVal {
+		return nil, false
+	}
+	n, err := strconv.Atoi(string(val.Val))
+	if err != nil || n < 1 || n > len(sel.SelectExprs) {
+		return nil, false
+	}
+	item, ok := sel.SelectExprs[n-1].(*sqlparser.AliasedExpr)
+	return item, ok
+}
+
+// extractHaving returns the HAVING clause of a SELECT as SQL text, or ""
+// when it has none.
+func extractHaving(sel *sqlparser.Select) string {
+	if sel.Having == nil {
+		return ""
+	}
+	return formatPredicate(sel.Having.Expr)
+}
+
+// AggregateCall is a call of an aggregate function in the SELECT list of a
+// query.
+type AggregateCall struct {
+	// Function is the function name in upper case, such as COUNT or SUM.
+	Function string
+
+	// Argument is the argument as SQL text: a column as table.column or
+	// column, "*" for COUNT(*), or the text of any other expression.
+	Argument string
+
+	// ColumnArgument is set when the argument is a single column or "*".
+	ColumnArgument bool
+
+	// Distinct is set for an aggregate of distinct values, such as
+	// COUNT(DISTINCT col).
+	Distinct bool
+
+	// Nested is set when the call is not a whole SELECT item, such as
+	// SUM(col) + 1.
+	Nested bool
+
+	// Alias is the alias of the SELECT item the call is, or "".
+	Alias string
+}
+
+// extractAggregates returns the aggregate calls in the SELECT list of a
+// SELECT, in order. Aggregates in subqueries are not included.
+func extractAggregates(sel *sqlparser.Select) []AggregateCall {
+	var calls []AggregateCall
+	for _, item := range sel.SelectExprs {
+		aliased, ok := item.(*sqlparser.AliasedExpr)
+		if !ok {
+			continue
+		}
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch n := node.(type) {
+			case *sqlparser.Subquery:
+				return false, nil
+			case *sqlparser.FuncExpr:
+				if !n.IsAggregate() || n.Over != nil {
+					return true, nil
+				}
+				call := aggregateCall(n)
+				if sqlparser.Expr(n) == aliased.Expr {
+					call.Alias = aliased.As.String()
+				} else {
+					call.Nested = true
+				}
+				calls = append(calls, call)
+				return false, nil
+			case *sqlparser.GroupConcatExpr:
+				calls = append(calls, AggregateCall{
+					Function: "GROUP_CONCAT",
+					Argument: sqlparser.String(n.Exprs),
+					Distinct: n.Distinct != "",
+					Nested:   sqlparser.Expr(n) != aliased.Expr,
+				})
+				return false, nil
+			}
+			return true, nil
+		}, aliased.Expr)
+	}
+	return calls
+}
+
+// aggregateCall describes a call of an aggregate function.
+func aggregateCall(fn *sqlparser.FuncExpr) AggregateCall {
+	call := AggregateCall{
+		Function: strings.ToUpper(fn.Name.String()),
+		Argument: sqlparser.String(fn.Exprs),
+		Distinct: fn.Distinct,
+	}
+	if len(fn.Exprs) != 1 {
+		return call
+	}
+	switch arg := fn.Exprs[0].(type) {
+	case *sqlparser.StarExpr:
+		if arg.TableName.IsEmpty() {
+			call.Argument, call.ColumnArgument = StarColumn, true
+		}
+	case *sqlparser.AliasedExpr:
+		if col, ok := arg.Expr.(*sqlparser.ColName); ok {
+			call.Argument, call.ColumnArgument = col.Name.String(), true
+			if !col.Qualifier.IsEmpty() {
+				call.Argument = formatTableName(col.Qualifier) + "." + call.Argument
+			}
+		}
+	}
+	return call
+}
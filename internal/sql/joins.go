@@ -0,0 +1,378 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// JoinCondition is an equality between columns of two tables in the ON
+// clause of a join, such as o.customer_id = c.id. A compound condition
+// (ON a.x = b.y AND a.z = b.w) yields one JoinCondition per equality.
+type JoinCondition struct {
+	// Type is the join type: INNER, LEFT, RIGHT or FULL.
+	Type string
+
+	// LeftTable and RightTable are the column qualifiers as written: a
+	// table alias, or a table name.
+	LeftTable  string
+	LeftColumn string
+
+	RightTable  string
+	RightColumn string
+}
+
+// ColumnPredicate is a comparison of a qualified column with literals, which
+// the table's engine can evaluate on its own: o.total > 100, o.status IN
+// ('new', 'paid') or o.total BETWEEN 10 AND 20.
+type ColumnPredicate struct {
+	// Table is the column qualifier as written: a table alias, or a table
+	// name.
+	Table  string
+	Column string
+
+	// Operator is =, !=, <, >, <=, >=, LIKE, IN or BETWEEN. A literal
+	// compared with a column is turned around (5 < o.x becomes o.x > 5).
+	Operator string
+
+	// Values are the literals: one for a comparison, the list of an IN, or
+	// the lower and upper bounds of a BETWEEN. Strings are quoted in
+	// standard SQL, with quotes doubled.
+	Values []string
+
+	// SQL is the predicate's text, with its literals formatted as Values.
+	SQL string
+
+	// Conjunct is the WHERE predicate the comparison was taken from, as in
+	// LogicalPlan.WherePredicates. It is empty for predicates of ON clauses.
+	Conjunct string
+}
+
+// extractJoinConditions returns the column equalities of the ON clauses of
+// the joins in the FROM clause of a SELECT. Other conjuncts, such as
+// comparisons with literals or other operators, are skipped (see
+// extractJoinFilters), as are joins without ON (USING, NATURAL, CROSS).
+func extractJoinConditions(sel *sqlparser.Select) []JoinCondition {
+	var conditions []JoinCondition
+	for _, expr := range sel.From {
+		walkJoins(expr, func(join *sqlparser.JoinTableExpr) {
+			joinType := joinTypeName(join.Join)
+			if joinType == "" || join.Condition.On == nil {
+				return
+			}
+			for _, conjunct := range splitConjuncts(join.Condition.On) {
+				if cond, ok := newJoinCondition(joinType, conjunct); ok {
+					conditions = append(conditions, cond)
+				}
+			}
+		})
+	}
+	return conditions
+}
+
+// newJoinCondition converts an equality between the qualified columns of
+// two tables. It reports false for any other expression.
+func newJoinCondition(joinType string, expr sqlparser.Expr) (JoinCondition, bool) {
+	cmp, ok := unparen(expr).(*sqlparser.ComparisonExpr)
+	if !ok || cmp.Operator != sqlparser.EqualStr {
+		return JoinCondition{}, false
+	}
+	left, leftOK := cmp.Left.(*sqlparser.ColName)
+	right, rightOK := cmp.Right.(*sqlparser.ColName)
+	if !leftOK || !rightOK || left.Qualifier.IsEmpty() || right.Qualifier.IsEmpty() {
+		return JoinCondition{}, false
+	}
+	leftTable, rightTable := formatTableName(left.Qualifier), formatTableName(right.Qualifier)
+	if leftTable == rightTable {
+		return JoinCondition{}, false
+	}
+	return JoinCondition{
+		Type:        joinType,
+		LeftTable:   leftTable,
+		LeftColumn:  left.Name.String(),
+		RightTable:  rightTable,
+		RightColumn: right.Name.String(),
+	}, true
+}
+
+// extractJoinFilters returns the conjuncts of the ON clauses of the joins
+// of a SELECT that are neither join conditions (see extractJoinConditions)
+// nor predicates filtering a table before the join (see
+// extractColumnPredicates): comparisons of two tables' columns other than
+// equalities, predicates on the table an outer join preserves, and any
+// other expression, such as OR or a function call. They are formatted like
+// WherePredicates.
+func extractJoinFilters(sel *sqlparser.Select) []string {
+	var filters []string
+	for _, expr := range sel.From {
+		walkJoins(expr, func(join *sqlparser.JoinTableExpr) {
+			joinType := joinTypeName(join.Join)
+			if joinType == "" || join.Condition.On == nil {
+				return
+			}
+			filtered, prefilter := prefilteredTables(join)
+			for _, conjunct := range splitConjuncts(join.Condition.On) {
+				if _, ok := newJoinCondition(joinType, conjunct); ok {
+					continue
+				}
+				pred, ok := newColumnPredicate(conjunct)
+				if ok && prefilter && (filtered == nil || filtered[pred.Table]) {
+					continue
+				}
+				filters = append(filters, formatPredicate(conjunct))
+			}
+		})
+	}
+	return filters
+}
+
+// extractColumnPredicates returns the comparisons of a qualified column with
+// literals among the top-level conjuncts of the WHERE clause of a SELECT
+// and of the ON clauses of its joins. An ON predicate is only returned when
+// filtering its table before the join gives the same result: for inner
+// joins, and for the tables an outer join pads with NULLs.
+func extractColumnPredicates(sel *sqlparser.Select) []ColumnPredicate {
+	var predicates []ColumnPredicate
+	if sel.Where != nil {
+		for _, conjunct := range splitConjuncts(sel.Where.Expr) {
+			if pred, ok := newColumnPredicate(conjunct); ok {
+				pred.Conjunct = formatPredicate(conjunct)
+				predicates = append(predicates, pred)
+			}
+		}
+	}
+
+	for _, expr := range sel.From {
+		walkJoins(expr, func(join *sqlparser.JoinTableExpr) {
+			if join.Condition.On == nil {
+				return
+			}
+			filtered, prefilter := prefilteredTables(join)
+			if !prefilter {
+				return
+			}
+			for _, conjunct := range splitConjuncts(join.Condition.On) {
+				pred, ok := newColumnPredicate(conjunct)
+				if ok && (filtered == nil || filtered[pred.Table]) {
+					predicates = append(predicates, pred)
+				}
+			}
+		})
+	}
+	return predicates
+}
+
+// prefilteredTables returns the qualifiers of the tables of a join whose
+// ON predicates can filter them before the join: the tables an outer join
+// pads with NULLs, or nil for both sides of an inner join. It reports false
+// when no table can be filtered, as for full outer joins.
+func prefilteredTables(join *sqlparser.JoinTableExpr) (map[string]bool, bool) {
+	switch joinTypeName(join.Join) {
+	case "INNER":
+		return nil, true
+	case "LEFT":
+		return tableQualifiers(join.RightExpr), true
+	case "RIGHT":
+		return tableQualifiers(join.LeftExpr), true
+	default:
+		return nil, false
+	}
+}
+
+// newColumnPredicate converts a comparison of a qualified column with
+// literals. It reports false for any other expression.
+func newColumnPredicate(expr sqlparser.Expr) (ColumnPredicate, bool) {
+	var col *sqlparser.ColName
+	var pred ColumnPredicate
+
+	switch e := unparen(expr).(type) {
+	case *sqlparser.ComparisonExpr:
+		if e.Escape != nil {
+			return pred, false
+		}
+		switch op := strings.ToUpper(e.Operator); op {
+		case "=", "!=", "<>", "<", ">", "<=", ">=", "LIKE":
+			left, right := e.Left, e.Right
+			if _, ok := left.(*sqlparser.ColName); !ok {
+				left, right = right, left
+				op = reverseComparison(op)
+			}
+			value, ok := formatLiteral(right)
+			if col, _ = left.(*sqlparser.ColName); col == nil || !ok || op == "" {
+				return pred, false
+			}
+			pred.Operator = op
+			pred.Values = []string{value}
+		case "IN":
+			col, _ = e.Left.(*sqlparser.ColName)
+			tuple, ok := e.Right.(sqlparser.ValTuple)
+			if col == nil || !ok || len(tuple) == 0 {
+				return pred, false
+			}
+			for _, item := range tuple {
+				value, ok := formatLiteral(item)
+				if !ok {
+					return pred, false
+				}
+				pred.Values = append(pred.Values, value)
+			}
+			pred.Operator = op
+		default:
+			return pred, false
+		}
+	case *sqlparser.RangeCond:
+		if strings.ToLower(e.Operator) != sqlparser.BetweenStr {
+			return pred, false
+		}
+		col, _ = e.Left.(*sqlparser.ColName)
+		low, lowOK := formatLiteral(e.From)
+		high, highOK := formatLiteral(e.To)
+		if col == nil || !lowOK || !highOK {
+			return pred, false
+		}
+		pred.Operator = "BETWEEN"
+		pred.Values = []string{low, high}
+	default:
+		return pred, false
+	}
+
+	if col.Qualifier.IsEmpty() {
+		return pred, false
+	}
+	pred.Table = formatTableName(col.Qualifier)
+	pred.Column = col.Name.String()
+
+	column := pred.Table + "." + pred.Column
+	switch pred.Operator {
+	case "IN":
+		pred.SQL = column + " IN (" + strings.Join(pred.Values, ", ") + ")"
+	case "BETWEEN":
+		pred.SQL = column + " BETWEEN " + pred.Values[0] + " AND " + pred.Values[1]
+	default:
+		pred.SQL = column + " " + pred.Operator + " " + pred.Values[0]
+	}
+	return pred, true
+}
+
+// reverseComparison returns the operator comparing the operands the other
+// way around, or "" for LIKE, which cannot be turned around.
+func reverseComparison(op string) string {
+	switch op {
+	case "<":
+		return ">"
+	case ">":
+		return "<"
+	case "<=":
+		return ">="
+	case ">=":
+		return "<="
+	case "LIKE":
+		return ""
+	default:
+		return op
+	}
+}
+
+// formatLiteral formats a string or numeric literal in standard SQL. It
+// reports false for any other expression.
+func formatLiteral(expr sqlparser.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *sqlparser.SQLVal:
+		switch e.Type {
+		case sqlparser.StrVal:
+			return "'" + strings.ReplaceAll(string(e.Val), "'", "''") + "'", true
+		case sqlparser.IntVal, sqlparser.FloatVal:
+			return string(e.Val), true
+		}
+	case *sqlparser.UnaryExpr:
+		if e.Operator != sqlparser.UMinusStr {
+			return "", false
+		}
+		if num, ok := e.Expr.(*sqlparser.SQLVal); ok && (num.Type == sqlparser.IntVal || num.Type == sqlparser.FloatVal) {
+			if value := string(num.Val); !strings.HasPrefix(value, "-") {
+				return "-" + value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// walkJoins calls fn for every join in a FROM item, outermost first.
+func walkJoins(expr sqlparser.TableExpr, fn func(*sqlparser.JoinTableExpr)) {
+	switch t := expr.(type) {
+	case *sqlparser.JoinTableExpr:
+		fn(t)
+		walkJoins(t.LeftExpr, fn)
+		walkJoins(t.RightExpr, fn)
+	case *sqlparser.ParenTableExpr:
+		for _, inner := range t.Exprs {
+			walkJoins(inner, fn)
+		}
+	}
+}
+
+// tableQualifiers returns the qualifiers that reference the base tables of
+// a FROM item: their aliases, or their names when unaliased.
+func tableQualifiers(expr sqlparser.TableExpr) map[string]bool {
+	qualifiers := make(map[string]bool)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.AliasedTableExpr:
+			name, ok := n.Expr.(sqlparser.TableName)
+			switch {
+			case !n.As.IsEmpty():
+				qualifiers[n.As.String()] = true
+			case ok:
+				qualifiers[formatTableName(name)] = true
+				qualifiers[name.Name.String()] = true
+			}
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+	return qualifiers
+}
+
+// joinTypeName returns the type of a join as INNER, LEFT, RIGHT or FULL, or
+// "" for natural joins.
+func joinTypeName(join string) string {
+	switch strings.ToLower(join) {
+	case sqlparser.JoinStr, sqlparser.StraightJoinStr:
+		return "INNER"
+	case sqlparser.LeftJoinStr:
+		return "LEFT"
+	case sqlparser.RightJoinStr:
+		return "RIGHT"
+	case sqlparser.FullOuterJoinStr:
+		return "FULL"
+	default:
+		return ""
+	}
+}
+
+// splitConjuncts returns the top-level AND conjuncts of an expression,
+// looking through parenthesized ANDs, as extractWherePredicates does.
+func splitConjuncts(expr sqlparser.Expr) []sqlparser.Expr {
+	switch e := expr.(type) {
+	case *sqlparser.AndExpr:
+		return append(splitConjuncts(e.Left), splitConjuncts(e.Right)...)
+	case *sqlparser.ParenExpr:
+		if and, ok := e.Expr.(*sqlparser.AndExpr); ok {
+			return splitConjuncts(and)
+		}
+	}
+	return []sqlparser.Expr{expr}
+}
+
+// unparen returns an expression without its enclosing parentheses.
+func unparen(expr sqlparser.Expr) sqlparser.Expr {
+	for {
+		paren, ok := expr.(*sqlparser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.Expr
+	}
+}
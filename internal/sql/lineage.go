@@ -0,0 +1,425 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// ColumnRef identifies a column of a base table.
+// Table is UnresolvedColumnsKey when the column cannot be attributed to a
+// single table (an unqualified column in a multi-table scope without schemas).
+type ColumnRef struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+// String returns the reference as table.column.
+func (r ColumnRef) String() string {
+	if r.Table == UnresolvedColumnsKey {
+		return r.Column
+	}
+	return r.Table + "." + r.Column
+}
+
+// ColumnLineage describes where one output column of a query comes from.
+type ColumnLineage struct {
+	// Column is the output column name (alias, column name, or expression text).
+	Column string `json:"column"`
+
+	// Sources are the base table columns the output column derives from.
+	// Empty for constants.
+	Sources []ColumnRef `json:"sources"`
+
+	// Expression is the SQL expression for computed columns (aggregates,
+	// arithmetic, functions). Empty when the column is a plain reference.
+	Expression string `json:"expression,omitempty"`
+}
+
+// SchemaLookup returns the column names of a table, or nil if unknown.
+// It is used to expand SELECT * and to attribute unqualified columns.
+type SchemaLookup func(table string) []string
+
+// Lineage returns the column lineage of a SELECT query: for each output
+// column, the base table columns it derives from, traced through aliases,
+// joins, aggregates, derived tables and CTEs.
+//
+// SELECT * is expanded from schemas; a wildcard over a table with no known
+// schema is rejected because its output columns cannot be determined.
+func (p *Parser) Lineage(query string, schemas SchemaLookup) ([]ColumnLineage, error) {
+	if _, err := p.Parse(query); err != nil {
+		return nil, err
+	}
+
+	stmt, err := sqlparser.Parse(strings.TrimSpace(query))
+	if err != nil {
+		return nil, errors.NewQueryRejected(query, "invalid SQL syntax", err.Error())
+	}
+	sel, ok := stmt.(sqlparser.SelectStatement)
+	if !ok {
+		return nil, errors.NewQueryRejected(query,
+			"lineage is only available for SELECT queries",
+			"submit a SELECT query")
+	}
+
+	if schemas == nil {
+		schemas = func(string) []string { return nil }
+	}
+	tracer := &lineageTracer{schemas: schemas}
+	lineage, err := tracer.statement(sel, nil)
+	if err != nil {
+		return nil, errors.NewQueryRejected(query, "cannot compute column lineage", err.Error())
+	}
+	return lineage, nil
+}
+
+// lineageSource is a FROM item: a base table or a derived table/CTE whose
+// columns have already been traced.
+type lineageSource struct {
+	table   string
+	derived []ColumnLineage
+}
+
+// columns returns the output columns of the source, or nil if unknown.
+func (s *lineageSource) columns(schemas SchemaLookup) []string {
+	if s.derived == nil {
+		return schemas(s.table)
+	}
+	names := make([]string, len(s.derived))
+	for i, col := range s.derived {
+		names[i] = col.Column
+	}
+	return names
+}
+
+// lookup returns the sources of a column of this FROM item.
+func (s *lineageSource) lookup(column string) ([]ColumnRef, bool) {
+	if s.derived == nil {
+		return []ColumnRef{{Table: s.table, Column: column}}, true
+	}
+	for _, col := range s.derived {
+		if strings.EqualFold(col.Column, column) {
+			return col.Sources, true
+		}
+	}
+	return nil, false
+}
+
+// lineageScope tracks the FROM items visible to one SELECT.
+type lineageScope struct {
+	parent     *lineageScope
+	qualifiers map[string]*lineageSource
+	sources    []*lineageSource
+	ctes       map[string][]ColumnLineage
+}
+
+func newLineageScope(parent *lineageScope) *lineageScope {
+	ctes := make(map[string][]ColumnLineage)
+	if parent != nil {
+		for name, cte := range parent.ctes {
+			ctes[name] = cte
+		}
+	}
+	return &lineageScope{
+		parent:     parent,
+		qualifiers: make(map[string]*lineageSource),
+		ctes:       ctes,
+	}
+}
+
+// lineageTracer computes column lineage over the AST.
+type lineageTracer struct {
+	schemas SchemaLookup
+}
+
+// statement traces the output columns of any SelectStatement.
+func (t *lineageTracer) statement(stmt sqlparser.SelectStatement, parent *lineageScope) ([]ColumnLineage, error) {
+	switch s := stmt.(type) {
+	case *sqlparser.Select:
+		return t.selectStmt(s, parent)
+	case *sqlparser.ParenSelect:
+		return t.statement(s.Select, parent)
+	case *sqlparser.SetOp:
+		scope, err := t.with(s.With, parent)
+		if err != nil {
+			return nil, err
+		}
+		left, err := t.statement(s.Left, scope)
+		if err != nil {
+			return nil, err
+		}
+		right, err := t.statement(s.Right, scope)
+		if err != nil {
+			return nil, err
+		}
+		if len(left) != len(right) {
+			return nil, fmt.Errorf("set operation branches have %d and %d columns", len(left), len(right))
+		}
+		// Output names come from the left branch; sources come from both.
+		for i := range left {
+			left[i].Sources = mergeColumnRefs(left[i].Sources, right[i].Sources)
+		}
+		return left, nil
+	default:
+		return nil, fmt.Errorf("unsupported statement %T", stmt)
+	}
+}
+
+// with traces CTE definitions and returns a scope in which they are visible.
+func (t *lineageTracer) with(with *sqlparser.With, parent *lineageScope) (*lineageScope, error) {
+	if with == nil {
+		return parent, nil
+	}
+	scope := newLineageScope(parent)
+	for _, cte := range with.Ctes {
+		subquery, ok := cte.Expr.(*sqlparser.Subquery)
+		if !ok {
+			continue
+		}
+		lineage, err := t.statement(subquery.Select, scope)
+		if err != nil {
+			return nil, err
+		}
+		renameColumns(lineage, cte.Columns)
+		scope.ctes[cte.As.String()] = lineage
+	}
+	return scope, nil
+}
+
+// selectStmt traces the output columns of a single SELECT.
+func (t *lineageTracer) selectStmt(sel *sqlparser.Select, parent *lineageScope) ([]ColumnLineage, error) {
+	outer, err := t.with(sel.With, parent)
+	if err != nil {
+		return nil, err
+	}
+	scope := newLineageScope(outer)
+	for _, expr := range sel.From {
+		if err := t.register(expr, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	var lineage []ColumnLineage
+	for _, expr := range sel.SelectExprs {
+		switch e := expr.(type) {
+		case *sqlparser.StarExpr:
+			expanded, err := t.expandStar(e, scope)
+			if err != nil {
+				return nil, err
+			}
+			lineage = append(lineage, expanded...)
+		case *sqlparser.AliasedExpr:
+			sources, err := t.expr(e.Expr, scope)
+			if err != nil {
+				return nil, err
+			}
+			col := ColumnLineage{Sources: sources}
+			if ref, ok := e.Expr.(*sqlparser.ColName); ok {
+				col.Column = ref.Name.String()
+			} else {
+				col.Expression = sqlparser.String(e.Expr)
+				col.Column = col.Expression
+			}
+			if !e.As.IsEmpty() {
+				col.Column = e.As.String()
+			}
+			lineage = append(lineage, col)
+		}
+	}
+	return lineage, nil
+}
+
+// register adds the FROM items of a table expression to the scope.
+func (t *lineageTracer) register(expr sqlparser.TableExpr, scope *lineageScope) error {
+	switch te := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		alias := te.As.String()
+		var source *lineageSource
+		var names []string
+
+		switch e := te.Expr.(type) {
+		case sqlparser.TableName:
+			name := formatTableName(e)
+			if cte, ok := scope.ctes[name]; ok {
+				source = &lineageSource{derived: cte}
+				names = []string{name}
+			} else {
+				source = &lineageSource{table: name}
+				names = []string{name, e.Name.String()}
+			}
+		case *sqlparser.Subquery:
+			derived, err := t.statement(e.Select, scope.parent)
+			if err != nil {
+				return err
+			}
+			renameColumns(derived, e.Columns)
+			source = &lineageSource{derived: derived}
+		default:
+			return nil
+		}
+
+		scope.sources = append(scope.sources, source)
+		if alias != "" {
+			names = []string{alias}
+		}
+		for _, name := range names {
+			scope.qualifiers[name] = source
+		}
+	case *sqlparser.JoinTableExpr:
+		if err := t.register(te.LeftExpr, scope); err != nil {
+			return err
+		}
+		return t.register(te.RightExpr, scope)
+	case *sqlparser.ParenTableExpr:
+		for _, inner := range te.Exprs {
+			if err := t.register(inner, scope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandStar expands a wildcard into one lineage entry per column.
+func (t *lineageTracer) expandStar(star *sqlparser.StarExpr, scope *lineageScope) ([]ColumnLineage, error) {
+	sources := scope.sources
+	label := "*"
+	if !star.TableName.IsEmpty() {
+		label = formatTableName(star.TableName) + ".*"
+		source, ok := scope.qualifiers[formatTableName(star.TableName)]
+		if !ok {
+			return nil, fmt.Errorf("unknown table %s in %s", formatTableName(star.TableName), label)
+		}
+		sources = []*lineageSource{source}
+	}
+
+	var lineage []ColumnLineage
+	for _, source := range sources {
+		if source.derived != nil {
+			lineage = append(lineage, source.derived...)
+			continue
+		}
+		columns := t.schemas(source.table)
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("cannot expand %s: no column schema for table %s", label, source.table)
+		}
+		for _, column := range columns {
+			lineage = append(lineage, ColumnLineage{
+				Column:  column,
+				Sources: []ColumnRef{{Table: source.table, Column: column}},
+			})
+		}
+	}
+	return lineage, nil
+}
+
+// expr returns the base columns an expression reads, including columns read
+// by scalar subqueries.
+func (t *lineageTracer) expr(expr sqlparser.Expr, scope *lineageScope) ([]ColumnRef, error) {
+	var refs []ColumnRef
+	var walkErr error
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			lineage, err := t.statement(n.Select, scope)
+			if err != nil {
+				walkErr = err
+				return false, err
+			}
+			for _, col := range lineage {
+				refs = mergeColumnRefs(refs, col.Sources)
+			}
+			return false, nil
+		case *sqlparser.ColName:
+			refs = mergeColumnRefs(refs, t.column(n, scope))
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+	return refs, walkErr
+}
+
+// column resolves a column reference to its base table columns.
+func (t *lineageTracer) column(col *sqlparser.ColName, scope *lineageScope) []ColumnRef {
+	name := col.Name.String()
+
+	if !col.Qualifier.IsEmpty() {
+		qualifier := formatTableName(col.Qualifier)
+		for s := scope; s != nil; s = s.parent {
+			if source, ok := s.qualifiers[qualifier]; ok {
+				if refs, ok := source.lookup(name); ok {
+					return refs
+				}
+				break
+			}
+		}
+		return []ColumnRef{{Table: UnresolvedColumnsKey, Column: name}}
+	}
+
+	// Unqualified: the innermost scope with a single candidate wins;
+	// enclosing scopes are searched for correlated references.
+	for s := scope; s != nil; s = s.parent {
+		if len(s.sources) == 1 {
+			if refs, ok := s.sources[0].lookup(name); ok {
+				return refs
+			}
+			continue
+		}
+		var match *lineageSource
+		ambiguous := false
+		for _, source := range s.sources {
+			if containsFold(source.columns(t.schemas), name) {
+				if match != nil {
+					ambiguous = true
+				}
+				match = source
+			}
+		}
+		if match != nil && !ambiguous {
+			refs, _ := match.lookup(name)
+			return refs
+		}
+		if len(s.sources) > 0 {
+			break
+		}
+	}
+	return []ColumnRef{{Table: UnresolvedColumnsKey, Column: name}}
+}
+
+// renameColumns applies an explicit column list, e.g. "t(a, b)" or "cte(a, b)".
+func renameColumns(lineage []ColumnLineage, columns sqlparser.Columns) {
+	for i, col := range columns {
+		if i < len(lineage) {
+			lineage[i].Column = col.String()
+		}
+	}
+}
+
+// mergeColumnRefs appends refs not already present, preserving order.
+func mergeColumnRefs(dst, src []ColumnRef) []ColumnRef {
+	for _, ref := range src {
+		found := false
+		for _, existing := range dst {
+			if existing == ref {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, ref)
+		}
+	}
+	return dst
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// OrderItem is an item of the top-level ORDER BY clause of a query.
+type OrderItem struct {
+	// Expr is the sort expression as SQL text, formatted like
+	// WherePredicates. An ordinal (ORDER BY 1) is replaced by the alias of
+	// the SELECT item it refers to, or by its expression when it has none.
+	Expr string
+
+	// Column is set when Expr is a column reference or a SELECT-list
+	// alias, so its value can be read from each result row.
+	Column bool
+
+	// Descending is set for ORDER BY ... DESC.
+	Descending bool
+
+	// NullsFirst is set for an explicit NULLS FIRST.
+	NullsFirst bool
+}
+
+// extractOrderBy returns the items of the ORDER BY clause of a SELECT or
+// set operation. Ordinals refer to the SELECT list of sel, the left-most
+// SELECT of a set operation; one that refers to a wildcard or is out of
+// range is kept as written. nullsFirst reports, per item, whether the
+// query text places NULLs first, which the parser does not keep.
+func extractOrderBy(orderBy sqlparser.OrderBy, sel *sqlparser.Select, nullsFirst []bool) []OrderItem {
+	var items []OrderItem
+	for i, order := range orderBy {
+		item := OrderItem{Descending: order.Direction == sqlparser.DescScr}
+		expr := order.Expr
+		if sel != nil {
+			if aliased, ok := ordinalItem(sel, expr); ok {
+				expr = aliased.Expr
+				if !aliased.As.IsEmpty() {
+					item.Expr, item.Column = aliased.As.String(), true
+				}
+			}
+		}
+		if item.Expr == "" {
+			item.Expr, item.Column = formatPredicate(expr), isColName(expr)
+		}
+		item.NullsFirst = i < len(nullsFirst) && nullsFirst[i]
+		items = append(items, item)
+	}
+	return items
+}
+
+// leftmostSelect returns the left-most SELECT of a set operation, whose
+// SELECT list names the columns of its rows.
+func leftmostSelect(stmt sqlparser.SelectStatement) *sqlparser.Select {
+	for {
+		switch s := stmt.(type) {
+		case *sqlparser.Select:
+			return s
+		case *sqlparser.SetOp:
+			stmt = s.Left
+		default:
+			return nil
+		}
+	}
+}
+
+// nullOrderings reports, for each item of the top-level ORDER BY clause of
+// sql, whether it ends in NULLS FIRST. Items are counted at the clause's
+// own nesting level, so commas within function calls and ORDER BY clauses
+// of subqueries or window functions are not counted.
+func nullOrderings(sql string) []bool {
+	tkn := sqlparser.NewStringTokenizer(sql)
+	var items []bool
+	depth := 0
+	inOrderBy := false
+	var prev string
+	for {
+		typ, val := tkn.Scan()
+		if typ == 0 || typ == sqlparser.LEX_ERROR {
+			return items
+		}
+		word := strings.ToUpper(string(val))
+		switch {
+		case typ == '(':
+			depth++
+		case typ == ')':
+			depth--
+		case depth != 0:
+		case typ == sqlparser.BY && prev == "ORDER":
+			items, inOrderBy = []bool{false}, true
+		case !inOrderBy:
+		case typ == ',':
+			items = append(items, false)
+		case word == "FIRST" && prev == "NULLS":
+			items[len(items)-1] = true
+		}
+		prev = word
+	}
+}
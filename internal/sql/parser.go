@@ -7,7 +7,10 @@
 package sql
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/errors"
@@ -35,16 +38,138 @@ type LogicalPlan struct {
 	// TimeTravelPerTable maps table names to their AS OF timestamps.
 	// Per tracker.md T015: Enables per-table snapshot consistency validation.
 	TimeTravelPerTable map[string]string
+
+	// ParsedTimestamps maps each table of TimeTravelPerTable read AS OF a
+	// timestamp literal to the parsed timestamp. They are authoritative for
+	// comparing snapshots; TimeTravelPerTable keeps the text for display.
+	// Tables read AS OF a version, a bind parameter or an expression are
+	// absent.
+	ParsedTimestamps map[string]time.Time
+
+	// PinSnapshots requests that every table be read AS OF the latest snapshot
+	// common to all referenced tables, without the query naming timestamps.
+	PinSnapshots bool
+
+	// Columns maps each referenced table to the columns the query reads from it.
+	// Aliases are resolved to table names and wildcards are recorded as "*".
+	// Unqualified columns in multi-table scopes are keyed by UnresolvedColumnsKey.
+	Columns map[string][]string
+
+	// TableAliases maps each table alias in the query to the table name it
+	// refers to. Aliases of derived tables are not included.
+	TableAliases map[string]string
+
+	// OutputColumns are the names of the columns the query produces, in
+	// SELECT-list order. Unaliased expressions are named by their text and
+	// wildcards are recorded as "*" or "table.*".
+	OutputColumns []string
+
+	// SelectColumns are the column references of the SELECT list of a
+	// SELECT, formatted like WherePredicates (c.region), in SELECT-list
+	// order. Nil when the list has any other item, such as a wildcard or an
+	// expression, and for set operations.
+	SelectColumns []string
+
+	// FilterColumns maps each referenced table to the columns its WHERE
+	// clauses and join conditions constrain, keyed like Columns.
+	FilterColumns map[string][]string
+
+	// CartesianProducts describes each join in the query that combines
+	// tables without a condition linking them, such as a JOIN without ON or
+	// a comma join without a WHERE predicate relating the tables.
+	CartesianProducts []string
+
+	// WherePredicates are the top-level AND conjuncts of the WHERE clause of
+	// a SELECT, as SQL text. Empty for set operations.
+	WherePredicates []string
+
+	// JoinConditions are the column equalities of the ON clauses of the
+	// joins of a SELECT. Empty for set operations.
+	JoinConditions []JoinCondition
+
+	// JoinFilters are the other conjuncts of the ON clauses of the joins of
+	// a SELECT that do not filter a single table before its join, formatted
+	// like WherePredicates: o.total < c.credit, or o.total > 5 in orders o
+	// LEFT JOIN customers c. Empty for set operations.
+	JoinFilters []string
+
+	// ColumnPredicates are the comparisons of single columns with literals
+	// that can be evaluated before the joins of a SELECT. Empty for set
+	// operations.
+	ColumnPredicates []ColumnPredicate
+
+	// GroupBy are the GROUP BY expressions of a SELECT, as SQL text
+	// formatted like WherePredicates. Ordinals are replaced by the SELECT-list
+	// expressions they refer to. Empty for set operations.
+	GroupBy []string
+
+	// Aggregates are the aggregate calls in the SELECT list of a SELECT.
+	// Empty for set operations.
+	Aggregates []AggregateCall
+
+	// Having is the HAVING clause of a SELECT, as SQL text, or "" when it
+	// has none. Empty for set operations.
+	Having string
+
+	// Distinct is set for SELECT DISTINCT. False for set operations.
+	Distinct bool
+
+	// OrderBy are the items of the top-level ORDER BY clause of a SELECT or
+	// set operation, in order.
+	OrderBy []OrderItem
+
+	// SetOperation is the kind of a top-level set operation, one of the
+	// SetOperation constants such as SetOperationIntersect. Empty for a
+	// SELECT.
+	SetOperation string
+
+	// SetOperands are the SQL text of the left and right SELECTs of a set
+	// operation, formatted like WherePredicates. Empty when an operand is
+	// itself a set operation.
+	SetOperands []string
+
+	// HasWindowFunction is set when the query calls a function with an OVER
+	// clause. Such queries are only parsed when the parser allows window
+	// functions (see Parser.SetAllowWindowFunctions); they can run on a
+	// single engine, but not across engines.
+	HasWindowFunction bool
+
+	// Limit and Offset are the row count and offset of the top-level LIMIT
+	// clause, in any of its forms: LIMIT n, LIMIT n OFFSET m and LIMIT m, n.
+	// They are nil when the clause is absent, or when it is not an integer
+	// literal, such as a bind parameter.
+	Limit  *int
+	Offset *int
 }
 
 // Parser parses SQL queries into logical plans.
-type Parser struct{}
+type Parser struct {
+	allowWindowFunctions bool
+}
 
 // NewParser creates a new SQL parser.
 func NewParser() *Parser {
 	return &Parser{}
 }
 
+// SetAllowWindowFunctions sets whether queries with window functions (OVER
+// clauses) are parsed rather than rejected. They are forwarded to the
+// engine of a single-engine query, which must have the WINDOW capability;
+// the planner still rejects them in cross-engine queries, which the
+// federated executor cannot evaluate. Off by default.
+func (p *Parser) SetAllowWindowFunctions(allow bool) {
+	p.allowWindowFunctions = allow
+}
+
+// supports reports whether the parser accepts a feature, including the
+// features enabled on this parser.
+func (p *Parser) supports(feature SQLFeature) bool {
+	if feature == FeatureWindowFunction && p.allowWindowFunctions {
+		return true
+	}
+	return featureSupported(feature)
+}
+
 // Parse parses a SQL query into a LogicalPlan.
 // Returns an error if the query is invalid or uses unsupported syntax.
 // Per phase-3-spec.md §9: "Parser rejections must be explicit, stable, and human-readable."
@@ -59,7 +184,7 @@ func (p *Parser) Parse(sql string) (*LogicalPlan, error) {
 	if err != nil {
 		return nil, errors.NewQueryRejected(sql, "failed to parse SQL", err.Error())
 	}
-	if len(stmts) > 1 {
+	if len(stmts) > 1 && !featureSupported(FeatureMultipleStatements) {
 		return nil, errors.NewQueryRejected(sql,
 			"multiple statements not allowed",
 			"submit one query at a time")
@@ -67,7 +192,7 @@ func (p *Parser) Parse(sql string) (*LogicalPlan, error) {
 
 	// Phase 3: Pre-parse detection of unsupported syntax constructs
 	// Per phase-3-spec.md §9: Must detect and report these BEFORE generic parse errors
-	if err := detectUnsupportedSyntax(sql); err != nil {
+	if err := detectUnsupportedSyntax(sql, p.supports(FeatureWindowFunction)); err != nil {
 		return nil, err
 	}
 
@@ -77,7 +202,7 @@ func (p *Parser) Parse(sql string) (*LogicalPlan, error) {
 	}
 
 	// Parse the SQL into an AST
-	stmt, err := sqlparser.Parse(sql)
+	stmt, err := sqlparser.Parse(stripNullOrdering(sql))
 	if err != nil {
 		// Phase 3: Attempt to classify the parse error more specifically
 		if classifiedErr := classifyParseError(sql, err); classifiedErr != nil {
@@ -92,16 +217,60 @@ func (p *Parser) Parse(sql string) (*LogicalPlan, error) {
 	var hasTimeTravel bool
 	var timestamp string
 	var perTableTimestamps map[string]string
+	var columns map[string][]string
+	var filterColumns map[string][]string
+	var outputColumns []string
+	var selectColumns []string
+	var cartesianProducts []string
+	var wherePredicates []string
+	var joinConditions []JoinCondition
+	var joinFilters []string
+	var columnPredicates []ColumnPredicate
+	var groupBy []string
+	var aggregates []AggregateCall
+	var having string
+	var distinct bool
+	var orderBy []OrderItem
+	var limit, offset *int
+	var setOperation string
+	var setOperands []string
 
 	switch s := stmt.(type) {
 	case *sqlparser.Select:
 		op = capabilities.OperationSelect
 		tables, hasTimeTravel, timestamp, perTableTimestamps = extractTablesFromSelectWithAsOf(s)
+		columns = extractColumns(s)
+		filterColumns = extractFilterColumns(s)
+		outputColumns = extractOutputColumns(s)
+		selectColumns = extractSelectColumns(s)
+		cartesianProducts = extractCartesianProducts(s)
+		wherePredicates = extractWherePredicates(s)
+		joinConditions = extractJoinConditions(s)
+		joinFilters = extractJoinFilters(s)
+		columnPredicates = extractColumnPredicates(s)
+		groupBy = extractGroupBy(s)
+		aggregates = extractAggregates(s)
+		having = extractHaving(s)
+		distinct = s.QueryOpts.Distinct
+		orderBy = extractOrderBy(s.OrderBy, s, nullOrderings(sql))
+		limit, offset = extractLimit(s.Limit)
 
 	case *sqlparser.SetOp:
+		if !featureSupported(FeatureSetOperation) {
+			return nil, errors.NewUnsupportedSyntax("SET OPERATION (UNION/INTERSECT/EXCEPT)", "")
+		}
 		op = capabilities.OperationSelect
 		tables, hasTimeTravel, timestamp, perTableTimestamps = extractTablesFromUnionWithAsOf(s)
-
+		columns = extractColumns(s)
+		filterColumns = extractFilterColumns(s)
+		outputColumns = extractOutputColumns(s)
+		cartesianProducts = extractCartesianProducts(s)
+		orderBy = extractOrderBy(s.OrderBy, leftmostSelect(s), nullOrderings(sql))
+		limit, offset = extractLimit(s.Limit)
+		setOperation, setOperands = extractSetOperation(s)
+
+	// Writes, DDL, SHOW and SET are rejected per the parserFeatures allowlist,
+	// which reports them as unsupported.
 	case *sqlparser.Insert:
 		op = capabilities.OperationInsert
 		return nil, errors.NewWriteNotAllowed(string(op))
@@ -146,6 +315,15 @@ func (p *Parser) Parse(sql string) (*LogicalPlan, error) {
 		hasTimeTravel, timestamp = detectTimeTravel(sql)
 	}
 
+	if err := checkFeatureAllowlist(stmt, hasTimeTravel); err != nil {
+		return nil, err
+	}
+
+	parsedTimestamps, err := extractAsOfTimestamps(sql, stmt)
+	if err != nil {
+		return nil, err
+	}
+
 	return &LogicalPlan{
 		RawSQL:              sql,
 		Operation:           op,
@@ -153,9 +331,54 @@ func (p *Parser) Parse(sql string) (*LogicalPlan, error) {
 		HasTimeTravel:       hasTimeTravel,
 		TimeTravelTimestamp: timestamp,
 		TimeTravelPerTable:  perTableTimestamps,
+		ParsedTimestamps:    parsedTimestamps,
+		Columns:             columns,
+		TableAliases:        extractTableAliases(stmt),
+		OutputColumns:       outputColumns,
+		SelectColumns:       selectColumns,
+		FilterColumns:       filterColumns,
+		CartesianProducts:   cartesianProducts,
+		WherePredicates:     wherePredicates,
+		JoinConditions:      joinConditions,
+		JoinFilters:         joinFilters,
+		ColumnPredicates:    columnPredicates,
+		GroupBy:             groupBy,
+		Aggregates:          aggregates,
+		Having:              having,
+		Distinct:            distinct,
+		OrderBy:             orderBy,
+		SetOperation:        setOperation,
+		SetOperands:         setOperands,
+		HasWindowFunction:   containsOver(stmt),
+		Limit:               limit,
+		Offset:              offset,
 	}, nil
 }
 
+// extractLimit returns the row count and offset of a LIMIT clause. The
+// parser reads LIMIT m, n the same as LIMIT n OFFSET m. Either is nil when
+// absent or not an integer literal.
+func extractLimit(limit *sqlparser.Limit) (rowCount, offset *int) {
+	if limit == nil {
+		return nil, nil
+	}
+	return intLiteral(limit.Rowcount), intLiteral(limit.Offset)
+}
+
+// intLiteral returns the value of a non-negative integer literal, or nil
+// for any other expression.
+func intLiteral(expr sqlparser.Expr) *int {
+	val, ok := expr.(*sqlparser.SQLVal)
+	if !ok || val.Type != sqlparser.IntVal {
+		return nil
+	}
+	n, err := strconv.Atoi(string(val.Val))
+	if err != nil || n < 0 {
+		return nil
+	}
+	return &n
+}
+
 // extractTablesFromSelectWithAsOf extracts tables and AS OF from a SELECT statement.
 // This is the enhanced version that returns time-travel information from AST.
 // Also extracts tables from CTEs (WITH clause).
@@ -531,6 +754,17 @@ func formatTableName(tn sqlparser.TableName) string {
 	return name
 }
 
+// nullOrderingPattern matches NULLS FIRST / NULLS LAST on an ORDER BY item.
+var nullOrderingPattern = regexp.MustCompile(`(?i)\s+NULLS\s+(FIRST|LAST)\b`)
+
+// stripNullOrdering removes NULLS FIRST / NULLS LAST from ORDER BY items,
+// which the MySQL-dialect parser does not accept. Null ordering does not
+// affect the tables or columns a query reads; nullOrderings reads it from the
+// query text.
+func stripNullOrdering(sql string) string {
+	return nullOrderingPattern.ReplaceAllString(sql, "")
+}
+
 // detectTimeTravel checks for AS OF syntax in the query.
 // Returns true and the timestamp if found.
 // Note: This uses text search as vitess/sqlparser doesn't natively support AS OF.
@@ -608,12 +842,12 @@ func IsQualifiedTableName(name string) bool {
 // Returns an error if unsupported syntax is detected, nil otherwise.
 //
 // NOTE: CTEs (WITH clause) are now supported via dolthub/vitess parser (T013).
-func detectUnsupportedSyntax(sql string) error {
+func detectUnsupportedSyntax(sql string, windowFunctions bool) error {
 	upperSQL := strings.ToUpper(sql)
 
 	// Check for WINDOW functions (OVER clause)
 	// Per phase-3-spec.md §9: WINDOW functions must fail with specific error
-	if containsWindowFunction(upperSQL) {
+	if !windowFunctions && containsWindowFunction(upperSQL) {
 		return errors.NewUnsupportedSyntax(
 			"WINDOW FUNCTION (OVER clause)",
 			"simple SELECT with GROUP BY for aggregation",
@@ -623,6 +857,19 @@ func detectUnsupportedSyntax(sql string) error {
 	return nil
 }
 
+// containsOver reports whether a parsed statement calls a function with an
+// OVER clause.
+func containsOver(stmt sqlparser.Statement) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if over, ok := node.(*sqlparser.Over); ok && over != nil {
+			found = true
+		}
+		return !found, nil
+	}, stmt)
+	return found
+}
+
 // containsWindowFunction checks if the SQL contains window function syntax.
 // Window functions are identified by the OVER keyword following a function call.
 func containsWindowFunction(upperSQL string) bool {
@@ -681,6 +928,9 @@ func containsWindowFunction(upperSQL string) bool {
 // detectVendorHints checks for vendor-specific SQL hints.
 // Per phase-3-spec.md §9: Vendor-specific hints must fail with specific error.
 func detectVendorHints(sql string) error {
+	if featureSupported(FeatureVendorHint) {
+		return nil
+	}
 	upperSQL := strings.ToUpper(sql)
 
 	// MySQL-style index hints
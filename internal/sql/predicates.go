@@ -0,0 +1,39 @@
+package sql
+
+import (
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// extractWherePredicates returns the top-level AND conjuncts of the WHERE
+// clause of a SELECT, as SQL text with column references written as in the
+// query, unquoted (o.status rather than o.`status`). Each conjunct is a predicate that can be
+// evaluated on its own, so federated planning decides per conjunct whether
+// an engine or the gateway evaluates it.
+func extractWherePredicates(sel *sqlparser.Select) []string {
+	if sel.Where == nil {
+		return nil
+	}
+	var predicates []string
+	for _, conjunct := range splitConjuncts(sel.Where.Expr) {
+		predicates = append(predicates, formatPredicate(conjunct))
+	}
+	return predicates
+}
+
+// formatPredicate formats an expression, or a statement, without quoting
+// column names.
+func formatPredicate(expr sqlparser.SQLNode) string {
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		col, ok := node.(*sqlparser.ColName)
+		if !ok {
+			node.Format(buf)
+			return
+		}
+		if !col.Qualifier.IsEmpty() {
+			buf.WriteString(formatTableName(col.Qualifier) + ".")
+		}
+		buf.WriteString(col.Name.String())
+	})
+	buf.Myprintf("%v", expr)
+	return buf.String()
+}
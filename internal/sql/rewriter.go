@@ -7,11 +7,15 @@ package sql
 
 import (
 	"fmt"
+	"math"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
 )
 
 // TimeTravelClause represents a parsed time-travel clause.
@@ -30,13 +34,18 @@ type TimeTravelClause struct {
 
 	// OriginalClause is the full original clause text.
 	OriginalClause string
+
+	// Start and End are the byte offsets of OriginalClause in the query.
+	Start, End int
 }
 
 // TimeTravelRewriter rewrites unified time-travel syntax to format/engine-specific syntax.
 // Per phase-8-spec.md §1.3: SQL Rewriter for format transparency.
 type TimeTravelRewriter struct {
-	format catalog.TableFormat
-	engine string
+	format  catalog.TableFormat
+	engine  string
+	version EngineVersion
+	now     func() time.Time
 }
 
 // NewTimeTravelRewriter creates a new rewriter for the given format and engine.
@@ -47,15 +56,78 @@ func NewTimeTravelRewriter(format catalog.TableFormat, engine string) *TimeTrave
 	}
 }
 
+// SetEngineVersion sets the version the engine reported, so the rewriter can
+// choose between syntax variants. Without a version, the current syntax is
+// assumed.
+func (r *TimeTravelRewriter) SetEngineVersion(version EngineVersion) {
+	r.version = version
+}
+
+// SetClock sets the clock that relative timestamps (NOW() - INTERVAL '7' DAY)
+// are resolved against, and that future timestamps are checked against.
+// Without a clock, the system clock is used.
+func (r *TimeTravelRewriter) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// clock returns the current time of the rewriter's clock.
+func (r *TimeTravelRewriter) clock() time.Time {
+	if r.now == nil {
+		return time.Now()
+	}
+	return r.now()
+}
+
+// Minimum engine versions for the SQL AS OF time-travel syntax.
+var (
+	// Trino added FOR VERSION AS OF and FOR TIMESTAMP AS OF in release 385.
+	trinoAsOfVersion = EngineVersion{Major: 385}
+
+	// Spark added VERSION AS OF and TIMESTAMP AS OF in 3.3. Earlier
+	// releases only accept table-name suffixes.
+	sparkAsOfVersion = EngineVersion{Major: 3, Minor: 3}
+)
+
+// usesLegacySyntax reports whether the engine's reported version predates
+// the AS OF syntax.
+func (r *TimeTravelRewriter) usesLegacySyntax() bool {
+	if r.version.IsZero() {
+		return false
+	}
+	switch r.engine {
+	case "trino":
+		return r.version.Less(trinoAsOfVersion)
+	case "spark":
+		return r.version.Less(sparkAsOfVersion)
+	default:
+		return false
+	}
+}
+
 // Patterns for detecting time-travel clauses.
 var (
-	// FOR SYSTEM_TIME AS OF 'timestamp' or FOR SYSTEM_TIME AS OF timestamp
+	// FOR SYSTEM_TIME AS OF 'timestamp', FOR SYSTEM_TIME AS OF timestamp or
+	// FOR SYSTEM_TIME AS OF NOW() - INTERVAL 'n' unit
 	systemTimePattern = regexp.MustCompile(
-		`(?i)\s+FOR\s+SYSTEM_TIME\s+AS\s+OF\s+('([^']+)'|"([^"]+)"|(\S+))`)
+		`(?i)\s+FOR\s+SYSTEM_TIME\s+AS\s+OF\s+(` +
+			`(?:NOW\(\)|CURRENT_TIMESTAMP(?:\(\))?)\s*[-+]\s*INTERVAL\s+(?:'\d+'|\d+)\s+(?:DAY|HOUR|MINUTE)S?\b` +
+			`|'([^']+)'|"([^"]+)"|(\S+))`)
+
+	// The FROM or JOIN item, with an optional alias, ending the query text
+	// before a time-travel clause
+	clauseTablePattern = regexp.MustCompile(`(?i)(?:\bFROM|\bJOIN|,)\s*([\w.]+)(?:\s+(?:AS\s+)?\w+)?\s*$`)
+
+	// NOW() - INTERVAL 'n' unit, relative to the time of the rewrite
+	relativeTimePattern = regexp.MustCompile(
+		`(?i)^(?:NOW\(\)|CURRENT_TIMESTAMP(?:\(\))?)\s*([-+])\s*INTERVAL\s+'?(\d+)'?\s+(DAY|HOUR|MINUTE)S?$`)
 
 	// FOR VERSION AS OF version_id
 	versionAsOfPattern = regexp.MustCompile(
 		`(?i)\s+FOR\s+VERSION\s+AS\s+OF\s+(\d+|'[^']+')`)
+
+	// table FOR VERSION AS OF version_id
+	versionPinPattern = regexp.MustCompile(
+		`(?i)([\w.]+)(\s+FOR\s+VERSION\s+AS\s+OF\s+(\d+|'[^']+'))`)
 )
 
 // Rewrite translates unified time-travel syntax to format/engine-specific syntax.
@@ -74,16 +146,28 @@ func (r *TimeTravelRewriter) Rewrite(sql string) (string, error) {
 	}
 
 	// Rewrite each clause
-	result := sql
-	for _, clause := range clauses {
-		rewritten, err := r.rewriteClause(clause)
+	return replaceClauses(sql, clauses, r.rewriteClause)
+}
+
+// replaceClauses replaces each clause of a query, at its offsets, with its
+// rewrite. Identical clauses on several tables are each replaced in place.
+func replaceClauses(sql string, clauses []TimeTravelClause, rewrite func(TimeTravelClause) (string, error)) (string, error) {
+	ordered := append([]TimeTravelClause(nil), clauses...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Start < ordered[j].Start })
+
+	var result strings.Builder
+	last := 0
+	for _, clause := range ordered {
+		rewritten, err := rewrite(clause)
 		if err != nil {
 			return "", err
 		}
-		result = strings.Replace(result, clause.OriginalClause, rewritten, 1)
+		result.WriteString(sql[last:clause.Start])
+		result.WriteString(rewritten)
+		last = clause.End
 	}
-
-	return result, nil
+	result.WriteString(sql[last:])
+	return result.String(), nil
 }
 
 // extractTimeTravelClauses finds all time-travel clauses in the SQL.
@@ -91,38 +175,86 @@ func (r *TimeTravelRewriter) extractTimeTravelClauses(sql string) []TimeTravelCl
 	var clauses []TimeTravelClause
 
 	// Find SYSTEM_TIME AS OF clauses
-	matches := systemTimePattern.FindAllStringSubmatch(sql, -1)
+	matches := systemTimePattern.FindAllStringSubmatchIndex(sql, -1)
 	for _, match := range matches {
-		if len(match) >= 2 {
+		if len(match) >= 4 {
 			// Extract timestamp from the match
-			timestamp := match[1]
+			timestamp := sql[match[2]:match[3]]
 			// Remove quotes if present
 			timestamp = strings.Trim(timestamp, "'\"")
+			// Engines receive relative timestamps resolved
+			if resolved, ok := r.resolveRelativeTimestamp(timestamp); ok {
+				timestamp = resolved
+			}
 
 			clauses = append(clauses, TimeTravelClause{
+				TableName:      clauseTable(sql[:match[0]]),
 				ClauseType:     "SYSTEM_TIME",
 				Timestamp:      timestamp,
-				OriginalClause: match[0],
+				OriginalClause: sql[match[0]:match[1]],
+				Start:          match[0],
+				End:            match[1],
 			})
 		}
 	}
 
 	// Find VERSION AS OF clauses
-	matches = versionAsOfPattern.FindAllStringSubmatch(sql, -1)
+	matches = versionAsOfPattern.FindAllStringSubmatchIndex(sql, -1)
 	for _, match := range matches {
-		if len(match) >= 2 {
-			version := strings.Trim(match[1], "'")
+		if len(match) >= 4 {
+			version := strings.Trim(sql[match[2]:match[3]], "'")
 			clauses = append(clauses, TimeTravelClause{
+				TableName:      clauseTable(sql[:match[0]]),
 				ClauseType:     "VERSION",
 				Version:        version,
-				OriginalClause: match[0],
+				OriginalClause: sql[match[0]:match[1]],
+				Start:          match[0],
+				End:            match[1],
 			})
 		}
 	}
 
+	// Take table names from the parsed statement, which lists the tables
+	// read with a time-travel clause in query order
+	sort.Slice(clauses, func(i, j int) bool { return clauses[i].Start < clauses[j].Start })
+	if tables, ok := timeTravelTables(sql); ok && len(tables) == len(clauses) {
+		for i := range clauses {
+			clauses[i].TableName = tables[i]
+		}
+	}
+
 	return clauses
 }
 
+// timeTravelTables returns the tables of a query read with a time-travel
+// clause, in query order. ok is false when the query cannot be parsed.
+func timeTravelTables(query string) (tables []string, ok bool) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil, false
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if aliased, isAliased := node.(*sqlparser.AliasedTableExpr); isAliased && aliased.AsOf != nil {
+			if name, isTable := aliased.Expr.(sqlparser.TableName); isTable {
+				tables = append(tables, formatTableName(name))
+			}
+		}
+		return true, nil
+	}, stmt)
+	return tables, true
+}
+
+// clauseTable returns the name of the table a time-travel clause follows,
+// given the query text before the clause, or "" if none precedes it. It is
+// used for queries the parser rejects.
+func clauseTable(before string) string {
+	match := clauseTablePattern.FindStringSubmatch(before)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
 // validateTimeTravelSupport checks if time-travel is supported for this format/engine.
 // Per phase-8-spec.md §1.7: Red-Flag behavior for unsupported combinations.
 func (r *TimeTravelRewriter) validateTimeTravelSupport(clauses []TimeTravelClause) error {
@@ -169,8 +301,31 @@ func (r *TimeTravelRewriter) validateTimestamp(ts string) error {
 	if ts == "" {
 		return fmt.Errorf("time-travel: empty timestamp not allowed")
 	}
+	if ts == "?" {
+		return fmt.Errorf(
+			"time-travel: FOR SYSTEM_TIME AS OF ? has no bound value; " +
+				"bind it with RewriteWithParams")
+	}
+
+	parsedTime, err := parseTimeTravelTimestamp(ts)
+	if err != nil {
+		return err
+	}
 
-	// Try to parse common timestamp formats
+	// Reject future timestamps
+	if parsedTime.After(r.clock()) {
+		return fmt.Errorf(
+			"time-travel: timestamp %q is in the future; "+
+				"time-travel can only query historical data",
+			ts)
+	}
+
+	return nil
+}
+
+// parseTimeTravelTimestamp parses a time-travel timestamp in one of the
+// accepted ISO 8601 forms.
+func parseTimeTravelTimestamp(ts string) (time.Time, error) {
 	formats := []string{
 		time.RFC3339,
 		"2006-01-02T15:04:05Z",
@@ -178,36 +333,56 @@ func (r *TimeTravelRewriter) validateTimestamp(ts string) error {
 		"2006-01-02",
 	}
 
-	var parsedTime time.Time
-	var parseErr error
-
 	for _, format := range formats {
-		parsedTime, parseErr = time.Parse(format, ts)
-		if parseErr == nil {
-			break
+		if parsedTime, err := time.Parse(format, ts); err == nil {
+			return parsedTime, nil
 		}
 	}
 
-	if parseErr != nil {
-		return fmt.Errorf(
-			"time-travel: invalid timestamp format %q; "+
-				"expected ISO 8601 format (e.g., '2026-01-01T00:00:00Z')",
-			ts)
-	}
+	return time.Time{}, fmt.Errorf(
+		"time-travel: invalid timestamp format %q; "+
+			"expected ISO 8601 format (e.g., '2026-01-01T00:00:00Z')",
+		ts)
+}
 
-	// Reject future timestamps
-	if parsedTime.After(time.Now()) {
-		return fmt.Errorf(
-			"time-travel: timestamp %q is in the future; "+
-				"time-travel can only query historical data",
-			ts)
+// resolveRelativeTimestamp resolves a timestamp relative to the current
+// time, such as NOW() - INTERVAL '7' DAY, to an absolute UTC timestamp in
+// the rewriter's clock. ok is false for other timestamps.
+func (r *TimeTravelRewriter) resolveRelativeTimestamp(ts string) (resolved string, ok bool) {
+	match := relativeTimePattern.FindStringSubmatch(strings.TrimSpace(ts))
+	if match == nil {
+		return "", false
+	}
+	n, err := strconv.Atoi(match[2])
+	if err != nil {
+		return "", false
 	}
 
-	return nil
+	var unit time.Duration
+	switch strings.ToUpper(match[3]) {
+	case "DAY":
+		unit = 24 * time.Hour
+	case "HOUR":
+		unit = time.Hour
+	case "MINUTE":
+		unit = time.Minute
+	}
+	if int64(n) > math.MaxInt64/int64(unit) {
+		return "", false
+	}
+	offset := time.Duration(n) * unit
+	if match[1] == "-" {
+		offset = -offset
+	}
+	return r.clock().Add(offset).UTC().Format("2006-01-02 15:04:05"), true
 }
 
 // rewriteClause rewrites a single time-travel clause to format/engine-specific syntax.
 func (r *TimeTravelRewriter) rewriteClause(clause TimeTravelClause) (string, error) {
+	if r.usesLegacySyntax() {
+		return r.rewriteLegacyClause(clause)
+	}
+
 	switch clause.ClauseType {
 	case "SYSTEM_TIME":
 		return r.rewriteSystemTime(clause)
@@ -339,10 +514,50 @@ func (r *TimeTravelRewriter) rewriteDeltaVersion(version string) (string, error)
 	}
 }
 
+// rewriteLegacyClause rewrites a clause for an engine release older than the
+// AS OF syntax. Spark before 3.3 selects a snapshot with a suffix on the
+// table name, which replaces the clause; Trino before 385 has no
+// equivalent and is rejected.
+func (r *TimeTravelRewriter) rewriteLegacyClause(clause TimeTravelClause) (string, error) {
+	if r.engine != "spark" {
+		return "", fmt.Errorf(
+			"time-travel: %s %s does not support AS OF queries; upgrade to %s %s or later",
+			r.engine, r.version, r.engine, trinoAsOfVersion)
+	}
+
+	switch r.format {
+	case catalog.FormatDelta:
+		// Delta: table@v<version> or table@yyyyMMddHHmmssSSS
+		if clause.ClauseType == "VERSION" {
+			return fmt.Sprintf("@v%s", clause.Version), nil
+		}
+		ts, err := parseTimeTravelTimestamp(clause.Timestamp)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("@%s%03d", ts.UTC().Format("20060102150405"), ts.Nanosecond()/int(time.Millisecond)), nil
+	case catalog.FormatIceberg:
+		// Iceberg: table.snapshot_id_<id> or table.at_timestamp_<millis>
+		if clause.ClauseType == "VERSION" {
+			return fmt.Sprintf(".snapshot_id_%s", clause.Version), nil
+		}
+		ts, err := parseTimeTravelTimestamp(clause.Timestamp)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(".at_timestamp_%d", ts.UnixMilli()), nil
+	default:
+		return "", fmt.Errorf(
+			"time-travel: %s tables require spark %s or later; the engine reports spark %s",
+			r.format, sparkAsOfVersion, r.version)
+	}
+}
+
 // WarehouseRewriter rewrites time-travel for cloud warehouses.
 // Per phase-8-spec.md §4-6: Snowflake, BigQuery, Redshift adapters.
 type WarehouseRewriter struct {
 	warehouse string
+	formats   map[string]catalog.TableFormat
 }
 
 // NewWarehouseRewriter creates a rewriter for a specific warehouse.
@@ -350,6 +565,17 @@ func NewWarehouseRewriter(warehouse string) *WarehouseRewriter {
 	return &WarehouseRewriter{warehouse: warehouse}
 }
 
+// SetTableFormat records the format of a table as the query names it.
+// Redshift reads Iceberg tables through Spectrum with their snapshots, so
+// their time-travel clauses are translated; other Redshift tables are
+// native and reject them.
+func (r *WarehouseRewriter) SetTableFormat(table string, format catalog.TableFormat) {
+	if r.formats == nil {
+		r.formats = make(map[string]catalog.TableFormat)
+	}
+	r.formats[table] = format
+}
+
 // Rewrite translates time-travel syntax for the warehouse.
 func (r *WarehouseRewriter) Rewrite(sql string) (string, error) {
 	clauses := (&TimeTravelRewriter{}).extractTimeTravelClauses(sql)
@@ -357,16 +583,7 @@ func (r *WarehouseRewriter) Rewrite(sql string) (string, error) {
 		return sql, nil
 	}
 
-	result := sql
-	for _, clause := range clauses {
-		rewritten, err := r.rewriteClause(clause)
-		if err != nil {
-			return "", err
-		}
-		result = strings.Replace(result, clause.OriginalClause, rewritten, 1)
-	}
-
-	return result, nil
+	return replaceClauses(sql, clauses, r.rewriteClause)
 }
 
 // rewriteClause rewrites a time-travel clause for the warehouse.
@@ -377,9 +594,7 @@ func (r *WarehouseRewriter) rewriteClause(clause TimeTravelClause) (string, erro
 	case "bigquery":
 		return r.rewriteBigQuery(clause)
 	case "redshift":
-		return "", fmt.Errorf(
-			"time-travel: Redshift does not support time-travel queries; "+
-				"consider using a table with historical data or a different warehouse")
+		return r.rewriteRedshift(clause)
 	default:
 		return "", fmt.Errorf("time-travel: unknown warehouse %q", r.warehouse)
 	}
@@ -398,6 +613,28 @@ func (r *WarehouseRewriter) rewriteSnowflake(clause TimeTravelClause) (string, e
 			"use FOR SYSTEM_TIME AS OF instead")
 }
 
+// rewriteRedshift translates SYSTEM_TIME AS OF for Iceberg tables read
+// through Redshift Spectrum, and rejects time-travel on native tables.
+// Per phase-8-spec.md §6.2: native Redshift tables have no time-travel.
+func (r *WarehouseRewriter) rewriteRedshift(clause TimeTravelClause) (string, error) {
+	table := clause.TableName
+	if table == "" {
+		table = "(unknown)"
+	}
+	if r.formats[clause.TableName] != catalog.FormatIceberg {
+		return "", fmt.Errorf(
+			"time-travel: Redshift table %s is a native table and does not support time-travel queries; "+
+				"query it through Trino instead", table)
+	}
+	if clause.ClauseType == "SYSTEM_TIME" {
+		// Spectrum Iceberg: FOR SYSTEM_TIME AS OF TIMESTAMP 'ts'
+		return fmt.Sprintf(" FOR SYSTEM_TIME AS OF TIMESTAMP '%s'", clause.Timestamp), nil
+	}
+	return "", fmt.Errorf(
+		"time-travel: Redshift Spectrum does not support VERSION AS OF on table %s; "+
+			"use FOR SYSTEM_TIME AS OF, or query it through Trino instead", table)
+}
+
 // rewriteBigQuery translates to BigQuery syntax.
 // Per phase-8-spec.md §5.2: BigQuery uses similar syntax to Canonic.
 func (r *WarehouseRewriter) rewriteBigQuery(clause TimeTravelClause) (string, error) {
@@ -416,6 +653,23 @@ func HasTimeTravel(sql string) bool {
 	return systemTimePattern.MatchString(sql) || versionAsOfPattern.MatchString(sql)
 }
 
+// ExtractVersionPins returns the snapshot ID or version each table of a
+// query is pinned to with FOR VERSION AS OF, keyed by the table name as
+// written, and the query with the clauses removed. Federated planning pins
+// each table in its engine's sub-query (see TimeTravelRewriter), so the
+// rest of the query can be analyzed without them.
+func ExtractVersionPins(sql string) (pins map[string]string, stripped string) {
+	matches := versionPinPattern.FindAllStringSubmatch(sql, -1)
+	if len(matches) == 0 {
+		return nil, sql
+	}
+	pins = make(map[string]string, len(matches))
+	for _, match := range matches {
+		pins[match[1]] = strings.Trim(match[3], "'")
+	}
+	return pins, versionPinPattern.ReplaceAllString(sql, "$1")
+}
+
 // ExtractTimeTravelInfo extracts time-travel information from SQL.
 func ExtractTimeTravelInfo(sql string) (hasTimeTravel bool, timestamp string, version string) {
 	rewriter := &TimeTravelRewriter{}
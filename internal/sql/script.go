@@ -0,0 +1,56 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// StatementVerdict is the validation result of one statement of a script.
+type StatementVerdict struct {
+	// Index is the 1-based position of the statement in the script.
+	Index int
+
+	// SQL is the statement text, without the terminating semicolon.
+	SQL string
+
+	// Valid is true if the statement is a supported query.
+	Valid bool
+
+	// Err explains why the statement was rejected; nil if Valid.
+	Err error
+}
+
+// ValidateScript splits a multi-statement script and validates each
+// statement on its own, so that every rejected statement gets its own reason.
+// Splitting respects quoted literals and comments, so semicolons inside them
+// do not end a statement.
+//
+// ValidateScript is for diagnostics only: Parse still rejects any input that
+// contains more than one statement.
+func (p *Parser) ValidateScript(script string) ([]StatementVerdict, error) {
+	pieces, err := sqlparser.SplitStatementToPieces(strings.TrimSpace(script))
+	if err != nil {
+		return nil, errors.NewQueryRejected(script, "failed to split script into statements", err.Error())
+	}
+
+	verdicts := make([]StatementVerdict, 0, len(pieces))
+	for _, piece := range pieces {
+		stmt := strings.TrimSpace(piece)
+		if stmt == "" {
+			continue
+		}
+		_, err := p.Parse(stmt)
+		verdicts = append(verdicts, StatementVerdict{
+			Index: len(verdicts) + 1,
+			SQL:   stmt,
+			Valid: err == nil,
+			Err:   err,
+		})
+	}
+	if len(verdicts) == 0 {
+		return nil, errors.NewQueryRejected(script, "empty script", "provide at least one SQL statement")
+	}
+	return verdicts, nil
+}
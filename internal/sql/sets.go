@@ -0,0 +1,44 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// Set operations, as recorded in LogicalPlan.SetOperation. The DISTINCT
+// forms (UNION DISTINCT) are recorded without their keyword, as they are
+// the default.
+const (
+	SetOperationUnion        = "UNION"
+	SetOperationUnionAll     = "UNION ALL"
+	SetOperationIntersect    = "INTERSECT"
+	SetOperationIntersectAll = "INTERSECT ALL"
+	SetOperationExcept       = "EXCEPT"
+	SetOperationExceptAll    = "EXCEPT ALL"
+)
+
+// extractSetOperation returns the kind of a set operation and, when both
+// its operands are SELECTs, their SQL text, formatted like WherePredicates.
+// The operands of nested set operations are not returned.
+func extractSetOperation(setOp *sqlparser.SetOp) (kind string, operands []string) {
+	kind = strings.ToUpper(strings.TrimSuffix(setOp.Type, " distinct"))
+
+	left, leftOK := unparenSelect(setOp.Left).(*sqlparser.Select)
+	right, rightOK := unparenSelect(setOp.Right).(*sqlparser.Select)
+	if !leftOK || !rightOK {
+		return kind, nil
+	}
+	return kind, []string{formatPredicate(left), formatPredicate(right)}
+}
+
+// unparenSelect returns a statement without its enclosing parentheses.
+func unparenSelect(stmt sqlparser.SelectStatement) sqlparser.SelectStatement {
+	for {
+		paren, ok := stmt.(*sqlparser.ParenSelect)
+		if !ok {
+			return stmt
+		}
+		stmt = paren.Select
+	}
+}
@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// PinSnapshots returns a new plan in which every reference to a table in pins
+// reads AS OF the pinned timestamp. References that already carry an explicit
+// AS OF are left untouched. The rewritten SQL is re-parsed so RawSQL,
+// HasTimeTravel and TimeTravelPerTable all reflect the pins.
+func (p *Parser) PinSnapshots(plan *LogicalPlan, pins map[string]string) (*LogicalPlan, error) {
+	if len(pins) == 0 {
+		return plan, nil
+	}
+
+	stmt, err := sqlparser.Parse(plan.RawSQL)
+	if err != nil {
+		return nil, errors.NewQueryRejected(plan.RawSQL, "invalid SQL syntax", err.Error())
+	}
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok || aliased.AsOf != nil {
+			return true, nil
+		}
+		if name, ok := aliased.Expr.(sqlparser.TableName); ok {
+			if ts, ok := pins[formatTableName(name)]; ok {
+				aliased.AsOf = &sqlparser.AsOf{Time: sqlparser.NewStrVal([]byte(ts))}
+			}
+		}
+		return true, nil
+	}, stmt)
+
+	pinned, err := p.Parse(sqlparser.String(stmt))
+	if err != nil {
+		return nil, err
+	}
+	pinned.PinSnapshots = plan.PinSnapshots
+	return pinned, nil
+}
@@ -0,0 +1,166 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeTravelParamPattern matches a time-travel clause whose point in time is
+// a "?" placeholder, e.g. FOR SYSTEM_TIME AS OF ?.
+var timeTravelParamPattern = regexp.MustCompile(`(?i)\bFOR\s+(SYSTEM_TIME|VERSION)\s+AS\s+OF\s+\?`)
+
+// BindTimeTravelParams binds values to "?" placeholders used as the point in
+// time of time-travel clauses, so a dashboard can choose "as of" at
+// execution time:
+//
+//	SELECT * FROM sales.orders FOR SYSTEM_TIME AS OF ?
+//
+// Placeholders are numbered in order of appearance across the whole query,
+// ignoring "?" inside string literals and comments, and args[i] is bound to
+// the i-th placeholder. Placeholders outside time-travel clauses are left in
+// place. SYSTEM_TIME values may be a time.Time or a timestamp string and are
+// validated like literal timestamps (format, not in the future); VERSION
+// values must be non-negative integers.
+//
+// The result is ready for TimeTravelRewriter.Rewrite.
+func BindTimeTravelParams(sql string, args []interface{}) (string, error) {
+	matches := timeTravelParamPattern.FindAllStringSubmatchIndex(sql, -1)
+	if len(matches) == 0 {
+		return sql, nil
+	}
+
+	ordinals := placeholderOrdinals(sql)
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		// The placeholder is the last character of the match
+		pos := m[1] - 1
+		ordinal, ok := ordinals[pos]
+		if !ok {
+			// Inside a string literal or comment
+			continue
+		}
+		if ordinal >= len(args) {
+			return "", fmt.Errorf("time-travel: no value bound for parameter %d", ordinal+1)
+		}
+
+		literal, err := timeTravelLiteral(strings.ToUpper(sql[m[2]:m[3]]), ordinal, args[ordinal])
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(sql[last:pos])
+		b.WriteString(literal)
+		last = pos + 1
+	}
+	b.WriteString(sql[last:])
+	return b.String(), nil
+}
+
+// RewriteWithParams binds time-travel parameters with BindTimeTravelParams
+// and rewrites the result.
+func (r *TimeTravelRewriter) RewriteWithParams(sql string, args []interface{}) (string, error) {
+	bound, err := BindTimeTravelParams(sql, args)
+	if err != nil {
+		return "", err
+	}
+	return r.Rewrite(bound)
+}
+
+// timeTravelLiteral renders the value bound to parameter ordinal as the
+// literal of a time-travel clause.
+func timeTravelLiteral(clauseType string, ordinal int, value interface{}) (string, error) {
+	if clauseType == "VERSION" {
+		version, ok := timeTravelVersion(value)
+		if !ok {
+			return "", fmt.Errorf(
+				"time-travel: parameter %d: expected a non-negative integer version for VERSION AS OF, got %v",
+				ordinal+1, value)
+		}
+		return version, nil
+	}
+
+	var ts string
+	switch v := value.(type) {
+	case time.Time:
+		ts = v.UTC().Format(time.RFC3339)
+	case string:
+		ts = strings.TrimSpace(v)
+	default:
+		return "", fmt.Errorf(
+			"time-travel: parameter %d: expected a timestamp for FOR SYSTEM_TIME AS OF, got %T",
+			ordinal+1, value)
+	}
+	if err := (&TimeTravelRewriter{}).validateTimestamp(ts); err != nil {
+		return "", err
+	}
+	return "'" + ts + "'", nil
+}
+
+// timeTravelVersion renders a bound version or snapshot ID.
+func timeTravelVersion(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int:
+		if v >= 0 {
+			return strconv.Itoa(v), true
+		}
+	case int32:
+		if v >= 0 {
+			return strconv.FormatInt(int64(v), 10), true
+		}
+	case int64:
+		if v >= 0 {
+			return strconv.FormatInt(v, 10), true
+		}
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case string:
+		if _, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64); err == nil {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", false
+}
+
+// placeholderOrdinals maps the byte offset of every "?" placeholder to its
+// zero-based ordinal, skipping string literals, quoted identifiers and
+// comments.
+func placeholderOrdinals(sql string) map[int]int {
+	ordinals := make(map[int]int)
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			// Skip to the closing quote; doubled quotes are escapes
+			for i++; i < len(sql); i++ {
+				if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return ordinals
+			}
+			i += end + 3
+		case c == '?':
+			ordinals[i] = n
+			n++
+		}
+	}
+	return ordinals
+}
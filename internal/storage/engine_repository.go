@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// EngineDefinition is a stored engine adapter configuration.
+// The gateway constructs adapters from these at startup and on refresh, so
+// engines can be added or removed without a restart.
+type EngineDefinition struct {
+	// Name is the engine name used in routing (e.g. "trino", "trino-eu").
+	Name string
+
+	// Type selects the adapter implementation: "duckdb", "trino", "spark", ...
+	Type string
+
+	// Endpoint is the engine URL (e.g. "http://trino.internal:8080").
+	Endpoint string
+
+	// Database is the default database, catalog or file path.
+	Database string
+
+	// User is the user the adapter connects as.
+	User string
+
+	// CredentialRef names the secret holding the engine credential. It is
+	// resolved through a secrets provider; the secret itself is never stored.
+	CredentialRef string
+
+	// Capabilities the engine supports (e.g. "READ", "TIME_TRAVEL").
+	Capabilities []string
+
+	// SessionProperties are engine session properties applied to every
+	// query. Values are templates resolved from the request context
+	// (e.g. "{{.Role}}"); see adapters.SessionProperties.
+	SessionProperties map[string]string
+
+	// Enabled engines are registered; disabled ones are kept but not loaded.
+	Enabled bool
+}
+
+// Validate checks that the definition has the fields needed to build an adapter.
+func (d *EngineDefinition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("engine definition: name is required")
+	}
+	if d.Type == "" {
+		return fmt.Errorf("engine definition %s: type is required", d.Name)
+	}
+	return nil
+}
+
+// EngineRepository persists engine definitions.
+// Implementations must be thread-safe and context-aware.
+type EngineRepository interface {
+	// Save creates or replaces an engine definition.
+	Save(ctx context.Context, def *EngineDefinition) error
+
+	// Get retrieves an engine definition by name.
+	// Returns ErrEngineNotFound if it does not exist.
+	Get(ctx context.Context, name string) (*EngineDefinition, error)
+
+	// Delete removes an engine definition.
+	// Returns ErrEngineNotFound if it does not exist.
+	Delete(ctx context.Context, name string) error
+
+	// List returns all engine definitions, sorted by name.
+	List(ctx context.Context) ([]*EngineDefinition, error)
+}
+
+// PostgresEngineRepository implements EngineRepository using PostgreSQL.
+type PostgresEngineRepository struct {
+	db      *sql.DB
+	replica *ReadReplica // optional: serves reads
+}
+
+// NewPostgresEngineRepository creates a new PostgreSQL engine repository.
+func NewPostgresEngineRepository(db *sql.DB) *PostgresEngineRepository {
+	return &PostgresEngineRepository{db: db}
+}
+
+// SetReadReplica routes reads to a read replica; see
+// PostgresRepository.SetReadReplica.
+func (r *PostgresEngineRepository) SetReadReplica(replica *ReadReplica) {
+	r.replica = replica
+}
+
+// reader returns the connection for reading the engine name, or for reads
+// spanning every engine when name is empty.
+func (r *PostgresEngineRepository) reader(name string) *sql.DB {
+	if r.replica == nil {
+		return r.db
+	}
+	return r.replica.Reader(engineReplicaKey(name))
+}
+
+// wrote records a write of the engine name for read-your-writes routing.
+func (r *PostgresEngineRepository) wrote(name string) {
+	if r.replica != nil {
+		r.replica.Wrote(engineReplicaKey(name))
+	}
+}
+
+// engineReplicaKey keeps engine keys distinct from table keys when a
+// ReadReplica is shared by both repositories.
+func engineReplicaKey(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "engine:" + name
+}
+
+// Save creates or replaces an engine definition.
+func (r *PostgresEngineRepository) Save(ctx context.Context, def *EngineDefinition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	sessionProps := ""
+	if len(def.SessionProperties) > 0 {
+		data, err := json.Marshal(def.SessionProperties)
+		if err != nil {
+			return fmt.Errorf("failed to encode session properties of engine %s: %w", def.Name, err)
+		}
+		sessionProps = string(data)
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO engines (name, engine_type, endpoint, database_name, username, credential_ref, capabilities, session_properties, enabled)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (name) DO UPDATE SET
+		     engine_type = EXCLUDED.engine_type,
+		     endpoint = EXCLUDED.endpoint,
+		     database_name = EXCLUDED.database_name,
+		     username = EXCLUDED.username,
+		     credential_ref = EXCLUDED.credential_ref,
+		     capabilities = EXCLUDED.capabilities,
+		     session_properties = EXCLUDED.session_properties,
+		     enabled = EXCLUDED.enabled`,
+		def.Name, def.Type, def.Endpoint, def.Database, def.User, def.CredentialRef,
+		strings.Join(def.Capabilities, ","), sessionProps, def.Enabled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save engine %s: %w", def.Name, err)
+	}
+	r.wrote(def.Name)
+	return nil
+}
+
+// Get retrieves an engine definition by name.
+func (r *PostgresEngineRepository) Get(ctx context.Context, name string) (*EngineDefinition, error) {
+	row := r.reader(name).QueryRowContext(ctx,
+		`SELECT name, engine_type, endpoint, database_name, username, credential_ref, capabilities, session_properties, enabled
+		 FROM engines WHERE name = $1`,
+		name,
+	)
+	def, err := scanEngineDefinition(row)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewEngineNotFound(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get engine %s: %w", name, err)
+	}
+	return def, nil
+}
+
+// Delete removes an engine definition.
+func (r *PostgresEngineRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM engines WHERE name = $1", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete engine %s: %w", name, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return errors.NewEngineNotFound(name)
+	}
+	r.wrote(name)
+	return nil
+}
+
+// List returns all engine definitions, sorted by name.
+func (r *PostgresEngineRepository) List(ctx context.Context) ([]*EngineDefinition, error) {
+	rows, err := r.reader("").QueryContext(ctx,
+		`SELECT name, engine_type, endpoint, database_name, username, credential_ref, capabilities, session_properties, enabled
+		 FROM engines ORDER BY name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list engines: %w", err)
+	}
+	defer rows.Close()
+
+	defs := make([]*EngineDefinition, 0)
+	for rows.Next() {
+		def, err := scanEngineDefinition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan engine: %w", err)
+		}
+		defs = append(defs, def)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating engines: %w", err)
+	}
+	return defs, nil
+}
+
+// scanEngineDefinition scans one engines row.
+func scanEngineDefinition(row interface{ Scan(...interface{}) error }) (*EngineDefinition, error) {
+	var def EngineDefinition
+	var caps, sessionProps string
+	if err := row.Scan(&def.Name, &def.Type, &def.Endpoint, &def.Database, &def.User,
+		&def.CredentialRef, &caps, &sessionProps, &def.Enabled); err != nil {
+		return nil, err
+	}
+	if caps != "" {
+		def.Capabilities = strings.Split(caps, ",")
+	}
+	if sessionProps != "" {
+		if err := json.Unmarshal([]byte(sessionProps), &def.SessionProperties); err != nil {
+			return nil, fmt.Errorf("invalid session properties for engine %s: %w", def.Name, err)
+		}
+	}
+	return &def, nil
+}
+
+// MockEngineRepository is an in-memory implementation of EngineRepository for testing.
+type MockEngineRepository struct {
+	mu      sync.RWMutex
+	engines map[string]*EngineDefinition
+}
+
+// NewMockEngineRepository creates a new mock engine repository.
+func NewMockEngineRepository() *MockEngineRepository {
+	return &MockEngineRepository{engines: make(map[string]*EngineDefinition)}
+}
+
+// Save creates or replaces an engine definition.
+func (r *MockEngineRepository) Save(ctx context.Context, def *EngineDefinition) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.engines[def.Name] = copyEngineDefinition(def)
+	return nil
+}
+
+// Get retrieves an engine definition by name.
+func (r *MockEngineRepository) Get(ctx context.Context, name string) (*EngineDefinition, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.engines[name]
+	if !ok {
+		return nil, errors.NewEngineNotFound(name)
+	}
+	return copyEngineDefinition(def), nil
+}
+
+// Delete removes an engine definition.
+func (r *MockEngineRepository) Delete(ctx context.Context, name string) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.engines[name]; !ok {
+		return errors.NewEngineNotFound(name)
+	}
+	delete(r.engines, name)
+	return nil
+}
+
+// List returns all engine definitions, sorted by name.
+func (r *MockEngineRepository) List(ctx context.Context) ([]*EngineDefinition, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]*EngineDefinition, 0, len(r.engines))
+	for _, def := range r.engines {
+		defs = append(defs, copyEngineDefinition(def))
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs, nil
+}
+
+// copyEngineDefinition creates a deep copy of an engine definition.
+func copyEngineDefinition(src *EngineDefinition) *EngineDefinition {
+	dst := *src
+	dst.Capabilities = append([]string(nil), src.Capabilities...)
+	if src.SessionProperties != nil {
+		dst.SessionProperties = make(map[string]string, len(src.SessionProperties))
+		for name, value := range src.SessionProperties {
+			dst.SessionProperties[name] = value
+		}
+	}
+	return &dst
+}
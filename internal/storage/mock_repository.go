@@ -178,10 +178,14 @@ func (r *MockRepository) Exists(ctx context.Context, name string) (bool, error)
 // copyTable creates a deep copy of a virtual table.
 func copyTable(src *tables.VirtualTable) *tables.VirtualTable {
 	dst := &tables.VirtualTable{
-		Name:        src.Name,
-		Description: src.Description,
-		CreatedAt:   src.CreatedAt,
-		UpdatedAt:   src.UpdatedAt,
+		Name:               src.Name,
+		Description:        src.Description,
+		RequireSchema:      src.RequireSchema,
+		Deprecated:         src.Deprecated,
+		DeprecationMessage: src.DeprecationMessage,
+		FormatOverride:     src.FormatOverride,
+		CreatedAt:          src.CreatedAt,
+		UpdatedAt:          src.UpdatedAt,
 	}
 
 	// Copy sources
@@ -202,6 +206,12 @@ func copyTable(src *tables.VirtualTable) *tables.VirtualTable {
 		copy(dst.Constraints, src.Constraints)
 	}
 
+	// Copy columns
+	if len(src.Columns) > 0 {
+		dst.Columns = make([]tables.Column, len(src.Columns))
+		copy(dst.Columns, src.Columns)
+	}
+
 	return dst
 }
 
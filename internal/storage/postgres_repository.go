@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -15,7 +16,8 @@ import (
 // PostgresRepository implements TableRepository using PostgreSQL.
 // This is the production implementation per docs/plan.md.
 type PostgresRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	replica *ReadReplica // optional: serves reads
 }
 
 // PostgresConfig configures the PostgreSQL repository.
@@ -38,6 +40,30 @@ func NewPostgresRepository(db *sql.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
+// SetReadReplica routes reads to a read replica; writes keep using the
+// primary connection the repository was created with, which must be the
+// replica's primary. Tables written within the replica's lag window are
+// read from the primary.
+func (r *PostgresRepository) SetReadReplica(replica *ReadReplica) {
+	r.replica = replica
+}
+
+// reader returns the connection for reading the table name, or for reads
+// spanning every table when name is empty.
+func (r *PostgresRepository) reader(name string) *sql.DB {
+	if r.replica == nil {
+		return r.db
+	}
+	return r.replica.Reader(name)
+}
+
+// wrote records a write of the table name for read-your-writes routing.
+func (r *PostgresRepository) wrote(name string) {
+	if r.replica != nil {
+		r.replica.Wrote(name)
+	}
+}
+
 // Create registers a new virtual table.
 func (r *PostgresRepository) Create(ctx context.Context, table *tables.VirtualTable) error {
 	// Validate table definition first
@@ -68,10 +94,10 @@ func (r *PostgresRepository) Create(ctx context.Context, table *tables.VirtualTa
 	// Insert virtual table
 	var tableID string
 	err = tx.QueryRowContext(ctx,
-		`INSERT INTO virtual_tables (name, description) 
-		 VALUES ($1, $2) 
+		`INSERT INTO virtual_tables (name, description, require_schema, deprecated, deprecation_message, format_override) 
+		 VALUES ($1, $2, $3, $4, $5, $6) 
 		 RETURNING id`,
-		table.Name, table.Description,
+		table.Name, table.Description, table.RequireSchema, table.Deprecated, table.DeprecationMessage, string(table.FormatOverride),
 	).Scan(&tableID)
 	if err != nil {
 		return fmt.Errorf("failed to insert virtual table: %w", err)
@@ -113,10 +139,16 @@ func (r *PostgresRepository) Create(ctx context.Context, table *tables.VirtualTa
 		}
 	}
 
+	// Insert columns
+	if err := insertColumns(ctx, tx, tableID, table.Columns); err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	r.wrote(table.Name)
 
 	return nil
 }
@@ -127,16 +159,20 @@ func (r *PostgresRepository) Get(ctx context.Context, name string) (*tables.Virt
 		return nil, errors.NewInvalidTableDefinition("name", "cannot be empty")
 	}
 
+	db := r.reader(name)
+
 	// Get virtual table
 	var tableID string
 	var description sql.NullString
+	var requireSchema, deprecated bool
+	var deprecationMessage, formatOverride string
 	var createdAt, updatedAt time.Time
 
-	err := r.db.QueryRowContext(ctx,
-		`SELECT id, description, created_at, updated_at 
+	err := db.QueryRowContext(ctx,
+		`SELECT id, description, require_schema, deprecated, deprecation_message, format_override, created_at, updated_at 
 		 FROM virtual_tables WHERE name = $1`,
 		name,
-	).Scan(&tableID, &description, &createdAt, &updatedAt)
+	).Scan(&tableID, &description, &requireSchema, &deprecated, &deprecationMessage, &formatOverride, &createdAt, &updatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, errors.NewTableNotFound(name)
@@ -146,14 +182,18 @@ func (r *PostgresRepository) Get(ctx context.Context, name string) (*tables.Virt
 	}
 
 	table := &tables.VirtualTable{
-		Name:        name,
-		Description: description.String,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		Name:               name,
+		Description:        description.String,
+		RequireSchema:      requireSchema,
+		Deprecated:         deprecated,
+		DeprecationMessage: deprecationMessage,
+		FormatOverride:     tables.StorageFormat(formatOverride),
+		CreatedAt:          createdAt,
+		UpdatedAt:          updatedAt,
 	}
 
 	// Get physical sources
-	rows, err := r.db.QueryContext(ctx,
+	rows, err := db.QueryContext(ctx,
 		`SELECT format, location, engine 
 		 FROM physical_sources WHERE virtual_table_id = $1`,
 		tableID,
@@ -180,7 +220,7 @@ func (r *PostgresRepository) Get(ctx context.Context, name string) (*tables.Virt
 	}
 
 	// Get capabilities
-	rows, err = r.db.QueryContext(ctx,
+	rows, err = db.QueryContext(ctx,
 		`SELECT capability FROM table_capabilities WHERE virtual_table_id = $1`,
 		tableID,
 	)
@@ -194,14 +234,14 @@ func (r *PostgresRepository) Get(ctx context.Context, name string) (*tables.Virt
 		if err := rows.Scan(&cap); err != nil {
 			return nil, fmt.Errorf("failed to scan capability: %w", err)
 		}
-		table.Capabilities = append(table.Capabilities, capabilities.Capability(cap))
+		table.Capabilities = append(table.Capabilities, capabilities.Capability(strings.ToUpper(cap)))
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating capabilities: %w", err)
 	}
 
 	// Get constraints
-	rows, err = r.db.QueryContext(ctx,
+	rows, err = db.QueryContext(ctx,
 		`SELECT constraint_type FROM table_constraints WHERE virtual_table_id = $1`,
 		tableID,
 	)
@@ -215,12 +255,34 @@ func (r *PostgresRepository) Get(ctx context.Context, name string) (*tables.Virt
 		if err := rows.Scan(&con); err != nil {
 			return nil, fmt.Errorf("failed to scan constraint: %w", err)
 		}
-		table.Constraints = append(table.Constraints, capabilities.Constraint(con))
+		table.Constraints = append(table.Constraints, capabilities.Constraint(strings.ToUpper(con)))
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating constraints: %w", err)
 	}
 
+	// Get columns
+	rows, err = db.QueryContext(ctx,
+		`SELECT name, data_type, nullable FROM table_columns
+		 WHERE virtual_table_id = $1 ORDER BY ordinal`,
+		tableID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var col tables.Column
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", err)
+	}
+
 	return table, nil
 }
 
@@ -253,8 +315,8 @@ func (r *PostgresRepository) Update(ctx context.Context, table *tables.VirtualTa
 
 	// Update virtual table
 	_, err = tx.ExecContext(ctx,
-		`UPDATE virtual_tables SET description = $1, updated_at = NOW() WHERE id = $2`,
-		table.Description, tableID,
+		`UPDATE virtual_tables SET description = $1, require_schema = $2, deprecated = $3, deprecation_message = $4, format_override = $5, updated_at = NOW() WHERE id = $6`,
+		table.Description, table.RequireSchema, table.Deprecated, table.DeprecationMessage, string(table.FormatOverride), tableID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update virtual table: %w", err)
@@ -308,11 +370,36 @@ func (r *PostgresRepository) Update(ctx context.Context, table *tables.VirtualTa
 		}
 	}
 
+	// Delete and re-insert columns
+	_, err = tx.ExecContext(ctx, "DELETE FROM table_columns WHERE virtual_table_id = $1", tableID)
+	if err != nil {
+		return fmt.Errorf("failed to delete columns: %w", err)
+	}
+	if err := insertColumns(ctx, tx, tableID, table.Columns); err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	r.wrote(table.Name)
+
+	return nil
+}
 
+// insertColumns stores a table's column schema in declaration order.
+func insertColumns(ctx context.Context, tx *sql.Tx, tableID string, columns []tables.Column) error {
+	for i, col := range columns {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO table_columns (virtual_table_id, ordinal, name, data_type, nullable)
+			 VALUES ($1, $2, $3, $4, $5)`,
+			tableID, i, col.Name, col.Type, col.Nullable,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert column: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -337,13 +424,14 @@ func (r *PostgresRepository) Delete(ctx context.Context, name string) error {
 	if rowsAffected == 0 {
 		return errors.NewTableNotFound(name)
 	}
+	r.wrote(name)
 
 	return nil
 }
 
 // List returns all registered virtual tables.
 func (r *PostgresRepository) List(ctx context.Context) ([]*tables.VirtualTable, error) {
-	rows, err := r.db.QueryContext(ctx,
+	rows, err := r.reader("").QueryContext(ctx,
 		"SELECT name FROM virtual_tables ORDER BY name",
 	)
 	if err != nil {
@@ -379,7 +467,7 @@ func (r *PostgresRepository) List(ctx context.Context) ([]*tables.VirtualTable,
 // Exists checks if a table with the given name exists.
 func (r *PostgresRepository) Exists(ctx context.Context, name string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRowContext(ctx,
+	err := r.reader(name).QueryRowContext(ctx,
 		"SELECT EXISTS(SELECT 1 FROM virtual_tables WHERE name = $1)",
 		name,
 	).Scan(&exists)
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultReplicaLag is the read-your-writes window of a ReadReplica when
+// none is configured.
+const DefaultReplicaLag = 5 * time.Second
+
+// ReadReplica routes read-only queries to a read replica of the primary
+// database, so metadata reads do not compete with writes.
+//
+// Replication is asynchronous: a replica may not yet have a row written on
+// the primary. To keep read-your-writes behavior, reads of a key written
+// within the lag window (for example a table just registered or dropped) go
+// to the primary; all other reads go to the replica. The window should be at
+// least the replica's typical replication lag.
+type ReadReplica struct {
+	primary *sql.DB
+	replica *sql.DB
+	lag     time.Duration
+
+	mu     sync.Mutex
+	writes map[string]time.Time // key → time of the last write; "" is any key
+}
+
+// NewReadReplica creates a read replica router. A zero lag selects
+// DefaultReplicaLag.
+func NewReadReplica(primary, replica *sql.DB, lag time.Duration) (*ReadReplica, error) {
+	if primary == nil || replica == nil {
+		return nil, fmt.Errorf("read replica requires both a primary and a replica connection")
+	}
+	if lag < 0 {
+		return nil, fmt.Errorf("read replica lag must not be negative, got %s", lag)
+	}
+	if lag == 0 {
+		lag = DefaultReplicaLag
+	}
+	return &ReadReplica{
+		primary: primary,
+		replica: replica,
+		lag:     lag,
+		writes:  make(map[string]time.Time),
+	}, nil
+}
+
+// Primary returns the primary connection, used for all writes.
+func (r *ReadReplica) Primary() *sql.DB {
+	return r.primary
+}
+
+// Replica returns the replica connection.
+func (r *ReadReplica) Replica() *sql.DB {
+	return r.replica
+}
+
+// Lag returns the read-your-writes window.
+func (r *ReadReplica) Lag() time.Duration {
+	return r.lag
+}
+
+// Reader returns the connection for reading key: the primary if key was
+// written within the lag window, otherwise the replica. The empty key is for
+// reads spanning every key, such as listings, and goes to the primary after
+// any recent write.
+func (r *ReadReplica) Reader(key string) *sql.DB {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if written, ok := r.writes[key]; ok {
+		if time.Since(written) < r.lag {
+			return r.primary
+		}
+		delete(r.writes, key)
+	}
+	return r.replica
+}
+
+// Wrote records a write of key on the primary.
+func (r *ReadReplica) Wrote(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	// Forget writes the replica has caught up with
+	for k, written := range r.writes {
+		if now.Sub(written) >= r.lag {
+			delete(r.writes, k)
+		}
+	}
+	r.writes[key] = now
+	r.writes[""] = now
+}
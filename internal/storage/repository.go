@@ -85,7 +85,7 @@ func DetectMetadataConflict(ctx context.Context, tableName string, primary, seco
 
 // tablesMatch checks if two virtual tables have the same definition.
 func tablesMatch(a, b *tables.VirtualTable) bool {
-	if a.Name != b.Name {
+	if a.Name != b.Name || a.FormatOverride != b.FormatOverride {
 		return false
 	}
 
@@ -0,0 +1,92 @@
+package tables
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// IsRawFile reports whether the format is a raw file format without a table
+// layer, so reads bypass table-level governance such as snapshots and schema
+// evolution.
+func (f StorageFormat) IsRawFile() bool {
+	return strings.EqualFold(string(f), string(FormatParquet))
+}
+
+// SafeMode is the policy for locked-down deployments.
+// In safe mode, federation is disabled, raw-file sources are rejected and
+// queries may only run on the allowed engines.
+// A nil *SafeMode means safe mode is disabled; all methods accept nil.
+type SafeMode struct {
+	// AllowedEngines lists the engines queries may run on.
+	// Empty allows every engine.
+	AllowedEngines []string
+}
+
+// Enabled reports whether safe mode is in effect.
+func (m *SafeMode) Enabled() bool {
+	return m != nil
+}
+
+// EngineAllowed reports whether queries may run on engine.
+func (m *SafeMode) EngineAllowed(engine string) bool {
+	if m == nil || len(m.AllowedEngines) == 0 {
+		return true
+	}
+	for _, allowed := range m.AllowedEngines {
+		if strings.EqualFold(allowed, engine) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEngine returns ErrEngineNotAllowed if engine is not on the allowlist.
+func (m *SafeMode) CheckEngine(engine string) error {
+	if !m.EngineAllowed(engine) {
+		return errors.NewEngineNotAllowed(engine, m.AllowedEngines)
+	}
+	return nil
+}
+
+// CheckTable rejects tables backed by raw-file sources or pinned to an
+// engine outside the allowlist.
+func (m *SafeMode) CheckTable(vt *VirtualTable) error {
+	if m == nil {
+		return nil
+	}
+	for i, src := range vt.Sources {
+		if src.Format.IsRawFile() {
+			return errors.NewInvalidTableDefinition(
+				fmt.Sprintf("sources[%d].format", i),
+				fmt.Sprintf("raw-file format %s is not allowed in safe mode", src.Format),
+			)
+		}
+		if src.Engine != "" {
+			if err := m.CheckEngine(src.Engine); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CheckEngines rejects queries that span more than one engine, since safe
+// mode disables federation, and queries on engines outside the allowlist.
+func (m *SafeMode) CheckEngines(engines []string) error {
+	if m == nil {
+		return nil
+	}
+	if len(engines) > 1 {
+		err := errors.NewCrossEngineQuery(engines)
+		err.Suggestion = "federation is disabled in safe mode; ensure all tables use the same engine"
+		return err
+	}
+	for _, engine := range engines {
+		if err := m.CheckEngine(engine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package tables
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -24,12 +25,33 @@ type VirtualTable struct {
 	// Sources are the physical storage locations backing this table.
 	Sources []PhysicalSource `json:"sources"`
 
+	// FormatOverride, when set, replaces the format detected for the table
+	// (the format of its first source) when routing queries. It corrects
+	// catalogs that misreport a table's format.
+	FormatOverride StorageFormat `json:"format_override,omitempty"`
+
 	// Capabilities are the operations this table supports.
 	Capabilities []capabilities.Capability `json:"capabilities"`
 
 	// Constraints are restrictions on table operations.
 	Constraints []capabilities.Constraint `json:"constraints"`
 
+	// Columns is the synced column schema. Empty until the schema is synced
+	// from a catalog or declared explicitly.
+	Columns []Column `json:"columns,omitempty"`
+
+	// RequireSchema rejects queries against this table while Columns is empty,
+	// regardless of the global require-schema policy.
+	RequireSchema bool `json:"require_schema,omitempty"`
+
+	// Deprecated marks a table scheduled for removal. Queries still succeed
+	// but receive a warning.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// DeprecationMessage explains the deprecation, typically naming the
+	// replacement table.
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+
 	// CreatedAt is when the table was registered.
 	CreatedAt time.Time `json:"created_at"`
 
@@ -55,6 +77,18 @@ type PhysicalSource struct {
 	Engine string `json:"engine,omitempty"`
 }
 
+// Column describes a column of a virtual table.
+type Column struct {
+	// Name is the column name.
+	Name string `json:"name"`
+
+	// Type is the engine type string (e.g., BIGINT, VARCHAR).
+	Type string `json:"type"`
+
+	// Nullable indicates the column may contain NULLs.
+	Nullable bool `json:"nullable"`
+}
+
 // StorageFormat represents the physical storage format.
 type StorageFormat string
 
@@ -105,6 +139,46 @@ func (vt *VirtualTable) HasConstraint(con capabilities.Constraint) bool {
 	return vt.ConstraintSet().Has(con)
 }
 
+// HasSchema reports whether the table has a synced column schema.
+func (vt *VirtualTable) HasSchema() bool {
+	return len(vt.Columns) > 0
+}
+
+// DeprecationWarning returns the warning attached to queries on a deprecated
+// table, or "" if the table is not deprecated.
+func (vt *VirtualTable) DeprecationWarning() string {
+	if !vt.Deprecated {
+		return ""
+	}
+	if vt.DeprecationMessage == "" {
+		return fmt.Sprintf("table %s is deprecated", vt.Name)
+	}
+	return fmt.Sprintf("table %s is deprecated: %s", vt.Name, vt.DeprecationMessage)
+}
+
+// EffectiveFormat returns the format queries are routed by: FormatOverride
+// if set, otherwise the detected format of the first source.
+func (vt *VirtualTable) EffectiveFormat() StorageFormat {
+	if vt.FormatOverride != "" {
+		return vt.FormatOverride
+	}
+	if len(vt.Sources) == 0 {
+		return ""
+	}
+	return vt.Sources[0].Format
+}
+
+// FormatOverrideNotice returns a notice for logs when FormatOverride differs
+// from the detected format, or "" if there is no such override.
+func (vt *VirtualTable) FormatOverrideNotice() string {
+	if vt.FormatOverride == "" || len(vt.Sources) == 0 ||
+		strings.EqualFold(string(vt.FormatOverride), string(vt.Sources[0].Format)) {
+		return ""
+	}
+	return fmt.Sprintf("table %s: format override %s replaces detected format %s",
+		vt.Name, vt.FormatOverride, vt.Sources[0].Format)
+}
+
 // CanPerform checks if an operation can be performed on this table.
 // Returns nil if allowed, or an error explaining why it's forbidden.
 func (vt *VirtualTable) CanPerform(op capabilities.OperationType) error {
@@ -156,6 +230,13 @@ func (vt *VirtualTable) Validate() error {
 		}
 	}
 
+	if vt.FormatOverride != "" && !vt.FormatOverride.IsValid() {
+		return errors.NewInvalidTableDefinition(
+			"format_override",
+			fmt.Sprintf("invalid format: %s (valid: %v)", vt.FormatOverride, AllFormats()),
+		)
+	}
+
 	// Validate capabilities
 	for i, cap := range vt.Capabilities {
 		if !cap.IsValid() {
@@ -176,6 +257,24 @@ func (vt *VirtualTable) Validate() error {
 		}
 	}
 
+	// Validate columns
+	seenColumns := make(map[string]bool, len(vt.Columns))
+	for i, col := range vt.Columns {
+		if col.Name == "" {
+			return errors.NewInvalidTableDefinition(
+				fmt.Sprintf("columns[%d].name", i),
+				"required",
+			)
+		}
+		if seenColumns[strings.ToLower(col.Name)] {
+			return errors.NewInvalidTableDefinition(
+				fmt.Sprintf("columns[%d].name", i),
+				fmt.Sprintf("duplicate column: %s", col.Name),
+			)
+		}
+		seenColumns[strings.ToLower(col.Name)] = true
+	}
+
 	// Check for conflicting sources (same format, different locations)
 	// This would create ambiguity in which source to use
 	formatLocations := make(map[StorageFormat]string)
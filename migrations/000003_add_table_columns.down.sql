@@ -0,0 +1,4 @@
+-- Rollback column schemas and the require-schema policy
+DROP INDEX IF EXISTS idx_table_columns_table;
+DROP TABLE IF EXISTS table_columns;
+ALTER TABLE virtual_tables DROP COLUMN IF EXISTS require_schema;
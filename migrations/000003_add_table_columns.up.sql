@@ -0,0 +1,18 @@
+-- Add column schemas and the per-table require-schema policy
+-- Tables registered before their schema is synced have no rows in table_columns.
+
+ALTER TABLE virtual_tables
+    ADD COLUMN IF NOT EXISTS require_schema BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS table_columns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    virtual_table_id UUID NOT NULL REFERENCES virtual_tables(id) ON DELETE CASCADE,
+    ordinal INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    data_type VARCHAR(255) NOT NULL DEFAULT '',
+    nullable BOOLEAN NOT NULL DEFAULT TRUE,
+
+    CONSTRAINT unique_table_column UNIQUE (virtual_table_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_table_columns_table ON table_columns(virtual_table_id);
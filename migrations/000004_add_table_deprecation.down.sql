@@ -0,0 +1,3 @@
+-- Rollback table deprecation
+ALTER TABLE virtual_tables DROP COLUMN IF EXISTS deprecation_message;
+ALTER TABLE virtual_tables DROP COLUMN IF EXISTS deprecated;
@@ -0,0 +1,6 @@
+-- Add table deprecation
+-- Deprecated tables remain queryable; queries receive a warning pointing to the replacement.
+
+ALTER TABLE virtual_tables
+    ADD COLUMN IF NOT EXISTS deprecated BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS deprecation_message TEXT NOT NULL DEFAULT '';
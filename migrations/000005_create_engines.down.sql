@@ -0,0 +1,3 @@
+-- Rollback engines table
+DROP TRIGGER IF EXISTS update_engines_updated_at ON engines;
+DROP TABLE IF EXISTS engines;
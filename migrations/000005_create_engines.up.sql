@@ -0,0 +1,20 @@
+-- Migration: Create engines table
+-- Description: Engine adapter definitions loaded by the gateway at startup and on refresh
+
+CREATE TABLE IF NOT EXISTS engines (
+    name VARCHAR(100) PRIMARY KEY,
+    engine_type VARCHAR(50) NOT NULL,
+    endpoint TEXT NOT NULL DEFAULT '',
+    database_name VARCHAR(255) NOT NULL DEFAULT '',
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    credential_ref VARCHAR(255) NOT NULL DEFAULT '',
+    capabilities TEXT NOT NULL DEFAULT '', -- comma-separated
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_engines_updated_at
+    BEFORE UPDATE ON engines
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Rollback per-table format override
+ALTER TABLE virtual_tables DROP COLUMN IF EXISTS format_override;
@@ -0,0 +1,5 @@
+-- Add per-table format override
+-- When set, the override replaces the format detected from the catalog when routing queries.
+
+ALTER TABLE virtual_tables
+    ADD COLUMN IF NOT EXISTS format_override TEXT NOT NULL DEFAULT '';
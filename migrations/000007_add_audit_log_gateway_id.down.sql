@@ -0,0 +1,6 @@
+-- Rollback audit log gateway IDs
+-- Fails if the same query ID was ingested from more than one gateway.
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_query_id_gateway_id_unique;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS gateway_id;
+ALTER TABLE audit_logs
+    ADD CONSTRAINT audit_logs_query_id_unique UNIQUE (query_id);
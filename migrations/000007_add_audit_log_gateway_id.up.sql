@@ -0,0 +1,11 @@
+-- Record which gateway served each audited query
+-- Entries shipped from sibling gateways are merged into audit_logs and
+-- deduplicated by (query_id, gateway_id), so the same query ID may appear once per gateway.
+
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS gateway_id VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_query_id_unique;
+
+ALTER TABLE audit_logs
+    ADD CONSTRAINT audit_logs_query_id_gateway_id_unique UNIQUE (query_id, gateway_id);
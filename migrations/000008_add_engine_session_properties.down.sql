@@ -0,0 +1,2 @@
+-- Rollback engine session properties
+ALTER TABLE engines DROP COLUMN IF EXISTS session_properties;
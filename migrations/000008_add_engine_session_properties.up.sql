@@ -0,0 +1,5 @@
+-- Add templated session properties to engine definitions
+-- Stored as a JSON object mapping property names to templates resolved per query (e.g. {"resource_group": "{{.Role}}"}).
+
+ALTER TABLE engines
+    ADD COLUMN IF NOT EXISTS session_properties TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Rollback audit log engine SQL
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS engine_sql;
@@ -0,0 +1,5 @@
+-- Record the rewritten SQL sent to each engine (verbose audit only)
+-- Stored as a JSON object mapping sub-query IDs to the final SQL, e.g. {"sq_1_trino": "SELECT ..."}.
+
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS engine_sql JSONB;
@@ -0,0 +1,2 @@
+-- Rollback audit log query policy
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS query_policy;
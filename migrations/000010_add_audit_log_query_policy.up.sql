@@ -0,0 +1,5 @@
+-- Record the role query policy that bounded each query's default LIMIT and timeout
+-- Either the roles whose overrides applied (comma-separated) or 'default'.
+
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS query_policy VARCHAR(255);
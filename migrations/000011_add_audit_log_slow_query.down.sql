@@ -0,0 +1,2 @@
+-- Rollback audit log slow query flag
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS slow_query;
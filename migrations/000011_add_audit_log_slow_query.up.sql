@@ -0,0 +1,5 @@
+-- Record whether each audited query exceeded the slow-query threshold
+-- Audit summaries report the number of slow queries.
+
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS slow_query BOOLEAN NOT NULL DEFAULT FALSE;
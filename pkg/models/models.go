@@ -2,6 +2,9 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -13,6 +16,12 @@ type TableDefinition struct {
 	Sources      []Source `json:"sources" yaml:"sources"`
 	Capabilities []string `json:"capabilities" yaml:"capabilities"`
 	Constraints  []string `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+
+	Deprecated         bool   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty" yaml:"deprecation_message,omitempty"`
+
+	// FormatOverride replaces the detected source format when routing queries.
+	FormatOverride string `json:"format_override,omitempty" yaml:"format_override,omitempty"`
 }
 
 // Source is the external representation of a physical source.
@@ -29,6 +38,7 @@ type TableInfo struct {
 	Sources      []Source  `json:"sources"`
 	Capabilities []string  `json:"capabilities"`
 	Constraints  []string  `json:"constraints,omitempty"`
+	Deprecated   bool      `json:"deprecated,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -39,16 +49,172 @@ type QueryRequest struct {
 }
 
 // QueryResponse is the API response for a query execution.
+// It is the envelope for every execution mode (single-engine, federated,
+// rerun), so clients find warnings, truncation and statistics in the same
+// place regardless of how the query ran.
 type QueryResponse struct {
 	QueryID   string                   `json:"query_id"`
 	Columns   []string                 `json:"columns"`
+	Schema    []ColumnSchema           `json:"schema,omitempty"`
 	Rows      []map[string]interface{} `json:"rows"`
 	RowCount  int                      `json:"row_count"`
 	Engine    string                   `json:"engine"`
 	Duration  string                   `json:"duration"`
+	Warnings  []QueryWarning           `json:"warnings,omitempty"`
+	Truncated bool                     `json:"truncated"`
+	Stats     *QueryStats              `json:"stats,omitempty"`
 	Metadata  map[string]string        `json:"metadata,omitempty"`
 }
 
+// ColumnSchema describes a result column. QueryResponse.Schema is aligned
+// with Columns, so clients can render values by type.
+type ColumnSchema struct {
+	Name string `json:"name"`
+
+	// Type is the engine type name, e.g. BIGINT or TIMESTAMP(3).
+	// Empty when the engine does not report it.
+	Type string `json:"type,omitempty"`
+}
+
+// baseType returns the upper-case type name without parameters,
+// e.g. "DECIMAL" for "decimal(10,2)".
+func (c ColumnSchema) baseType() string {
+	base := strings.ToUpper(strings.TrimSpace(c.Type))
+	if i := strings.IndexByte(base, '('); i >= 0 {
+		base = base[:i]
+	}
+	return strings.TrimSpace(base)
+}
+
+// IsNumeric reports whether the column holds integer, decimal or
+// floating-point values.
+func (c ColumnSchema) IsNumeric() bool {
+	switch c.baseType() {
+	case "TINYINT", "SMALLINT", "INT", "INTEGER", "BIGINT", "HUGEINT",
+		"UTINYINT", "USMALLINT", "UINTEGER", "UBIGINT", "INT2", "INT4", "INT8",
+		"DECIMAL", "NUMERIC", "NUMBER", "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE",
+		"DOUBLE PRECISION":
+		return true
+	}
+	return false
+}
+
+// IsTimestamp reports whether the column holds timestamps.
+func (c ColumnSchema) IsTimestamp() bool {
+	base := c.baseType()
+	return strings.HasPrefix(base, "TIMESTAMP") || base == "DATETIME"
+}
+
+// IsDate reports whether the column holds calendar dates.
+func (c ColumnSchema) IsDate() bool {
+	return c.baseType() == "DATE"
+}
+
+// SetColumnTypes sets Schema from Columns and the engine type names,
+// which are aligned with Columns. Missing types are left empty.
+func (r *QueryResponse) SetColumnTypes(types []string) {
+	if len(types) == 0 {
+		r.Schema = nil
+		return
+	}
+	r.Schema = make([]ColumnSchema, len(r.Columns))
+	for i, name := range r.Columns {
+		r.Schema[i] = ColumnSchema{Name: name}
+		if i < len(types) {
+			r.Schema[i].Type = types[i]
+		}
+	}
+}
+
+// Warning codes identify the source of a QueryWarning.
+const (
+	// WarningDeprecatedTable: the query references a deprecated table.
+	WarningDeprecatedTable = "deprecated_table"
+
+	// WarningPartialResults: some engines failed and the rows are incomplete.
+	WarningPartialResults = "partial_results"
+
+	// WarningDefaultLimit: the query had no LIMIT and a default one was applied.
+	WarningDefaultLimit = "default_limit"
+
+	// WarningTruncated: the result exceeded the row cap and was cut short.
+	WarningTruncated = "truncated"
+)
+
+// QueryWarning is a non-fatal notice attached to a query response.
+type QueryWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// UnmarshalJSON accepts a warning object or, as sent by older gateways,
+// a bare message string.
+func (w *QueryWarning) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*w = QueryWarning{Message: message}
+		return nil
+	}
+	type plain QueryWarning
+	return json.Unmarshal(data, (*plain)(w))
+}
+
+// QueryStats holds execution statistics for a query.
+type QueryStats struct {
+	// Engines lists the engines the query ran on.
+	Engines []string `json:"engines,omitempty"`
+
+	// SubQueries is the number of engine queries a federated query ran.
+	SubQueries int `json:"sub_queries,omitempty"`
+
+	// RowsRead is the number of rows read from engines before local
+	// joins, filters and limits.
+	RowsRead int64 `json:"rows_read,omitempty"`
+
+	// PlanningTime and ExecutionTime split Duration.
+	PlanningTime  string `json:"planning_time,omitempty"`
+	ExecutionTime string `json:"execution_time,omitempty"`
+}
+
+// AddWarning appends a warning with the given code.
+func (r *QueryResponse) AddWarning(code, message string) {
+	r.Warnings = append(r.Warnings, QueryWarning{Code: code, Message: message})
+}
+
+// AddDeprecationWarnings adds the deprecated-table warnings of a plan.
+func (r *QueryResponse) AddDeprecationWarnings(messages []string) {
+	for _, message := range messages {
+		r.AddWarning(WarningDeprecatedTable, message)
+	}
+}
+
+// MarkPartial records that the rows are incomplete, e.g. because an engine
+// of a federated query failed.
+func (r *QueryResponse) MarkPartial(reason string) {
+	r.AddWarning(WarningPartialResults, "results are partial: "+reason)
+}
+
+// NoteDefaultLimit records that a default LIMIT was added to the query.
+func (r *QueryResponse) NoteDefaultLimit(limit int) {
+	r.AddWarning(WarningDefaultLimit,
+		fmt.Sprintf("query has no LIMIT; a default LIMIT %d was applied", limit))
+}
+
+// Truncate cuts the rows to at most maxRows. If rows were dropped it sets
+// Truncated and adds a warning. It returns whether the result was truncated.
+func (r *QueryResponse) Truncate(maxRows int) bool {
+	if maxRows <= 0 || len(r.Rows) <= maxRows {
+		return false
+	}
+	total := len(r.Rows)
+	r.Rows = r.Rows[:maxRows]
+	r.RowCount = maxRows
+	r.Truncated = true
+	r.AddWarning(WarningTruncated,
+		fmt.Sprintf("result truncated to %d of %d rows", maxRows, total))
+	return true
+}
+
 // ExplainResponse is the API response for query explanation.
 type ExplainResponse struct {
 	SQL                  string   `json:"sql"`
@@ -59,6 +225,27 @@ type ExplainResponse struct {
 	Explanation          string   `json:"explanation"`
 }
 
+// QueryEstimateResponse is the API response for a pre-execution cost
+// estimate, used by clients to confirm expensive queries.
+type QueryEstimateResponse struct {
+	SQL            string             `json:"sql"`
+	Engines        []string           `json:"engines"`
+	EstimatedRows  int64              `json:"estimated_rows"`
+	EstimatedBytes int64              `json:"estimated_bytes"`
+	EstimatedTime  string             `json:"estimated_time"`
+	SubQueries     []SubQueryEstimate `json:"sub_queries,omitempty"`
+
+	// GenericProfile is true when an engine has no tuned cost factors.
+	GenericProfile bool `json:"generic_profile,omitempty"`
+}
+
+// SubQueryEstimate is the estimated cost of one engine's part of a query.
+type SubQueryEstimate struct {
+	Engine        string `json:"engine"`
+	EstimatedRows int64  `json:"estimated_rows"`
+	EstimatedTime string `json:"estimated_time"`
+}
+
 // ValidationResult is the API response for query validation.
 type ValidationResult struct {
 	Valid   bool     `json:"valid"`
@@ -74,6 +261,28 @@ type EngineInfo struct {
 	Priority     int      `json:"priority"`
 }
 
+// TableEnginesResponse is the API response listing the engines that can
+// serve a table, in the order the router would try them.
+type TableEnginesResponse struct {
+	Table   string            `json:"table"`
+	Format  string            `json:"format"`
+	Engines []EngineCandidate `json:"engines"`
+}
+
+// EngineCandidate is one engine capable of serving a table.
+type EngineCandidate struct {
+	Engine       string   `json:"engine"`
+	Available    bool     `json:"available"`
+	Selected     bool     `json:"selected"`
+	Priority     int      `json:"priority"`
+	Capabilities []string `json:"capabilities"`
+	Reason       string   `json:"reason"`
+
+	// EstimatedTime is the estimated time to scan the table on the engine;
+	// empty when no estimate is available.
+	EstimatedTime string `json:"estimated_time,omitempty"`
+}
+
 // AuthStatus is the API response for authentication status.
 type AuthStatus struct {
 	Authenticated bool      `json:"authenticated"`
@@ -3,6 +3,7 @@ package greenflag
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -246,3 +247,53 @@ func TestAuthorization_GrantAndRevokeAccess(t *testing.T) {
 		t.Error("expected access denied after revoke")
 	}
 }
+
+// TestAuthorization_SchemaWildcardGrantsSchemaTables proves that a grant on
+// "analytics.*" authorizes every table in the analytics schema.
+func TestAuthorization_SchemaWildcardGrantsSchemaTables(t *testing.T) {
+	authz := auth.NewAuthorizationService()
+	authz.GrantAccess("analyst", "analytics.*", capabilities.CapabilityRead)
+
+	user := &auth.User{
+		ID:    "user-analyst",
+		Name:  "Analyst",
+		Roles: []string{"analyst"},
+	}
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	tables := []string{"analytics.sales_orders", "analytics.payments"}
+	if err := authz.Authorize(ctx, user, tables, capabilities.CapabilityRead); err != nil {
+		t.Errorf("GREEN-FLAG VIOLATION: schema wildcard grant denied access to schema tables: %v", err)
+	}
+	if !authz.HasAccess(user, "analytics.customers", capabilities.CapabilityRead) {
+		t.Error("GREEN-FLAG VIOLATION: schema wildcard grant does not cover tables granted later")
+	}
+}
+
+// TestQueryPolicies_RestrictedRoleGetsTighterBounds proves that a role with
+// a query policy gets a tighter default LIMIT and timeout than an
+// unrestricted role for the same query, layered over the global defaults.
+func TestQueryPolicies_RestrictedRoleGetsTighterBounds(t *testing.T) {
+	policies := auth.NewQueryPolicies(auth.QueryPolicy{DefaultLimit: 10000, Timeout: 5 * time.Minute})
+	policies.SetRolePolicy("exec_dashboard", auth.RoleQueryPolicy{DefaultLimit: 500, Timeout: 30 * time.Second})
+	policies.SetRolePolicy("analyst", auth.RoleQueryPolicy{DefaultLimit: -1, Timeout: -1})
+
+	dashboard := policies.Resolve(&auth.User{ID: "dash", Roles: []string{"exec_dashboard"}})
+	if dashboard.DefaultLimit != 500 || dashboard.Timeout != 30*time.Second {
+		t.Errorf("exec_dashboard policy = %+v, want LIMIT 500 and 30s timeout", dashboard.QueryPolicy)
+	}
+	if dashboard.Name() != "exec_dashboard" {
+		t.Errorf("exec_dashboard policy name = %q", dashboard.Name())
+	}
+
+	analyst := policies.Resolve(&auth.User{ID: "ann", Roles: []string{"analyst"}})
+	if analyst.DefaultLimit != 0 || analyst.Timeout != 0 {
+		t.Errorf("analyst policy = %+v, want unbounded", analyst.QueryPolicy)
+	}
+
+	// Roles without a policy get the global defaults
+	other := policies.Resolve(&auth.User{ID: "ops", Roles: []string{"ops"}})
+	if other.QueryPolicy != policies.Defaults() || other.Name() != auth.DefaultQueryPolicyName {
+		t.Errorf("ops policy = %+v (%s), want the global defaults", other.QueryPolicy, other.Name())
+	}
+}
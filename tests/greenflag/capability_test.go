@@ -40,6 +40,8 @@ func TestCapability_ParseValidCapabilities(t *testing.T) {
 		{"READ", capabilities.CapabilityRead},
 		{"TIME_TRAVEL", capabilities.CapabilityTimeTravel},
 		{"  READ  ", capabilities.CapabilityRead}, // With whitespace
+		{"read", capabilities.CapabilityRead},     // Lowercase
+		{"Time_Travel", capabilities.CapabilityTimeTravel},
 	}
 
 	for _, tc := range validCaps {
@@ -69,6 +71,8 @@ func TestCapability_ParseValidConstraints(t *testing.T) {
 		{"READ_ONLY", capabilities.ConstraintReadOnly},
 		{"SNAPSHOT_CONSISTENT", capabilities.ConstraintSnapshotConsistent},
 		{"  READ_ONLY  ", capabilities.ConstraintReadOnly}, // With whitespace
+		{"read_only", capabilities.ConstraintReadOnly},     // Lowercase
+		{"Snapshot_Consistent", capabilities.ConstraintSnapshotConsistent},
 	}
 
 	for _, tc := range validConstraints {
@@ -4,12 +4,19 @@ package greenflag
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/catalog"
 	"github.com/canonica-labs/canonica/internal/catalog/hive"
+	"github.com/canonica-labs/canonica/internal/catalog/syncer"
 	"github.com/canonica-labs/canonica/internal/catalog/unity"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
 )
 
 // TestFormatDetectionIceberg verifies Iceberg format detection from properties.
@@ -206,6 +213,34 @@ func TestSelectEngine(t *testing.T) {
 	}
 }
 
+// TestSelectEngine_ConfiguredFormatOverride verifies that a deployment can
+// route a format to a different engine.
+// Green-Flag: A configured override MUST route Iceberg to duckdb while other formats keep their defaults.
+func TestSelectEngine_ConfiguredFormatOverride(t *testing.T) {
+	catalog.SetFormatEngines(map[catalog.TableFormat]string{catalog.FormatIceberg: "duckdb"})
+	defer catalog.SetFormatEngines(nil)
+
+	testCases := []struct {
+		format         catalog.TableFormat
+		expectedEngine string
+	}{
+		{catalog.FormatIceberg, "duckdb"},
+		{catalog.FormatDelta, "trino"},
+		{catalog.FormatORC, "trino"},
+		{catalog.FormatParquet, "duckdb"},
+	}
+	for _, tc := range testCases {
+		if engine := catalog.SelectEngine(tc.format); engine != tc.expectedEngine {
+			t.Errorf("expected %s for format %s, got %s", tc.expectedEngine, tc.format, engine)
+		}
+	}
+
+	catalog.SetFormatEngines(nil)
+	if engine := catalog.SelectEngine(catalog.FormatIceberg); engine != "trino" {
+		t.Errorf("expected defaults to be restored, got %s for iceberg", engine)
+	}
+}
+
 // TestCatalogRegistry verifies the CatalogRegistry functionality.
 // Per phase-7-spec.md §2.5: Green-Flag tests for registry operations.
 func TestCatalogRegistry(t *testing.T) {
@@ -438,3 +473,286 @@ func (m *mockCatalog) Close() error {
 
 // Ensure mockCatalog implements Catalog interface
 var _ catalog.Catalog = (*mockCatalog)(nil)
+
+// browsableCatalog is a mock catalog with fixed databases and tables whose
+// metadata is reported in the loose shape some catalogs return.
+type browsableCatalog struct {
+	tables map[string][]catalog.TableInfo
+}
+
+func newBrowsableCatalog() *browsableCatalog {
+	return &browsableCatalog{tables: map[string][]catalog.TableInfo{
+		"sales":     {{Name: "orders", Format: "ICEBERG"}, {Name: "customers", Format: ""}},
+		"analytics": {{Database: "analytics", Name: "events", Format: catalog.FormatDelta}},
+		"scratch":   {{Name: "tmp", Format: catalog.FormatCSV}},
+	}}
+}
+
+func (m *browsableCatalog) Name() string { return "mock" }
+
+func (m *browsableCatalog) ListDatabases(ctx context.Context) ([]string, error) {
+	return []string{"scratch", "sales", "analytics"}, nil
+}
+
+func (m *browsableCatalog) ListTables(ctx context.Context, database string) ([]catalog.TableInfo, error) {
+	return m.tables[database], nil
+}
+
+func (m *browsableCatalog) GetTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	for _, t := range m.tables[database] {
+		if t.Name == table {
+			return &catalog.TableMetadata{
+				Name:     table,
+				Format:   t.Format,
+				Location: "s3://lake/" + database + "/" + table,
+				Columns:  []catalog.ColumnMetadata{{Name: "id", Type: "bigint"}},
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *browsableCatalog) CheckConnectivity(ctx context.Context) error { return nil }
+
+func (m *browsableCatalog) Close() error { return nil }
+
+// TestCatalogProxyNormalizesResults verifies that the catalog proxy returns
+// tables and metadata in a normalized shape.
+// Green-Flag: Catalog browsing through Canonic MUST return normalized results.
+func TestCatalogProxyNormalizesResults(t *testing.T) {
+	proxy := catalog.NewProxy(newBrowsableCatalog(), catalog.DatabaseFilter{})
+	ctx := context.Background()
+
+	tables, err := proxy.ListTables(ctx, "sales")
+	if err != nil {
+		t.Fatalf("ListTables failed: %v", err)
+	}
+	want := []catalog.TableInfo{
+		{Database: "sales", Name: "customers", Format: catalog.FormatUnknown},
+		{Database: "sales", Name: "orders", Format: catalog.FormatIceberg},
+	}
+	if len(tables) != len(want) {
+		t.Fatalf("expected %d tables, got %v", len(want), tables)
+	}
+	for i := range want {
+		if tables[i] != want[i] {
+			t.Errorf("table %d: expected %+v, got %+v", i, want[i], tables[i])
+		}
+	}
+
+	meta, err := proxy.GetTable(ctx, "sales", "orders")
+	if err != nil {
+		t.Fatalf("GetTable failed: %v", err)
+	}
+	if meta.FullName() != "sales.orders" || meta.Format != catalog.FormatIceberg {
+		t.Errorf("expected sales.orders in iceberg format, got %s in %s", meta.FullName(), meta.Format)
+	}
+	if meta.Partitions == nil || meta.Properties == nil {
+		t.Errorf("expected empty partitions and properties, got %v and %v", meta.Partitions, meta.Properties)
+	}
+}
+
+// TestCatalogProxyAppliesDatabaseFilters verifies that the catalog proxy
+// only lists databases allowed by its include/exclude filters.
+// Green-Flag: Catalog browsing MUST respect include/exclude filters.
+func TestCatalogProxyAppliesDatabaseFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter catalog.DatabaseFilter
+		want   []string
+	}{
+		{"no filter", catalog.DatabaseFilter{}, []string{"analytics", "sales", "scratch"}},
+		{"include", catalog.DatabaseFilter{Include: []string{"sales", "analytics"}}, []string{"analytics", "sales"}},
+		{"exclude", catalog.DatabaseFilter{Exclude: []string{"scratch"}}, []string{"analytics", "sales"}},
+		{"exclude wins", catalog.DatabaseFilter{Include: []string{"sales", "scratch"}, Exclude: []string{"scratch"}}, []string{"sales"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			proxy := catalog.NewProxy(newBrowsableCatalog(), tc.filter)
+			databases, err := proxy.ListDatabases(context.Background())
+			if err != nil {
+				t.Fatalf("ListDatabases failed: %v", err)
+			}
+			if strings.Join(databases, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("expected %v, got %v", tc.want, databases)
+			}
+		})
+	}
+}
+
+// newSyncedRepository returns a repository holding tables previously synced
+// from the browsable catalog: analytics.events at an outdated location, and
+// sales.legacy, which the catalog no longer lists.
+func newSyncedRepository(t *testing.T) *storage.MockRepository {
+	t.Helper()
+	repo := storage.NewMockRepository()
+	for name, location := range map[string]string{
+		"analytics.events": "s3://old/analytics/events",
+		"sales.legacy":     "s3://lake/sales/legacy",
+	} {
+		err := repo.Create(context.Background(), &tables.VirtualTable{
+			Name:        name,
+			Description: syncer.SyncedDescription("mock"),
+			Sources: []tables.PhysicalSource{{
+				Format:   tables.FormatDelta,
+				Location: location,
+				Engine:   catalog.SelectEngine(catalog.FormatDelta),
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+			Columns:      []tables.Column{{Name: "id", Type: "bigint"}},
+		})
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	return repo
+}
+
+// TestSyncerDryRunReportsWithoutWriting verifies that a dry-run sync reports
+// the intended changes and leaves the repository untouched.
+// Green-Flag: A dry-run catalog sync MUST report creates, updates, removals
+// and skips without mutating the repository.
+func TestSyncerDryRunReportsWithoutWriting(t *testing.T) {
+	repo := newSyncedRepository(t)
+	ctx := context.Background()
+
+	report, err := syncer.NewSyncer(newBrowsableCatalog(), repo).Sync(ctx, syncer.SyncOptions{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !report.DryRun || report.Catalog != "mock" {
+		t.Errorf("expected a dry-run report for the mock catalog, got %+v", report)
+	}
+
+	want := map[syncer.SyncAction][]string{
+		syncer.SyncCreate: {"sales.orders"},
+		syncer.SyncUpdate: {"analytics.events"},
+		syncer.SyncRemove: {"sales.legacy"},
+		syncer.SyncSkip:   {"sales.customers", "scratch.tmp"},
+	}
+	for action, tables := range want {
+		if got := strings.Join(report.Tables(action), ","); got != strings.Join(tables, ",") {
+			t.Errorf("%s: expected %v, got %s", action, tables, got)
+		}
+	}
+	for _, change := range report.Changes {
+		if change.Table == "analytics.events" && !strings.Contains(strings.Join(change.Details, ";"), "location: s3://old/analytics/events → s3://lake/analytics/events") {
+			t.Errorf("expected the update to describe the location change, got %v", change.Details)
+		}
+	}
+
+	// Nothing was written
+	if exists, _ := repo.Exists(ctx, "sales.orders"); exists {
+		t.Error("dry run created sales.orders")
+	}
+	if exists, _ := repo.Exists(ctx, "sales.legacy"); !exists {
+		t.Error("dry run removed sales.legacy")
+	}
+	events, err := repo.Get(ctx, "analytics.events")
+	if err != nil || events.Sources[0].Location != "s3://old/analytics/events" {
+		t.Errorf("dry run updated analytics.events: %+v (err %v)", events, err)
+	}
+}
+
+// TestSyncerAppliesReportedChanges verifies that a real sync applies the
+// changes a dry run reports, after which the catalog and repository match.
+// Green-Flag: A catalog sync MUST create, update and prune tables as reported.
+func TestSyncerAppliesReportedChanges(t *testing.T) {
+	repo := newSyncedRepository(t)
+	ctx := context.Background()
+	sync := syncer.NewSyncer(newBrowsableCatalog(), repo)
+
+	report, err := sync.Sync(ctx, syncer.SyncOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if report.Failed() != 0 {
+		t.Fatalf("expected no failures, got %+v", report)
+	}
+
+	orders, err := repo.Get(ctx, "sales.orders")
+	if err != nil {
+		t.Fatalf("sales.orders was not created: %v", err)
+	}
+	if orders.Sources[0].Format != tables.FormatIceberg || orders.Sources[0].Engine != catalog.SelectEngine(catalog.FormatIceberg) {
+		t.Errorf("expected sales.orders on iceberg, got %+v", orders.Sources[0])
+	}
+	if len(orders.Columns) != 1 || orders.Columns[0].Name != "id" {
+		t.Errorf("expected the catalog columns to be synced, got %+v", orders.Columns)
+	}
+	events, _ := repo.Get(ctx, "analytics.events")
+	if events == nil || events.Sources[0].Location != "s3://lake/analytics/events" {
+		t.Errorf("analytics.events was not updated: %+v", events)
+	}
+	if exists, _ := repo.Exists(ctx, "sales.legacy"); exists {
+		t.Error("sales.legacy was not removed")
+	}
+
+	// A second sync finds nothing to change
+	again, err := sync.Sync(ctx, syncer.SyncOptions{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatalf("second Sync failed: %v", err)
+	}
+	if got := strings.Join(again.Tables(syncer.SyncUnchanged), ","); got != "analytics.events,sales.orders" {
+		t.Errorf("expected synced tables to be unchanged, got %s (report %+v)", got, again)
+	}
+}
+
+// slowCatalog is a mock catalog with one database of many tables, whose
+// GetTable takes a while and records how many calls are in flight at once.
+type slowCatalog struct {
+	browsableCatalog
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func newSlowCatalog(tableCount int) *slowCatalog {
+	infos := make([]catalog.TableInfo, tableCount)
+	for i := range infos {
+		infos[i] = catalog.TableInfo{Name: fmt.Sprintf("t%02d", i), Format: catalog.FormatIceberg}
+	}
+	return &slowCatalog{browsableCatalog: browsableCatalog{tables: map[string][]catalog.TableInfo{"lake": infos}}}
+}
+
+func (m *slowCatalog) ListDatabases(ctx context.Context) ([]string, error) {
+	return []string{"lake"}, nil
+}
+
+func (m *slowCatalog) GetTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		highest := m.maxInFlight.Load()
+		if n <= highest || m.maxInFlight.CompareAndSwap(highest, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return m.browsableCatalog.GetTable(ctx, database, table)
+}
+
+// TestSyncerDiscoversTablesConcurrently verifies that a sync reads table
+// metadata from the catalog in parallel, within the configured concurrency.
+// Green-Flag: Catalog sync MUST discover tables with up to the configured
+// number of concurrent catalog requests.
+func TestSyncerDiscoversTablesConcurrently(t *testing.T) {
+	cat := newSlowCatalog(20)
+	repo := storage.NewMockRepository()
+
+	report, err := syncer.NewSyncer(cat, repo).Sync(context.Background(), syncer.SyncOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if report.Failed() != 0 {
+		t.Fatalf("expected no failures, got %v", report.Errors)
+	}
+
+	created := report.Tables(syncer.SyncCreate)
+	if len(created) != 20 || created[0] != "lake.t00" || created[19] != "lake.t19" {
+		t.Errorf("expected all 20 tables created in catalog order, got %v", created)
+	}
+	if got := cat.maxInFlight.Load(); got < 2 || got > 4 {
+		t.Errorf("expected between 2 and 4 concurrent GetTable calls, got %d", got)
+	}
+}
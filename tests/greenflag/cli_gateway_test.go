@@ -12,11 +12,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/catalog/syncer"
 	"github.com/canonica-labs/canonica/internal/cli"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/pkg/models"
 )
 
 // TestCLIReflectsGatewayMetadata tests that CLI reflects gateway state.
@@ -195,6 +203,48 @@ func TestCLIErrorsPropagateUnchanged(t *testing.T) {
 	}
 }
 
+// TestCLIErrorsRoundTripWireFormat tests that an error written by the
+// gateway's error helper reaches the CLI with its fields and code intact.
+// Green-Flag: The HTTP status MUST follow the error category, and the decoded
+// error MUST keep its message, reason, suggestion and exit code.
+func TestCLIErrorsRoundTripWireFormat(t *testing.T) {
+	sent := errors.NewTableNotFound("analytics.unknown")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errors.WriteHTTPError(w, sent)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var wire map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&wire); err != nil {
+		t.Fatalf("invalid JSON error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+	if wire["code"] != float64(errors.CodeValidation) || wire["error"] != sent.Message ||
+		wire["reason"] != sent.Reason || wire["suggestion"] != sent.Suggestion {
+		t.Errorf("unexpected wire format: %v", wire)
+	}
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	_, err = client.DescribeTable(context.Background(), "analytics.unknown")
+	var received *errors.CanonicError
+	if !stderrors.As(err, &received) {
+		t.Fatalf("expected a CanonicError, got %T: %v", err, err)
+	}
+	if received.Message != sent.Message || received.Reason != sent.Reason || received.Suggestion != sent.Suggestion {
+		t.Errorf("expected %+v, got %+v", sent.CanonicError, *received)
+	}
+	if code := errors.CodeOf(err); code != errors.CodeValidation || int(code) != cli.ExitValidation {
+		t.Errorf("expected validation exit code %d, got %d", cli.ExitValidation, code)
+	}
+}
+
 // TestCLIAuthTokenIncludedInRequests tests that auth token is sent.
 // Per phase-3-spec.md §8: "The CLI MUST authenticate to the gateway"
 func TestCLIAuthTokenIncludedInRequests(t *testing.T) {
@@ -295,3 +345,526 @@ func TestCLIHealthCheck(t *testing.T) {
 
 // Helper to suppress unused warning
 var _ = bytes.Buffer{}
+
+// TestCLIQueryHistoryListsCurrentUserEntries tests that query history asks the
+// gateway for the caller's own audit entries and returns them unchanged.
+// Green-Flag: Query history MUST list the current user's audit entries.
+func TestCLIQueryHistoryListsCurrentUserEntries(t *testing.T) {
+	mockEntries := []cli.AuditEntry{
+		{QueryID: "q-2", User: "alice", SQL: "SELECT id FROM analytics.orders", Outcome: "success", DurationMs: 42},
+		{QueryID: "q-1", User: "alice", SQL: "SELECT * FROM analytics.customers", Outcome: "error", Error: "timeout", DurationMs: 30000},
+	}
+
+	var gotUser, gotLimit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/audit/entries" {
+			gotUser = r.URL.Query().Get("user")
+			gotLimit = r.URL.Query().Get("limit")
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"entries": mockEntries})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	entries, err := client.ListAuditEntries(context.Background(), cli.AuditEntryFilter{User: cli.CurrentUser, Limit: 5})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+
+	if gotUser != cli.CurrentUser || gotLimit != "5" {
+		t.Errorf("expected user=%s limit=5, got user=%q limit=%q", cli.CurrentUser, gotUser, gotLimit)
+	}
+	if len(entries) != len(mockEntries) {
+		t.Fatalf("expected %d entries, got %d", len(mockEntries), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.QueryID != mockEntries[i].QueryID || entry.Outcome != mockEntries[i].Outcome ||
+			entry.DurationMs != mockEntries[i].DurationMs {
+			t.Errorf("entry %d mismatch: got %+v, want %+v", i, entry, mockEntries[i])
+		}
+	}
+}
+
+// TestCLIQueryRerunResubmitsRecordedSQL tests that rerun fetches the audit
+// entry and executes its SQL again.
+// Green-Flag: Rerun MUST resubmit exactly the SQL recorded for the query ID.
+func TestCLIQueryRerunResubmitsRecordedSQL(t *testing.T) {
+	const recordedSQL = "SELECT id, amount FROM analytics.orders WHERE amount > 100"
+
+	var executedSQL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/audit/entries/q-42":
+			json.NewEncoder(w).Encode(cli.AuditEntry{QueryID: "q-42", SQL: recordedSQL, Outcome: "success"})
+		case r.Method == "POST" && r.URL.Path == "/query":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			executedSQL = body["sql"]
+			json.NewEncoder(w).Encode(cli.QueryResult{QueryID: "q-43", RowCount: 1, Engine: "duckdb"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	result, err := client.RerunQuery(context.Background(), "q-42")
+	if err != nil {
+		t.Fatalf("RerunQuery failed: %v", err)
+	}
+
+	if executedSQL != recordedSQL {
+		t.Errorf("expected rerun to submit %q, got %q", recordedSQL, executedSQL)
+	}
+	if result.QueryID != "q-43" {
+		t.Errorf("expected new query ID q-43, got %s", result.QueryID)
+	}
+}
+
+// TestCLIQueryResultCarriesEnvelope tests that every warning source reaches
+// the CLI through the query response envelope.
+// Green-Flag: Deprecation, default LIMIT, partial-result and truncation warnings MUST each populate the envelope and render distinctly.
+func TestCLIQueryResultCarriesEnvelope(t *testing.T) {
+	response := models.QueryResponse{
+		QueryID:  "q-envelope",
+		Columns:  []string{"id"},
+		Rows:     []map[string]interface{}{{"id": 1}, {"id": 2}, {"id": 3}},
+		RowCount: 3,
+		Engine:   "trino",
+		Duration: "12ms",
+		Stats:    &models.QueryStats{Engines: []string{"spark", "trino"}, SubQueries: 2, RowsRead: 40},
+	}
+	response.AddDeprecationWarnings([]string{"table analytics.events is deprecated: use analytics.events_v2"})
+	response.NoteDefaultLimit(1000)
+	response.MarkPartial("engine spark failed")
+	if !response.Truncate(2) {
+		t.Fatal("expected Truncate to report truncation")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	result, err := cli.NewGatewayClient(server.URL, "test-token").ExecuteQuery(context.Background(), "SELECT id FROM analytics.events")
+	if err != nil {
+		t.Fatalf("ExecuteQuery failed: %v", err)
+	}
+
+	if !result.Truncated || result.RowCount != 2 || len(result.Rows) != 2 {
+		t.Errorf("expected a truncated result of 2 rows, got truncated=%v rows=%d", result.Truncated, result.RowCount)
+	}
+	if result.Stats == nil || result.Stats.SubQueries != 2 || result.Stats.RowsRead != 40 {
+		t.Errorf("expected stats to round-trip, got %+v", result.Stats)
+	}
+
+	wantCodes := []string{
+		models.WarningDeprecatedTable,
+		models.WarningDefaultLimit,
+		models.WarningPartialResults,
+		models.WarningTruncated,
+	}
+	if len(result.Warnings) != len(wantCodes) {
+		t.Fatalf("expected %d warnings, got %+v", len(wantCodes), result.Warnings)
+	}
+	for i, code := range wantCodes {
+		if result.Warnings[i].Code != code {
+			t.Errorf("warning %d: expected code %s, got %+v", i, code, result.Warnings[i])
+		}
+		rendered := cli.FormatWarning(result.Warnings[i])
+		if !strings.HasPrefix(rendered, "⚠ WARNING ["+code+"]: ") || !strings.Contains(rendered, result.Warnings[i].Message) {
+			t.Errorf("warning %d rendered as %q", i, rendered)
+		}
+	}
+}
+
+// TestCLIAuditSummaryRequestsDimensions tests that summary options are sent
+// to the gateway and the extra top-N lists are decoded.
+// Green-Flag: The client MUST pass top-N and dimensions and return top engines.
+func TestCLIAuditSummaryRequestsDimensions(t *testing.T) {
+	var receivedTop, receivedDimensions string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedTop = r.URL.Query().Get("top")
+		receivedDimensions = r.URL.Query().Get("dimensions")
+		json.NewEncoder(w).Encode(cli.AuditSummary{
+			AcceptedCount: 4,
+			TopEngines:    []cli.EngineQueryStat{{Engine: "trino", Count: 3}},
+		})
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	summary, err := client.GetAuditSummaryWithOptions(context.Background(), cli.AuditSummaryOptions{
+		TopN:       10,
+		Dimensions: []string{"engines", "users"},
+	})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+
+	if receivedTop != "10" || receivedDimensions != "engines,users" {
+		t.Errorf("expected top=10 dimensions=engines,users, got top=%q dimensions=%q", receivedTop, receivedDimensions)
+	}
+	if len(summary.TopEngines) != 1 || summary.TopEngines[0].Engine != "trino" || summary.TopEngines[0].Count != 3 {
+		t.Errorf("expected top engines to be decoded, got %v", summary.TopEngines)
+	}
+}
+
+// TestCLIAuditSummaryRequestsWindow tests that a summary window is sent to
+// the gateway.
+// Green-Flag: The client MUST pass --since and --until as RFC 3339 UTC
+// timestamps, and omit an open side of the window.
+func TestCLIAuditSummaryRequestsWindow(t *testing.T) {
+	var received []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.Query())
+		json.NewEncoder(w).Encode(cli.AuditSummary{AcceptedCount: 1})
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	since := time.Date(2026, 1, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+	until := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := client.GetAuditSummaryWithOptions(context.Background(), cli.AuditSummaryOptions{Since: since, Until: until}); err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if _, err := client.GetAuditSummaryWithOptions(context.Background(), cli.AuditSummaryOptions{Since: since}); err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(received))
+	}
+	if got := received[0].Get("since"); got != "2026-01-01T08:00:00Z" {
+		t.Errorf("expected since=2026-01-01T08:00:00Z, got %q", got)
+	}
+	if got := received[0].Get("until"); got != "2026-01-02T00:00:00Z" {
+		t.Errorf("expected until=2026-01-02T00:00:00Z, got %q", got)
+	}
+	if received[1].Has("until") {
+		t.Errorf("expected no until for an open window, got %q", received[1].Get("until"))
+	}
+}
+
+// TestCLIRendersTypedColumns verifies that the CLI formats results by
+// column type.
+// Green-Flag: Numeric columns MUST be right-aligned without exponent
+// notation, and timestamps MUST be shown as "2006-01-02 15:04:05".
+func TestCLIRendersTypedColumns(t *testing.T) {
+	response := models.QueryResponse{
+		QueryID: "q-typed",
+		Columns: []string{"id", "created_at", "name"},
+		Rows: []map[string]interface{}{
+			{"id": 7, "created_at": "2024-01-15T10:30:00Z", "name": "alice"},
+			{"id": 1500000, "created_at": nil, "name": "bob"},
+		},
+		RowCount: 2,
+		Engine:   "duckdb",
+	}
+	response.SetColumnTypes([]string{"BIGINT", "TIMESTAMP", "VARCHAR"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	result, err := cli.NewGatewayClient(server.URL, "test-token").ExecuteQuery(context.Background(), "SELECT id, created_at, name FROM analytics.users")
+	if err != nil {
+		t.Fatalf("ExecuteQuery failed: %v", err)
+	}
+	if len(result.Schema) != 3 || result.Schema[0].Type != "BIGINT" || !result.Schema[1].IsTimestamp() {
+		t.Fatalf("expected the column schema to round-trip, got %+v", result.Schema)
+	}
+
+	want := []string{
+		"     id  created_at           name",
+		"      7  2024-01-15 10:30:00  alice",
+		"1500000  NULL                 bob",
+	}
+	got := cli.FormatResultRows(result)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d lines, got %q", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+// TestCLIEstimateQuery verifies the cost estimate request used by
+// --confirm-cost.
+// Green-Flag: EstimateQuery MUST POST the SQL to /query/estimate and decode
+// the estimate, and the prompt MUST name the rows, engines and time.
+func TestCLIEstimateQuery(t *testing.T) {
+	var receivedPath, receivedSQL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		var req models.QueryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		receivedSQL = req.SQL
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.QueryEstimateResponse{
+			SQL:            req.SQL,
+			Engines:        []string{"spark", "trino"},
+			EstimatedRows:  2000000,
+			EstimatedBytes: 256000000,
+			EstimatedTime:  "1.2s",
+			SubQueries: []models.SubQueryEstimate{
+				{Engine: "spark", EstimatedRows: 1000000, EstimatedTime: "1.2s"},
+				{Engine: "trino", EstimatedRows: 1000000, EstimatedTime: "0.6s"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	query := "SELECT * FROM sales.orders o JOIN crm.customers c ON o.customer_id = c.id"
+	estimate, err := cli.NewGatewayClient(server.URL, "test-token").EstimateQuery(context.Background(), query)
+	if err != nil {
+		t.Fatalf("EstimateQuery failed: %v", err)
+	}
+	if receivedPath != "/query/estimate" || receivedSQL != query {
+		t.Errorf("expected POST /query/estimate with the SQL, got path=%q sql=%q", receivedPath, receivedSQL)
+	}
+	if estimate.EstimatedRows != 2000000 || len(estimate.SubQueries) != 2 {
+		t.Errorf("expected the estimate to be decoded, got %+v", estimate)
+	}
+
+	want := "This query will scan ~2,000,000 rows across spark and trino (~244.1 MB transferred, est. 1.2s)"
+	if got := cli.FormatCostEstimate(estimate); got != want {
+		t.Errorf("expected prompt %q, got %q", want, got)
+	}
+}
+
+// TestCLIBrowsesCatalogThroughGateway tests that the CLI reads the external
+// catalog through the gateway's catalog proxy endpoints.
+func TestCLIBrowsesCatalogThroughGateway(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/catalog/databases":
+			json.NewEncoder(w).Encode(map[string]interface{}{"databases": []string{"sales"}})
+		case "/catalog/sales/tables":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"tables": []catalog.TableInfo{{Database: "sales", Name: "orders", Format: catalog.FormatIceberg}},
+			})
+		case "/catalog/sales/orders":
+			json.NewEncoder(w).Encode(catalog.TableMetadata{
+				Database: "sales",
+				Name:     "orders",
+				Format:   catalog.FormatIceberg,
+				Columns:  []catalog.ColumnMetadata{{Name: "id", Type: "bigint"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	ctx := context.Background()
+
+	databases, err := client.ListCatalogDatabases(ctx)
+	if err != nil || len(databases) != 1 || databases[0] != "sales" {
+		t.Fatalf("ListCatalogDatabases: expected [sales], got %v (err %v)", databases, err)
+	}
+
+	tables, err := client.ListCatalogTables(ctx, "sales")
+	if err != nil || len(tables) != 1 || tables[0].FullName() != "sales.orders" {
+		t.Fatalf("ListCatalogTables: expected [sales.orders], got %v (err %v)", tables, err)
+	}
+
+	meta, err := client.DescribeCatalogTable(ctx, "sales", "orders")
+	if err != nil {
+		t.Fatalf("DescribeCatalogTable failed: %v", err)
+	}
+	if meta.Format != catalog.FormatIceberg || len(meta.Columns) != 1 {
+		t.Errorf("Expected iceberg table with 1 column, got %+v", meta)
+	}
+}
+
+// TestCLISyncsCatalogThroughGateway tests that a dry-run catalog sync sends
+// its options to the gateway and returns the sync report.
+func TestCLISyncsCatalogThroughGateway(t *testing.T) {
+	var received cli.CatalogSyncOptions
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/catalog/sync" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(syncer.SyncReport{
+			Catalog: "hive",
+			DryRun:  received.DryRun,
+			Changes: []syncer.SyncChange{
+				{Table: "sales.customers", Action: syncer.SyncUpdate, Details: []string{"column added: email varchar"}},
+				{Table: "sales.orders", Action: syncer.SyncCreate},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	report, err := client.SyncCatalog(context.Background(), &cli.CatalogSyncOptions{Database: "sales", DryRun: true})
+	if err != nil {
+		t.Fatalf("SyncCatalog failed: %v", err)
+	}
+
+	if !received.DryRun || received.Database != "sales" {
+		t.Errorf("expected a dry-run sync of sales to be requested, got %+v", received)
+	}
+	if !report.DryRun || len(report.Tables(syncer.SyncCreate)) != 1 || len(report.Tables(syncer.SyncUpdate)) != 1 {
+		t.Errorf("expected the dry-run report with one create and one update, got %+v", report)
+	}
+}
+
+// sampleLogLines are gateway JSON log records, interleaved with a plain log
+// line as on the gateway's stdout.
+const sampleLogLines = `{"timestamp":"2026-01-05T10:00:00Z","level":"info","query_id":"q-1","user":"alice","tables":["sales.orders"],"engine":"trino","execution_time_ms":42,"outcome":"success"}
+2026/01/05 10:00:01 Canonic Gateway starting on :8080
+{"timestamp":"2026-01-05T10:00:02Z","level":"error","query_id":"q-2","user":"bob","tables":["sales.orders","crm.customers"],"engine":"spark","execution_time_ms":30000,"outcome":"error","error":"engine timeout"}
+{"timestamp":"2026-01-05T10:00:03Z","level":"warn","event":"slow_query","query_id":"q-2","user":"bob","tables":["sales.orders","crm.customers"],"engine":"spark","execution_time_ms":30000,"threshold_ms":5000}
+{"timestamp":"2026-01-05T10:00:04Z","level":"info","query_id":"q-3","user":"alice","tables":["crm.customers"],"engine":"duckdb","execution_time_ms":7,"outcome":"rejected"}
+`
+
+// TestCLILogsFormatsAlignedColumns tests that log records are rendered as
+// aligned columns with outcome colors.
+// Green-Flag: Formatted log lines MUST align their columns and color outcomes.
+func TestCLILogsFormatsAlignedColumns(t *testing.T) {
+	var out bytes.Buffer
+	n, err := cli.TailLogs(strings.NewReader(sampleLogLines), &out, cli.LogFilter{}, cli.LogFormatter{})
+	if err != nil {
+		t.Fatalf("TailLogs failed: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("Expected 4 records (plain log line skipped), got %d:\n%s", n, out.String())
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	header := cli.LogFormatter{}.Header()
+	userCol := strings.Index(header, "USER")
+	for _, line := range lines {
+		if len(line) <= userCol || line[userCol-1] != ' ' || line[userCol] == ' ' {
+			t.Errorf("Expected the user column at offset %d in %q", userCol, line)
+		}
+	}
+	if !strings.Contains(lines[1], "engine timeout") {
+		t.Errorf("Expected the error of a failed query, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "slow") || !strings.Contains(lines[2], "exceeded 5000ms") {
+		t.Errorf("Expected a slow query line with its threshold, got %q", lines[2])
+	}
+
+	colored := cli.LogFormatter{Color: true}
+	var record cli.LogRecord
+	if err := json.Unmarshal([]byte(strings.Split(sampleLogLines, "\n")[0]), &record); err != nil {
+		t.Fatalf("Failed to decode sample record: %v", err)
+	}
+	if line := colored.Format(record); !strings.Contains(line, "\033[32msuccess") {
+		t.Errorf("Expected success in green, got %q", line)
+	}
+	record.Outcome = "rejected"
+	if line := colored.Format(record); !strings.Contains(line, "\033[31mrejected") {
+		t.Errorf("Expected rejected in red, got %q", line)
+	}
+}
+
+// TestCLILogsFiltersEntries tests filtering log records by user, table and
+// outcome.
+// Green-Flag: Log filters MUST select only matching records.
+func TestCLILogsFiltersEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  cli.LogFilter
+		queries []string
+	}{
+		{"user", cli.LogFilter{User: "ALICE"}, []string{"q-1", "q-3"}},
+		{"table", cli.LogFilter{Table: "crm.customers"}, []string{"q-2", "q-2", "q-3"}},
+		{"outcome", cli.LogFilter{Outcome: "error"}, []string{"q-2"}},
+		{"slow", cli.LogFilter{Outcome: "slow"}, []string{"q-2"}},
+		{"combined", cli.LogFilter{User: "alice", Table: "sales.orders", Outcome: "success"}, []string{"q-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := cli.TailLogs(strings.NewReader(sampleLogLines), &out, tt.filter, cli.LogFormatter{}); err != nil {
+				t.Fatalf("TailLogs failed: %v", err)
+			}
+			var got []string
+			for _, line := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+				if line == "" {
+					continue
+				}
+				fields := strings.Fields(line)
+				for _, f := range fields {
+					if strings.HasPrefix(f, "q-") {
+						got = append(got, f)
+					}
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.queries, ",") {
+				t.Errorf("Expected queries %v, got %v", tt.queries, got)
+			}
+		})
+	}
+}
+
+// TestCLIEngineHealthFromGateway tests that the client retrieves an
+// engine's recorded health from the gateway.
+// Green-Flag: Engine health MUST be fetched from /engines/{name}/health.
+func TestCLIEngineHealthFromGateway(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/engines/trino/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"engine":"trino","healthy":true,"last_check":"2026-01-05T10:00:00Z",` +
+			`"last_error":"connection refused","last_error_at":"2026-01-05T09:59:00Z","last_latency_ms":12,"latency_ms":30}`))
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	health, err := client.GetEngineHealth(context.Background(), "trino")
+	if err != nil {
+		t.Fatalf("GetEngineHealth failed: %v", err)
+	}
+	if !health.Healthy || health.LastError != "connection refused" || health.LatencyMs != 30 {
+		t.Errorf("unexpected engine health: %+v", health)
+	}
+	if !health.LastSuccessfulQuery.IsZero() {
+		t.Errorf("expected no successful query, got %v", health.LastSuccessfulQuery)
+	}
+}
+
+// TestCLITableEnginesFromGateway tests that the client retrieves the
+// candidate engines of a table from the gateway.
+// Green-Flag: Table engines MUST be fetched from /tables/{name}/engines.
+func TestCLITableEnginesFromGateway(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tables/sales.orders/engines" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"table":"sales.orders","format":"ICEBERG","engines":[` +
+			`{"engine":"trino","available":true,"selected":true,"priority":2,"capabilities":["READ"],"reason":"preferred for ICEBERG","estimated_time":"205ms"},` +
+			`{"engine":"duckdb","available":true,"selected":false,"priority":1,"capabilities":["READ"],"reason":"priority 1","estimated_time":"11ms"}]}`))
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	result, err := client.GetTableEngines(context.Background(), "sales.orders")
+	if err != nil {
+		t.Fatalf("GetTableEngines failed: %v", err)
+	}
+	if len(result.Engines) != 2 || !result.Engines[0].Selected || result.Engines[1].EstimatedTime != "11ms" {
+		t.Errorf("unexpected table engines: %+v", result)
+	}
+}
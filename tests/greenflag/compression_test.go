@@ -0,0 +1,66 @@
+package greenflag
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/compression"
+)
+
+// ndjsonRowsHandler streams n rows as NDJSON, flushing after each row.
+func ndjsonRowsHandler(n int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		for i := 0; i < n; i++ {
+			_ = encoder.Encode(map[string]any{"id": i, "name": fmt.Sprintf("customer-%d", i)})
+			w.(http.Flusher).Flush()
+		}
+	})
+}
+
+// TestGzipHandler_CompressesLargeResults tests compression of a large
+// streamed result.
+// Green-Flag: A large result MUST be gzip-compressed when the client accepts
+// gzip, and MUST decompress to the original rows.
+func TestGzipHandler_CompressesLargeResults(t *testing.T) {
+	handler := compression.NewGzipHandler(ndjsonRowsHandler(500), compression.DefaultMinSize)
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip Content-Encoding, got %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("expected flushes to reach the client")
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("expected a gzip body: %v", err)
+	}
+	scanner := bufio.NewScanner(reader)
+	rows := 0
+	for scanner.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("row %d: %v", rows, err)
+		}
+		if row["name"] != fmt.Sprintf("customer-%d", rows) {
+			t.Fatalf("row %d: unexpected row %v", rows, row)
+		}
+		rows++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rows != 500 {
+		t.Errorf("expected 500 rows, got %d", rows)
+	}
+}
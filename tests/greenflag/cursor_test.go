@@ -0,0 +1,122 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/federation"
+)
+
+// closeTrackingStream records whether the cursor manager closed its stream.
+type closeTrackingStream struct {
+	*federation.SliceStream
+	closed bool
+}
+
+func (s *closeTrackingStream) Close() error {
+	s.closed = true
+	return s.SliceStream.Close()
+}
+
+// TestCursor_PagesLargeResultToCompletion tests paging through a large result.
+// Green-Flag: A cursor MUST return every row exactly once, in order, and
+// release its stream after the last page.
+func TestCursor_PagesLargeResultToCompletion(t *testing.T) {
+	const total = 10500
+	rows := make([]federation.Row, total)
+	for i := range rows {
+		rows[i] = federation.Row{"id": i}
+	}
+	stream := &closeTrackingStream{SliceStream: federation.NewSliceStream(rows, &federation.ResultSchema{
+		Columns: []federation.ColumnDef{{Name: "id", Type: "INTEGER"}},
+	})}
+
+	manager := federation.NewCursorManager(0, 1000)
+	token, err := manager.Open(stream)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	ctx := context.Background()
+	next := 0
+	pages := 0
+	for page := 1; ; page++ {
+		result, err := manager.Fetch(ctx, token, page)
+		if err != nil {
+			t.Fatalf("Fetch page %d failed: %v", page, err)
+		}
+		pages++
+		if len(result.Columns) != 1 || result.Columns[0].Name != "id" {
+			t.Errorf("page %d: expected id column, got %v", page, result.Columns)
+		}
+		for _, row := range result.Rows {
+			if row["id"] != next {
+				t.Fatalf("page %d: expected id %d, got %v", page, next, row["id"])
+			}
+			next++
+		}
+		if result.Done {
+			break
+		}
+		if len(result.Rows) != 1000 {
+			t.Errorf("page %d: expected full page of 1000 rows, got %d", page, len(result.Rows))
+		}
+	}
+
+	if next != total {
+		t.Errorf("expected %d rows, got %d", total, next)
+	}
+	if pages != 11 {
+		t.Errorf("expected 11 pages, got %d", pages)
+	}
+	if !stream.closed {
+		t.Error("expected stream to be closed after the last page")
+	}
+	if manager.Len() != 0 {
+		t.Errorf("expected no open cursors, got %d", manager.Len())
+	}
+}
+
+// TestCursor_RefetchLastPage tests retrying the most recent page.
+// Green-Flag: Re-fetching the most recent page MUST return the same rows,
+// including after the last page.
+func TestCursor_RefetchLastPage(t *testing.T) {
+	rows := []federation.Row{{"id": 1}, {"id": 2}, {"id": 3}}
+	manager := federation.NewCursorManager(0, 2)
+	token, err := manager.Open(federation.NewSliceStream(rows, &federation.ResultSchema{}))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	first, err := manager.Fetch(context.Background(), token, 1)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	retry, err := manager.Fetch(context.Background(), token, 1)
+	if err != nil {
+		t.Fatalf("re-fetch failed: %v", err)
+	}
+	if len(retry.Rows) != 2 || retry.Rows[0]["id"] != first.Rows[0]["id"] {
+		t.Errorf("expected the same page on retry, got %v", retry.Rows)
+	}
+
+	second, err := manager.Fetch(context.Background(), token, 2)
+	if err != nil {
+		t.Fatalf("Fetch page 2 failed: %v", err)
+	}
+	if len(second.Rows) != 1 || second.Rows[0]["id"] != 3 || !second.Done {
+		t.Errorf("expected final page with id 3, got %+v", second)
+	}
+
+	// The last page stays retryable after the stream is exhausted
+	lastRetry, err := manager.Fetch(context.Background(), token, 2)
+	if err != nil {
+		t.Fatalf("re-fetch of last page failed: %v", err)
+	}
+	if len(lastRetry.Rows) != 1 || lastRetry.Rows[0]["id"] != 3 || !lastRetry.Done {
+		t.Errorf("expected the same final page on retry, got %+v", lastRetry)
+	}
+	if manager.Len() != 0 {
+		t.Errorf("expected no open cursors after the last page, got %d", manager.Len())
+	}
+}
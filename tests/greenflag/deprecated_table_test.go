@@ -0,0 +1,93 @@
+package greenflag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// planDeprecationTestQuery plans a simple SELECT against vt.
+func planDeprecationTestQuery(t *testing.T, vt *tables.VirtualTable) *planner.ExecutionPlan {
+	t.Helper()
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     1,
+	})
+	p := planner.NewPlanner(schemaTestRegistry{vt.Name: vt}, r)
+
+	logical, err := sql.NewParser().Parse("SELECT id FROM " + vt.Name)
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	plan, err := p.Plan(context.Background(), logical)
+	if err != nil {
+		t.Fatalf("expected query to succeed, got: %v", err)
+	}
+	return plan
+}
+
+// TestDeprecatedTable_QuerySucceedsWithWarning proves that deprecated tables
+// stay queryable and that the response carries the deprecation notice.
+//
+// Green-Flag: Queries on deprecated tables MUST succeed with a warning naming the replacement.
+func TestDeprecatedTable_QuerySucceedsWithWarning(t *testing.T) {
+	vt := schemaTestTable()
+	vt.Deprecated = true
+	vt.DeprecationMessage = "use analytics.events_v2 instead"
+
+	plan := planDeprecationTestQuery(t, vt)
+
+	if len(plan.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", plan.Warnings)
+	}
+	if !strings.Contains(plan.Warnings[0], "analytics.events") ||
+		!strings.Contains(plan.Warnings[0], "analytics.events_v2") {
+		t.Errorf("warning should name the table and its replacement, got: %s", plan.Warnings[0])
+	}
+}
+
+// TestDeprecatedTable_ActiveTableHasNoWarning proves that warnings are only
+// attached for deprecated tables.
+//
+// Green-Flag: Queries on non-deprecated tables MUST NOT carry warnings.
+func TestDeprecatedTable_ActiveTableHasNoWarning(t *testing.T) {
+	plan := planDeprecationTestQuery(t, schemaTestTable())
+	if len(plan.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", plan.Warnings)
+	}
+}
+
+// TestDeprecatedTable_RepositoryPreservesDeprecation proves that deprecation
+// survives registration.
+//
+// Green-Flag: Deprecation fields MUST round-trip through the repository.
+func TestDeprecatedTable_RepositoryPreservesDeprecation(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewMockRepository()
+
+	vt := schemaTestTable()
+	vt.Deprecated = true
+	vt.DeprecationMessage = "use analytics.events_v2 instead"
+	if err := repo.Create(ctx, vt); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	got, err := repo.Get(ctx, vt.Name)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if !got.Deprecated || got.DeprecationMessage != vt.DeprecationMessage {
+		t.Errorf("expected deprecation to be preserved, got deprecated=%v message=%q",
+			got.Deprecated, got.DeprecationMessage)
+	}
+}
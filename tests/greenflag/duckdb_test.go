@@ -11,6 +11,7 @@ import (
 	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/planner"
 	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/pkg/models"
 )
 
 // TestDuckDB_ExecuteSimpleSelect verifies the adapter can execute a simple SELECT.
@@ -283,3 +284,70 @@ func TestDuckDB_CheckHealthExecutesSelect1(t *testing.T) {
 		t.Fatalf("CheckHealth failed after successful Execute: %v", err)
 	}
 }
+
+// TestDuckDB_ReportsEngineVersion verifies that the adapter reports the
+// DuckDB version at startup.
+// Green-Flag: ProbeVersions MUST return a parseable version for DuckDB.
+func TestDuckDB_ReportsEngineVersion(t *testing.T) {
+	registry := adapters.NewAdapterRegistry()
+	adapter := duckdb.NewAdapter()
+	defer adapter.Close()
+	registry.Register(adapter)
+
+	versions, failures := registry.ProbeVersions(context.Background())
+	if len(failures) != 0 {
+		t.Fatalf("unexpected probe failures: %v", failures)
+	}
+
+	version, err := sql.ParseEngineVersion(versions["duckdb"])
+	if err != nil {
+		t.Fatalf("expected a parseable DuckDB version, got %q: %v", versions["duckdb"], err)
+	}
+	if version.IsZero() {
+		t.Errorf("expected a non-zero version, got %q", versions["duckdb"])
+	}
+}
+
+// TestDuckDB_ReportsColumnTypes verifies that results carry the engine's
+// column types.
+// Green-Flag: Numeric and timestamp columns MUST be typed in the result and
+// in the query response schema built from it.
+func TestDuckDB_ReportsColumnTypes(t *testing.T) {
+	adapter := duckdb.NewAdapter()
+	defer adapter.Close()
+
+	plan := &planner.ExecutionPlan{
+		LogicalPlan: &sql.LogicalPlan{
+			RawSQL:    "SELECT CAST(42 AS BIGINT) AS id, CAST(9.5 AS DOUBLE) AS amount, TIMESTAMP '2024-01-15 10:30:00' AS created_at, 'a' AS name",
+			Operation: capabilities.OperationSelect,
+		},
+		Engine: "duckdb",
+	}
+
+	result, err := adapter.Execute(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := []string{"BIGINT", "DOUBLE", "TIMESTAMP", "VARCHAR"}
+	if len(result.ColumnTypes) != len(want) {
+		t.Fatalf("expected column types %v, got %v", want, result.ColumnTypes)
+	}
+	for i, typ := range want {
+		if result.ColumnTypes[i] != typ {
+			t.Errorf("column %s: expected type %s, got %s", result.Columns[i], typ, result.ColumnTypes[i])
+		}
+	}
+
+	response := &models.QueryResponse{Columns: result.Columns}
+	response.SetColumnTypes(result.ColumnTypes)
+	if !response.Schema[0].IsNumeric() || !response.Schema[1].IsNumeric() {
+		t.Errorf("expected id and amount to be numeric, got %+v", response.Schema)
+	}
+	if !response.Schema[2].IsTimestamp() {
+		t.Errorf("expected created_at to be a timestamp, got %+v", response.Schema[2])
+	}
+	if response.Schema[3].IsNumeric() || response.Schema[3].IsTimestamp() {
+		t.Errorf("expected name to be neither numeric nor a timestamp, got %+v", response.Schema[3])
+	}
+}
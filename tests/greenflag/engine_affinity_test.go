@@ -0,0 +1,100 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// newAffinityRouter registers three engines capable of reading Delta tables.
+// Spark is the preferred engine for Delta.
+func newAffinityRouter() *router.Router {
+	r := router.NewRouter()
+	for i, name := range []string{"trino", "duckdb", "spark"} {
+		r.RegisterEngine(&router.Engine{
+			Name:         name,
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+			Available:    true,
+			Priority:     i + 1,
+		})
+	}
+	return r
+}
+
+// affinityPlan returns a read plan over a Delta table with no engine assigned.
+func affinityPlan() *planner.ExecutionPlan {
+	return &planner.ExecutionPlan{
+		ResolvedTables: []*tables.VirtualTable{{
+			Name:    "sales.events",
+			Sources: []tables.PhysicalSource{{Format: tables.FormatDelta, Location: "s3://bucket/events"}},
+		}},
+		RequiredCapabilities: []capabilities.Capability{capabilities.CapabilityRead},
+	}
+}
+
+// TestEngineAffinity_RepeatedQueriesReuseEngine tests engine affinity.
+//
+// Green-Flag: Repeated queries on a table MUST reuse the engine that last
+// served it while it stays healthy, instead of re-running selection.
+func TestEngineAffinity_RepeatedQueriesReuseEngine(t *testing.T) {
+	r := newAffinityRouter()
+	selector := router.NewEngineSelector(r, nil)
+	selector.SetEngineAffinityTTL(time.Minute)
+	ctx := context.Background()
+
+	// Spark is down, so the query falls back to Trino
+	r.SetEngineAvailability("spark", false)
+	engine, err := selector.SelectEngine(ctx, affinityPlan())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine != "trino" {
+		t.Fatalf("expected trino while spark is down, got %s", engine)
+	}
+	selector.RecordEngineSuccess("sales.events", engine)
+
+	// Spark recovers, but the table keeps its affine engine
+	r.SetEngineAvailability("spark", true)
+	for i := 0; i < 3; i++ {
+		engine, err := selector.SelectEngine(ctx, affinityPlan())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if engine != "trino" {
+			t.Fatalf("query %d: expected affine engine trino, got %s", i, engine)
+		}
+		selector.RecordEngineSuccess("sales.events", engine)
+	}
+}
+
+// TestEngineAffinity_ExpiresForReevaluation tests engine affinity expiry.
+//
+// Green-Flag: Engine affinity MUST expire after its TTL so selection is
+// re-evaluated.
+func TestEngineAffinity_ExpiresForReevaluation(t *testing.T) {
+	selector := router.NewEngineSelector(newAffinityRouter(), nil)
+	selector.SetEngineAffinityTTL(20 * time.Millisecond)
+	selector.RecordEngineSuccess("sales.events", "trino")
+
+	engine, err := selector.SelectEngine(context.Background(), affinityPlan())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine != "trino" {
+		t.Fatalf("expected affine engine trino, got %s", engine)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	engine, err = selector.SelectEngine(context.Background(), affinityPlan())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine != "spark" {
+		t.Errorf("expected re-evaluation to select spark, got %s", engine)
+	}
+}
@@ -0,0 +1,66 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/federation"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// TestCandidateEngines_ListsCapableEnginesWithCost tests the candidate
+// engines of an Iceberg table.
+//
+// Green-Flag: The engines that can serve a table MUST be listed in
+// selection order with their availability and estimated cost.
+func TestCandidateEngines_ListsCapableEnginesWithCost(t *testing.T) {
+	r := router.NewRouter()
+	for i, name := range []string{"duckdb", "trino", "bigquery"} {
+		r.RegisterEngine(&router.Engine{
+			Name:         name,
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+			Available:    true,
+			Priority:     i + 1,
+		})
+	}
+	selector := router.NewEngineSelector(r, nil)
+	selector.SetCostEstimator(federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), storage.NewMockRepository()))
+
+	table := &tables.VirtualTable{
+		Name:    "sales.orders",
+		Sources: []tables.PhysicalSource{{Format: tables.FormatIceberg, Location: "s3://lake/orders"}},
+	}
+	candidates := selector.CandidateEngines(context.Background(), table, []capabilities.Capability{capabilities.CapabilityRead})
+
+	// BigQuery cannot read Iceberg; Trino is preferred for it
+	if len(candidates) != 2 || candidates[0].Engine != "trino" || candidates[1].Engine != "duckdb" {
+		t.Fatalf("expected trino then duckdb, got %+v", candidates)
+	}
+	if !candidates[0].Selected || candidates[1].Selected {
+		t.Errorf("expected only trino to be selected, got %+v", candidates)
+	}
+	if candidates[0].Reason != "preferred for ICEBERG" || candidates[1].Reason != "priority 1" {
+		t.Errorf("unexpected reasons: %q, %q", candidates[0].Reason, candidates[1].Reason)
+	}
+	for _, candidate := range candidates {
+		if !candidate.Available || len(candidate.Capabilities) != 1 || candidate.Capabilities[0] != capabilities.CapabilityRead {
+			t.Errorf("expected %s to be available with READ, got %+v", candidate.Engine, candidate)
+		}
+		if candidate.EstimatedTime <= 0 {
+			t.Errorf("expected an estimated cost for %s", candidate.Engine)
+		}
+	}
+	if candidates[1].EstimatedTime >= candidates[0].EstimatedTime {
+		t.Errorf("expected the local duckdb scan to be cheaper than trino: %s vs %s",
+			candidates[1].EstimatedTime, candidates[0].EstimatedTime)
+	}
+
+	resp := selector.TableEnginesResponse(table, candidates)
+	if resp.Format != "ICEBERG" || len(resp.Engines) != 2 || resp.Engines[0].EstimatedTime == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
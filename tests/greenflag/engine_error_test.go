@@ -0,0 +1,109 @@
+package greenflag
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// TestEngineError_ClassifiesEnginePayloads verifies representative Trino and
+// Spark error payloads map to the right category.
+// Green-Flag: Engine errors MUST be classified by their error name or exception class.
+func TestEngineError_ClassifiesEnginePayloads(t *testing.T) {
+	cases := []struct {
+		engine   string
+		message  string
+		category errors.EngineErrorCategory
+	}{
+		{"trino", `trino: query failed (200 OK): "line 1:8: mismatched input 'FORM'. Expecting: <expression>" (SYNTAX_ERROR)`, errors.EngineErrorSyntax},
+		{"trino", `trino: query failed (200 OK): "Access Denied: Cannot select from table hive.sales.orders" (PERMISSION_DENIED)`, errors.EngineErrorPermission},
+		{"trino", `trino: query failed (200 OK): "line 1:15: Table 'hive.sales.orders' does not exist" (TABLE_NOT_FOUND)`, errors.EngineErrorNotFound},
+		{"trino", `trino: query failed (200 OK): "Query exceeded distributed user memory limit of 2GB" (EXCEEDED_USER_MEMORY_LIMIT)`, errors.EngineErrorResource},
+		{"trino", `trino: query failed (200 OK): "Trino server is still initializing" (SERVER_STARTING_UP)`, errors.EngineErrorTransient},
+		{"spark", `org.apache.spark.sql.catalyst.parser.ParseException: [PARSE_SYNTAX_ERROR] Syntax error at or near 'FORM'`, errors.EngineErrorSyntax},
+		{"spark", `org.apache.spark.sql.AnalysisException: [TABLE_OR_VIEW_NOT_FOUND] The table or view sales.orders cannot be found`, errors.EngineErrorNotFound},
+		{"spark", `org.apache.hadoop.security.AccessControlException: Permission denied: user=canonic, access=READ`, errors.EngineErrorPermission},
+		{"spark", `java.lang.OutOfMemoryError: Java heap space`, errors.EngineErrorResource},
+		{"spark", `dial tcp 10.0.0.5:10000: connect: connection refused`, errors.EngineErrorTransient},
+	}
+
+	for _, tc := range cases {
+		err := adapters.ClassifyEngineError(tc.engine, fmt.Errorf("%s adapter: query execution failed: %w", tc.engine, stderrors.New(tc.message)))
+
+		var engineErr *errors.ErrEngineQuery
+		if !stderrors.As(err, &engineErr) {
+			t.Fatalf("%q: expected ErrEngineQuery, got %T", tc.message, err)
+		}
+		if engineErr.Category != tc.category {
+			t.Errorf("%q: expected category %s, got %s", tc.message, tc.category, engineErr.Category)
+		}
+		if engineErr.Engine != tc.engine {
+			t.Errorf("%q: expected engine %s, got %s", tc.message, tc.engine, engineErr.Engine)
+		}
+	}
+}
+
+// TestEngineError_CategoryMapsToCodeAndStatus verifies each category maps to
+// an error code and HTTP status.
+// Green-Flag: Classified engine errors MUST carry the matching code and HTTP status.
+func TestEngineError_CategoryMapsToCodeAndStatus(t *testing.T) {
+	cases := []struct {
+		category errors.EngineErrorCategory
+		code     errors.ErrorCode
+		status   int
+	}{
+		{errors.EngineErrorSyntax, errors.CodeValidation, http.StatusBadRequest},
+		{errors.EngineErrorPermission, errors.CodeAuth, http.StatusForbidden},
+		{errors.EngineErrorNotFound, errors.CodeValidation, http.StatusNotFound},
+		{errors.EngineErrorTransient, errors.CodeEngine, http.StatusServiceUnavailable},
+		{errors.EngineErrorResource, errors.CodeEngine, http.StatusUnprocessableEntity},
+		{errors.EngineErrorUnknown, errors.CodeEngine, http.StatusBadGateway},
+	}
+
+	for _, tc := range cases {
+		err := errors.NewEngineQueryError("trino", tc.category, stderrors.New("boom"))
+		if err.Code != tc.code {
+			t.Errorf("%s: expected code %d, got %d", tc.category, tc.code, err.Code)
+		}
+		if err.HTTPStatus() != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.category, tc.status, err.HTTPStatus())
+		}
+		if err.Reason == "" || err.Suggestion == "" {
+			t.Errorf("%s: expected reason and suggestion", tc.category)
+		}
+	}
+}
+
+// TestEngineError_TransientErrorsAreRetried verifies retry logic retries
+// transient engine errors.
+// Green-Flag: Transient engine errors MUST be retried up to MaxAttempts.
+func TestEngineError_TransientErrorsAreRetried(t *testing.T) {
+	config := adapters.RetryConfig{
+		MaxAttempts:       3,
+		InitialDelay:      time.Millisecond,
+		MaxDelay:          time.Millisecond,
+		BackoffMultiplier: 1,
+	}
+
+	calls := 0
+	result := adapters.ExecuteWithRetry(context.Background(), config, func() error {
+		calls++
+		if calls < 3 {
+			return adapters.ClassifyEngineError("trino", stderrors.New("read tcp: connection reset by peer"))
+		}
+		return nil
+	})
+
+	if !result.Success {
+		t.Fatalf("expected success after retries, got %v", result)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,182 @@
+package greenflag
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/adapters/duckdb"
+	"github.com/canonica-labs/canonica/internal/adapters/loader"
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// staticSecrets resolves credential references from a map.
+type staticSecrets map[string]string
+
+func (s staticSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	return s[ref], nil
+}
+
+// TestEngineLoader_RegistersStoredEngine verifies that an engine defined in
+// the repository is registered and usable.
+// Green-Flag: A stored, enabled engine MUST be registered under its name.
+func TestEngineLoader_RegistersStoredEngine(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewMockEngineRepository()
+	if err := repo.Save(ctx, &storage.EngineDefinition{
+		Name:         "local-duckdb",
+		Type:         "duckdb",
+		Database:     ":memory:",
+		Capabilities: []string{"read"},
+		Enabled:      true,
+	}); err != nil {
+		t.Fatalf("failed to save engine: %v", err)
+	}
+
+	registry := adapters.NewAdapterRegistry()
+	engineLoader := loader.NewLoader(repo, registry, nil)
+	result, err := engineLoader.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	defer registry.CloseAll()
+
+	if len(result.Added) != 1 || result.Added[0] != "local-duckdb" {
+		t.Fatalf("expected local-duckdb to be added, got %+v", result)
+	}
+
+	adapter, ok := registry.Get("local-duckdb")
+	if !ok {
+		t.Fatal("expected local-duckdb in registry")
+	}
+	if adapter.Name() != "local-duckdb" {
+		t.Errorf("expected adapter name local-duckdb, got %s", adapter.Name())
+	}
+	if caps := adapter.Capabilities(); len(caps) != 1 || caps[0] != capabilities.CapabilityRead {
+		t.Errorf("expected capabilities [READ], got %v", caps)
+	}
+	if err := adapter.Ping(ctx); err != nil {
+		t.Errorf("expected usable adapter, ping failed: %v", err)
+	}
+}
+
+// TestEngineLoader_ResolvesCredentialThroughSecrets verifies credentials are
+// resolved through the secrets provider.
+// Green-Flag: The factory MUST receive the secret named by CredentialRef.
+func TestEngineLoader_ResolvesCredentialThroughSecrets(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewMockEngineRepository()
+	_ = repo.Save(ctx, &storage.EngineDefinition{
+		Name:          "warehouse",
+		Type:          "custom",
+		CredentialRef: "WAREHOUSE_PASSWORD",
+		Enabled:       true,
+	})
+
+	var got string
+	registry := adapters.NewAdapterRegistry()
+	engineLoader := loader.NewLoader(repo, registry, staticSecrets{"WAREHOUSE_PASSWORD": "s3cret"})
+	engineLoader.RegisterFactory("custom", func(ctx context.Context, def *storage.EngineDefinition, credential string) (adapters.EngineAdapter, error) {
+		got = credential
+		return duckdb.NewAdapter(), nil
+	})
+
+	if _, err := engineLoader.Refresh(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	defer registry.CloseAll()
+
+	if got != "s3cret" {
+		t.Errorf("expected credential s3cret, got %q", got)
+	}
+	if _, ok := registry.Get("warehouse"); !ok {
+		t.Error("expected warehouse in registry")
+	}
+}
+
+// TestAdapterRegistry_TablesOnRegisteredEnginesPass verifies the startup
+// check of table engines against registered adapters.
+// Green-Flag: Tables assigned to registered engines, or routed by format,
+// MUST pass the startup check.
+func TestAdapterRegistry_TablesOnRegisteredEnginesPass(t *testing.T) {
+	registry := adapters.NewAdapterRegistry()
+	defer registry.CloseAll()
+	registry.Register(duckdb.NewAdapter())
+
+	registered := []*tables.VirtualTable{
+		{Name: "sales.events", Sources: []tables.PhysicalSource{{Engine: "duckdb", Format: tables.FormatParquet, Location: "s3://bucket/events"}}},
+		{Name: "sales.orders", Sources: []tables.PhysicalSource{{Format: tables.FormatIceberg, Location: "s3://bucket/orders"}}},
+	}
+
+	if err := registry.CheckTableAdapters(registered); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if missing := registry.MissingAdapters(registered); len(missing) != 0 {
+		t.Errorf("expected no missing adapters, got %v", missing)
+	}
+}
+
+// flakyHealthAdapter is a DuckDB adapter whose health check fails while err
+// is set.
+type flakyHealthAdapter struct {
+	adapters.EngineAdapter
+	err error
+}
+
+func (a *flakyHealthAdapter) CheckHealth(ctx context.Context) error {
+	return a.err
+}
+
+// TestAdapterRegistry_HealthSnapshotTracksRecovery verifies that an engine's
+// health snapshot reflects a failed and then successful health check.
+// Green-Flag: After recovering, the snapshot MUST report the engine healthy
+// while keeping the last error, and record successful queries.
+func TestAdapterRegistry_HealthSnapshotTracksRecovery(t *testing.T) {
+	ctx := context.Background()
+	adapter := &flakyHealthAdapter{EngineAdapter: duckdb.NewAdapter(), err: stderrors.New("connection refused")}
+	registry := adapters.NewAdapterRegistry()
+	registry.Register(adapter)
+
+	snapshot, ok := registry.HealthSnapshot("duckdb")
+	if !ok || !snapshot.LastCheck.IsZero() {
+		t.Fatalf("expected an empty snapshot before any check, got %+v (ok %v)", snapshot, ok)
+	}
+
+	snapshot, err := registry.CheckHealth(ctx, "duckdb")
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if snapshot.Healthy || snapshot.LastError != "connection refused" {
+		t.Fatalf("expected the failed check to be recorded, got %+v", snapshot)
+	}
+	failedAt := snapshot.LastErrorAt
+
+	adapter.err = nil
+	snapshot, err = registry.CheckHealth(ctx, "duckdb")
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if !snapshot.Healthy {
+		t.Errorf("expected the engine to be healthy after a successful check, got %+v", snapshot)
+	}
+	if snapshot.LastError != "connection refused" || !snapshot.LastErrorAt.Equal(failedAt) {
+		t.Errorf("expected the last error to be kept after recovery, got %+v", snapshot)
+	}
+	if snapshot.LastCheck.Before(failedAt) {
+		t.Errorf("expected the last check to be the successful one, got %+v", snapshot)
+	}
+
+	registry.RecordQuery("duckdb", 40*time.Millisecond, nil)
+	registry.RecordQuery("duckdb", 20*time.Millisecond, nil)
+	snapshot, _ = registry.HealthSnapshot("duckdb")
+	if snapshot.LastSuccessfulQuery.IsZero() {
+		t.Error("expected the last successful query time to be recorded")
+	}
+	if snapshot.LastLatencyMs != 20 || snapshot.LatencyMs < 15 || snapshot.LatencyMs > 20 {
+		t.Errorf("expected last latency 20ms and a recent average, got %+v", snapshot)
+	}
+}
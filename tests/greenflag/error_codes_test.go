@@ -0,0 +1,53 @@
+package greenflag
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/cli"
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// TestErrorCodes_MapToHTTPStatusAndExitCode verifies the central mapping of
+// error codes to HTTP statuses and CLI exit codes.
+// Green-Flag: Each error code MUST map to its documented exit code and HTTP
+// status, and a capability denial MUST be 403 and exit 2 everywhere.
+func TestErrorCodes_MapToHTTPStatusAndExitCode(t *testing.T) {
+	cases := []struct {
+		code   errors.ErrorCode
+		status int
+		exit   int
+	}{
+		{errors.CodeValidation, http.StatusBadRequest, cli.ExitValidation},
+		{errors.CodeAuth, http.StatusForbidden, cli.ExitAuth},
+		{errors.CodeEngine, http.StatusServiceUnavailable, cli.ExitEngine},
+		{errors.CodeInternal, http.StatusInternalServerError, cli.ExitInternal},
+	}
+	for _, tc := range cases {
+		if status := tc.code.HTTPStatus(); status != tc.status {
+			t.Errorf("code %d: expected status %d, got %d", tc.code, tc.status, status)
+		}
+		if exit := tc.code.ExitCode(); exit != tc.exit {
+			t.Errorf("code %d: expected exit code %d, got %d", tc.code, tc.exit, exit)
+		}
+		err := fmt.Errorf("wrapped: %w", &errors.CanonicError{Code: tc.code, Message: "failed"})
+		if exit := errors.ExitCodeForError(err); exit != tc.exit {
+			t.Errorf("code %d: expected exit code %d for a wrapped error, got %d", tc.code, tc.exit, exit)
+		}
+	}
+	if exit := errors.ExitCodeForError(nil); exit != cli.ExitSuccess {
+		t.Errorf("expected exit code %d for no error, got %d", cli.ExitSuccess, exit)
+	}
+
+	denied := fmt.Errorf("planner: %w", errors.NewCapabilityDenied("analytics.orders", "TIME_TRAVEL", "SELECT"))
+	if exit := errors.ExitCodeForError(denied); exit != cli.ExitAuth {
+		t.Errorf("expected capability denial to exit %d, got %d", cli.ExitAuth, exit)
+	}
+	recorder := httptest.NewRecorder()
+	errors.WriteHTTPError(recorder, denied)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("expected capability denial to be 403, got %d", recorder.Code)
+	}
+}
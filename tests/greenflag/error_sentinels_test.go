@@ -0,0 +1,57 @@
+package greenflag
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// TestErrorSentinels_MatchWrappedTypedErrors verifies that typed errors match
+// their category with errors.Is however deeply they are wrapped.
+// Green-Flag: A wrapped ErrCapabilityDenied MUST match ErrForbidden and be
+// reported as 403 Forbidden, and a failed sub-query MUST match the category
+// of its engine error.
+func TestErrorSentinels_MatchWrappedTypedErrors(t *testing.T) {
+	denied := fmt.Errorf("plan: %w", fmt.Errorf("resolve: %w",
+		errors.NewCapabilityDenied("analytics.orders", "TIME_TRAVEL", "SELECT")))
+	if !stderrors.Is(denied, errors.ErrForbidden) {
+		t.Error("expected a wrapped ErrCapabilityDenied to match ErrForbidden")
+	}
+	if status := errors.HTTPStatus(denied); status != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", status)
+	}
+	var typed *errors.ErrCapabilityDenied
+	if !stderrors.As(denied, &typed) || typed.Table != "analytics.orders" {
+		t.Error("expected errors.As to still find the typed error")
+	}
+
+	cases := []struct {
+		name     string
+		err      error
+		sentinel error
+		status   int
+	}{
+		{"table not found", errors.NewTableNotFound("analytics.missing"), errors.ErrNotFound, http.StatusNotFound},
+		{"auth failed", errors.NewAuthFailed("token expired"), errors.ErrUnauthenticated, http.StatusUnauthorized},
+		{"unsupported syntax", errors.NewUnsupportedSyntax("WINDOW FUNCTION", ""), errors.ErrInvalid, http.StatusBadRequest},
+		{"engine unavailable", errors.NewEngineUnavailable([]string{"READ"}), errors.ErrUnavailable, http.StatusServiceUnavailable},
+		{
+			"failed sub-query",
+			errors.NewSubQueryFailed("trino", []string{"sales.orders"}, "SELECT 1",
+				errors.NewEngineQueryError("trino", errors.EngineErrorTransient, stderrors.New("node lost"))),
+			errors.ErrUnavailable, http.StatusServiceUnavailable,
+		},
+	}
+	for _, tc := range cases {
+		wrapped := fmt.Errorf("gateway: %w", tc.err)
+		if !stderrors.Is(wrapped, tc.sentinel) {
+			t.Errorf("%s: expected to match %v", tc.name, tc.sentinel)
+		}
+		if status := errors.HTTPStatus(wrapped); status != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, status)
+		}
+	}
+}
@@ -6,11 +6,23 @@
 package greenflag
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/canonica-labs/canonica/internal/adapters"
 	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/catalog"
 	"github.com/canonica-labs/canonica/internal/federation"
+	"github.com/canonica-labs/canonica/internal/observability"
 	"github.com/canonica-labs/canonica/internal/sql"
 	"github.com/canonica-labs/canonica/internal/storage"
 	"github.com/canonica-labs/canonica/internal/tables"
@@ -30,10 +42,9 @@ func TestDecomposer_CrossEngineQuery(t *testing.T) {
 			{
 				Type:       federation.JoinTypeInner,
 				LeftTable:  "t1",
-				LeftCol:    "id",
+				LeftCols:   []string{"id"},
 				RightTable: "t2",
-				RightCol:   "id",
-				Operator:   "=",
+				RightCols:  []string{"id"},
 			},
 		},
 		RequiredColumns: map[string][]string{
@@ -92,8 +103,8 @@ func TestHashJoin_InnerJoin(t *testing.T) {
 	config := federation.HashJoinConfig{
 		BuildSide: buildStream,
 		ProbeSide: probeStream,
-		BuildKey:  "id",
-		ProbeKey:  "id",
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"id"},
 		Type:      federation.JoinTypeInner,
 	}
 
@@ -151,8 +162,8 @@ func TestHashJoin_LeftJoin(t *testing.T) {
 	config := federation.HashJoinConfig{
 		BuildSide: buildStream,
 		ProbeSide: probeStream,
-		BuildKey:  "id",
-		ProbeKey:  "id",
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"id"},
 		Type:      federation.JoinTypeLeft,
 	}
 
@@ -224,6 +235,83 @@ func TestCostEstimator_BasicEstimate(t *testing.T) {
 	}
 }
 
+// TestCostEstimator_UnregisteredEngineUsesGenericProfile tests the fallback
+// for engines without tuned cost factors.
+// Green-Flag: Estimation for an unregistered engine MUST use the generic profile.
+func TestCostEstimator_UnregisteredEngineUsesGenericProfile(t *testing.T) {
+	model := federation.NewCostModel()
+	estimator := federation.NewCostEstimator(model, nil)
+
+	query := &federation.SubQuery{
+		Engine: "clickhouse",
+		SQL:    "SELECT * FROM t1",
+		Tables: []*federation.TableRef{{Schema: "analytics", Name: "events"}},
+	}
+
+	if model.HasFactors(query.Engine) {
+		t.Fatalf("test requires an engine without cost factors")
+	}
+	if got := model.GetFactors(query.Engine); got != federation.GenericCostFactors {
+		t.Errorf("expected generic cost factors, got %+v", got)
+	}
+
+	cost, err := estimator.EstimateCost(context.Background(), query, query.Engine)
+	if err != nil {
+		t.Fatalf("expected estimate for unregistered engine, got error: %v", err)
+	}
+	if !cost.GenericProfile {
+		t.Error("expected cost to be marked as using the generic profile")
+	}
+	if cost.EstimatedTime <= 0 {
+		t.Errorf("expected positive estimated time, got %v", cost.EstimatedTime)
+	}
+
+	known, err := estimator.EstimateCost(context.Background(), query, "duckdb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if known.GenericProfile {
+		t.Error("expected duckdb estimate to use its own factors")
+	}
+}
+
+// TestCostModel_GenericProfileDiagnostic tests where the generic-profile
+// fallback is reported.
+// Green-Flag: The fallback MUST be reported once, with its package prefix,
+// to the injected DiagnosticLogger.
+func TestCostModel_GenericProfileDiagnostic(t *testing.T) {
+	var buf bytes.Buffer
+	observability.SetDiagnosticLogger(log.New(&buf, "", 0))
+	defer observability.SetDiagnosticLogger(nil)
+
+	model := federation.NewCostModel()
+	model.GetFactors("clickhouse")
+	model.GetFactors("clickhouse")
+
+	want := "federation: no cost factors for engine \"clickhouse\", using generic cost profile\n"
+	if buf.String() != want {
+		t.Errorf("expected one diagnostic %q, got %q", want, buf.String())
+	}
+}
+
+// TestCostModel_ConfigurableGenericProfile tests overriding the generic profile.
+// Green-Flag: The generic profile MUST be configurable.
+func TestCostModel_ConfigurableGenericProfile(t *testing.T) {
+	model := federation.NewCostModel()
+	custom := &federation.EngineCostFactors{
+		QueryOverhead:  time.Second,
+		ScanCostPerRow: 0.01,
+	}
+	model.SetGenericFactors(custom)
+
+	if got := model.GetFactors("new_engine"); got != custom {
+		t.Errorf("expected custom generic factors, got %+v", got)
+	}
+	if got := model.GetFactors("trino"); got == custom {
+		t.Error("known engines must keep their own factors")
+	}
+}
+
 // TestMemoryResultStore_BasicOperations tests result store.
 // Green-Flag: Memory store MUST store and retrieve rows correctly.
 func TestMemoryResultStore_BasicOperations(t *testing.T) {
@@ -289,10 +377,9 @@ func TestJoinStrategySelector_SmallTable(t *testing.T) {
 	joinCondition := &federation.JoinCondition{
 		Type:       federation.JoinTypeInner,
 		LeftTable:  "t1",
-		LeftCol:    "id",
+		LeftCols:   []string{"id"},
 		RightTable: "t2",
-		RightCol:   "id",
-		Operator:   "=",
+		RightCols:  []string{"id"},
 	}
 
 	strategy, config := selector.SelectStrategy(leftStream, rightStream, joinCondition)
@@ -376,6 +463,156 @@ func TestTableFormat_EngineMapping(t *testing.T) {
 	}
 }
 
+// TestAnalyzer_ConfiguredFormatEngine verifies that tables without an
+// explicit engine follow the configured format mapping.
+// Green-Flag: An Iceberg table without an engine MUST route to the configured engine.
+func TestAnalyzer_ConfiguredFormatEngine(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, format := range map[string]tables.StorageFormat{
+		"lake.events": tables.FormatIceberg,
+		"lake.users":  tables.FormatDelta,
+	} {
+		err := repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Format: format, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	catalog.SetFormatEngines(map[catalog.TableFormat]string{catalog.FormatIceberg: "duckdb"})
+	defer catalog.SetFormatEngines(nil)
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	analysis, err := analyzer.Analyze(context.Background(),
+		"SELECT e.id, u.name FROM lake.events e JOIN lake.users u ON e.user_id = u.id")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	if len(analysis.TablesByEngine["duckdb"]) != 1 || analysis.TablesByEngine["duckdb"][0].FullName() != "lake.events" {
+		t.Errorf("expected lake.events on duckdb, got %v", analysis.TablesByEngine)
+	}
+	if len(analysis.TablesByEngine["spark"]) != 1 || analysis.TablesByEngine["spark"][0].FullName() != "lake.users" {
+		t.Errorf("expected lake.users to keep its default engine spark, got %v", analysis.TablesByEngine)
+	}
+}
+
+// TestAnalyzer_FormatOverrideRouting verifies that a table's format override
+// takes precedence over the detected format.
+// Green-Flag: A Delta-detected table overridden to Iceberg MUST route to the
+// Iceberg engine; a table without an override MUST route by its detected format.
+func TestAnalyzer_FormatOverrideRouting(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, override := range map[string]tables.StorageFormat{
+		"lake.events": tables.FormatIceberg,
+		"lake.users":  "",
+	} {
+		err := repo.Create(context.Background(), &tables.VirtualTable{
+			Name:           name,
+			Sources:        []tables.PhysicalSource{{Format: tables.FormatDelta, Location: "s3://bucket/" + name}},
+			FormatOverride: override,
+			Capabilities:   []capabilities.Capability{capabilities.CapabilityRead},
+		})
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	analysis, err := analyzer.Analyze(context.Background(),
+		"SELECT e.id, u.name FROM lake.events e JOIN lake.users u ON e.user_id = u.id")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	trino := analysis.TablesByEngine["trino"]
+	if len(trino) != 1 || trino[0].FullName() != "lake.events" || trino[0].Format != catalog.TableFormat("ICEBERG") {
+		t.Errorf("expected overridden lake.events as Iceberg on trino, got %v", analysis.TablesByEngine)
+	}
+	if spark := analysis.TablesByEngine["spark"]; len(spark) != 1 || spark[0].FullName() != "lake.users" {
+		t.Errorf("expected lake.users to route by its detected Delta format to spark, got %v", analysis.TablesByEngine)
+	}
+}
+
+// TestAnalyzer_ReservedWordAliases verifies alias extraction when aliases
+// collide with keywords.
+// Green-Flag: Quoted keyword aliases MUST be attached to their tables, no
+// table MUST be dropped, and each keyword alias MUST produce a warning.
+func TestAnalyzer_ReservedWordAliases(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	analysis, err := analyzer.Analyze(context.Background(),
+		"SELECT `order`.id, `select`.name FROM sales.orders `order` JOIN sales.customers `select` ON `order`.customer_id = `select`.id")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	aliases := map[string]string{}
+	for _, refs := range analysis.TablesByEngine {
+		for _, ref := range refs {
+			aliases[ref.FullName()] = ref.Alias
+		}
+	}
+	if aliases["sales.orders"] != "order" || aliases["sales.customers"] != "select" {
+		t.Errorf("expected aliases order and select, got %v", aliases)
+	}
+	if len(analysis.Warnings) != 2 {
+		t.Fatalf("expected a warning per reserved-word alias, got %v", analysis.Warnings)
+	}
+	for i, alias := range []string{"order", "select"} {
+		if !strings.Contains(analysis.Warnings[i], `"`+alias+`"`) {
+			t.Errorf("warning %d should name alias %q: %s", i, alias, analysis.Warnings[i])
+		}
+	}
+}
+
+// TestAnalyzer_ConfiguredReservedWords verifies the configurable reserved words.
+// Green-Flag: An alias that is reserved only by configuration MUST be kept
+// and MUST produce a warning; ordinary aliases MUST NOT.
+func TestAnalyzer_ConfiguredReservedWords(t *testing.T) {
+	repo := storage.NewMockRepository()
+	_ = repo.Create(context.Background(), &tables.VirtualTable{
+		Name:         "sales.orders",
+		Sources:      []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/orders"}},
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+	})
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	analysis, err := analyzer.Analyze(context.Background(), "SELECT uescape.id FROM sales.orders uescape")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if len(analysis.Warnings) != 0 {
+		t.Errorf("expected no warnings before configuring reserved words, got %v", analysis.Warnings)
+	}
+
+	analyzer.SetReservedWords([]string{"UESCAPE"})
+	analysis, err = analyzer.Analyze(context.Background(), "SELECT uescape.id FROM sales.orders uescape")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if ref := analysis.TablesByEngine["trino"][0]; ref.Alias != "uescape" {
+		t.Errorf("expected alias uescape, got %q", ref.Alias)
+	}
+	if len(analysis.Warnings) != 1 {
+		t.Errorf("expected a warning for the configured reserved word, got %v", analysis.Warnings)
+	}
+}
+
 // newMockResultStream creates a mock result stream for testing.
 func newMockResultStream(rows []federation.Row, schema *federation.ResultSchema) *mockResultStream {
 	return &mockResultStream{
@@ -532,3 +769,2856 @@ func (s *successAdapter) TableStats(ctx context.Context, table string) (*federat
 func (s *successAdapter) HealthCheck(ctx context.Context) bool {
 	return true
 }
+
+// countingStream yields rows lazily and records how many have been consumed.
+type countingStream struct {
+	total    int
+	groups   int
+	consumed int
+}
+
+func (c *countingStream) Schema() *federation.ResultSchema {
+	return &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "region", Type: "int"},
+		{Name: "amount", Type: "int"},
+	}}
+}
+
+func (c *countingStream) Next(ctx context.Context) (federation.Row, error) {
+	if c.consumed >= c.total {
+		return nil, nil
+	}
+	perGroup := c.total / c.groups
+	row := federation.Row{"region": c.consumed / perGroup, "amount": 1}
+	c.consumed++
+	return row, nil
+}
+
+func (c *countingStream) Close() error { return nil }
+
+func (c *countingStream) EstimatedRows() int64 { return int64(c.total) }
+
+// TestAggregation_StreamingMatchesBuffered tests sorted-input aggregation.
+// Green-Flag: Streaming aggregation on pre-sorted input MUST match buffered aggregation.
+func TestAggregation_StreamingMatchesBuffered(t *testing.T) {
+	rows := []federation.Row{
+		{"region": "east", "total": 10},
+		{"region": "east", "total": 20},
+		{"region": "north", "total": 5},
+		{"region": "west", "total": 7.5},
+		{"region": "west", "total": nil},
+		{"region": "west", "total": 2.5},
+	}
+	schema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "region", Type: "string"},
+		{Name: "total", Type: "float"},
+	}}
+	aggs := []*federation.Aggregation{
+		{Function: "SUM", Column: "total", Alias: "sum_total"},
+		{Function: "COUNT", Column: "*", Alias: "cnt"},
+		{Function: "MAX", Column: "o.total", Alias: "max_total"},
+	}
+	groupBy := []string{"o.region"}
+
+	buffered, err := federation.CollectStream(context.Background(),
+		federation.NewAggregationStream(newMockResultStream(rows, schema), aggs, groupBy))
+	if err != nil {
+		t.Fatalf("buffered aggregation failed: %v", err)
+	}
+
+	sorted := federation.NewOrderedStream(newMockResultStream(rows, schema), []string{"region"})
+	streamed, err := federation.CollectStream(context.Background(),
+		federation.NewAggregationStream(sorted, aggs, groupBy))
+	if err != nil {
+		t.Fatalf("streaming aggregation failed: %v", err)
+	}
+
+	if len(buffered) != 3 || len(streamed) != 3 {
+		t.Fatalf("expected 3 groups, got buffered=%d streamed=%d", len(buffered), len(streamed))
+	}
+	for i := range buffered {
+		if fmt.Sprint(buffered[i]) != fmt.Sprint(streamed[i]) {
+			t.Errorf("group %d mismatch: buffered=%v streamed=%v", i, buffered[i], streamed[i])
+		}
+	}
+
+	if streamed[0]["sum_total"] != int64(30) || streamed[0]["cnt"] != int64(2) {
+		t.Errorf("unexpected east aggregate: %v", streamed[0])
+	}
+	if streamed[2]["sum_total"] != 10.0 || streamed[2]["cnt"] != int64(3) {
+		t.Errorf("unexpected west aggregate: %v", streamed[2])
+	}
+}
+
+// TestAggregation_StreamingBoundedMemory tests that sorted input is not buffered.
+// Green-Flag: Streaming aggregation MUST emit a group as soon as its key changes.
+func TestAggregation_StreamingBoundedMemory(t *testing.T) {
+	source := &countingStream{total: 100000, groups: 1000}
+	aggs := []*federation.Aggregation{{Function: "SUM", Column: "amount", Alias: "amount"}}
+
+	stream := federation.NewAggregationStream(
+		federation.NewOrderedStream(source, []string{"region"}), aggs, []string{"region"})
+
+	row, err := stream.Next(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row["region"] != 0 || row["amount"] != int64(100) {
+		t.Errorf("unexpected first group: %v", row)
+	}
+
+	// Only the first group plus one look-ahead row may have been read.
+	if source.consumed != 101 {
+		t.Errorf("expected 101 rows consumed before first group, got %d", source.consumed)
+	}
+
+	groups := 1
+	for {
+		row, err := stream.Next(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if row == nil {
+			break
+		}
+		groups++
+	}
+	if groups != 1000 {
+		t.Errorf("expected 1000 groups, got %d", groups)
+	}
+}
+
+// TestAggregation_SchemaReflectsAliases tests the aggregated result schema.
+// Green-Flag: Aggregated results MUST describe the GROUP BY keys and
+// aggregate aliases, with NULLs counted only by COUNT(*).
+func TestAggregation_SchemaReflectsAliases(t *testing.T) {
+	rows := []federation.Row{
+		{"region": "east", "total": 10, "note": "a"},
+		{"region": "east", "total": nil, "note": "b"},
+		{"region": "east", "total": 30, "note": "c"},
+	}
+	schema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "region", Type: "VARCHAR"},
+		{Name: "total", Type: "INTEGER"},
+		{Name: "note", Type: "VARCHAR"},
+	}}
+	aggs := []*federation.Aggregation{
+		{Function: "SUM", Column: "o.total", Alias: "sum_total"},
+		{Function: "COUNT", Column: "*", Alias: "cnt"},
+		{Function: "COUNT", Column: "total", Alias: "cnt_total"},
+		{Function: "AVG", Column: "total", Alias: "avg_total"},
+		{Function: "MIN", Column: "total"},
+	}
+	stream := federation.NewAggregationStream(newMockResultStream(rows, schema), aggs, []string{"o.region"})
+
+	want := []federation.ColumnDef{
+		{Name: "region", Type: "VARCHAR"},
+		{Name: "sum_total", Type: "BIGINT"},
+		{Name: "cnt", Type: "BIGINT"},
+		{Name: "cnt_total", Type: "BIGINT"},
+		{Name: "avg_total", Type: "DOUBLE"},
+		{Name: "MIN(total)", Type: "INTEGER"},
+	}
+	if got := stream.Schema().Columns; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected schema %v, got %v", want, got)
+	}
+
+	results, err := federation.CollectStream(context.Background(), stream)
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected one group, got %v", results)
+	}
+	got := results[0]
+	if got["sum_total"] != int64(40) || got["cnt"] != int64(3) || got["cnt_total"] != int64(2) ||
+		got["avg_total"] != 20.0 || got["MIN(total)"] != 10 {
+		t.Errorf("unexpected aggregate: %v", got)
+	}
+}
+
+// partialAggregatingAdapter emulates an engine that computes the partial
+// aggregates of the sub-queries it receives with a GROUP BY clause.
+type partialAggregatingAdapter struct {
+	successAdapter
+	partialRows []federation.Row
+	queries     []string
+}
+
+func (p *partialAggregatingAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	p.queries = append(p.queries, query)
+	if strings.Contains(query, " GROUP BY ") {
+		return newMockResultStream(p.partialRows, p.schema), nil
+	}
+	return newMockResultStream(p.rows, p.schema), nil
+}
+
+// TestFederatedExecutor_CombinesPartialAggregates tests partial aggregation
+// pushdown.
+// Green-Flag: Aggregations of one table MUST be computed partially in its
+// sub-query, grouped by its join key, and combined after the join into the
+// same results as aggregating the joined rows: sums of sums, sums of counts,
+// and averages from the partial sums and counts.
+func TestFederatedExecutor_CombinesPartialAggregates(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	orders := &partialAggregatingAdapter{
+		successAdapter: successAdapter{
+			name: "trino",
+			rows: []federation.Row{
+				{"customer_id": 1, "total": 10},
+				{"customer_id": 1, "total": 20},
+				{"customer_id": 2, "total": 5},
+				{"customer_id": 3, "total": 7},
+				{"customer_id": 3, "total": nil},
+			},
+			schema: &federation.ResultSchema{},
+		},
+		partialRows: []federation.Row{
+			{"customer_id": 1, "canonic_partial_0": int64(30), "canonic_partial_1_count": int64(2), "canonic_partial_2": int64(30), "canonic_partial_2_count": int64(2)},
+			{"customer_id": 2, "canonic_partial_0": int64(5), "canonic_partial_1_count": int64(1), "canonic_partial_2": int64(5), "canonic_partial_2_count": int64(1)},
+			{"customer_id": 3, "canonic_partial_0": int64(7), "canonic_partial_1_count": int64(2), "canonic_partial_2": int64(7), "canonic_partial_2_count": int64(1)},
+		},
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 1, "region": "eu"},
+			{"id": 2, "region": "us"},
+			{"id": 3, "region": "eu"},
+		},
+		schema: &federation.ResultSchema{},
+	})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	query := `SELECT c.region, SUM(o.total) AS revenue, COUNT(*) AS orders, AVG(o.total) AS average
+		FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id
+		GROUP BY c.region`
+	run := func(ctx context.Context) string {
+		result, err := executor.Execute(ctx, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer result.Close()
+		rows, err := federation.CollectStream(ctx, result)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var groups []string
+		for _, row := range rows {
+			groups = append(groups, fmt.Sprintf("%v:%v/%v/%v", row["region"], row["revenue"], row["orders"], row["average"]))
+		}
+		sort.Strings(groups)
+		return fmt.Sprint(groups)
+	}
+
+	partial := run(context.Background())
+	want := "SELECT o.customer_id, SUM(o.total) AS canonic_partial_0, COUNT(*) AS canonic_partial_1_count, " +
+		"SUM(o.total) AS canonic_partial_2, COUNT(o.total) AS canonic_partial_2_count " +
+		"FROM sales.orders AS o GROUP BY o.customer_id"
+	if orders.queries[0] != want {
+		t.Errorf("expected the orders sub-query to compute partial aggregates:\n got: %s\nwant: %s", orders.queries[0], want)
+	}
+	if partial != "[eu:37/4/12.333333333333334 us:5/1/5]" {
+		t.Errorf("unexpected combined aggregates: %s", partial)
+	}
+
+	whole := run(federation.ContextWithPushdownMode(context.Background(), federation.PushdownDisabled))
+	if strings.Contains(orders.queries[1], "GROUP BY") {
+		t.Fatalf("expected no aggregation pushdown with no_pushdown, got: %s", orders.queries[1])
+	}
+	if whole != partial {
+		t.Errorf("results differ: partial %s, joined rows %s", partial, whole)
+	}
+}
+
+// TestFederatedExecutor_GroupsWithoutAggregates tests a cross-engine GROUP
+// BY without aggregates.
+// Green-Flag: A GROUP BY MUST group the joined rows even when the query has
+// no aggregates, with or without pushdown.
+func TestFederatedExecutor_GroupsWithoutAggregates(t *testing.T) {
+	executor := newOrdersCustomersJoinExecutor()
+	query := `SELECT c.region FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region`
+	for _, mode := range []federation.PushdownMode{federation.PushdownDefault, federation.PushdownDisabled} {
+		ctx := federation.ContextWithPushdownMode(context.Background(), mode)
+		result, err := executor.Execute(ctx, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows, err := federation.CollectStream(ctx, result)
+		result.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var regions []string
+		for _, row := range rows {
+			regions = append(regions, fmt.Sprint(row["region"]))
+		}
+		sort.Strings(regions)
+		if fmt.Sprint(regions) != "[eu us]" {
+			t.Errorf("pushdown %v: expected one row per region, got %v", mode, rows)
+		}
+	}
+}
+
+// newOrdersCustomersJoinExecutor creates an executor over three orders on
+// Trino joined to customers in two regions on Spark.
+func newOrdersCustomersJoinExecutor() *federation.FederatedExecutor {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"customer_id": 1, "total": 10, "qty": 2},
+			{"customer_id": 2, "total": 10, "qty": 1},
+			{"customer_id": 3, "total": 20, "qty": 3},
+		},
+		schema: &federation.ResultSchema{},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 1, "region": "eu"},
+			{"id": 2, "region": "us"},
+			{"id": 3, "region": "eu"},
+		},
+		schema: &federation.ResultSchema{},
+	})
+	return federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+}
+
+// likeTestQuery returns a single-engine decomposed query and analysis with a
+// LIKE predicate on t1.
+func likeTestQuery(engine string) (*federation.DecomposedQuery, *federation.QueryAnalysis) {
+	decomposed := &federation.DecomposedQuery{
+		OriginalSQL: "SELECT * FROM t1 WHERE t1.name LIKE 'a%'",
+		SubQueries: []*federation.SubQuery{{
+			ID:     "sq_0_" + engine,
+			Engine: engine,
+			SQL:    "SELECT * FROM t1",
+			Tables: []*federation.TableRef{{Name: "t1", Engine: engine}},
+		}},
+	}
+	analysis := &federation.QueryAnalysis{
+		OriginalSQL: decomposed.OriginalSQL,
+		PushablePredicates: map[string][]*federation.Predicate{
+			"t1": {{Table: "t1", Column: "name", Operator: "LIKE", Value: "'a%'", Raw: "t1.name LIKE 'a%'"}},
+		},
+	}
+	return decomposed, analysis
+}
+
+// TestPushdownOptimizer_LikePushedWhenAllowed tests the per-engine allowlist.
+// Green-Flag: A LIKE predicate MUST be pushed to an engine whose allowlist includes LIKE.
+func TestPushdownOptimizer_LikePushedWhenAllowed(t *testing.T) {
+	decomposed, analysis := likeTestQuery("duckdb")
+
+	optimizer := federation.NewPushdownOptimizer()
+	optimizer.SetPushdownOperators("duckdb", append(federation.DefaultPushdownOperators, "LIKE"))
+
+	optimized, err := optimizer.Optimize(decomposed, analysis)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sq := optimized.SubQueries[0]
+	if len(sq.Predicates) != 1 || len(sq.PostFilters) != 0 {
+		t.Fatalf("expected LIKE to be pushed, got predicates=%d post-filters=%d", len(sq.Predicates), len(sq.PostFilters))
+	}
+	if sq.SQL != "SELECT * FROM t1 WHERE t1.name LIKE 'a%'" {
+		t.Errorf("expected LIKE in sub-query SQL, got: %s", sq.SQL)
+	}
+}
+
+// TestPredicateFilterStream_AppliesPostFilters tests local predicate evaluation.
+// Green-Flag: Post-filters MUST keep exactly the rows matching the predicates.
+func TestPredicateFilterStream_AppliesPostFilters(t *testing.T) {
+	source := federation.NewSliceStream([]federation.Row{
+		{"name": "alice", "region": "eu"},
+		{"name": "bob", "region": "eu"},
+		{"name": "anna", "region": "us"},
+		{"name": nil, "region": "eu"},
+		{"name": "amir", "region": "apac"},
+	}, &federation.ResultSchema{})
+
+	stream := federation.NewPredicateFilterStream(source, []*federation.Predicate{
+		{Column: "name", Operator: "LIKE", Value: "'a%'", Raw: "name LIKE 'a%'"},
+		{Column: "region", Operator: "IN", Value: "('eu', 'us')", Raw: "region IN ('eu', 'us')"},
+	})
+
+	var names []string
+	for {
+		row, err := stream.Next(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if row == nil {
+			break
+		}
+		names = append(names, row["name"].(string))
+	}
+	if fmt.Sprint(names) != "[alice anna]" {
+		t.Errorf("expected [alice anna], got %v", names)
+	}
+}
+
+// TestPredicateFilterStream_EvaluatesInListsAndRanges tests local
+// evaluation of IN-list and BETWEEN predicates.
+// Green-Flag: An IN list MUST keep rows equal to one of its values, and a
+// BETWEEN range MUST keep rows within its inclusive bounds.
+func TestPredicateFilterStream_EvaluatesInListsAndRanges(t *testing.T) {
+	source := federation.NewSliceStream([]federation.Row{
+		{"name": "alice", "total": int64(10)},
+		{"name": "o'brien", "total": int64(15)},
+		{"name": "bob", "total": int64(20)},
+		{"name": "anna", "total": int64(25)},
+	}, &federation.ResultSchema{})
+
+	stream := federation.NewPredicateFilterStream(source, []*federation.Predicate{
+		{Column: "name", Operator: "IN", Values: []interface{}{"'alice'", "'o''brien'", "'anna'"},
+			Raw: "name IN ('alice', 'o''brien', 'anna')"},
+		{Column: "total", Operator: "BETWEEN", Values: []interface{}{"10", "20"}, Raw: "total BETWEEN 10 AND 20"},
+	})
+
+	var names []string
+	for {
+		row, err := stream.Next(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if row == nil {
+			break
+		}
+		names = append(names, row["name"].(string))
+	}
+	if fmt.Sprint(names) != "[alice o'brien]" {
+		t.Errorf("expected [alice o'brien], got %v", names)
+	}
+}
+
+// TestFederatedExecutor_QueryAtEngineCapSucceeds tests the engine cap boundary.
+// Green-Flag: A query spanning exactly the maximum number of engines MUST be planned.
+func TestFederatedExecutor_QueryAtEngineCapSucceeds(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+	executor.SetMaxEngines(2)
+
+	plan, err := executor.Plan(context.Background(),
+		"SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.SubQueryPlans) != 2 {
+		t.Fatalf("expected 2 sub-query plans, got %d", len(plan.SubQueryPlans))
+	}
+}
+
+// TestFederatedExecutor_EqualEstimatesOrderDeterministically tests that
+// execution order does not depend on map iteration order.
+// Green-Flag: Sub-queries with equal row estimates MUST be planned in the same
+// order, with the same IDs, on every run.
+func TestFederatedExecutor_EqualEstimatesOrderDeterministically(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+		"sales.regions":   "duckdb",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	// No adapters are registered, so every sub-query gets the default estimate
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+	query := "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id JOIN sales.regions r ON c.region_id = r.id"
+
+	var want string
+	for run := 0; run < 20; run++ {
+		plan, err := executor.Plan(context.Background(), query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ordered []string
+		for _, idx := range plan.ExecutionOrder {
+			ordered = append(ordered, plan.SubQueryPlans[idx].SubQuery.ID)
+		}
+		got := strings.Join(ordered, ",")
+		if run == 0 {
+			want = got
+			continue
+		}
+		if got != want {
+			t.Fatalf("run %d: execution order %s differs from first run %s", run, got, want)
+		}
+	}
+	if want != "sq_0_duckdb,sq_1_spark,sq_2_trino" {
+		t.Errorf("expected equal estimates to keep sub-query ID order, got %s", want)
+	}
+}
+
+// TestFederatedExecutor_EstimateTwoEngineQuery tests the pre-execution cost
+// estimate.
+// Green-Flag: The estimate for a two-engine query MUST report both engines
+// and populated row, byte and time estimates, using engine statistics where
+// an adapter provides them.
+func TestFederatedExecutor_EstimateTwoEngineQuery(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatIceberg,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	// Trino reports 3 rows for its table; Spark has no adapter, so the
+	// estimator falls back to its default row count
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{name: "trino", rows: make([]federation.Row, 3)})
+
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	query := "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+	estimate, err := executor.Estimate(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	if fmt.Sprint(estimate.Engines) != "[spark trino]" {
+		t.Errorf("expected engines [spark trino], got %v", estimate.Engines)
+	}
+	if len(estimate.SubQueries) != 2 {
+		t.Fatalf("expected 2 sub-query costs, got %d", len(estimate.SubQueries))
+	}
+	rowsByEngine := map[string]int64{}
+	for _, cost := range estimate.SubQueries {
+		rowsByEngine[cost.Engine] = cost.EstimatedRows
+		if cost.EstimatedTime <= 0 {
+			t.Errorf("%s: expected a positive time estimate, got %v", cost.Engine, cost.EstimatedTime)
+		}
+	}
+	if rowsByEngine["trino"] != 3 {
+		t.Errorf("expected trino estimate from adapter stats (3 rows), got %d", rowsByEngine["trino"])
+	}
+	if rowsByEngine["spark"] <= 3 {
+		t.Errorf("expected spark to use the default row estimate, got %d", rowsByEngine["spark"])
+	}
+	if estimate.EstimatedRows != rowsByEngine["trino"]+rowsByEngine["spark"] {
+		t.Errorf("expected total rows to sum sub-queries, got %d", estimate.EstimatedRows)
+	}
+	if estimate.EstimatedBytes != estimate.EstimatedRows*federation.DefaultEstimatedRowBytes {
+		t.Errorf("unexpected byte estimate %d for %d rows", estimate.EstimatedBytes, estimate.EstimatedRows)
+	}
+	if estimate.EstimatedTime <= 0 {
+		t.Errorf("expected a positive total time estimate, got %v", estimate.EstimatedTime)
+	}
+
+	resp := estimate.Response(query)
+	if resp.SQL != query || resp.EstimatedRows != estimate.EstimatedRows || len(resp.SubQueries) != 2 || resp.EstimatedTime == "" {
+		t.Errorf("expected the response to carry the estimate, got %+v", resp)
+	}
+}
+
+// whereFilteringAdapter emulates an engine: it applies filter to its rows
+// only when the sub-query it receives has a WHERE clause, and records the SQL.
+type whereFilteringAdapter struct {
+	successAdapter
+	filter  func(federation.Row) bool
+	queries []string
+}
+
+func (w *whereFilteringAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	w.queries = append(w.queries, query)
+	rows := w.rows
+	if strings.Contains(query, " WHERE ") {
+		rows = nil
+		for _, row := range w.rows {
+			if w.filter(row) {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return newMockResultStream(rows, w.schema), nil
+}
+
+// TestFederatedExecutor_NoPushdownMatchesPushdown tests the no_pushdown mode.
+// Green-Flag: With no_pushdown, sub-queries MUST carry no predicates and the
+// result MUST match the result with pushdown.
+func TestFederatedExecutor_NoPushdownMatchesPushdown(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	orders := &whereFilteringAdapter{
+		successAdapter: successAdapter{
+			name: "trino",
+			rows: []federation.Row{
+				{"customer_id": 10, "total": 100},
+				{"customer_id": 20, "total": 200},
+				{"customer_id": 10, "total": 300},
+			},
+			schema: &federation.ResultSchema{},
+		},
+		filter: func(row federation.Row) bool { return row["total"].(int) > 150 },
+	}
+	customers := &whereFilteringAdapter{
+		successAdapter: successAdapter{
+			name: "spark",
+			rows: []federation.Row{
+				{"id": 10, "name": "Alice"},
+				{"id": 20, "name": "Bob"},
+			},
+			schema: &federation.ResultSchema{},
+		},
+		filter: func(federation.Row) bool { return true },
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(customers)
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	query := "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE o.total > 150"
+	run := func(ctx context.Context) []string {
+		result, err := executor.Execute(ctx, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer result.Close()
+		var rows []string
+		for {
+			row, err := result.Next(ctx)
+			if err != nil {
+				t.Fatalf("error iterating results: %v", err)
+			}
+			if row == nil {
+				break
+			}
+			rows = append(rows, fmt.Sprintf("%v/%v", row["total"], row["name"]))
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	pushed := run(context.Background())
+	if !strings.Contains(orders.queries[0], "WHERE o.total > 150") {
+		t.Fatalf("expected predicate to be pushed by default, got: %s", orders.queries[0])
+	}
+
+	ctx := federation.ContextWithPushdownMode(context.Background(), federation.PushdownDisabled)
+	local := run(ctx)
+	if strings.Contains(orders.queries[1], "WHERE") {
+		t.Errorf("expected no predicate with no_pushdown, got: %s", orders.queries[1])
+	}
+
+	if fmt.Sprint(pushed) != fmt.Sprint(local) {
+		t.Errorf("results differ: pushdown %v, no_pushdown %v", pushed, local)
+	}
+	if fmt.Sprint(local) != "[200/Bob 300/Alice]" {
+		t.Errorf("expected [200/Bob 300/Alice], got %v", local)
+	}
+
+	explain, err := executor.Explain(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected explain error: %v", err)
+	}
+	if !strings.Contains(explain, "Pushdown: disabled (no_pushdown)") || !strings.Contains(explain, "Post-Filter: o.total > 150") {
+		t.Errorf("expected EXPLAIN to show the pushdown mode and post-filter, got:\n%s", explain)
+	}
+}
+
+// TestFederatedExecutor_BroadcastsSmallInputs tests broadcast joins.
+// Green-Flag: A join input estimated below the broadcast threshold MUST run
+// first and filter the other input's sub-query on its distinct, non-NULL
+// join keys, without changing the joined rows.
+func TestFederatedExecutor_BroadcastsSmallInputs(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	orders := &whereFilteringAdapter{
+		successAdapter: successAdapter{
+			name: "trino",
+			rows: []federation.Row{
+				{"order_id": 1, "customer_id": 10},
+				{"order_id": 2, "customer_id": 20},
+				{"order_id": 3, "customer_id": 10},
+				{"order_id": 4, "customer_id": 30},
+				{"order_id": 5, "customer_id": 40},
+			},
+			schema: &federation.ResultSchema{},
+		},
+		filter: func(row federation.Row) bool { return row["customer_id"] != 30 && row["customer_id"] != 40 },
+	}
+	customers := &whereFilteringAdapter{
+		successAdapter: successAdapter{
+			name: "spark",
+			rows: []federation.Row{
+				{"id": 10, "name": "Alice"},
+				{"id": 20, "name": "Bob"},
+				{"id": 10, "name": "Alice"},
+				{"id": nil, "name": "Nobody"},
+			},
+			schema: &federation.ResultSchema{},
+		},
+		filter: func(federation.Row) bool { return true },
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(customers)
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	query := "SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+	ctx := context.Background()
+	plan, err := executor.Plan(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected plan error: %v", err)
+	}
+	step := plan.JoinPlan.Steps[0]
+	if step.Strategy != federation.JoinStrategyBroadcast || step.BroadcastInput != step.RightInput {
+		t.Fatalf("expected the customers input to be broadcast, got %+v", step)
+	}
+	explain, err := executor.Explain(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected explain error: %v", err)
+	}
+	if !strings.Contains(explain, "(broadcast "+step.RightInput+")") {
+		t.Errorf("expected EXPLAIN to show the broadcast input, got:\n%s", explain)
+	}
+
+	result, err := executor.Execute(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(orders.queries) != 1 || !strings.HasSuffix(orders.queries[0], " WHERE o.customer_id IN (10, 20)") {
+		t.Errorf("expected the orders sub-query to be filtered on the customer ids, got %v", orders.queries)
+	}
+	var joined []string
+	for _, row := range rows {
+		joined = append(joined, fmt.Sprintf("%v/%v", row["order_id"], row["name"]))
+	}
+	sort.Strings(joined)
+	if fmt.Sprint(joined) != "[1/Alice 1/Alice 2/Bob 3/Alice 3/Alice]" {
+		t.Errorf("unexpected joined rows: %v", joined)
+	}
+}
+
+// TestPushdownOptimizer_ForcePushdownIgnoresAllowlist tests force_pushdown.
+// Green-Flag: With force_pushdown, a predicate outside the allowlist MUST be pushed.
+func TestPushdownOptimizer_ForcePushdownIgnoresAllowlist(t *testing.T) {
+	decomposed, analysis := likeTestQuery("trino")
+
+	optimizer := federation.NewPushdownOptimizer()
+	optimized, err := optimizer.OptimizeWithMode(decomposed, analysis, federation.PushdownForced)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sq := optimized.SubQueries[0]
+	if len(sq.Predicates) != 1 || len(sq.PostFilters) != 0 {
+		t.Fatalf("expected LIKE to be pushed, got predicates=%d post-filters=%d", len(sq.Predicates), len(sq.PostFilters))
+	}
+	if sq.SQL != "SELECT * FROM t1 WHERE t1.name LIKE 'a%'" {
+		t.Errorf("expected LIKE in sub-query SQL, got: %s", sq.SQL)
+	}
+}
+
+// TestMemoryResultStore_BudgetedRows tests materialization under a memory
+// budget.
+// Green-Flag: A store within a generous budget MUST keep rows in memory; a
+// store over a tiny budget MUST spill to disk and return the same rows,
+// releasing its memory reservation.
+func TestMemoryResultStore_BudgetedRows(t *testing.T) {
+	schema := &federation.ResultSchema{
+		Columns: []federation.ColumnDef{
+			{Name: "id", Type: "int"},
+			{Name: "name", Type: "string"},
+			{Name: "created", Type: "timestamp"},
+		},
+	}
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []federation.Row{
+		{"id": 1, "name": "Alice", "created": created},
+		{"id": 2, "name": "Bob", "created": nil},
+		{"id": 3, "name": "Carol", "created": created},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		limit       int64
+		wantSpilled bool
+	}{
+		{name: "generous", limit: 1 << 20, wantSpilled: false},
+		{name: "tiny", limit: 128, wantSpilled: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			budget := federation.NewMemoryBudget(tc.limit)
+			store := federation.NewMemoryResultStore(schema)
+			store.SetBudget(budget, true)
+			defer store.Close()
+
+			for _, row := range rows {
+				if err := store.Append(row); err != nil {
+					t.Fatalf("append failed: %v", err)
+				}
+			}
+			if store.Spilled() != tc.wantSpilled {
+				t.Fatalf("expected spilled=%v, got %v", tc.wantSpilled, store.Spilled())
+			}
+			if tc.wantSpilled && budget.Used() != 0 {
+				t.Errorf("expected spilled rows to release their reservation, %d bytes still used", budget.Used())
+			}
+			if !tc.wantSpilled && budget.Used() == 0 {
+				t.Error("expected in-memory rows to be charged to the budget")
+			}
+			if store.Size() != len(rows) {
+				t.Errorf("expected size %d, got %d", len(rows), store.Size())
+			}
+
+			stream := store.Stream()
+			defer stream.Close()
+			for i, want := range rows {
+				got, err := stream.Next(context.Background())
+				if err != nil {
+					t.Fatalf("next failed: %v", err)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("row %d: expected %v, got %v", i, want, got)
+				}
+			}
+			if extra, _ := stream.Next(context.Background()); extra != nil {
+				t.Errorf("expected %d rows, got extra row %v", len(rows), extra)
+			}
+		})
+	}
+}
+
+// TestFederatedExecutor_GenerousMemoryBudget tests a join within the
+// per-query memory budget.
+// Green-Flag: A cross-engine join within its memory budget MUST complete and
+// return the joined rows.
+func TestFederatedExecutor_GenerousMemoryBudget(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatIceberg,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"customer_id": 10, "total": 100.0},
+			{"customer_id": 20, "total": 200.0},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "customer_id"}, {Name: "total"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 10, "name": "Alice"},
+			{"id": 20, "name": "Bob"},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}},
+	})
+
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetMaxQueryMemory(64 << 20)
+	ctx := federation.ContextWithMemoryLimit(context.Background(), 1<<20)
+
+	result, err := executor.Execute(ctx, "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 joined rows, got %d: %v", len(rows), rows)
+	}
+}
+
+// TestAnalyzer_RenamesDuplicateColumns tests the rename duplicate column
+// policy.
+// Green-Flag: With the rename policy, repeated output column names MUST be
+// suffixed, keeping every column, and each rename MUST produce a warning.
+func TestAnalyzer_RenamesDuplicateColumns(t *testing.T) {
+	repo := storage.NewMockRepository()
+	_ = repo.Create(context.Background(), &tables.VirtualTable{
+		Name:         "sales.orders",
+		Sources:      []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/orders"}},
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+	})
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	analyzer.SetDuplicateColumnPolicy(federation.DuplicateColumnsRename)
+	analysis, err := analyzer.Analyze(context.Background(),
+		"SELECT o.id, o.total AS x, o.customer_id AS X, o.id FROM sales.orders o")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	if got := strings.Join(analysis.OutputColumns, ","); got != "id,x,X_1,id_1" {
+		t.Errorf("expected output columns id,x,X_1,id_1, got %s", got)
+	}
+	if len(analysis.Warnings) != 2 || !strings.Contains(analysis.Warnings[0], "X_1") {
+		t.Errorf("expected a warning per renamed column, got %v", analysis.Warnings)
+	}
+}
+
+// TestQueryResultStream_KeepsDuplicateColumns tests conversion of engine
+// results with repeated column names to rows.
+// Green-Flag: Every column of an engine result MUST survive conversion to a
+// row, with repeated names suffixed.
+func TestQueryResultStream_KeepsDuplicateColumns(t *testing.T) {
+	stream := federation.NewQueryResultStream(&adapters.QueryResult{
+		Columns: []string{"x", "x"},
+		Rows:    [][]interface{}{{1, 2}},
+	})
+
+	row, err := stream.Next(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row["x"] != 1 || row["x_1"] != 2 {
+		t.Errorf("expected x=1 and x_1=2, got %v", row)
+	}
+	if cols := stream.Schema().Columns; len(cols) != 2 || cols[1].Name != "x_1" {
+		t.Errorf("expected schema columns x, x_1, got %v", cols)
+	}
+}
+
+// collectJoinedRows executes a hash join and drains its result.
+func collectJoinedRows(t *testing.T, config federation.HashJoinConfig) []federation.Row {
+	t.Helper()
+	result, err := federation.NewHashJoinExecutor(config).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+
+	var joined []federation.Row
+	for {
+		row, err := result.Next(context.Background())
+		if err != nil {
+			t.Fatalf("error during iteration: %v", err)
+		}
+		if row == nil {
+			return joined
+		}
+		joined = append(joined, row)
+	}
+}
+
+// TestHashJoin_CoercesIntegerKeyWidths tests that an INTEGER key from one
+// engine matches an equal BIGINT key from another.
+// Green-Flag: Join keys of different integer widths MUST match by value.
+func TestHashJoin_CoercesIntegerKeyWidths(t *testing.T) {
+	build := newMockResultStream([]federation.Row{
+		{"id": int32(1), "name": "Alice"},
+		{"id": int32(2), "name": "Bob"},
+	}, &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "id", Type: "INTEGER"},
+		{Name: "name", Type: "VARCHAR"},
+	}})
+	probe := newMockResultStream([]federation.Row{
+		{"customer_id": int64(1), "total": 100.0},
+		{"customer_id": int64(2), "total": 200.0},
+		{"customer_id": int64(3), "total": 300.0},
+	}, &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "customer_id", Type: "BIGINT"},
+		{Name: "total", Type: "DOUBLE"},
+	}})
+
+	joined := collectJoinedRows(t, federation.HashJoinConfig{
+		BuildSide: build,
+		ProbeSide: probe,
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"customer_id"},
+		Type:      federation.JoinTypeInner,
+	})
+	if len(joined) != 2 {
+		t.Fatalf("expected 2 joined rows after coercion, got %d: %v", len(joined), joined)
+	}
+	for _, row := range joined {
+		if fmt.Sprint(row["id"]) != fmt.Sprint(row["customer_id"]) {
+			t.Errorf("joined mismatched keys: %v", row)
+		}
+	}
+}
+
+// TestHashJoin_StringNumberCoercion tests that the string_number policy
+// joins string keys to equal integer keys.
+// Green-Flag: String keys MUST match integer keys when the policy allows it.
+func TestHashJoin_StringNumberCoercion(t *testing.T) {
+	build := newMockResultStream([]federation.Row{
+		{"code": "42", "label": "answer"},
+		{"code": "x1", "label": "not a number"},
+	}, &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "code", Type: "VARCHAR"},
+		{Name: "label", Type: "VARCHAR"},
+	}})
+	probe := newMockResultStream([]federation.Row{
+		{"id": int64(42)},
+	}, &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "id", Type: "BIGINT"},
+	}})
+
+	joined := collectJoinedRows(t, federation.HashJoinConfig{
+		BuildSide:   build,
+		ProbeSide:   probe,
+		BuildKeys:   []string{"code"},
+		ProbeKeys:   []string{"id"},
+		Type:        federation.JoinTypeInner,
+		KeyCoercion: federation.JoinKeyCoercionStringNumber,
+	})
+	if len(joined) != 1 || joined[0]["label"] != "answer" {
+		t.Fatalf("expected the '42' row to match id 42, got %v", joined)
+	}
+}
+
+// TestHashJoin_CompoundKeys tests a join on two key columns where the ids
+// repeat across tenants, so matching on the id alone would pair rows of
+// different tenants.
+// Green-Flag: A compound join key MUST match only rows equal on every key
+// column, both in memory and after spilling.
+func TestHashJoin_CompoundKeys(t *testing.T) {
+	buildSchema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "tenant", Type: "VARCHAR"},
+		{Name: "id", Type: "INTEGER"},
+		{Name: "name", Type: "VARCHAR"},
+	}}
+	probeSchema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "tenant_id", Type: "VARCHAR"},
+		{Name: "customer_id", Type: "BIGINT"},
+		{Name: "total", Type: "DOUBLE"},
+	}}
+
+	for _, threshold := range []int{0, 1} {
+		joined := collectJoinedRows(t, federation.HashJoinConfig{
+			BuildSide: newMockResultStream([]federation.Row{
+				{"tenant": "acme", "id": int32(1), "name": "Alice"},
+				{"tenant": "globex", "id": int32(1), "name": "Bob"},
+				{"tenant": "globex", "id": int32(2), "name": "Carol"},
+			}, buildSchema),
+			ProbeSide: newMockResultStream([]federation.Row{
+				{"tenant_id": "globex", "customer_id": int64(1), "total": 100.0},
+				{"tenant_id": "acme", "customer_id": int64(2), "total": 200.0},
+				{"tenant_id": nil, "customer_id": int64(1), "total": 300.0},
+			}, probeSchema),
+			BuildKeys:      []string{"tenant", "id"},
+			ProbeKeys:      []string{"tenant_id", "customer_id"},
+			Type:           federation.JoinTypeInner,
+			AllowSpill:     threshold > 0,
+			SpillThreshold: threshold,
+			SpillDir:       t.TempDir(),
+		})
+		if len(joined) != 1 {
+			t.Fatalf("threshold %d: expected 1 joined row, got %d: %v", threshold, len(joined), joined)
+		}
+		if joined[0]["name"] != "Bob" || joined[0]["total"] != 100.0 {
+			t.Errorf("threshold %d: joined the wrong rows: %v", threshold, joined[0])
+		}
+	}
+}
+
+// TestHashJoin_SpillsBuildSide tests the grace hash join used when the
+// build side exceeds the spill threshold.
+// Green-Flag: A hash join past its spill threshold MUST partition both
+// sides to the spill directory, return the same rows as an in-memory join,
+// and delete its spill files when closed.
+func TestHashJoin_SpillsBuildSide(t *testing.T) {
+	buildRows := make([]federation.Row, 20)
+	for i := range buildRows {
+		buildRows[i] = federation.Row{"id": i, "name": fmt.Sprintf("customer %d", i)}
+	}
+	probeRows := make([]federation.Row, 30)
+	for i := range probeRows {
+		probeRows[i] = federation.Row{"customer_id": i % 25, "total": float64(i)}
+	}
+	buildSchema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "id", Type: "INTEGER"},
+		{Name: "name", Type: "VARCHAR"},
+	}}
+	probeSchema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "customer_id", Type: "INTEGER"},
+		{Name: "total", Type: "DOUBLE"},
+	}}
+	spillDir := t.TempDir()
+
+	stream, err := federation.NewHashJoinExecutor(federation.HashJoinConfig{
+		BuildSide:      newMockResultStream(buildRows, buildSchema),
+		ProbeSide:      newMockResultStream(probeRows, probeSchema),
+		BuildKeys:      []string{"id"},
+		ProbeKeys:      []string{"customer_id"},
+		Type:           federation.JoinTypeLeft,
+		AllowSpill:     true,
+		SpillThreshold: 3,
+		SpillDir:       spillDir,
+	}).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(spillDir); len(entries) != 1 {
+		t.Fatalf("expected the join to spill into %s, found %d entries", spillDir, len(entries))
+	}
+
+	matched := 0
+	for i := 0; ; i++ {
+		row, err := stream.Next(context.Background())
+		if err != nil {
+			t.Fatalf("error during iteration: %v", err)
+		}
+		if row == nil {
+			if i != len(probeRows) {
+				t.Errorf("expected %d joined rows, got %d", len(probeRows), i)
+			}
+			break
+		}
+		if row["name"] == nil {
+			continue
+		}
+		matched++
+		if want := fmt.Sprintf("customer %d", row["customer_id"]); row["name"] != want {
+			t.Errorf("joined mismatched keys: %v", row)
+		}
+	}
+	if matched != 25 {
+		t.Errorf("expected 25 matched rows, got %d", matched)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(spillDir); len(entries) != 0 {
+		t.Errorf("expected spill files to be deleted on close, found %d entries", len(entries))
+	}
+}
+
+// newFingerprintExecutor creates an executor over orders on Trino and
+// customers on the given engine.
+func newFingerprintExecutor(customersEngine string) *federation.FederatedExecutor {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": customersEngine,
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	return federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+}
+
+// TestPlanFingerprint_StableForEquivalentQueries tests plan fingerprints.
+// Green-Flag: Equivalent queries MUST have the same plan fingerprint on every
+// run, and unchanged golden fingerprints MUST report no drift.
+func TestPlanFingerprint_StableForEquivalentQueries(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	queries := map[string]string{
+		"join":             "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE o.total > 100",
+		"join_reformatted": "select o.id,   c.name\n  from sales.orders o\n  join sales.customers c on o.customer_id = c.id\n where o.total > 100",
+	}
+
+	golden, err := executor.PlanFingerprints(context.Background(), queries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(golden["join"]) != 64 {
+		t.Fatalf("expected a hex SHA-256 fingerprint, got %q", golden["join"])
+	}
+	if golden["join"] != golden["join_reformatted"] {
+		t.Errorf("equivalent queries have different fingerprints: %s and %s", golden["join"], golden["join_reformatted"])
+	}
+
+	for run := 0; run < 10; run++ {
+		current, err := newFingerprintExecutor("spark").PlanFingerprints(context.Background(), queries)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if drift := federation.ComparePlanFingerprints(golden, current); len(drift) != 0 {
+			t.Fatalf("run %d: unexpected plan drift: %+v", run, drift)
+		}
+	}
+}
+
+// TestCompareRows_NullOrdering tests NULLS FIRST / NULLS LAST ordering.
+// Green-Flag: NULLs MUST sort where the ORDER BY item places them, in either
+// direction, and last by default.
+func TestCompareRows_NullOrdering(t *testing.T) {
+	testCases := []struct {
+		name     string
+		clause   federation.OrderByClause
+		expected string
+	}{
+		{"asc_default", federation.OrderByClause{Column: "total"}, "[1 2 3 <nil> <nil>]"},
+		{"asc_nulls_first", federation.OrderByClause{Column: "total", NullsFirst: true}, "[<nil> <nil> 1 2 3]"},
+		{"desc_nulls_last", federation.OrderByClause{Column: "total", Descending: true}, "[3 2 1 <nil> <nil>]"},
+		{"desc_nulls_first", federation.OrderByClause{Column: "total", Descending: true, NullsFirst: true}, "[<nil> <nil> 3 2 1]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows := []federation.Row{
+				{"total": 2},
+				{"total": nil},
+				{"total": 3.0},
+				{},
+				{"total": int64(1)},
+			}
+			clause := tc.clause
+			orderBy := []*federation.OrderByClause{&clause}
+
+			var sortErr error
+			sort.SliceStable(rows, func(i, j int) bool {
+				cmp, err := federation.CompareRows(rows[i], rows[j], orderBy)
+				if err != nil {
+					sortErr = err
+				}
+				return cmp < 0
+			})
+			if sortErr != nil {
+				t.Fatalf("unexpected error: %v", sortErr)
+			}
+
+			var totals []interface{}
+			for _, row := range rows {
+				totals = append(totals, row["total"])
+			}
+			if got := fmt.Sprint(totals); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestAnalyzer_ParsesNullOrdering tests NULLS FIRST / NULLS LAST parsing.
+// Green-Flag: Explicit null ordering MUST be accepted and recorded on the
+// ORDER BY clauses of a cross-engine query.
+func TestAnalyzer_ParsesNullOrdering(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	plan, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id "+
+			"ORDER BY c.name NULLS FIRST, o.id DESC NULLS LAST")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orderBy := plan.Decomposed.PostJoinOps.OrderBy
+	if len(orderBy) != 2 {
+		t.Fatalf("expected 2 ORDER BY clauses, got %d", len(orderBy))
+	}
+	if orderBy[0].Column != "c.name" || orderBy[0].Descending || !orderBy[0].NullsFirst {
+		t.Errorf("expected c.name ASC NULLS FIRST, got %+v", *orderBy[0])
+	}
+	if orderBy[1].Column != "o.id" || !orderBy[1].Descending || orderBy[1].NullsFirst {
+		t.Errorf("expected o.id DESC NULLS LAST, got %+v", *orderBy[1])
+	}
+}
+
+// TestAnalyzer_ReadsOrderByFromTheParsedQuery tests ORDER BY items that a
+// scan of the query text would misread.
+// Green-Flag: Ordinals MUST resolve to the SELECT items they refer to, and an
+// ORDER BY clause spanning lines MUST be read in full.
+func TestAnalyzer_ReadsOrderByFromTheParsedQuery(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	plan, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name AS customer FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id\n"+
+			"ORDER BY\n  2 DESC,\n  1 NULLS FIRST\nLIMIT 5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orderBy := plan.Decomposed.PostJoinOps.OrderBy
+	if len(orderBy) != 2 {
+		t.Fatalf("expected 2 ORDER BY clauses, got %d", len(orderBy))
+	}
+	if orderBy[0].Column != "customer" || !orderBy[0].Descending || orderBy[0].NullsFirst {
+		t.Errorf("expected customer DESC, got %+v", *orderBy[0])
+	}
+	if orderBy[1].Column != "o.id" || orderBy[1].Descending || !orderBy[1].NullsFirst {
+		t.Errorf("expected o.id ASC NULLS FIRST, got %+v", *orderBy[1])
+	}
+}
+
+// TestAnalyzer_ReadsLimitAndOffset tests that the LIMIT and OFFSET of a
+// cross-engine query are taken from the logical plan.
+// Green-Flag: LIMIT n OFFSET m MUST be applied after the joins with both
+// its row count and its offset.
+func TestAnalyzer_ReadsLimitAndOffset(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	plan, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id "+
+			"ORDER BY o.id LIMIT 10 OFFSET 20")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ops := plan.Decomposed.PostJoinOps
+	if ops.Limit == nil || *ops.Limit != 10 {
+		t.Errorf("expected limit 10, got %v", ops.Limit)
+	}
+	if ops.Offset == nil || *ops.Offset != 20 {
+		t.Errorf("expected offset 20, got %v", ops.Offset)
+	}
+}
+
+// newSameEngineAnalyzer returns an analyzer over sales.orders and
+// crm.customers, both on trino, so joins between them are pushed down as a
+// single statement.
+func newSameEngineAnalyzer() *federation.Analyzer {
+	repo := storage.NewMockRepository()
+	for _, name := range []string{"sales.orders", "crm.customers"} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	return federation.NewAnalyzer(sql.NewParser(), repo)
+}
+
+// TestAnalyzer_ConditionedSameEngineJoinsPass tests that joins linked by a
+// join condition or a WHERE predicate are not flagged as cartesian products.
+// Green-Flag: Properly conditioned joins MUST pass the cartesian join check
+// without warnings, even under the reject policy.
+func TestAnalyzer_ConditionedSameEngineJoinsPass(t *testing.T) {
+	analyzer := newSameEngineAnalyzer()
+	analyzer.SetCartesianJoinPolicy(federation.CartesianJoinsReject)
+
+	queries := []string{
+		"SELECT o.id, c.name FROM sales.orders o JOIN crm.customers c ON o.customer_id = c.id",
+		"SELECT o.id, c.name FROM sales.orders o LEFT JOIN crm.customers c ON c.id = o.customer_id AND c.active = 1",
+		"SELECT o.id, c.name FROM sales.orders o, crm.customers c WHERE o.customer_id = c.id AND o.total > 10",
+		"SELECT id FROM sales.orders JOIN crm.customers USING (id)",
+		"SELECT o.id FROM sales.orders o WHERE o.customer_id IN (SELECT c.id FROM crm.customers c WHERE c.active = 1)",
+	}
+	for _, query := range queries {
+		analysis, err := analyzer.Analyze(context.Background(), query)
+		if err != nil {
+			t.Errorf("expected %q to pass, got %v", query, err)
+			continue
+		}
+		if analysis.IsCrossEngine {
+			t.Errorf("expected %q to be a single-engine query", query)
+		}
+		if len(analysis.Warnings) != 0 {
+			t.Errorf("expected no warnings for %q, got %v", query, analysis.Warnings)
+		}
+	}
+}
+
+// newTieredExecutor returns an executor joining sales.orders on trino with
+// sales.customers on spark, whose queries are expensive under limiter as the
+// engines' query overhead alone exceeds the expensive threshold. One
+// expensive query may run at a time, and others are rejected immediately.
+func newTieredExecutor(t *testing.T) (*federation.FederatedExecutor, *federation.CostTierLimiter) {
+	t.Helper()
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name:   "trino",
+		rows:   []federation.Row{{"id": 1, "customer_id": 10}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id", Type: "int"}, {Name: "customer_id", Type: "int"}}},
+	})
+	registry.Register(&successAdapter{
+		name:   "spark",
+		rows:   []federation.Row{{"id": 10, "name": "Alice"}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id", Type: "int"}, {Name: "name", Type: "string"}}},
+	})
+
+	limiter, err := federation.NewCostTierLimiter(federation.CostTierLimits{
+		MediumThreshold:    time.Millisecond,
+		ExpensiveThreshold: 50 * time.Millisecond,
+		MaxExpensive:       1,
+	})
+	if err != nil {
+		t.Fatalf("NewCostTierLimiter failed: %v", err)
+	}
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetCostTierLimiter(limiter)
+	return executor, limiter
+}
+
+// TestCostTierLimiter_LimitsExpensiveButNotCheap tests admission by cost tier.
+// Green-Flag: At most MaxExpensive expensive queries MUST run at once, the
+// rest queuing until a slot frees, while cheap queries are admitted freely.
+func TestCostTierLimiter_LimitsExpensiveButNotCheap(t *testing.T) {
+	limiter, err := federation.NewCostTierLimiter(federation.CostTierLimits{
+		MediumThreshold:    time.Second,
+		ExpensiveThreshold: 10 * time.Second,
+		MaxExpensive:       2,
+		QueueTimeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewCostTierLimiter failed: %v", err)
+	}
+
+	for estimate, want := range map[time.Duration]federation.CostTier{
+		100 * time.Millisecond: federation.CostTierCheap,
+		2 * time.Second:        federation.CostTierMedium,
+		time.Minute:            federation.CostTierExpensive,
+	} {
+		if got := limiter.Classify(estimate); got != want {
+			t.Errorf("Classify(%s) = %s, want %s", estimate, got, want)
+		}
+	}
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.Acquire(context.Background(), federation.CostTierExpensive)
+			if err != nil {
+				t.Errorf("expensive query should queue, not fail: %v", err)
+				return
+			}
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			release()
+		}()
+	}
+
+	// Cheap queries flow while expensive ones hold every slot
+	for i := 0; i < 100; i++ {
+		release, err := limiter.Acquire(context.Background(), federation.CostTierCheap)
+		if err != nil {
+			t.Fatalf("cheap query %d was limited: %v", i, err)
+		}
+		release()
+	}
+
+	wg.Wait()
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 concurrent expensive queries, saw %d", peak.Load())
+	}
+	if limiter.Running(federation.CostTierExpensive) != 0 {
+		t.Errorf("expected all expensive slots released, %d still held", limiter.Running(federation.CostTierExpensive))
+	}
+}
+
+// TestFederatedExecutor_CostTierSlotHeldUntilClose tests executor admission.
+// Green-Flag: An expensive query MUST hold its slot while its results are
+// read and release it when the stream is closed, admitting the next query.
+func TestFederatedExecutor_CostTierSlotHeldUntilClose(t *testing.T) {
+	executor, limiter := newTieredExecutor(t)
+
+	query := "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+	result, err := executor.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := limiter.Running(federation.CostTierExpensive); got != 1 {
+		t.Fatalf("expected the open query to hold 1 expensive slot, got %d", got)
+	}
+	if err := result.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := limiter.Running(federation.CostTierExpensive); got != 0 {
+		t.Fatalf("expected the slot released on Close, %d still held", got)
+	}
+
+	next, err := executor.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatalf("expected the next expensive query to be admitted: %v", err)
+	}
+	_ = next.Close()
+}
+
+// TestExplain_CategorizesPredicates tests the predicates section of EXPLAIN.
+// Green-Flag: EXPLAIN MUST report each predicate as pushed to its engine,
+// kept as a post-filter, or unsupported, which rejects the query.
+func TestExplain_CategorizesPredicates(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	query := "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id " +
+		"WHERE o.total > 100 AND c.name LIKE 'A%' AND UPPER(c.name) = 'BOB'"
+
+	plan, err := executor.Plan(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string]federation.PredicateExplanation)
+	for _, pred := range plan.Predicates() {
+		got[pred.Predicate] = pred
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 predicates, got %+v", plan.Predicates())
+	}
+	if pred := got["o.total > 100"]; pred.Disposition != federation.PredicatePushed || pred.Engine != "trino" {
+		t.Errorf("expected o.total > 100 pushed to trino, got %+v", pred)
+	}
+	if pred := got["c.name LIKE 'A%'"]; pred.Disposition != federation.PredicatePostFilter || pred.Engine != "spark" {
+		t.Errorf("expected LIKE kept as a post-filter on spark, got %+v", pred)
+	}
+	if pred := got["UPPER(c.name) = 'BOB'"]; pred.Disposition != federation.PredicateRejected {
+		t.Errorf("expected UPPER(c.name) = 'BOB' rejected, got %+v", pred)
+	}
+
+	explain, err := executor.Explain(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected explain error: %v", err)
+	}
+	for _, want := range []string{
+		"Predicates:",
+		"o.total > 100: pushed to trino",
+		"c.name LIKE 'A%': kept as post-filter on spark results (operator LIKE is not push-safe for spark)",
+		"UPPER(c.name) = 'BOB': unsupported, the query is rejected",
+	} {
+		if !strings.Contains(explain, want) {
+			t.Errorf("expected EXPLAIN to contain %q, got:\n%s", want, explain)
+		}
+	}
+}
+
+// TestFederatedExecutor_VersionPinsFlowIntoEngineSyntax tests per-table
+// FOR VERSION AS OF pins in federated queries.
+// Green-Flag: Each pinned table MUST be read at its version in the syntax of
+// its format and engine, and the join MUST still be planned.
+func TestFederatedExecutor_VersionPinsFlowIntoEngineSyntax(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, source := range map[string]tables.PhysicalSource{
+		"sales.orders":    {Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/orders"},
+		"sales.customers": {Engine: "spark", Format: tables.FormatDelta, Location: "s3://bucket/customers"},
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{source},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+
+	query := "SELECT o.id, c.name FROM sales.orders FOR VERSION AS OF 8765309 AS o " +
+		"JOIN sales.customers FOR VERSION AS OF 12 AS c ON o.customer_id = c.id WHERE o.total > 100"
+	plan, err := executor.Plan(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	engineSQL := plan.EngineSQL()
+	if !strings.Contains(engineSQL["sq_1_trino"], "FROM sales.orders FOR VERSION AS OF 8765309 AS o") {
+		t.Errorf("expected the Iceberg snapshot pin in Trino syntax, got: %s", engineSQL["sq_1_trino"])
+	}
+	if !strings.Contains(engineSQL["sq_1_trino"], "WHERE o.total > 100") {
+		t.Errorf("expected the predicate to still be pushed, got: %s", engineSQL["sq_1_trino"])
+	}
+	if !strings.Contains(engineSQL["sq_0_spark"], "FROM sales.customers VERSION AS OF 12 AS c") {
+		t.Errorf("expected the Delta version pin in Spark syntax, got: %s", engineSQL["sq_0_spark"])
+	}
+	if steps := plan.JoinPlan.Steps; len(steps) != 1 || steps[0].Type != federation.JoinTypeInner {
+		t.Errorf("expected one inner join step, got %+v", steps)
+	}
+}
+
+// newSortExecutor creates an executor over orders on Trino and customers on
+// Spark whose rows arrive in no particular order.
+func newSortExecutor() *federation.FederatedExecutor {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"order_id": 1, "customer_id": 20, "total": 250},
+			{"order_id": 2, "customer_id": 10, "total": 75.5},
+			{"order_id": 3, "customer_id": 30, "total": 120},
+			{"order_id": 4, "customer_id": 10, "total": 130},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "order_id"}, {Name: "customer_id"}, {Name: "total"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 10, "name": "Carol"},
+			{"id": 20, "name": "Alice"},
+			{"id": 30, "name": "Bob"},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}},
+	})
+	return federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+}
+
+// TestFederatedExecutor_QualifiesCollidingColumns tests the output columns
+// of a join whose inputs share a column name.
+// Green-Flag: A column present on both sides MUST be kept once per side,
+// qualified with its table alias, while other columns keep bare names and
+// ORDER BY MUST resolve the qualified name.
+func TestFederatedExecutor_QualifiesCollidingColumns(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"id": 2, "customer_id": 10},
+			{"id": 1, "customer_id": 20},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "customer_id"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 10, "name": "Carol"},
+			{"id": 20, "name": "Alice"},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}},
+	})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx,
+		"SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id ORDER BY o.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+
+	var columns []string
+	for _, col := range result.Schema().Columns {
+		columns = append(columns, col.Name)
+	}
+	sort.Strings(columns)
+	if want := "[c.id customer_id name o.id]"; fmt.Sprint(columns) != want {
+		t.Errorf("expected columns %s, got %v", want, columns)
+	}
+
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 joined rows, got %v", rows)
+	}
+	for i, want := range []federation.Row{
+		{"o.id": 1, "customer_id": 20, "c.id": 20, "name": "Alice"},
+		{"o.id": 2, "customer_id": 10, "c.id": 10, "name": "Carol"},
+	} {
+		if fmt.Sprint(rows[i]) != fmt.Sprint(want) {
+			t.Errorf("row %d: expected %v, got %v", i, want, rows[i])
+		}
+	}
+}
+
+// TestFederatedExecutor_PushesInListsAndRanges tests IN-list and BETWEEN
+// predicate pushdown.
+// Green-Flag: IN-list and BETWEEN predicates MUST be pushed into the WHERE
+// clause of the sub-query reading their table, with their string literals
+// quoted as written.
+func TestFederatedExecutor_PushesInListsAndRanges(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	plan, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id "+
+			"WHERE o.status IN ('new', 'o''brien') AND o.total BETWEEN 10 AND 20.5 AND c.region IN ('eu')")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	engineSQL := plan.EngineSQL()
+	if want := " WHERE o.status IN ('new', 'o''brien') AND o.total BETWEEN 10 AND 20.5"; !strings.HasSuffix(engineSQL["sq_1_trino"], want) {
+		t.Errorf("expected trino sub-query to end with %q, got: %s", want, engineSQL["sq_1_trino"])
+	}
+	if want := " WHERE c.region IN ('eu')"; !strings.HasSuffix(engineSQL["sq_0_spark"], want) {
+		t.Errorf("expected spark sub-query to end with %q, got: %s", want, engineSQL["sq_0_spark"])
+	}
+
+	predicates := plan.Predicates()
+	if len(predicates) != 3 {
+		t.Fatalf("expected 3 predicates, got %+v", predicates)
+	}
+	for _, pred := range predicates {
+		if pred.Disposition != federation.PredicatePushed {
+			t.Errorf("expected %s to be pushed, got %s", pred.Predicate, pred.Disposition)
+		}
+	}
+	for _, sqp := range plan.SubQueryPlans {
+		for _, pred := range sqp.SubQuery.Predicates {
+			switch pred.Operator {
+			case "IN":
+				if pred.Column == "status" && fmt.Sprint(pred.Values) != "['new' 'o''brien']" {
+					t.Errorf("expected IN values ['new' 'o''brien'], got %v", pred.Values)
+				}
+			case "BETWEEN":
+				if fmt.Sprint(pred.Values) != "[10 20.5]" {
+					t.Errorf("expected BETWEEN bounds [10 20.5], got %v", pred.Values)
+				}
+			}
+		}
+	}
+}
+
+// TestPushdownOptimizer_ProjectsRequiredColumns tests projection pushdown.
+// Green-Flag: Each sub-query MUST select only the columns the query reads
+// from its table, including join keys and filtered columns.
+func TestPushdownOptimizer_ProjectsRequiredColumns(t *testing.T) {
+	executor := newFingerprintExecutor("spark")
+	plan, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE o.total > 100")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	engineSQL := plan.EngineSQL()
+	if want := "SELECT o.id, o.customer_id, o.total FROM "; !strings.HasPrefix(engineSQL["sq_1_trino"], want) {
+		t.Errorf("expected trino sub-query to start with %q, got: %s", want, engineSQL["sq_1_trino"])
+	}
+	if want := "SELECT c.name, c.id FROM "; !strings.HasPrefix(engineSQL["sq_0_spark"], want) {
+		t.Errorf("expected spark sub-query to start with %q, got: %s", want, engineSQL["sq_0_spark"])
+	}
+}
+
+// TestFederatedExecutor_JoinsThreeEngines tests a join across three
+// engines.
+// Green-Flag: Each join step after the first MUST join the previous step's
+// result with one more engine's rows, and the final result MUST hold every
+// matching combination.
+func TestFederatedExecutor_JoinsThreeEngines(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, source := range map[string]tables.PhysicalSource{
+		"sales.orders":    {Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/orders"},
+		"sales.customers": {Engine: "spark", Format: tables.FormatDelta, Location: "s3://bucket/customers"},
+		"sales.payments":  {Engine: "duckdb", Format: tables.FormatParquet, Location: "s3://bucket/payments"},
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{source},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"order_id": 1, "customer_id": 10},
+			{"order_id": 2, "customer_id": 20},
+			{"order_id": 3, "customer_id": 10},
+			{"order_id": 4, "customer_id": 30},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "order_id"}, {Name: "customer_id"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 10, "name": "Alice"},
+			{"id": 20, "name": "Bob"},
+			{"id": 30, "name": "Carol"},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "duckdb",
+		rows: []federation.Row{
+			{"payment_id": 100, "order_ref": 1},
+			{"payment_id": 101, "order_ref": 1},
+			{"payment_id": 102, "order_ref": 2},
+			{"payment_id": 103, "order_ref": 4},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "payment_id"}, {Name: "order_ref"}}},
+	})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	// The second condition names the new table (payments) on its left
+	query := "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id " +
+		"JOIN sales.payments p ON p.order_ref = o.order_id"
+	ctx := context.Background()
+	plan, err := executor.Plan(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected plan error: %v", err)
+	}
+	steps := plan.JoinPlan.Steps
+	if len(steps) != 2 || steps[1].LeftInput != "step_0" {
+		t.Fatalf("expected two left-deep join steps, got %+v", steps)
+	}
+
+	result, err := executor.Execute(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Orders 1 (two payments), 2 and 4 have payments; order 3 has none
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 joined rows, got %d: %v", len(rows), rows)
+	}
+	for _, row := range rows {
+		if row["order_ref"] != row["order_id"] || row["id"] != row["customer_id"] || row["name"] == nil {
+			t.Errorf("joined mismatched rows: %v", row)
+		}
+	}
+}
+
+// TestFederatedExecutor_JoinsOnCompoundKeys tests a cross-engine join whose
+// ON clause compares two column pairs.
+// Green-Flag: Every comparison of a compound ON clause, written over several
+// lines and in parentheses, MUST be part of the join key, so only rows
+// matching on both columns are joined.
+func TestFederatedExecutor_JoinsOnCompoundKeys(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.shipments": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"order_id": 1, "region": "eu"},
+			{"order_id": 1, "region": "us"},
+			{"order_id": 2, "region": "eu"},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "order_id"}, {Name: "region"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"order_id": 1, "region": "us", "carrier": "ups"},
+			{"order_id": 2, "region": "us", "carrier": "dhl"},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "order_id"}, {Name: "region"}, {Name: "carrier"}}},
+	})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	query := `SELECT o.order_id, o.region, s.carrier
+		FROM sales.orders o
+		JOIN sales.shipments s
+		  ON (o.order_id = s.order_id
+		      AND s.region = o.region)`
+	ctx := context.Background()
+	plan, err := executor.Plan(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected plan error: %v", err)
+	}
+	steps := plan.JoinPlan.Steps
+	if len(steps) != 1 || len(steps[0].Keys) != 2 {
+		t.Fatalf("expected one join step with a compound key, got %+v", steps)
+	}
+
+	result, err := executor.Execute(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rows) != 1 || rows[0]["carrier"] != "ups" {
+		t.Errorf("expected only the order 1 us shipment to join, got %v", rows)
+	}
+}
+
+// TestFederatedExecutor_OrdersJoinedRows tests ORDER BY after a cross-engine
+// join.
+// Green-Flag: Cross-engine results MUST be sorted by the ORDER BY clauses,
+// ascending or descending, for numbers and strings.
+func TestFederatedExecutor_OrdersJoinedRows(t *testing.T) {
+	const join = "SELECT o.order_id, o.total, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id "
+	testCases := []struct {
+		name    string
+		orderBy string
+		want    []int
+	}{
+		{"numbers", "ORDER BY o.total", []int{2, 3, 4, 1}},
+		{"strings", "ORDER BY c.name", []int{1, 3, 2, 4}},
+		{"descending", "ORDER BY o.total DESC", []int{1, 4, 3, 2}},
+		{"multiple keys", "ORDER BY c.name DESC, o.total", []int{2, 4, 3, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			result, err := newSortExecutor().Execute(ctx, join+tc.orderBy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer result.Close()
+
+			rows, err := federation.CollectStream(ctx, result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := make([]int, len(rows))
+			for i, row := range rows {
+				got[i], _ = row["order_id"].(int)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("expected orders %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// mergeJoinInputs returns orders sorted on customer_id, with a NULL key,
+// and customers in no particular order; both sides repeat key 2.
+func mergeJoinInputs() (federation.ResultStream, federation.ResultStream) {
+	orders := newMockResultStream([]federation.Row{
+		{"order_id": 1, "customer_id": 1},
+		{"order_id": 2, "customer_id": 2},
+		{"order_id": 3, "customer_id": 2},
+		{"order_id": 4, "customer_id": 4},
+		{"order_id": 5, "customer_id": nil},
+	}, &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "order_id"}, {Name: "customer_id"}}})
+	customers := newMockResultStream([]federation.Row{
+		{"id": 4, "name": "Dan"},
+		{"id": 2, "name": "Bob"},
+		{"id": 3, "name": "Cat"},
+		{"id": 2, "name": "Bea"},
+	}, &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}})
+	return federation.NewOrderedStream(orders, []string{"customer_id"}), customers
+}
+
+// TestMergeJoin_JoinTypes tests the sort-merge join.
+// Green-Flag: A merge join MUST produce the rows of a hash join for INNER,
+// LEFT, RIGHT and FULL joins, sorting inputs that are not declared sorted.
+func TestMergeJoin_JoinTypes(t *testing.T) {
+	testCases := []struct {
+		joinType federation.JoinType
+		want     []string
+	}{
+		{federation.JoinTypeInner, []string{"2:Bea", "2:Bob", "3:Bea", "3:Bob", "4:Dan"}},
+		{federation.JoinTypeLeft, []string{"1:<nil>", "2:Bea", "2:Bob", "3:Bea", "3:Bob", "4:Dan", "5:<nil>"}},
+		{federation.JoinTypeRight, []string{"2:Bea", "2:Bob", "3:Bea", "3:Bob", "4:Dan", "<nil>:Cat"}},
+		{federation.JoinTypeFull, []string{"1:<nil>", "2:Bea", "2:Bob", "3:Bea", "3:Bob", "4:Dan", "5:<nil>", "<nil>:Cat"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.joinType), func(t *testing.T) {
+			orders, customers := mergeJoinInputs()
+			stream, err := federation.ExecuteJoin(context.Background(), federation.JoinStrategyMerge, &federation.JoinConfig{
+				LeftStream:  orders,
+				RightStream: customers,
+				LeftKeys:    []string{"customer_id"},
+				RightKeys:   []string{"id"},
+				Type:        tc.joinType,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rows, err := federation.CollectStream(context.Background(), stream)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]string, len(rows))
+			for i, row := range rows {
+				got[i] = fmt.Sprintf("%v:%v", row["order_id"], row["name"])
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestJoinStrategySelector_SortedLargeInputs tests merge join selection.
+// Green-Flag: Two large inputs sorted on their join keys SHOULD be merged.
+func TestJoinStrategySelector_SortedLargeInputs(t *testing.T) {
+	selector := federation.NewJoinStrategySelector(0)
+	left := federation.NewOrderedStream(newMockResultStream(make([]federation.Row, 200000), nil), []string{"o.customer_id"})
+	right := federation.NewOrderedStream(newMockResultStream(make([]federation.Row, 300000), nil), []string{"id", "name"})
+
+	strategy, config := selector.SelectStrategy(left, right, &federation.JoinCondition{
+		Type:      federation.JoinTypeInner,
+		LeftCols:  []string{"customer_id"},
+		RightCols: []string{"id"},
+	})
+	if strategy != federation.JoinStrategyMerge {
+		t.Fatalf("expected merge join strategy, got %s", strategy)
+	}
+	if config.LeftStream != left || config.RightStream != right || strings.Join(config.LeftKeys, ",") != "customer_id" || strings.Join(config.RightKeys, ",") != "id" {
+		t.Errorf("unexpected merge join config: %+v", config)
+	}
+}
+
+// contextBoundAdapter returns streams that fail once the context of the
+// query that produced them is done, as database cursors do.
+type contextBoundAdapter struct {
+	successAdapter
+}
+
+func (c *contextBoundAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	return &contextBoundStream{ResultStream: newMockResultStream(c.rows, c.schema), ctx: ctx}, nil
+}
+
+type contextBoundStream struct {
+	federation.ResultStream
+	ctx context.Context
+}
+
+func (s *contextBoundStream) Next(ctx context.Context) (federation.Row, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.ResultStream.Next(ctx)
+}
+
+// TestFederatedExecutor_SubQueriesWithinTimeout tests the per-sub-query
+// timeout on engines that answer in time.
+// Green-Flag: Sub-queries completing within the sub-query timeout MUST
+// return every joined row, including rows streamed after the other
+// sub-queries finished.
+func TestFederatedExecutor_SubQueriesWithinTimeout(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&contextBoundAdapter{successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"order_id": 1, "customer_id": 10},
+			{"order_id": 2, "customer_id": 20},
+			{"order_id": 3, "customer_id": 10},
+		},
+		schema: &federation.ResultSchema{},
+	}})
+	registry.Register(&contextBoundAdapter{successAdapter{
+		name:   "spark",
+		rows:   []federation.Row{{"id": 10, "name": "Alice"}, {"id": 20, "name": "Bob"}},
+		schema: &federation.ResultSchema{},
+	}})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetSubQueryTimeout(time.Minute)
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx,
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Errorf("expected 3 joined rows, got %d: %v", len(rows), rows)
+	}
+}
+
+// recordingMetrics records the sub-query latencies observed by an executor.
+type recordingMetrics struct {
+	mu         sync.Mutex
+	subQueries map[string][]time.Duration
+}
+
+func (m *recordingMetrics) ObserveQuery(entry observability.QueryLogEntry, err error) {}
+
+func (m *recordingMetrics) ObserveAuditFailure(entries int) {}
+
+func (m *recordingMetrics) ObserveSlowQuery(entry observability.QueryLogEntry) {}
+
+func (m *recordingMetrics) ObserveSubQuery(engine string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subQueries[engine] = append(m.subQueries[engine], latency)
+}
+
+// TestFederatedExecutor_RecordsSubQueryLatencyByEngine tests the sub-query
+// metrics of a federated query.
+// Green-Flag: The executor MUST record the latency of each sub-query in
+// its metrics, under the engine the sub-query ran on.
+func TestFederatedExecutor_RecordsSubQueryLatencyByEngine(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name:   "trino",
+		rows:   []federation.Row{{"order_id": 1, "customer_id": 10}},
+		schema: &federation.ResultSchema{},
+	})
+	registry.Register(&successAdapter{
+		name:   "spark",
+		rows:   []federation.Row{{"id": 10, "name": "Alice"}},
+		schema: &federation.ResultSchema{},
+	})
+	metrics := &recordingMetrics{subQueries: make(map[string][]time.Duration)}
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetMetrics(metrics)
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx,
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	if _, err := federation.CollectStream(ctx, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.subQueries) != 2 || len(metrics.subQueries["trino"]) != 1 || len(metrics.subQueries["spark"]) != 1 {
+		t.Errorf("expected one sub-query latency for trino and one for spark, got %v", metrics.subQueries)
+	}
+}
+
+// concurrencyCounter records the most queries running at once across
+// adapters.
+type concurrencyCounter struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+// countingAdapter returns fixed rows after a delay, counting the queries it
+// runs at once. It has no table statistics, so no input is broadcast.
+type countingAdapter struct {
+	successAdapter
+	counter *concurrencyCounter
+}
+
+func (c *countingAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	c.counter.mu.Lock()
+	c.counter.running++
+	if c.counter.running > c.counter.peak {
+		c.counter.peak = c.counter.running
+	}
+	c.counter.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.counter.mu.Lock()
+	c.counter.running--
+	c.counter.mu.Unlock()
+	return c.successAdapter.Execute(ctx, query)
+}
+
+func (c *countingAdapter) TableStats(ctx context.Context, table string) (*federation.TableStats, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// TestFederatedExecutor_BoundsSubQueryParallelism tests the sub-query worker
+// pool.
+// Green-Flag: A query spanning more engines than the maximum parallelism
+// MUST run no more sub-queries at once than the maximum, and still return
+// every joined row.
+func TestFederatedExecutor_BoundsSubQueryParallelism(t *testing.T) {
+	engines := []string{"trino", "spark", "duckdb", "snowflake", "bigquery"}
+	repo := storage.NewMockRepository()
+	registry := federation.NewAdapterRegistry()
+	counter := &concurrencyCounter{}
+	for i, engine := range engines {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         fmt.Sprintf("sales.t%d", i),
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: fmt.Sprintf("s3://bucket/t%d", i)}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+		key := fmt.Sprintf("k%d", i)
+		registry.Register(&countingAdapter{
+			successAdapter: successAdapter{
+				name:   engine,
+				rows:   []federation.Row{{key: 1}, {key: 2}},
+				schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: key}}},
+			},
+			counter: counter,
+		})
+	}
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetMaxParallelism(2)
+
+	query := "SELECT * FROM sales.t0"
+	for i := 1; i < len(engines); i++ {
+		query += fmt.Sprintf(" JOIN sales.t%d ON sales.t%d.k%d = sales.t%d.k%d", i, i-1, i-1, i, i)
+	}
+	ctx := context.Background()
+	result, err := executor.Execute(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if counter.peak > 2 {
+		t.Errorf("expected at most 2 sub-queries at once, got %d", counter.peak)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 joined rows, got %d: %v", len(rows), rows)
+	}
+}
+
+// TestFederatedExecutor_RemovesDuplicateRows tests SELECT DISTINCT across
+// engines.
+// Green-Flag: A DISTINCT query MUST return each distinct joined row once,
+// treating NULLs as equal to each other.
+func TestFederatedExecutor_RemovesDuplicateRows(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"customer_id": 10},
+			{"customer_id": 20},
+			{"customer_id": 10},
+			{"customer_id": 30},
+			{"customer_id": 30},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "customer_id"}}},
+	})
+	registry.Register(&successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 10, "name": "Alice"},
+			{"id": 20, "name": "Bob"},
+			{"id": 30, "name": nil},
+		},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}},
+	})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	query := "SELECT DISTINCT c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+	ctx := context.Background()
+	plan, err := executor.Plan(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected plan error: %v", err)
+	}
+	if !plan.Decomposed.PostJoinOps.Distinct {
+		t.Fatal("expected DISTINCT to be applied after the joins")
+	}
+
+	result, err := executor.Execute(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, row := range rows {
+		names = append(names, fmt.Sprint(row["name"]))
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "<nil>,Alice,Bob" {
+		t.Errorf("expected each name once, got %s", got)
+	}
+}
+
+// TestFederatedExecutor_DistinctComparesSelectedColumns tests SELECT
+// DISTINCT over joined rows that carry more columns than the SELECT list.
+// Green-Flag: DISTINCT MUST compare rows on the SELECT-list columns only,
+// not on the join keys and other columns read from the engines.
+func TestFederatedExecutor_DistinctComparesSelectedColumns(t *testing.T) {
+	executor := newOrdersCustomersJoinExecutor()
+	query := "SELECT DISTINCT c.region FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+	for _, mode := range []federation.PushdownMode{federation.PushdownDefault, federation.PushdownDisabled} {
+		ctx := federation.ContextWithPushdownMode(context.Background(), mode)
+		result, err := executor.Execute(ctx, query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", mode, err)
+		}
+		rows, err := federation.CollectStream(ctx, result)
+		result.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", mode, err)
+		}
+
+		var regions []string
+		for _, row := range rows {
+			regions = append(regions, fmt.Sprint(row["region"]))
+		}
+		sort.Strings(regions)
+		if got := strings.Join(regions, ","); got != "eu,us" {
+			t.Errorf("%s: expected each region once, got %s", mode, got)
+		}
+	}
+}
+
+// newSetOperationExecutor returns an executor over sales.orders on trino,
+// with customer IDs 10, 20, 20 and 30 returned as int32, and sales.customers
+// on spark, with IDs 20, 30 and 40 returned as int64.
+func newSetOperationExecutor() *federation.FederatedExecutor {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name:   "trino",
+		rows:   []federation.Row{{"customer_id": int32(10)}, {"customer_id": int32(20)}, {"customer_id": int32(20)}, {"customer_id": int32(30)}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "customer_id"}}},
+	})
+	registry.Register(&successAdapter{
+		name:   "spark",
+		rows:   []federation.Row{{"id": int64(20)}, {"id": int64(30)}, {"id": int64(40)}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}}},
+	})
+	return federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+}
+
+// collectSetOperation executes a set operation and returns the values of
+// its customer_id column, sorted.
+func collectSetOperation(t *testing.T, query string) string {
+	t.Helper()
+	ctx := context.Background()
+	result, err := newSetOperationExecutor().Execute(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var values []string
+	for _, row := range rows {
+		values = append(values, fmt.Sprint(row["customer_id"]))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// TestFederatedExecutor_UnionAcrossEngines tests cross-engine UNION of
+// integer columns of different widths.
+// Green-Flag: UNION MUST return each distinct value once when the engines
+// return it as different integer types, and UNION ALL MUST keep every row.
+func TestFederatedExecutor_UnionAcrossEngines(t *testing.T) {
+	if got := collectSetOperation(t, "SELECT o.customer_id FROM sales.orders o UNION SELECT c.id FROM sales.customers c"); got != "10,20,30,40" {
+		t.Errorf("expected distinct values 10,20,30,40, got %s", got)
+	}
+	if got := collectSetOperation(t, "SELECT o.customer_id FROM sales.orders o UNION ALL SELECT c.id FROM sales.customers c"); got != "10,20,20,20,30,30,40" {
+		t.Errorf("expected every row, got %s", got)
+	}
+}
+
+// TestFederatedExecutor_IntersectAcrossEngines tests cross-engine INTERSECT.
+// Green-Flag: INTERSECT MUST return each row of the left operand that is
+// also a row of the right operand once, matching columns by position.
+func TestFederatedExecutor_IntersectAcrossEngines(t *testing.T) {
+	got := collectSetOperation(t, "SELECT o.customer_id FROM sales.orders o INTERSECT SELECT c.id FROM sales.customers c")
+	if got != "20,30" {
+		t.Errorf("expected matching rows 20,30, got %s", got)
+	}
+}
+
+// TestFederatedExecutor_ExceptAcrossEngines tests cross-engine EXCEPT.
+// Green-Flag: EXCEPT MUST return the rows of the left operand that are not
+// rows of the right operand, and EXCEPT ALL MUST keep left duplicates not
+// cancelled by a right row.
+func TestFederatedExecutor_ExceptAcrossEngines(t *testing.T) {
+	if got := collectSetOperation(t, "SELECT o.customer_id FROM sales.orders o EXCEPT SELECT c.id FROM sales.customers c"); got != "10" {
+		t.Errorf("expected left minus right 10, got %s", got)
+	}
+	if got := collectSetOperation(t, "SELECT o.customer_id FROM sales.orders o EXCEPT ALL SELECT c.id FROM sales.customers c"); got != "10,20" {
+		t.Errorf("expected left minus right 10,20, got %s", got)
+	}
+}
+
+// joiningAdapter emulates an engine joining two of its tables: it returns
+// joinedRows when the sub-query it receives has the join condition on, and
+// the cross product of the tables, its rows, otherwise. It records the SQL.
+type joiningAdapter struct {
+	successAdapter
+	on         string
+	joinedRows []federation.Row
+	queries    []string
+}
+
+func (j *joiningAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	j.queries = append(j.queries, query)
+	if strings.Contains(query, j.on) {
+		return newMockResultStream(j.joinedRows, j.schema), nil
+	}
+	return newMockResultStream(j.rows, j.schema), nil
+}
+
+// TestFederatedExecutor_JoinsTablesWithinASubQuery tests a cross-engine
+// query joining two tables on the same engine.
+// Green-Flag: The join condition between two tables of one sub-query MUST be
+// sent to their engine in the sub-query's FROM clause, so the query returns
+// the joined rows rather than their cross product.
+func TestFederatedExecutor_JoinsTablesWithinASubQuery(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.items":     "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	// Orders 1 and 2, of customers 10 and 20, with two items and one item
+	orders := &joiningAdapter{
+		successAdapter: successAdapter{
+			name: "trino",
+			rows: []federation.Row{
+				{"customer_id": 10, "sku": "a"}, {"customer_id": 10, "sku": "b"}, {"customer_id": 10, "sku": "c"},
+				{"customer_id": 20, "sku": "a"}, {"customer_id": 20, "sku": "b"}, {"customer_id": 20, "sku": "c"},
+			},
+			schema: &federation.ResultSchema{},
+		},
+		on: "FROM sales.orders AS o INNER JOIN sales.items AS i ON o.id = i.order_id",
+		joinedRows: []federation.Row{
+			{"customer_id": 10, "sku": "a"}, {"customer_id": 10, "sku": "b"}, {"customer_id": 20, "sku": "c"},
+		},
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(&successAdapter{
+		name:   "spark",
+		rows:   []federation.Row{{"id": 10, "name": "Alice"}, {"id": 20, "name": "Bob"}},
+		schema: &federation.ResultSchema{},
+	})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx, "SELECT i.sku, c.name FROM sales.orders o "+
+		"JOIN sales.items i ON o.id = i.order_id JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(orders.queries) != 1 || !strings.Contains(orders.queries[0], orders.on) {
+		t.Errorf("expected the orders and items sub-query to join them, got %v", orders.queries)
+	}
+	if len(rows) != 3 {
+		t.Errorf("expected 3 joined rows, got %d: %v", len(rows), rows)
+	}
+}
+
+// projectingAdapter returns only the columns its SELECT list names, as an
+// engine does, or every column for a wildcard.
+type projectingAdapter struct {
+	successAdapter
+}
+
+func (p *projectingAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	list := query[len("SELECT "):strings.Index(query, " FROM ")]
+	if strings.HasSuffix(list, "*") {
+		return p.successAdapter.Execute(ctx, query)
+	}
+	var rows []federation.Row
+	for _, row := range p.rows {
+		projected := make(federation.Row)
+		for _, col := range strings.Split(list, ", ") {
+			col = col[strings.LastIndex(col, ".")+1:]
+			projected[col] = row[col]
+		}
+		rows = append(rows, projected)
+	}
+	return newMockResultStream(rows, p.schema), nil
+}
+
+// TestFederatedExecutor_ReportsBytesTransferred tests the execution
+// statistics of a federated query.
+// Green-Flag: The result stream MUST report the rows and bytes read from
+// engines, and projection pushdown MUST reduce the bytes transferred.
+func TestFederatedExecutor_ReportsBytesTransferred(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	note := strings.Repeat("x", 200)
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&projectingAdapter{successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"id": 1, "customer_id": 1, "note": note},
+			{"id": 2, "customer_id": 2, "note": note},
+		},
+		schema: &federation.ResultSchema{},
+	}})
+	registry.Register(&projectingAdapter{successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 1, "name": "Alice", "bio": note},
+			{"id": 2, "name": "Bob", "bio": note},
+		},
+		schema: &federation.ResultSchema{},
+	}})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	query := "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+
+	run := func(ctx context.Context) federation.ExecutionStats {
+		result, err := executor.Execute(ctx, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer result.Close()
+		if _, err := federation.CollectStream(ctx, result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stream, ok := result.(federation.StatsStream)
+		if !ok {
+			t.Fatalf("expected a StatsStream, got %T", result)
+		}
+		return stream.Stats()
+	}
+
+	projected := run(context.Background())
+	unprojected := run(federation.ContextWithPushdownMode(context.Background(), federation.PushdownDisabled))
+
+	if projected.RowsProcessed != 4 || unprojected.RowsProcessed != 4 {
+		t.Errorf("expected 4 rows read from engines, got %d and %d", projected.RowsProcessed, unprojected.RowsProcessed)
+	}
+	if projected.BytesTransferred <= 0 || projected.BytesTransferred >= unprojected.BytesTransferred {
+		t.Errorf("expected projection to reduce bytes transferred, got %d with and %d without",
+			projected.BytesTransferred, unprojected.BytesTransferred)
+	}
+	if len(projected.EnginesUsed) != 2 {
+		t.Errorf("expected 2 engines used, got %v", projected.EnginesUsed)
+	}
+}
+
+// sortedAdapter returns its rows as a stream that declares their order, as
+// an engine reading a table sorted on key would, and reports a size that
+// rules out broadcast joins.
+type sortedAdapter struct {
+	successAdapter
+	key    string
+	stream *mockResultStream
+}
+
+func (s *sortedAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	s.stream = newMockResultStream(s.rows, s.schema)
+	return federation.NewOrderedStream(s.stream, []string{s.key}), nil
+}
+
+func (s *sortedAdapter) TableStats(ctx context.Context, table string) (*federation.TableStats, error) {
+	return &federation.TableStats{RowCount: 1000000}, nil
+}
+
+// TestFederatedExecutor_StreamsAggregationOfMergeJoins tests aggregation
+// over the output of a merge join.
+// Green-Flag: A merge join MUST declare that its rows arrive in key order,
+// so a GROUP BY on the key emits each group without reading the rest of
+// the inputs.
+func TestFederatedExecutor_StreamsAggregationOfMergeJoins(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	var orderRows, customerRows []federation.Row
+	for id := 1; id <= 100; id++ {
+		customerRows = append(customerRows, federation.Row{"id": id, "region": "eu"})
+		for i := 0; i < 10; i++ {
+			orderRows = append(orderRows, federation.Row{"customer_id": id, "total": 5})
+		}
+	}
+	orders := &sortedAdapter{successAdapter: successAdapter{name: "trino", rows: orderRows, schema: &federation.ResultSchema{}}, key: "customer_id"}
+	customers := &sortedAdapter{successAdapter: successAdapter{name: "spark", rows: customerRows, schema: &federation.ResultSchema{}}, key: "id"}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(customers)
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx, "SELECT o.customer_id, SUM(o.total) AS total FROM sales.orders o "+
+		"LEFT JOIN sales.customers c ON o.customer_id = c.id GROUP BY o.customer_id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+
+	first, err := result.Next(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first == nil || first["total"] != int64(50) {
+		t.Fatalf("expected a first group totalling 50, got %v", first)
+	}
+	if read := orders.stream.idx; read >= len(orderRows) {
+		t.Errorf("expected the first group before all %d order rows were read, read %d", len(orderRows), read)
+	}
+
+	rest, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rest) != 99 {
+		t.Errorf("expected 100 groups, got %d", len(rest)+1)
+	}
+}
+
+// largeTableAdapter is a successAdapter over a table too large to hold in
+// memory, which records its queries.
+type largeTableAdapter struct {
+	successAdapter
+	queries []string
+}
+
+func (l *largeTableAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	l.queries = append(l.queries, query)
+	return l.successAdapter.Execute(ctx, query)
+}
+
+func (l *largeTableAdapter) TableStats(ctx context.Context, table string) (*federation.TableStats, error) {
+	return &federation.TableStats{RowCount: 5000000}, nil
+}
+
+// TestFederatedExecutor_MergesLargeInputs tests joins between two large
+// tables.
+// Green-Flag: Each sub-query MUST be sorted on its join key by its engine,
+// and the join MUST merge the sorted rows.
+func TestFederatedExecutor_MergesLargeInputs(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	orders := &largeTableAdapter{successAdapter: successAdapter{
+		name: "trino",
+		rows: []federation.Row{
+			{"id": 10, "customer_id": 1},
+			{"id": 11, "customer_id": 2},
+			{"id": 12, "customer_id": 2},
+			{"id": 13, "customer_id": 4},
+		},
+		schema: &federation.ResultSchema{},
+	}}
+	customers := &largeTableAdapter{successAdapter: successAdapter{
+		name: "spark",
+		rows: []federation.Row{
+			{"id": 1, "name": "Alice"},
+			{"id": 2, "name": "Bob"},
+			{"id": 3, "name": "Carol"},
+		},
+		schema: &federation.ResultSchema{},
+	}}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(customers)
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	query := "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+
+	plan, err := executor.Plan(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if steps := plan.JoinPlan.Steps; len(steps) != 1 || steps[0].Strategy != federation.JoinStrategyMerge {
+		t.Fatalf("expected one merge join step, got %+v", steps)
+	}
+
+	result, err := executor.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(context.Background(), result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Errorf("expected 3 joined rows, got %d: %v", len(rows), rows)
+	}
+	if len(orders.queries) != 1 || !strings.HasSuffix(orders.queries[0], " ORDER BY o.customer_id") {
+		t.Errorf("expected the orders sub-query sorted on o.customer_id, got %v", orders.queries)
+	}
+	if len(customers.queries) != 1 || !strings.HasSuffix(customers.queries[0], " ORDER BY c.id") {
+		t.Errorf("expected the customers sub-query sorted on c.id, got %v", customers.queries)
+	}
+}
@@ -8,6 +8,7 @@ package greenflag
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -146,6 +147,260 @@ func TestPersistentLogger_FullWorkflow(t *testing.T) {
 	}
 }
 
+// TestPersistentLogger_IngestsSiblingNDJSON verifies that audit entries
+// shipped from a sibling gateway are merged into the summary, and that
+// re-shipped entries are deduplicated by query_id and gateway_id.
+// Green-Flag: The audit summary MUST reflect the whole fleet.
+func TestPersistentLogger_IngestsSiblingNDJSON(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		gateway_id TEXT NOT NULL DEFAULT '',
+		slow_query BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (query_id, gateway_id)
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.SetGatewayID("gw-a")
+
+	ctx := context.Background()
+	if err := logger.LogQuery(ctx, observability.QueryLogEntry{
+		QueryID: "q-1", User: "alice", Engine: "duckdb", Outcome: "success",
+	}); err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+
+	// The sibling gateway's log output, as shipped.
+	var shipped strings.Builder
+	sibling := observability.NewJSONLogger(&shipped)
+	sibling.SetGatewayID("gw-b")
+	for _, entry := range []observability.QueryLogEntry{
+		{QueryID: "q-1", User: "bob", Engine: "trino", Outcome: "success"},
+		{QueryID: "q-2", User: "bob", Engine: "trino", Outcome: "error", Error: "access denied"},
+	} {
+		if err := sibling.LogQuery(ctx, entry); err != nil {
+			t.Fatalf("sibling LogQuery failed: %v", err)
+		}
+	}
+
+	result, err := logger.IngestNDJSON(ctx, "", strings.NewReader(shipped.String()))
+	if err != nil {
+		t.Fatalf("IngestNDJSON failed: %v", err)
+	}
+	if result.Ingested != 2 || result.Duplicates != 0 {
+		t.Errorf("expected 2 ingested and 0 duplicates, got %+v", result)
+	}
+
+	// Shipping the same entries again must not double-count them.
+	result, err = logger.IngestNDJSON(ctx, "", strings.NewReader(shipped.String()))
+	if err != nil {
+		t.Fatalf("second IngestNDJSON failed: %v", err)
+	}
+	if result.Ingested != 0 || result.Duplicates != 2 {
+		t.Errorf("expected 0 ingested and 2 duplicates, got %+v", result)
+	}
+
+	summary, err := logger.GetAuditSummaryWithOptions(observability.AuditSummaryOptions{
+		Dimensions: []observability.AuditDimension{observability.DimensionUsers, observability.DimensionRejectionReasons},
+	})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if summary.AcceptedCount != 2 || summary.RejectedCount != 1 {
+		t.Errorf("expected 2 accepted and 1 rejected, got %d and %d", summary.AcceptedCount, summary.RejectedCount)
+	}
+	wantUsers := []observability.UserQueryStat{{User: "bob", Count: 2}, {User: "alice", Count: 1}}
+	if len(summary.TopUsers) != 2 || summary.TopUsers[0] != wantUsers[0] || summary.TopUsers[1] != wantUsers[1] {
+		t.Errorf("expected top users %v, got %v", wantUsers, summary.TopUsers)
+	}
+	if len(summary.TopRejectionReasons) != 1 || summary.TopRejectionReasons[0].Reason != "access denied" {
+		t.Errorf("expected the sibling's rejection reason, got %v", summary.TopRejectionReasons)
+	}
+}
+
+// TestPersistentLogger_BatchesEntries verifies asynchronous batched audit
+// persistence.
+// Green-Flag: Batched entries MUST be persisted once the batch size or
+// interval is reached, and Close MUST drain the entries still buffered.
+func TestPersistentLogger_BatchesEntries(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		gateway_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	if err := logger.SetAuditBatch(observability.AuditBatch{Size: 3, Interval: time.Hour}); err != nil {
+		t.Fatalf("SetAuditBatch failed: %v", err)
+	}
+
+	countRows := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM audit_logs`).Scan(&n); err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		return n
+	}
+
+	ctx := context.Background()
+	for i, entry := range []observability.QueryLogEntry{
+		{QueryID: "q-1", User: "alice", Engine: "duckdb", Outcome: "success"},
+		{QueryID: "q-2", User: "alice", Engine: "duckdb", Outcome: "success", GatewayID: "gw-a"},
+		{QueryID: "q-3", User: "bob", Engine: "trino", Outcome: "error", Error: "engine unavailable"},
+	} {
+		if err := logger.LogQuery(ctx, entry); err != nil {
+			t.Fatalf("LogQuery %d failed: %v", i, err)
+		}
+	}
+
+	// Reaching the batch size flushes the entries, with and without a
+	// gateway_id, in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for countRows() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := countRows(); n != 3 {
+		t.Fatalf("expected the batch of 3 entries to be persisted, got %d", n)
+	}
+
+	// A partial batch waits for the interval.
+	if err := logger.LogQuery(ctx, observability.QueryLogEntry{QueryID: "q-4", User: "bob", Engine: "trino", Outcome: "success"}); err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+	if n := countRows(); n != 3 {
+		t.Errorf("expected the partial batch to stay buffered, got %d rows", n)
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if n := countRows(); n != 4 {
+		t.Errorf("expected Close to persist the buffered entry, got %d rows", n)
+	}
+	var gateway string
+	db.QueryRow(`SELECT gateway_id FROM audit_logs WHERE query_id = 'q-2'`).Scan(&gateway)
+	if gateway != "gw-a" {
+		t.Errorf("expected q-2 to be persisted with its gateway_id, got %q", gateway)
+	}
+}
+
+// TestPersistentLogger_AuditSummaryWindow verifies windowed summaries of
+// persisted entries.
+// Green-Flag: A summary window MUST count only the entries created in
+// [Since, Until), filtering audit_logs on created_at.
+func TestPersistentLogger_AuditSummaryWindow(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		slow_query BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, entry := range []observability.QueryLogEntry{
+		{QueryID: "q-1", User: "alice", Engine: "duckdb", Timestamp: day.Add(-time.Minute)},
+		{QueryID: "q-2", User: "alice", Engine: "duckdb", Timestamp: day},
+		{QueryID: "q-3", User: "bob", Engine: "trino", Timestamp: day.Add(time.Hour), Error: "access denied"},
+		{QueryID: "q-4", User: "carol", Engine: "trino", Timestamp: day.Add(24 * time.Hour)},
+	} {
+		if err := logger.LogQuery(ctx, entry); err != nil {
+			t.Fatalf("LogQuery %d failed: %v", i, err)
+		}
+	}
+
+	summary, err := logger.GetAuditSummaryWithOptions(observability.AuditSummaryOptions{
+		Dimensions: []observability.AuditDimension{observability.DimensionUsers, observability.DimensionRejectionReasons},
+		Since:      day,
+		Until:      day.Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if summary.AcceptedCount != 1 || summary.RejectedCount != 1 {
+		t.Errorf("expected 1 accepted and 1 rejected in the window, got %d and %d", summary.AcceptedCount, summary.RejectedCount)
+	}
+	wantUsers := []observability.UserQueryStat{{User: "alice", Count: 1}, {User: "bob", Count: 1}}
+	if len(summary.TopUsers) != 2 || summary.TopUsers[0] != wantUsers[0] || summary.TopUsers[1] != wantUsers[1] {
+		t.Errorf("expected top users %v, got %v", wantUsers, summary.TopUsers)
+	}
+	if len(summary.TopRejectionReasons) != 1 || summary.TopRejectionReasons[0].Reason != "access denied" {
+		t.Errorf("expected the rejection in the window, got %v", summary.TopRejectionReasons)
+	}
+}
+
 // ============== T033: Time-Travel Normalization ==============
 
 // TestTimeTravelRewriter_AllFormats verifies rewriting for all supported formats.
@@ -284,3 +539,88 @@ func TestWarehouseAdapters_Capabilities(t *testing.T) {
 		t.Error("BigQuery should report capabilities")
 	}
 }
+
+// TestVerboseAudit_LogsRewrittenEngineSQL verifies that verbose audit records
+// the SQL actually sent to each engine.
+// Green-Flag: The logged and persisted engine SQL MUST match the rewriter
+// output for a time-travel query.
+func TestVerboseAudit_LogsRewrittenEngineSQL(t *testing.T) {
+	query := "SELECT * FROM orders FOR SYSTEM_TIME AS OF '2024-01-01 00:00:00'"
+	rewritten, err := canonicsql.NewTimeTravelRewriter(catalog.FormatIceberg, "trino").Rewrite(query)
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	entry := observability.QueryLogEntry{
+		QueryID:   "q-tt",
+		User:      "alice",
+		Engine:    "trino",
+		Outcome:   "success",
+		SQL:       query,
+		EngineSQL: map[string]string{"trino": rewritten},
+	}
+	ctx := context.Background()
+
+	// JSON log
+	var buf strings.Builder
+	jsonLogger := observability.NewJSONLogger(&buf)
+	jsonLogger.SetVerboseAudit(true)
+	if err := jsonLogger.LogQuery(ctx, entry); err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+	var record struct {
+		EngineSQL map[string]string `json:"engine_sql"`
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &record); err != nil {
+		t.Fatalf("invalid JSON log: %v", err)
+	}
+	if record.EngineSQL["trino"] != rewritten {
+		t.Errorf("expected logged engine SQL %q, got %q", rewritten, record.EngineSQL["trino"])
+	}
+
+	// Persisted audit log
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		engine_sql TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	persistent, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	persistent.SetVerboseAudit(true)
+	if err := persistent.LogQuery(ctx, entry); err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT engine_sql FROM audit_logs WHERE query_id = 'q-tt'").Scan(&stored); err != nil {
+		t.Fatalf("failed to read engine_sql: %v", err)
+	}
+	var persisted map[string]string
+	if err := json.Unmarshal([]byte(stored), &persisted); err != nil {
+		t.Fatalf("engine_sql is not JSON: %v", err)
+	}
+	if persisted["trino"] != rewritten {
+		t.Errorf("expected persisted engine SQL %q, got %q", rewritten, persisted["trino"])
+	}
+}
@@ -0,0 +1,130 @@
+package greenflag
+
+import (
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// lineageSources returns the sources of an output column as table.column strings.
+func lineageSources(t *testing.T, lineage []sql.ColumnLineage, column string) []string {
+	t.Helper()
+	for _, col := range lineage {
+		if col.Column == column {
+			refs := make([]string, len(col.Sources))
+			for i, ref := range col.Sources {
+				refs[i] = ref.String()
+			}
+			return refs
+		}
+	}
+	t.Fatalf("output column %q not found in lineage %+v", column, lineage)
+	return nil
+}
+
+func assertSources(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("expected sources %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected sources %v, got %v", want, got)
+		}
+	}
+}
+
+// TestLineage_JoinColumnsMapToSources proves that each output column of a
+// join maps back to the table it was read from.
+//
+// Green-Flag: Lineage MUST resolve aliases across joins to source columns.
+func TestLineage_JoinColumnsMapToSources(t *testing.T) {
+	query := `SELECT o.id, c.name AS customer, o.amount * 2 AS doubled
+		FROM analytics.orders o
+		JOIN analytics.customers c ON o.customer_id = c.id`
+
+	lineage, err := sql.NewParser().Lineage(query, nil)
+	if err != nil {
+		t.Fatalf("lineage failed: %v", err)
+	}
+	if len(lineage) != 3 {
+		t.Fatalf("expected 3 output columns, got %d: %+v", len(lineage), lineage)
+	}
+
+	assertSources(t, lineageSources(t, lineage, "id"), "analytics.orders.id")
+	assertSources(t, lineageSources(t, lineage, "customer"), "analytics.customers.name")
+	assertSources(t, lineageSources(t, lineage, "doubled"), "analytics.orders.amount")
+
+	if lineage[2].Expression == "" {
+		t.Error("expected computed column to carry its expression")
+	}
+}
+
+// TestLineage_AggregateTracesToInput proves that an aggregate output column
+// traces back to the column it aggregates.
+//
+// Green-Flag: Aggregates MUST trace to their input columns.
+func TestLineage_AggregateTracesToInput(t *testing.T) {
+	query := `SELECT c.region, SUM(o.amount) AS revenue, COUNT(*) AS orders
+		FROM analytics.orders o
+		JOIN analytics.customers c ON o.customer_id = c.id
+		GROUP BY c.region`
+
+	lineage, err := sql.NewParser().Lineage(query, nil)
+	if err != nil {
+		t.Fatalf("lineage failed: %v", err)
+	}
+
+	assertSources(t, lineageSources(t, lineage, "region"), "analytics.customers.region")
+	assertSources(t, lineageSources(t, lineage, "revenue"), "analytics.orders.amount")
+	assertSources(t, lineageSources(t, lineage, "orders"))
+}
+
+// TestLineage_TracesThroughSubqueriesAndCTEs proves that columns of derived
+// tables and CTEs are traced to their base tables.
+//
+// Green-Flag: Lineage MUST see through derived tables and CTEs.
+func TestLineage_TracesThroughSubqueriesAndCTEs(t *testing.T) {
+	query := `WITH totals AS (
+			SELECT customer_id, SUM(amount) AS total FROM analytics.orders GROUP BY customer_id
+		)
+		SELECT c.name, t.total
+		FROM totals t JOIN (SELECT id, name FROM analytics.customers) c ON t.customer_id = c.id`
+
+	lineage, err := sql.NewParser().Lineage(query, nil)
+	if err != nil {
+		t.Fatalf("lineage failed: %v", err)
+	}
+
+	assertSources(t, lineageSources(t, lineage, "name"), "analytics.customers.name")
+	assertSources(t, lineageSources(t, lineage, "total"), "analytics.orders.amount")
+}
+
+// TestLineage_ExpandsStarFromSchemas proves that SELECT * is expanded using
+// table schemas, and that schemas disambiguate unqualified columns.
+//
+// Green-Flag: SELECT * MUST expand to one lineage entry per schema column.
+func TestLineage_ExpandsStarFromSchemas(t *testing.T) {
+	schemas := map[string][]string{
+		"analytics.orders":    {"id", "customer_id", "amount"},
+		"analytics.customers": {"id", "name"},
+	}
+	lookup := func(table string) []string { return schemas[table] }
+
+	lineage, err := sql.NewParser().Lineage("SELECT * FROM analytics.orders", lookup)
+	if err != nil {
+		t.Fatalf("lineage failed: %v", err)
+	}
+	if len(lineage) != 3 {
+		t.Fatalf("expected 3 expanded columns, got %+v", lineage)
+	}
+	assertSources(t, lineageSources(t, lineage, "amount"), "analytics.orders.amount")
+
+	lineage, err = sql.NewParser().Lineage(
+		"SELECT name, amount FROM analytics.orders o JOIN analytics.customers c ON o.customer_id = c.id", lookup)
+	if err != nil {
+		t.Fatalf("lineage failed: %v", err)
+	}
+	assertSources(t, lineageSources(t, lineage, "name"), "analytics.customers.name")
+	assertSources(t, lineageSources(t, lineage, "amount"), "analytics.orders.amount")
+}
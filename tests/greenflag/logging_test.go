@@ -11,9 +11,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/observability"
 )
 
@@ -199,3 +207,455 @@ func TestLoggingUserRole(t *testing.T) {
 		t.Error("User role should be logged when provided")
 	}
 }
+
+// TestLoggingQueryPolicy tests that the role query policy bounding a query
+// is logged, resolved from the user's roles.
+func TestLoggingQueryPolicy(t *testing.T) {
+	policies := auth.NewQueryPolicies(auth.QueryPolicy{DefaultLimit: 10000})
+	policies.SetRolePolicy("exec_dashboard", auth.RoleQueryPolicy{DefaultLimit: 500})
+	policy := policies.Resolve(&auth.User{ID: "dash", Roles: []string{"exec_dashboard"}})
+
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-policy-1",
+		User:          "dash",
+		Role:          "exec_dashboard",
+		QueryPolicy:   policy.Name(),
+		ExecutionTime: time.Millisecond,
+		Outcome:       "success",
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if output["query_policy"] != "exec_dashboard" {
+		t.Errorf("query_policy = %v, want exec_dashboard", output["query_policy"])
+	}
+}
+
+// logHashedQuery logs a query through a JSON logger with sensitive column
+// hashing and returns the logged SQL.
+func logHashedQuery(t *testing.T, hasher *observability.ColumnHasher, queryID, query string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+	logger.SetColumnHasher(hasher)
+
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       queryID,
+		User:          "alice@example.com",
+		ExecutionTime: time.Millisecond,
+		SQL:           query,
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	sql, _ := output["sql"].(string)
+	return sql
+}
+
+// TestLoggingHashesSensitiveColumnsDeterministically tests that a sensitive
+// value hashes to the same token in every log entry, so entries stay joinable.
+func TestLoggingHashesSensitiveColumnsDeterministically(t *testing.T) {
+	hasher, err := observability.NewColumnHasher("test-salt", map[string][]string{
+		"analytics.customers": {"email"},
+	})
+	if err != nil {
+		t.Fatalf("NewColumnHasher failed: %v", err)
+	}
+
+	first := logHashedQuery(t, hasher, "q-1",
+		"SELECT id FROM analytics.customers WHERE email = 'alice@example.com'")
+	second := logHashedQuery(t, hasher, "q-2",
+		"SELECT c.name FROM analytics.customers c WHERE c.email IN ('bob@example.com', 'alice@example.com')")
+
+	hash := hasher.Hash("alice@example.com")
+	for _, logged := range []string{first, second} {
+		if strings.Contains(logged, "alice@example.com") {
+			t.Errorf("sensitive value leaked into log: %s", logged)
+		}
+		if !strings.Contains(logged, hash) {
+			t.Errorf("expected hash %s in logged SQL: %s", hash, logged)
+		}
+	}
+	if strings.Contains(second, "bob@example.com") {
+		t.Errorf("sensitive IN-list value leaked into log: %s", second)
+	}
+}
+
+// TestLoggingLeavesNonSensitiveValuesUntouched tests that only literals
+// compared against sensitive columns are hashed.
+func TestLoggingLeavesNonSensitiveValuesUntouched(t *testing.T) {
+	hasher, err := observability.NewColumnHasher("test-salt", map[string][]string{
+		"analytics.customers": {"email"},
+	})
+	if err != nil {
+		t.Fatalf("NewColumnHasher failed: %v", err)
+	}
+
+	query := "SELECT id FROM analytics.orders WHERE region = 'EMEA' AND amount > 100"
+	if logged := logHashedQuery(t, hasher, "q-3", query); logged != query {
+		t.Errorf("expected query without sensitive columns to be logged verbatim\n got: %s\nwant: %s", logged, query)
+	}
+
+	logged := logHashedQuery(t, hasher, "q-4",
+		"SELECT id FROM analytics.customers WHERE email = 'carol@example.com' AND region = 'EMEA'")
+	if !strings.Contains(logged, "'EMEA'") {
+		t.Errorf("expected non-sensitive value to be untouched: %s", logged)
+	}
+	if strings.Contains(logged, "carol@example.com") {
+		t.Errorf("sensitive value leaked into log: %s", logged)
+	}
+}
+
+// TestLoggingRedactsLiteralsToFingerprint tests that a redactor logs queries
+// differing only in literal values as the same normalized SQL and
+// fingerprint.
+// Green-Flag: Redacted entries MUST keep the shape of the query, so they can
+// still be grouped and diagnosed, without its literal values.
+func TestLoggingRedactsLiteralsToFingerprint(t *testing.T) {
+	logged := make([]map[string]interface{}, 0, 2)
+	for i, query := range []string{
+		"SELECT id FROM analytics.orders WHERE region = 'EMEA' AND amount > 100",
+		"select id from analytics.orders where region = 'APAC' and amount > 2500",
+	} {
+		var buf bytes.Buffer
+		logger := observability.NewJSONLogger(&buf)
+		logger.SetRedactor(observability.NewRedactor())
+		err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+			QueryID:       fmt.Sprintf("q-%d", i),
+			User:          "alice",
+			ExecutionTime: time.Millisecond,
+			SQL:           query,
+			EngineSQL:     map[string]string{"trino": query},
+		})
+		if err != nil {
+			t.Fatalf("Logging failed: %v", err)
+		}
+		var output map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+			t.Fatalf("Failed to parse log output: %v", err)
+		}
+		logged = append(logged, output)
+	}
+
+	first, second := logged[0], logged[1]
+	sql, _ := first["sql"].(string)
+	if !strings.Contains(sql, "region = ?") || !strings.Contains(sql, "amount > ?") {
+		t.Errorf("expected literals replaced by placeholders, got %q", sql)
+	}
+	if first["sql"] != second["sql"] {
+		t.Errorf("expected the same redacted SQL, got %q and %q", first["sql"], second["sql"])
+	}
+	if first["fingerprint"] == nil || first["fingerprint"] != second["fingerprint"] {
+		t.Errorf("expected the same fingerprint, got %v and %v", first["fingerprint"], second["fingerprint"])
+	}
+}
+
+// TestLoggingSlowQueryTriggersHook tests that a query over the slow-query
+// threshold emits a warn record and invokes the hook with its metadata.
+func TestLoggingSlowQueryTriggersHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+
+	var events []observability.SlowQueryEvent
+	logger.SetSlowQueryThreshold(time.Second, func(_ context.Context, event observability.SlowQueryEvent) {
+		events = append(events, event)
+	})
+
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-slow",
+		User:          "alice@example.com",
+		Tables:        []string{"analytics.orders"},
+		Engine:        "trino",
+		ExecutionTime: 3 * time.Second,
+		Outcome:       "success",
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 slow query event, got %d", len(events))
+	}
+	event := events[0]
+	if event.QueryID != "q-slow" || event.User != "alice@example.com" || event.Engine != "trino" {
+		t.Errorf("Unexpected event metadata: %+v", event)
+	}
+	if len(event.Tables) != 1 || event.Tables[0] != "analytics.orders" {
+		t.Errorf("Expected tables [analytics.orders], got %v", event.Tables)
+	}
+	if event.Duration != 3*time.Second || event.Threshold != time.Second {
+		t.Errorf("Expected duration 3s over threshold 1s, got %v over %v", event.Duration, event.Threshold)
+	}
+
+	// The slow query record follows the regular query record
+	decoder := json.NewDecoder(&buf)
+	var records []map[string]interface{}
+	for decoder.More() {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to parse log output: %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected query record and slow query record, got %d records", len(records))
+	}
+	slow := records[1]
+	if slow["level"] != "warn" || slow["event"] != "slow_query" {
+		t.Errorf("Expected warn-level slow_query record, got %v", slow)
+	}
+	if slow["threshold_ms"] != float64(1000) || slow["execution_time_ms"] != float64(3000) {
+		t.Errorf("Expected durations in slow query record, got %v", slow)
+	}
+}
+
+// TestLoggingFlagsQueriesOverSlowThreshold tests the slow-query flag of
+// queries below, at and above the threshold.
+// Green-Flag: Only a query taking longer than the threshold MUST be logged
+// at warn level with slow_query set, counted in the metrics and counted by
+// the audit summary.
+func TestLoggingFlagsQueriesOverSlowThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+	metrics := observability.NewPrometheusMetrics()
+	logger.SetMetrics(metrics)
+	logger.SetSlowQueryThreshold(time.Second, nil)
+
+	for _, entry := range []observability.QueryLogEntry{
+		{QueryID: "q-below", User: "alice", Engine: "trino", ExecutionTime: 999 * time.Millisecond},
+		{QueryID: "q-at", User: "alice", Engine: "trino", ExecutionTime: time.Second},
+		{QueryID: "q-above", User: "alice", Engine: "trino", ExecutionTime: 1001 * time.Millisecond},
+	} {
+		if err := logger.LogQuery(context.Background(), entry); err != nil {
+			t.Fatalf("Logging failed: %v", err)
+		}
+	}
+
+	levels := map[string]interface{}{}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to parse log output: %v", err)
+		}
+		if record["event"] == nil {
+			levels[record["query_id"].(string)] = record["level"]
+			if slow := record["slow_query"] == true; slow != (record["query_id"] == "q-above") {
+				t.Errorf("unexpected slow_query flag in record %v", record)
+			}
+		}
+	}
+	want := map[string]interface{}{"q-below": "info", "q-at": "info", "q-above": "warn"}
+	for queryID, level := range want {
+		if levels[queryID] != level {
+			t.Errorf("expected %s logged at %v, got %v", queryID, level, levels[queryID])
+		}
+	}
+
+	if summary := logger.GetAuditSummary(); summary.SlowQueryCount != 1 {
+		t.Errorf("expected 1 slow query in the audit summary, got %d", summary.SlowQueryCount)
+	}
+	var text strings.Builder
+	if err := metrics.WriteText(&text); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	if !strings.Contains(text.String(), `canonica_slow_queries_total{engine="trino"} 1`) {
+		t.Errorf("expected 1 slow query counted for trino, got:\n%s", text.String())
+	}
+}
+
+// TestLoggingSlowQueryWebhook tests that the webhook hook delivers the event.
+func TestLoggingSlowQueryWebhook(t *testing.T) {
+	received := make(chan observability.SlowQueryEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event observability.SlowQueryEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("Failed to decode webhook body: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	logger.SetSlowQueryThreshold(100*time.Millisecond,
+		observability.NewSlowQueryWebhook(server.URL, server.Client()))
+
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-hook",
+		User:          "bob",
+		Engine:        "duckdb",
+		ExecutionTime: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	select {
+	case event := <-received:
+		if event.QueryID != "q-hook" || event.Duration != time.Second {
+			t.Errorf("Unexpected webhook event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+}
+
+// TestLoggingLogfmtIncludesAllRequiredFields tests that the logfmt logger
+// emits the required fields and quotes values containing spaces.
+func TestLoggingLogfmtIncludesAllRequiredFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := observability.NewQueryLogger(observability.LogFormatLogfmt, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	err = logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:         "q-12345",
+		User:            "alice@example.com",
+		Tables:          []string{"analytics.orders", "analytics.customers"},
+		PlannerDecision: "single-table read via DuckDB",
+		Engine:          "duckdb",
+		ExecutionTime:   150 * time.Millisecond,
+		Outcome:         "success",
+		SQL:             `SELECT * FROM analytics.orders WHERE status = "open"`,
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	line := buf.String()
+	if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+		t.Fatalf("Expected a single newline-terminated line, got %q", line)
+	}
+
+	wantPairs := []string{
+		"level=info",
+		"query_id=q-12345",
+		"user=alice@example.com",
+		"tables=analytics.orders,analytics.customers",
+		"engine=duckdb",
+		"execution_time_ms=150",
+		"outcome=success",
+		`planner_decision="single-table read via DuckDB"`,
+		`sql="SELECT * FROM analytics.orders WHERE status = \"open\""`,
+	}
+	for _, pair := range wantPairs {
+		if !strings.Contains(line, " "+pair+" ") && !strings.Contains(line, " "+pair+"\n") {
+			t.Errorf("Expected %s in logfmt output, got %q", pair, line)
+		}
+	}
+	if !strings.HasPrefix(line, "timestamp=") {
+		t.Errorf("Expected timestamp first, got %q", line)
+	}
+}
+
+// TestLoggingDefaultFormatIsJSON tests that an unset log format selects JSON.
+func TestLoggingDefaultFormatIsJSON(t *testing.T) {
+	format, err := observability.ParseLogFormat("")
+	if err != nil {
+		t.Fatalf("Failed to parse empty log format: %v", err)
+	}
+	if format != observability.LogFormatJSON {
+		t.Fatalf("Expected default format %s, got %s", observability.LogFormatJSON, format)
+	}
+
+	var buf bytes.Buffer
+	logger, err := observability.NewQueryLogger(format, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	if err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID: "q-1",
+		User:    "alice@example.com",
+	}); err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+}
+
+// TestLoggingOverflowEntriesPersistedAndSummarized tests that entries
+// evicted from a bounded in-memory buffer are written to the overflow file,
+// across rotations, and included in the audit summary.
+func TestLoggingOverflowEntriesPersistedAndSummarized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	logger.SetMaxEntries(2)
+	if err := logger.SetAuditOverflow(observability.AuditOverflow{
+		Path:             path,
+		MaxBytes:         300,
+		MaxFiles:         5,
+		IncludeInSummary: true,
+	}); err != nil {
+		t.Fatalf("Failed to configure overflow: %v", err)
+	}
+	defer logger.Close()
+
+	for i := 1; i <= 6; i++ {
+		entry := observability.QueryLogEntry{
+			QueryID:       fmt.Sprintf("q-%d", i),
+			User:          "alice@example.com",
+			Tables:        []string{"analytics.orders"},
+			Engine:        "duckdb",
+			ExecutionTime: 10 * time.Millisecond,
+		}
+		if i%3 == 0 {
+			entry.Error = "access denied"
+		}
+		if err := logger.LogQuery(context.Background(), entry); err != nil {
+			t.Fatalf("Logging failed: %v", err)
+		}
+	}
+
+	// Four entries were evicted; the records may span rotated files
+	files, _ := filepath.Glob(path + "*")
+	var persisted []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read overflow file: %v", err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("Overflow line is not JSON: %q", line)
+			}
+			persisted = append(persisted, record["query_id"].(string))
+		}
+	}
+	sort.Strings(persisted)
+	if strings.Join(persisted, ",") != "q-1,q-2,q-3,q-4" {
+		t.Errorf("Expected q-1..q-4 in overflow files, got %v", persisted)
+	}
+	if len(files) < 2 {
+		t.Errorf("Expected the overflow file to rotate, got files %v", files)
+	}
+
+	summary := logger.GetAuditSummary()
+	if summary.AcceptedCount != 4 || summary.RejectedCount != 2 {
+		t.Errorf("Expected 4 accepted and 2 rejected across memory and overflow, got %d and %d",
+			summary.AcceptedCount, summary.RejectedCount)
+	}
+	if len(summary.TopQueriedTables) != 1 || summary.TopQueriedTables[0].Count != 6 {
+		t.Errorf("Expected analytics.orders queried 6 times, got %v", summary.TopQueriedTables)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -254,3 +257,139 @@ func TestObservability_LogLevel(t *testing.T) {
 		t.Error("log level field missing from output")
 	}
 }
+
+// TestObservability_AuditSummaryTopN verifies that the audit summary honours
+// a configured N and ranks the requested dimensions.
+// Green-Flag: Top-N lists MUST have the configured length and correct per-engine and per-user counts.
+func TestObservability_AuditSummaryTopN(t *testing.T) {
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	ctx := context.Background()
+
+	entries := []observability.QueryLogEntry{
+		{QueryID: "q1", User: "alice", Tables: []string{"sales.orders"}, Engine: "trino"},
+		{QueryID: "q2", User: "alice", Tables: []string{"sales.orders", "sales.customers"}, Engine: "trino"},
+		{QueryID: "q3", User: "bob", Tables: []string{"sales.customers"}, Engine: "duckdb"},
+		{QueryID: "q4", User: "carol", Tables: []string{"sales.returns"}, Engine: "trino"},
+		{QueryID: "q5", User: "bob", Tables: []string{"sales.orders"}, Engine: "spark", Error: "engine unavailable"},
+	}
+	for _, entry := range entries {
+		if err := logger.LogQuery(ctx, entry); err != nil {
+			t.Fatalf("LogQuery failed: %v", err)
+		}
+	}
+
+	summary, err := logger.GetAuditSummaryWithOptions(observability.AuditSummaryOptions{
+		TopN:       2,
+		Dimensions: []observability.AuditDimension{observability.DimensionTables, observability.DimensionEngines, observability.DimensionUsers},
+	})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+
+	wantTables := []observability.TableQueryStat{{Table: "sales.orders", Count: 3}, {Table: "sales.customers", Count: 2}}
+	if len(summary.TopQueriedTables) != 2 || summary.TopQueriedTables[0] != wantTables[0] || summary.TopQueriedTables[1] != wantTables[1] {
+		t.Errorf("expected top tables %v, got %v", wantTables, summary.TopQueriedTables)
+	}
+
+	wantEngines := []observability.EngineQueryStat{{Engine: "trino", Count: 3}, {Engine: "duckdb", Count: 1}}
+	if len(summary.TopEngines) != 2 || summary.TopEngines[0] != wantEngines[0] || summary.TopEngines[1] != wantEngines[1] {
+		t.Errorf("expected top engines %v, got %v", wantEngines, summary.TopEngines)
+	}
+
+	wantUsers := []observability.UserQueryStat{{User: "alice", Count: 2}, {User: "bob", Count: 2}}
+	if len(summary.TopUsers) != 2 || summary.TopUsers[0] != wantUsers[0] || summary.TopUsers[1] != wantUsers[1] {
+		t.Errorf("expected top users %v, got %v", wantUsers, summary.TopUsers)
+	}
+
+	if len(summary.TopRejectionReasons) != 0 {
+		t.Errorf("rejection reasons were not requested, got %v", summary.TopRejectionReasons)
+	}
+	if summary.AcceptedCount != 4 || summary.RejectedCount != 1 {
+		t.Errorf("expected 4 accepted and 1 rejected, got %d and %d", summary.AcceptedCount, summary.RejectedCount)
+	}
+}
+
+// TestMetrics_ServesPrometheusText verifies the /metrics exposition.
+// Green-Flag: Queries MUST be counted by outcome and engine, and their
+// execution times and sub-query latencies observed in cumulative histograms.
+func TestMetrics_ServesPrometheusText(t *testing.T) {
+	metrics := observability.NewPrometheusMetrics()
+	metrics.ObserveQuery(observability.QueryLogEntry{QueryID: "q1", Engine: "trino", Outcome: "success", ExecutionTime: 20 * time.Millisecond}, nil)
+	metrics.ObserveQuery(observability.QueryLogEntry{QueryID: "q2", Engine: "trino", ExecutionTime: 2 * time.Second}, nil)
+	metrics.ObserveSubQuery("spark", 300*time.Millisecond)
+
+	server := httptest.NewServer(metrics)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("expected the Prometheus text content type, got %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+
+	for _, want := range []string{
+		"# TYPE canonica_queries_total counter",
+		`canonica_queries_total{outcome="success",engine="trino"} 2`,
+		"# TYPE canonica_query_duration_seconds histogram",
+		`canonica_query_duration_seconds_bucket{engine="trino",le="0.01"} 0`,
+		`canonica_query_duration_seconds_bucket{engine="trino",le="0.025"} 1`,
+		`canonica_query_duration_seconds_bucket{engine="trino",le="2.5"} 2`,
+		`canonica_query_duration_seconds_bucket{engine="trino",le="+Inf"} 2`,
+		`canonica_query_duration_seconds_sum{engine="trino"} 2.02`,
+		`canonica_query_duration_seconds_count{engine="trino"} 2`,
+		`canonica_subquery_duration_seconds_bucket{engine="spark",le="0.25"} 0`,
+		`canonica_subquery_duration_seconds_bucket{engine="spark",le="0.5"} 1`,
+		`canonica_subquery_duration_seconds_count{engine="spark"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+// TestObservability_AuditSummaryRange verifies windowed audit summaries.
+// Green-Flag: A summary range MUST count only the entries logged in
+// [from, to), and an open bound MUST not filter that side.
+func TestObservability_AuditSummaryRange(t *testing.T) {
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, entry := range []observability.QueryLogEntry{
+		{QueryID: "q1", User: "alice", Tables: []string{"sales.orders"}, Timestamp: day.Add(-time.Hour)},
+		{QueryID: "q2", User: "alice", Tables: []string{"sales.orders"}, Timestamp: day},
+		{QueryID: "q3", User: "bob", Tables: []string{"sales.customers"}, Timestamp: day.Add(time.Hour), Error: "access denied"},
+		{QueryID: "q4", User: "bob", Tables: []string{"sales.customers"}, Timestamp: day.Add(24 * time.Hour)},
+	} {
+		if err := logger.LogQuery(ctx, entry); err != nil {
+			t.Fatalf("LogQuery %d failed: %v", i, err)
+		}
+	}
+
+	summary, err := logger.GetAuditSummaryRange(ctx, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetAuditSummaryRange failed: %v", err)
+	}
+	if summary.AcceptedCount != 1 || summary.RejectedCount != 1 {
+		t.Errorf("expected 1 accepted and 1 rejected in the window, got %d and %d", summary.AcceptedCount, summary.RejectedCount)
+	}
+	if len(summary.TopQueriedTables) != 2 {
+		t.Errorf("expected the two tables queried in the window, got %v", summary.TopQueriedTables)
+	}
+
+	summary, err = logger.GetAuditSummaryRange(ctx, day, time.Time{})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryRange failed: %v", err)
+	}
+	if summary.AcceptedCount != 2 || summary.RejectedCount != 1 {
+		t.Errorf("expected 2 accepted and 1 rejected since the start of the day, got %d and %d", summary.AcceptedCount, summary.RejectedCount)
+	}
+}
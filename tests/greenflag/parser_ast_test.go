@@ -5,6 +5,8 @@
 package greenflag
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -141,6 +143,21 @@ func TestParser_HandlesNestedSubqueries(t *testing.T) {
 	}
 }
 
+// TestParser_AcceptsUncorrelatedSubquery verifies that a subquery reading
+// only its own tables is not mistaken for a correlated one, even when its
+// columns are qualified like those of the outer query.
+// Green-Flag: An uncorrelated IN (SELECT ...) MUST still parse.
+func TestParser_AcceptsUncorrelatedSubquery(t *testing.T) {
+	parser := sql.NewParser()
+	query := `SELECT o.id FROM orders o
+			  WHERE o.customer_id IN (SELECT o.customer_id FROM returns o WHERE o.reason = 'damaged')
+			  AND o.region_id IN (SELECT r.id FROM regions r WHERE r.country = 'US')`
+
+	if _, err := parser.Parse(query); err != nil {
+		t.Fatalf("expected uncorrelated subquery to parse, got error: %v", err)
+	}
+}
+
 // TestParser_HandlesUNION verifies UNION queries extract all tables.
 // This is a Green-Flag test: all SELECT branches should be parsed.
 func TestParser_HandlesUNION(t *testing.T) {
@@ -389,3 +406,335 @@ func TestParser_DeterministicTableOrder(t *testing.T) {
 		}
 	}
 }
+
+// TestParser_ExtractsColumnsPerTable verifies column attribution across a join.
+// Green-Flag: Qualified columns MUST be attributed to the aliased table.
+func TestParser_ExtractsColumnsPerTable(t *testing.T) {
+	parser := sql.NewParser()
+	query := `SELECT o.id, c.name, SUM(o.total) AS spend
+		FROM sales.orders o
+		JOIN crm.customers c ON o.customer_id = c.id
+		WHERE c.region = 'EU'
+		GROUP BY o.id, c.name
+		ORDER BY spend DESC`
+
+	result, err := parser.Parse(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"sales.orders":  {"id", "total", "customer_id"},
+		"crm.customers": {"name", "id", "region"},
+	}
+	for table, cols := range expected {
+		got := result.Columns[table]
+		if strings.Join(got, ",") != strings.Join(cols, ",") {
+			t.Errorf("columns for %s: expected %v, got %v", table, cols, got)
+		}
+	}
+	if unresolved, ok := result.Columns[sql.UnresolvedColumnsKey]; ok {
+		t.Errorf("expected no unresolved columns, got %v", unresolved)
+	}
+}
+
+// TestParser_ExtractsStarColumns verifies wildcard attribution.
+// Green-Flag: SELECT * MUST mark every joined table, t.* only its own table.
+func TestParser_ExtractsStarColumns(t *testing.T) {
+	parser := sql.NewParser()
+
+	result, err := parser.Parse("SELECT * FROM sales.orders o JOIN crm.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, table := range []string{"sales.orders", "crm.customers"} {
+		if cols := result.Columns[table]; len(cols) == 0 || cols[0] != sql.StarColumn {
+			t.Errorf("expected %s to include *, got %v", table, cols)
+		}
+	}
+
+	result, err = parser.Parse("SELECT c.*, amount FROM crm.customers c JOIN sales.orders o ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cols := result.Columns["crm.customers"]; strings.Join(cols, ",") != "*,id" {
+		t.Errorf("expected crm.customers columns [* id], got %v", cols)
+	}
+	if cols := result.Columns["sales.orders"]; strings.Join(cols, ",") != "customer_id" {
+		t.Errorf("expected sales.orders columns [customer_id], got %v", cols)
+	}
+	if cols := result.Columns[sql.UnresolvedColumnsKey]; strings.Join(cols, ",") != "amount" {
+		t.Errorf("expected unresolved [amount], got %v", cols)
+	}
+}
+
+// TestParser_ExtractsUnqualifiedColumns verifies single-table attribution.
+// Green-Flag: Unqualified columns with one table in scope MUST belong to that table.
+func TestParser_ExtractsUnqualifiedColumns(t *testing.T) {
+	parser := sql.NewParser()
+
+	result, err := parser.Parse("SELECT id, name FROM analytics.users WHERE active = true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cols := result.Columns["analytics.users"]; strings.Join(cols, ",") != "id,name,active" {
+		t.Errorf("expected [id name active], got %v", cols)
+	}
+}
+
+// TestParser_ExtractsColumnsNamedLikeAliases verifies alias handling.
+// Green-Flag: A column named like an earlier SELECT alias MUST belong to its
+// table outside HAVING and ORDER BY, where the name refers to the alias.
+func TestParser_ExtractsColumnsNamedLikeAliases(t *testing.T) {
+	parser := sql.NewParser()
+
+	result, err := parser.Parse("SELECT x AS id, id FROM analytics.users WHERE id > 0 ORDER BY id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cols := result.Columns["analytics.users"]; strings.Join(cols, ",") != "x,id" {
+		t.Errorf("expected [x id], got %v", cols)
+	}
+
+	result, err = parser.Parse("SELECT region, COUNT(*) AS total FROM analytics.users GROUP BY region HAVING total > 1 ORDER BY total")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cols := result.Columns["analytics.users"]; strings.Join(cols, ",") != "region" {
+		t.Errorf("expected [region], got %v", cols)
+	}
+}
+
+// TestParser_ExtractsJoinConditions verifies join condition extraction.
+// Green-Flag: Every column equality of a compound, parenthesized or
+// multi-line ON clause MUST be extracted, and comparisons through function
+// calls MUST be skipped.
+func TestParser_ExtractsJoinConditions(t *testing.T) {
+	parser := sql.NewParser()
+	query := `SELECT o.id
+		FROM sales.orders o
+		LEFT JOIN crm.customers c
+		  ON (o.customer_id = c.id
+		      AND o.region = c.region)
+		JOIN crm.accounts a ON LOWER(a.email) = c.email AND a.customer_id = c.id`
+
+	result, err := parser.Parse(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, cond := range result.JoinConditions {
+		got = append(got, cond.Type+" "+cond.LeftTable+"."+cond.LeftColumn+" = "+cond.RightTable+"."+cond.RightColumn)
+	}
+	expected := []string{
+		"INNER a.customer_id = c.id",
+		"LEFT o.customer_id = c.id",
+		"LEFT o.region = c.region",
+	}
+	if strings.Join(got, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("expected join conditions %v, got %v", expected, got)
+	}
+}
+
+// TestParser_ExtractsColumnPredicates verifies single-column predicate
+// extraction.
+// Green-Flag: Comparisons, IN lists and BETWEEN ranges of a column with
+// literals MUST be extracted with standard SQL literals, a literal compared
+// with a column MUST be turned around, and predicates that are not
+// comparisons with literals MUST be skipped.
+func TestParser_ExtractsColumnPredicates(t *testing.T) {
+	parser := sql.NewParser()
+	query := `SELECT o.id FROM sales.orders o JOIN crm.customers c ON o.customer_id = c.id AND c.active = 1
+		WHERE 100 < o.total
+		  AND o.status IN ('new', 'o''brien')
+		  AND o.placed BETWEEN '2024-01-01' AND '2024-12-31'
+		  AND (o.total > c.credit OR o.status = 'vip')
+		  AND UPPER(c.name) = 'BOB'`
+
+	result, err := parser.Parse(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, pred := range result.ColumnPredicates {
+		got = append(got, pred.SQL)
+	}
+	expected := []string{
+		"o.total > 100",
+		"o.status IN ('new', 'o''brien')",
+		"o.placed BETWEEN '2024-01-01' AND '2024-12-31'",
+		"c.active = 1",
+	}
+	if strings.Join(got, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("expected predicates %v, got %v", expected, got)
+	}
+	if len(result.ColumnPredicates) > 1 && strings.Join(result.ColumnPredicates[1].Values, ",") != "'new','o''brien'" {
+		t.Errorf("expected IN values ['new' 'o''brien'], got %v", result.ColumnPredicates[1].Values)
+	}
+}
+
+// TestParser_ValidateScriptReportsEachStatement verifies that a script is
+// validated statement by statement.
+// Green-Flag: A two-statement script MUST yield two verdicts, each with its own reason.
+func TestParser_ValidateScriptReportsEachStatement(t *testing.T) {
+	parser := sql.NewParser()
+
+	verdicts, err := parser.ValidateScript("SELECT id, ';' AS sep FROM users;\nDELETE FROM orders WHERE id = 1;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(verdicts) != 2 {
+		t.Fatalf("expected 2 verdicts, got %d: %+v", len(verdicts), verdicts)
+	}
+
+	if !verdicts[0].Valid || verdicts[0].Index != 1 || verdicts[0].SQL != "SELECT id, ';' AS sep FROM users" {
+		t.Errorf("expected statement 1 to be a valid SELECT, got %+v", verdicts[0])
+	}
+	if verdicts[1].Valid || verdicts[1].Index != 2 {
+		t.Errorf("expected statement 2 to be invalid, got %+v", verdicts[1])
+	}
+	if verdicts[1].Err == nil || !strings.Contains(verdicts[1].Err.Error(), "DELETE") {
+		t.Errorf("expected statement 2 to be rejected as a DELETE, got %v", verdicts[1].Err)
+	}
+}
+
+// TestParser_ExtractsLimitAndOffset verifies that each form of the LIMIT
+// clause is parsed into the row count and offset of the logical plan.
+// Green-Flag: LIMIT n, LIMIT n OFFSET m and LIMIT m, n MUST all set Limit,
+// and Offset when one is given.
+func TestParser_ExtractsLimitAndOffset(t *testing.T) {
+	parser := sql.NewParser()
+
+	tests := []struct {
+		query  string
+		limit  string
+		offset string
+	}{
+		{"SELECT id FROM users", "<nil>", "<nil>"},
+		{"SELECT id FROM users LIMIT 10", "10", "<nil>"},
+		{"SELECT id FROM users LIMIT 10 OFFSET 20", "10", "20"},
+		{"SELECT id FROM users LIMIT 20, 10", "10", "20"},
+		{"SELECT id FROM users UNION SELECT id FROM admins LIMIT 5 OFFSET 1", "5", "1"},
+	}
+	format := func(n *int) string {
+		if n == nil {
+			return "<nil>"
+		}
+		return strconv.Itoa(*n)
+	}
+
+	for _, tt := range tests {
+		result, err := parser.Parse(tt.query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.query, err)
+		}
+		if got := format(result.Limit); got != tt.limit {
+			t.Errorf("%s: expected limit %s, got %s", tt.query, tt.limit, got)
+		}
+		if got := format(result.Offset); got != tt.offset {
+			t.Errorf("%s: expected offset %s, got %s", tt.query, tt.offset, got)
+		}
+	}
+}
+
+// TestParser_ExtractsGroupByAndHaving verifies that the GROUP BY and HAVING
+// clauses are parsed into the logical plan.
+// Green-Flag: Each GROUP BY item MUST be recorded in order, with ordinals
+// resolved to their SELECT-list expressions, along with the HAVING clause.
+func TestParser_ExtractsGroupByAndHaving(t *testing.T) {
+	parser := sql.NewParser()
+
+	result, err := parser.Parse("SELECT o.region, YEAR(o.placed), COUNT(*) FROM sales.orders o " +
+		"GROUP BY o.region, 2, o.status HAVING COUNT(*) > 10 AND o.region != 'eu'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"o.region", "YEAR(o.placed)", "o.status"}
+	if strings.Join(result.GroupBy, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("expected GROUP BY %v, got %v", expected, result.GroupBy)
+	}
+	if result.Having != "COUNT(*) > 10 and o.region != 'eu'" {
+		t.Errorf("expected HAVING clause, got %q", result.Having)
+	}
+
+	plain, err := parser.Parse("SELECT id FROM users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plain.GroupBy) != 0 || plain.Having != "" {
+		t.Errorf("expected no GROUP BY or HAVING, got %v, %q", plain.GroupBy, plain.Having)
+	}
+}
+
+// TestParser_TagsSetOperations verifies that set operations are parsed with
+// their kind and operands.
+// Green-Flag: UNION, INTERSECT and EXCEPT MUST parse as SELECTs tagged with
+// their set operation, and a SELECT MUST carry none.
+func TestParser_TagsSetOperations(t *testing.T) {
+	parser := sql.NewParser()
+
+	tests := map[string]string{
+		"SELECT id FROM users":                                       "",
+		"SELECT id FROM users UNION SELECT id FROM admins":           sql.SetOperationUnion,
+		"SELECT id FROM users UNION ALL SELECT id FROM admins":       sql.SetOperationUnionAll,
+		"SELECT id FROM users INTERSECT SELECT id FROM admins":       sql.SetOperationIntersect,
+		"SELECT id FROM users EXCEPT SELECT id FROM admins":          sql.SetOperationExcept,
+		"SELECT id FROM users EXCEPT DISTINCT SELECT id FROM admins": sql.SetOperationExcept,
+	}
+	for query, expected := range tests {
+		result, err := parser.Parse(query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", query, err)
+		}
+		if result.Operation != capabilities.OperationSelect {
+			t.Errorf("%s: expected SELECT operation, got %s", query, result.Operation)
+		}
+		if result.SetOperation != expected {
+			t.Errorf("%s: expected set operation %q, got %q", query, expected, result.SetOperation)
+		}
+	}
+
+	result, err := parser.Parse("SELECT u.id FROM users u INTERSECT SELECT a.id FROM admins a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.SetOperands) != 2 || !strings.Contains(result.SetOperands[0], "users") || !strings.Contains(result.SetOperands[1], "admins") {
+		t.Errorf("expected the users and admins operands, got %v", result.SetOperands)
+	}
+}
+
+// TestParser_FingerprintIgnoresLiteralsAndFormatting verifies that query
+// fingerprints group queries of the same shape.
+// Green-Flag: Queries differing only in literal values, IN list length,
+// comments, whitespace or keyword and identifier case MUST share a
+// fingerprint.
+func TestParser_FingerprintIgnoresLiteralsAndFormatting(t *testing.T) {
+	parser := sql.NewParser()
+
+	base := "SELECT o.id, o.amount FROM orders o WHERE o.region = 'eu' AND o.amount > 10 AND o.status IN (1, 2) LIMIT 5"
+	variants := []string{
+		"SELECT o.id, o.amount FROM orders o WHERE o.region = 'us' AND o.amount > 250.5 AND o.status IN (3) LIMIT 100",
+		"select O.ID, o.Amount\n\tfrom Orders o\n\twhere o.region = 'eu'   and o.amount > 10 and o.status in (1, 2, 3, 4) limit 5",
+		"SELECT /* dashboard */ o.id, o.amount FROM orders o WHERE o.region = 'eu' AND o.amount > 10 AND o.status IN (1, 2) LIMIT 5",
+	}
+
+	expected, err := parser.Fingerprint(base)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	if expected == "" {
+		t.Fatal("expected a non-empty fingerprint")
+	}
+	for _, query := range variants {
+		fingerprint, err := parser.Fingerprint(query)
+		if err != nil {
+			t.Fatalf("%s: Fingerprint failed: %v", query, err)
+		}
+		if fingerprint != expected {
+			t.Errorf("%s: expected fingerprint %s, got %s", query, expected, fingerprint)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package greenflag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// partitionedCatalog is a catalog whose tables are all partitioned by
+// event_date into 5000 partitions.
+type partitionedCatalog struct{}
+
+func (partitionedCatalog) Name() string                                        { return "mock" }
+func (partitionedCatalog) ListDatabases(ctx context.Context) ([]string, error) { return nil, nil }
+func (partitionedCatalog) ListTables(ctx context.Context, database string) ([]catalog.TableInfo, error) {
+	return nil, nil
+}
+func (partitionedCatalog) CheckConnectivity(ctx context.Context) error { return nil }
+func (partitionedCatalog) Close() error                                { return nil }
+func (partitionedCatalog) GetTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	return &catalog.TableMetadata{
+		Database:       database,
+		Name:           table,
+		Format:         catalog.FormatParquet,
+		Partitions:     []string{"event_date"},
+		PartitionCount: 5000,
+	}, nil
+}
+
+// newPartitionGuardPlanner builds a planner over a partitioned table with the
+// partition guard limited to 100 partitions.
+func newPartitionGuardPlanner(action planner.PartitionGuardAction) *planner.Planner {
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     1,
+	})
+	vt := &tables.VirtualTable{
+		Name:         "analytics.events",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Sources: []tables.PhysicalSource{{
+			Engine:   "duckdb",
+			Location: "s3://bucket/events",
+			Format:   tables.FormatParquet,
+		}},
+	}
+	p := planner.NewPlanner(schemaTestRegistry{vt.Name: vt}, r)
+	p.SetPartitionGuard(&planner.PartitionGuard{
+		Resolver:      &planner.CatalogPartitionResolver{Catalog: partitionedCatalog{}},
+		MaxPartitions: 100,
+		Action:        action,
+	})
+	return p
+}
+
+// TestPartitionGuard_AllowsPartitionFilteredQuery proves that queries with a
+// predicate on the partition column pass the guard.
+// Green-Flag: Partition-filtered queries MUST be planned without warnings.
+func TestPartitionGuard_AllowsPartitionFilteredQuery(t *testing.T) {
+	p := newPartitionGuardPlanner(planner.PartitionGuardReject)
+
+	queries := []string{
+		"SELECT id FROM analytics.events WHERE event_date = '2026-01-01'",
+		"SELECT e.id FROM analytics.events e WHERE e.event_date >= '2026-01-01' AND e.id > 10",
+		"SELECT id FROM analytics.events WHERE id IN (SELECT id FROM analytics.events WHERE event_date = '2026-01-01') AND event_date > '2025-12-01'",
+	}
+	for _, query := range queries {
+		logical, err := sql.NewParser().Parse(query)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", query, err)
+		}
+		plan, err := p.Plan(context.Background(), logical)
+		if err != nil {
+			t.Errorf("%q: expected the query to pass the partition guard, got %v", query, err)
+			continue
+		}
+		if len(plan.Warnings) != 0 {
+			t.Errorf("%q: expected no warnings, got %v", query, plan.Warnings)
+		}
+	}
+}
+
+// TestPartitionGuard_WarnModeRunsUnfilteredQuery proves that in warn mode an
+// unfiltered query is planned and carries a warning suggesting a filter.
+// Green-Flag: Warn mode MUST flag the query without rejecting it.
+func TestPartitionGuard_WarnModeRunsUnfilteredQuery(t *testing.T) {
+	p := newPartitionGuardPlanner(planner.PartitionGuardWarn)
+
+	logical, err := sql.NewParser().Parse("SELECT id FROM analytics.events")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	plan, err := p.Plan(context.Background(), logical)
+	if err != nil {
+		t.Fatalf("expected warn mode to plan the query, got %v", err)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "event_date") {
+		t.Errorf("expected a warning naming event_date, got %v", plan.Warnings)
+	}
+}
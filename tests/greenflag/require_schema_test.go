@@ -0,0 +1,85 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// schemaTestRegistry is a minimal planner.TableRegistry for require-schema tests.
+type schemaTestRegistry map[string]*tables.VirtualTable
+
+func (r schemaTestRegistry) GetTable(_ context.Context, name string) (*tables.VirtualTable, error) {
+	vt, ok := r[name]
+	if !ok {
+		return nil, errors.NewTableNotFound(name)
+	}
+	return vt, nil
+}
+
+// planSchemaTestQuery plans a simple SELECT against vt.
+func planSchemaTestQuery(t *testing.T, vt *tables.VirtualTable, requireSchema bool) error {
+	t.Helper()
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     1,
+	})
+	p := planner.NewPlanner(schemaTestRegistry{vt.Name: vt}, r)
+	p.SetRequireSchema(requireSchema)
+
+	plan, err := sql.NewParser().Parse("SELECT id FROM analytics.events")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	_, err = p.Plan(context.Background(), plan)
+	return err
+}
+
+func schemaTestTable(columns ...tables.Column) *tables.VirtualTable {
+	return &tables.VirtualTable{
+		Name:         "analytics.events",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Sources: []tables.PhysicalSource{{
+			Engine:   "duckdb",
+			Location: "s3://bucket/events",
+			Format:   tables.FormatParquet,
+		}},
+		Columns: columns,
+	}
+}
+
+// TestRequireSchema_PolicyOffAllowsSchemaLessTable proves that schema-less
+// tables remain queryable when the policy is off.
+//
+// Green-Flag: Without the policy, tables MUST NOT need a synced schema.
+func TestRequireSchema_PolicyOffAllowsSchemaLessTable(t *testing.T) {
+	if err := planSchemaTestQuery(t, schemaTestTable(), false); err != nil {
+		t.Fatalf("expected schema-less table to be allowed, got: %v", err)
+	}
+}
+
+// TestRequireSchema_PolicyOnAllowsSyncedTable proves that tables with a
+// synced column schema pass the policy.
+//
+// Green-Flag: Tables with columns MUST be queryable when schemas are required.
+func TestRequireSchema_PolicyOnAllowsSyncedTable(t *testing.T) {
+	vt := schemaTestTable(
+		tables.Column{Name: "id", Type: "BIGINT"},
+		tables.Column{Name: "ts", Type: "TIMESTAMP", Nullable: true},
+	)
+	if err := vt.Validate(); err != nil {
+		t.Fatalf("expected valid table, got: %v", err)
+	}
+	if err := planSchemaTestQuery(t, vt, true); err != nil {
+		t.Fatalf("expected synced table to be allowed, got: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/federation"
+)
+
+// drainChecksum reads a stream to the end and returns its checksum.
+func drainChecksum(t *testing.T, stream federation.ResultStream) string {
+	t.Helper()
+	cs := federation.NewChecksumStream(stream)
+	for {
+		row, err := cs.Next(context.Background())
+		if err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	sum, ok := cs.Checksum()
+	if !ok {
+		t.Fatal("expected checksum after exhausting the stream")
+	}
+	return sum
+}
+
+// TestResultChecksum_IdenticalDataAcrossEngines proves that the same data
+// served by two engines yields the same checksum, even when the engines
+// return rows in different orders and with different Go types.
+//
+// Green-Flag: Identical results from different engines MUST have identical checksums.
+func TestResultChecksum_IdenticalDataAcrossEngines(t *testing.T) {
+	// Trino-style result: int64 and float64
+	trino := federation.NewSliceStream([]federation.Row{
+		{"id": int64(1), "amount": float64(10), "region": "eu"},
+		{"id": int64(2), "amount": 12.5, "region": "us"},
+		{"id": int64(3), "amount": nil, "region": "us"},
+	}, &federation.ResultSchema{})
+
+	// DuckDB-style result: int32, integral amount as int, different order
+	duckdb := federation.NewSliceStream([]federation.Row{
+		{"ID": int32(3), "AMOUNT": nil, "REGION": "us"},
+		{"ID": int32(1), "AMOUNT": int32(10), "REGION": "eu"},
+		{"ID": int32(2), "AMOUNT": 12.5, "REGION": "us"},
+	}, &federation.ResultSchema{})
+
+	trinoSum := drainChecksum(t, trino)
+	duckdbSum := drainChecksum(t, duckdb)
+	if trinoSum != duckdbSum {
+		t.Errorf("expected identical checksums, got %s and %s", trinoSum, duckdbSum)
+	}
+}
+
+// TestResultChecksum_MetadataMatchesStream proves that the checksum attached
+// to a buffered QueryResult matches the streamed checksum of the same rows.
+//
+// Green-Flag: Buffered and streamed checksums of the same rows MUST match.
+func TestResultChecksum_MetadataMatchesStream(t *testing.T) {
+	result := &adapters.QueryResult{
+		Columns: []string{"id", "name"},
+		Rows: [][]interface{}{
+			{1, "alice"},
+			{2, "bob"},
+		},
+		RowCount: 2,
+	}
+	result.AttachChecksum()
+
+	streamed := drainChecksum(t, federation.NewQueryResultStream(result))
+	if got := result.Metadata[adapters.MetadataResultChecksum]; got != streamed {
+		t.Errorf("expected metadata checksum %s to equal streamed checksum %s", got, streamed)
+	}
+}
@@ -0,0 +1,66 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/bootstrap"
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// TestSafeMode_AllowsGovernedSingleEngineQuery proves that safe mode does not
+// get in the way of queries on governed table formats.
+//
+// Green-Flag: A single-engine query on an Iceberg table MUST be planned on
+// the allowed engine in safe mode.
+func TestSafeMode_AllowsGovernedSingleEngineQuery(t *testing.T) {
+	cfg := &bootstrap.Config{
+		Engines: map[string]bootstrap.EngineConfig{
+			"trino": {Enabled: true, Endpoint: "http://trino:8080"},
+		},
+		Tables: map[string]bootstrap.TableConfig{
+			"sales.orders": {Sources: []bootstrap.SourceConfig{{
+				Engine: "trino", Format: "iceberg", Location: "s3://bucket/orders",
+			}}},
+		},
+		SafeMode: bootstrap.SafeModeConfig{Enabled: true, AllowedEngines: []string{"trino"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected governed table to pass safe mode validation, got: %v", err)
+	}
+
+	vt := &tables.VirtualTable{
+		Name:         "sales.orders",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Sources: []tables.PhysicalSource{{
+			Engine:   "trino",
+			Format:   tables.FormatIceberg,
+			Location: "s3://bucket/orders",
+		}},
+	}
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     1,
+	})
+	p := planner.NewPlanner(schemaTestRegistry{vt.Name: vt}, r)
+	p.SetSafeMode(cfg.SafeModePolicy())
+
+	logical, err := sql.NewParser().Parse("SELECT id FROM sales.orders")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	plan, err := p.Plan(context.Background(), logical)
+	if err != nil {
+		t.Fatalf("expected query to be planned in safe mode, got: %v", err)
+	}
+	if plan.Engine != "trino" {
+		t.Errorf("expected engine trino, got %s", plan.Engine)
+	}
+}
@@ -257,3 +257,62 @@ func TestSnapshotConsistent_AcceptsSameTimestampPerTable(t *testing.T) {
 		t.Fatal("expected non-nil execution plan")
 	}
 }
+
+// planTimeTravelJoin plans a join of orders and customers, two time-travel
+// tables without SNAPSHOT_CONSISTENT, both with the given constraints.
+func planTimeTravelJoin(t *testing.T, query string, constraints ...capabilities.Constraint) error {
+	t.Helper()
+	registry := gateway.NewInMemoryTableRegistry()
+	for _, name := range []string{"orders", "customers"} {
+		registry.Register(&tables.VirtualTable{
+			Name:         name,
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+			Constraints:  constraints,
+			Sources: []tables.PhysicalSource{{
+				Engine:   "iceberg-trino",
+				Location: "catalog.schema." + name,
+				Format:   "iceberg",
+			}},
+		})
+	}
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "iceberg-trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     1,
+	})
+
+	plan, err := sql.NewParser().Parse(query)
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	_, err = planner.NewPlanner(registry, r).Plan(context.Background(), plan)
+	return err
+}
+
+// TestSnapshotConsistent_AcceptsMatchingTimestamps proves that tables read
+// AS OF the same timestamp are consistent, however it is written.
+//
+// Green-Flag: Two tables read AS OF the same instant MUST be planned.
+func TestSnapshotConsistent_AcceptsMatchingTimestamps(t *testing.T) {
+	err := planTimeTravelJoin(t, "SELECT * FROM orders FOR SYSTEM_TIME AS OF '2024-01-01' "+
+		"JOIN customers FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z' ON orders.customer_id = customers.id")
+	if err != nil {
+		t.Fatalf("expected matching timestamps to be planned, got: %v", err)
+	}
+}
+
+// TestSnapshotConsistent_AllowsMixedSnapshots proves that the
+// MIXED_SNAPSHOTS constraint opts tables out of the same-timestamp rule.
+//
+// Green-Flag: Tables with MIXED_SNAPSHOTS MUST be planned when read AS OF
+// different timestamps.
+func TestSnapshotConsistent_AllowsMixedSnapshots(t *testing.T) {
+	err := planTimeTravelJoin(t, "SELECT * FROM orders FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z' "+
+		"JOIN customers FOR SYSTEM_TIME AS OF '2024-01-02T00:00:00Z' ON orders.customer_id = customers.id",
+		capabilities.ConstraintMixedSnapshots)
+	if err != nil {
+		t.Fatalf("expected mixed snapshots to be allowed, got: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package greenflag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// fakeSnapshotResolver returns fixed latest snapshots per table.
+type fakeSnapshotResolver map[string]time.Time
+
+func (r fakeSnapshotResolver) LatestSnapshot(_ context.Context, vt *tables.VirtualTable) (time.Time, error) {
+	ts, ok := r[vt.Name]
+	if !ok {
+		return time.Time{}, fmt.Errorf("no snapshot for %s", vt.Name)
+	}
+	return ts, nil
+}
+
+// snapshotPinTable builds a readable table on the given format.
+func snapshotPinTable(name string, format tables.StorageFormat, constraints ...capabilities.Constraint) *tables.VirtualTable {
+	caps := []capabilities.Capability{capabilities.CapabilityRead}
+	if format != tables.FormatParquet {
+		caps = append(caps, capabilities.CapabilityTimeTravel)
+	}
+	return &tables.VirtualTable{
+		Name:         name,
+		Capabilities: caps,
+		Constraints:  constraints,
+		Sources: []tables.PhysicalSource{{
+			Engine:   "trino",
+			Location: "s3://bucket/" + name,
+			Format:   format,
+		}},
+	}
+}
+
+// newSnapshotPinPlanner builds a planner over the given tables and resolver.
+func newSnapshotPinPlanner(resolver planner.SnapshotResolver, vts ...*tables.VirtualTable) *planner.Planner {
+	registry := schemaTestRegistry{}
+	for _, vt := range vts {
+		registry[vt.Name] = vt
+	}
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     1,
+	})
+	p := planner.NewPlanner(registry, r)
+	p.SetSnapshotResolver(resolver)
+	return p
+}
+
+// TestSnapshotPin_InjectsMatchingPinsAcrossTables proves that pinning reads
+// every table as of the same snapshot: the latest one all tables have reached.
+//
+// Green-Flag: Pinned SNAPSHOT_CONSISTENT tables MUST share one AS OF timestamp.
+func TestSnapshotPin_InjectsMatchingPinsAcrossTables(t *testing.T) {
+	consistent := capabilities.ConstraintSnapshotConsistent
+	orders := snapshotPinTable("sales.orders", tables.FormatDelta, consistent)
+	customers := snapshotPinTable("sales.customers", tables.FormatIceberg, consistent)
+
+	resolver := fakeSnapshotResolver{
+		"sales.orders":    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		"sales.customers": time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+	}
+	p := newSnapshotPinPlanner(resolver, orders, customers)
+
+	logical, err := sql.NewParser().Parse(
+		"SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	logical.PinSnapshots = true
+
+	plan, err := p.Plan(context.Background(), logical)
+	if err != nil {
+		t.Fatalf("expected pinned query to plan, got: %v", err)
+	}
+
+	pins := plan.LogicalPlan.TimeTravelPerTable
+	want := "'2026-03-01 09:30:00'"
+	for _, name := range []string{"sales.orders", "sales.customers"} {
+		if pins[name] != want {
+			t.Errorf("expected %s pinned AS OF %s, got %q (all pins: %v)", name, want, pins[name], pins)
+		}
+	}
+	if !plan.LogicalPlan.HasTimeTravel {
+		t.Error("expected pinned plan to use time travel")
+	}
+	if strings.Count(strings.ToLower(plan.LogicalPlan.RawSQL), "as of") != 2 {
+		t.Errorf("expected AS OF injected for both tables, got SQL: %s", plan.LogicalPlan.RawSQL)
+	}
+}
+
+// TestSnapshotPin_DegradesForTablesWithoutSnapshots proves that tables on
+// formats without snapshots are left unpinned instead of failing the query.
+//
+// Green-Flag: Non-snapshot tables MUST still be queryable in pinning mode.
+func TestSnapshotPin_DegradesForTablesWithoutSnapshots(t *testing.T) {
+	orders := snapshotPinTable("sales.orders", tables.FormatDelta)
+	raw := snapshotPinTable("sales.raw_events", tables.FormatParquet)
+
+	resolver := fakeSnapshotResolver{
+		"sales.orders": time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	p := newSnapshotPinPlanner(resolver, orders, raw)
+
+	logical, err := sql.NewParser().Parse(
+		"SELECT o.id FROM sales.orders o JOIN sales.raw_events e ON o.id = e.order_id")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	logical.PinSnapshots = true
+
+	plan, err := p.Plan(context.Background(), logical)
+	if err != nil {
+		t.Fatalf("expected query to plan with partial pinning, got: %v", err)
+	}
+
+	pins := plan.LogicalPlan.TimeTravelPerTable
+	if pins["sales.orders"] != "'2026-03-01 12:00:00'" {
+		t.Errorf("expected sales.orders to be pinned, got pins %v", pins)
+	}
+	if _, ok := pins["sales.raw_events"]; ok {
+		t.Errorf("expected parquet table to stay unpinned, got pins %v", pins)
+	}
+}
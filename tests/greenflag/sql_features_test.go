@@ -0,0 +1,73 @@
+package greenflag
+
+import (
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// TestSQLFeatures_MatchParserAllowlist proves that every reported feature
+// behaves as reported: supported examples parse, unsupported ones are rejected.
+//
+// Green-Flag: The reported SQL surface MUST match what the parser enforces.
+func TestSQLFeatures_MatchParserAllowlist(t *testing.T) {
+	parser := sql.NewParser()
+	features := parser.Features()
+
+	if len(features.Features) == 0 {
+		t.Fatal("expected reported features")
+	}
+	for _, f := range features.Features {
+		_, err := parser.Parse(f.Example)
+		if f.Supported && err != nil {
+			t.Errorf("%s reported supported, but its example was rejected: %v", f.Feature, err)
+		}
+		if !f.Supported && err == nil {
+			t.Errorf("%s reported unsupported, but its example was accepted: %s", f.Feature, f.Example)
+		}
+	}
+}
+
+// TestSQLFeatures_ReportsReadOnlyOperations proves that the reported
+// operations are exactly the statement types the parser accepts.
+//
+// Green-Flag: Only SELECT MUST be reported while the gateway is read-only.
+func TestSQLFeatures_ReportsReadOnlyOperations(t *testing.T) {
+	features := sql.NewParser().Features()
+
+	if len(features.Operations) != 1 || features.Operations[0] != string(capabilities.OperationSelect) {
+		t.Errorf("expected operations [SELECT], got %v", features.Operations)
+	}
+	if len(features.Limitations) == 0 {
+		t.Error("expected limitations for unsupported features")
+	}
+}
+
+// TestSQLFeatures_ReportsPerEngineSupport proves that engine-dependent
+// features follow the engine capability matrix.
+//
+// Green-Flag: Per-engine support MUST reflect engine capabilities.
+func TestSQLFeatures_ReportsPerEngineSupport(t *testing.T) {
+	features := sql.NewParser().Features()
+
+	for engine, caps := range capabilities.EngineCapabilities {
+		support, ok := features.Engines[engine]
+		if !ok {
+			t.Errorf("expected features for engine %s", engine)
+			continue
+		}
+		hasTimeTravel := false
+		for _, c := range caps {
+			if c == capabilities.CapabilityTimeTravel {
+				hasTimeTravel = true
+			}
+		}
+		if support[sql.FeatureTimeTravel] != hasTimeTravel {
+			t.Errorf("engine %s: expected TIME_TRAVEL=%v, got %v", engine, hasTimeTravel, support[sql.FeatureTimeTravel])
+		}
+		if support[sql.FeatureWindowFunction] {
+			t.Errorf("engine %s: window functions are rejected by the parser and must not be reported", engine)
+		}
+	}
+}
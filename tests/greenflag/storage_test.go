@@ -6,7 +6,9 @@ package greenflag
 
 import (
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/storage"
@@ -343,3 +345,74 @@ func TestStorage_ExistsByName(t *testing.T) {
 func TestStorage_RepositoryInterface(t *testing.T) {
 	var _ storage.TableRepository = storage.NewMockRepository()
 }
+
+// openVirtualTablesDB opens an in-memory database holding the named virtual
+// tables, standing in for a PostgreSQL primary or replica.
+func openVirtualTablesDB(t *testing.T, names ...string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE virtual_tables (name TEXT PRIMARY KEY)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for _, name := range names {
+		if _, err := db.Exec(`INSERT INTO virtual_tables (name) VALUES ($1)`, name); err != nil {
+			t.Fatalf("failed to insert %s: %v", name, err)
+		}
+	}
+	return db
+}
+
+// TestStorage_ReadReplicaServesReads verifies that repository reads use the
+// replica, writes use the primary, and tables written within the lag window
+// are read from the primary.
+//
+// Green-Flag: Reads must go to the replica except for recently written tables.
+func TestStorage_ReadReplicaServesReads(t *testing.T) {
+	primary := openVirtualTablesDB(t, "crm.customers")
+	// Only the replica knows sales.orders, so reading it proves the replica
+	// served the read
+	replica := openVirtualTablesDB(t, "crm.customers", "sales.orders")
+
+	readReplica, err := storage.NewReadReplica(primary, replica, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create read replica: %v", err)
+	}
+	repo := storage.NewPostgresRepository(primary)
+	repo.SetReadReplica(readReplica)
+	ctx := context.Background()
+
+	if exists, err := repo.Exists(ctx, "sales.orders"); err != nil || !exists {
+		t.Fatalf("expected sales.orders to be read from the replica, got %v (err %v)", exists, err)
+	}
+
+	// The delete goes to the primary only
+	if err := repo.Delete(ctx, "crm.customers"); err != nil {
+		t.Fatalf("failed to delete table: %v", err)
+	}
+	var remaining int
+	primary.QueryRow(`SELECT COUNT(*) FROM virtual_tables`).Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected the delete on the primary, %d rows remain", remaining)
+	}
+
+	// Within the lag window the deleted table is read from the primary
+	if exists, err := repo.Exists(ctx, "crm.customers"); err != nil || exists {
+		t.Errorf("expected the recently deleted table to be gone, got %v (err %v)", exists, err)
+	}
+	if exists, _ := repo.Exists(ctx, "sales.orders"); !exists {
+		t.Error("expected tables not written recently to be read from the replica")
+	}
+
+	// After the window the replica serves the table again (it has not
+	// replicated the delete in this test)
+	time.Sleep(150 * time.Millisecond)
+	if exists, _ := repo.Exists(ctx, "crm.customers"); !exists {
+		t.Error("expected reads to return to the replica after the lag window")
+	}
+}
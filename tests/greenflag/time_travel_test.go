@@ -1,11 +1,17 @@
 package greenflag
 
 import (
+	"context"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
 	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
 )
 
 // TestIcebergTimeTravelTrino proves SYSTEM_TIME translation for Iceberg/Trino.
@@ -272,6 +278,24 @@ func TestWarehouseTimeTravelBigQuery(t *testing.T) {
 	}
 }
 
+// TestWarehouseTimeTravelRedshiftSpectrum proves time travel for Iceberg
+// tables read through Redshift Spectrum.
+//
+// Green-Flag: SYSTEM_TIME AS OF on a Spectrum Iceberg table MUST be translated.
+func TestWarehouseTimeTravelRedshiftSpectrum(t *testing.T) {
+	rewriter := sql.NewWarehouseRewriter("redshift")
+	rewriter.SetTableFormat("lake.orders", catalog.FormatIceberg)
+
+	result, err := rewriter.Rewrite("SELECT * FROM lake.orders FOR SYSTEM_TIME AS OF '2024-01-01 00:00:00' WHERE id = 1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "SELECT * FROM lake.orders FOR SYSTEM_TIME AS OF TIMESTAMP '2024-01-01 00:00:00' WHERE id = 1"
+	if result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+}
+
 // TestFormatCapabilities proves format capability mapping works.
 //
 // Green-Flag: Each format has correct capabilities.
@@ -353,3 +377,300 @@ func TestEngineSelectorPreference(t *testing.T) {
 		})
 	}
 }
+
+// TestTimeTravel_EngineVersionSelectsSyntax proves that the reported engine
+// version selects the time-travel syntax variant.
+//
+// Green-Flag: Spark before 3.3 MUST get the legacy table-suffix syntax; newer releases the AS OF form.
+func TestTimeTravel_EngineVersionSelectsSyntax(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   catalog.TableFormat
+		engine   string
+		version  string
+		input    string
+		expected string
+	}{
+		{
+			name:     "legacy_spark_delta_version",
+			format:   catalog.FormatDelta,
+			engine:   "spark",
+			version:  "3.2.1",
+			input:    "SELECT * FROM events FOR VERSION AS OF 12",
+			expected: "SELECT * FROM events@v12",
+		},
+		{
+			name:     "legacy_spark_delta_timestamp",
+			format:   catalog.FormatDelta,
+			engine:   "spark",
+			version:  "3.2.1",
+			input:    "SELECT * FROM events FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z'",
+			expected: "SELECT * FROM events@20240101000000000",
+		},
+		{
+			name:     "legacy_spark_iceberg_timestamp",
+			format:   catalog.FormatIceberg,
+			engine:   "spark",
+			version:  "3.1.3",
+			input:    "SELECT * FROM lake.orders FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z' WHERE id = 1",
+			expected: "SELECT * FROM lake.orders.at_timestamp_1704067200000 WHERE id = 1",
+		},
+		{
+			name:     "modern_spark_delta_version",
+			format:   catalog.FormatDelta,
+			engine:   "spark",
+			version:  "3.5.1 fd86f85e181fc2dc0f50a096855acf83a6cc5d9c",
+			input:    "SELECT * FROM events FOR VERSION AS OF 12",
+			expected: "SELECT * FROM events VERSION AS OF 12",
+		},
+		{
+			name:     "modern_trino_iceberg_version",
+			format:   catalog.FormatIceberg,
+			engine:   "trino",
+			version:  "435",
+			input:    "SELECT * FROM orders FOR VERSION AS OF 42",
+			expected: "SELECT * FROM orders FOR VERSION AS OF 42",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := sql.ParseEngineVersion(tc.version)
+			if err != nil {
+				t.Fatalf("failed to parse version: %v", err)
+			}
+			rewriter := sql.NewTimeTravelRewriter(tc.format, tc.engine)
+			rewriter.SetEngineVersion(version)
+
+			result, err := rewriter.Rewrite(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestTimeTravel_BoundTimestampParameter proves that a timestamp bound to
+// FOR SYSTEM_TIME AS OF ? is rewritten like the equivalent literal.
+//
+// Green-Flag: A bound time-travel parameter MUST produce the engine's rewrite.
+func TestTimeTravel_BoundTimestampParameter(t *testing.T) {
+	asOf := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	query := "SELECT * FROM orders FOR SYSTEM_TIME AS OF ? WHERE region = ?"
+
+	testCases := []struct {
+		name     string
+		format   catalog.TableFormat
+		engine   string
+		args     []interface{}
+		expected string
+	}{
+		{
+			name:     "iceberg_trino_time",
+			format:   "iceberg",
+			engine:   "trino",
+			args:     []interface{}{asOf, "EU"},
+			expected: "FOR TIMESTAMP AS OF TIMESTAMP '2024-06-15T12:00:00Z'",
+		},
+		{
+			name:     "iceberg_spark_string",
+			format:   "iceberg",
+			engine:   "spark",
+			args:     []interface{}{"2024-06-15 12:00:00", "EU"},
+			expected: "TIMESTAMP AS OF '2024-06-15 12:00:00'",
+		},
+		{
+			name:     "delta_spark_time",
+			format:   "delta",
+			engine:   "spark",
+			args:     []interface{}{asOf, "EU"},
+			expected: "TIMESTAMP AS OF '2024-06-15T12:00:00Z'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rewriter := sql.NewTimeTravelRewriter(tc.format, tc.engine)
+			result, err := rewriter.RewriteWithParams(query, tc.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(result, tc.expected) {
+				t.Errorf("expected %q in result, got: %s", tc.expected, result)
+			}
+			if strings.Contains(result, "SYSTEM_TIME") {
+				t.Errorf("expected SYSTEM_TIME clause to be rewritten, got: %s", result)
+			}
+			// Placeholders outside time-travel clauses are left for the engine
+			if !strings.HasSuffix(result, "WHERE region = ?") {
+				t.Errorf("expected unrelated placeholder to be kept, got: %s", result)
+			}
+		})
+	}
+}
+
+// TestTimeTravel_BoundVersionParameter proves that VERSION AS OF ? binds a
+// snapshot ID by placeholder position.
+//
+// Green-Flag: A bound version parameter MUST produce the engine's rewrite.
+func TestTimeTravel_BoundVersionParameter(t *testing.T) {
+	rewriter := sql.NewTimeTravelRewriter("iceberg", "trino")
+
+	result, err := rewriter.RewriteWithParams(
+		"SELECT * FROM orders WHERE note = '?' AND id > ? FOR VERSION AS OF ?",
+		[]interface{}{10, int64(12345)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(result, "12345") {
+		t.Errorf("expected bound snapshot ID in result, got: %s", result)
+	}
+	if !strings.Contains(result, "id > ?") {
+		t.Errorf("expected unrelated placeholder to be kept, got: %s", result)
+	}
+}
+
+// TestTimeTravel_ParsesAsOfTimestamps proves that AS OF timestamps are
+// parsed once, at parse time, and compared as instants.
+//
+// Green-Flag: Each AS OF timestamp literal MUST be parsed per table, with
+// its text kept for display, and SNAPSHOT_CONSISTENT tables read at the
+// same instant written in different forms MUST be planned.
+func TestTimeTravel_ParsesAsOfTimestamps(t *testing.T) {
+	parser := sql.NewParser()
+	logical, err := parser.Parse("SELECT o.id FROM sales.orders AS OF '2024-01-01' o " +
+		"JOIN sales.customers AS OF '2024-01-01 00:00:00' c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, table := range []string{"sales.orders", "sales.customers"} {
+		if ts := logical.ParsedTimestamps[table]; !ts.Equal(expected) {
+			t.Errorf("expected %s AS OF %v, got %v", table, expected, ts)
+		}
+	}
+	if raw := logical.TimeTravelPerTable["sales.orders"]; !strings.Contains(raw, "2024-01-01") {
+		t.Errorf("expected the AS OF text to be kept, got %q", raw)
+	}
+
+	snapshotTable := func(name string) *tables.VirtualTable {
+		return &tables.VirtualTable{
+			Name:         name,
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+			Constraints:  []capabilities.Constraint{capabilities.ConstraintSnapshotConsistent},
+			Sources:      []tables.PhysicalSource{{Engine: "trino", Location: "s3://bucket/" + name, Format: tables.FormatIceberg}},
+		}
+	}
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     1,
+	})
+	p := planner.NewPlanner(schemaTestRegistry{
+		"sales.orders":    snapshotTable("sales.orders"),
+		"sales.customers": snapshotTable("sales.customers"),
+	}, r)
+	if _, err := p.Plan(context.Background(), logical); err != nil {
+		t.Errorf("expected the same snapshot in both forms to be planned, got: %v", err)
+	}
+
+	pinned, err := parser.Parse("SELECT * FROM sales.orders FOR VERSION AS OF 42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pinned.ParsedTimestamps["sales.orders"]; ok {
+		t.Error("expected a version pin not to be parsed as a timestamp")
+	}
+}
+
+// TestTimeTravel_ResolvesRelativeTimestamps proves that relative timestamps
+// are resolved against the rewriter's clock.
+//
+// Green-Flag: NOW() - INTERVAL n DAY/HOUR/MINUTE MUST reach the engine as
+// the absolute timestamp it resolves to.
+func TestTimeTravel_ResolvesRelativeTimestamps(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 30, 0, 0, time.UTC)
+	rewriter := sql.NewTimeTravelRewriter(catalog.FormatIceberg, "trino")
+	rewriter.SetClock(func() time.Time { return now })
+
+	tests := map[string]string{
+		"NOW() - INTERVAL '7' DAY":             "2026-03-08 12:30:00",
+		"now() - interval '2' hour":            "2026-03-15 10:30:00",
+		"NOW() - INTERVAL 45 MINUTES":          "2026-03-15 11:45:00",
+		"CURRENT_TIMESTAMP - INTERVAL '1' DAY": "2026-03-14 12:30:00",
+	}
+	for expr, expected := range tests {
+		query := "SELECT id FROM orders FOR SYSTEM_TIME AS OF " + expr + " WHERE amount > 10"
+		result, err := rewriter.Rewrite(query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", expr, err)
+		}
+		want := "SELECT id FROM orders FOR TIMESTAMP AS OF TIMESTAMP '" + expected + "' WHERE amount > 10"
+		if result != want {
+			t.Errorf("%s: expected %q, got %q", expr, want, result)
+		}
+	}
+}
+
+// TestTimeTravel_RewritesIdenticalClausesInPlace proves that identical
+// time-travel clauses on several tables are each rewritten where they occur.
+//
+// Green-Flag: Two tables read AS OF the same literal MUST both be rewritten
+// to the engine's syntax, in place.
+func TestTimeTravel_RewritesIdenticalClausesInPlace(t *testing.T) {
+	input := "SELECT o.id FROM lake.orders FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z' o " +
+		"JOIN lake.customers FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z' c ON o.customer_id = c.id " +
+		"JOIN lake.regions FOR VERSION AS OF 7 r ON c.region_id = r.id"
+
+	testCases := []struct {
+		name     string
+		engine   string
+		version  string
+		expected string
+	}{
+		{
+			name:   "trino",
+			engine: "trino",
+			expected: "SELECT o.id FROM lake.orders FOR TIMESTAMP AS OF TIMESTAMP '2024-01-01T00:00:00Z' o " +
+				"JOIN lake.customers FOR TIMESTAMP AS OF TIMESTAMP '2024-01-01T00:00:00Z' c ON o.customer_id = c.id " +
+				"JOIN lake.regions FOR VERSION AS OF 7 r ON c.region_id = r.id",
+		},
+		{
+			name:    "legacy_spark",
+			engine:  "spark",
+			version: "3.1.3",
+			expected: "SELECT o.id FROM lake.orders.at_timestamp_1704067200000 o " +
+				"JOIN lake.customers.at_timestamp_1704067200000 c ON o.customer_id = c.id " +
+				"JOIN lake.regions.snapshot_id_7 r ON c.region_id = r.id",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rewriter := sql.NewTimeTravelRewriter(catalog.FormatIceberg, tc.engine)
+			if tc.version != "" {
+				version, err := sql.ParseEngineVersion(tc.version)
+				if err != nil {
+					t.Fatalf("failed to parse version: %v", err)
+				}
+				rewriter.SetEngineVersion(version)
+			}
+
+			result, err := rewriter.Rewrite(input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package greenflag
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/federation"
+	"github.com/canonica-labs/canonica/internal/observability"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// recordingTracer records the spans started through it.
+type recordingTracer struct {
+	embedded.Tracer
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+// recordedSpan is a span recorded by recordingTracer, with its parent.
+type recordedSpan struct {
+	noop.Span
+	name       string
+	parent     *recordedSpan
+	attributes map[attribute.Key]attribute.Value
+	ended      bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := trace.SpanFromContext(ctx).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attributes: make(map[attribute.Key]attribute.Value)}
+	config := trace.NewSpanStartConfig(opts...)
+	for _, kv := range config.Attributes() {
+		span.attributes[kv.Key] = kv.Value
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordedSpan) End(options ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+// named returns the recorded spans with a name.
+func (t *recordingTracer) named(name string) []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var spans []*recordedSpan
+	for _, span := range t.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// TestFederatedExecutor_TracesQueryLifecycle tests the spans of a federated
+// query.
+// Green-Flag: A federated query MUST be traced in an execute span parenting
+// the plan, sub-query and join spans, each sub-query span tagged with its
+// engine and estimated rows, and the trace MUST continue the span in the
+// caller's context.
+func TestFederatedExecutor_TracesQueryLifecycle(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&successAdapter{
+		name:   "trino",
+		rows:   []federation.Row{{"order_id": 1, "customer_id": 10}, {"order_id": 2, "customer_id": 10}},
+		schema: &federation.ResultSchema{},
+	})
+	registry.Register(&successAdapter{
+		name:   "spark",
+		rows:   []federation.Row{{"id": 10, "name": "Alice"}},
+		schema: &federation.ResultSchema{},
+	})
+	tracer := &recordingTracer{}
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetTracer(tracer)
+
+	ctx, request := tracer.Start(context.Background(), "http.request")
+	result, err := executor.Execute(ctx,
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	if _, err := federation.CollectStream(ctx, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	executes := tracer.named(observability.SpanExecute)
+	if len(executes) != 1 || executes[0].parent != request.(*recordedSpan) || !executes[0].ended {
+		t.Fatalf("expected one ended execute span under the request span, got %v", executes)
+	}
+	execute := executes[0]
+	for _, name := range []string{observability.SpanPlan, observability.SpanJoin} {
+		spans := tracer.named(name)
+		if len(spans) != 1 || spans[0].parent != execute || !spans[0].ended {
+			t.Errorf("expected one ended %s span under the execute span, got %v", name, spans)
+		}
+	}
+
+	wantRows := map[string]int64{"trino": 2, "spark": 1}
+	subQueries := tracer.named(observability.SpanSubQuery)
+	if len(subQueries) != 2 {
+		t.Fatalf("expected 2 sub-query spans, got %d", len(subQueries))
+	}
+	for _, span := range subQueries {
+		if span.parent != execute || !span.ended {
+			t.Errorf("expected the sub-query span to end under the execute span")
+		}
+		engine := span.attributes[observability.AttrEngine].AsString()
+		rows, ok := wantRows[engine]
+		if !ok {
+			t.Errorf("unexpected sub-query span engine %q", engine)
+			continue
+		}
+		delete(wantRows, engine)
+		if got := span.attributes[observability.AttrEstimatedRows].AsInt64(); got != rows {
+			t.Errorf("expected %s sub-query span to estimate %d rows, got %d", engine, rows, got)
+		}
+	}
+}
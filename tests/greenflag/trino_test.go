@@ -4,11 +4,20 @@ package greenflag
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/adapters"
 	"github.com/canonica-labs/canonica/internal/adapters/trino"
+	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	canonicsql "github.com/canonica-labs/canonica/internal/sql"
 )
 
 // TestTrino_Name verifies the adapter returns correct name.
@@ -87,12 +96,12 @@ func TestTrino_WithDefaultConfig(t *testing.T) {
 // Green-Flag: All configuration options must work.
 func TestTrino_WithFullConfig(t *testing.T) {
 	config := trino.AdapterConfig{
-		Host:     "trino.example.com",
-		Port:     443,
-		Catalog:  "hive",
-		Schema:   "analytics",
-		User:     "query-user",
-		SSLMode:  "require",
+		Host:    "trino.example.com",
+		Port:    443,
+		Catalog: "hive",
+		Schema:  "analytics",
+		User:    "query-user",
+		SSLMode: "require",
 	}
 	adapter := trino.NewAdapter(config)
 	defer adapter.Close()
@@ -207,3 +216,244 @@ func TestTrino_CheckHealthMethodExists(t *testing.T) {
 	// Error is expected since no actual Trino server is running
 	_ = adapter.CheckHealth(context.Background())
 }
+
+// recordingDriver is a database/sql driver seam that records queries and arguments.
+// When blockUserQueries is set, tagged user queries block until their context is done,
+// simulating a long-running engine query.
+type recordingDriver struct {
+	mu               sync.Mutex
+	args             []driver.NamedValue
+	statements       []string
+	blockUserQueries bool
+	started          chan struct{}
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+func (d *recordingDriver) lastArgs() []driver.NamedValue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.args
+}
+
+func (d *recordingDriver) recorded() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.statements...)
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+func (c *recordingConn) CheckNamedValue(arg *driver.NamedValue) error { return nil }
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.mu.Lock()
+	c.driver.statements = append(c.driver.statements, query)
+	if !strings.Contains(query, "system.runtime") {
+		c.driver.args = append([]driver.NamedValue(nil), args...)
+	}
+	block := c.driver.blockUserQueries && !strings.Contains(query, "system.runtime")
+	c.driver.mu.Unlock()
+
+	if strings.Contains(query, "system.runtime.queries") {
+		return &staticRows{values: []string{"20260101_000000_00001_abcde"}}, nil
+	}
+	if block {
+		close(c.driver.started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &staticRows{}, nil
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	c.driver.statements = append(c.driver.statements, query)
+	c.driver.mu.Unlock()
+	return driver.RowsAffected(0), nil
+}
+
+// staticRows returns a single string column with the given values.
+type staticRows struct {
+	values []string
+	index  int
+}
+
+func (r *staticRows) Columns() []string { return []string{"x"} }
+func (r *staticRows) Close() error      { return nil }
+func (r *staticRows) Next(dest []driver.Value) error {
+	if r.index >= len(r.values) {
+		return io.EOF
+	}
+	dest[0] = r.values[r.index]
+	r.index++
+	return nil
+}
+
+func newRecordingTrinoAdapter(t *testing.T, impersonate bool) (*trino.Adapter, *recordingDriver) {
+	t.Helper()
+	drv := &recordingDriver{}
+	db := sql.OpenDB(driverConnector{drv})
+	t.Cleanup(func() { db.Close() })
+	adapter := trino.NewAdapterWithDB(trino.AdapterConfig{
+		Host:        "localhost",
+		Port:        8080,
+		Impersonate: impersonate,
+	}, db)
+	return adapter, drv
+}
+
+type driverConnector struct{ drv *recordingDriver }
+
+func (c driverConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.drv.Open("") }
+func (c driverConnector) Driver() driver.Driver                            { return c.drv }
+
+func trinoPlan() *planner.ExecutionPlan {
+	return &planner.ExecutionPlan{
+		LogicalPlan: &canonicsql.LogicalPlan{RawSQL: "SELECT 1"},
+		Engine:      "trino",
+	}
+}
+
+// TestTrino_ImpersonatesContextUser verifies the end user is sent to Trino.
+// Green-Flag: With impersonation enabled, X-Trino-User MUST be the authenticated user.
+func TestTrino_ImpersonatesContextUser(t *testing.T) {
+	adapter, drv := newRecordingTrinoAdapter(t, true)
+
+	ctx := auth.ContextWithUser(context.Background(), &auth.User{ID: "alice"})
+	if _, err := adapter.Execute(ctx, trinoPlan()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args := drv.lastArgs()
+	if len(args) != 1 {
+		t.Fatalf("expected 1 impersonation argument, got %d", len(args))
+	}
+	if args[0].Name != trino.ImpersonationHeader || args[0].Value != "alice" {
+		t.Errorf("expected %s=alice, got %s=%v", trino.ImpersonationHeader, args[0].Name, args[0].Value)
+	}
+}
+
+// TestTrino_SharedAccountByDefault verifies no identity is sent when impersonation is off.
+// Green-Flag: Default adapters MUST run queries as the shared service account.
+func TestTrino_SharedAccountByDefault(t *testing.T) {
+	adapter, drv := newRecordingTrinoAdapter(t, false)
+
+	ctx := auth.ContextWithUser(context.Background(), &auth.User{ID: "alice"})
+	if _, err := adapter.Execute(ctx, trinoPlan()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if args := drv.lastArgs(); len(args) != 0 {
+		t.Errorf("expected no impersonation arguments, got %v", args)
+	}
+}
+
+// TestTrino_SessionPropertiesFromRole verifies templated session properties
+// are resolved from the request context and sent with the query.
+// Green-Flag: The resource-group property MUST follow the user's role.
+func TestTrino_SessionPropertiesFromRole(t *testing.T) {
+	drv := &recordingDriver{}
+	db := sql.OpenDB(driverConnector{drv})
+	t.Cleanup(func() { db.Close() })
+	adapter := trino.NewAdapterWithDB(trino.AdapterConfig{
+		Host: "localhost",
+		Port: 8080,
+		SessionProperties: map[string]string{
+			"resource_group": "{{.Role}}_pool",
+			"query_priority": "{{.Labels.priority}}",
+		},
+	}, db)
+
+	cases := []struct {
+		user   *auth.User
+		labels map[string]string
+		want   string
+	}{
+		{&auth.User{ID: "alice", Roles: []string{"analyst"}}, nil, "resource_group=analyst_pool"},
+		{&auth.User{ID: "bob", Roles: []string{"etl", "analyst"}}, map[string]string{"priority": "1"}, "query_priority=1,resource_group=etl_pool"},
+	}
+	for _, tc := range cases {
+		ctx := auth.ContextWithUser(context.Background(), tc.user)
+		ctx = adapters.ContextWithQueryLabels(ctx, tc.labels)
+		if _, err := adapter.Execute(ctx, trinoPlan()); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.user.ID, err)
+		}
+
+		args := drv.lastArgs()
+		if len(args) != 1 || args[0].Name != trino.SessionHeader {
+			t.Fatalf("%s: expected a single %s argument, got %v", tc.user.ID, trino.SessionHeader, args)
+		}
+		if args[0].Value != tc.want {
+			t.Errorf("%s: expected session %q, got %q", tc.user.ID, tc.want, args[0].Value)
+		}
+	}
+}
+
+// TestTrino_ContextCancellationKillsEngineQuery verifies orphaned queries are killed.
+// Green-Flag: Cancelling the caller's context MUST issue an engine-side kill.
+func TestTrino_ContextCancellationKillsEngineQuery(t *testing.T) {
+	adapter, drv := newRecordingTrinoAdapter(t, false)
+	drv.blockUserQueries = true
+	drv.started = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := adapter.Execute(ctx, trinoPlan())
+		done <- err
+	}()
+
+	<-drv.started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected cancelled query to return an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execute did not return after cancellation")
+	}
+
+	var killed bool
+	for _, stmt := range drv.recorded() {
+		if strings.Contains(stmt, "system.runtime.kill_query") &&
+			strings.Contains(stmt, "20260101_000000_00001_abcde") {
+			killed = true
+		}
+	}
+	if !killed {
+		t.Errorf("expected kill_query call, got statements %v", drv.recorded())
+	}
+}
+
+// TestTrino_CompletedQueryIsNotCancelled verifies no kill is sent for finished queries.
+// Green-Flag: A query that completes normally MUST NOT trigger an engine cancel.
+func TestTrino_CompletedQueryIsNotCancelled(t *testing.T) {
+	adapter, drv := newRecordingTrinoAdapter(t, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := adapter.Execute(ctx, trinoPlan()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cancel()
+
+	for _, stmt := range drv.recorded() {
+		if strings.Contains(stmt, "kill_query") {
+			t.Errorf("unexpected cancel after completion: %s", stmt)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package greenflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// TestWindowFunctions_ForwardedToSingleEngine proves that a parser allowing
+// window functions passes them through to an engine that can run them.
+//
+// Green-Flag: With window functions allowed, a single-engine query with an
+// OVER clause MUST parse with HasWindowFunction set, and MUST be planned on
+// an engine with the WINDOW capability.
+func TestWindowFunctions_ForwardedToSingleEngine(t *testing.T) {
+	parser := sql.NewParser()
+	parser.SetAllowWindowFunctions(true)
+
+	logical, err := parser.Parse("SELECT id, ROW_NUMBER() OVER (PARTITION BY customer_id ORDER BY id) AS rn FROM analytics.events")
+	if err != nil {
+		t.Fatalf("expected window function to parse, got: %v", err)
+	}
+	if !logical.HasWindowFunction {
+		t.Fatal("expected HasWindowFunction to be set")
+	}
+
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     1,
+	})
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityWindow},
+		Available:    true,
+		Priority:     2,
+	})
+	p := planner.NewPlanner(schemaTestRegistry{"analytics.events": schemaTestTable()}, r)
+
+	plan, err := p.Plan(context.Background(), logical)
+	if err != nil {
+		t.Fatalf("expected window function query to be planned, got: %v", err)
+	}
+	if plan.Engine != "trino" {
+		t.Errorf("expected the engine with the WINDOW capability, got %s", plan.Engine)
+	}
+
+	features := parser.Features()
+	for _, f := range features.Features {
+		if f.Feature == sql.FeatureWindowFunction && !f.Supported {
+			t.Error("expected window functions to be reported supported")
+		}
+	}
+}
+
+// TestWindowFunctions_PlainQueriesUnflagged proves that queries without an
+// OVER clause are not mistaken for window function queries.
+//
+// Green-Flag: Aggregates without OVER MUST NOT set HasWindowFunction.
+func TestWindowFunctions_PlainQueriesUnflagged(t *testing.T) {
+	parser := sql.NewParser()
+	parser.SetAllowWindowFunctions(true)
+
+	logical, err := parser.Parse("SELECT customer_id, COUNT(*) FROM analytics.events GROUP BY customer_id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logical.HasWindowFunction {
+		t.Error("expected HasWindowFunction to be unset")
+	}
+}
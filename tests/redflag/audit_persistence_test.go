@@ -7,6 +7,7 @@ package redflag
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"testing"
 	"time"
 
@@ -204,3 +205,453 @@ func TestPersistentLogger_SurvivesRestart(t *testing.T) {
 		t.Errorf("Expected 2 audit log entries after restart, got %d", count)
 	}
 }
+
+// TestPersistentLogger_IngestRejectsInvalidBatch verifies that a shipped
+// batch with an invalid record, or without a gateway ID, is rejected as a
+// whole.
+// Red-Flag: Ingestion MUST NOT partially merge a batch or record entries that
+// cannot be deduplicated.
+func TestPersistentLogger_IngestRejectsInvalidBatch(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		gateway_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (query_id, gateway_id)
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	ctx := context.Background()
+
+	batches := map[string]struct {
+		gatewayID string
+		ndjson    string
+	}{
+		"malformed record": {"gw-b", `{"query_id":"q-1","user":"bob","engine":"trino"}` + "\n" + `{"query_id":`},
+		"missing user":     {"gw-b", `{"query_id":"q-1","user":"bob"}` + "\n" + `{"query_id":"q-2"}`},
+		"no gateway id":    {"", `{"query_id":"q-1","user":"bob"}`},
+		"bad timestamp":    {"gw-b", `{"timestamp":"yesterday","query_id":"q-1","user":"bob"}`},
+	}
+	for name, batch := range batches {
+		if _, err := logger.IngestNDJSON(ctx, batch.gatewayID, strings.NewReader(batch.ndjson)); err == nil {
+			t.Errorf("%s: expected the batch to be rejected", name)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM audit_logs").Scan(&count); err != nil {
+		t.Fatalf("Failed to count audit logs: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("rejected batches must not be merged, found %d entries", count)
+	}
+}
+
+// TestPersistentLogger_IngestHashesSensitiveValues verifies that shipped
+// entries are recorded the way LogQuery records them.
+// Red-Flag: Ingestion MUST NOT record sensitive values that the logger's
+// ColumnHasher would hash, and MUST keep each entry's original log time.
+func TestPersistentLogger_IngestHashesSensitiveValues(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		gateway_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (query_id, gateway_id)
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	hasher, err := observability.NewColumnHasher("test-salt", map[string][]string{
+		"analytics.customers": {"email"},
+	})
+	if err != nil {
+		t.Fatalf("NewColumnHasher failed: %v", err)
+	}
+	logger.SetColumnHasher(hasher)
+
+	ndjson := `{"timestamp":"2026-01-02T03:04:05Z","query_id":"q-1","user":"bob","engine":"trino",` +
+		`"outcome":"error","sql":"SELECT id FROM analytics.customers WHERE email = 'alice@example.com'",` +
+		`"error":"no customer with email 'alice@example.com'"}`
+	if _, err := logger.IngestNDJSON(context.Background(), "gw-b", strings.NewReader(ndjson)); err != nil {
+		t.Fatalf("IngestNDJSON failed: %v", err)
+	}
+
+	var message string
+	var createdAt time.Time
+	if err := db.QueryRow("SELECT error_message, created_at FROM audit_logs WHERE query_id = 'q-1'").Scan(&message, &createdAt); err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if strings.Contains(message, "alice@example.com") {
+		t.Errorf("sensitive value recorded by ingestion: %s", message)
+	}
+	if !strings.Contains(message, hasher.Hash("alice@example.com")) {
+		t.Errorf("expected the hashed value in the error message: %s", message)
+	}
+	if want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC); !createdAt.Equal(want) {
+		t.Errorf("expected created_at %v, got %v", want, createdAt)
+	}
+}
+
+// TestPersistentLogger_ConsistentSummaryReadsPrimary verifies that audit
+// summaries are served from the read replica, and that a consistent summary
+// reads the primary so recently written entries are not missed.
+//
+// Red-Flag: A consistent summary MUST include entries the replica lacks.
+func TestPersistentLogger_ConsistentSummaryReadsPrimary(t *testing.T) {
+	openAuditDB := func() *sql.DB {
+		db, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			t.Fatalf("Failed to open SQLite: %v", err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+		_, err = db.Exec(`CREATE TABLE audit_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			query_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			role TEXT,
+			tables_json TEXT DEFAULT '[]',
+			auth_decision TEXT,
+			planner_decision TEXT,
+			engine TEXT,
+			execution_time_ms INTEGER DEFAULT 0,
+			outcome TEXT,
+			error_message TEXT,
+			invariant_violated TEXT,
+			slow_query BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`)
+		if err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+		return db
+	}
+	primary := openAuditDB()
+	replica := openAuditDB() // never catches up in this test
+
+	logger, err := observability.NewPersistentLogger(primary)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.SetReadReplica(replica)
+
+	err = logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-1",
+		User:          "alice",
+		ExecutionTime: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+
+	var written int
+	replica.QueryRow(`SELECT COUNT(*) FROM audit_logs`).Scan(&written)
+	if written != 0 {
+		t.Fatalf("Expected the entry to be written to the primary only, replica has %d", written)
+	}
+
+	opts := observability.AuditSummaryOptions{Dimensions: []observability.AuditDimension{observability.DimensionUsers}}
+	summary, err := logger.GetAuditSummaryWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if summary.AcceptedCount != 0 {
+		t.Errorf("Expected the summary to come from the lagging replica, got %d accepted", summary.AcceptedCount)
+	}
+
+	opts.Consistent = true
+	summary, err = logger.GetAuditSummaryWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if summary.AcceptedCount != 1 || len(summary.TopUsers) != 1 {
+		t.Errorf("Expected a consistent summary to include the new entry, got %+v", summary)
+	}
+}
+
+// countingAuditMetrics counts the audit entries reported as failing to
+// persist.
+type countingAuditMetrics struct {
+	observability.NoopMetrics
+	failures int
+}
+
+func (m *countingAuditMetrics) ObserveAuditFailure(entries int) {
+	m.failures += entries
+}
+
+// TestPersistentLogger_BatchFailureFallsBackToWriter verifies batched
+// entries are not lost when the database fails.
+// Red-Flag: Per plan.md, "silent failures are forbidden": entries failing to
+// persist MUST be written to the logger's writer and counted in metrics.
+func TestPersistentLogger_BatchFailureFallsBackToWriter(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // no audit_logs table: every insert fails
+
+	var out strings.Builder
+	logger, err := observability.NewPersistentLoggerWithWriter(db, &out)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	metrics := &countingAuditMetrics{}
+	logger.SetMetrics(metrics)
+	if err := logger.SetAuditBatch(observability.AuditBatch{Size: 10, Interval: time.Hour}); err != nil {
+		t.Fatalf("SetAuditBatch failed: %v", err)
+	}
+
+	for _, id := range []string{"q-1", "q-2"} {
+		if err := logger.LogQuery(context.Background(), observability.QueryLogEntry{QueryID: id, User: "alice"}); err != nil {
+			t.Fatalf("LogQuery failed: %v", err)
+		}
+	}
+	if err := logger.Close(); err == nil {
+		t.Error("Expected Close to report the failed flush")
+	}
+
+	if !strings.Contains(out.String(), `"query_id":"q-1"`) || !strings.Contains(out.String(), `"query_id":"q-2"`) {
+		t.Errorf("Expected both entries to be written to the writer, got %q", out.String())
+	}
+	if metrics.failures != 2 {
+		t.Errorf("Expected 2 entries counted as failing to persist, got %d", metrics.failures)
+	}
+}
+
+// TestPersistentLogger_BatchFailureKeepsEntries verifies batched entries
+// are kept when the database fails and there is no writer.
+// Red-Flag: Entries MUST stay buffered until they persist, and LogQuery
+// MUST fail once the buffer is full rather than drop entries.
+func TestPersistentLogger_BatchFailureKeepsEntries(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	if err := logger.SetAuditBatch(observability.AuditBatch{Size: 2, Interval: time.Hour, MaxPending: 2}); err != nil {
+		t.Fatalf("SetAuditBatch failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, id := range []string{"q-1", "q-2"} {
+		if err := logger.LogQuery(ctx, observability.QueryLogEntry{QueryID: id, User: "alice"}); err != nil {
+			t.Fatalf("LogQuery failed: %v", err)
+		}
+	}
+	if err := logger.Flush(ctx); err == nil {
+		t.Fatal("Expected Flush to fail without an audit_logs table")
+	}
+	if err := logger.LogQuery(ctx, observability.QueryLogEntry{QueryID: "q-3", User: "alice"}); err == nil {
+		t.Error("Expected LogQuery to fail once the buffer is full")
+	}
+
+	// Once the database recovers, the buffered entries are persisted.
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM audit_logs`).Scan(&n)
+	if n != 2 {
+		t.Errorf("Expected the 2 buffered entries to be persisted, got %d", n)
+	}
+}
+
+// TestPersistentLogger_AuditSummaryEmptyWindow verifies persisted summaries
+// of a window without entries.
+// Red-Flag: A window outside every persisted entry MUST summarize nothing.
+func TestPersistentLogger_AuditSummaryEmptyWindow(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		slow_query BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID: "q-1", User: "alice", Error: "access denied", Timestamp: day,
+	}); err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+
+	summary, err := logger.GetAuditSummaryWithOptions(observability.AuditSummaryOptions{
+		Dimensions: []observability.AuditDimension{observability.DimensionUsers, observability.DimensionRejectionReasons},
+		Since:      day.Add(time.Hour),
+		Until:      day.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if summary.AcceptedCount != 0 || summary.RejectedCount != 0 || len(summary.TopUsers) != 0 || len(summary.TopRejectionReasons) != 0 {
+		t.Errorf("Expected an empty summary, got %+v", summary)
+	}
+}
+
+// TestPersistentLogger_RecordsOnlySlowQueries verifies the slow_query column
+// of persisted entries below, at and above the threshold.
+// Red-Flag: A query within the threshold MUST NOT be recorded or counted as
+// slow.
+func TestPersistentLogger_RecordsOnlySlowQueries(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT,
+		tables_json TEXT DEFAULT '[]',
+		auth_decision TEXT,
+		planner_decision TEXT,
+		engine TEXT,
+		execution_time_ms INTEGER DEFAULT 0,
+		outcome TEXT,
+		error_message TEXT,
+		invariant_violated TEXT,
+		slow_query BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	logger, err := observability.NewPersistentLogger(db)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.SetSlowQueryThreshold(time.Second, nil)
+	for _, entry := range []observability.QueryLogEntry{
+		{QueryID: "q-below", User: "alice", ExecutionTime: 999 * time.Millisecond},
+		{QueryID: "q-at", User: "alice", ExecutionTime: time.Second},
+		{QueryID: "q-above", User: "alice", ExecutionTime: 1001 * time.Millisecond},
+	} {
+		if err := logger.LogQuery(context.Background(), entry); err != nil {
+			t.Fatalf("LogQuery failed: %v", err)
+		}
+	}
+
+	var slow []string
+	rows, err := db.Query(`SELECT query_id FROM audit_logs WHERE slow_query = TRUE`)
+	if err != nil {
+		t.Fatalf("Failed to query audit logs: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var queryID string
+		if err := rows.Scan(&queryID); err != nil {
+			t.Fatalf("Failed to scan audit log: %v", err)
+		}
+		slow = append(slow, queryID)
+	}
+	if len(slow) != 1 || slow[0] != "q-above" {
+		t.Errorf("Expected only q-above recorded as slow, got %v", slow)
+	}
+
+	summary, err := logger.GetAuditSummaryWithOptions(observability.AuditSummaryOptions{
+		Dimensions: []observability.AuditDimension{observability.DimensionUsers},
+	})
+	if err != nil {
+		t.Fatalf("GetAuditSummaryWithOptions failed: %v", err)
+	}
+	if summary.SlowQueryCount != 1 {
+		t.Errorf("Expected 1 slow query in the summary, got %d", summary.SlowQueryCount)
+	}
+}
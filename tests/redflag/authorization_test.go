@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -357,3 +358,67 @@ func TestAuthorization_NoEngineInteractionOnFailure(t *testing.T) {
 			"This would allow engine interaction without permission")
 	}
 }
+
+// TestAuthorization_SchemaWildcardDeniesOtherSchemas proves that a grant on
+// "analytics.*" does not extend beyond the analytics schema or its capability.
+//
+// Per phase-2-spec.md: "Absence of permission is denial."
+func TestAuthorization_SchemaWildcardDeniesOtherSchemas(t *testing.T) {
+	authz := auth.NewAuthorizationService()
+	authz.GrantAccess("analyst", "analytics.*", capabilities.CapabilityRead)
+
+	user := &auth.User{
+		ID:    "user-analyst",
+		Name:  "Analyst",
+		Roles: []string{"analyst"},
+	}
+	ctx := auth.ContextWithUser(context.Background(), user)
+
+	denied := []string{"sales.orders", "orders", "analytics_archive.orders", "warehouse.analytics.orders"}
+	for _, table := range denied {
+		if err := authz.Authorize(ctx, user, []string{table}, capabilities.CapabilityRead); err == nil {
+			t.Errorf("RED-FLAG: schema wildcard analytics.* authorized %s", table)
+		}
+	}
+
+	// Mixing a covered and an uncovered table must still be denied
+	err := authz.Authorize(ctx, user, []string{"analytics.orders", "sales.orders"}, capabilities.CapabilityRead)
+	if err == nil {
+		t.Error("RED-FLAG: schema wildcard authorized a query that also reads sales.orders")
+	}
+
+	// The wildcard grants only its capability
+	err = authz.Authorize(ctx, user, []string{"analytics.orders"}, capabilities.CapabilityTimeTravel)
+	if err == nil {
+		t.Error("RED-FLAG: schema wildcard READ grant authorized TIME_TRAVEL")
+	}
+}
+
+// TestQueryPolicies_ExtraRoleDoesNotLiftRestriction proves that a user with
+// both a restricted and an unrestricted role keeps the restriction, and that
+// a role overriding only one bound inherits the other from the defaults.
+func TestQueryPolicies_ExtraRoleDoesNotLiftRestriction(t *testing.T) {
+	policies := auth.NewQueryPolicies(auth.QueryPolicy{DefaultLimit: 10000, Timeout: 5 * time.Minute})
+	policies.SetRolePolicy("exec_dashboard", auth.RoleQueryPolicy{DefaultLimit: 500})
+	policies.SetRolePolicy("analyst", auth.RoleQueryPolicy{DefaultLimit: -1, Timeout: -1})
+
+	both := policies.Resolve(&auth.User{ID: "dash", Roles: []string{"analyst", "exec_dashboard"}})
+	if both.DefaultLimit != 500 {
+		t.Errorf("RED-FLAG: analyst role lifted exec_dashboard LIMIT 500, got %d", both.DefaultLimit)
+	}
+	if both.Timeout != 0 {
+		t.Errorf("analyst timeout override not applied, got %s", both.Timeout)
+	}
+	if both.Name() != "analyst,exec_dashboard" {
+		t.Errorf("policy name = %q, want both roles", both.Name())
+	}
+
+	dashboard := policies.Resolve(&auth.User{ID: "dash", Roles: []string{"exec_dashboard"}})
+	if dashboard.Timeout != 5*time.Minute {
+		t.Errorf("RED-FLAG: exec_dashboard lost the global timeout, got %s", dashboard.Timeout)
+	}
+
+	if none := policies.Resolve(nil); none.QueryPolicy != policies.Defaults() {
+		t.Errorf("RED-FLAG: user without roles escaped the global defaults: %+v", none.QueryPolicy)
+	}
+}
@@ -7,12 +7,15 @@ package redflag
 
 import (
 	"context"
+	stderrors "errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/bootstrap"
+	"github.com/canonica-labs/canonica/internal/errors"
 )
 
 // TestBootstrap_RejectsMissingRequiredSection verifies that configuration
@@ -301,3 +304,153 @@ func containsString(s, substr string) bool {
 			len(s) > len(substr) &&
 				(s[:len(substr)] == substr || containsString(s[1:], substr)))
 }
+
+// TestBootstrap_RejectsMalformedEngineEndpoint verifies that malformed engine
+// endpoints fail at validate time rather than at the first query.
+// Red-Flag: Endpoints without a host or with the wrong scheme MUST fail validation.
+func TestBootstrap_RejectsMalformedEngineEndpoint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		engine   string
+		endpoint string
+		wantErr  string
+	}{
+		{"missing scheme", "trino", "localhost:8080", "absolute URL"},
+		{"missing host", "trino", "http://", "absolute URL"},
+		{"invalid port", "trino", "http://localhost:80a0", "invalid endpoint"},
+		{"wrong scheme for trino", "trino", "thrift://localhost:8080", "scheme must be one of http, https"},
+		{"wrong scheme for spark", "spark", "http://localhost:10000", "scheme must be one of thrift, hive2"},
+		{"unparseable", "custom", "http://[::1", "invalid endpoint"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &bootstrap.Config{
+				Engines: map[string]bootstrap.EngineConfig{
+					tc.engine: {Enabled: true, Endpoint: tc.endpoint},
+				},
+			}
+
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatalf("expected validation error for endpoint %q", tc.endpoint)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) || !strings.Contains(err.Error(), tc.engine) {
+				t.Errorf("error should name engine %q and contain %q, got: %v", tc.engine, tc.wantErr, err)
+			}
+			if cfg.IsValidated() {
+				t.Error("config should not be marked as validated")
+			}
+		})
+	}
+}
+
+// TestBootstrap_RejectsInvalidFormatEngines verifies that format → engine
+// overrides are checked at validate time.
+// Red-Flag: Unknown formats and unconfigured engines MUST fail validation.
+func TestBootstrap_RejectsInvalidFormatEngines(t *testing.T) {
+	testCases := []struct {
+		name          string
+		formatEngines map[string]string
+		wantErr       string
+	}{
+		{"unknown format", map[string]string{"avro": "duckdb"}, "unknown table format"},
+		{"unknown engine", map[string]string{"iceberg": "trino"}, "unknown engine 'trino'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &bootstrap.Config{
+				Engines:       map[string]bootstrap.EngineConfig{"duckdb": {Enabled: true}},
+				FormatEngines: tc.formatEngines,
+			}
+
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+			if cfg.IsValidated() {
+				t.Error("config should not be marked as validated")
+			}
+		})
+	}
+}
+
+// TestBootstrap_SafeModeRejectsRawFileTable verifies that safe mode refuses
+// tables that bypass table-level governance.
+// Red-Flag: A raw-file table and a table on an engine outside the allowlist
+// MUST fail validation in safe mode.
+func TestBootstrap_SafeModeRejectsRawFileTable(t *testing.T) {
+	testCases := []struct {
+		name    string
+		source  bootstrap.SourceConfig
+		wantErr string
+	}{
+		{"raw parquet", bootstrap.SourceConfig{Engine: "trino", Format: "parquet", Location: "s3://bucket/raw"}, "not allowed in safe mode"},
+		{"engine not allowed", bootstrap.SourceConfig{Engine: "duckdb", Format: "iceberg", Location: "s3://bucket/t"}, "engine not allowed: duckdb"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &bootstrap.Config{
+				Engines: map[string]bootstrap.EngineConfig{
+					"trino":  {Enabled: true, Endpoint: "http://trino:8080"},
+					"duckdb": {Enabled: true},
+				},
+				Tables: map[string]bootstrap.TableConfig{
+					"analytics.events": {Sources: []bootstrap.SourceConfig{tc.source}},
+				},
+				SafeMode: bootstrap.SafeModeConfig{Enabled: true, AllowedEngines: []string{"trino"}},
+			}
+
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("expected safe mode validation error")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) || !strings.Contains(err.Error(), "analytics.events") {
+				t.Errorf("error should name the table and contain %q, got: %v", tc.wantErr, err)
+			}
+			if cfg.IsValidated() {
+				t.Error("config should not be marked as validated")
+			}
+		})
+	}
+}
+
+// TestBootstrap_RejectsUnsetEnvironmentVariable verifies that a config
+// referencing an unset variable without a default fails to load.
+// Red-Flag: An unresolved ${VAR} MUST NOT load as an empty value, and the
+// error MUST name the variable.
+func TestBootstrap_RejectsUnsetEnvironmentVariable(t *testing.T) {
+	config := `
+gateway:
+  listen: :8080
+
+repository:
+  postgres:
+    dsn: ${CANONICA_TEST_UNSET_PG_DSN}
+
+engines:
+  duckdb:
+    enabled: true
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := bootstrap.LoadConfig(configPath)
+	if err == nil {
+		t.Fatalf("expected error for unset variable, got config with DSN %q", cfg.Repository.Postgres.DSN)
+	}
+	var bootstrapErr *errors.ErrBootstrapError
+	if !stderrors.As(err, &bootstrapErr) {
+		t.Errorf("expected ErrBootstrapError, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "CANONICA_TEST_UNSET_PG_DSN") {
+		t.Errorf("error should name the unset variable, got: %v", err)
+	}
+}
@@ -4,14 +4,21 @@ package redflag
 
 import (
 	"context"
+	stderrors "errors"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/catalog"
 	"github.com/canonica-labs/canonica/internal/catalog/glue"
 	"github.com/canonica-labs/canonica/internal/catalog/hive"
+	"github.com/canonica-labs/canonica/internal/catalog/syncer"
 	"github.com/canonica-labs/canonica/internal/catalog/unity"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
 )
 
 // TestHiveUnreachable verifies that Hive client fails appropriately
@@ -422,3 +429,178 @@ func TestNetworkErrorWrapping(t *testing.T) {
 		t.Logf("Error type: %T", err)
 	}
 }
+
+// TestCatalogProxyHidesExcludedDatabases verifies that databases excluded by
+// the proxy's filters cannot be browsed by naming them directly.
+// Red-Flag: Excluded catalog databases MUST NOT be reachable through the proxy.
+func TestCatalogProxyHidesExcludedDatabases(t *testing.T) {
+	proxy := catalog.NewProxy(&mockCatalog{name: "mock"}, catalog.DatabaseFilter{Exclude: []string{"secret"}})
+	ctx := context.Background()
+
+	var notFound *errors.ErrCatalogObjectNotFound
+	if _, err := proxy.ListTables(ctx, "secret"); !stderrors.As(err, &notFound) {
+		t.Errorf("expected ErrCatalogObjectNotFound listing an excluded database, got %v", err)
+	}
+	if _, err := proxy.GetTable(ctx, "secret", "keys"); !stderrors.As(err, &notFound) {
+		t.Errorf("expected ErrCatalogObjectNotFound describing a table in an excluded database, got %v", err)
+	}
+}
+
+// TestCatalogProxyMissingTable verifies that a table the catalog does not
+// report is a not-found error rather than empty metadata.
+// Red-Flag: Missing catalog tables MUST be reported explicitly.
+func TestCatalogProxyMissingTable(t *testing.T) {
+	proxy := catalog.NewProxy(&mockCatalog{name: "mock"}, catalog.DatabaseFilter{})
+
+	meta, err := proxy.GetTable(context.Background(), "sales", "missing")
+	var notFound *errors.ErrCatalogObjectNotFound
+	if !stderrors.As(err, &notFound) {
+		t.Fatalf("expected ErrCatalogObjectNotFound, got %v (metadata %v)", err, meta)
+	}
+}
+
+// syncCatalog is a mock catalog for sync tests: sales lists orders, and
+// listing finance fails.
+type syncCatalog struct {
+	mockCatalog
+}
+
+func (m *syncCatalog) ListDatabases(ctx context.Context) ([]string, error) {
+	return []string{"finance", "sales"}, nil
+}
+
+func (m *syncCatalog) ListTables(ctx context.Context, database string) ([]catalog.TableInfo, error) {
+	if database == "finance" {
+		return nil, stderrors.New("access denied")
+	}
+	return []catalog.TableInfo{{Database: database, Name: "orders", Format: catalog.FormatIceberg}}, nil
+}
+
+func (m *syncCatalog) GetTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	return &catalog.TableMetadata{Database: database, Name: table, Format: catalog.FormatIceberg, Location: "s3://lake/" + table}, nil
+}
+
+// TestSyncerLeavesTablesItDoesNotOwn verifies that a sync only changes tables
+// it created and never prunes databases it could not list.
+// Red-Flag: Catalog sync MUST NOT overwrite manually registered tables
+// without force, nor remove tables of a database that failed to list.
+func TestSyncerLeavesTablesItDoesNotOwn(t *testing.T) {
+	repo := storage.NewMockRepository()
+	ctx := context.Background()
+	for name, description := range map[string]string{
+		"sales.orders":   "Curated orders",
+		"sales.refunds":  "Curated refunds",
+		"finance.ledger": syncer.SyncedDescription("mock"),
+	} {
+		_ = repo.Create(ctx, &tables.VirtualTable{
+			Name:         name,
+			Description:  description,
+			Sources:      []tables.PhysicalSource{{Format: tables.FormatParquet, Location: "s3://curated/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	report, err := syncer.NewSyncer(&syncCatalog{mockCatalog{name: "mock"}}, repo).Sync(ctx, syncer.SyncOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if got := report.Tables(syncer.SyncSkip); len(got) != 1 || got[0] != "sales.orders" {
+		t.Errorf("expected the manually registered sales.orders to be skipped, got %v", got)
+	}
+	if removed := report.Tables(syncer.SyncRemove); len(removed) != 0 {
+		t.Errorf("expected nothing removed, got %v", removed)
+	}
+	if report.Failed() != 1 {
+		t.Errorf("expected the finance listing failure to be reported, got %v", report.Errors)
+	}
+
+	orders, _ := repo.Get(ctx, "sales.orders")
+	if orders == nil || orders.Sources[0].Location != "s3://curated/sales.orders" {
+		t.Errorf("sales.orders was overwritten: %+v", orders)
+	}
+	for _, name := range []string{"sales.refunds", "finance.ledger"} {
+		if exists, _ := repo.Exists(ctx, name); !exists {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
+
+// TestSyncerUnknownDatabase verifies that syncing a database the catalog
+// does not have fails before anything is written.
+// Red-Flag: Syncing an unknown database MUST fail without changes.
+func TestSyncerUnknownDatabase(t *testing.T) {
+	repo := storage.NewMockRepository()
+
+	_, err := syncer.NewSyncer(&syncCatalog{mockCatalog{name: "mock"}}, repo).Sync(context.Background(), syncer.SyncOptions{Database: "missing"})
+	if err == nil {
+		t.Fatal("expected an error syncing an unknown database")
+	}
+	if registered, _ := repo.List(context.Background()); len(registered) != 0 {
+		t.Errorf("expected no tables registered, got %d", len(registered))
+	}
+}
+
+// flakyCatalog is a sync catalog whose sales database also lists refunds
+// and returns, and reading returns fails.
+type flakyCatalog struct {
+	syncCatalog
+}
+
+func (m *flakyCatalog) ListTables(ctx context.Context, database string) ([]catalog.TableInfo, error) {
+	if database == "finance" {
+		return nil, stderrors.New("access denied")
+	}
+	return []catalog.TableInfo{{Name: "orders"}, {Name: "returns"}, {Name: "refunds"}}, nil
+}
+
+func (m *flakyCatalog) GetTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	if table == "returns" {
+		return nil, stderrors.New("throttled")
+	}
+	return m.syncCatalog.GetTable(ctx, database, table)
+}
+
+// TestSyncerTableFailureDoesNotAbortSync verifies that a table whose
+// metadata cannot be read is reported while the other tables still sync.
+// Red-Flag: One table's catalog failure MUST NOT abort a concurrent sync.
+func TestSyncerTableFailureDoesNotAbortSync(t *testing.T) {
+	repo := storage.NewMockRepository()
+	ctx := context.Background()
+
+	report, err := syncer.NewSyncer(&flakyCatalog{syncCatalog{mockCatalog{name: "mock"}}}, repo).Sync(ctx, syncer.SyncOptions{Concurrency: 3})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if report.Failed() != 2 || !strings.Contains(strings.Join(report.Errors, ";"), "sales.returns: throttled") {
+		t.Errorf("expected the finance and sales.returns failures to be reported, got %v", report.Errors)
+	}
+	if got := strings.Join(report.Tables(syncer.SyncCreate), ","); got != "sales.orders,sales.refunds" {
+		t.Errorf("expected sales.orders and sales.refunds created, got %s", got)
+	}
+	for _, name := range []string{"sales.orders", "sales.refunds"} {
+		if exists, _ := repo.Exists(ctx, name); !exists {
+			t.Errorf("%s was not created", name)
+		}
+	}
+	if exists, _ := repo.Exists(ctx, "sales.returns"); exists {
+		t.Error("sales.returns was created despite the failure")
+	}
+}
+
+// TestSyncerCanceled verifies that a canceled sync fails without changes.
+// Red-Flag: A canceled catalog sync MUST NOT apply a partial discovery.
+func TestSyncerCanceled(t *testing.T) {
+	repo := storage.NewMockRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := syncer.NewSyncer(&syncCatalog{mockCatalog{name: "mock"}}, repo).Sync(ctx, syncer.SyncOptions{})
+	if !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+	if registered, _ := repo.List(context.Background()); len(registered) != 0 {
+		t.Errorf("expected no tables registered, got %d", len(registered))
+	}
+}
@@ -10,11 +10,18 @@ package redflag
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/cli"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/pkg/models"
 )
 
 // TestCLIFailsWithoutGateway tests that the CLI fails when the gateway is unreachable.
@@ -169,3 +176,170 @@ func TestCLIRejectsLocalMetadata(t *testing.T) {
 		t.Error("Client should have endpoint configured")
 	}
 }
+
+// TestCLIQueryRerunUnknownQueryFails tests that rerunning a query that is not
+// in the audit log fails without executing anything.
+// Red-Flag: Rerun of an unknown or SQL-less query MUST fail and MUST NOT execute.
+func TestCLIQueryRerunUnknownQueryFails(t *testing.T) {
+	executed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audit/entries/missing":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "audit entry not found"})
+		case "/audit/entries/no-sql":
+			json.NewEncoder(w).Encode(cli.AuditEntry{QueryID: "no-sql", Outcome: "rejected"})
+		case "/query":
+			executed = true
+			json.NewEncoder(w).Encode(cli.QueryResult{})
+		}
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	for _, id := range []string{"missing", "no-sql"} {
+		if _, err := client.RerunQuery(context.Background(), id); err == nil {
+			t.Errorf("%s: expected error", id)
+		}
+	}
+	if executed {
+		t.Error("rerun must not execute a query when the audit entry cannot be used")
+	}
+}
+
+// TestCLIQueryResultAcceptsLegacyWarnings tests that warnings sent as bare
+// strings by older gateways are still shown.
+// Red-Flag: A legacy warning MUST NOT fail decoding or be dropped.
+func TestCLIQueryResultAcceptsLegacyWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"query_id":"q1","row_count":0,"engine":"duckdb","duration":"1ms","warnings":["table analytics.events is deprecated"]}`))
+	}))
+	defer server.Close()
+
+	result, err := cli.NewGatewayClient(server.URL, "test-token").ExecuteQuery(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("ExecuteQuery failed: %v", err)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Message != "table analytics.events is deprecated" {
+		t.Fatalf("expected the legacy warning to be kept, got %+v", result.Warnings)
+	}
+	if got := cli.FormatWarning(result.Warnings[0]); got != "⚠ WARNING: table analytics.events is deprecated" {
+		t.Errorf("unexpected rendering: %q", got)
+	}
+}
+
+// TestQueryResponseTruncateWithinCap tests that a result under the row cap
+// is not reported as truncated.
+// Red-Flag: Truncate MUST NOT set Truncated or warn when no rows are dropped.
+func TestQueryResponseTruncateWithinCap(t *testing.T) {
+	response := models.QueryResponse{
+		Rows:     []map[string]interface{}{{"id": 1}, {"id": 2}},
+		RowCount: 2,
+	}
+	if response.Truncate(2) || response.Truncated || len(response.Warnings) != 0 {
+		t.Errorf("expected no truncation, got truncated=%v warnings=%+v", response.Truncated, response.Warnings)
+	}
+	if response.Truncate(0) || len(response.Rows) != 2 {
+		t.Error("a non-positive cap must not truncate")
+	}
+}
+
+// TestCLIRendersUntypedResultsUnchanged tests rendering when column types
+// are missing or do not match the values.
+// Red-Flag: Results without a matching schema MUST keep the tab-separated
+// output, and values that do not parse as their column type MUST NOT be
+// altered.
+func TestCLIRendersUntypedResultsUnchanged(t *testing.T) {
+	rows := []map[string]interface{}{{"id": float64(1), "created_at": "yesterday"}}
+
+	legacy := &cli.QueryResult{Columns: []string{"id", "created_at"}, Rows: rows}
+	mismatched := &cli.QueryResult{
+		Columns: []string{"id", "created_at"},
+		Schema:  []models.ColumnSchema{{Name: "id", Type: "BIGINT"}},
+		Rows:    rows,
+	}
+	for name, result := range map[string]*cli.QueryResult{"no schema": legacy, "schema length mismatch": mismatched} {
+		got := cli.FormatResultRows(result)
+		if len(got) != 2 || got[0] != "id\tcreated_at" || got[1] != "1\tyesterday" {
+			t.Errorf("%s: expected tab-separated output, got %q", name, got)
+		}
+	}
+
+	typed := &cli.QueryResult{
+		Columns: []string{"id", "created_at"},
+		Schema:  []models.ColumnSchema{{Name: "id", Type: "BIGINT"}, {Name: "created_at", Type: "TIMESTAMP(3)"}},
+		Rows:    rows,
+	}
+	got := cli.FormatResultRows(typed)
+	if len(got) != 2 || got[1] != " 1  yesterday" {
+		t.Errorf("expected an unparseable timestamp to be shown verbatim, got %q", got)
+	}
+
+	response := models.QueryResponse{Columns: []string{"id", "created_at"}}
+	response.SetColumnTypes([]string{"BIGINT"})
+	if len(response.Schema) != 2 || response.Schema[1].Type != "" || response.Schema[1].IsTimestamp() {
+		t.Errorf("expected a missing type to be left empty, got %+v", response.Schema)
+	}
+}
+
+// TestCLILogsSkipsMalformedLines tests that truncated or non-JSON log lines
+// do not stop the log view.
+// Red-Flag: Malformed log lines MUST be skipped, not printed or fatal.
+func TestCLILogsSkipsMalformedLines(t *testing.T) {
+	input := `{"timestamp":"2026-01-05T10:00:00Z","query_id":"q-1","user":"alice","outcome":"success"
+not json at all
+[1, 2, 3]
+{"timestamp":"2026-01-05T10:00:01Z","query_id":"q-2","user":"alice","outcome":"success"}`
+
+	var out strings.Builder
+	n, err := cli.TailLogs(strings.NewReader(input), &out, cli.LogFilter{}, cli.LogFormatter{})
+	if err != nil {
+		t.Fatalf("TailLogs failed: %v", err)
+	}
+	if n != 1 || !strings.Contains(out.String(), "q-2") || strings.Contains(out.String(), "q-1") {
+		t.Errorf("Expected only q-2 to be printed, got %d records:\n%s", n, out.String())
+	}
+}
+
+// TestCLIErrorCodesSurviveMissingOrForeignErrors tests the edges of the
+// error wire format.
+// Red-Flag: An error that is not a CanonicError MUST be reported as an
+// internal error, an error type's own HTTP status MUST win over its code,
+// and a response without a code MUST map to an exit code from its status.
+func TestCLIErrorCodesSurviveMissingOrForeignErrors(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	errors.WriteHTTPError(recorder, fmt.Errorf("connection reset"))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 for a plain error, got %d", recorder.Code)
+	}
+	var wire map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &wire); err != nil {
+		t.Fatalf("invalid JSON error: %v", err)
+	}
+	if wire["code"] != float64(errors.CodeInternal) || wire["error"] != "connection reset" {
+		t.Errorf("unexpected wire format for a plain error: %v", wire)
+	}
+
+	recorder = httptest.NewRecorder()
+	errors.WriteHTTPError(recorder, errors.NewConcurrencyLimit("heavy", 2, time.Second))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the error's own status 429, got %d", recorder.Code)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "access denied"})
+	}))
+	defer server.Close()
+
+	client := cli.NewGatewayClient(server.URL, "test-token")
+	_, err := client.DescribeTable(context.Background(), "analytics.secret")
+	var received *errors.CanonicError
+	if !stderrors.As(err, &received) || received.Message != "access denied" {
+		t.Fatalf("expected the gateway's error, got %T: %v", err, err)
+	}
+	if code := errors.CodeOf(err); int(code) != cli.ExitAuth {
+		t.Errorf("expected auth exit code %d for a 403 without a code, got %d", cli.ExitAuth, code)
+	}
+}
@@ -0,0 +1,46 @@
+package redflag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/compression"
+)
+
+// TestGzipHandler_SkipsSmallOrUnacceptedResponses tests responses that must
+// be sent uncompressed.
+// Red-Flag: Responses under the size threshold, and responses to clients
+// that do not accept gzip, MUST NOT be compressed.
+func TestGzipHandler_SkipsSmallOrUnacceptedResponses(t *testing.T) {
+	cases := map[string]struct {
+		body           string
+		acceptEncoding string
+	}{
+		"small body":   {body: `{"rows":[[1]]}`, acceptEncoding: "gzip"},
+		"no gzip":      {body: strings.Repeat("x", 4096), acceptEncoding: "br"},
+		"gzip refused": {body: strings.Repeat("x", 4096), acceptEncoding: "gzip;q=0"},
+		"no encoding":  {body: strings.Repeat("x", 4096)},
+	}
+	for name, tc := range cases {
+		body := tc.body
+		handler := compression.NewGzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}), compression.DefaultMinSize)
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		if tc.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("%s: expected no Content-Encoding, got %q", name, got)
+		}
+		if rec.Body.String() != body {
+			t.Errorf("%s: expected the body unchanged, got %d bytes", name, rec.Body.Len())
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package redflag
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/federation"
+)
+
+// cursorTestStream returns a stream of n rows and a flag set when it is closed.
+func cursorTestStream(n int) (federation.ResultStream, *bool) {
+	rows := make([]federation.Row, n)
+	for i := range rows {
+		rows[i] = federation.Row{"id": i}
+	}
+	closed := false
+	return &closeFlagStream{SliceStream: federation.NewSliceStream(rows, &federation.ResultSchema{}), closed: &closed}, &closed
+}
+
+type closeFlagStream struct {
+	*federation.SliceStream
+	closed *bool
+}
+
+func (s *closeFlagStream) Close() error {
+	*s.closed = true
+	return s.SliceStream.Close()
+}
+
+// TestCursor_ExpiresAfterIdleTTL tests that idle cursors are closed.
+// Red-Flag: A cursor idle for longer than its TTL MUST be rejected as expired
+// and its stream MUST be closed.
+func TestCursor_ExpiresAfterIdleTTL(t *testing.T) {
+	stream, closed := cursorTestStream(100)
+	manager := federation.NewCursorManager(20*time.Millisecond, 10)
+	token, err := manager.Open(stream)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	if _, err := manager.Fetch(context.Background(), token, 1); err != nil {
+		t.Fatalf("Fetch page 1 failed: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = manager.Fetch(context.Background(), token, 2)
+	if !errors.Is(err, federation.ErrCursorExpired) {
+		t.Fatalf("expected ErrCursorExpired, got %v", err)
+	}
+	if !*closed {
+		t.Error("expected stream of expired cursor to be closed")
+	}
+}
+
+// TestCursor_SweepExpiresIdleCursor tests the background sweep.
+// Red-Flag: Swept cursors MUST report expiry, not silently vanish.
+func TestCursor_SweepExpiresIdleCursor(t *testing.T) {
+	stream, closed := cursorTestStream(100)
+	manager := federation.NewCursorManager(20*time.Millisecond, 10)
+	token, err := manager.Open(stream)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if n := manager.ExpireIdle(); n != 1 {
+		t.Errorf("expected 1 expired cursor, got %d", n)
+	}
+	if !*closed {
+		t.Error("expected stream of swept cursor to be closed")
+	}
+	if _, err := manager.Fetch(context.Background(), token, 1); !errors.Is(err, federation.ErrCursorExpired) {
+		t.Errorf("expected ErrCursorExpired, got %v", err)
+	}
+}
+
+// blockingStream blocks in Next until released, and records whether it was
+// closed while a Next call was in progress.
+type blockingStream struct {
+	*federation.SliceStream
+	entered       chan struct{}
+	release       chan struct{}
+	inNext        atomic.Bool
+	closedInFetch atomic.Bool
+}
+
+func (s *blockingStream) Next(ctx context.Context) (federation.Row, error) {
+	s.inNext.Store(true)
+	defer s.inNext.Store(false)
+	select {
+	case s.entered <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return s.SliceStream.Next(ctx)
+}
+
+func (s *blockingStream) Close() error {
+	if s.inNext.Load() {
+		s.closedInFetch.Store(true)
+	}
+	return s.SliceStream.Close()
+}
+
+// TestCursor_SweepSkipsCursorBeingFetched tests expiry during a slow fetch.
+// Red-Flag: The idle sweep MUST NOT close the stream of a cursor whose page
+// is being built, and the page MUST refresh the cursor's idle time.
+func TestCursor_SweepSkipsCursorBeingFetched(t *testing.T) {
+	stream := &blockingStream{
+		SliceStream: federation.NewSliceStream([]federation.Row{{"id": 1}, {"id": 2}}, &federation.ResultSchema{}),
+		entered:     make(chan struct{}, 1),
+		release:     make(chan struct{}),
+	}
+	manager := federation.NewCursorManager(20*time.Millisecond, 1)
+	token, err := manager.Open(stream)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.Fetch(context.Background(), token, 1)
+		done <- err
+	}()
+	<-stream.entered
+	time.Sleep(50 * time.Millisecond)
+
+	if n := manager.ExpireIdle(); n != 0 {
+		t.Errorf("expected the busy cursor to be skipped, got %d expired", n)
+	}
+	close(stream.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if stream.closedInFetch.Load() {
+		t.Error("expected the stream not to be closed during Next")
+	}
+	if n := manager.ExpireIdle(); n != 0 {
+		t.Errorf("expected the cursor to be fresh after its page, got %d expired", n)
+	}
+	if _, err := manager.Fetch(context.Background(), token, 2); err != nil {
+		t.Errorf("expected page 2, got %v", err)
+	}
+}
+
+// TestCursor_RejectsOutOfOrderPage tests that pages cannot be skipped.
+// Red-Flag: Requesting a page other than the next or most recent MUST fail.
+func TestCursor_RejectsOutOfOrderPage(t *testing.T) {
+	stream, _ := cursorTestStream(100)
+	manager := federation.NewCursorManager(0, 10)
+	token, err := manager.Open(stream)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	if _, err := manager.Fetch(context.Background(), token, 3); err == nil {
+		t.Error("expected error when skipping pages")
+	}
+}
+
+// TestCursor_UnknownToken tests fetching an unknown cursor.
+// Red-Flag: Unknown cursor tokens MUST return ErrCursorNotFound.
+func TestCursor_UnknownToken(t *testing.T) {
+	manager := federation.NewCursorManager(0, 0)
+	if _, err := manager.Fetch(context.Background(), "does-not-exist", 1); !errors.Is(err, federation.ErrCursorNotFound) {
+		t.Errorf("expected ErrCursorNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package redflag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// TestEngineAffinity_DroppedWhenEngineUnhealthy tests that engine affinity
+// does not outlive the engine's health.
+//
+// Red-Flag: A table's affine engine MUST NOT be selected once it becomes
+// unavailable, and the affinity MUST be dropped rather than resumed.
+func TestEngineAffinity_DroppedWhenEngineUnhealthy(t *testing.T) {
+	r := router.NewRouter()
+	for i, name := range []string{"trino", "duckdb", "spark"} {
+		r.RegisterEngine(&router.Engine{
+			Name:         name,
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+			Available:    true,
+			Priority:     i + 1,
+		})
+	}
+	plan := &planner.ExecutionPlan{
+		ResolvedTables: []*tables.VirtualTable{{
+			Name:    "sales.events",
+			Sources: []tables.PhysicalSource{{Format: tables.FormatDelta, Location: "s3://bucket/events"}},
+		}},
+		RequiredCapabilities: []capabilities.Capability{capabilities.CapabilityRead},
+	}
+	selector := router.NewEngineSelector(r, nil)
+	selector.SetEngineAffinityTTL(time.Minute)
+	ctx := context.Background()
+
+	selector.RecordEngineSuccess("sales.events", "trino")
+	r.SetEngineAvailability("trino", false)
+
+	engine, err := selector.SelectEngine(ctx, plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine == "trino" {
+		t.Fatal("selected the affine engine while it is unavailable")
+	}
+
+	// Trino recovering does not restore the dropped affinity
+	r.SetEngineAvailability("trino", true)
+	engine, err = selector.SelectEngine(ctx, plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine != "spark" {
+		t.Errorf("expected re-evaluated selection spark, got %s", engine)
+	}
+
+	// A failed query drops the affinity too
+	selector.RecordEngineSuccess("sales.events", "trino")
+	selector.RecordEngineFailure("sales.events", "trino")
+	engine, err = selector.SelectEngine(ctx, plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine != "spark" {
+		t.Errorf("expected affinity to be dropped after a failure, got %s", engine)
+	}
+}
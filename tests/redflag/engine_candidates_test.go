@@ -0,0 +1,64 @@
+package redflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// TestCandidateEngines_ExcludesIncapableEngines tests that engines that
+// cannot serve a table are never listed as candidates.
+//
+// Red-Flag: An engine lacking a required capability or the table's format
+// MUST NOT be listed, and an unavailable engine MUST NOT be selected.
+func TestCandidateEngines_ExcludesIncapableEngines(t *testing.T) {
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    false,
+		Priority:     1,
+	})
+	r.RegisterEngine(&router.Engine{
+		Name:         "spark",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     2,
+	})
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     3,
+	})
+	r.RegisterEngine(&router.Engine{
+		Name:         "bigquery",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     4,
+	})
+	selector := router.NewEngineSelector(r, nil)
+	table := &tables.VirtualTable{
+		Name:    "sales.orders",
+		Sources: []tables.PhysicalSource{{Format: tables.FormatIceberg, Location: "s3://lake/orders"}},
+	}
+	required := []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel}
+
+	candidates := selector.CandidateEngines(context.Background(), table, required)
+	if len(candidates) != 2 || candidates[0].Engine != "spark" || candidates[1].Engine != "trino" {
+		t.Fatalf("expected spark then the unavailable trino, got %+v", candidates)
+	}
+	if !candidates[0].Selected || candidates[1].Selected || candidates[1].Available {
+		t.Errorf("expected only spark selected and trino unavailable, got %+v", candidates)
+	}
+
+	// A table assigned to an engine has no other candidates
+	table.Sources[0].Engine = "duckdb"
+	candidates = selector.CandidateEngines(context.Background(), table, required)
+	if len(candidates) != 1 || candidates[0].Engine != "duckdb" || candidates[0].Reason != "assigned to the table" {
+		t.Errorf("expected only the assigned engine, got %+v", candidates)
+	}
+}
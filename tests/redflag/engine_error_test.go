@@ -0,0 +1,84 @@
+package redflag
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// TestEngineError_NonTransientErrorsAreNotRetried verifies syntax,
+// permission, not-found and resource errors are not retried.
+// Red-Flag: Only transient engine errors may be retried.
+func TestEngineError_NonTransientErrorsAreNotRetried(t *testing.T) {
+	messages := []string{
+		`line 1:8: mismatched input 'FORM' (SYNTAX_ERROR)`,
+		`Access Denied: Cannot select from table orders (PERMISSION_DENIED)`,
+		`Table 'hive.sales.orders' does not exist (TABLE_NOT_FOUND)`,
+		`Query exceeded maximum time limit of 5.00m (EXCEEDED_TIME_LIMIT)`,
+	}
+	config := adapters.RetryConfig{MaxAttempts: 3, InitialDelay: time.Millisecond}
+
+	for _, msg := range messages {
+		calls := 0
+		result := adapters.ExecuteWithRetry(context.Background(), config, func() error {
+			calls++
+			return adapters.ClassifyEngineError("trino", stderrors.New(msg))
+		})
+		if result.Success {
+			t.Fatalf("%q: expected failure", msg)
+		}
+		if calls != 1 {
+			t.Errorf("%q: expected 1 call, got %d", msg, calls)
+		}
+	}
+}
+
+// TestEngineError_UnrecognizedErrorIsUnknown verifies unrecognized errors are
+// not guessed into a category.
+// Red-Flag: Unrecognized engine errors must be classified unknown and not retried.
+func TestEngineError_UnrecognizedErrorIsUnknown(t *testing.T) {
+	err := adapters.ClassifyEngineError("spark", stderrors.New("something unexpected happened"))
+
+	var engineErr *errors.ErrEngineQuery
+	if !stderrors.As(err, &engineErr) {
+		t.Fatalf("expected ErrEngineQuery, got %T", err)
+	}
+	if engineErr.Category != errors.EngineErrorUnknown {
+		t.Fatalf("expected unknown category, got %s", engineErr.Category)
+	}
+	if adapters.IsRetryable(err) {
+		t.Fatal("unknown engine errors must not be retryable")
+	}
+}
+
+// TestEngineError_CancellationIsNotClassified verifies context errors pass
+// through unchanged.
+// Red-Flag: Cancellation must not be reported as an engine failure.
+func TestEngineError_CancellationIsNotClassified(t *testing.T) {
+	cause := fmt.Errorf("Trino adapter: context error: %w", context.Canceled)
+	err := adapters.ClassifyEngineError("trino", cause)
+
+	if err != cause {
+		t.Fatalf("expected context error unchanged, got %v", err)
+	}
+	var engineErr *errors.ErrEngineQuery
+	if stderrors.As(err, &engineErr) {
+		t.Fatal("context errors must not become ErrEngineQuery")
+	}
+}
+
+// TestEngineError_PreservesCause verifies the engine's error stays in the chain.
+// Red-Flag: Classification must not hide the engine's original error.
+func TestEngineError_PreservesCause(t *testing.T) {
+	cause := stderrors.New("Table 'hive.sales.orders' does not exist (TABLE_NOT_FOUND)")
+	err := adapters.ClassifyEngineError("trino", cause)
+
+	if !stderrors.Is(err, cause) {
+		t.Fatal("classified error must wrap the engine error")
+	}
+}
@@ -0,0 +1,172 @@
+package redflag
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/adapters/duckdb"
+	"github.com/canonica-labs/canonica/internal/adapters/loader"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// TestEngineLoader_RemovedEngineIsDeregistered verifies that deleting or
+// disabling a stored engine removes its adapter on refresh.
+// Red-Flag: Queries must not be routed to an engine that was removed from the store.
+func TestEngineLoader_RemovedEngineIsDeregistered(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewMockEngineRepository()
+	for _, name := range []string{"duck-a", "duck-b"} {
+		_ = repo.Save(ctx, &storage.EngineDefinition{Name: name, Type: "duckdb", Enabled: true})
+	}
+
+	registry := adapters.NewAdapterRegistry()
+	defer registry.CloseAll()
+	engineLoader := loader.NewLoader(repo, registry, nil)
+	if _, err := engineLoader.Refresh(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	if err := repo.Delete(ctx, "duck-a"); err != nil {
+		t.Fatalf("failed to delete engine: %v", err)
+	}
+	_ = repo.Save(ctx, &storage.EngineDefinition{Name: "duck-b", Type: "duckdb", Enabled: false})
+
+	result, err := engineLoader.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if len(result.Removed) != 2 {
+		t.Errorf("expected 2 removed engines, got %v", result.Removed)
+	}
+	for _, name := range []string{"duck-a", "duck-b"} {
+		if _, ok := registry.Get(name); ok {
+			t.Errorf("%s must be deregistered", name)
+		}
+	}
+}
+
+// TestEngineLoader_KeepsDirectlyRegisteredAdapters verifies the loader only
+// removes adapters it registered.
+// Red-Flag: A refresh must not deregister adapters configured outside the store.
+func TestEngineLoader_KeepsDirectlyRegisteredAdapters(t *testing.T) {
+	ctx := context.Background()
+	registry := adapters.NewAdapterRegistry()
+	defer registry.CloseAll()
+	registry.Register(duckdb.NewAdapter()) // registered from flags, not the store
+
+	repo := storage.NewMockEngineRepository()
+	_ = repo.Save(ctx, &storage.EngineDefinition{Name: "stored", Type: "duckdb", Enabled: true})
+	engineLoader := loader.NewLoader(repo, registry, nil)
+	if _, err := engineLoader.Refresh(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	_ = repo.Delete(ctx, "stored")
+	if _, err := engineLoader.Refresh(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if _, ok := registry.Get("stored"); ok {
+		t.Error("stored engine must be deregistered")
+	}
+	if _, ok := registry.Get("duckdb"); !ok {
+		t.Error("directly registered duckdb adapter must be kept")
+	}
+}
+
+// TestEngineLoader_InvalidDefinitionNotRegistered verifies that engines that
+// cannot be built are reported and not registered.
+// Red-Flag: An unsupported type or unresolvable credential must fail explicitly.
+func TestEngineLoader_InvalidDefinitionNotRegistered(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewMockEngineRepository()
+	_ = repo.Save(ctx, &storage.EngineDefinition{Name: "mystery", Type: "mystery-db", Enabled: true})
+	_ = repo.Save(ctx, &storage.EngineDefinition{
+		Name:          "locked",
+		Type:          "duckdb",
+		CredentialRef: "CANONIC_TEST_UNSET_SECRET_VARIABLE",
+		Enabled:       true,
+	})
+	_ = repo.Save(ctx, &storage.EngineDefinition{Name: "bad-trino", Type: "trino", Endpoint: "not a url", Enabled: true})
+
+	registry := adapters.NewAdapterRegistry()
+	engineLoader := loader.NewLoader(repo, registry, nil)
+	result, err := engineLoader.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	for _, name := range []string{"mystery", "locked", "bad-trino"} {
+		if result.Errors[name] == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+		if _, ok := registry.Get(name); ok {
+			t.Errorf("%s must not be registered", name)
+		}
+	}
+}
+
+// TestAdapterRegistry_TableOnUnregisteredEngineFlagged verifies the startup
+// check of table engines against registered adapters.
+// Red-Flag: A table assigned to an engine without an adapter MUST be reported
+// at startup, not when it is first queried.
+func TestAdapterRegistry_TableOnUnregisteredEngineFlagged(t *testing.T) {
+	registry := adapters.NewAdapterRegistry()
+	defer registry.CloseAll()
+	registry.Register(duckdb.NewAdapter())
+
+	registered := []*tables.VirtualTable{
+		{Name: "sales.orders", Sources: []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/orders"}}},
+		{Name: "sales.returns", Sources: []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/returns"}}},
+		{Name: "sales.events", Sources: []tables.PhysicalSource{{Engine: "duckdb", Format: tables.FormatParquet, Location: "s3://bucket/events"}}},
+	}
+
+	err := registry.CheckTableAdapters(registered)
+	var missingErr *errors.ErrMissingAdapters
+	if !stderrors.As(err, &missingErr) {
+		t.Fatalf("expected ErrMissingAdapters, got %v", err)
+	}
+	if len(missingErr.Missing) != 1 {
+		t.Fatalf("expected only trino to be missing, got %v", missingErr.Missing)
+	}
+	if got := strings.Join(missingErr.Missing["trino"], ","); got != "sales.orders,sales.returns" {
+		t.Errorf("expected both trino tables to be listed, got %s", got)
+	}
+	if !strings.Contains(err.Error(), "trino") {
+		t.Errorf("error should name the missing engine, got: %v", err)
+	}
+}
+
+// TestAdapterRegistry_HealthSnapshotIgnoresQueryErrors verifies that only
+// engine-side failures are recorded as an engine's last error.
+// Red-Flag: A query failing on its own SQL MUST NOT mark the engine degraded,
+// and an engine-side failure MUST be recorded.
+func TestAdapterRegistry_HealthSnapshotIgnoresQueryErrors(t *testing.T) {
+	registry := adapters.NewAdapterRegistry()
+	registry.Register(duckdb.NewAdapter())
+
+	registry.RecordQuery("duckdb", time.Millisecond, stderrors.New("Parser Error: syntax error at or near \"SELEC\""))
+	registry.RecordQuery("duckdb", time.Millisecond, stderrors.New("Catalog Error: Table with name orders does not exist"))
+	registry.RecordQuery("duckdb", time.Millisecond, context.Canceled)
+	if snapshot, _ := registry.HealthSnapshot("duckdb"); snapshot.LastError != "" {
+		t.Errorf("expected query errors not to be recorded, got %q", snapshot.LastError)
+	}
+
+	registry.RecordQuery("duckdb", time.Second, stderrors.New("dial tcp 10.0.0.1:8080: connection refused"))
+	snapshot, _ := registry.HealthSnapshot("duckdb")
+	if !strings.Contains(snapshot.LastError, "connection refused") || snapshot.LastErrorAt.IsZero() {
+		t.Errorf("expected the engine-side failure to be recorded, got %+v", snapshot)
+	}
+
+	if _, err := registry.CheckHealth(context.Background(), "trino"); err == nil {
+		t.Error("expected a health check of an unregistered engine to fail")
+	}
+	if _, ok := registry.HealthSnapshot("trino"); ok {
+		t.Error("expected no snapshot for an unregistered engine")
+	}
+}
@@ -0,0 +1,37 @@
+package redflag
+
+import (
+	stderrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/cli"
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// TestErrorCodes_UnknownCodesAreInternal verifies that errors without a
+// known code are not reported as a more specific failure.
+// Red-Flag: Unknown codes, unset codes and plain errors MUST map to the
+// internal error status and exit code.
+func TestErrorCodes_UnknownCodesAreInternal(t *testing.T) {
+	for _, code := range []errors.ErrorCode{0, 99} {
+		if status := code.HTTPStatus(); status != http.StatusInternalServerError {
+			t.Errorf("code %d: expected status 500, got %d", code, status)
+		}
+		if exit := code.ExitCode(); exit != cli.ExitInternal {
+			t.Errorf("code %d: expected exit code %d, got %d", code, cli.ExitInternal, exit)
+		}
+	}
+
+	for _, err := range []error{
+		&errors.CanonicError{Message: "no code"},
+		stderrors.New("plain failure"),
+	} {
+		if exit := errors.ExitCodeForError(err); exit != cli.ExitInternal {
+			t.Errorf("%v: expected exit code %d, got %d", err, cli.ExitInternal, exit)
+		}
+		if status := errors.HTTPStatus(err); status != http.StatusInternalServerError {
+			t.Errorf("%v: expected status 500, got %d", err, status)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package redflag
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/errors"
+)
+
+// TestErrorSentinels_DoNotCrossCategories verifies that typed errors match
+// only their own category.
+// Red-Flag: A typed error MUST NOT match another category's sentinel, and
+// unclassified engine errors and plain errors MUST match none.
+func TestErrorSentinels_DoNotCrossCategories(t *testing.T) {
+	sentinels := []error{
+		errors.ErrNotFound, errors.ErrForbidden, errors.ErrUnauthenticated,
+		errors.ErrInvalid, errors.ErrConflict, errors.ErrUnavailable,
+		errors.ErrLimitExceeded, errors.ErrTimeout, errors.ErrInternal,
+	}
+	matches := func(err error) []error {
+		var matched []error
+		for _, sentinel := range sentinels {
+			if stderrors.Is(err, sentinel) {
+				matched = append(matched, sentinel)
+			}
+		}
+		return matched
+	}
+
+	if matched := matches(errors.NewTableNotFound("analytics.orders")); len(matched) != 1 || matched[0] != errors.ErrNotFound {
+		t.Errorf("expected ErrTableNotFound to match only ErrNotFound, got %v", matched)
+	}
+	if matched := matches(errors.NewAccessDenied("analytics.orders", "READ", "role analyst lacks READ")); len(matched) != 1 || matched[0] != errors.ErrForbidden {
+		t.Errorf("expected ErrAccessDenied to match only ErrForbidden, got %v", matched)
+	}
+	if matched := matches(errors.NewEngineQueryError("trino", errors.EngineErrorUnknown, stderrors.New("boom"))); len(matched) != 0 {
+		t.Errorf("expected an unclassified engine error to match no sentinel, got %v", matched)
+	}
+	if matched := matches(stderrors.New("not found")); len(matched) != 0 {
+		t.Errorf("expected a plain error to match no sentinel, got %v", matched)
+	}
+}
@@ -7,10 +7,16 @@ package redflag
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
 	"github.com/canonica-labs/canonica/internal/federation"
 	"github.com/canonica-labs/canonica/internal/sql"
 	"github.com/canonica-labs/canonica/internal/storage"
@@ -58,6 +64,41 @@ func TestAnalyzer_UnknownTable(t *testing.T) {
 	}
 }
 
+// TestAnalyzer_KeywordAliasDoesNotDropTable tests alias extraction against
+// keyword collisions.
+// Red-Flag: A table aliased to a keyword MUST still be resolved to its engine,
+// so the query MUST be detected as cross-engine.
+func TestAnalyzer_KeywordAliasDoesNotDropTable(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	analysis, err := analyzer.Analyze(context.Background(),
+		"SELECT * FROM sales.orders AS `order` JOIN sales.customers AS c ON `order`.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if !analysis.IsCrossEngine {
+		t.Fatalf("expected cross-engine query, got engines %v", analysis.TablesByEngine)
+	}
+	orders := analysis.TablesByEngine["trino"]
+	if len(orders) != 1 || orders[0].Alias != "order" {
+		t.Errorf("expected sales.orders with alias order on trino, got %v", orders)
+	}
+	if len(analysis.Warnings) != 1 {
+		t.Errorf("expected a warning for the keyword alias, got %v", analysis.Warnings)
+	}
+}
+
 // TestDecomposer_SingleEngine tests that single-engine queries are rejected.
 // Red-Flag: Decomposer MUST reject non-cross-engine queries.
 func TestDecomposer_SingleEngine(t *testing.T) {
@@ -99,8 +140,8 @@ func TestHashJoin_NilBuildSide(t *testing.T) {
 	config := federation.HashJoinConfig{
 		BuildSide: nil,
 		ProbeSide: &mockResultStream{},
-		BuildKey:  "id",
-		ProbeKey:  "id",
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"id"},
 		Type:      federation.JoinTypeInner,
 	}
 
@@ -117,8 +158,8 @@ func TestHashJoin_NilProbeSide(t *testing.T) {
 	config := federation.HashJoinConfig{
 		BuildSide: &mockResultStream{},
 		ProbeSide: nil,
-		BuildKey:  "id",
-		ProbeKey:  "id",
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"id"},
 		Type:      federation.JoinTypeInner,
 	}
 
@@ -159,8 +200,8 @@ func TestExecuteJoin_InvalidStrategy(t *testing.T) {
 	config := &federation.JoinConfig{
 		BuildSide: &mockResultStream{},
 		ProbeSide: &mockResultStream{},
-		BuildKey:  "id",
-		ProbeKey:  "id",
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"id"},
 		Type:      federation.JoinTypeInner,
 	}
 
@@ -328,3 +369,1672 @@ func (f *failingAdapter) TableStats(ctx context.Context, table string) (*federat
 func (f *failingAdapter) HealthCheck(ctx context.Context) bool {
 	return false
 }
+
+// TestPushdownOptimizer_LikeKeptLocalByDefault tests the conservative default allowlist.
+// Red-Flag: A LIKE predicate MUST NOT be pushed to an engine that does not allow it;
+// it MUST be kept as a post-filter instead of being dropped.
+func TestPushdownOptimizer_LikeKeptLocalByDefault(t *testing.T) {
+	decomposed := &federation.DecomposedQuery{
+		OriginalSQL: "SELECT * FROM t1 WHERE t1.name LIKE 'a%'",
+		SubQueries: []*federation.SubQuery{{
+			ID:     "sq_0_trino",
+			Engine: "trino",
+			SQL:    "SELECT * FROM t1",
+			Tables: []*federation.TableRef{{Name: "t1", Engine: "trino"}},
+		}},
+	}
+	analysis := &federation.QueryAnalysis{
+		OriginalSQL: decomposed.OriginalSQL,
+		PushablePredicates: map[string][]*federation.Predicate{
+			"t1": {{Table: "t1", Column: "name", Operator: "LIKE", Value: "'a%'", Raw: "t1.name LIKE 'a%'"}},
+		},
+	}
+
+	optimizer := federation.NewPushdownOptimizer()
+	optimizer.SetPushdownOperators("duckdb", append(federation.DefaultPushdownOperators, "LIKE"))
+
+	optimized, err := optimizer.Optimize(decomposed, analysis)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sq := optimized.SubQueries[0]
+	if len(sq.Predicates) != 0 {
+		t.Errorf("expected LIKE not to be pushed to trino, got %d pushed predicates", len(sq.Predicates))
+	}
+	if sq.SQL != "SELECT * FROM t1" {
+		t.Errorf("expected sub-query SQL to be unchanged, got: %s", sq.SQL)
+	}
+	if len(sq.PostFilters) != 1 || sq.PostFilters[0].Operator != "LIKE" {
+		t.Errorf("expected LIKE to be kept as a post-filter, got %v", sq.PostFilters)
+	}
+}
+
+// TestPushdownOptimizer_PredicateNotPushedToOtherTable tests predicate routing.
+// Red-Flag: A predicate on one table MUST NOT be pushed into another table's sub-query.
+func TestPushdownOptimizer_PredicateNotPushedToOtherTable(t *testing.T) {
+	decomposed := &federation.DecomposedQuery{
+		SubQueries: []*federation.SubQuery{
+			{ID: "sq_0_duckdb", Engine: "duckdb", SQL: "SELECT * FROM t1",
+				Tables: []*federation.TableRef{{Name: "t1", Engine: "duckdb"}}},
+			{ID: "sq_1_trino", Engine: "trino", SQL: "SELECT * FROM t2",
+				Tables: []*federation.TableRef{{Name: "t2", Engine: "trino"}}},
+		},
+	}
+	analysis := &federation.QueryAnalysis{
+		PushablePredicates: map[string][]*federation.Predicate{
+			"t1": {{Table: "t1", Column: "x", Operator: ">", Value: "10", Raw: "t1.x > 10"}},
+		},
+	}
+
+	optimized, err := federation.NewPushdownOptimizer().Optimize(decomposed, analysis)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if optimized.SubQueries[1].SQL != "SELECT * FROM t2" || len(optimized.SubQueries[1].PostFilters) != 0 {
+		t.Errorf("expected t2 sub-query to be untouched, got SQL=%q post-filters=%v",
+			optimized.SubQueries[1].SQL, optimized.SubQueries[1].PostFilters)
+	}
+	if len(optimized.SubQueries[0].Predicates) != 1 {
+		t.Errorf("expected t1 predicate to be pushed to t1 sub-query")
+	}
+}
+
+// TestFederatedExecutor_RejectsQuerySpanningTooManyEngines tests the engine cap.
+// Red-Flag: A query spanning more engines than the cap MUST be rejected,
+// naming the engines involved.
+func TestFederatedExecutor_RejectsQuerySpanningTooManyEngines(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+		"sales.regions":   "duckdb",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+	executor.SetMaxEngines(2)
+
+	_, err := executor.Plan(context.Background(),
+		"SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id JOIN sales.regions r ON c.region_id = r.id")
+	if err == nil {
+		t.Fatal("expected error for query spanning 3 engines with a cap of 2")
+	}
+
+	var tooMany *errors.ErrTooManyEngines
+	if !stderrors.As(err, &tooMany) {
+		t.Fatalf("expected ErrTooManyEngines, got %T: %v", err, err)
+	}
+	for _, engine := range []string{"duckdb", "spark", "trino"} {
+		if !strings.Contains(err.Error(), engine) {
+			t.Errorf("error should name engine %s: %v", engine, err)
+		}
+	}
+	if !strings.Contains(tooMany.Suggestion, "co-locate") {
+		t.Errorf("error should suggest co-locating data: %s", tooMany.Suggestion)
+	}
+}
+
+// TestPushdownOptimizer_NoPushdownInjectsNoPredicates tests no_pushdown.
+// Red-Flag: With no_pushdown, no predicate may reach the engine; every
+// predicate MUST be kept as a post-filter.
+func TestPushdownOptimizer_NoPushdownInjectsNoPredicates(t *testing.T) {
+	pred := &federation.Predicate{Table: "t1", Column: "x", Operator: ">", Value: "10", Raw: "t1.x > 10"}
+	decomposed := &federation.DecomposedQuery{
+		OriginalSQL: "SELECT * FROM t1 WHERE t1.x > 10",
+		SubQueries: []*federation.SubQuery{{
+			ID:     "sq_0_duckdb",
+			Engine: "duckdb",
+			SQL:    "SELECT * FROM t1",
+			Tables: []*federation.TableRef{{Name: "t1", Engine: "duckdb"}},
+		}},
+	}
+	analysis := &federation.QueryAnalysis{
+		PushablePredicates: map[string][]*federation.Predicate{"t1": {pred}},
+	}
+
+	optimizer := federation.NewPushdownOptimizer()
+	optimized, err := optimizer.OptimizeWithMode(decomposed, analysis, federation.PushdownDisabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sq := optimized.SubQueries[0]
+	if sq.SQL != "SELECT * FROM t1" {
+		t.Errorf("expected sub-query SQL without predicates, got: %s", sq.SQL)
+	}
+	if len(sq.Predicates) != 0 || len(sq.PostFilters) != 1 {
+		t.Errorf("expected predicate as post-filter, got predicates=%d post-filters=%d", len(sq.Predicates), len(sq.PostFilters))
+	}
+}
+
+// TestPushdownMode_RejectsUnknownMode tests pushdown mode parsing.
+// Red-Flag: An unknown pushdown mode MUST be rejected rather than ignored.
+func TestPushdownMode_RejectsUnknownMode(t *testing.T) {
+	for _, value := range []string{"off", "no-pushdown", "aggressive"} {
+		if _, err := federation.ParsePushdownMode(value); err == nil {
+			t.Errorf("expected error for pushdown mode %q", value)
+		}
+	}
+}
+
+// TestFederatedExecutor_SafeModeRefusesCrossEngineQuery tests that safe mode
+// disables federation.
+// Red-Flag: In safe mode, a query spanning two engines MUST be rejected with
+// ErrCrossEngineQuery even though it is under the engine cap.
+func TestFederatedExecutor_SafeModeRefusesCrossEngineQuery(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatIceberg,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+	executor.SetSafeMode(&tables.SafeMode{})
+
+	_, err := executor.Plan(context.Background(),
+		"SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err == nil {
+		t.Fatal("expected cross-engine query to be refused in safe mode")
+	}
+
+	var crossEngine *errors.ErrCrossEngineQuery
+	if !stderrors.As(err, &crossEngine) {
+		t.Fatalf("expected ErrCrossEngineQuery, got %T: %v", err, err)
+	}
+	if !strings.Contains(crossEngine.Suggestion, "safe mode") {
+		t.Errorf("error should explain that safe mode disables federation: %s", crossEngine.Suggestion)
+	}
+}
+
+// TestFederatedExecutor_EstimateUnknownTable tests that estimates are only
+// produced for plannable queries.
+// Red-Flag: Estimating a query on an unregistered table MUST fail instead of
+// returning a default-sized estimate.
+func TestFederatedExecutor_EstimateUnknownTable(t *testing.T) {
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), storage.NewMockRepository())
+
+	estimate, err := executor.Estimate(context.Background(), "SELECT * FROM sales.missing")
+	if err == nil {
+		t.Fatalf("expected error for unknown table, got estimate %+v", estimate)
+	}
+	if !strings.Contains(err.Error(), "sales.missing") {
+		t.Errorf("error should name the table: %v", err)
+	}
+}
+
+// rowsAdapter is an adapter that returns fixed rows for testing.
+type rowsAdapter struct {
+	name    string
+	rows    []federation.Row
+	queries []string
+}
+
+func (r *rowsAdapter) Name() string {
+	return r.name
+}
+
+func (r *rowsAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	r.queries = append(r.queries, query)
+	return federation.NewSliceStream(r.rows, &federation.ResultSchema{}), nil
+}
+
+func (r *rowsAdapter) TableStats(ctx context.Context, table string) (*federation.TableStats, error) {
+	return &federation.TableStats{RowCount: int64(len(r.rows))}, nil
+}
+
+func (r *rowsAdapter) HealthCheck(ctx context.Context) bool {
+	return true
+}
+
+// TestFederatedExecutor_BroadcastFallsBackToHashJoin tests joins that must
+// not filter the other input on the keys of a small one.
+// Red-Flag: An input with more distinct keys than the inline limit, or
+// joined to an input whose unmatched rows are kept, MUST leave the other
+// sub-query unfiltered and return every joined row.
+func TestFederatedExecutor_BroadcastFallsBackToHashJoin(t *testing.T) {
+	testCases := []struct {
+		name    string
+		join    string
+		maxKeys int
+		want    int
+	}{
+		{"too many keys", "JOIN", 1, 2},
+		{"preserved side", "FULL OUTER JOIN", 0, 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := storage.NewMockRepository()
+			for name, engine := range map[string]string{
+				"sales.orders":    "trino",
+				"sales.customers": "spark",
+			} {
+				_ = repo.Create(context.Background(), &tables.VirtualTable{
+					Name:         name,
+					Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+					Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+				})
+			}
+			orders := &rowsAdapter{name: "trino", rows: []federation.Row{
+				{"order_id": 1, "customer_id": 10},
+				{"order_id": 2, "customer_id": 20},
+				{"order_id": 3, "customer_id": 30},
+			}}
+			registry := federation.NewAdapterRegistry()
+			registry.Register(orders)
+			registry.Register(&rowsAdapter{name: "spark", rows: []federation.Row{
+				{"id": 10, "name": "Alice"},
+				{"id": 20, "name": "Bob"},
+			}})
+			executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+			executor.SetMaxBroadcastKeys(tc.maxKeys)
+
+			ctx := context.Background()
+			result, err := executor.Execute(ctx, "SELECT o.order_id, c.name FROM sales.orders o "+tc.join+" sales.customers c ON o.customer_id = c.id")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer result.Close()
+			rows, err := federation.CollectStream(ctx, result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(orders.queries) != 1 || strings.Contains(orders.queries[0], "WHERE") {
+				t.Errorf("expected the orders sub-query to be unfiltered, got %v", orders.queries)
+			}
+			if len(rows) != tc.want {
+				t.Errorf("expected %d joined rows, got %d: %v", tc.want, len(rows), rows)
+			}
+		})
+	}
+}
+
+// TestFederatedExecutor_TinyMemoryBudgetFails tests the per-query memory
+// budget.
+// Red-Flag: A join whose hash table exceeds the request's memory budget MUST
+// fail with ErrMemoryBudgetExceeded naming the operator, instead of growing
+// without bound.
+func TestFederatedExecutor_TinyMemoryBudgetFails(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatIceberg,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&rowsAdapter{name: "trino", rows: []federation.Row{
+		{"customer_id": 10, "total": 100.0},
+		{"customer_id": 20, "total": 200.0},
+	}})
+	registry.Register(&rowsAdapter{name: "spark", rows: []federation.Row{
+		{"id": 10, "name": "Alice"},
+		{"id": 20, "name": "Bob"},
+	}})
+
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	ctx := federation.ContextWithMemoryLimit(context.Background(), 128)
+
+	_, err := executor.Execute(ctx, "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err == nil {
+		t.Fatal("expected query over its memory budget to fail")
+	}
+
+	var overBudget *errors.ErrMemoryBudgetExceeded
+	if !stderrors.As(err, &overBudget) {
+		t.Fatalf("expected ErrMemoryBudgetExceeded, got %T: %v", err, err)
+	}
+	if overBudget.Operator != federation.OperatorHashJoin || overBudget.Limit != 128 {
+		t.Errorf("expected hash join over a 128-byte budget, got %s over %d", overBudget.Operator, overBudget.Limit)
+	}
+}
+
+// TestParseMemorySize_RejectsInvalid tests memory size parsing.
+// Red-Flag: Malformed and non-positive memory sizes MUST be rejected.
+func TestParseMemorySize_RejectsInvalid(t *testing.T) {
+	for _, size := range []string{"lots", "-1MB", "0", "1.5GB", "12XB"} {
+		if n, err := federation.ParseMemorySize(size); err == nil {
+			t.Errorf("expected %q to be rejected, got %d", size, n)
+		}
+	}
+}
+
+// TestAnalyzer_RejectsDuplicateColumnAlias tests the default duplicate
+// column policy.
+// Red-Flag: A query producing two output columns with the same alias MUST be
+// rejected with an error naming the alias, instead of silently dropping one.
+func TestAnalyzer_RejectsDuplicateColumnAlias(t *testing.T) {
+	repo := storage.NewMockRepository()
+	_ = repo.Create(context.Background(), &tables.VirtualTable{
+		Name:         "sales.orders",
+		Sources:      []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/orders"}},
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+	})
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	_, err := analyzer.Analyze(context.Background(), "SELECT o.id AS x, o.total AS x FROM sales.orders o")
+	if err == nil {
+		t.Fatal("expected duplicate output alias to be rejected")
+	}
+
+	var duplicate *errors.ErrDuplicateColumn
+	if !stderrors.As(err, &duplicate) {
+		t.Fatalf("expected ErrDuplicateColumn, got %T: %v", err, err)
+	}
+	if duplicate.Column != "x" {
+		t.Errorf("expected duplicate column x, got %q", duplicate.Column)
+	}
+}
+
+// TestHashJoin_RefusesLossyStringFloatKeys tests that a join between string
+// and floating-point keys is refused rather than compared approximately.
+// Red-Flag: Lossy join key coercion MUST be refused with a clear error.
+func TestHashJoin_RefusesLossyStringFloatKeys(t *testing.T) {
+	for _, policy := range []federation.JoinKeyCoercion{federation.JoinKeyCoercionNumeric, federation.JoinKeyCoercionStringNumber} {
+		build := &mockResultStream{
+			rows:   []federation.Row{{"price": "0.1"}},
+			schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "price", Type: "VARCHAR"}}},
+		}
+		probe := &mockResultStream{
+			rows:   []federation.Row{{"amount": 0.1}},
+			schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "amount", Type: "DOUBLE"}}},
+		}
+
+		_, err := federation.NewHashJoinExecutor(federation.HashJoinConfig{
+			BuildSide:   build,
+			ProbeSide:   probe,
+			BuildKeys:   []string{"price"},
+			ProbeKeys:   []string{"amount"},
+			Type:        federation.JoinTypeInner,
+			KeyCoercion: policy,
+		}).Execute(context.Background())
+
+		var coercionErr *errors.ErrJoinKeyCoercion
+		if !stderrors.As(err, &coercionErr) {
+			t.Fatalf("policy %s: expected ErrJoinKeyCoercion, got %v", policy, err)
+		}
+		if !strings.Contains(err.Error(), "price (VARCHAR)") || !strings.Contains(err.Error(), "amount (DOUBLE)") {
+			t.Errorf("policy %s: error should name both keys and types, got %q", policy, err.Error())
+		}
+	}
+}
+
+// TestHashJoin_RefusesStringIntegerKeysByDefault tests that string keys are
+// not parsed as numbers unless the string_number policy is selected.
+// Red-Flag: String-to-number join key coercion MUST be opt-in.
+func TestHashJoin_RefusesStringIntegerKeysByDefault(t *testing.T) {
+	build := &mockResultStream{
+		rows:   []federation.Row{{"code": "42"}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "code", Type: "VARCHAR"}}},
+	}
+	probe := &mockResultStream{
+		rows:   []federation.Row{{"id": int64(42)}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id", Type: "BIGINT"}}},
+	}
+
+	_, err := federation.NewHashJoinExecutor(federation.HashJoinConfig{
+		BuildSide: build,
+		ProbeSide: probe,
+		BuildKeys: []string{"code"},
+		ProbeKeys: []string{"id"},
+		Type:      federation.JoinTypeInner,
+	}).Execute(context.Background())
+
+	var coercionErr *errors.ErrJoinKeyCoercion
+	if !stderrors.As(err, &coercionErr) {
+		t.Fatalf("expected ErrJoinKeyCoercion, got %v", err)
+	}
+	if !strings.Contains(coercionErr.Suggestion, string(federation.JoinKeyCoercionStringNumber)) {
+		t.Errorf("suggestion should name the string_number policy, got %q", coercionErr.Suggestion)
+	}
+}
+
+// TestHashJoin_RejectsUnpairedCompoundKeys tests a compound join key with
+// more columns on one side than the other.
+// Red-Flag: Key columns that do not pair MUST fail the join rather than
+// match on a prefix of the key.
+func TestHashJoin_RejectsUnpairedCompoundKeys(t *testing.T) {
+	schema := &federation.ResultSchema{Columns: []federation.ColumnDef{
+		{Name: "tenant", Type: "VARCHAR"},
+		{Name: "id", Type: "INTEGER"},
+	}}
+	_, err := federation.NewHashJoinExecutor(federation.HashJoinConfig{
+		BuildSide: &mockResultStream{rows: []federation.Row{{"tenant": "acme", "id": 1}}, schema: schema},
+		ProbeSide: &mockResultStream{rows: []federation.Row{{"tenant": "acme", "id": 1}}, schema: schema},
+		BuildKeys: []string{"tenant", "id"},
+		ProbeKeys: []string{"tenant"},
+		Type:      federation.JoinTypeInner,
+	}).Execute(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "do not pair") {
+		t.Fatalf("expected unpaired key columns to fail, got %v", err)
+	}
+}
+
+// TestParseJoinKeyCoercion_RejectsUnknownPolicy tests that an unknown
+// coercion policy is rejected.
+// Red-Flag: Unknown join key coercion policy MUST fail.
+func TestParseJoinKeyCoercion_RejectsUnknownPolicy(t *testing.T) {
+	if _, err := federation.ParseJoinKeyCoercion("lenient"); err == nil {
+		t.Error("expected error for unknown join key coercion policy")
+	}
+}
+
+// newOrdersCustomersExecutor creates an executor over orders on Trino and
+// customers on the given engine.
+func newOrdersCustomersExecutor(customersEngine string) *federation.FederatedExecutor {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": customersEngine,
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	return federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+}
+
+// TestPushdownOptimizer_KeepsAggregationsAfterJoins tests queries whose
+// aggregates cannot be combined from partial aggregates of one table.
+// Red-Flag: Aggregations MUST run on the joined rows only when they are not
+// all decomposable, read more than one table or unqualified columns, or
+// follow an outer join.
+func TestPushdownOptimizer_KeepsAggregationsAfterJoins(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	queries := map[string]string{
+		"distinct":     "SELECT DISTINCT c.region, SUM(o.total) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region",
+		"two tables":   "SELECT SUM(o.total), MAX(c.id) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+		"unqualified":  "SELECT SUM(total) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+		"group column": "SELECT region, SUM(o.total) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY region",
+		"outer join":   "SELECT c.region, COUNT(*), SUM(o.total) FROM sales.orders o RIGHT JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region",
+	}
+	for name, query := range queries {
+		plan, err := executor.Plan(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for _, sq := range plan.Decomposed.SubQueries {
+			if sq.PartialAggregates || strings.Contains(sq.SQL, "GROUP BY") {
+				t.Errorf("%s: expected no partial aggregation, got: %s", name, sq.SQL)
+			}
+		}
+	}
+}
+
+// TestFederatedExecutor_RejectsUnsupportedAggregates tests cross-engine
+// aggregates the gateway cannot compute on joined rows.
+// Red-Flag: DISTINCT aggregates and aggregates of expressions MUST fail with
+// ErrUnsupportedSyntax instead of returning ungrouped rows.
+func TestFederatedExecutor_RejectsUnsupportedAggregates(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	ctx := federation.ContextWithPushdownMode(context.Background(), federation.PushdownDisabled)
+	queries := map[string]string{
+		"distinct":   "SELECT c.region, COUNT(DISTINCT o.total) AS n FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region",
+		"expression": "SELECT c.region, SUM(o.total * o.qty) AS s FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region",
+		"nested":     "SELECT c.region, SUM(o.total) + 1 AS s FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region",
+		"group expr": "SELECT COUNT(*) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY UPPER(c.region)",
+	}
+	for name, query := range queries {
+		result, err := executor.Execute(ctx, query)
+		if err == nil {
+			result.Close()
+			t.Errorf("%s: expected error", name)
+			continue
+		}
+		var unsupported *errors.ErrUnsupportedSyntax
+		if !stderrors.As(err, &unsupported) {
+			t.Errorf("%s: expected ErrUnsupportedSyntax, got: %v", name, err)
+		}
+	}
+}
+
+// TestPlanFingerprint_DetectsPlanChange tests that plan fingerprints catch
+// plan drift.
+// Red-Flag: A change to the chosen engines or pushdowns MUST change the plan
+// fingerprint and be reported as drift.
+func TestPlanFingerprint_DetectsPlanChange(t *testing.T) {
+	newExecutor := newOrdersCustomersExecutor
+	queries := map[string]string{
+		"join": "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE o.total > 100",
+	}
+
+	golden, err := newExecutor("spark").PlanFingerprints(context.Background(), queries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Customers re-routed to another engine
+	rerouted, err := newExecutor("duckdb").PlanFingerprints(context.Background(), queries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	drift := federation.ComparePlanFingerprints(golden, rerouted)
+	if len(drift) != 1 || drift[0].Name != "join" {
+		t.Fatalf("expected drift for the re-routed query, got %+v", drift)
+	}
+
+	// Pushdown disabled
+	ctx := federation.ContextWithPushdownMode(context.Background(), federation.PushdownDisabled)
+	noPushdown, err := newExecutor("spark").PlanFingerprints(ctx, queries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if noPushdown["join"] == golden["join"] {
+		t.Error("expected disabling pushdown to change the plan fingerprint")
+	}
+
+	// A golden query that is no longer planned is drift too
+	drift = federation.ComparePlanFingerprints(golden, map[string]string{})
+	if len(drift) != 1 || drift[0].Current != "" {
+		t.Errorf("expected drift for the missing query, got %+v", drift)
+	}
+}
+
+// TestAnalyzer_FlagsSameEngineCartesianJoins tests that joins without a
+// join condition are flagged on single-engine queries, which are pushed
+// down as one statement and bypass federation.
+// Red-Flag: A same-engine join without a join condition MUST be warned about
+// by default and rejected with ErrCartesianJoin under the reject policy.
+func TestAnalyzer_FlagsSameEngineCartesianJoins(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for _, name := range []string{"sales.orders", "crm.customers"} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: "trino", Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	queries := []string{
+		"SELECT o.id, c.name FROM sales.orders o JOIN crm.customers c",
+		"SELECT o.id, c.name FROM sales.orders o CROSS JOIN crm.customers c",
+		"SELECT o.id, c.name FROM sales.orders o, crm.customers c WHERE o.total > 10 AND c.active = 1",
+		"SELECT o.id, c.name FROM sales.orders o JOIN crm.customers c ON o.total > 10",
+	}
+
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+	for _, query := range queries {
+		analysis, err := analyzer.Analyze(context.Background(), query)
+		if err != nil {
+			t.Fatalf("expected the default policy to only warn for %q, got %v", query, err)
+		}
+		if analysis.IsCrossEngine {
+			t.Errorf("expected %q to be a single-engine query", query)
+		}
+		if len(analysis.Warnings) != 1 || !strings.Contains(analysis.Warnings[0], "without a join condition") {
+			t.Errorf("expected a cartesian product warning for %q, got %v", query, analysis.Warnings)
+		}
+	}
+
+	analyzer.SetCartesianJoinPolicy(federation.CartesianJoinsReject)
+	for _, query := range queries {
+		_, err := analyzer.Analyze(context.Background(), query)
+		var cartesian *errors.ErrCartesianJoin
+		if !stderrors.As(err, &cartesian) {
+			t.Errorf("expected ErrCartesianJoin for %q, got %T: %v", query, err, err)
+			continue
+		}
+		if cartesian.Join != "o and c are joined without a join condition" {
+			t.Errorf("expected the error to name the joined tables, got %q", cartesian.Join)
+		}
+	}
+
+	if _, err := federation.ParseCartesianJoinPolicy("explode"); err == nil {
+		t.Error("expected an unknown cartesian join policy to be rejected")
+	}
+}
+
+// TestFederatedExecutor_RejectsExpensiveQueryOverLimit tests cost tier
+// admission when every expensive slot is taken.
+// Red-Flag: An expensive query beyond the limit MUST be rejected with
+// ErrConcurrencyLimit once its queue timeout passes, without executing.
+func TestFederatedExecutor_RejectsExpensiveQueryOverLimit(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name: name,
+			Sources: []tables.PhysicalSource{{
+				Engine:   engine,
+				Format:   tables.FormatParquet,
+				Location: "s3://bucket/" + name,
+			}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	limiter, err := federation.NewCostTierLimiter(federation.CostTierLimits{
+		MediumThreshold:    time.Millisecond,
+		ExpensiveThreshold: 50 * time.Millisecond,
+		MaxExpensive:       1,
+		QueueTimeout:       20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewCostTierLimiter failed: %v", err)
+	}
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+	executor.SetCostTierLimiter(limiter)
+
+	// Hold the only expensive slot
+	release, err := limiter.Acquire(context.Background(), federation.CostTierExpensive)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+
+	start := time.Now()
+	_, err = executor.Execute(context.Background(),
+		"SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	var limited *errors.ErrConcurrencyLimit
+	if !stderrors.As(err, &limited) {
+		t.Fatalf("expected ErrConcurrencyLimit, got %T: %v", err, err)
+	}
+	if limited.Tier != "expensive" || limited.Limit != 1 {
+		t.Errorf("expected the expensive tier with limit 1, got %s/%d", limited.Tier, limited.Limit)
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("expected the query to queue for the timeout, rejected after %s", waited)
+	}
+
+	// A canceled caller stops waiting with its context error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limiter.Acquire(ctx, federation.CostTierExpensive); !stderrors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled while queued, got %v", err)
+	}
+}
+
+// TestNewCostTierLimiter_RejectsInvertedThresholds tests limit validation.
+// Red-Flag: An expensive threshold below the medium threshold MUST be rejected.
+func TestNewCostTierLimiter_RejectsInvertedThresholds(t *testing.T) {
+	_, err := federation.NewCostTierLimiter(federation.CostTierLimits{
+		MediumThreshold:    time.Minute,
+		ExpensiveThreshold: time.Second,
+	})
+	if err == nil {
+		t.Fatal("expected an error for an expensive threshold below the medium threshold")
+	}
+}
+
+// TestExplain_NeverReportsUnsupportedPredicatesAsPushed tests that EXPLAIN
+// does not hide predicates the federated executor cannot evaluate.
+// Red-Flag: OR expressions, function calls and comparisons across engines
+// MUST be reported as rejected, and with no_pushdown no predicate MUST be
+// reported as pushed.
+func TestExplain_NeverReportsUnsupportedPredicatesAsPushed(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	query := "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id " +
+		"WHERE o.total > 100 AND (o.status = 'open' OR o.status = 'late') AND LOWER(c.name) = 'bob' AND o.region = c.region"
+
+	plan, err := executor.Plan(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rejected := make(map[string]bool)
+	for _, pred := range plan.Predicates() {
+		if pred.Disposition == federation.PredicateRejected {
+			rejected[pred.Predicate] = true
+		}
+	}
+	for _, want := range []string{"(o.status = 'open' or o.status = 'late')", "LOWER(c.name) = 'bob'", "o.region = c.region"} {
+		if !rejected[want] {
+			t.Errorf("expected %q to be reported as rejected, got %+v", want, plan.Predicates())
+		}
+	}
+	if rejected["o.total > 100"] {
+		t.Error("simple predicate o.total > 100 reported as rejected")
+	}
+
+	ctx := federation.ContextWithPushdownMode(context.Background(), federation.PushdownDisabled)
+	plan, err = executor.Plan(ctx, "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE o.total > 100")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, pred := range plan.Predicates() {
+		if pred.Disposition == federation.PredicatePushed {
+			t.Errorf("predicate %s reported as pushed with no_pushdown", pred.Predicate)
+		}
+	}
+	if preds := plan.Predicates(); len(preds) != 1 || preds[0].Reason != "pushdown disabled" {
+		t.Errorf("expected one post-filter kept because pushdown is disabled, got %+v", preds)
+	}
+}
+
+// TestFederatedExecutor_RejectsVersionPinOnRawFiles tests that version pins
+// on formats without version history are rejected.
+// Red-Flag: FOR VERSION AS OF on a Parquet table MUST fail planning with an
+// error naming the table, rather than reading its current state.
+func TestFederatedExecutor_RejectsVersionPinOnRawFiles(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+
+	_, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name FROM sales.orders FOR VERSION AS OF 42 AS o JOIN sales.customers c ON o.customer_id = c.id")
+	if err == nil {
+		t.Fatal("expected a version pin on a Parquet table to be rejected")
+	}
+	if !strings.Contains(err.Error(), "sales.orders") || !strings.Contains(err.Error(), "VERSION AS OF is not supported for parquet tables") {
+		t.Errorf("expected an error naming the table and format, got: %v", err)
+	}
+}
+
+// TestAggregation_SchemaHidesSourceColumns tests that aggregation does not
+// report the columns it consumed.
+// Red-Flag: The schema of aggregated results MUST NOT list source columns
+// that are neither grouped nor aggregated.
+func TestAggregation_SchemaHidesSourceColumns(t *testing.T) {
+	source := &mockResultStream{
+		rows:   []federation.Row{{"id": int64(1), "amount": 2.5}, {"id": int64(2), "amount": nil}},
+		schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id", Type: "BIGINT"}, {Name: "amount", Type: "DOUBLE"}}},
+	}
+	stream := federation.NewAggregationStream(source, []*federation.Aggregation{
+		{Function: "SUM", Column: "amount", Alias: "total"},
+		{Function: "MAX", Column: "amount", Alias: "largest"},
+	}, nil)
+
+	columns := stream.Schema().Columns
+	if len(columns) != 2 || columns[0] != (federation.ColumnDef{Name: "total", Type: "DOUBLE"}) ||
+		columns[1] != (federation.ColumnDef{Name: "largest", Type: "DOUBLE"}) {
+		t.Errorf("expected only the aggregate columns, got %v", columns)
+	}
+
+	rows, err := federation.CollectStream(context.Background(), stream)
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["total"] != 2.5 || rows[0]["largest"] != 2.5 {
+		t.Errorf("expected NULLs skipped by SUM and MAX, got %v", rows)
+	}
+	if _, ok := rows[0]["id"]; ok {
+		t.Errorf("aggregated row exposes source column id: %v", rows[0])
+	}
+}
+
+// TestFederatedExecutor_OrderByRejectsUnorderableValues tests ORDER BY over
+// values that cannot be compared.
+// Red-Flag: Sorting cross-engine results by a column mixing strings and
+// numbers MUST fail with an error naming the column, not panic, and NULLs
+// MUST sort last by default.
+func TestFederatedExecutor_OrderByRejectsUnorderableValues(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatIceberg, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&rowsAdapter{name: "trino", rows: []federation.Row{
+		{"customer_id": 10, "total": 100.0, "code": "A1"},
+		{"customer_id": 20, "total": nil, "code": 7},
+		{"customer_id": 10, "total": 50, "code": "B2"},
+	}})
+	registry.Register(&rowsAdapter{name: "spark", rows: []federation.Row{
+		{"id": 10, "name": "Alice"},
+		{"id": 20, "name": "Bob"},
+	}})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	ctx := context.Background()
+	const join = "SELECT o.total, o.code, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id "
+
+	result, err := executor.Execute(ctx, join+"ORDER BY o.code")
+	if err == nil {
+		_, err = federation.CollectStream(ctx, result)
+		result.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "ORDER BY o.code") {
+		t.Errorf("expected an ORDER BY error naming o.code, got %v", err)
+	}
+
+	for _, orderBy := range []string{"ORDER BY o.total", "ORDER BY o.total DESC"} {
+		result, err := executor.Execute(ctx, join+orderBy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", orderBy, err)
+		}
+		rows, err := federation.CollectStream(ctx, result)
+		result.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", orderBy, err)
+		}
+		if len(rows) != 3 || rows[2]["total"] != nil {
+			t.Errorf("%s: expected the NULL total last, got %v", orderBy, rows)
+		}
+	}
+}
+
+// TestMergeJoin_RejectsUnsortedInput tests that a merge join verifies the
+// order its inputs declare.
+// Red-Flag: An input declared sorted on its key that is not MUST fail the
+// merge join instead of silently dropping matches.
+func TestMergeJoin_RejectsUnsortedInput(t *testing.T) {
+	left := federation.NewOrderedStream(
+		federation.NewSliceStream([]federation.Row{{"k": 1}, {"k": 3}, {"k": 2}}, &federation.ResultSchema{}),
+		[]string{"k"})
+	right := federation.NewOrderedStream(
+		federation.NewSliceStream([]federation.Row{{"id": 1}, {"id": 2}, {"id": 3}}, &federation.ResultSchema{}),
+		[]string{"id"})
+
+	stream, err := federation.NewMergeJoinExecutor(federation.MergeJoinConfig{
+		Left: left, Right: right, LeftKeys: []string{"k"}, RightKeys: []string{"id"}, Type: federation.JoinTypeInner,
+	}).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = federation.CollectStream(context.Background(), stream)
+	if err == nil || !strings.Contains(err.Error(), "not sorted on k") {
+		t.Errorf("expected an unsorted input error, got %v", err)
+	}
+
+	_, err = federation.NewMergeJoinExecutor(federation.MergeJoinConfig{
+		Left: left, Right: right, LeftKeys: []string{"k"}, RightKeys: []string{"id"}, Type: federation.JoinTypeCross,
+	}).Execute(context.Background())
+	if err == nil {
+		t.Error("expected a CROSS merge join to be rejected")
+	}
+}
+
+// TestMergeJoin_DoesNotBufferSortedInputs tests the memory use of a merge
+// join over sorted inputs.
+// Red-Flag: A merge join of sorted inputs MUST NOT hold either input in
+// memory; a budget far smaller than either side MUST suffice.
+func TestMergeJoin_DoesNotBufferSortedInputs(t *testing.T) {
+	const n = 10000
+	leftRows := make([]federation.Row, n)
+	rightRows := make([]federation.Row, n)
+	for i := range leftRows {
+		leftRows[i] = federation.Row{"customer_id": i}
+		rightRows[i] = federation.Row{"id": i, "name": fmt.Sprintf("customer %d", i)}
+	}
+	budget := federation.NewMemoryBudget(4096)
+
+	stream, err := federation.NewMergeJoinExecutor(federation.MergeJoinConfig{
+		Left:      federation.NewOrderedStream(federation.NewSliceStream(leftRows, &federation.ResultSchema{}), []string{"customer_id"}),
+		Right:     federation.NewOrderedStream(federation.NewSliceStream(rightRows, &federation.ResultSchema{}), []string{"id"}),
+		LeftKeys:  []string{"customer_id"},
+		RightKeys: []string{"id"},
+		Type:      federation.JoinTypeInner,
+		Budget:    budget,
+	}).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err := federation.CollectStream(context.Background(), stream)
+	if err != nil {
+		t.Fatalf("merge join exceeded a small budget: %v", err)
+	}
+	if len(rows) != n {
+		t.Errorf("expected %d joined rows, got %d", n, len(rows))
+	}
+	if budget.Used() != 0 {
+		t.Errorf("expected the budget to be released, %d bytes still reserved", budget.Used())
+	}
+}
+
+// TestHashJoin_SpilledPartitionOverBudget tests the memory budget of a
+// spilled hash join.
+// Red-Flag: A spilled partition that does not fit the memory budget MUST
+// fail with ErrMemoryBudgetExceeded, and closing the join MUST still delete
+// its spill files.
+func TestHashJoin_SpilledPartitionOverBudget(t *testing.T) {
+	// Every build row has the same key, so they all land in one partition
+	buildRows := make([]federation.Row, 10)
+	for i := range buildRows {
+		buildRows[i] = federation.Row{"id": 1, "payload": strings.Repeat("x", 1000)}
+	}
+	spillDir := t.TempDir()
+
+	stream, err := federation.NewHashJoinExecutor(federation.HashJoinConfig{
+		BuildSide:      &mockResultStream{rows: buildRows, schema: &federation.ResultSchema{}},
+		ProbeSide:      &mockResultStream{rows: []federation.Row{{"customer_id": 1}}, schema: &federation.ResultSchema{}},
+		BuildKeys:      []string{"id"},
+		ProbeKeys:      []string{"customer_id"},
+		Type:           federation.JoinTypeInner,
+		AllowSpill:     true,
+		SpillThreshold: 2,
+		SpillDir:       spillDir,
+		Budget:         federation.NewMemoryBudget(4000),
+	}).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("expected the build side to spill within budget, got: %v", err)
+	}
+
+	_, err = federation.CollectStream(context.Background(), stream)
+	var overBudget *errors.ErrMemoryBudgetExceeded
+	if !stderrors.As(err, &overBudget) || overBudget.Operator != federation.OperatorHashJoin {
+		t.Fatalf("expected ErrMemoryBudgetExceeded from the hash join, got %v", err)
+	}
+
+	stream.Close()
+	if entries, _ := os.ReadDir(spillDir); len(entries) != 0 {
+		t.Errorf("expected spill files to be deleted on close, found %d entries", len(entries))
+	}
+}
+
+// TestHashJoin_CollidingColumnsOfUnknownTables tests a join whose inputs
+// share a column name but do not say which table they read.
+// Red-Flag: The right side's column MUST NOT overwrite the left side's; it
+// is kept under a suffixed name instead.
+func TestHashJoin_CollidingColumnsOfUnknownTables(t *testing.T) {
+	stream, err := federation.NewHashJoinExecutor(federation.HashJoinConfig{
+		BuildSide: &mockResultStream{
+			rows:   []federation.Row{{"id": 10, "name": "Alice"}},
+			schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "name"}}},
+		},
+		ProbeSide: &mockResultStream{
+			rows:   []federation.Row{{"id": 1, "customer_id": 10}},
+			schema: &federation.ResultSchema{Columns: []federation.ColumnDef{{Name: "id"}, {Name: "customer_id"}}},
+		},
+		BuildKeys: []string{"id"},
+		ProbeKeys: []string{"customer_id"},
+		Type:      federation.JoinTypeInner,
+	}).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	rows, err := federation.CollectStream(context.Background(), stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != 1 || rows[0]["id_1"] != 10 {
+		t.Errorf("expected probe id 1 and build id_1 10, got %v", rows)
+	}
+}
+
+// TestDecomposer_RejectsCyclicJoinConditions tests join conditions that
+// cannot be applied by a left-deep join plan.
+// Red-Flag: A condition between tables already joined by an earlier step of
+// another join type MUST fail planning rather than re-reading an engine's
+// rows or being dropped.
+func TestDecomposer_RejectsCyclicJoinConditions(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+		"sales.payments":  "duckdb",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	executor := federation.NewFederatedExecutor(federation.NewAdapterRegistry(), sql.NewParser(), repo)
+
+	_, err := executor.Plan(context.Background(),
+		"SELECT * FROM sales.orders o LEFT JOIN sales.customers c ON o.customer_id = c.id "+
+			"JOIN sales.payments p ON p.order_id = o.id AND o.region = c.region")
+	if err == nil || !strings.Contains(err.Error(), "already joined") {
+		t.Fatalf("expected the cyclic condition to be rejected, got %v", err)
+	}
+}
+
+// TestPushdownOptimizer_ReadsWholeTableWhenColumnsUnknown tests the
+// projection of queries whose columns cannot be attributed to tables.
+// Red-Flag: A sub-query MUST read every column of a table selected with a
+// wildcard, or of every table when the query references unqualified
+// columns, rather than drop columns the query needs.
+func TestPushdownOptimizer_ReadsWholeTableWhenColumnsUnknown(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	testCases := []struct {
+		query string
+		want  map[string]string
+	}{
+		{
+			query: "SELECT * FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+			want:  map[string]string{"sq_1_trino": "SELECT o.* FROM", "sq_0_spark": "SELECT c.* FROM"},
+		},
+		{
+			query: "SELECT o.id, c.* FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+			want:  map[string]string{"sq_1_trino": "SELECT o.id, o.customer_id FROM", "sq_0_spark": "SELECT c.* FROM"},
+		},
+		{
+			query: "SELECT o.id, name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+			want:  map[string]string{"sq_1_trino": "SELECT o.* FROM", "sq_0_spark": "SELECT c.* FROM"},
+		},
+	}
+
+	for _, tc := range testCases {
+		plan, err := executor.Plan(context.Background(), tc.query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.query, err)
+		}
+		engineSQL := plan.EngineSQL()
+		for id, want := range tc.want {
+			if !strings.HasPrefix(engineSQL[id], want) {
+				t.Errorf("%s: expected sub-query %s to start with %q, got: %s", tc.query, id, want, engineSQL[id])
+			}
+		}
+	}
+}
+
+// TestExecutionPlan_EngineSQLKeepsEverySubQuery tests the audit SQL of a
+// plan with two sub-queries on one engine.
+// Red-Flag: A sub-query's SQL MUST NOT be replaced by that of another
+// sub-query on the same engine.
+func TestExecutionPlan_EngineSQLKeepsEverySubQuery(t *testing.T) {
+	plan := &federation.ExecutionPlan{SubQueryPlans: []*federation.SubQueryPlan{
+		{Engine: "trino", SubQuery: &federation.SubQuery{ID: "sq_0_trino", Engine: "trino", SQL: "SELECT o.id FROM sales.orders o"}},
+		{Engine: "trino", SubQuery: &federation.SubQuery{ID: "sq_1_trino", Engine: "trino", SQL: "SELECT c.id FROM sales.customers c"}},
+	}}
+
+	engineSQL := plan.EngineSQL()
+	if len(engineSQL) != 2 {
+		t.Fatalf("expected the SQL of both sub-queries, got %v", engineSQL)
+	}
+	for _, sqp := range plan.SubQueryPlans {
+		if engineSQL[sqp.SubQuery.ID] != sqp.SubQuery.SQL {
+			t.Errorf("expected %s to record %q, got %q", sqp.SubQuery.ID, sqp.SubQuery.SQL, engineSQL[sqp.SubQuery.ID])
+		}
+	}
+}
+
+// TestFederatedExecutor_DoesNotPushNegatedInListsOrRanges tests the IN-list
+// and BETWEEN predicates that pushdown does not represent.
+// Red-Flag: NOT IN, NOT BETWEEN and IN sub-query predicates MUST NOT be
+// pushed as the IN lists or ranges they contain.
+func TestFederatedExecutor_DoesNotPushNegatedInListsOrRanges(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	plan, err := executor.Plan(context.Background(),
+		"SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id "+
+			"WHERE o.status NOT IN ('void', 'test') AND o.total NOT BETWEEN 1 AND 5 "+
+			"AND o.customer_id IN (SELECT id FROM sales.customers)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sql := plan.EngineSQL()["sq_1_trino"]; strings.Contains(sql, "WHERE") {
+		t.Errorf("expected no predicate pushed to trino, got: %s", sql)
+	}
+	for _, pred := range plan.Predicates() {
+		if pred.Disposition == federation.PredicatePushed {
+			t.Errorf("expected %s not to be pushed", pred.Predicate)
+		}
+	}
+}
+
+// hangingAdapter is an adapter whose queries run until they are cancelled.
+type hangingAdapter struct {
+	name      string
+	cancelled chan struct{}
+}
+
+func (h *hangingAdapter) Name() string {
+	return h.name
+}
+
+func (h *hangingAdapter) Execute(ctx context.Context, query string) (federation.ResultStream, error) {
+	<-ctx.Done()
+	close(h.cancelled)
+	return nil, ctx.Err()
+}
+
+func (h *hangingAdapter) TableStats(ctx context.Context, table string) (*federation.TableStats, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (h *hangingAdapter) HealthCheck(ctx context.Context) bool {
+	return true
+}
+
+// newHangingExecutor creates an executor over orders on Trino and
+// customers on Spark, with the given adapters.
+func newHangingExecutor(orders, customers federation.EngineAdapter) *federation.FederatedExecutor {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(customers)
+	return federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+}
+
+// TestFederatedExecutor_SubQueryTimeoutNamesEngine tests the per-sub-query
+// timeout.
+// Red-Flag: A sub-query running past the sub-query timeout MUST be cancelled
+// and fail the query with ErrEngineTimeout naming its engine, instead of
+// waiting on the engine indefinitely.
+func TestFederatedExecutor_SubQueryTimeoutNamesEngine(t *testing.T) {
+	customers := &hangingAdapter{name: "spark", cancelled: make(chan struct{})}
+	executor := newHangingExecutor(&rowsAdapter{name: "trino", rows: []federation.Row{
+		{"order_id": 1, "customer_id": 10},
+	}}, customers)
+	executor.SetSubQueryTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	_, err := executor.Execute(context.Background(),
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	var timedOut *errors.ErrEngineTimeout
+	if !stderrors.As(err, &timedOut) {
+		t.Fatalf("expected ErrEngineTimeout, got %T: %v", err, err)
+	}
+	if timedOut.Engine != "spark" || timedOut.Timeout != 20*time.Millisecond {
+		t.Errorf("expected spark to time out after 20ms, got %s after %s", timedOut.Engine, timedOut.Timeout)
+	}
+	if !strings.Contains(err.Error(), "spark") {
+		t.Errorf("error should name the engine: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the query to fail at the timeout, failed after %s", elapsed)
+	}
+}
+
+// TestFederatedExecutor_FailedSubQueryCancelsOthers tests sub-query
+// cancellation.
+// Red-Flag: When one sub-query fails, the others MUST be cancelled and the
+// query MUST fail with the first error, rather than reporting the
+// cancellation of the others.
+func TestFederatedExecutor_FailedSubQueryCancelsOthers(t *testing.T) {
+	orders := &hangingAdapter{name: "trino", cancelled: make(chan struct{})}
+	executor := newHangingExecutor(orders, &failingAdapter{name: "spark"})
+
+	_, err := executor.Execute(context.Background(),
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err == nil || !strings.Contains(err.Error(), "adapter spark unavailable") {
+		t.Fatalf("expected the spark failure, got: %v", err)
+	}
+	select {
+	case <-orders.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the trino sub-query to be cancelled")
+	}
+}
+
+// TestFederatedExecutor_SubQueryFailureNamesEngine tests the error of a
+// failed sub-query.
+// Red-Flag: A sub-query failing on its engine MUST fail the query with
+// ErrSubQueryFailed naming the engine, the tables and the SQL sent, and
+// keeping the engine's error as its cause.
+func TestFederatedExecutor_SubQueryFailureNamesEngine(t *testing.T) {
+	executor := newHangingExecutor(&rowsAdapter{name: "trino", rows: []federation.Row{
+		{"order_id": 1, "customer_id": 10},
+	}}, &failingAdapter{name: "spark"})
+
+	_, err := executor.Execute(context.Background(),
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	var failed *errors.ErrSubQueryFailed
+	if !stderrors.As(err, &failed) {
+		t.Fatalf("expected ErrSubQueryFailed, got %T: %v", err, err)
+	}
+	if failed.Engine != "spark" {
+		t.Errorf("expected the spark sub-query to fail, got %s", failed.Engine)
+	}
+	if len(failed.Tables) != 1 || failed.Tables[0] != "sales.customers" {
+		t.Errorf("expected the failure to name sales.customers, got %v", failed.Tables)
+	}
+	if !strings.Contains(failed.SQL, "sales.customers") {
+		t.Errorf("expected the failure to carry the spark SQL, got %q", failed.SQL)
+	}
+	if failed.Cause == nil || !strings.Contains(failed.Cause.Error(), "adapter spark unavailable") {
+		t.Errorf("expected the engine's error as the cause, got %v", failed.Cause)
+	}
+	if status := failed.HTTPStatus(); status != http.StatusBadGateway {
+		t.Errorf("expected status %d, got %d", http.StatusBadGateway, status)
+	}
+}
+
+// TestAnalyzer_RejectsCrossEngineWindowFunctions tests window functions in
+// federated queries.
+// Red-Flag: Even when the parser allows window functions, a cross-engine
+// query with an OVER clause MUST be rejected with an error naming window
+// functions, since they cannot be evaluated over joined rows.
+func TestAnalyzer_RejectsCrossEngineWindowFunctions(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	parser := sql.NewParser()
+	parser.SetAllowWindowFunctions(true)
+	analyzer := federation.NewAnalyzer(parser, repo)
+
+	_, err := analyzer.Analyze(context.Background(),
+		"SELECT o.id, ROW_NUMBER() OVER (PARTITION BY c.region ORDER BY o.id) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	var unsupported *errors.ErrUnsupportedSyntax
+	if !stderrors.As(err, &unsupported) {
+		t.Fatalf("expected ErrUnsupportedSyntax, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "WINDOW") {
+		t.Errorf("error should name window functions: %v", err)
+	}
+}
+
+// TestAnalyzer_RejectsCrossEngineHaving tests HAVING in federated queries.
+// Red-Flag: A cross-engine query with a HAVING clause MUST be rejected with
+// an error naming HAVING rather than run without filtering its groups.
+func TestAnalyzer_RejectsCrossEngineHaving(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+
+	_, err := analyzer.Analyze(context.Background(),
+		"SELECT c.region, COUNT(*) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY c.region HAVING COUNT(*) > 10")
+	var unsupported *errors.ErrUnsupportedSyntax
+	if !stderrors.As(err, &unsupported) {
+		t.Fatalf("expected ErrUnsupportedSyntax, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "HAVING") {
+		t.Errorf("error should name HAVING: %v", err)
+	}
+}
+
+// TestFederatedExecutor_RejectsNonEquiJoinConditions tests ON comparisons
+// other than equality in cross-engine joins.
+// Red-Flag: A non-equi ON conjunct MUST NOT become part of the hash join
+// key; it MUST be reported as rejected and the query MUST fail with
+// ErrUnsupportedSyntax.
+func TestFederatedExecutor_RejectsNonEquiJoinConditions(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	query := "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id AND c.name <> o.note"
+
+	plan, err := executor.Plan(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if steps := plan.JoinPlan.Steps; len(steps) != 1 || len(steps[0].Keys) != 1 {
+		t.Fatalf("expected one join step keyed on o.customer_id = c.id only, got %+v", steps)
+	}
+	rejected := false
+	for _, pred := range plan.Predicates() {
+		if pred.Disposition == federation.PredicateRejected && strings.Contains(pred.Predicate, "o.note") {
+			rejected = true
+		}
+	}
+	if !rejected {
+		t.Errorf("expected the non-equi ON conjunct to be reported as rejected, got %+v", plan.Predicates())
+	}
+
+	result, err := executor.Execute(context.Background(), query)
+	if err == nil {
+		result.Close()
+		t.Fatal("expected error")
+	}
+	var unsupported *errors.ErrUnsupportedSyntax
+	if !stderrors.As(err, &unsupported) {
+		t.Fatalf("expected ErrUnsupportedSyntax, got %T: %v", err, err)
+	}
+}
+
+// TestFederatedExecutor_RejectsPreservedSideOnFilters tests ON predicates on
+// the table an outer join preserves.
+// Red-Flag: Such a predicate cannot filter its table before the join; it
+// MUST NOT be pushed, it MUST be reported as rejected, and the query MUST
+// fail with ErrUnsupportedSyntax instead of ignoring it.
+func TestFederatedExecutor_RejectsPreservedSideOnFilters(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	query := "SELECT o.id, c.name FROM sales.orders o LEFT JOIN sales.customers c ON o.customer_id = c.id AND o.total > 5"
+
+	plan, err := executor.Plan(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, sq := range plan.Decomposed.SubQueries {
+		if strings.Contains(sq.SQL, "total > 5") {
+			t.Errorf("preserved-side ON filter pushed into %s: %s", sq.ID, sq.SQL)
+		}
+	}
+	rejected := false
+	for _, pred := range plan.Predicates() {
+		if pred.Predicate == "o.total > 5" && pred.Disposition == federation.PredicateRejected {
+			rejected = true
+		}
+	}
+	if !rejected {
+		t.Errorf("expected o.total > 5 to be reported as rejected, got %+v", plan.Predicates())
+	}
+
+	result, err := executor.Execute(context.Background(), query)
+	if err == nil {
+		result.Close()
+		t.Fatal("expected error")
+	}
+	var unsupported *errors.ErrUnsupportedSyntax
+	if !stderrors.As(err, &unsupported) {
+		t.Fatalf("expected ErrUnsupportedSyntax, got %T: %v", err, err)
+	}
+}
+
+// TestPushdownOptimizer_NeverLimitsJoinOrAggregateInputs tests LIMIT
+// pushdown in cross-engine queries.
+// Red-Flag: A LIMIT MUST NOT be pushed into the sub-query of a join input,
+// a partial aggregation, or a sub-query with post-filters, whose rows are
+// joined, grouped, sorted or filtered before the limit applies.
+func TestPushdownOptimizer_NeverLimitsJoinOrAggregateInputs(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	queries := map[string]string{
+		"join":        "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id LIMIT 2",
+		"order by":    "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id ORDER BY o.total DESC LIMIT 2",
+		"aggregate":   "SELECT o.customer_id, SUM(o.total) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id GROUP BY o.customer_id LIMIT 2",
+		"post-filter": "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE c.name LIKE 'A%' LIMIT 2",
+	}
+	for name, query := range queries {
+		plan, err := executor.Plan(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for _, sq := range plan.Decomposed.SubQueries {
+			if strings.Contains(strings.ToUpper(sq.SQL), "LIMIT") {
+				t.Errorf("%s: LIMIT pushed into %s: %s", name, sq.ID, sq.SQL)
+			}
+		}
+	}
+}
+
+// TestPushdownOptimizer_AddsPredicatesAheadOfGroupByAndLimit tests where
+// predicates are added to sub-query SQL.
+// Red-Flag: A predicate MUST be added to the WHERE clause ahead of GROUP BY
+// and LIMIT, never appended after them, and keywords inside literals MUST
+// NOT be mistaken for clauses.
+func TestPushdownOptimizer_AddsPredicatesAheadOfGroupByAndLimit(t *testing.T) {
+	decomposed := &federation.DecomposedQuery{
+		SubQueries: []*federation.SubQuery{{
+			ID:     "sq_0_trino",
+			Engine: "trino",
+			SQL:    "SELECT t1.k, COUNT(*) FROM t1 WHERE t1.note = 'nowhere LIMIT 1' GROUP BY t1.k LIMIT 2",
+			Tables: []*federation.TableRef{{Name: "t1", Engine: "trino"}},
+		}},
+	}
+	analysis := &federation.QueryAnalysis{
+		PushablePredicates: map[string][]*federation.Predicate{
+			"t1": {{Table: "t1", Column: "x", Operator: ">", Value: "10", Raw: "t1.x > 10"}},
+		},
+	}
+
+	optimized, err := federation.NewPushdownOptimizer().Optimize(decomposed, analysis)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "SELECT t1.k, COUNT(*) FROM t1 WHERE t1.note = 'nowhere LIMIT 1' AND t1.x > 10 GROUP BY t1.k LIMIT 2"
+	if got := optimized.SubQueries[0].SQL; got != want {
+		t.Errorf("unexpected sub-query SQL:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+// TestFederatedExecutor_BroadcastsIntoLimitedQueries tests broadcast joins
+// in queries with a LIMIT.
+// Red-Flag: The broadcast IN filter MUST produce valid sub-query SQL, with
+// no LIMIT in the filtered join input, and the LIMIT MUST apply to the
+// joined rows.
+func TestFederatedExecutor_BroadcastsIntoLimitedQueries(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	orders := &rowsAdapter{name: "trino", rows: []federation.Row{
+		{"order_id": 1, "customer_id": 10},
+		{"order_id": 2, "customer_id": 20},
+		{"order_id": 3, "customer_id": 30},
+	}}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(orders)
+	registry.Register(&rowsAdapter{name: "spark", rows: []federation.Row{
+		{"id": 10, "name": "Alice"},
+		{"id": 20, "name": "Bob"},
+	}})
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx, "SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id LIMIT 1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer result.Close()
+	rows, err := federation.CollectStream(ctx, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(orders.queries) != 1 || !strings.HasSuffix(orders.queries[0], " WHERE o.customer_id IN (10, 20)") {
+		t.Errorf("expected the orders sub-query to end with the broadcast filter, got %v", orders.queries)
+	}
+	if len(rows) != 1 {
+		t.Errorf("expected 1 joined row, got %d: %v", len(rows), rows)
+	}
+}
+
+// TestFederatedExecutor_RejectsDistinctExpressions tests SELECT DISTINCT
+// lists the executor cannot compare joined rows on.
+// Red-Flag: SELECT DISTINCT of an expression or a table wildcard across
+// engines MUST fail with ErrUnsupportedSyntax instead of comparing every
+// column of the joined rows.
+func TestFederatedExecutor_RejectsDistinctExpressions(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	queries := map[string]string{
+		"expression": "SELECT DISTINCT UPPER(c.region) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+		"wildcard":   "SELECT DISTINCT c.* FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id",
+	}
+	for name, query := range queries {
+		_, err := executor.Plan(context.Background(), query)
+		var unsupported *errors.ErrUnsupportedSyntax
+		if !stderrors.As(err, &unsupported) {
+			t.Errorf("%s: expected ErrUnsupportedSyntax, got %T: %v", name, err, err)
+		}
+	}
+}
+
+// TestAnalyzer_RejectsOuterJoinFiltersWithinASubQuery tests ON predicates of
+// an outer join between two tables on the same engine.
+// Red-Flag: A filter on the padded table of such a join MUST fail with
+// ErrUnsupportedSyntax rather than be pushed into the sub-query's WHERE
+// clause, where it would drop the preserved rows.
+func TestAnalyzer_RejectsOuterJoinFiltersWithinASubQuery(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.items":     "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	analyzer := federation.NewAnalyzer(sql.NewParser(), repo)
+
+	_, err := analyzer.Analyze(context.Background(), "SELECT o.id, c.name FROM sales.orders o "+
+		"LEFT JOIN sales.items i ON o.id = i.order_id AND i.qty > 1 JOIN sales.customers c ON o.customer_id = c.id")
+	var unsupported *errors.ErrUnsupportedSyntax
+	if !stderrors.As(err, &unsupported) {
+		t.Fatalf("expected ErrUnsupportedSyntax, got %T: %v", err, err)
+	}
+}
+
+// TestFederatedExecutor_RejectsOrderByExpressions tests ORDER BY items the
+// executor cannot sort joined rows on.
+// Red-Flag: ORDER BY of an expression across engines MUST fail with
+// ErrUnsupportedSyntax instead of sorting on a column named after part of
+// it.
+func TestFederatedExecutor_RejectsOrderByExpressions(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	queries := map[string]string{
+		"function": "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id " +
+			"ORDER BY COALESCE(c.name, 'unknown'), o.id",
+		"ordinal": "SELECT o.id, UPPER(c.name) FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id " +
+			"ORDER BY 2 DESC",
+	}
+	for name, query := range queries {
+		_, err := executor.Plan(context.Background(), query)
+		var unsupported *errors.ErrUnsupportedSyntax
+		if !stderrors.As(err, &unsupported) {
+			t.Errorf("%s: expected ErrUnsupportedSyntax, got %T: %v", name, err, err)
+		}
+	}
+}
+
+// TestFederatedExecutor_RejectsUnsupportedWherePredicates tests WHERE
+// predicates the executor cannot evaluate over joined rows.
+// Red-Flag: A cross-engine query with an OR, IS NULL, NOT or cross-table
+// WHERE predicate MUST fail with ErrUnsupportedSyntax instead of returning
+// rows the predicate would have filtered out.
+func TestFederatedExecutor_RejectsUnsupportedWherePredicates(t *testing.T) {
+	executor := newOrdersCustomersExecutor("spark")
+	base := "SELECT o.id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id WHERE "
+	predicates := map[string]string{
+		"or":          "(o.status = 'open' OR o.status = 'late')",
+		"is null":     "c.name IS NULL",
+		"not":         "NOT o.total > 5",
+		"cross-table": "o.region = c.region",
+	}
+	for name, pred := range predicates {
+		result, err := executor.Execute(context.Background(), base+pred)
+		if err == nil {
+			result.Close()
+			t.Errorf("%s: expected error", name)
+			continue
+		}
+		var unsupported *errors.ErrUnsupportedSyntax
+		if !stderrors.As(err, &unsupported) {
+			t.Errorf("%s: expected ErrUnsupportedSyntax, got %T: %v", name, err, err)
+		}
+	}
+}
+
+// largeRowsAdapter is a rowsAdapter whose table statistics report rowCount
+// rows.
+type largeRowsAdapter struct {
+	rowsAdapter
+	rowCount int64
+}
+
+func (l *largeRowsAdapter) TableStats(ctx context.Context, table string) (*federation.TableStats, error) {
+	return &federation.TableStats{RowCount: l.rowCount}, nil
+}
+
+// TestFederatedExecutor_MergesOnlyLargeSortedInputs tests when the inputs
+// of a join are sorted by their engines and merged.
+// Red-Flag: No ORDER BY MUST be pushed when an input is small or pushdown
+// is disabled, and an engine returning rows out of the pushed order MUST
+// fail the join rather than miss matches.
+func TestFederatedExecutor_MergesOnlyLargeSortedInputs(t *testing.T) {
+	newExecutor := func(customerRows int64, orders []federation.Row) (*federation.FederatedExecutor, *largeRowsAdapter) {
+		repo := storage.NewMockRepository()
+		for name, engine := range map[string]string{
+			"sales.orders":    "trino",
+			"sales.customers": "spark",
+		} {
+			_ = repo.Create(context.Background(), &tables.VirtualTable{
+				Name:         name,
+				Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+				Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+			})
+		}
+		ordersAdapter := &largeRowsAdapter{rowsAdapter: rowsAdapter{name: "trino", rows: orders}, rowCount: 1000000}
+		registry := federation.NewAdapterRegistry()
+		registry.Register(ordersAdapter)
+		registry.Register(&largeRowsAdapter{
+			rowsAdapter: rowsAdapter{name: "spark", rows: []federation.Row{{"id": 1}, {"id": 2}}},
+			rowCount:    customerRows,
+		})
+		return federation.NewFederatedExecutor(registry, sql.NewParser(), repo), ordersAdapter
+	}
+	query := "SELECT o.id FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id"
+
+	for name, tc := range map[string]struct {
+		customerRows int64
+		mode         federation.PushdownMode
+	}{
+		"small input":       {customerRows: 5000, mode: federation.PushdownDefault},
+		"pushdown disabled": {customerRows: 1000000, mode: federation.PushdownDisabled},
+	} {
+		executor, _ := newExecutor(tc.customerRows, nil)
+		ctx := federation.ContextWithPushdownMode(context.Background(), tc.mode)
+		plan, err := executor.Plan(ctx, query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if plan.JoinPlan.Steps[0].Strategy == federation.JoinStrategyMerge {
+			t.Errorf("%s: expected no merge join", name)
+		}
+		for id, sql := range plan.EngineSQL() {
+			if strings.Contains(sql, "ORDER BY") {
+				t.Errorf("%s: expected no ORDER BY pushed into %s, got %s", name, id, sql)
+			}
+		}
+	}
+
+	executor, _ := newExecutor(1000000, []federation.Row{
+		{"id": 10, "customer_id": 2},
+		{"id": 11, "customer_id": 1},
+	})
+	result, err := executor.Execute(context.Background(), query)
+	if err == nil {
+		_, err = federation.CollectStream(context.Background(), result)
+		result.Close()
+	}
+	if err == nil {
+		t.Fatal("expected an error for rows out of the pushed order")
+	}
+}
@@ -0,0 +1,28 @@
+package redflag
+
+import (
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// TestLineage_RejectsStarWithoutSchema proves that SELECT * over a table
+// without a known schema is rejected instead of returning partial lineage.
+//
+// Red-Flag: Lineage MUST NOT guess the columns behind a wildcard.
+func TestLineage_RejectsStarWithoutSchema(t *testing.T) {
+	_, err := sql.NewParser().Lineage("SELECT * FROM analytics.orders", nil)
+	if err == nil {
+		t.Fatal("expected wildcard without schema to be rejected")
+	}
+}
+
+// TestLineage_RejectsWrites proves that lineage is only computed for reads.
+//
+// Red-Flag: Write statements MUST be rejected.
+func TestLineage_RejectsWrites(t *testing.T) {
+	_, err := sql.NewParser().Lineage("DELETE FROM analytics.orders WHERE id = 1", nil)
+	if err == nil {
+		t.Fatal("expected DELETE to be rejected")
+	}
+}
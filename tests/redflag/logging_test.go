@@ -13,6 +13,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -129,3 +131,220 @@ func TestLoggingIncludesInvariantViolated(t *testing.T) {
 		t.Error("Invariant violated should be logged for failures")
 	}
 }
+
+// TestLoggingHashingRequiresSalt tests that sensitive column hashing cannot be
+// configured without a salt, since unsalted hashes are easily reversed.
+func TestLoggingHashingRequiresSalt(t *testing.T) {
+	_, err := observability.NewColumnHasher("", map[string][]string{
+		"analytics.customers": {"email"},
+	})
+	if err == nil {
+		t.Fatal("Expected error when hashing is configured without a salt")
+	}
+}
+
+// TestLoggingSensitiveValueNeverInErrorMessage tests that a sensitive literal
+// echoed in an error message is hashed along with the logged SQL.
+func TestLoggingSensitiveValueNeverInErrorMessage(t *testing.T) {
+	hasher, err := observability.NewColumnHasher("test-salt", map[string][]string{
+		"*": {"ssn"},
+	})
+	if err != nil {
+		t.Fatalf("NewColumnHasher failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+	logger.SetColumnHasher(hasher)
+
+	query := "SELECT name FROM hr.employees WHERE ssn = '123-45-6789'"
+	err = logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-ssn",
+		User:          "auditor",
+		ExecutionTime: time.Millisecond,
+		Outcome:       "error",
+		Error:         "query rejected: " + query,
+		SQL:           query,
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("123-45-6789")) {
+		t.Errorf("Sensitive value MUST NOT appear in log output: %s", buf.String())
+	}
+	var output map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if output["error"] == "" {
+		t.Error("Error message MUST still be logged")
+	}
+}
+
+// TestLoggingRedactorLeavesNoLiterals tests that a redactor removes every
+// literal value from a logged entry, including those of unparseable SQL,
+// engine SQL and error messages echoing the query.
+// Red-Flag: Audit entries MUST NOT expose raw data.
+func TestLoggingRedactorLeavesNoLiterals(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+	logger.SetVerboseAudit(true)
+	logger.SetRedactor(observability.NewRedactor())
+
+	query := "SELECT name FROM hr.employees WHERE ssn = '123-45-6789' AND salary > 98765 ORDER BY"
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-redact",
+		User:          "auditor",
+		ExecutionTime: time.Millisecond,
+		Outcome:       "error",
+		Error:         "query rejected: " + query + "; retried with ssn = 'jane.doe@example.com' OR salary >= 98765",
+		SQL:           query,
+		EngineSQL:     map[string]string{"trino": "SELECT name FROM hr.employees WHERE ssn IN ('123-45-6789', '987-65-4321')"},
+	})
+	if err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	for _, literal := range []string{"123-45-6789", "987-65-4321", "98765", "jane.doe@example.com"} {
+		if bytes.Contains(buf.Bytes(), []byte(literal)) {
+			t.Errorf("Literal %q MUST NOT appear in log output: %s", literal, buf.String())
+		}
+	}
+	var output map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if output["error"] == "" || output["sql"] == "" {
+		t.Error("Redacted error and SQL MUST still be logged")
+	}
+}
+
+// TestLoggingFastQueryDoesNotTriggerSlowHook tests that queries within the
+// threshold, and all queries when the threshold is unset, are not reported.
+func TestLoggingFastQueryDoesNotTriggerSlowHook(t *testing.T) {
+	calls := 0
+	hook := func(_ context.Context, _ observability.SlowQueryEvent) { calls++ }
+
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+	logger.SetSlowQueryThreshold(time.Second, hook)
+
+	entry := observability.QueryLogEntry{
+		QueryID:       "q-fast",
+		User:          "alice",
+		ExecutionTime: 200 * time.Millisecond,
+	}
+	if err := logger.LogQuery(context.Background(), entry); err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Hook MUST NOT fire for a query under the threshold, got %d calls", calls)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("slow_query")) {
+		t.Errorf("Fast query MUST NOT produce a slow query record: %s", buf.String())
+	}
+
+	// A zero threshold (the default) disables reporting
+	unset := observability.NewJSONLogger(&bytes.Buffer{})
+	unset.SetSlowQueryThreshold(0, hook)
+	entry.QueryID = "q-unset"
+	entry.ExecutionTime = time.Hour
+	if err := unset.LogQuery(context.Background(), entry); err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Slow query reporting MUST be off by default, got %d calls", calls)
+	}
+}
+
+// TestLoggingRejectsUnknownLogFormat tests that an unknown log format is
+// rejected instead of silently falling back to JSON.
+func TestLoggingRejectsUnknownLogFormat(t *testing.T) {
+	if _, err := observability.ParseLogFormat("xml"); err == nil {
+		t.Error("Unknown log format MUST be rejected")
+	}
+	if _, err := observability.NewQueryLogger(observability.LogFormat("xml"), &bytes.Buffer{}); err == nil {
+		t.Error("Logger creation MUST reject an unknown log format")
+	}
+}
+
+// TestLoggingLogfmtRejectsEmptyUser tests that the logfmt logger applies the
+// same validation as the JSON logger.
+func TestLoggingLogfmtRejectsEmptyUser(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.NewLogfmtLogger(&buf)
+
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:       "q-1",
+		ExecutionTime: 100 * time.Millisecond,
+	})
+	if err == nil {
+		t.Error("Logfmt logging MUST reject entries without user")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Rejected entry MUST NOT be written, got %q", buf.String())
+	}
+}
+
+// TestLoggingOverflowRequiresPath tests that an audit overflow without a
+// file path is rejected instead of silently dropping evicted entries.
+func TestLoggingOverflowRequiresPath(t *testing.T) {
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	if err := logger.SetAuditOverflow(observability.AuditOverflow{IncludeInSummary: true}); err == nil {
+		t.Error("Audit overflow MUST require a file path")
+	}
+}
+
+// TestLoggingCorruptOverflowReported tests that a corrupt overflow file is
+// reported by the audit summary rather than silently under-counted.
+func TestLoggingCorruptOverflowReported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte("{not json\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write overflow file: %v", err)
+	}
+
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	if err := logger.SetAuditOverflow(observability.AuditOverflow{Path: path, IncludeInSummary: true}); err != nil {
+		t.Fatalf("Failed to configure overflow: %v", err)
+	}
+	defer logger.Close()
+
+	if err := logger.LogQuery(context.Background(), observability.QueryLogEntry{QueryID: "q-1", User: "alice"}); err != nil {
+		t.Fatalf("Logging failed: %v", err)
+	}
+
+	summary, err := logger.GetAuditSummaryWithOptions(observability.AuditSummaryOptions{})
+	if err == nil {
+		t.Fatal("Corrupt overflow file MUST be reported")
+	}
+	if summary == nil || summary.AcceptedCount != 1 {
+		t.Errorf("Summary of in-memory entries MUST still be returned, got %+v", summary)
+	}
+}
+
+// TestLoggingEngineSQLRequiresVerboseAudit tests that engine SQL is only
+// logged when verbose audit is enabled.
+// Red-Flag: Engine SQL MUST NOT be logged unless verbose audit is enabled.
+func TestLoggingEngineSQLRequiresVerboseAudit(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.NewJSONLogger(&buf)
+
+	err := logger.LogQuery(context.Background(), observability.QueryLogEntry{
+		QueryID:   "q-1",
+		User:      "alice",
+		Engine:    "trino",
+		EngineSQL: map[string]string{"trino": "SELECT * FROM orders FOR TIMESTAMP AS OF TIMESTAMP '2024-01-01 00:00:00'"},
+	})
+	if err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON log: %v", err)
+	}
+	if _, ok := record["engine_sql"]; ok {
+		t.Errorf("engine_sql must not be logged without verbose audit, got %v", record["engine_sql"])
+	}
+}
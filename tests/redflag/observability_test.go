@@ -6,9 +6,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/canonica-labs/canonica/internal/errors"
 	"github.com/canonica-labs/canonica/internal/observability"
 )
 
@@ -148,3 +153,101 @@ func TestObservability_RequiredFieldsInOutput(t *testing.T) {
 		}
 	}
 }
+
+// TestObservability_AuditSummaryRejectsInvalidOptions verifies that unknown
+// dimensions and out-of-range N are rejected rather than ignored.
+// Red-Flag: Invalid audit summary options must return an error.
+func TestObservability_AuditSummaryRejectsInvalidOptions(t *testing.T) {
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+
+	invalid := []observability.AuditSummaryOptions{
+		{Dimensions: []observability.AuditDimension{"roles"}},
+		{TopN: -1},
+		{TopN: observability.MaxAuditTopN + 1},
+	}
+	for _, opts := range invalid {
+		if _, err := logger.GetAuditSummaryWithOptions(opts); err == nil {
+			t.Errorf("expected error for options %+v", opts)
+		}
+	}
+}
+
+// TestMetrics_CountsErrorsByCode verifies error and rejection metrics.
+// Red-Flag: Failed queries MUST be counted under their error code, with
+// errors carrying no code counted as internal, and rejected queries without
+// an engine MUST still be counted.
+func TestMetrics_CountsErrorsByCode(t *testing.T) {
+	metrics := observability.NewPrometheusMetrics()
+	metrics.ObserveQuery(observability.QueryLogEntry{QueryID: "q1", Outcome: "rejected"},
+		errors.NewQueryRejected("SELECT * FROM t", "SELECT * is not allowed", "list the columns"))
+	metrics.ObserveQuery(observability.QueryLogEntry{QueryID: "q2", Engine: "trino", Outcome: "error"},
+		errors.NewEngineUnavailable([]string{"READ"}))
+	metrics.ObserveQuery(observability.QueryLogEntry{QueryID: "q3", Engine: "spark", Error: "boom"},
+		stderrors.New("boom"))
+
+	var buf bytes.Buffer
+	if err := metrics.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	for _, want := range []string{
+		`canonica_queries_total{outcome="rejected",engine=""} 1`,
+		`canonica_queries_total{outcome="error",engine="trino"} 1`,
+		`canonica_queries_total{outcome="error",engine="spark"} 1`,
+		`canonica_query_errors_total{code="validation"} 1`,
+		`canonica_query_errors_total{code="engine"} 1`,
+		`canonica_query_errors_total{code="internal"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), `outcome="success"`) {
+		t.Errorf("failed queries must not be counted as successes, got:\n%s", buf.String())
+	}
+}
+
+// TestMetrics_RejectsNonGetRequests verifies the /metrics handler methods.
+// Red-Flag: The metrics endpoint MUST only answer GET and HEAD.
+func TestMetrics_RejectsNonGetRequests(t *testing.T) {
+	rec := httptest.NewRecorder()
+	observability.NewPrometheusMetrics().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+// TestObservability_AuditSummaryEmptyWindow verifies windows without
+// entries.
+// Red-Flag: A window outside every entry MUST summarize nothing, and a
+// window ending before it starts MUST be rejected.
+func TestObservability_AuditSummaryEmptyWindow(t *testing.T) {
+	logger := observability.NewJSONLogger(&bytes.Buffer{})
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := logger.LogQuery(ctx, observability.QueryLogEntry{
+		QueryID: "q1", User: "alice", Tables: []string{"sales.orders"}, Timestamp: day,
+	}); err != nil {
+		t.Fatalf("LogQuery failed: %v", err)
+	}
+
+	summary, err := logger.GetAuditSummaryRange(ctx, day.Add(time.Hour), day.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetAuditSummaryRange failed: %v", err)
+	}
+	if summary.AcceptedCount != 0 || summary.RejectedCount != 0 || len(summary.TopQueriedTables) != 0 {
+		t.Errorf("expected an empty summary, got %+v", summary)
+	}
+
+	// The window excludes its end.
+	summary, err = logger.GetAuditSummaryRange(ctx, day.Add(-time.Hour), day)
+	if err != nil {
+		t.Fatalf("GetAuditSummaryRange failed: %v", err)
+	}
+	if summary.AcceptedCount != 0 {
+		t.Errorf("expected an entry logged at the end of the window to be excluded, got %d", summary.AcceptedCount)
+	}
+
+	if _, err := logger.GetAuditSummaryRange(ctx, day, day.Add(-time.Hour)); err == nil {
+		t.Error("expected a window ending before it starts to be rejected")
+	}
+}
@@ -318,3 +318,38 @@ func TestParser_ExtractsCTETables(t *testing.T) {
 	// Note: This is debatable - we may want to include CTE names
 	// But they should at minimum include the underlying tables
 }
+
+// TestParser_FingerprintDistinguishesQueryShapes proves that fingerprints
+// do not merge queries of different shapes.
+//
+// Red-Flag: Queries over other tables or columns, or with other predicates,
+// MUST NOT share a fingerprint, and rejected queries MUST NOT be fingerprinted.
+func TestParser_FingerprintDistinguishesQueryShapes(t *testing.T) {
+	parser := sql.NewParser()
+
+	queries := []string{
+		"SELECT id FROM orders WHERE amount > 10",
+		"SELECT id FROM returns WHERE amount > 10",
+		"SELECT name FROM orders WHERE amount > 10",
+		"SELECT id FROM orders WHERE amount < 10",
+		"SELECT id FROM orders WHERE amount > 10 AND region = 'eu'",
+		"SELECT id FROM orders WHERE amount > 10 ORDER BY id",
+		"SELECT id FROM orders o JOIN customers c ON o.customer_id = c.id",
+	}
+
+	seen := make(map[string]string)
+	for _, query := range queries {
+		fingerprint, err := parser.Fingerprint(query)
+		if err != nil {
+			t.Fatalf("%s: Fingerprint failed: %v", query, err)
+		}
+		if other, ok := seen[fingerprint]; ok {
+			t.Errorf("expected different fingerprints for %q and %q", other, query)
+		}
+		seen[fingerprint] = query
+	}
+
+	if _, err := parser.Fingerprint("DELETE FROM orders WHERE id = 1"); err == nil {
+		t.Error("expected a rejected query to fail fingerprinting")
+	}
+}
@@ -0,0 +1,78 @@
+package redflag
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// partitionedCatalog is a catalog whose tables are all partitioned by
+// event_date into 5000 partitions.
+type partitionedCatalog struct{}
+
+func (partitionedCatalog) Name() string                                        { return "mock" }
+func (partitionedCatalog) ListDatabases(ctx context.Context) ([]string, error) { return nil, nil }
+func (partitionedCatalog) ListTables(ctx context.Context, database string) ([]catalog.TableInfo, error) {
+	return nil, nil
+}
+func (partitionedCatalog) CheckConnectivity(ctx context.Context) error { return nil }
+func (partitionedCatalog) Close() error                                { return nil }
+func (partitionedCatalog) GetTable(ctx context.Context, database, table string) (*catalog.TableMetadata, error) {
+	return &catalog.TableMetadata{
+		Database:       database,
+		Name:           table,
+		Format:         catalog.FormatParquet,
+		Partitions:     []string{"event_date"},
+		PartitionCount: 5000,
+	}, nil
+}
+
+// TestPartitionGuard_RejectsUnfilteredScan proves that queries without a
+// predicate on the partition column are rejected when they would scan more
+// partitions than allowed.
+//
+// Red-Flag: Unfiltered scans of a heavily partitioned table MUST be rejected.
+func TestPartitionGuard_RejectsUnfilteredScan(t *testing.T) {
+	p := newSchemaTestPlanner(schemaLessTable())
+	p.SetPartitionGuard(&planner.PartitionGuard{
+		Resolver:      &planner.CatalogPartitionResolver{Catalog: partitionedCatalog{}},
+		MaxPartitions: 100,
+		Action:        planner.PartitionGuardReject,
+	})
+
+	queries := []string{
+		"SELECT id FROM analytics.events",
+		"SELECT id FROM analytics.events WHERE id > 10",
+		"SELECT event_date, COUNT(*) FROM analytics.events GROUP BY event_date",
+	}
+	for _, query := range queries {
+		logical, err := sql.NewParser().Parse(query)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", query, err)
+		}
+		_, err = p.Plan(context.Background(), logical)
+		var limitErr *errors.ErrPartitionScanLimit
+		if !stderrors.As(err, &limitErr) {
+			t.Errorf("%q: expected ErrPartitionScanLimit, got %v", query, err)
+			continue
+		}
+		if limitErr.Partitions != 5000 || limitErr.Limit != 100 {
+			t.Errorf("%q: expected 5000 partitions over a limit of 100, got %d and %d", query, limitErr.Partitions, limitErr.Limit)
+		}
+	}
+}
+
+// TestParsePartitionGuardAction_RejectsInvalid proves unknown actions are
+// not silently treated as a default.
+//
+// Red-Flag: Invalid partition guard actions MUST be rejected.
+func TestParsePartitionGuardAction_RejectsInvalid(t *testing.T) {
+	if _, err := planner.ParsePartitionGuardAction("ignore"); err == nil {
+		t.Error("expected an invalid action to be rejected")
+	}
+}
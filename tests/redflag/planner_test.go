@@ -2,11 +2,15 @@ package redflag
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
 	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/planner"
 	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
 )
 
 // TestRouter_NoAvailableEngine proves that queries fail when no engine
@@ -144,3 +148,92 @@ func TestPlanner_CrossEngineQueryRejected(t *testing.T) {
 		t.Fatal("expected non-empty error message")
 	}
 }
+
+// TestPlanner_TimeTravelWithoutCapableEngineNamesCapability proves that the
+// engine-selection failure names the capability no engine provides and the
+// engines that were considered.
+//
+// Red-Flag: A time-travel query with only DuckDB available MUST fail naming TIME_TRAVEL and duckdb.
+func TestPlanner_TimeTravelWithoutCapableEngineNamesCapability(t *testing.T) {
+	// Arrange: DuckDB is the only available engine and lacks TIME_TRAVEL
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityAggregate},
+		Available:    true,
+	})
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    false,
+	})
+	vt := &tables.VirtualTable{
+		Name:         "analytics.events",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Sources: []tables.PhysicalSource{{
+			Format:   tables.FormatParquet,
+			Location: "s3://bucket/events",
+		}},
+	}
+	p := planner.NewPlanner(schemaTestRegistry{vt.Name: vt}, r)
+
+	logical, err := sql.NewParser().Parse("SELECT id FROM analytics.events AS OF '2024-01-01 00:00:00'")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+
+	// Act
+	_, err = p.Plan(context.Background(), logical)
+
+	// Assert: Error names the missing capability and the considered engine
+	engErr, ok := err.(*errors.ErrEngineUnavailable)
+	if !ok {
+		t.Fatalf("expected ErrEngineUnavailable, got %T: %v", err, err)
+	}
+	if len(engErr.MissingCapabilities) != 1 || engErr.MissingCapabilities[0] != string(capabilities.CapabilityTimeTravel) {
+		t.Errorf("expected missing capability TIME_TRAVEL, got %v", engErr.MissingCapabilities)
+	}
+	if _, ok := engErr.ConsideredEngines["duckdb"]; !ok || len(engErr.ConsideredEngines) != 1 {
+		t.Errorf("expected only duckdb to be considered, got %v", engErr.ConsideredEngines)
+	}
+	if !strings.Contains(engErr.Error(), "TIME_TRAVEL") || !strings.Contains(engErr.Error(), "duckdb") {
+		t.Errorf("error should name TIME_TRAVEL and duckdb, got: %v", engErr)
+	}
+	if strings.Contains(engErr.Error(), "trino") {
+		t.Errorf("unavailable engines should not be listed as considered, got: %v", engErr)
+	}
+}
+
+// TestRouter_NoSingleEngineProvidesAllCapabilities proves that the error
+// distinguishes a split capability set from a capability nobody provides.
+//
+// Red-Flag: When each capability exists on some engine but none has all, MUST report no missing capability.
+func TestRouter_NoSingleEngineProvidesAllCapabilities(t *testing.T) {
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "a",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+	})
+	r.RegisterEngine(&router.Engine{
+		Name:         "b",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityTimeTravel},
+		Available:    true,
+	})
+
+	_, err := r.SelectEngine(context.Background(), []capabilities.Capability{
+		capabilities.CapabilityRead,
+		capabilities.CapabilityTimeTravel,
+	})
+
+	engErr, ok := err.(*errors.ErrEngineUnavailable)
+	if !ok {
+		t.Fatalf("expected ErrEngineUnavailable, got %T: %v", err, err)
+	}
+	if len(engErr.MissingCapabilities) != 0 {
+		t.Errorf("expected no missing capabilities, got %v", engErr.MissingCapabilities)
+	}
+	if !strings.Contains(engErr.Reason, "no single available engine") {
+		t.Errorf("expected reason to explain the split, got: %s", engErr.Reason)
+	}
+}
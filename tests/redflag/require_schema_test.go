@@ -0,0 +1,110 @@
+package redflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// schemaTestRegistry is a minimal planner.TableRegistry for require-schema tests.
+type schemaTestRegistry map[string]*tables.VirtualTable
+
+func (r schemaTestRegistry) GetTable(_ context.Context, name string) (*tables.VirtualTable, error) {
+	vt, ok := r[name]
+	if !ok {
+		return nil, errors.NewTableNotFound(name)
+	}
+	return vt, nil
+}
+
+// newSchemaTestPlanner builds a planner over a single schema-less table.
+func newSchemaTestPlanner(vt *tables.VirtualTable) *planner.Planner {
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "duckdb",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Available:    true,
+		Priority:     1,
+	})
+	return planner.NewPlanner(schemaTestRegistry{vt.Name: vt}, r)
+}
+
+func schemaLessTable() *tables.VirtualTable {
+	return &tables.VirtualTable{
+		Name:         "analytics.events",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		Sources: []tables.PhysicalSource{{
+			Engine:   "duckdb",
+			Location: "s3://bucket/events",
+			Format:   tables.FormatParquet,
+		}},
+	}
+}
+
+// TestRequireSchema_GlobalPolicyRejectsSchemaLessTable proves that a table
+// without a synced column schema is rejected when the global policy is on.
+//
+// Red-Flag: Queries on schema-less tables MUST fail when schemas are required.
+func TestRequireSchema_GlobalPolicyRejectsSchemaLessTable(t *testing.T) {
+	p := newSchemaTestPlanner(schemaLessTable())
+	p.SetRequireSchema(true)
+
+	plan, err := sql.NewParser().Parse("SELECT id FROM analytics.events")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+
+	_, err = p.Plan(context.Background(), plan)
+	if err == nil {
+		t.Fatal("expected schema-less table to be rejected")
+	}
+	schemaErr, ok := err.(*errors.ErrSchemaRequired)
+	if !ok {
+		t.Fatalf("expected ErrSchemaRequired, got %T: %v", err, err)
+	}
+	if schemaErr.Table != "analytics.events" {
+		t.Errorf("expected table analytics.events, got %q", schemaErr.Table)
+	}
+	if schemaErr.Suggestion == "" {
+		t.Error("error must tell the user how to sync the schema")
+	}
+}
+
+// TestRequireSchema_TablePolicyRejectsSchemaLessTable proves that the
+// per-table policy applies even when the global policy is off.
+//
+// Red-Flag: A table that requires a schema MUST NOT be queried before it is synced.
+func TestRequireSchema_TablePolicyRejectsSchemaLessTable(t *testing.T) {
+	vt := schemaLessTable()
+	vt.RequireSchema = true
+	p := newSchemaTestPlanner(vt)
+
+	plan, err := sql.NewParser().Parse("SELECT id FROM analytics.events")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+
+	_, err = p.Plan(context.Background(), plan)
+	if _, ok := err.(*errors.ErrSchemaRequired); !ok {
+		t.Fatalf("expected ErrSchemaRequired, got %T: %v", err, err)
+	}
+}
+
+// TestRequireSchema_RejectsDuplicateColumns proves that a column schema with
+// duplicate names is rejected at validation.
+//
+// Red-Flag: Ambiguous column schemas MUST be rejected.
+func TestRequireSchema_RejectsDuplicateColumns(t *testing.T) {
+	vt := schemaLessTable()
+	vt.Columns = []tables.Column{{Name: "id", Type: "BIGINT"}, {Name: "ID", Type: "BIGINT"}}
+
+	if err := vt.Validate(); err == nil {
+		t.Fatal("expected duplicate column names to be rejected")
+	}
+}
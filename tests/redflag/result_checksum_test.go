@@ -0,0 +1,66 @@
+package redflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/adapters"
+	"github.com/canonica-labs/canonica/internal/federation"
+)
+
+// TestResultChecksum_DifferingDataDiffers proves that results that differ in
+// any value, row count, or duplicate rows produce different checksums.
+//
+// Red-Flag: Differing results MUST NOT share a checksum.
+func TestResultChecksum_DifferingDataDiffers(t *testing.T) {
+	base := &adapters.QueryResult{
+		Columns: []string{"id", "amount"},
+		Rows:    [][]interface{}{{1, 10}, {2, 20}},
+	}
+	variants := map[string]*adapters.QueryResult{
+		"changed value": {
+			Columns: []string{"id", "amount"},
+			Rows:    [][]interface{}{{1, 10}, {2, 21}},
+		},
+		"missing row": {
+			Columns: []string{"id", "amount"},
+			Rows:    [][]interface{}{{1, 10}},
+		},
+		"duplicated row": {
+			Columns: []string{"id", "amount"},
+			Rows:    [][]interface{}{{1, 10}, {2, 20}, {2, 20}},
+		},
+		"null instead of value": {
+			Columns: []string{"id", "amount"},
+			Rows:    [][]interface{}{{1, 10}, {2, nil}},
+		},
+		"swapped columns": {
+			Columns: []string{"amount", "id"},
+			Rows:    [][]interface{}{{1, 10}, {2, 20}},
+		},
+	}
+
+	want := base.Checksum()
+	for name, variant := range variants {
+		if got := variant.Checksum(); got == want {
+			t.Errorf("%s: expected checksum to differ from base", name)
+		}
+	}
+}
+
+// TestResultChecksum_PartialStreamHasNoChecksum proves that a checksum is not
+// reported for a stream that was not read to the end.
+//
+// Red-Flag: A partially read stream MUST NOT report a checksum.
+func TestResultChecksum_PartialStreamHasNoChecksum(t *testing.T) {
+	cs := federation.NewChecksumStream(federation.NewSliceStream([]federation.Row{
+		{"id": 1}, {"id": 2},
+	}, &federation.ResultSchema{}))
+
+	if _, err := cs.Next(context.Background()); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if sum, ok := cs.Checksum(); ok {
+		t.Errorf("expected no checksum for partial stream, got %s", sum)
+	}
+}
@@ -2,10 +2,12 @@ package redflag
 
 import (
 	"context"
+	stderrors "errors"
 	"strings"
 	"testing"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
 	"github.com/canonica-labs/canonica/internal/gateway"
 	"github.com/canonica-labs/canonica/internal/planner"
 	"github.com/canonica-labs/canonica/internal/router"
@@ -257,6 +259,63 @@ func TestSnapshotConsistent_RejectsSnapshotMismatch(t *testing.T) {
 	}
 }
 
+// planTimeTravelJoin plans a join of orders and customers, two time-travel
+// tables without SNAPSHOT_CONSISTENT, both with the given constraints.
+func planTimeTravelJoin(t *testing.T, query string, constraints ...capabilities.Constraint) error {
+	t.Helper()
+	registry := gateway.NewInMemoryTableRegistry()
+	for _, name := range []string{"orders", "customers"} {
+		registry.Register(&tables.VirtualTable{
+			Name:         name,
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+			Constraints:  constraints,
+			Sources: []tables.PhysicalSource{{
+				Engine:   "iceberg-trino",
+				Location: "catalog.schema." + name,
+				Format:   "iceberg",
+			}},
+		})
+	}
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "iceberg-trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     1,
+	})
+
+	plan, err := sql.NewParser().Parse(query)
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	_, err = planner.NewPlanner(registry, r).Plan(context.Background(), plan)
+	return err
+}
+
+// TestSnapshotConsistent_RejectsMixedTimestamps proves that tables read AS
+// OF different timestamps are rejected even without SNAPSHOT_CONSISTENT.
+//
+// Red-Flag: A query reading two tables AS OF different timestamps MUST be
+// rejected with a SNAPSHOT_CONSISTENT constraint violation naming both
+// tables and both timestamps.
+func TestSnapshotConsistent_RejectsMixedTimestamps(t *testing.T) {
+	err := planTimeTravelJoin(t, "SELECT * FROM orders FOR SYSTEM_TIME AS OF '2024-01-01T00:00:00Z' "+
+		"JOIN customers FOR SYSTEM_TIME AS OF '2024-01-02T00:00:00Z' ON orders.customer_id = customers.id")
+
+	var violation *errors.ErrConstraintViolation
+	if !stderrors.As(err, &violation) {
+		t.Fatalf("expected ErrConstraintViolation, got %T: %v", err, err)
+	}
+	if violation.Constraint != string(capabilities.ConstraintSnapshotConsistent) {
+		t.Errorf("expected constraint SNAPSHOT_CONSISTENT, got %s", violation.Constraint)
+	}
+	for _, want := range []string{"orders", "customers", "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %s, got: %v", want, err)
+		}
+	}
+}
+
 // containsAny checks if the string contains any of the substrings.
 func containsAny(s string, substrs ...string) bool {
 	for _, sub := range substrs {
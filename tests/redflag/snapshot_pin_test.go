@@ -0,0 +1,120 @@
+package redflag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/errors"
+	"github.com/canonica-labs/canonica/internal/planner"
+	"github.com/canonica-labs/canonica/internal/router"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// fakeSnapshotResolver returns fixed latest snapshots per table.
+type fakeSnapshotResolver map[string]time.Time
+
+func (r fakeSnapshotResolver) LatestSnapshot(_ context.Context, vt *tables.VirtualTable) (time.Time, error) {
+	ts, ok := r[vt.Name]
+	if !ok {
+		return time.Time{}, fmt.Errorf("no snapshot for %s", vt.Name)
+	}
+	return ts, nil
+}
+
+// planPinned plans a two-table join over orders and customers in pinning mode.
+func planPinned(t *testing.T, resolver planner.SnapshotResolver, orders, customers *tables.VirtualTable) error {
+	t.Helper()
+	r := router.NewRouter()
+	r.RegisterEngine(&router.Engine{
+		Name:         "trino",
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Available:    true,
+		Priority:     1,
+	})
+	p := planner.NewPlanner(schemaTestRegistry{orders.Name: orders, customers.Name: customers}, r)
+	if resolver != nil {
+		p.SetSnapshotResolver(resolver)
+	}
+
+	logical, err := sql.NewParser().Parse(
+		"SELECT o.id FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	logical.PinSnapshots = true
+
+	_, err = p.Plan(context.Background(), logical)
+	return err
+}
+
+func consistentTable(name string, format tables.StorageFormat) *tables.VirtualTable {
+	return &tables.VirtualTable{
+		Name:         name,
+		Capabilities: []capabilities.Capability{capabilities.CapabilityRead, capabilities.CapabilityTimeTravel},
+		Constraints:  []capabilities.Constraint{capabilities.ConstraintSnapshotConsistent},
+		Sources: []tables.PhysicalSource{{
+			Engine:   "trino",
+			Location: "s3://bucket/" + name,
+			Format:   format,
+		}},
+	}
+}
+
+// TestSnapshotPin_RejectsUnresolvableConsistentTable proves that pinning fails
+// when a SNAPSHOT_CONSISTENT table's snapshot cannot be resolved.
+//
+// Red-Flag: A SNAPSHOT_CONSISTENT table MUST NOT be silently left unpinned.
+func TestSnapshotPin_RejectsUnresolvableConsistentTable(t *testing.T) {
+	orders := consistentTable("sales.orders", tables.FormatDelta)
+	customers := consistentTable("sales.customers", tables.FormatDelta)
+	resolver := fakeSnapshotResolver{
+		"sales.orders": time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		// sales.customers has no resolvable snapshot
+	}
+
+	err := planPinned(t, resolver, orders, customers)
+	if err == nil {
+		t.Fatal("expected pinning to fail for unresolvable snapshot")
+	}
+	violation, ok := err.(*errors.ErrConstraintViolation)
+	if !ok {
+		t.Fatalf("expected ErrConstraintViolation, got %T: %v", err, err)
+	}
+	if violation.Table != "sales.customers" {
+		t.Errorf("expected violation on sales.customers, got %q", violation.Table)
+	}
+}
+
+// TestSnapshotPin_RejectsConsistentTableWithoutSnapshots proves that a
+// SNAPSHOT_CONSISTENT table on a format without snapshots cannot be pinned.
+//
+// Red-Flag: Pinning MUST fail rather than read an unpinned consistent table.
+func TestSnapshotPin_RejectsConsistentTableWithoutSnapshots(t *testing.T) {
+	orders := consistentTable("sales.orders", tables.FormatDelta)
+	customers := consistentTable("sales.customers", tables.FormatParquet)
+	resolver := fakeSnapshotResolver{
+		"sales.orders":    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		"sales.customers": time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	if err := planPinned(t, resolver, orders, customers); err == nil {
+		t.Fatal("expected pinning to fail for a consistent table without snapshots")
+	}
+}
+
+// TestSnapshotPin_RejectsWithoutResolver proves that pinning consistent tables
+// requires a configured snapshot resolver.
+//
+// Red-Flag: Pinning MUST fail when snapshots cannot be looked up.
+func TestSnapshotPin_RejectsWithoutResolver(t *testing.T) {
+	orders := consistentTable("sales.orders", tables.FormatDelta)
+	customers := consistentTable("sales.customers", tables.FormatDelta)
+
+	if err := planPinned(t, nil, orders, customers); err == nil {
+		t.Fatal("expected pinning to fail without a snapshot resolver")
+	}
+}
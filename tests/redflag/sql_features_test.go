@@ -0,0 +1,40 @@
+package redflag
+
+import (
+	"testing"
+
+	"github.com/canonica-labs/canonica/internal/sql"
+)
+
+// TestSQLFeatures_UnsupportedFeaturesExplainLimitation proves that every
+// unsupported feature tells clients why it is rejected.
+//
+// Red-Flag: Unsupported features MUST NOT be reported without a limitation.
+func TestSQLFeatures_UnsupportedFeaturesExplainLimitation(t *testing.T) {
+	for _, f := range sql.NewParser().Features().Features {
+		if !f.Supported && f.Limitation == "" {
+			t.Errorf("unsupported feature %s has no limitation", f.Feature)
+		}
+	}
+}
+
+// TestSQLFeatures_WindowFunctionsReportedUnsupported proves that window
+// functions, which the parser rejects, are reported as unsupported.
+//
+// Red-Flag: The reported surface MUST NOT advertise rejected syntax.
+func TestSQLFeatures_WindowFunctionsReportedUnsupported(t *testing.T) {
+	parser := sql.NewParser()
+	for _, f := range parser.Features().Features {
+		if f.Feature != sql.FeatureWindowFunction {
+			continue
+		}
+		if f.Supported {
+			t.Fatal("window functions must be reported unsupported")
+		}
+		if _, err := parser.Parse(f.Example); err == nil {
+			t.Fatalf("expected window function example to be rejected: %s", f.Example)
+		}
+		return
+	}
+	t.Fatal("window functions missing from reported features")
+}
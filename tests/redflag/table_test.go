@@ -1,6 +1,7 @@
 package redflag
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/canonica-labs/canonica/internal/capabilities"
@@ -166,3 +167,30 @@ func TestTableValidation_InvalidConstraint(t *testing.T) {
 		t.Fatal("expected error for invalid constraint, got nil")
 	}
 }
+
+// TestTableValidation_InvalidFormatOverride proves that format overrides are
+// validated like source formats.
+//
+// Red-Flag: System MUST reject tables whose format override is not a known format.
+func TestTableValidation_InvalidFormatOverride(t *testing.T) {
+	// Arrange: Table with an unknown override format
+	vt := &tables.VirtualTable{
+		Name: "test_table",
+		Sources: []tables.PhysicalSource{
+			{Format: tables.FormatDelta, Location: "s3://bucket/path"},
+		},
+		FormatOverride: tables.StorageFormat("AVRO"),
+		Capabilities:   []capabilities.Capability{capabilities.CapabilityRead},
+	}
+
+	// Act
+	err := vt.Validate()
+
+	// Assert: Validation MUST fail and name the field
+	if err == nil {
+		t.Fatal("expected error for invalid format override, got nil")
+	}
+	if !strings.Contains(err.Error(), "format_override") {
+		t.Errorf("error should name format_override: %v", err)
+	}
+}
@@ -138,3 +138,63 @@ func containsSubstringHelper(s, substr string) bool {
 	}
 	return false
 }
+
+// TestTimeTravelNormalization_RedshiftNativeTableRejected verifies that
+// Redshift rejects time-travel on its native tables even when another table
+// of the query is a Spectrum Iceberg table.
+// Per phase-8-spec.md §6.2: native Redshift tables have no time-travel.
+func TestTimeTravelNormalization_RedshiftNativeTableRejected(t *testing.T) {
+	rewriter := sql.NewWarehouseRewriter("redshift")
+	rewriter.SetTableFormat("lake.orders", catalog.FormatIceberg)
+
+	input := "SELECT * FROM lake.orders FOR SYSTEM_TIME AS OF '2026-01-01T00:00:00Z' o " +
+		"JOIN sales.customers FOR SYSTEM_TIME AS OF '2026-01-01T00:00:00Z' c ON o.customer_id = c.id"
+	_, err := rewriter.Rewrite(input)
+	if err == nil {
+		t.Fatal("Expected error for time-travel on a native Redshift table, got nil")
+	}
+	if !containsSubstring(err.Error(), "sales.customers") {
+		t.Errorf("Error should name the table, got: %v", err)
+	}
+	if !containsSubstring(err.Error(), "Trino") {
+		t.Errorf("Error should suggest querying through Trino, got: %v", err)
+	}
+
+	// Spectrum Iceberg tables are translated for timestamps only
+	if _, err := rewriter.Rewrite("SELECT * FROM lake.orders FOR VERSION AS OF 42"); err == nil {
+		t.Error("Expected error for VERSION AS OF through Redshift Spectrum, got nil")
+	}
+}
+
+// TestTimeTravelNormalization_RedshiftTableNotAlias verifies that a
+// time-travel clause is attributed to its table rather than to an alias.
+// Red-Flag: A native Redshift table MUST be rejected even when its alias is
+// the name of a Spectrum Iceberg table.
+func TestTimeTravelNormalization_RedshiftTableNotAlias(t *testing.T) {
+	rewriter := sql.NewWarehouseRewriter("redshift")
+	rewriter.SetTableFormat("orders", catalog.FormatIceberg)
+	rewriter.SetTableFormat("lake.orders", catalog.FormatIceberg)
+
+	for _, input := range []string{
+		"SELECT * FROM sales.customers orders FOR SYSTEM_TIME AS OF '2026-01-01T00:00:00Z'",
+		"SELECT * FROM sales.customers AS orders FOR SYSTEM_TIME AS OF '2026-01-01T00:00:00Z'",
+	} {
+		_, err := rewriter.Rewrite(input)
+		if err == nil {
+			t.Errorf("%s: expected error for time-travel on a native Redshift table, got nil", input)
+			continue
+		}
+		if !containsSubstring(err.Error(), "sales.customers") {
+			t.Errorf("%s: error should name the table, got: %v", input, err)
+		}
+	}
+
+	// Quoted names are read from the parsed query
+	result, err := rewriter.Rewrite("SELECT * FROM `lake`.`orders` FOR SYSTEM_TIME AS OF '2026-01-01T00:00:00Z' AS o")
+	if err != nil {
+		t.Fatalf("unexpected error for a quoted Spectrum Iceberg table: %v", err)
+	}
+	if !containsSubstring(result, "TIMESTAMP '2026-01-01T00:00:00Z'") {
+		t.Errorf("Expected Spectrum TIMESTAMP syntax, got: %s", result)
+	}
+}
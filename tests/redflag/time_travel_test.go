@@ -1,9 +1,13 @@
 package redflag
 
 import (
+	stderrors "errors"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/canonica-labs/canonica/internal/catalog"
+	"github.com/canonica-labs/canonica/internal/errors"
 	"github.com/canonica-labs/canonica/internal/sql"
 )
 
@@ -250,3 +254,150 @@ func TestTimeTravelWithComplexQuery(t *testing.T) {
 		t.Error("rewritten query should preserve GROUP BY")
 	}
 }
+
+// TestTimeTravel_EngineTooOld proves that time travel on an engine release
+// without a usable syntax fails with a clear error.
+//
+// Red-Flag: System MUST reject time travel on engines too old to support it, naming the required version.
+func TestTimeTravel_EngineTooOld(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  string
+		engine  string
+		version sql.EngineVersion
+		query   string
+		wantErr string
+	}{
+		{
+			name:    "trino_before_385",
+			format:  "iceberg",
+			engine:  "trino",
+			version: sql.EngineVersion{Major: 370},
+			query:   "SELECT * FROM orders FOR VERSION AS OF 42",
+			wantErr: "upgrade to trino 385 or later",
+		},
+		{
+			name:    "hudi_on_spark_before_3_3",
+			format:  "hudi",
+			engine:  "spark",
+			version: sql.EngineVersion{Major: 3, Minor: 2, Patch: 1},
+			query:   "SELECT * FROM trips FOR SYSTEM_TIME AS OF '2024-01-01 00:00:00'",
+			wantErr: "require spark 3.3 or later",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rewriter := sql.NewTimeTravelRewriter(catalog.TableFormat(tc.format), tc.engine)
+			rewriter.SetEngineVersion(tc.version)
+
+			_, err := rewriter.Rewrite(tc.query)
+			if err == nil {
+				t.Fatal("expected error for an engine too old for time travel")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestParseEngineVersion_RejectsGarbage proves that a version string without
+// a version number is rejected rather than treated as version 0.
+//
+// Red-Flag: ParseEngineVersion MUST fail for strings without digits.
+func TestParseEngineVersion_RejectsGarbage(t *testing.T) {
+	if _, err := sql.ParseEngineVersion("unknown"); err == nil {
+		t.Fatal("expected error for a version string without digits")
+	}
+}
+
+// TestTimeTravel_BoundFutureTimestampRejected proves that bound time-travel
+// parameters are validated like literal timestamps.
+//
+// Red-Flag: System MUST reject a bound future or malformed timestamp.
+func TestTimeTravel_BoundFutureTimestampRejected(t *testing.T) {
+	rewriter := sql.NewTimeTravelRewriter("iceberg", "trino")
+	query := "SELECT * FROM orders FOR SYSTEM_TIME AS OF ?"
+
+	testCases := []struct {
+		name    string
+		args    []interface{}
+		message string
+	}{
+		{name: "future_time", args: []interface{}{time.Now().Add(24 * time.Hour)}, message: "future"},
+		{name: "future_string", args: []interface{}{"2099-01-01 00:00:00"}, message: "future"},
+		{name: "malformed_string", args: []interface{}{"yesterday"}, message: "invalid timestamp"},
+		{name: "injection", args: []interface{}{"2024-01-01' OR '1'='1"}, message: "invalid timestamp"},
+		{name: "wrong_type", args: []interface{}{42}, message: "expected a timestamp"},
+		{name: "missing", args: nil, message: "no value bound"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := rewriter.RewriteWithParams(query, tc.args)
+			if err == nil {
+				t.Fatal("expected error for bound timestamp, got nil")
+			}
+			if !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("error should mention %q, got: %v", tc.message, err)
+			}
+		})
+	}
+}
+
+// TestTimeTravel_UnboundParameterRejected proves that an unbound placeholder
+// is not passed to the rewriter as a timestamp.
+//
+// Red-Flag: System MUST reject FOR SYSTEM_TIME AS OF ? without a bound value.
+func TestTimeTravel_UnboundParameterRejected(t *testing.T) {
+	rewriter := sql.NewTimeTravelRewriter("iceberg", "trino")
+
+	_, err := rewriter.Rewrite("SELECT * FROM orders FOR SYSTEM_TIME AS OF ?")
+	if err == nil {
+		t.Fatal("expected error for unbound time-travel parameter, got nil")
+	}
+	if !strings.Contains(err.Error(), "no bound value") {
+		t.Errorf("error should explain the parameter is unbound, got: %v", err)
+	}
+}
+
+// TestTimeTravel_MalformedTimestampRejectedAtParse proves that a malformed
+// AS OF timestamp never reaches an engine.
+//
+// Red-Flag: Parsing MUST reject an AS OF literal that is not a timestamp
+// with ErrQueryRejected naming the table and the timestamp.
+func TestTimeTravel_MalformedTimestampRejectedAtParse(t *testing.T) {
+	parser := sql.NewParser()
+
+	for _, query := range []string{
+		"SELECT * FROM orders FOR SYSTEM_TIME AS OF '2024-13-45'",
+		"SELECT o.id FROM orders AS OF '2024-01-01' o JOIN customers AS OF 'yesterday' c ON o.customer_id = c.id",
+	} {
+		_, err := parser.Parse(query)
+		var rejected *errors.ErrQueryRejected
+		if !stderrors.As(err, &rejected) {
+			t.Fatalf("%s: expected ErrQueryRejected, got %T: %v", query, err, err)
+		}
+		if !strings.Contains(err.Error(), "invalid AS OF timestamp") {
+			t.Errorf("%s: error should name the invalid timestamp, got: %v", query, err)
+		}
+	}
+}
+
+// TestTimeTravel_FutureRelativeTimestampRejected proves that a relative
+// timestamp resolving to the future is rejected like an absolute one.
+//
+// Red-Flag: NOW() + INTERVAL MUST fail the future-timestamp check.
+func TestTimeTravel_FutureRelativeTimestampRejected(t *testing.T) {
+	rewriter := sql.NewTimeTravelRewriter(catalog.FormatIceberg, "trino")
+	rewriter.SetClock(func() time.Time { return time.Date(2026, 3, 15, 12, 30, 0, 0, time.UTC) })
+
+	_, err := rewriter.Rewrite("SELECT id FROM orders FOR SYSTEM_TIME AS OF NOW() + INTERVAL '1' HOUR")
+	if err == nil {
+		t.Fatal("expected error for future relative timestamp, got nil")
+	}
+	if !strings.Contains(strings.ToLower(err.Error()), "future") {
+		t.Errorf("error should mention 'future', got: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package redflag
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/canonica-labs/canonica/internal/capabilities"
+	"github.com/canonica-labs/canonica/internal/federation"
+	"github.com/canonica-labs/canonica/internal/observability"
+	"github.com/canonica-labs/canonica/internal/sql"
+	"github.com/canonica-labs/canonica/internal/storage"
+	"github.com/canonica-labs/canonica/internal/tables"
+)
+
+// statusTracer records the status and errors of the spans started through
+// it.
+type statusTracer struct {
+	embedded.Tracer
+	mu    sync.Mutex
+	spans []*statusSpan
+}
+
+// statusSpan is a span recorded by statusTracer.
+type statusSpan struct {
+	noop.Span
+	name   string
+	status codes.Code
+	errors []error
+	ended  bool
+}
+
+func (t *statusTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &statusSpan{name: name}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *statusSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *statusSpan) RecordError(err error, options ...trace.EventOption) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *statusSpan) End(options ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+// TestFederatedExecutor_TracesFailedSubQueries tests the spans of a failed
+// federated query.
+// Red-Flag: A failing sub-query MUST end its span marked as an error with
+// the error recorded, and so MUST the execute span of its query, so failed
+// queries are not traced as successes.
+func TestFederatedExecutor_TracesFailedSubQueries(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for name, engine := range map[string]string{
+		"sales.orders":    "trino",
+		"sales.customers": "spark",
+	} {
+		_ = repo.Create(context.Background(), &tables.VirtualTable{
+			Name:         name,
+			Sources:      []tables.PhysicalSource{{Engine: engine, Format: tables.FormatParquet, Location: "s3://bucket/" + name}},
+			Capabilities: []capabilities.Capability{capabilities.CapabilityRead},
+		})
+	}
+	registry := federation.NewAdapterRegistry()
+	registry.Register(&failingAdapter{name: "trino"})
+	registry.Register(&failingAdapter{name: "spark"})
+	tracer := &statusTracer{}
+	executor := federation.NewFederatedExecutor(registry, sql.NewParser(), repo)
+	executor.SetTracer(tracer)
+
+	_, err := executor.Execute(context.Background(),
+		"SELECT o.order_id, c.name FROM sales.orders o JOIN sales.customers c ON o.customer_id = c.id")
+	if err == nil {
+		t.Fatal("expected the query to fail")
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	failed := map[string]int{}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span %s was not ended", span.name)
+		}
+		if span.status == codes.Error && len(span.errors) == 1 {
+			failed[span.name]++
+		}
+	}
+	if failed[observability.SpanExecute] != 1 {
+		t.Errorf("expected the execute span to record the failure, got %v", failed)
+	}
+	if failed[observability.SpanSubQuery] == 0 {
+		t.Errorf("expected a sub-query span to record the failure, got %v", failed)
+	}
+	if failed[observability.SpanPlan] != 0 {
+		t.Errorf("planning succeeded and must not be marked failed, got %v", failed)
+	}
+}
@@ -4,11 +4,13 @@ package redflag
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/canonica-labs/canonica/internal/adapters"
 	"github.com/canonica-labs/canonica/internal/adapters/trino"
+	"github.com/canonica-labs/canonica/internal/auth"
 	"github.com/canonica-labs/canonica/internal/planner"
 	"github.com/canonica-labs/canonica/internal/sql"
 )
@@ -296,3 +298,41 @@ func TestTrino_CheckHealthExplicitTimeout(t *testing.T) {
 		t.Fatalf("CheckHealth took too long: %v (expected < 2s)", elapsed)
 	}
 }
+
+// TestTrino_RejectsUnresolvableSessionProperties verifies queries fail when
+// their session properties cannot be applied.
+// Red-Flag: Queries MUST NOT run outside their resource group because a
+// template is invalid or resolves to an unsafe value.
+func TestTrino_RejectsUnresolvableSessionProperties(t *testing.T) {
+	plan := &planner.ExecutionPlan{
+		LogicalPlan: &sql.LogicalPlan{RawSQL: "SELECT 1"},
+		Engine:      "trino",
+	}
+
+	invalid := trino.NewAdapter(trino.AdapterConfig{
+		Host:              "localhost",
+		Port:              8080,
+		SessionProperties: map[string]string{"resource_group": "{{.Role"},
+	})
+	defer invalid.Close()
+	_, err := invalid.Execute(context.Background(), plan)
+	if err == nil || !strings.Contains(err.Error(), "resource_group") {
+		t.Errorf("expected invalid template error, got %v", err)
+	}
+
+	injected := trino.NewAdapter(trino.AdapterConfig{
+		Host:              "localhost",
+		Port:              8080,
+		SessionProperties: map[string]string{"resource_group": "{{.Role}}"},
+	})
+	defer injected.Close()
+	ctx := auth.ContextWithUser(context.Background(), &auth.User{ID: "mallory", Roles: []string{"etl;admin"}})
+	_, err = injected.Execute(ctx, plan)
+	if err == nil || !strings.Contains(err.Error(), "forbidden characters") {
+		t.Errorf("expected forbidden value error, got %v", err)
+	}
+
+	if _, err := adapters.NewSessionProperties(map[string]string{"bad name": "x"}); err == nil {
+		t.Error("expected invalid property name to be rejected")
+	}
+}
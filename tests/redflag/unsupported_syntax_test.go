@@ -9,9 +9,11 @@
 package redflag
 
 import (
+	stderrors "errors"
 	"strings"
 	"testing"
 
+	"github.com/canonica-labs/canonica/internal/errors"
 	"github.com/canonica-labs/canonica/internal/sql"
 )
 
@@ -289,28 +291,80 @@ func TestErrorMessageContainsSuggestion(t *testing.T) {
 	}
 }
 
-// TestRejectsSubqueriesInSelect tests that certain complex subquery patterns fail clearly.
-// (Note: Basic subqueries may be allowed, but complex correlated subqueries should fail explicitly)
+// TestRejectsCorrelatedSubqueries tests that subqueries referencing a column
+// of the outer query are rejected with an explicit error.
+// Red-Flag: A correlated subquery cannot be federated as an independent
+// query; it MUST fail as CORRELATED SUBQUERY and suggest a JOIN.
 func TestRejectsCorrelatedSubqueries(t *testing.T) {
 	parser := sql.NewParser()
 
-	// Correlated subqueries referencing outer query
-	query := `SELECT * FROM test.orders o 
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{
+			name: "correlated scalar subquery",
+			query: `SELECT * FROM test.orders o 
 		WHERE o.amount > (
 			SELECT AVG(amount) FROM test.orders o2 
 			WHERE o2.customer_id = o.customer_id
-		)`
+		)`,
+		},
+		{
+			name: "correlated EXISTS",
+			query: `SELECT c.id FROM test.customers c
+		WHERE EXISTS (SELECT 1 FROM test.orders o WHERE o.customer_id = c.id)`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parser.Parse(tc.query)
+			if err == nil {
+				t.Fatalf("Correlated subquery should be rejected: %s", tc.query)
+			}
 
-	// This test documents expected behavior - correlated subqueries
-	// may or may not be supported. If rejected, error must be explicit.
-	_, err := parser.Parse(query)
+			var unsupported *errors.ErrUnsupportedSyntax
+			if !stderrors.As(err, &unsupported) {
+				t.Fatalf("expected ErrUnsupportedSyntax, got %T: %v", err, err)
+			}
+			if unsupported.Construct != "CORRELATED SUBQUERY" {
+				t.Errorf("Error must identify CORRELATED SUBQUERY, got %q", unsupported.Construct)
+			}
+			if !strings.Contains(err.Error(), "JOIN") {
+				t.Errorf("Error must suggest a JOIN rewrite:\nGot: %s", err.Error())
+			}
+		})
+	}
+}
+
+// TestValidateScript_DoesNotRelaxSingleStatementParse verifies that script
+// validation leaves single-statement execution strict.
+// Red-Flag: Parse must still reject a script that ValidateScript accepts statement by statement.
+func TestValidateScript_DoesNotRelaxSingleStatementParse(t *testing.T) {
+	parser := sql.NewParser()
+	script := "SELECT * FROM users; SELECT * FROM orders"
+
+	verdicts, err := parser.ValidateScript(script)
 	if err != nil {
-		// If rejected, error must be explicit about the reason
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "syntax error") && !strings.Contains(strings.ToLower(errMsg), "subquer") {
-			// Only fail if it's a generic syntax error without mentioning subqueries
-			t.Logf("Note: Query rejected with message: %s", errMsg)
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, v := range verdicts {
+		if !v.Valid {
+			t.Fatalf("expected statement %d to be valid: %v", v.Index, v.Err)
 		}
 	}
-	// If accepted, that's also fine - this documents behavior
+
+	if _, err := parser.Parse(script); err == nil {
+		t.Fatal("Parse must reject multiple statements even when each is valid")
+	}
+}
+
+// TestValidateScript_RejectsEmptyScript verifies that a script with no
+// statements is rejected.
+// Red-Flag: An empty script must not validate as an empty list of verdicts.
+func TestValidateScript_RejectsEmptyScript(t *testing.T) {
+	if _, err := sql.NewParser().ValidateScript(" ; ; "); err == nil {
+		t.Fatal("expected error for a script without statements")
+	}
 }